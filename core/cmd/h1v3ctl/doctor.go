@@ -0,0 +1,238 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/h1v3-io/h1v3/internal/agent"
+	"github.com/h1v3-io/h1v3/internal/config"
+	"github.com/h1v3-io/h1v3/internal/connector/discord"
+	slackconn "github.com/h1v3-io/h1v3/internal/connector/slack"
+	"github.com/h1v3-io/h1v3/internal/connector/telegram"
+	"github.com/h1v3-io/h1v3/internal/logbuf"
+	"github.com/h1v3-io/h1v3/internal/provider"
+	"github.com/h1v3-io/h1v3/pkg/protocol"
+)
+
+// doctorTimeout bounds each live check.
+const doctorTimeout = 30 * time.Second
+
+type checkStatus string
+
+const (
+	checkPass checkStatus = "PASS"
+	checkWarn checkStatus = "WARN"
+	checkFail checkStatus = "FAIL"
+)
+
+// doctor runs pre-flight checks and prints one line per check.
+type doctor struct {
+	out    io.Writer
+	counts map[checkStatus]int
+}
+
+func (d *doctor) report(status checkStatus, name, format string, args ...any) {
+	d.counts[status]++
+	// Errors from live checks can echo request details; mask credentials.
+	fmt.Fprintf(d.out, "%s  %-24s %s\n", status, name, logbuf.Redact(fmt.Sprintf(format, args...)))
+}
+
+// cmdConfigDoctor goes beyond config validate: it pings each provider,
+// authenticates the connectors, and checks agent directories and skills.
+// It exits non-zero if any check fails.
+func cmdConfigDoctor(path string) {
+	d := &doctor{out: os.Stdout, counts: make(map[checkStatus]int)}
+	cfg, err := config.Load(path)
+	if err != nil {
+		d.report(checkFail, "config", "%v", err)
+		os.Exit(1)
+	}
+	d.report(checkPass, "config", "%s is valid", path)
+
+	transport, err := cfg.HTTP.Transport()
+	if err != nil {
+		d.report(checkFail, "http", "%v", err)
+		os.Exit(1)
+	}
+	client := cfg.HTTP.Client(transport, doctorTimeout)
+
+	d.checkProviders(cfg, client)
+	for _, hs := range cfg.HiveSpecs() {
+		prefix := "" // names checks by hive in multi-hive configs
+		if len(cfg.Hives) > 0 {
+			prefix = hs.Hive.ID + "/"
+		}
+		d.checkHive(prefix, hs)
+	}
+
+	fmt.Fprintf(d.out, "\n%d passed, %d warnings, %d failed\n", d.counts[checkPass], d.counts[checkWarn], d.counts[checkFail])
+	if d.counts[checkFail] > 0 {
+		os.Exit(1)
+	}
+}
+
+// checkProviders sends each provider a one-line prompt, which catches bad
+// keys, base URLs and model names.
+func (d *doctor) checkProviders(cfg *config.Config, client *http.Client) {
+	names := make([]string, 0, len(cfg.Providers))
+	for name := range cfg.Providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		pcfg := cfg.Providers[name]
+		var prov provider.Provider
+		switch pcfg.Type {
+		case "anthropic":
+			opts := []provider.AnthropicOption{provider.WithAnthropicHTTPClient(client), provider.WithAnthropicModel(pcfg.Model)}
+			if pcfg.BaseURL != "" {
+				opts = append(opts, provider.WithAnthropicBaseURL(pcfg.BaseURL))
+			}
+			prov = provider.NewAnthropic(pcfg.APIKey, opts...)
+		case "ollama":
+			opts := []provider.OllamaOption{provider.WithOllamaHTTPClient(client), provider.WithOllamaModel(pcfg.Model)}
+			if pcfg.APIKey != "" {
+				opts = append(opts, provider.WithOllamaAPIKey(pcfg.APIKey))
+			}
+			prov = provider.NewOllama(pcfg.BaseURL, opts...)
+		default:
+			opts := []provider.OpenAIOption{provider.WithHTTPClient(client), provider.WithModel(pcfg.Model)}
+			if pcfg.BaseURL != "" {
+				opts = append(opts, provider.WithBaseURL(pcfg.BaseURL))
+			}
+			if pcfg.Reasoning != nil {
+				opts = append(opts, provider.WithReasoningModel(*pcfg.Reasoning))
+			}
+			prov = provider.NewOpenAI(pcfg.APIKey, opts...)
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), doctorTimeout)
+		start := time.Now()
+		_, err := prov.Chat(ctx, protocol.ChatRequest{
+			Messages:  []protocol.ChatMessage{{Role: "user", Content: "Reply with OK."}},
+			MaxTokens: 16,
+		})
+		cancel()
+		check := "provider " + name
+		if err != nil {
+			d.report(checkFail, check, "%s: %v", pcfg.Model, err)
+			continue
+		}
+		d.report(checkPass, check, "%s answered in %s", pcfg.Model, time.Since(start).Round(time.Millisecond))
+	}
+}
+
+// checkHive checks the hive's data dir, its agents and its connectors.
+func (d *doctor) checkHive(prefix string, hs config.HiveSpec) {
+	// The daemon creates the data dir, so only an unusable one fails.
+	d.checkDir(prefix+"data_dir", hs.Hive.DataDir)
+
+	for _, spec := range hs.Agents {
+		d.checkDir(prefix+"agent "+spec.ID, spec.Directory)
+		d.checkSkills(prefix+"skills "+spec.ID, hs.Hive, spec)
+	}
+
+	if tg := hs.Connectors.Telegram; tg != nil {
+		if bot, err := telegram.CheckAuth(tg.Token); err != nil {
+			d.report(checkFail, prefix+"telegram", "%v", err)
+		} else {
+			d.report(checkPass, prefix+"telegram", "authorized as @%s", bot)
+		}
+	}
+	if sc := hs.Connectors.Slack; sc != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), doctorTimeout)
+		who, err := slackconn.CheckAuth(ctx, slackconn.Config{BotToken: sc.BotToken, AppToken: sc.AppToken})
+		cancel()
+		if err != nil {
+			d.report(checkFail, prefix+"slack", "%v", err)
+		} else {
+			d.report(checkPass, prefix+"slack", "authorized as %s, socket mode available", who)
+		}
+	}
+	if dc := hs.Connectors.Discord; dc != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), doctorTimeout)
+		bot, err := discord.CheckAuth(ctx, discord.Config{Token: dc.Token})
+		cancel()
+		if err != nil {
+			d.report(checkFail, prefix+"discord", "%v", err)
+		} else {
+			d.report(checkPass, prefix+"discord", "authorized as %s", bot)
+		}
+	}
+}
+
+// checkDir passes for a writable directory and warns for a missing one
+// whose parent is writable, since it is created on first use.
+func (d *doctor) checkDir(check, dir string) {
+	info, err := os.Stat(dir)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		parent := filepath.Dir(dir)
+		for {
+			if _, err := os.Stat(parent); err == nil || parent == filepath.Dir(parent) {
+				break
+			}
+			parent = filepath.Dir(parent)
+		}
+		if err := probeWrite(parent); err != nil {
+			d.report(checkFail, check, "%s does not exist and cannot be created: %v", dir, err)
+			return
+		}
+		d.report(checkWarn, check, "%s does not exist yet and will be created on first use", dir)
+	case err != nil:
+		d.report(checkFail, check, "%v", err)
+	case !info.IsDir():
+		d.report(checkFail, check, "%s is not a directory", dir)
+	default:
+		if err := probeWrite(dir); err != nil {
+			d.report(checkFail, check, "%s is not writable: %v", dir, err)
+			return
+		}
+		d.report(checkPass, check, "%s is writable", dir)
+	}
+}
+
+// probeWrite creates and removes a temporary file in dir.
+func probeWrite(dir string) error {
+	f, err := os.CreateTemp(dir, ".h1v3-doctor-*")
+	if err != nil {
+		return err
+	}
+	f.Close()
+	return os.Remove(f.Name())
+}
+
+// checkSkills warns about skills an agent lists that none of its skill
+// directories provide.
+func (d *doctor) checkSkills(check string, hive config.HiveConfig, spec protocol.AgentSpec) {
+	if len(spec.Skills) == 0 {
+		return
+	}
+	var extra []string
+	for _, rel := range hive.SkillPaths {
+		extra = append(extra, filepath.Join(spec.Directory, rel))
+	}
+	found := make(map[string]bool)
+	for _, sk := range agent.LoadSkills([]string{hive.DataDir, spec.Directory}, extra).All() {
+		found[sk.Slug] = true
+	}
+	var missing []string
+	for _, slug := range spec.Skills {
+		if !found[slug] {
+			missing = append(missing, slug)
+		}
+	}
+	if len(missing) > 0 {
+		d.report(checkWarn, check, "not found in any skill directory: %v", missing)
+		return
+	}
+	d.report(checkPass, check, "%d skill(s) found", len(spec.Skills))
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"bufio"
+	"bytes"
 	"context"
 	"encoding/json"
 	"flag"
@@ -9,12 +10,16 @@ import (
 	"io"
 	"log/slog"
 	"net/http"
+	"net/url"
 	"os"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/h1v3-io/h1v3/internal/agent"
 	"github.com/h1v3-io/h1v3/internal/config"
+	"github.com/h1v3-io/h1v3/internal/logbuf"
 	"github.com/h1v3-io/h1v3/internal/provider"
 	"github.com/h1v3-io/h1v3/internal/tool"
 	"github.com/h1v3-io/h1v3/pkg/protocol"
@@ -33,7 +38,7 @@ func main() {
 		cmdHealth()
 	case "agents":
 		if len(os.Args) < 3 {
-			fmt.Fprintln(os.Stderr, "usage: h1v3ctl agents <list|show>")
+			fmt.Fprintln(os.Stderr, "usage: h1v3ctl agents <list|show|tools>")
 			os.Exit(1)
 		}
 		switch os.Args[2] {
@@ -45,13 +50,19 @@ func main() {
 				os.Exit(1)
 			}
 			cmdAgentsShow(os.Args[3])
+		case "tools":
+			if len(os.Args) < 4 {
+				fmt.Fprintln(os.Stderr, "usage: h1v3ctl agents tools <id>")
+				os.Exit(1)
+			}
+			cmdAgentsTools(os.Args[3])
 		default:
 			fmt.Fprintf(os.Stderr, "unknown agents subcommand: %s\n", os.Args[2])
 			os.Exit(1)
 		}
 	case "tickets":
 		if len(os.Args) < 3 {
-			fmt.Fprintln(os.Stderr, "usage: h1v3ctl tickets <list|show>")
+			fmt.Fprintln(os.Stderr, "usage: h1v3ctl tickets <list|show|create|close>")
 			os.Exit(1)
 		}
 		switch os.Args[2] {
@@ -63,20 +74,36 @@ func main() {
 				os.Exit(1)
 			}
 			cmdTicketsShow(os.Args[3])
+		case "create":
+			cmdTicketsCreate(os.Args[3:])
+		case "close":
+			cmdTicketsClose(os.Args[3:])
 		default:
 			fmt.Fprintf(os.Stderr, "unknown tickets subcommand: %s\n", os.Args[2])
 			os.Exit(1)
 		}
+	case "send":
+		cmdSend(os.Args[2:])
+	case "logs":
+		cmdLogs(os.Args[2:])
 	case "config":
-		if len(os.Args) < 3 || os.Args[2] != "validate" {
-			fmt.Fprintln(os.Stderr, "usage: h1v3ctl config validate <path>")
+		if len(os.Args) < 4 {
+			fmt.Fprintln(os.Stderr, "usage: h1v3ctl config <validate|doctor> <path>")
 			os.Exit(1)
 		}
-		if len(os.Args) < 4 {
-			fmt.Fprintln(os.Stderr, "usage: h1v3ctl config validate <path>")
+		switch os.Args[2] {
+		case "validate":
+			cmdConfigValidate(os.Args[3])
+		case "doctor":
+			cmdConfigDoctor(os.Args[3])
+		default:
+			fmt.Fprintf(os.Stderr, "unknown config subcommand: %s\n", os.Args[2])
 			os.Exit(1)
 		}
-		cmdConfigValidate(os.Args[3])
+	case "export":
+		cmdExport(os.Args[2:])
+	case "import":
+		cmdImport(os.Args[2:])
 	default:
 		fmt.Fprintf(os.Stderr, "unknown command: %s\n", os.Args[1])
 		printUsage()
@@ -88,13 +115,14 @@ func main() {
 
 func cmdRun(args []string) {
 	fs := flag.NewFlagSet("run", flag.ExitOnError)
-	provType := fs.String("provider", envOr("H1V3_PROVIDER", "openai"), "Provider type: openai or anthropic")
+	provType := fs.String("provider", envOr("H1V3_PROVIDER", "openai"), "Provider type: openai, anthropic or ollama")
 	model := fs.String("model", envOr("H1V3_MODEL", ""), "LLM model name")
 	apiKey := fs.String("api-key", "", "API key (or set OPENAI_API_KEY / ANTHROPIC_API_KEY)")
 	baseURL := fs.String("base-url", envOr("H1V3_BASE_URL", ""), "Override API base URL")
 	prompt := fs.String("prompt", "", "Single prompt (omit for interactive)")
 	workDir := fs.String("work-dir", ".", "Working directory")
 	verbose := fs.Bool("v", false, "Verbose logging")
+	noStream := fs.Bool("no-stream", false, "Print only the final result (for piping)")
 	fs.Parse(args)
 
 	// Resolve API key from env if not passed as flag
@@ -106,7 +134,7 @@ func cmdRun(args []string) {
 			*apiKey = os.Getenv("OPENAI_API_KEY")
 		}
 	}
-	if *apiKey == "" {
+	if *apiKey == "" && *provType != "ollama" {
 		fmt.Fprintln(os.Stderr, "error: API key required (--api-key, OPENAI_API_KEY, or ANTHROPIC_API_KEY)")
 		os.Exit(1)
 	}
@@ -128,7 +156,19 @@ func cmdRun(args []string) {
 		if *baseURL != "" {
 			opts = append(opts, provider.WithAnthropicBaseURL(*baseURL))
 		}
+		if *verbose {
+			opts = append(opts, provider.WithAnthropicRequestLogger(provider.SlogRequestLogger(logger, logbuf.Redact)))
+		}
 		prov = provider.NewAnthropic(*apiKey, opts...)
+	case "ollama":
+		opts := []provider.OllamaOption{provider.WithOllamaAPIKey(*apiKey)}
+		if *model != "" {
+			opts = append(opts, provider.WithOllamaModel(*model))
+		}
+		if *verbose {
+			opts = append(opts, provider.WithOllamaRequestLogger(provider.SlogRequestLogger(logger, logbuf.Redact)))
+		}
+		prov = provider.NewOllama(*baseURL, opts...)
 	default:
 		if *model == "" {
 			*model = "gpt-4o"
@@ -138,6 +178,9 @@ func cmdRun(args []string) {
 		if *baseURL != "" {
 			opts = append(opts, provider.WithBaseURL(*baseURL))
 		}
+		if *verbose {
+			opts = append(opts, provider.WithRequestLogger(provider.SlogRequestLogger(logger, logbuf.Redact)))
+		}
 		prov = provider.NewOpenAI(*apiKey, opts...)
 	}
 
@@ -150,8 +193,12 @@ func cmdRun(args []string) {
 	reg.Register(&tool.ReadFileTool{AllowedDir: absDir})
 	reg.Register(&tool.WriteFileTool{AllowedDir: absDir})
 	reg.Register(&tool.EditFileTool{AllowedDir: absDir})
+	reg.Register(&tool.ApplyPatchTool{AllowedDir: absDir})
 	reg.Register(&tool.ListDirTool{AllowedDir: absDir})
+	reg.Register(&tool.GrepTool{AllowedDir: absDir})
+	reg.Register(&tool.GlobTool{AllowedDir: absDir})
 	reg.Register(&tool.ExecTool{WorkDir: absDir})
+	reg.Register(&tool.GitTool{AllowedDir: absDir})
 	reg.Register(&tool.WebFetchTool{})
 	if braveKey := os.Getenv("BRAVE_API_KEY"); braveKey != "" {
 		reg.Register(&tool.WebSearchTool{APIKey: braveKey})
@@ -169,13 +216,27 @@ func cmdRun(args []string) {
 	a.Logger = logger
 	ctx := context.Background()
 
+	// run executes one prompt, streaming output unless --no-stream is set.
+	var sp *streamPrinter
+	if !*noStream {
+		sp = &streamPrinter{out: os.Stdout, status: os.Stderr}
+		a.OnStream = sp.handle
+	}
+	run := func(prompt string) error {
+		result, err := a.Run(ctx, prompt)
+		if sp != nil {
+			sp.finish()
+		} else if err == nil {
+			fmt.Println(result)
+		}
+		return err
+	}
+
 	if *prompt != "" {
-		result, err := a.Run(ctx, *prompt)
-		if err != nil {
+		if err := run(*prompt); err != nil {
 			fmt.Fprintf(os.Stderr, "error: %v\n", err)
 			os.Exit(1)
 		}
-		fmt.Println(result)
 	} else {
 		fmt.Println("h1v3ctl interactive mode (type 'quit' to exit)")
 		fmt.Printf("Model: %s | Tools: %s\n\n", *model, strings.Join(reg.List(), ", "))
@@ -192,17 +253,41 @@ func cmdRun(args []string) {
 			if line == "quit" || line == "exit" {
 				break
 			}
-			result, err := a.Run(ctx, line)
-			if err != nil {
+			if err := run(line); err != nil {
 				fmt.Fprintf(os.Stderr, "error: %v\n", err)
 				continue
 			}
-			fmt.Println(result)
 			fmt.Println()
 		}
 	}
 }
 
+// streamPrinter writes streamed response text as it arrives and a status
+// line whenever the agent starts a tool call.
+type streamPrinter struct {
+	out, status io.Writer
+	midLine     bool // last text written did not end in a newline
+}
+
+func (p *streamPrinter) handle(chunk protocol.StreamChunk) {
+	if chunk.ContentDelta != "" {
+		fmt.Fprint(p.out, chunk.ContentDelta)
+		p.midLine = !strings.HasSuffix(chunk.ContentDelta, "\n")
+	}
+	if d := chunk.ToolCallDelta; d != nil && d.Name != "" {
+		p.finish()
+		fmt.Fprintf(p.status, "[calling %s…]\n", d.Name)
+	}
+}
+
+// finish ends any partial line of streamed text.
+func (p *streamPrinter) finish() {
+	if p.midLine {
+		fmt.Fprintln(p.out)
+		p.midLine = false
+	}
+}
+
 // --- API client commands ---
 
 func cmdHealth() {
@@ -223,7 +308,8 @@ func cmdAgentsList() {
 	var agents []map[string]any
 	json.Unmarshal(body, &agents)
 	for _, a := range agents {
-		fmt.Printf("%-20s %s\n", a["id"], a["role"])
+		status, _ := a["status"].(string)
+		fmt.Printf("%-20s %-8s %s\n", a["id"], status, a["role"])
 	}
 }
 
@@ -236,6 +322,26 @@ func cmdAgentsShow(id string) {
 	fmt.Println(prettyJSON(body))
 }
 
+// cmdAgentsTools prints the agent's tool usage counters, most used first.
+func cmdAgentsTools(id string) {
+	body, err := apiGet("/api/agents/" + id + "/tools/stats")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+	var stats []tool.ToolStat
+	json.Unmarshal(body, &stats)
+	if len(stats) == 0 {
+		fmt.Println("no tool calls recorded")
+		return
+	}
+	fmt.Printf("%-28s %7s %8s %9s  %s\n", "TOOL", "CALLS", "FAILED", "AVG", "LAST USED")
+	for _, s := range stats {
+		avg := time.Duration(s.TotalMillis/max(s.Calls, 1)) * time.Millisecond
+		fmt.Printf("%-28s %7d %8d %9s  %s\n", s.Name, s.Calls, s.Failures, avg, s.LastUsed.Local().Format(time.DateTime))
+	}
+}
+
 func cmdTicketsList(args []string) {
 	fs := flag.NewFlagSet("tickets list", flag.ExitOnError)
 	status := fs.String("status", "", "Filter by status (open|awaiting_close|closed)")
@@ -272,6 +378,271 @@ func cmdTicketsShow(id string) {
 	fmt.Println(prettyJSON(body))
 }
 
+func cmdTicketsCreate(args []string) {
+	fs := flag.NewFlagSet("tickets create", flag.ExitOnError)
+	to := fs.String("to", "", "Comma-separated target agent IDs")
+	title := fs.String("title", "", "Ticket title")
+	goal := fs.String("goal", "", "What the ticket should achieve")
+	message := fs.String("message", "", "First message (default: the goal)")
+	tags := fs.String("tags", "", "Comma-separated tags")
+	from := fs.String("from", "api", "Creator ID")
+	priority := fs.Int("priority", 0, "Priority; higher is listed first")
+	asJSON := fs.Bool("json", false, "Print the API response as JSON")
+	fs.Parse(args)
+
+	if *to == "" || *title == "" || *goal == "" {
+		fmt.Fprintln(os.Stderr, `usage: h1v3ctl tickets create --to a,b --title "..." --goal "..." [--message "..."] [--tags x,y] [--priority N] [--json]`)
+		os.Exit(1)
+	}
+	reqBody, _ := json.Marshal(map[string]any{
+		"from":     *from,
+		"title":    *title,
+		"goal":     *goal,
+		"message":  *message,
+		"to":       splitList(*to),
+		"tags":     splitList(*tags),
+		"priority": *priority,
+	})
+	body, err := apiPost("/api/tickets", reqBody)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+	if *asJSON {
+		fmt.Println(prettyJSON(body))
+		return
+	}
+	var created struct {
+		TicketID string `json:"ticket_id"`
+	}
+	json.Unmarshal(body, &created)
+	fmt.Println(created.TicketID)
+}
+
+func cmdTicketsClose(args []string) {
+	// Accept the ID before or after the flags.
+	var id string
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		id, args = args[0], args[1:]
+	}
+	fs := flag.NewFlagSet("tickets close", flag.ExitOnError)
+	summary := fs.String("summary", "", "Closing summary, relayed to the parent ticket")
+	asJSON := fs.Bool("json", false, "Print the API response as JSON")
+	fs.Parse(args)
+	if id == "" {
+		id = fs.Arg(0)
+	}
+
+	if id == "" || *summary == "" {
+		fmt.Fprintln(os.Stderr, `usage: h1v3ctl tickets close <id> --summary "..." [--json]`)
+		os.Exit(1)
+	}
+	reqBody, _ := json.Marshal(map[string]string{"summary": *summary})
+	body, err := apiPost("/api/tickets/"+id+"/close", reqBody)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+	if *asJSON {
+		fmt.Println(prettyJSON(body))
+		return
+	}
+	fmt.Printf("closed %s\n", id)
+}
+
+// splitList splits a comma-separated flag value, dropping empty items.
+func splitList(s string) []string {
+	var out []string
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+func cmdSend(args []string) {
+	fs := flag.NewFlagSet("send", flag.ExitOnError)
+	from := fs.String("from", "api", "Sender ID")
+	ticketID := fs.String("ticket", "", "Existing ticket to post on (default: create one)")
+	wait := fs.Bool("wait", false, "Wait for a reply on the ticket and print it")
+	timeout := fs.Duration("timeout", 2*time.Minute, "How long --wait polls before giving up")
+	interval := fs.Duration("interval", 2*time.Second, "Poll interval with --wait")
+	fs.Parse(args)
+
+	content := strings.Join(fs.Args(), " ")
+	if content == "" {
+		fmt.Fprintln(os.Stderr, "usage: h1v3ctl send [--from X] [--ticket Y] [--wait] <content>")
+		os.Exit(1)
+	}
+
+	// Replies already on the ticket don't count as the answer.
+	seen := 0
+	if *wait && *ticketID != "" {
+		tk, err := fetchTicket(*ticketID)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			os.Exit(1)
+		}
+		seen = len(repliesTo(tk, *from))
+	}
+
+	reqBody, _ := json.Marshal(map[string]string{"from": *from, "ticket_id": *ticketID, "content": content})
+	body, err := apiPost("/api/messages", reqBody)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+	var accepted struct {
+		TicketID string `json:"ticket_id"`
+	}
+	json.Unmarshal(body, &accepted)
+	fmt.Println(accepted.TicketID)
+
+	if !*wait {
+		return
+	}
+	deadline := time.Now().Add(*timeout)
+	for time.Now().Before(deadline) {
+		time.Sleep(*interval)
+		tk, err := fetchTicket(accepted.TicketID)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			continue
+		}
+		if replies := repliesTo(tk, *from); len(replies) > seen {
+			for _, m := range replies[seen:] {
+				fmt.Printf("\n[%s] %s\n", m.From, m.Content)
+			}
+			return
+		}
+	}
+	fmt.Fprintf(os.Stderr, "timed out after %s waiting for a reply on %s\n", *timeout, accepted.TicketID)
+	os.Exit(1)
+}
+
+func fetchTicket(id string) (*protocol.Ticket, error) {
+	body, err := apiGet("/api/tickets/" + id)
+	if err != nil {
+		return nil, err
+	}
+	var tk protocol.Ticket
+	if err := json.Unmarshal(body, &tk); err != nil {
+		return nil, fmt.Errorf("decode ticket: %w", err)
+	}
+	return &tk, nil
+}
+
+// repliesTo returns the messages on a ticket not sent by from.
+func repliesTo(tk *protocol.Ticket, from string) []protocol.Message {
+	var out []protocol.Message
+	for _, m := range tk.Messages {
+		if m.From != from {
+			out = append(out, m)
+		}
+	}
+	return out
+}
+
+func cmdLogs(args []string) {
+	fs := flag.NewFlagSet("logs", flag.ExitOnError)
+	level := fs.String("level", "", "Minimum level (debug|info|warn|error)")
+	limit := fs.Int("limit", 200, "Max entries to fetch")
+	agentID := fs.String("agent", "", "Only entries for this agent")
+	ticketID := fs.String("ticket", "", "Only entries for this ticket")
+	since := fs.String("since", "", "Only entries newer than a duration (10m) or RFC 3339 time")
+	follow := fs.Bool("follow", false, "Keep polling for new entries")
+	interval := fs.Duration("interval", 2*time.Second, "Poll interval with --follow")
+	fs.Parse(args)
+
+	params := url.Values{}
+	params.Set("limit", strconv.Itoa(*limit))
+	if *level != "" {
+		params.Set("level", *level)
+	}
+	if *agentID != "" {
+		params.Set("agent", *agentID)
+	}
+	if *ticketID != "" {
+		params.Set("ticket", *ticketID)
+	}
+
+	var from time.Time
+	if *since != "" {
+		if d, err := time.ParseDuration(*since); err == nil {
+			from = time.Now().Add(-d)
+		} else if t, err := time.Parse(time.RFC3339, *since); err == nil {
+			from = t
+		} else {
+			fmt.Fprintf(os.Stderr, "error: --since must be a duration or RFC 3339 time: %q\n", *since)
+			os.Exit(1)
+		}
+	}
+
+	color := isTerminal(os.Stdout)
+	if !from.IsZero() {
+		params.Set("since", strconv.FormatInt(from.UnixMilli(), 10))
+	}
+	var last uint64 // Seq of the newest entry shown
+	for {
+		if last > 0 {
+			params.Set("after", strconv.FormatUint(last, 10))
+		}
+		body, err := apiGet("/api/logs?" + params.Encode())
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			if !*follow {
+				os.Exit(1)
+			}
+		}
+		var entries []logbuf.Entry
+		json.Unmarshal(body, &entries)
+		for _, e := range entries {
+			fmt.Println(formatLogEntry(e, color))
+			last = e.Seq
+		}
+
+		if !*follow {
+			return
+		}
+		time.Sleep(*interval)
+	}
+}
+
+// levelColors are ANSI colors for log levels on a terminal.
+var levelColors = map[string]string{
+	"DEBUG": "\033[90m",
+	"INFO":  "\033[36m",
+	"WARN":  "\033[33m",
+	"ERROR": "\033[31m",
+}
+
+func formatLogEntry(e logbuf.Entry, color bool) string {
+	level := fmt.Sprintf("%-5s", e.Level)
+	if c, ok := levelColors[e.Level]; ok && color {
+		level = c + level + "\033[0m"
+	}
+
+	keys := make([]string, 0, len(e.Attrs))
+	for k := range e.Attrs {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s %s %s", e.Time.Local().Format("15:04:05.000"), level, e.Message)
+	for _, k := range keys {
+		fmt.Fprintf(&b, " %s=%v", k, e.Attrs[k])
+	}
+	return b.String()
+}
+
+// isTerminal reports whether f is attached to a terminal.
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
 func cmdConfigValidate(path string) {
 	_, err := config.Load(path)
 	if err != nil {
@@ -284,13 +655,30 @@ func cmdConfigValidate(path string) {
 // --- Helpers ---
 
 func apiGet(path string) ([]byte, error) {
+	return apiDo("GET", path, nil)
+}
+
+func apiPost(path string, payload []byte) ([]byte, error) {
+	return apiDo("POST", path, payload)
+}
+
+// apiDo sends a request to the daemon's API at H1V3_API_URL, authenticated
+// with H1V3_API_KEY when set. A non-nil payload is sent as JSON. Responses
+// with status 400 or above are returned as errors carrying the body.
+func apiDo(method, path string, payload []byte) ([]byte, error) {
 	base := envOr("H1V3_API_URL", "http://localhost:8080")
-	url := base + path
 
-	req, err := http.NewRequest("GET", url, nil)
+	var reqBody io.Reader
+	if payload != nil {
+		reqBody = bytes.NewReader(payload)
+	}
+	req, err := http.NewRequest(method, base+path, reqBody)
 	if err != nil {
 		return nil, err
 	}
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
 	if key := os.Getenv("H1V3_API_KEY"); key != "" {
 		req.Header.Set("Authorization", "Bearer "+key)
 	}
@@ -337,9 +725,17 @@ func printUsage() {
 	fmt.Println("  health               Check daemon health")
 	fmt.Println("  agents list          List all agents")
 	fmt.Println("  agents show <id>     Show agent details")
+	fmt.Println("  agents tools <id>    Show the agent's tool usage counts")
 	fmt.Println("  tickets list         List tickets (--status, --agent, --limit)")
 	fmt.Println("  tickets show <id>    Show ticket details")
+	fmt.Println("  tickets create       Create a ticket (--to, --title, --goal, --message, --tags, --priority, --json)")
+	fmt.Println("  tickets close <id>   Close a ticket (--summary, --json)")
+	fmt.Println("  send <content>       Post a message (--from, --ticket, --wait)")
+	fmt.Println("  logs                 Show daemon logs (--level, --limit, --agent, --ticket, --since, --follow)")
 	fmt.Println("  config validate <p>  Validate config file")
+	fmt.Println("  config doctor <p>    Validate, then ping providers and connectors and check agent dirs")
+	fmt.Println("  export               Write a hive snapshot (--config, --out, --hive)")
+	fmt.Println("  import <file>        Restore a snapshot into a data dir (--config, --on-conflict)")
 	fmt.Println()
 	fmt.Println("Environment:")
 	fmt.Println("  H1V3_API_URL       Daemon URL (default: http://localhost:8080)")
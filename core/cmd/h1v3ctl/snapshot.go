@@ -0,0 +1,272 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/h1v3-io/h1v3/internal/config"
+	"github.com/h1v3-io/h1v3/internal/memory"
+	"github.com/h1v3-io/h1v3/internal/ticket"
+	"github.com/h1v3-io/h1v3/pkg/protocol"
+)
+
+// snapshotVersion is bumped whenever the snapshot layout changes incompatibly.
+const snapshotVersion = 1
+
+// snapshotFile is the on-disk format written by export and read by import.
+type snapshotFile struct {
+	Version    int            `json:"version"`
+	ExportedAt time.Time      `json:"exported_at"`
+	Hives      []hiveSnapshot `json:"hives"`
+}
+
+// hiveSnapshot holds one hive's state. Sessions are informational: the
+// front agent's chat→ticket mapping lives in the "chat:<id>" tags of the
+// exported tickets, so importing the tickets restores it.
+type hiveSnapshot struct {
+	Hive     string                          `json:"hive"`
+	Store    *ticket.Snapshot                `json:"store"`
+	Memory   map[string]map[string]string    `json:"memory,omitempty"`        // agent ID → scope → content
+	Expiry   map[string]map[string]time.Time `json:"memory_expiry,omitempty"` // agent ID → scope → expiry, for scopes with a TTL
+	Sessions map[string]string               `json:"sessions,omitempty"`      // chat ID → open ticket ID
+}
+
+// cmdExport reads every hive's ticket store and agent memory straight from the
+// data directories named in the config. The daemon may keep running; reads go
+// through SQLite's WAL snapshot.
+func cmdExport(args []string) {
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	configPath := fs.String("config", "", "Path to config JSON file")
+	out := fs.String("out", "-", "Output file (- for stdout)")
+	hiveID := fs.String("hive", "", "Only export this hive")
+	fs.Parse(args)
+
+	cfg := loadSnapshotConfig(*configPath)
+	snap := snapshotFile{Version: snapshotVersion, ExportedAt: time.Now().UTC()}
+	for _, hs := range cfg.HiveSpecs() {
+		if *hiveID != "" && hs.Hive.ID != *hiveID {
+			continue
+		}
+		h, err := exportHive(hs)
+		if err != nil {
+			fatalf("export hive %s: %v", hs.Hive.ID, err)
+		}
+		snap.Hives = append(snap.Hives, h)
+	}
+	if *hiveID != "" && len(snap.Hives) == 0 {
+		fatalf("hive %q not found in config", *hiveID)
+	}
+
+	data, err := json.MarshalIndent(snap, "", "  ")
+	if err != nil {
+		fatalf("encode snapshot: %v", err)
+	}
+	if *out == "-" {
+		os.Stdout.Write(append(data, '\n'))
+		return
+	}
+	if err := os.WriteFile(*out, data, 0o600); err != nil {
+		fatalf("write snapshot: %v", err)
+	}
+	for _, h := range snap.Hives {
+		fmt.Fprintf(os.Stderr, "exported hive %s: %d tickets, %d archived, %d agents with memory\n",
+			h.Hive, len(h.Store.Tickets), len(h.Store.Archived), len(h.Memory))
+	}
+}
+
+func exportHive(hs config.HiveSpec) (hiveSnapshot, error) {
+	h := hiveSnapshot{Hive: hs.Hive.ID}
+	dbPath := hs.Hive.TicketDBPath()
+	if _, err := os.Stat(dbPath); err != nil {
+		return h, fmt.Errorf("ticket store %s: %w", dbPath, err)
+	}
+	store, err := ticket.NewSQLiteStore(dbPath)
+	if err != nil {
+		return h, err
+	}
+	defer store.DB().Close()
+	if h.Store, err = store.Export(); err != nil {
+		return h, err
+	}
+
+	// Export, not List: List deletes expired scopes, and this must not
+	// change a running daemon's agent directories.
+	for _, spec := range hs.Agents {
+		scopes, expires := memory.NewStore(spec.Directory).Export()
+		if len(scopes) > 0 {
+			if h.Memory == nil {
+				h.Memory = make(map[string]map[string]string)
+			}
+			h.Memory[spec.ID] = scopes
+		}
+		if len(expires) > 0 {
+			if h.Expiry == nil {
+				h.Expiry = make(map[string]map[string]time.Time)
+			}
+			h.Expiry[spec.ID] = expires
+		}
+	}
+
+	for _, t := range h.Store.Tickets {
+		if t.Status == protocol.TicketClosed {
+			continue
+		}
+		for _, tag := range t.Tags {
+			if chatID, ok := strings.CutPrefix(tag, "chat:"); ok {
+				if h.Sessions == nil {
+					h.Sessions = make(map[string]string)
+				}
+				h.Sessions[chatID] = t.ID
+			}
+		}
+	}
+	return h, nil
+}
+
+// cmdImport restores a snapshot into the data directories named in the
+// config. It is meant for a fresh data dir with the daemon stopped; IDs that
+// already exist are either skipped or abort the import of that hive.
+func cmdImport(args []string) {
+	fs := flag.NewFlagSet("import", flag.ExitOnError)
+	configPath := fs.String("config", "", "Path to config JSON file")
+	onConflict := fs.String("on-conflict", "error", "What to do with IDs that already exist: error or skip")
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		fatalf("usage: h1v3ctl import [--config path] [--on-conflict error|skip] <snapshot.json>")
+	}
+	if *onConflict != "error" && *onConflict != "skip" {
+		fatalf("--on-conflict must be error or skip, got %q", *onConflict)
+	}
+	skip := *onConflict == "skip"
+
+	cfg := loadSnapshotConfig(*configPath)
+	var snap snapshotFile
+	if err := readSnapshot(fs.Arg(0), &snap); err != nil {
+		fatalf("read snapshot: %v", err)
+	}
+	if snap.Version != snapshotVersion {
+		fatalf("unsupported snapshot version %d (want %d)", snap.Version, snapshotVersion)
+	}
+
+	specs := make(map[string]config.HiveSpec)
+	for _, hs := range cfg.HiveSpecs() {
+		specs[hs.Hive.ID] = hs
+	}
+	for _, h := range snap.Hives {
+		hs, ok := specs[h.Hive]
+		if !ok {
+			fatalf("hive %q from the snapshot is not in the config", h.Hive)
+		}
+		if err := importHive(hs, h, skip); err != nil {
+			fatalf("import hive %s: %v", h.Hive, err)
+		}
+	}
+}
+
+func importHive(hs config.HiveSpec, h hiveSnapshot, skip bool) error {
+	dbPath := hs.Hive.TicketDBPath()
+	if err := os.MkdirAll(filepath.Dir(dbPath), 0o755); err != nil {
+		return err
+	}
+	store, err := ticket.NewSQLiteStore(dbPath)
+	if err != nil {
+		return err
+	}
+	defer store.DB().Close()
+
+	// Resolve memory conflicts before touching the store so an "error"
+	// import fails without writing anything.
+	dirs := make(map[string]string)
+	for _, spec := range hs.Agents {
+		dirs[spec.ID] = spec.Directory
+	}
+	type memWrite struct {
+		store        *memory.Store
+		agent, scope string
+		content      string
+		ttl          time.Duration // 0 = no expiry
+	}
+	var writes []memWrite
+	skipped := 0
+	for agentID, mem := range h.Memory {
+		dir, ok := dirs[agentID]
+		if !ok {
+			fmt.Fprintf(os.Stderr, "warning: hive %s: skipping memory of unknown agent %q\n", h.Hive, agentID)
+			continue
+		}
+		ms := memory.NewStore(dir)
+		for scope, content := range mem {
+			var ttl time.Duration
+			if at, ok := h.Expiry[agentID][scope]; ok {
+				if ttl = time.Until(at); ttl <= 0 {
+					skipped++ // expired since the export
+					continue
+				}
+			}
+			if ms.Get(scope) != "" {
+				if skip {
+					skipped++
+					continue
+				}
+				return fmt.Errorf("memory %s/%s: already exists", agentID, scope)
+			}
+			writes = append(writes, memWrite{ms, agentID, scope, content, ttl})
+		}
+	}
+
+	var stats ticket.ImportStats
+	if h.Store != nil {
+		if stats, err = store.Import(h.Store, skip); err != nil {
+			return err
+		}
+	}
+	for _, w := range writes {
+		if err := w.store.SetWithTTL(w.scope, w.content, w.ttl); err != nil {
+			return fmt.Errorf("memory %s/%s: %w", w.agent, w.scope, err)
+		}
+	}
+	stats.Skipped += skipped
+
+	fmt.Printf("imported hive %s: %d tickets, %d messages, %d events, %d usage records, %d schedules, %d dead letters, %d memory scopes, %d skipped\n",
+		h.Hive, stats.Tickets, stats.Messages, stats.Events, stats.Usage, stats.Schedules, stats.DeadLetters, len(writes), stats.Skipped)
+	return nil
+}
+
+func loadSnapshotConfig(path string) *config.Config {
+	if path == "" {
+		fatalf("a config file is required (--config)")
+	}
+	cfg, err := config.Load(path)
+	if err != nil {
+		fatalf("load config: %v", err)
+	}
+	return cfg
+}
+
+func readSnapshot(path string, snap *snapshotFile) error {
+	f := os.Stdin
+	if path != "-" {
+		var err error
+		if f, err = os.Open(path); err != nil {
+			return err
+		}
+		defer f.Close()
+	}
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, snap)
+}
+
+func fatalf(format string, args ...any) {
+	fmt.Fprintf(os.Stderr, "error: "+format+"\n", args...)
+	os.Exit(1)
+}
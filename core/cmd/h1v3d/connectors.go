@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/h1v3-io/h1v3/internal/agent"
+	"github.com/h1v3-io/h1v3/internal/config"
+	"github.com/h1v3-io/h1v3/internal/connector"
+	"github.com/h1v3-io/h1v3/internal/registry"
+)
+
+// frontAgentFor picks the agent a connector feeds: its own agent_id, else the
+// hive's first front agent, else the first configured agent.
+func frontAgentFor(hs config.HiveSpec, agentID string) string {
+	if agentID == "" && len(hs.Hive.FrontAgentIDs) > 0 {
+		agentID = hs.Hive.FrontAgentIDs[0]
+	}
+	if agentID == "" && len(hs.Agents) > 0 {
+		agentID = hs.Agents[0].ID
+	}
+	return agentID
+}
+
+// newFrontSession creates the session manager routing one connector's chats
+// to the front agents, and registers the connector with the hive's external
+// sink under name so replies find their way back to it.
+func newFrontSession(name string, hs config.HiveSpec, frontID string, reg *registry.Registry, mux *connector.Mux, receipts bool,
+	send func(ctx context.Context, msg connector.OutboundMessage) (*connector.Receipt, error), logger *slog.Logger) *agent.SessionManager {
+	sm := agent.NewSessionManager(frontID, reg, logger.With("component", "session-manager", "connector", name))
+	sm.Connector = name
+	sm.DedupWindow = time.Duration(hs.Hive.InboundDedupSeconds) * time.Second
+	sm.CCAgentIDs = hs.Hive.FrontAgentIDs
+	sm.ReplyPolicy = agent.ReplyPolicy(hs.Hive.FrontReplyPolicy)
+	sm.OnSessionCreated = func(chatID, ticketID string) {
+		mux.MapTicket(name, ticketID, chatID)
+	}
+	sm.OnSessionClosed = func(chatID string) {
+		mux.UnmapChat(name, chatID)
+	}
+
+	target := connector.Target{Send: send, Allow: sm.AllowReply}
+	if receipts {
+		// Persisted only, never routed, so a receipt cannot loop back out
+		// through the sink or wake the front agent.
+		target.OnDelivered = func(ticketID string, r connector.Receipt) {
+			if err := reg.PersistMessage(ticketID, connector.ReceiptMessage(ticketID, r)); err != nil {
+				logger.Warn("failed to record delivery receipt", "ticket", ticketID, "error", err)
+			}
+		}
+	}
+	mux.Add(name, target)
+	return sm
+}
+
+// sessionHandler handles the chat commands shared by all connectors
+// (/new, /start, /parallel, /close, /ticket) and forwards everything else to
+// the session manager.
+func sessionHandler(sm *agent.SessionManager, send func(ctx context.Context, msg connector.OutboundMessage) error) connector.InboundHandler {
+	return func(ctx context.Context, msg connector.InboundMessage) error {
+		reply := func(content string) error {
+			return send(ctx, connector.OutboundMessage{ChatID: msg.ChatID, Content: content})
+		}
+		cmd := msg.Content
+		if cmd == "/new" || cmd == "/start" {
+			sm.CloseSession(msg.ChatID)
+			return reply("Starting a new conversation. Send me your message!")
+		}
+		if cmd == "/parallel" || strings.HasPrefix(cmd, "/parallel ") {
+			text := strings.TrimPrefix(cmd, "/parallel")
+			text = strings.TrimSpace(text)
+			if text == "" {
+				text = "New parallel conversation"
+			}
+			ticketID, err := sm.StartParallelSession(msg.ChatID, text)
+			if err != nil {
+				return reply(fmt.Sprintf("Failed to create parallel session: %v", err))
+			}
+			_ = reply(fmt.Sprintf("Parallel conversation started (ticket %s). Send your message!", ticketID))
+			if text != "New parallel conversation" {
+				return sm.HandleInbound(msg.ChatID, text)
+			}
+			return nil
+		}
+		if strings.HasPrefix(cmd, "/close ") {
+			ticketID := strings.TrimSpace(strings.TrimPrefix(cmd, "/close"))
+			if ticketID == "" {
+				return reply("Usage: /close <ticket_id>")
+			}
+			if err := sm.CloseTicket(ticketID, "manually closed via /close"); err != nil {
+				return reply(fmt.Sprintf("Failed to close ticket: %v", err))
+			}
+			return reply(fmt.Sprintf("Ticket %s closed.", ticketID))
+		}
+		if strings.HasPrefix(cmd, "/ticket ") {
+			rest := strings.TrimSpace(strings.TrimPrefix(cmd, "/ticket"))
+			parts := strings.SplitN(rest, " ", 2)
+			if len(parts) < 2 || parts[0] == "" || parts[1] == "" {
+				return reply("Usage: /ticket <ticket_id> <message>")
+			}
+			ticketID, text := parts[0], parts[1]
+			if err := sm.SendToTicket(ticketID, text); err != nil {
+				return reply(fmt.Sprintf("Failed to send to ticket: %v", err))
+			}
+			return nil
+		}
+		return sm.HandleInboundEvent(msg.ChatID, msg.EventID, msg.Content, msg.Attachments...)
+	}
+}
@@ -0,0 +1,485 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/h1v3-io/h1v3/internal/agent"
+	"github.com/h1v3-io/h1v3/internal/config"
+	"github.com/h1v3-io/h1v3/internal/connector"
+	"github.com/h1v3-io/h1v3/internal/connector/discord"
+	"github.com/h1v3-io/h1v3/internal/connector/httpchat"
+	slackconn "github.com/h1v3-io/h1v3/internal/connector/slack"
+	"github.com/h1v3-io/h1v3/internal/connector/telegram"
+	"github.com/h1v3-io/h1v3/internal/connector/webhook"
+	"github.com/h1v3-io/h1v3/internal/memory"
+	"github.com/h1v3-io/h1v3/internal/provider"
+	"github.com/h1v3-io/h1v3/internal/registry"
+	"github.com/h1v3-io/h1v3/internal/ticket"
+	"github.com/h1v3-io/h1v3/internal/tool"
+	"github.com/h1v3-io/h1v3/pkg/protocol"
+)
+
+// scheduleInterval is how often each hive checks for due scheduled messages.
+const scheduleInterval = 15 * time.Second
+
+// defaultHistoryWindow is how many recent ticket messages an agent sees per
+// turn when hive.history_window is unset.
+const defaultHistoryWindow = 100
+
+// defaultShutdownGrace is how many seconds a hive's agents get to finish
+// their work on shutdown when hive.shutdown_grace_seconds is unset.
+const defaultShutdownGrace = 30
+
+// hive is one running tenant: its own ticket store, registry, agents and
+// connectors. Hives never share a registry, so agents and tickets of one hive
+// are invisible to another.
+type hive struct {
+	id           string
+	reg          *registry.Registry
+	store        *ticket.SQLiteStore
+	frontAgentID string
+	chat         *httpchat.Connector
+	webhooks     *webhook.Sink      // nil without outbound webhooks
+	stopInbound  context.CancelFunc // stops connectors and schedules; see drain
+
+	// What startAgent builds agents from. A config reload replaces cfg and
+	// spec and adds new providers to the shared map.
+	ctx        context.Context
+	cfg        *config.Config
+	spec       config.HiveSpec
+	providers  map[string]provider.Provider
+	httpClient *http.Client
+	logger     *slog.Logger
+}
+
+// startHive opens the hive's store, registers and starts its agents, and
+// starts its connectors. Everything runs until ctx is cancelled; connectors
+// and schedules stop earlier when the hive is drained.
+func startHive(ctx context.Context, cfg *config.Config, hs config.HiveSpec, providers map[string]provider.Provider, httpClient *http.Client, logger *slog.Logger) (*hive, error) {
+	logger = logger.With("hive", hs.Hive.ID)
+
+	// Ticket store + registry
+	dbPath := hs.Hive.TicketDBPath()
+	os.MkdirAll(hs.Hive.DataDir, 0o755)
+	os.MkdirAll(filepath.Dir(dbPath), 0o755)
+	store, err := ticket.NewSQLiteStore(dbPath)
+	if err != nil {
+		return nil, fmt.Errorf("open ticket store %s: %w", dbPath, err)
+	}
+	// store will be cleaned up when the process exits
+
+	reg := registry.New(store, logger)
+	reg.SetRelayMode(registry.RelayMode(hs.Hive.RelayMode))
+	reg.SetInboxPolicy(hs.Hive.InboxPolicy, time.Duration(hs.Hive.InboxBlockSeconds)*time.Second)
+	reg.SetSelfDelivery(hs.Hive.SelfDelivery)
+	reg.SetContext(ctx)
+	reg.SetModelCosts(cfg.Tools.ModelCosts)
+
+	// Outbound webhooks: agents address "_webhook" on a ticket tagged with
+	// the endpoint name, and its messages are posted there.
+	var webhooks *webhook.Sink
+	if len(hs.Connectors.OutboundWebhooks) > 0 {
+		endpoints := make(map[string]webhook.OutboundEndpoint, len(hs.Connectors.OutboundWebhooks))
+		for name, w := range hs.Connectors.OutboundWebhooks {
+			endpoints[name] = webhook.OutboundEndpoint{URL: w.URL, Secret: w.Secret, BearerToken: w.BearerToken}
+		}
+		webhooks = webhook.NewSink(endpoints, reg.GetTicket, logger.With("component", "webhook-sink"))
+		reg.RegisterSink(webhook.SinkName, webhooks)
+	}
+
+	if days := hs.Hive.TicketRetentionDays; days > 0 {
+		retention := time.Duration(days) * 24 * time.Hour
+		go safeGo(logger, "ticket-retention", func() { reg.RunRetention(ctx, retention, time.Hour) })
+		logger.Info("ticket retention enabled", "days", days)
+	}
+	inbound, stopInbound := context.WithCancel(ctx)
+	go safeGo(logger, "schedules", func() { reg.RunSchedules(inbound, scheduleInterval) })
+
+	h := &hive{id: hs.Hive.ID, reg: reg, store: store, webhooks: webhooks, stopInbound: stopInbound, ctx: ctx, cfg: cfg, spec: hs, providers: providers, httpClient: httpClient, logger: logger}
+
+	// Register agents from config
+	for _, spec := range hs.Agents {
+		if err := h.startAgent(spec); err != nil {
+			return nil, err
+		}
+	}
+
+	// Start connectors. They share one "_external" sink, which sends each
+	// reply back through the connector and chat its ticket came from.
+	var chat *httpchat.Connector
+	if hs.Connectors.Telegram != nil || hs.Connectors.Slack != nil || hs.Connectors.Discord != nil || hs.Connectors.HTTP != nil {
+		mux := connector.NewMux(reg.GetTicket, logger.With("component", "external-sink"))
+		reg.RegisterSink("_external", mux)
+
+		if tc := hs.Connectors.Telegram; tc != nil {
+			frontID := frontAgentFor(hs, tc.AgentID)
+			if _, ok := reg.GetAgent(frontID); !ok {
+				logger.Warn("telegram agent not found, telegram connector will not start", "agent_id", frontID)
+			} else {
+				// Forward-declare tgConn so the handler/sink closures can reference it
+				var tgConn *telegram.Connector
+				sm := newFrontSession("telegram", hs, frontID, reg, mux, tc.DeliveryReceipts,
+					func(ctx context.Context, msg connector.OutboundMessage) (*connector.Receipt, error) {
+						return tgConn.SendWithReceipt(ctx, msg)
+					}, logger)
+
+				var tgErr error
+				tgConn, tgErr = telegram.New(
+					telegram.Config{
+						Token:     tc.Token,
+						AllowFrom: tc.AllowFrom,
+					},
+					sessionHandler(sm, func(ctx context.Context, msg connector.OutboundMessage) error {
+						return tgConn.Send(ctx, msg)
+					}),
+					logger.With("connector", "telegram"),
+				)
+				if tgErr != nil {
+					return nil, fmt.Errorf("init telegram connector: %w", tgErr)
+				}
+
+				go safeGo(logger, "telegram", func() { tgConn.Start(inbound) })
+				logger.Info("telegram connector started")
+			}
+		}
+
+		if sc := hs.Connectors.Slack; sc != nil {
+			frontID := frontAgentFor(hs, sc.AgentID)
+			if _, ok := reg.GetAgent(frontID); !ok {
+				logger.Warn("slack agent not found, slack connector will not start", "agent_id", frontID)
+			} else {
+				var slConn *slackconn.Connector
+				sm := newFrontSession("slack", hs, frontID, reg, mux, sc.DeliveryReceipts,
+					func(ctx context.Context, msg connector.OutboundMessage) (*connector.Receipt, error) {
+						return slConn.SendWithReceipt(ctx, msg)
+					}, logger)
+
+				var slErr error
+				slConn, slErr = slackconn.New(
+					slackconn.Config{
+						BotToken: sc.BotToken,
+						AppToken: sc.AppToken,
+						Channels: sc.Channels,
+					},
+					sessionHandler(sm, func(ctx context.Context, msg connector.OutboundMessage) error {
+						return slConn.Send(ctx, msg)
+					}),
+					logger.With("connector", "slack"),
+				)
+				if slErr != nil {
+					return nil, fmt.Errorf("init slack connector: %w", slErr)
+				}
+
+				go safeGo(logger, "slack", func() { slConn.Start(inbound) })
+				logger.Info("slack connector started")
+			}
+		}
+
+		if dc := hs.Connectors.Discord; dc != nil {
+			frontID := frontAgentFor(hs, dc.AgentID)
+			if _, ok := reg.GetAgent(frontID); !ok {
+				logger.Warn("discord agent not found, discord connector will not start", "agent_id", frontID)
+			} else {
+				var dcConn *discord.Connector
+				sm := newFrontSession("discord", hs, frontID, reg, mux, dc.DeliveryReceipts,
+					func(ctx context.Context, msg connector.OutboundMessage) (*connector.Receipt, error) {
+						return dcConn.SendWithReceipt(ctx, msg)
+					}, logger)
+
+				var dcErr error
+				dcConn, dcErr = discord.New(
+					discord.Config{
+						Token:    dc.Token,
+						Guilds:   dc.Guilds,
+						Channels: dc.Channels,
+					},
+					sessionHandler(sm, func(ctx context.Context, msg connector.OutboundMessage) error {
+						return dcConn.Send(ctx, msg)
+					}),
+					logger.With("connector", "discord"),
+				)
+				if dcErr != nil {
+					return nil, fmt.Errorf("init discord connector: %w", dcErr)
+				}
+
+				go safeGo(logger, "discord", func() {
+					if err := dcConn.Start(inbound); err != nil {
+						logger.Error("discord connector stopped", "error", err)
+					}
+				})
+				logger.Info("discord connector started")
+			}
+		}
+
+		if hc := hs.Connectors.HTTP; hc != nil {
+			frontID := frontAgentFor(hs, hc.AgentID)
+			if _, ok := reg.GetAgent(frontID); !ok {
+				logger.Warn("http chat agent not found, /api/chat will not be available", "agent_id", frontID)
+			} else {
+				// The handler needs the session manager, which needs the connector.
+				var handle connector.InboundHandler
+				chat = httpchat.New(
+					httpchat.Config{
+						MaxQueued:   hc.MaxQueued,
+						IdleTimeout: time.Duration(hc.IdleTimeoutSeconds) * time.Second,
+					},
+					func(ctx context.Context, msg connector.InboundMessage) error { return handle(ctx, msg) },
+					logger.With("connector", "http"),
+				)
+				sm := newFrontSession("http", hs, frontID, reg, mux, hc.DeliveryReceipts, chat.SendWithReceipt, logger)
+				handle = sessionHandler(sm, chat.Send)
+
+				go safeGo(logger, "http-chat", func() { chat.Start(inbound) })
+				logger.Info("http chat connector started")
+			}
+		}
+	}
+
+	// Redeliver turns interrupted by the last shutdown, now that workers
+	// and sinks are in place.
+	if n, err := reg.ResumeInFlight(); err != nil {
+		logger.Error("failed to resume in-flight messages", "error", err)
+	} else if n > 0 {
+		logger.Info("resumed in-flight messages", "count", n)
+	}
+
+	frontID := hs.Hive.FrontAgentID
+	if frontID == "" && len(hs.Agents) > 0 {
+		frontID = hs.Agents[0].ID
+	}
+	h.frontAgentID, h.chat = frontID, chat
+	return h, nil
+}
+
+// startAgent builds an agent from spec, with its memory, tools and provider,
+// registers it and starts its worker. It serves startup and config reloads.
+func (h *hive) startAgent(spec protocol.AgentSpec) error {
+	cfg, hs, reg, store, logger, httpClient := h.cfg, h.spec, h.reg, h.store, h.logger, h.httpClient
+
+	// Create per-agent memory store
+	mem := memory.NewStore(spec.Directory)
+
+	// Create per-agent tool registry with whitelist/blacklist gating
+	agentTools := tool.NewRegistry()
+	agentTools.SetUsage(tool.NewUsage(filepath.Join(hs.Hive.DataDir, "tool_stats", spec.ID+".json")))
+	register := func(t tool.Tool) {
+		if spec.ToolAllowed(t.Name()) {
+			agentTools.Register(t)
+		}
+	}
+	register(&tool.ReadFileTool{AllowedDir: spec.Directory})
+	var quota *tool.DiskQuota
+	if spec.DiskQuotaMB > 0 {
+		quota = tool.NewDiskQuota(spec.Directory, int64(spec.DiskQuotaMB)<<20)
+	}
+	register(&tool.WriteFileTool{AllowedDir: spec.Directory, Quota: quota})
+	register(&tool.EditFileTool{AllowedDir: spec.Directory, Quota: quota})
+	register(&tool.ApplyPatchTool{AllowedDir: spec.Directory, Quota: quota})
+	register(&tool.ListDirTool{AllowedDir: spec.Directory})
+	register(&tool.GrepTool{AllowedDir: spec.Directory})
+	register(&tool.GlobTool{AllowedDir: spec.Directory})
+	execTool := &tool.ExecTool{
+		WorkDir:         spec.Directory,
+		Timeout:         time.Duration(cfg.Tools.ShellTimeout) * time.Second,
+		MaxOutput:       cfg.Tools.ExecMaxOutput,
+		BlockedCommands: cfg.Tools.BlockedCommands,
+	}
+	if cfg.Tools.ExecLogOutput {
+		execTool.Logger = logger.With("agent", spec.ID)
+	}
+	register(execTool)
+	register(&tool.GitTool{
+		AllowedDir:  spec.Directory,
+		AuthorName:  spec.ID,
+		AuthorEmail: spec.ID + "@h1v3.local",
+		Timeout:     execTool.Timeout,
+	})
+	register(&tool.WebFetchTool{Client: httpClient})
+	if cfg.Tools.BraveAPIKey != "" {
+		register(&tool.WebSearchTool{APIKey: cfg.Tools.BraveAPIKey, Client: httpClient})
+	}
+	// Memory tools bound to this agent's store
+	register(&tool.ReadMemoryTool{Store: mem})
+	register(&tool.WriteMemoryTool{Store: mem})
+	register(&tool.ListMemoryTool{Store: mem})
+	register(&tool.SearchMemoryTool{Store: mem})
+	register(&tool.DeleteMemoryTool{Store: mem})
+	// Hive discovery
+	register(&tool.ListAgentsTool{Lister: &agentListerAdapter{reg: reg}})
+	register(&tool.GetAgentTool{Profiler: &agentListerAdapter{reg: reg}})
+	// Ticket tools — create, respond, close, search, my_tickets
+	broker := &ticketBrokerAdapter{reg: reg}
+	lister := &agentListerAdapter{reg: reg}
+	register(&tool.CreateTicketTool{Broker: broker, AgentID: spec.ID, Agents: lister, Templates: hs.Templates})
+	register(&tool.RespondToTicketTool{Broker: broker, AgentID: spec.ID, Logger: logger.With("agent", spec.ID)})
+	register(&tool.CloseTicketTool{Broker: broker, AgentID: spec.ID})
+	register(&tool.ReopenTicketTool{Broker: broker, AgentID: spec.ID})
+	register(&tool.ReassignTicketTool{Broker: broker, AgentID: spec.ID, Agents: lister})
+	register(&tool.SearchTicketsTool{Broker: broker, AgentID: spec.ID})
+	register(&tool.MyTicketsTool{Broker: broker, AgentID: spec.ID})
+	register(&tool.ScheduleTool{Scheduler: reg, AgentID: spec.ID, Agents: lister})
+	register(&tool.CancelScheduleTool{Scheduler: reg, AgentID: spec.ID})
+	register(&tool.GetTicketTool{Broker: broker})
+	register(&tool.WatchTicketTool{Broker: broker, AgentID: spec.ID, Agents: lister})
+	register(&tool.UnwatchTicketTool{Broker: broker, AgentID: spec.ID})
+	register(&tool.ReadAttachmentTool{Broker: broker, AgentID: spec.ID, AllowedDir: spec.Directory})
+	register(&tool.WaitTool{})
+
+	// Select provider: per-agent override, then "default"
+	prov := h.providers["default"]
+	provName := "default"
+	if spec.Provider != "" {
+		if p, ok := h.providers[spec.Provider]; ok {
+			prov = p
+			provName = spec.Provider
+		}
+	}
+	// Semantic memory search needs a provider that can embed text. Many
+	// OpenAI-compatible endpoints have no /embeddings, so the operator opts
+	// in by naming an embedding model.
+	if emb, ok := prov.(provider.Embedder); ok && cfg.Providers[provName].EmbeddingModel != "" {
+		register(&tool.SemanticSearchMemoryTool{Vectors: memory.NewVectorStore(mem, emb.Embed)})
+	}
+	// Wrap in a fallback chain when the agent lists fallback providers
+	var fallbacks []provider.Provider
+	for _, name := range spec.FallbackProviders {
+		if p, ok := h.providers[name]; ok && name != provName {
+			fallbacks = append(fallbacks, p)
+		}
+	}
+	if len(fallbacks) > 0 {
+		fb := provider.NewFallback(prov, fallbacks...)
+		fb.Logger = logger.With("agent", spec.ID)
+		prov = fb
+	}
+
+	ag := agent.New(spec, prov, agentTools)
+	ag.Memory = mem
+	ag.CompactThreshold = hs.Hive.CompactThreshold
+	// Skill dirs: shared (dataDir) and agent-specific (dir) are scanned as {dir}/skills/.
+	// Extra skill_paths from preset are resolved per-agent and scanned directly.
+	// e.g. skill_paths: [".moltbot/skills"] → scans {agentDir}/.moltbot/skills/
+	skillDirs := []string{hs.Hive.DataDir, spec.Directory}
+	var extraSkillDirs []string
+	for _, rel := range hs.Hive.SkillPaths {
+		extraSkillDirs = append(extraSkillDirs, filepath.Join(spec.Directory, rel))
+	}
+	ag.SkillDirs = skillDirs
+	ag.ExtraSkillDirs = extraSkillDirs
+	skillProvider := &agent.DynamicSkillProvider{Dirs: skillDirs, ExtraDirs: extraSkillDirs}
+	register(&tool.LoadSkillTool{Provider: skillProvider})
+	register(&tool.RunSkillScriptTool{
+		Provider:        skillProvider,
+		WorkDir:         spec.Directory,
+		Timeout:         execTool.Timeout,
+		MaxOutput:       execTool.MaxOutput,
+		BlockedCommands: execTool.BlockedCommands,
+	})
+	ag.Logger = logger.With("agent", spec.ID)
+
+	if err := reg.RegisterAgent(spec, ag); err != nil {
+		return fmt.Errorf("register agent %s: %w", spec.ID, err)
+	}
+
+	// Start worker goroutine; with hibernation the registry restarts it
+	// when a message arrives for the dormant agent.
+	handle, _ := reg.GetAgent(spec.ID)
+	agentID := spec.ID
+	worker := &agent.Worker{
+		Agent:        ag,
+		Inbox:        handle.Inbox,
+		Router:       reg,
+		InFlight:     store,
+		Usage:        store,
+		HistoryLimit: cmp.Or(hs.Hive.HistoryWindow, defaultHistoryWindow),
+		IdleTimeout:  time.Duration(hs.Hive.IdleHibernateSeconds) * time.Second,
+		Hibernate:    func() bool { return reg.Hibernate(agentID) },
+		Nudge:        h.nudge(),
+	}
+	reg.StartWorker(spec.ID, func() {
+		go safeGo(logger, agentID, func() { worker.Start(h.ctx) })
+	}, worker.Idle)
+
+	logger.Info("agent started", "agent", spec.ID, "role", spec.Role)
+
+	if _, err := reg.Startup(spec.ID); err != nil {
+		logger.Warn("startup prompt not delivered", "agent", spec.ID, "error", err)
+	}
+	return nil
+}
+
+// reload applies a reloaded config to the running hive as far as it can
+// be done live (see Registry.ApplyAgentSpecs). Agents started later are
+// built from the new config. The API front agent and the agents connectors
+// feed are kept even if the new config drops them, since the connectors
+// stay bound to them until a restart.
+func (h *hive) reload(cfg *config.Config, hs config.HiveSpec) registry.SpecChanges {
+	bound := append([]string{h.frontAgentID}, h.spec.Hive.FrontAgentIDs...)
+	c := h.spec.Connectors
+	if c.Telegram != nil {
+		bound = append(bound, frontAgentFor(h.spec, c.Telegram.AgentID))
+	}
+	if c.Slack != nil {
+		bound = append(bound, frontAgentFor(h.spec, c.Slack.AgentID))
+	}
+	if c.Discord != nil {
+		bound = append(bound, frontAgentFor(h.spec, c.Discord.AgentID))
+	}
+	if c.HTTP != nil {
+		bound = append(bound, frontAgentFor(h.spec, c.HTTP.AgentID))
+	}
+	specs := slices.Clip(hs.Agents)
+	for _, id := range bound {
+		handle, ok := h.reg.GetAgent(id)
+		if !ok || slices.ContainsFunc(specs, func(s protocol.AgentSpec) bool { return s.ID == id }) {
+			continue
+		}
+		h.logger.Warn("config reload: agent is bound to a connector, keeping it until restart", "agent", id)
+		kept := handle.Spec
+		kept.CoreInstructions, kept.ScopedContexts = handle.Agent.Instructions()
+		specs = append(specs, kept)
+	}
+
+	h.cfg, h.spec = cfg, hs
+	return h.reg.ApplyAgentSpecs(specs, h.startAgent)
+}
+
+// nudge returns the hive's nudge policy for agents that end a turn without
+// using a tool.
+func (h *hive) nudge() agent.NudgePolicy {
+	n := h.spec.Hive.Nudge
+	if n == nil {
+		return agent.NudgePolicy{}
+	}
+	return agent.NudgePolicy{Message: n.Message, MaxRetries: n.MaxRetries, AutoWrap: n.AutoWrap, DeliverOnGiveUp: n.DeliverOnGiveUp}
+}
+
+// service returns the API view of this hive.
+func (h *hive) service() *hiveServiceAdapter {
+	return &hiveServiceAdapter{reg: h.reg, store: h.store, frontAgentID: h.frontAgentID, chat: h.chat}
+}
+
+// drain stops the hive's connectors and schedules, so no new work comes in,
+// then waits up to the hive's shutdown grace period for its agents to
+// finish the messages they have. Replies still go out through the sinks.
+func (h *hive) drain() error {
+	h.stopInbound()
+	grace := time.Duration(cmp.Or(h.spec.Hive.ShutdownGraceSeconds, defaultShutdownGrace)) * time.Second
+	ctx, cancel := context.WithTimeout(context.Background(), grace)
+	defer cancel()
+	err := h.reg.Drain(ctx)
+	if h.webhooks != nil {
+		// Agents may have just sent their last results to _webhook.
+		err = errors.Join(err, h.webhooks.Wait(ctx))
+	}
+	return err
+}
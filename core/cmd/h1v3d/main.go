@@ -5,9 +5,13 @@ import (
 	"flag"
 	"fmt"
 	"log/slog"
+	"net/http"
 	"os"
 	"os/signal"
-	"path/filepath"
+	"reflect"
+	"slices"
+	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"syscall"
@@ -16,9 +20,10 @@ import (
 	"github.com/h1v3-io/h1v3/internal/agent"
 	apiPkg "github.com/h1v3-io/h1v3/internal/api"
 	"github.com/h1v3-io/h1v3/internal/config"
-	"github.com/h1v3-io/h1v3/internal/connector"
-	"github.com/h1v3-io/h1v3/internal/connector/telegram"
+	"github.com/h1v3-io/h1v3/internal/connector/httpchat"
+	"github.com/h1v3-io/h1v3/internal/connector/webhook"
 	"github.com/h1v3-io/h1v3/internal/logbuf"
+	"github.com/h1v3-io/h1v3/internal/logging"
 	"github.com/h1v3-io/h1v3/internal/memory"
 	"github.com/h1v3-io/h1v3/internal/provider"
 	"github.com/h1v3-io/h1v3/internal/registry"
@@ -27,22 +32,28 @@ import (
 	"github.com/h1v3-io/h1v3/pkg/protocol"
 )
 
+// logBufferSize is how many recent log entries /api/logs can return.
+const logBufferSize = 2000
+
 func main() {
 	configPath := flag.String("config", "", "Path to config JSON file")
 	platformURL := flag.String("platform-url", os.Getenv("H1V3_PLATFORM_URL"), "Platform dashboard URL")
 	hiveID := flag.String("hive-id", os.Getenv("H1V3_HIVE_ID"), "Hive ID for platform mode")
 	platformKey := flag.String("platform-key", os.Getenv("H1V3_PLATFORM_KEY"), "API key for platform auth")
+	platformSecret := flag.String("platform-signing-secret", os.Getenv("H1V3_PLATFORM_SIGNING_SECRET"), "Require platform responses signed with this HMAC secret")
 	verbose := flag.Bool("v", false, "Verbose logging")
+	pidFile := flag.String("pid-file", "", "Write the process ID to this file while running")
 	flag.Parse()
 
-	// Set up logging
+	// Set up logging: JSON to stdout until the config says otherwise.
 	logLevel := slog.LevelInfo
 	if *verbose {
 		logLevel = slog.LevelDebug
 	}
-	logBuf := logbuf.New(2000)
+	logBuf := logbuf.New(logBufferSize)
 	jsonHandler := slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: logLevel})
-	logger := slog.New(logbuf.NewHandler(jsonHandler, logBuf))
+	logHandler := logbuf.NewHandler(jsonHandler, logBuf)
+	logger := slog.New(logHandler)
 
 	// Load config (3 modes: file, platform, env)
 	var cfg *config.Config
@@ -52,9 +63,11 @@ func main() {
 	} else if *platformURL != "" {
 		logger.Info("loading config from platform", "url", *platformURL, "hive_id", *hiveID)
 		cfg, err = config.LoadFromPlatform(config.PlatformOptions{
-			PlatformURL: *platformURL,
-			HiveID:      *hiveID,
-			APIKey:      *platformKey,
+			PlatformURL:   *platformURL,
+			HiveID:        *hiveID,
+			APIKey:        *platformKey,
+			SigningSecret: *platformSecret,
+			Logger:        logger,
 		})
 	} else {
 		cfg, err = config.LoadFromEnv()
@@ -64,283 +77,236 @@ func main() {
 		os.Exit(1)
 	}
 
-	logger.Info("h1v3d starting", "hive_id", cfg.Hive.ID)
-
-	// 1. Initialize provider(s)
-	providers := make(map[string]provider.Provider)
-	for name, pcfg := range cfg.Providers {
-		switch pcfg.Type {
-		case "anthropic":
-			var opts []provider.AnthropicOption
-			if pcfg.BaseURL != "" {
-				opts = append(opts, provider.WithAnthropicBaseURL(pcfg.BaseURL))
-			}
-			if pcfg.Model != "" {
-				opts = append(opts, provider.WithAnthropicModel(pcfg.Model))
-			}
-			providers[name] = provider.NewAnthropic(pcfg.APIKey, opts...)
-		default: // "openai" or empty
-			var opts []provider.OpenAIOption
-			if pcfg.BaseURL != "" {
-				opts = append(opts, provider.WithBaseURL(pcfg.BaseURL))
+	// Switch to the configured log format and destination. Output changes
+	// take effect on restart; of the logging settings, SIGHUP only reloads
+	// redaction (see reload below for what else it picks up).
+	outHandler, logOut, err := logging.NewHandler(logging.Options{
+		Format:     cfg.Logging.Format,
+		File:       cfg.Logging.File,
+		MaxSizeMB:  cfg.Logging.MaxSizeMB,
+		MaxBackups: cfg.Logging.MaxBackups,
+		MaxAgeDays: cfg.Logging.MaxAgeDays,
+		Level:      logLevel,
+	})
+	if err != nil {
+		logger.Error("invalid logging config", "error", err)
+		os.Exit(1)
+	}
+	defer logOut.Close()
+	var logs apiPkg.LogQuerier
+	if cfg.Logging.BufferEnabled() {
+		if path := cfg.Logging.BufferFile; path != "" {
+			persistent, err := logbuf.NewPersistent(logBufferSize, path)
+			if err != nil {
+				logger.Error("failed to open log buffer file", "path", path, "error", err)
+				os.Exit(1)
 			}
-			if pcfg.Model != "" {
-				opts = append(opts, provider.WithModel(pcfg.Model))
+			defer persistent.Close()
+			for _, e := range logBuf.Query(logbuf.Filter{MinLevel: slog.LevelDebug}) {
+				persistent.Write(e)
 			}
-			providers[name] = provider.NewOpenAI(pcfg.APIKey, opts...)
+			logBuf = persistent
 		}
-		logger.Info("provider initialized", "name", name, "type", pcfg.Type, "model", pcfg.Model)
+		logs = logBuf
+	} else {
+		logBuf = nil
 	}
+	logHandler = logbuf.NewHandler(outHandler, logBuf)
+	logger = slog.New(logHandler)
 
-	defaultProv, ok := providers["default"]
-	if !ok {
-		logger.Error("no 'default' provider configured")
+	redactor, err := logbuf.NewRedactor(cfg.Logging.RedactPatterns, cfg.Secrets())
+	if err != nil {
+		logger.Error("invalid log redaction config", "error", err)
 		os.Exit(1)
 	}
+	logHandler.SetRedactor(redactor)
 
-	// 2. Initialize ticket store + registry
-	dbPath := cfg.Hive.DataDir + "/tickets.db"
-	os.MkdirAll(cfg.Hive.DataDir, 0o755)
-	store, err := ticket.NewSQLiteStore(dbPath)
+	logger.Info("h1v3d starting", "hive_id", cfg.Hive.ID)
+
+	// Outbound HTTP (proxy, CA, pooling) shared by providers and tools.
+	httpTransport, err := cfg.HTTP.Transport()
 	if err != nil {
-		logger.Error("failed to open ticket store", "path", dbPath, "error", err)
+		logger.Error("invalid http config", "error", err)
 		os.Exit(1)
 	}
-	// store will be cleaned up when the process exits
+	providerClient := cfg.HTTP.Client(httpTransport, 120*time.Second)
+	toolClient := cfg.HTTP.Client(httpTransport, 30*time.Second)
 
-	reg := registry.New(store, logger)
+	// 1. Initialize provider(s)
+	providers := make(map[string]provider.Provider)
+	for name, pcfg := range cfg.Providers {
+		providers[name] = newProvider(name, pcfg, providerClient, *verbose, logHandler.Redact, logger)
+	}
+	if _, ok := providers["default"]; !ok {
+		logger.Error("no 'default' provider configured")
+		os.Exit(1)
+	}
 
-	// Context for graceful shutdown
+	// 2. Start hives (one per tenant; a plain config is a single hive)
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
-	// 3. Register agents from config
-	for _, spec := range cfg.Agents {
-		// Create per-agent memory store
-		mem := memory.NewStore(spec.Directory)
-
-		// Create per-agent tool registry with whitelist/blacklist gating
-		agentTools := tool.NewRegistry()
-		register := func(t tool.Tool) {
-			if spec.ToolAllowed(t.Name()) {
-				agentTools.Register(t)
-			}
-		}
-		register(&tool.ReadFileTool{AllowedDir: spec.Directory})
-		register(&tool.WriteFileTool{AllowedDir: spec.Directory})
-		register(&tool.EditFileTool{AllowedDir: spec.Directory})
-		register(&tool.ListDirTool{AllowedDir: spec.Directory})
-		register(&tool.ExecTool{WorkDir: spec.Directory})
-		register(&tool.WebFetchTool{})
-		if cfg.Tools.BraveAPIKey != "" {
-			register(&tool.WebSearchTool{APIKey: cfg.Tools.BraveAPIKey})
-		}
-		// Memory tools bound to this agent's store
-		register(&tool.ReadMemoryTool{Store: mem})
-		register(&tool.WriteMemoryTool{Store: mem})
-		register(&tool.ListMemoryTool{Store: mem})
-		register(&tool.DeleteMemoryTool{Store: mem})
-		// Hive discovery
-		register(&tool.ListAgentsTool{Lister: &agentListerAdapter{reg: reg}})
-		// Ticket tools — create, respond, close, search
-		broker := &ticketBrokerAdapter{reg: reg}
-		lister := &agentListerAdapter{reg: reg}
-		register(&tool.CreateTicketTool{Broker: broker, AgentID: spec.ID, Agents: lister})
-		register(&tool.RespondToTicketTool{Broker: broker, AgentID: spec.ID, Logger: logger.With("agent", spec.ID)})
-		register(&tool.CloseTicketTool{Broker: broker, AgentID: spec.ID})
-		register(&tool.SearchTicketsTool{Broker: broker, AgentID: spec.ID})
-		register(&tool.GetTicketTool{Broker: broker})
-		register(&tool.WaitTool{})
-
-		// Select provider: per-agent override, then "default"
-		prov := defaultProv
-		if spec.Provider != "" {
-			if p, ok := providers[spec.Provider]; ok {
-				prov = p
-			}
+	var hives []*hive
+	for _, hs := range cfg.HiveSpecs() {
+		h, err := startHive(ctx, cfg, hs, providers, toolClient, logger)
+		if err != nil {
+			logger.Error("failed to start hive", "hive", hs.Hive.ID, "error", err)
+			os.Exit(1)
 		}
+		hives = append(hives, h)
+		logger.Info("hive started", "hive", h.id)
+	}
 
-		ag := agent.New(spec, prov, agentTools)
-		ag.Memory = mem
-		// Skill dirs: shared (dataDir) and agent-specific (dir) are scanned as {dir}/skills/.
-		// Extra skill_paths from preset are resolved per-agent and scanned directly.
-		// e.g. skill_paths: [".moltbot/skills"] → scans {agentDir}/.moltbot/skills/
-		skillDirs := []string{cfg.Hive.DataDir, spec.Directory}
-		var extraSkillDirs []string
-		for _, rel := range cfg.Hive.SkillPaths {
-			extraSkillDirs = append(extraSkillDirs, filepath.Join(spec.Directory, rel))
-		}
-		ag.SkillDirs = skillDirs
-		ag.ExtraSkillDirs = extraSkillDirs
-		register(&tool.LoadSkillTool{Provider: &agent.DynamicSkillProvider{Dirs: skillDirs, ExtraDirs: extraSkillDirs}})
-		ag.Logger = logger.With("agent", spec.ID)
+	// 3. Start API server. Unscoped routes serve the first hive; every hive
+	// is also reachable under /api/hives/{hiveID}/.
+	var apiTokens []apiPkg.Token
+	for _, t := range cfg.API.Tokens {
+		apiTokens = append(apiTokens, apiPkg.Token{Key: t.Key, Scope: t.Scope})
+	}
+	apiSrv := apiPkg.NewServer(hives[0].service(), apiPkg.Config{
+		Host:   cfg.API.Host,
+		Port:   cfg.API.Port,
+		Key:    cfg.API.Key,
+		Tokens: apiTokens,
+	}, logger.With("component", "api"), logs)
+	for _, h := range hives {
+		apiSrv.AddHive(h.id, h.service())
+	}
 
-		if err := reg.RegisterAgent(spec, ag); err != nil {
-			logger.Error("failed to register agent", "agent", spec.ID, "error", err)
-			os.Exit(1)
-		}
+	apiCtx, stopAPI := context.WithCancel(ctx)
+	go safeGo(logger, "api-server", func() { apiSrv.Start(apiCtx) })
+	logger.Info("api server started", "port", cfg.API.Port)
 
-		// Start worker goroutine
-		handle, _ := reg.GetAgent(spec.ID)
-		worker := &agent.Worker{
-			Agent:  ag,
-			Inbox:  handle.Inbox,
-			Router: reg,
+	// The pid file is written once startup can no longer fail, so the
+	// os.Exit paths above never leave a stale one behind.
+	if *pidFile != "" {
+		if err := writePIDFile(*pidFile); err != nil {
+			logger.Error("failed to write pid file", "path", *pidFile, "error", err)
+			os.Exit(1)
 		}
-		go safeGo(logger, spec.ID, func() { worker.Start(ctx) })
-
-		logger.Info("agent started", "agent", spec.ID, "role", spec.Role)
+		defer os.Remove(*pidFile)
 	}
 
-	// 4. Start connectors
-	if cfg.Connectors.Telegram != nil {
-		// Determine which agent handles Telegram messages
-		frontID := cfg.Connectors.Telegram.AgentID
-		if frontID == "" && len(cfg.Agents) > 0 {
-			frontID = cfg.Agents[0].ID
+	// 4. Signals: SIGHUP reloads config, SIGINT/SIGTERM shut down gracefully
+	reload := func() {
+		if *configPath == "" {
+			logger.Warn("config reload needs --config, ignoring SIGHUP")
+			return
 		}
-
-		if _, ok := reg.GetAgent(frontID); !ok {
-			logger.Warn("telegram agent not found, telegram connector will not start", "agent_id", frontID)
-		} else {
-			// Forward-declare tgConn so the handler/sink closures can reference it
-			var tgConn *telegram.Connector
-
-			// telegramSink delivers messages to Telegram when an agent
-			// routes to "_external" via respond_to_ticket.
-			sink := &telegramSink{
-				ticketToChat: make(map[string]string),
-				getTicket:    reg.GetTicket,
-				logger:       logger.With("component", "telegram-sink"),
-			}
-			sink.send = func(ctx context.Context, msg connector.OutboundMessage) error {
-				return tgConn.Send(ctx, msg)
-			}
-			reg.RegisterSink("_external", sink)
-
-			// SessionManager routes inbound messages to the front agent's inbox.
-			sm := agent.NewSessionManager(frontID, reg, logger.With("component", "session-manager"))
-			sm.OnSessionCreated = func(chatID, ticketID string) {
-				sink.MapTicket(ticketID, chatID)
+		newCfg, err := config.Load(*configPath)
+		if err != nil {
+			logger.Error("config reload failed, keeping current config", "error", err)
+			return
+		}
+		redactor, err := logbuf.NewRedactor(newCfg.Logging.RedactPatterns, newCfg.Secrets())
+		if err != nil {
+			logger.Error("config reload failed, keeping current config", "error", err)
+			return
+		}
+		logHandler.SetRedactor(redactor)
+
+		// New providers are added; changing or removing one needs a
+		// restart, since agents hold on to the old one.
+		for name, pcfg := range newCfg.Providers {
+			old, ok := cfg.Providers[name]
+			switch {
+			case !ok:
+				providers[name] = newProvider(name, pcfg, providerClient, *verbose, logHandler.Redact, logger)
+				cfg.Providers[name] = pcfg
+			case !reflect.DeepEqual(old, pcfg):
+				logger.Warn("config reload: provider changed, restart to apply", "provider", name)
 			}
-			sm.OnSessionClosed = func(chatID string) {
-				sink.UnmapChat(chatID)
+		}
+		for name := range cfg.Providers {
+			if _, ok := newCfg.Providers[name]; !ok {
+				logger.Warn("config reload: provider removed, restart to apply", "provider", name)
 			}
+		}
 
-			tgHandler := func(ctx context.Context, msg connector.InboundMessage) error {
-				cmd := msg.Content
-				if cmd == "/new" || cmd == "/start" {
-					sm.CloseSession(msg.ChatID)
-					return tgConn.Send(ctx, connector.OutboundMessage{
-						ChatID:  msg.ChatID,
-						Content: "Starting a new conversation. Send me your message!",
-					})
-				}
-				if cmd == "/parallel" || strings.HasPrefix(cmd, "/parallel ") {
-					text := strings.TrimPrefix(cmd, "/parallel")
-					text = strings.TrimSpace(text)
-					if text == "" {
-						text = "New parallel conversation"
-					}
-					ticketID, err := sm.StartParallelSession(msg.ChatID, text)
-					if err != nil {
-						return tgConn.Send(ctx, connector.OutboundMessage{
-							ChatID:  msg.ChatID,
-							Content: fmt.Sprintf("Failed to create parallel session: %v", err),
-						})
-					}
-					_ = tgConn.Send(ctx, connector.OutboundMessage{
-						ChatID:  msg.ChatID,
-						Content: fmt.Sprintf("Parallel conversation started (ticket %s). Send your message!", ticketID),
-					})
-					if text != "New parallel conversation" {
-						return sm.HandleInbound(msg.ChatID, text)
-					}
-					return nil
-				}
-				if strings.HasPrefix(cmd, "/close ") {
-					ticketID := strings.TrimSpace(strings.TrimPrefix(cmd, "/close"))
-					if ticketID == "" {
-						return tgConn.Send(ctx, connector.OutboundMessage{
-							ChatID:  msg.ChatID,
-							Content: "Usage: /close <ticket_id>",
-						})
-					}
-					if err := sm.CloseTicket(ticketID, "manually closed via /close"); err != nil {
-						return tgConn.Send(ctx, connector.OutboundMessage{
-							ChatID:  msg.ChatID,
-							Content: fmt.Sprintf("Failed to close ticket: %v", err),
-						})
-					}
-					return tgConn.Send(ctx, connector.OutboundMessage{
-						ChatID:  msg.ChatID,
-						Content: fmt.Sprintf("Ticket %s closed.", ticketID),
-					})
-				}
-				if strings.HasPrefix(cmd, "/ticket ") {
-					rest := strings.TrimSpace(strings.TrimPrefix(cmd, "/ticket"))
-					parts := strings.SplitN(rest, " ", 2)
-					if len(parts) < 2 || parts[0] == "" || parts[1] == "" {
-						return tgConn.Send(ctx, connector.OutboundMessage{
-							ChatID:  msg.ChatID,
-							Content: "Usage: /ticket <ticket_id> <message>",
-						})
-					}
-					ticketID, text := parts[0], parts[1]
-					if err := sm.SendToTicket(ticketID, text); err != nil {
-						return tgConn.Send(ctx, connector.OutboundMessage{
-							ChatID:  msg.ChatID,
-							Content: fmt.Sprintf("Failed to send to ticket: %v", err),
-						})
-					}
-					return nil
-				}
-				return sm.HandleInbound(msg.ChatID, msg.Content)
+		// Agents: instructions, new and removed agents; see hive.reload.
+		newSpecs := newCfg.HiveSpecs()
+		for _, h := range hives {
+			i := slices.IndexFunc(newSpecs, func(hs config.HiveSpec) bool { return hs.Hive.ID == h.id })
+			if i < 0 {
+				logger.Warn("config reload: hive removed, restart to apply", "hive", h.id)
+				continue
 			}
-
-			var tgErr error
-			tgConn, tgErr = telegram.New(
-				telegram.Config{
-					Token:     cfg.Connectors.Telegram.Token,
-					AllowFrom: cfg.Connectors.Telegram.AllowFrom,
-				},
-				tgHandler,
-				logger.With("connector", "telegram"),
-			)
-			if tgErr != nil {
-				logger.Error("failed to init telegram connector", "error", tgErr)
-				os.Exit(1)
+			changes := h.reload(newCfg, newSpecs[i])
+			logger.Info("config reload: hive updated", "hive", h.id,
+				"updated", changes.Updated, "added", changes.Added, "removed", changes.Removed, "skipped", len(changes.Skipped))
+		}
+		for _, hs := range newSpecs {
+			if !slices.ContainsFunc(hives, func(h *hive) bool { return h.id == hs.Hive.ID }) {
+				logger.Warn("config reload: new hive, restart to start it", "hive", hs.Hive.ID)
 			}
-
-			go safeGo(logger, "telegram", func() { tgConn.Start(ctx) })
-			logger.Info("telegram connector started")
 		}
+		logger.Info("config reloaded", "path", *configPath)
 	}
 
-	// 5. Start API server
-	apiFrontID := cfg.Hive.FrontAgentID
-	if apiFrontID == "" && len(cfg.Agents) > 0 {
-		apiFrontID = cfg.Agents[0].ID
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
+	for sig := range sigCh {
+		if sig == syscall.SIGHUP {
+			logger.Info("received SIGHUP, reloading config")
+			reload()
+			continue
+		}
+		logger.Info("received signal, shutting down", "signal", sig)
+		break
 	}
-	apiSvc := &hiveServiceAdapter{reg: reg, store: store, frontAgentID: apiFrontID}
-	apiSrv := apiPkg.NewServer(apiSvc, apiPkg.Config{
-		Host: cfg.API.Host,
-		Port: cfg.API.Port,
-		Key:  cfg.API.Key,
-	}, logger.With("component", "api"), logBuf)
-
-	go safeGo(logger, "api-server", func() { apiSrv.Start(ctx) })
-	logger.Info("api server started", "port", cfg.API.Port)
 
-	// 6. Graceful shutdown
-	sigCh := make(chan os.Signal, 1)
-	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
-	sig := <-sigCh
-	logger.Info("received signal, shutting down", "signal", sig)
+	// Drain: stop taking new messages from the API and connectors, let the
+	// agents finish what they hold, then cancel whatever is left.
+	stopAPI()
+	var wg sync.WaitGroup
+	for _, h := range hives {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := h.drain(); err != nil {
+				logger.Warn("shutdown grace period expired, cancelling remaining work", "hive", h.id, "error", err)
+			} else {
+				logger.Info("hive drained", "hive", h.id)
+			}
+		}()
+	}
+	wg.Wait()
 	cancel()
 	logger.Info("h1v3d stopped")
 }
 
+// modelCapabilities applies a provider's configured capability overrides on
+// top of the built-in entry for its model.
+func modelCapabilities(pcfg config.ProviderConfig) provider.Capabilities {
+	caps, _ := provider.LookupCapabilities(pcfg.Model)
+	o := pcfg.Capabilities
+	if o.SupportsTools != nil {
+		caps.Tools = *o.SupportsTools
+	}
+	if o.SupportsJSON != nil {
+		caps.JSON = *o.SupportsJSON
+	}
+	if o.SupportsVision != nil {
+		caps.Vision = *o.SupportsVision
+	}
+	if o.MaxContext > 0 {
+		caps.MaxContext = o.MaxContext
+	}
+	if o.MaxOutput > 0 {
+		caps.MaxOutput = o.MaxOutput
+	}
+	return caps
+}
+
+// writePIDFile writes the current process ID to path, failing if the file
+// cannot be created.
+func writePIDFile(path string) error {
+	if err := os.WriteFile(path, []byte(strconv.Itoa(os.Getpid())+"\n"), 0o644); err != nil {
+		return fmt.Errorf("pid file: %w", err)
+	}
+	return nil
+}
+
 // safeGo runs fn with panic recovery.
 func safeGo(logger *slog.Logger, name string, fn func()) {
 	defer func() {
@@ -356,6 +322,7 @@ type hiveServiceAdapter struct {
 	reg          *registry.Registry
 	store        ticket.Store
 	frontAgentID string
+	chat         *httpchat.Connector // nil unless connectors.http is set
 }
 
 func (h *hiveServiceAdapter) ListAgents() []apiPkg.AgentInfo {
@@ -364,8 +331,9 @@ func (h *hiveServiceAdapter) ListAgents() []apiPkg.AgentInfo {
 	for i, id := range ids {
 		handle, _ := h.reg.GetAgent(id)
 		agents[i] = apiPkg.AgentInfo{
-			ID:   id,
-			Role: handle.Spec.Role,
+			ID:     id,
+			Role:   handle.Spec.Role,
+			Status: handle.State(),
 		}
 	}
 	return agents
@@ -377,8 +345,9 @@ func (h *hiveServiceAdapter) GetAgent(id string) (*apiPkg.AgentInfo, bool) {
 		return nil, false
 	}
 	return &apiPkg.AgentInfo{
-		ID:   id,
-		Role: handle.Spec.Role,
+		ID:     id,
+		Role:   handle.Spec.Role,
+		Status: handle.State(),
 	}, true
 }
 
@@ -390,77 +359,119 @@ func (h *hiveServiceAdapter) GetTicket(id string) (*protocol.Ticket, error) {
 	return h.reg.GetTicket(id)
 }
 
+func (h *hiveServiceAdapter) TicketEvents(id string) ([]protocol.TicketEvent, error) {
+	return h.reg.TicketEvents(id)
+}
+
+func (h *hiveServiceAdapter) SubscribeTicket(id string) (<-chan protocol.Message, func()) {
+	return h.reg.Subscribe(id)
+}
+
+func (h *hiveServiceAdapter) ListDeadLetters() ([]ticket.DeadLetter, error) {
+	return h.reg.ListDeadLetters()
+}
+
+func (h *hiveServiceAdapter) ReplayDeadLetter(id string) error {
+	return h.reg.ReplayDeadLetter(id)
+}
+
+func (h *hiveServiceAdapter) TicketUsage(id string) (*protocol.TicketUsage, error) {
+	return h.reg.TicketUsage(id)
+}
+
+func (h *hiveServiceAdapter) AgentMemory(id string) (map[string]string, error) {
+	mem, err := h.agentMemory(id)
+	if err != nil {
+		return nil, err
+	}
+	return mem.List(), nil
+}
+
+func (h *hiveServiceAdapter) SetAgentMemory(id, scope, content string) error {
+	mem, err := h.agentMemory(id)
+	if err != nil {
+		return err
+	}
+	if content == "" {
+		return mem.Delete(scope)
+	}
+	return mem.Set(scope, content)
+}
+
+func (h *hiveServiceAdapter) AgentToolStats(id string) ([]tool.ToolStat, error) {
+	handle, ok := h.reg.GetAgent(id)
+	if !ok {
+		return nil, fmt.Errorf("agent %q not found", id)
+	}
+	if handle.Agent == nil || handle.Agent.Tools == nil {
+		return nil, nil
+	}
+	return handle.Agent.Tools.Usage().Stats(), nil
+}
+
+// agentMemory returns the memory store the agent's tools and prompt use, so
+// API writes share its lock and show up on the agent's next turn.
+func (h *hiveServiceAdapter) agentMemory(id string) (*memory.Store, error) {
+	handle, ok := h.reg.GetAgent(id)
+	if !ok {
+		return nil, fmt.Errorf("agent %q not found", id)
+	}
+	if handle.Agent == nil || handle.Agent.Memory == nil {
+		return nil, fmt.Errorf("agent %q has no memory store", id)
+	}
+	return handle.Agent.Memory, nil
+}
+
 func (h *hiveServiceAdapter) InjectMessage(from, ticketID, content string) (string, error) {
 	if from == "" {
 		from = "api"
 	}
 
+	msg := protocol.Message{
+		From:      from,
+		To:        []string{h.frontAgentID},
+		Content:   content,
+		TicketID:  ticketID,
+		Timestamp: time.Now(),
+	}
+
 	// Auto-create a ticket if none provided
 	if ticketID == "" {
-		t, err := h.reg.CreateTicket(from, content, "", "", []string{h.frontAgentID}, nil)
+		t, err := h.reg.CreateAndRoute(from, content, "", "", []string{h.frontAgentID}, nil, 0, nil, msg)
 		if err != nil {
 			return "", fmt.Errorf("create ticket: %w", err)
 		}
-		ticketID = t.ID
+		return t.ID, nil
 	}
+	return ticketID, h.reg.RouteMessage(msg)
+}
 
+func (h *hiveServiceAdapter) CreateTicket(req apiPkg.CreateTicketRequest) (*protocol.Ticket, error) {
 	msg := protocol.Message{
-		From:      from,
-		To:        []string{h.frontAgentID},
-		Content:   content,
-		TicketID:  ticketID,
+		From:      req.From,
+		To:        req.To,
+		Content:   req.Message,
 		Timestamp: time.Now(),
 	}
-	return ticketID, h.reg.RouteMessage(msg)
+	return h.reg.CreateAndRoute(req.From, req.Title, req.Goal, "", req.To, req.Tags, req.Priority, req.DueAt, msg)
 }
 
-// telegramSink implements registry.Sink — delivers messages to Telegram
-// by looking up the chat ID for the message's ticket.
-type telegramSink struct {
-	mu           sync.Mutex
-	ticketToChat map[string]string // ticketID → chatID
-	send         func(ctx context.Context, msg connector.OutboundMessage) error
-	getTicket    func(ticketID string) (*protocol.Ticket, error)
-	logger       *slog.Logger
+func (h *hiveServiceAdapter) CloseTicket(id, summary string) error {
+	return h.reg.CloseTicket(id, summary, "api")
 }
 
-func (s *telegramSink) Deliver(msg protocol.Message) error {
-	s.mu.Lock()
-	chatID, ok := s.ticketToChat[msg.TicketID]
-	s.mu.Unlock()
-	if !ok {
-		s.logger.Warn("no chat mapping for ticket", "ticket", msg.TicketID)
-		return fmt.Errorf("telegram sink: no chat mapping for ticket %s", msg.TicketID)
-	}
-
-	// Prepend ticket ID and title so the user knows which conversation this belongs to.
-	content := msg.Content
-	if s.getTicket != nil {
-		if tk, err := s.getTicket(msg.TicketID); err == nil {
-			content = fmt.Sprintf("[`%s` — %s]\n%s", tk.ID, tk.Title, content)
-		}
+func (h *hiveServiceAdapter) ChatPost(ctx context.Context, sessionID, content, eventID string) (int64, error) {
+	if h.chat == nil {
+		return 0, apiPkg.ErrChatDisabled
 	}
-
-	return s.send(context.Background(), connector.OutboundMessage{
-		ChatID:  chatID,
-		Content: content,
-	})
+	return h.chat.Post(ctx, sessionID, content, eventID)
 }
 
-func (s *telegramSink) MapTicket(ticketID, chatID string) {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-	s.ticketToChat[ticketID] = chatID
-}
-
-func (s *telegramSink) UnmapChat(chatID string) {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-	for tid, cid := range s.ticketToChat {
-		if cid == chatID {
-			delete(s.ticketToChat, tid)
-		}
+func (h *hiveServiceAdapter) ChatWait(ctx context.Context, sessionID string, cursor int64) ([]httpchat.Reply, error) {
+	if h.chat == nil {
+		return nil, apiPkg.ErrChatDisabled
 	}
+	return h.chat.WaitAfter(ctx, sessionID, cursor), nil
 }
 
 // agentListerAdapter implements tool.AgentLister using the registry.
@@ -476,21 +487,66 @@ func (a *agentListerAdapter) ListAgentInfo() []tool.AgentInfo {
 		if !ok {
 			continue
 		}
+		core, _ := handle.Agent.Instructions()
+		agents = append(agents, tool.AgentInfo{
+			ID:      id,
+			Role:    handle.Spec.Role,
+			Summary: capabilitySummary(core),
+		})
+	}
+	// The outbound webhook sink is addressable like an agent, so tickets
+	// can be created for it.
+	if a.reg.HasSink(webhook.SinkName) {
 		agents = append(agents, tool.AgentInfo{
-			ID:   id,
-			Role: handle.Spec.Role,
+			ID:      webhook.SinkName,
+			Role:    "outbound webhook",
+			Summary: "Posts the ticket's messages to an external URL. Tag the ticket " + webhook.TicketTagPrefix + "<endpoint> to pick the endpoint.",
 		})
 	}
 	return agents
 }
 
+func (a *agentListerAdapter) AgentProfile(id string) (*tool.AgentProfile, bool) {
+	handle, ok := a.reg.GetAgent(id)
+	if !ok {
+		return nil, false
+	}
+	tools := handle.Agent.Tools.List()
+	sort.Strings(tools)
+	core, _ := handle.Agent.Instructions()
+	profile := &tool.AgentProfile{
+		ID:             id,
+		Role:           handle.Spec.Role,
+		Summary:        capabilitySummary(core),
+		State:          handle.State(),
+		Tools:          tools,
+		CanDelegateTo:  handle.Spec.CanDelegateTo,
+		CanReceiveFrom: handle.Spec.CanReceiveFrom,
+	}
+	for _, sk := range agent.LoadSkills(handle.Agent.SkillDirs, handle.Agent.ExtraSkillDirs).All() {
+		profile.Skills = append(profile.Skills, tool.SkillBrief{Slug: sk.Slug, Description: sk.Description})
+	}
+	return profile, true
+}
+
+// capabilitySummary returns the first paragraph of an agent's core
+// instructions, shortened to a line other agents can skim.
+func capabilitySummary(instructions string) string {
+	para, _, _ := strings.Cut(strings.TrimSpace(instructions), "\n\n")
+	para = strings.Join(strings.Fields(para), " ")
+	if r := []rune(para); len(r) > 200 {
+		para = string(r[:197]) + "..."
+	}
+	return para
+}
+
 // ticketBrokerAdapter implements tool.TicketBroker using the registry.
 type ticketBrokerAdapter struct {
 	reg *registry.Registry
 }
 
-func (b *ticketBrokerAdapter) CreateTicket(from, title, goal, parentID string, to, tags []string) (*protocol.Ticket, error) {
-	return b.reg.CreateTicket(from, title, goal, parentID, to, tags)
+func (b *ticketBrokerAdapter) CreateAndRoute(from, title, goal, parentID string, to, tags []string, priority int, dueAt *time.Time, msg protocol.Message) (*protocol.Ticket, error) {
+	return b.reg.CreateAndRoute(from, title, goal, parentID, to, tags, priority, dueAt, msg)
 }
 
 func (b *ticketBrokerAdapter) GetTicket(ticketID string) (*protocol.Ticket, error) {
@@ -505,14 +561,140 @@ func (b *ticketBrokerAdapter) CountTickets(filter ticket.Filter) (int, error) {
 	return b.reg.CountTickets(filter)
 }
 
-func (b *ticketBrokerAdapter) CloseTicket(ticketID, summary string) error {
-	return b.reg.CloseTicket(ticketID, summary)
+func (b *ticketBrokerAdapter) CloseTicket(ticketID, summary, by string) error {
+	return b.reg.CloseTicket(ticketID, summary, by)
+}
+
+func (b *ticketBrokerAdapter) CloseTicketTree(ticketID, summary, by string) ([]string, error) {
+	return b.reg.CloseTicketTree(ticketID, summary, by)
+}
+
+func (b *ticketBrokerAdapter) ReopenTicket(ticketID, reason, by string) error {
+	return b.reg.ReopenTicket(ticketID, reason, by)
+}
+
+func (b *ticketBrokerAdapter) ReassignTicket(ticketID string, to []string, reason, by string) error {
+	return b.reg.ReassignTicket(ticketID, to, reason, by)
+}
+
+func (b *ticketBrokerAdapter) UpdateTicketStatus(ticketID string, status protocol.TicketStatus, by string) error {
+	return b.reg.UpdateTicketStatus(ticketID, status, by)
 }
 
-func (b *ticketBrokerAdapter) UpdateTicketStatus(ticketID string, status protocol.TicketStatus) error {
-	return b.reg.UpdateTicketStatus(ticketID, status)
+func (b *ticketBrokerAdapter) TicketEvents(ticketID string) ([]protocol.TicketEvent, error) {
+	return b.reg.TicketEvents(ticketID)
+}
+
+func (b *ticketBrokerAdapter) TicketUsage(ticketID string) (*protocol.TicketUsage, error) {
+	return b.reg.TicketUsage(ticketID)
 }
 
 func (b *ticketBrokerAdapter) RouteMessage(msg protocol.Message) error {
 	return b.reg.RouteMessage(msg)
 }
+
+func (b *ticketBrokerAdapter) WatchTicket(ticketID, agentID, by string) error {
+	return b.reg.WatchTicket(ticketID, agentID, by)
+}
+
+func (b *ticketBrokerAdapter) UnwatchTicket(ticketID, agentID, by string) error {
+	return b.reg.UnwatchTicket(ticketID, agentID, by)
+}
+
+// newProvider builds the named provider from its config. In verbose mode
+// raw exchanges are logged, with bodies passed through redact before they
+// are truncated, so a secret cut at the limit is still masked.
+func newProvider(name string, pcfg config.ProviderConfig, client *http.Client, verbose bool, redact func(string) string, logger *slog.Logger) provider.Provider {
+	var prov provider.Provider
+	switch pcfg.Type {
+	case "anthropic":
+		opts := []provider.AnthropicOption{provider.WithAnthropicHTTPClient(client)}
+		if pcfg.BaseURL != "" {
+			opts = append(opts, provider.WithAnthropicBaseURL(pcfg.BaseURL))
+		}
+		if pcfg.Model != "" {
+			opts = append(opts, provider.WithAnthropicModel(pcfg.Model))
+		}
+		if pcfg.MaxTokens > 0 {
+			opts = append(opts, provider.WithAnthropicMaxTokens(pcfg.MaxTokens))
+		}
+		if pcfg.PromptCaching {
+			opts = append(opts, provider.WithAnthropicPromptCaching(true))
+		}
+		if pcfg.Capabilities != nil {
+			opts = append(opts, provider.WithAnthropicCapabilities(modelCapabilities(pcfg)))
+		}
+		if pcfg.MaxRetries != nil {
+			opts = append(opts, provider.WithAnthropicMaxRetries(*pcfg.MaxRetries))
+		}
+		if pcfg.RetryBaseDelayMS > 0 {
+			opts = append(opts, provider.WithAnthropicRetryBaseDelay(time.Duration(pcfg.RetryBaseDelayMS)*time.Millisecond))
+		}
+		opts = append(opts, provider.WithAnthropicLogger(logger))
+		if verbose {
+			opts = append(opts, provider.WithAnthropicRequestLogger(provider.SlogRequestLogger(logger, redact)))
+		}
+		prov = provider.NewAnthropic(pcfg.APIKey, opts...)
+	case "ollama":
+		opts := []provider.OllamaOption{provider.WithOllamaHTTPClient(client)}
+		if pcfg.APIKey != "" {
+			opts = append(opts, provider.WithOllamaAPIKey(pcfg.APIKey))
+		}
+		if pcfg.Model != "" {
+			opts = append(opts, provider.WithOllamaModel(pcfg.Model))
+		}
+		if pcfg.MaxTokens > 0 {
+			opts = append(opts, provider.WithOllamaMaxTokens(pcfg.MaxTokens))
+		}
+		if pcfg.Capabilities != nil {
+			opts = append(opts, provider.WithOllamaCapabilities(modelCapabilities(pcfg)))
+		}
+		if pcfg.MaxRetries != nil {
+			opts = append(opts, provider.WithOllamaMaxRetries(*pcfg.MaxRetries))
+		}
+		if pcfg.RetryBaseDelayMS > 0 {
+			opts = append(opts, provider.WithOllamaRetryBaseDelay(time.Duration(pcfg.RetryBaseDelayMS)*time.Millisecond))
+		}
+		opts = append(opts, provider.WithOllamaLogger(logger))
+		if verbose {
+			opts = append(opts, provider.WithOllamaRequestLogger(provider.SlogRequestLogger(logger, redact)))
+		}
+		prov = provider.NewOllama(pcfg.BaseURL, opts...)
+	default: // "openai" or empty
+		opts := []provider.OpenAIOption{provider.WithHTTPClient(client)}
+		if pcfg.BaseURL != "" {
+			opts = append(opts, provider.WithBaseURL(pcfg.BaseURL))
+		}
+		if pcfg.Model != "" {
+			opts = append(opts, provider.WithModel(pcfg.Model))
+		}
+		if pcfg.EmbeddingModel != "" {
+			opts = append(opts, provider.WithEmbeddingModel(pcfg.EmbeddingModel))
+		}
+		if pcfg.MaxTokens > 0 {
+			opts = append(opts, provider.WithOpenAIDefaultMaxTokens(pcfg.MaxTokens))
+		}
+		if pcfg.Reasoning != nil {
+			opts = append(opts, provider.WithReasoningModel(*pcfg.Reasoning))
+		}
+		if pcfg.Capabilities != nil {
+			opts = append(opts, provider.WithOpenAICapabilities(modelCapabilities(pcfg)))
+		}
+		if pcfg.MaxRetries != nil {
+			opts = append(opts, provider.WithMaxRetries(*pcfg.MaxRetries))
+		}
+		if pcfg.RetryBaseDelayMS > 0 {
+			opts = append(opts, provider.WithRetryBaseDelay(time.Duration(pcfg.RetryBaseDelayMS)*time.Millisecond))
+		}
+		opts = append(opts, provider.WithLogger(logger))
+		if verbose {
+			opts = append(opts, provider.WithRequestLogger(provider.SlogRequestLogger(logger, redact)))
+		}
+		prov = provider.NewOpenAI(pcfg.APIKey, opts...)
+	}
+	if _, known := provider.LookupCapabilities(pcfg.Model); !known && pcfg.Capabilities == nil {
+		logger.Info("model not in capability table, assuming full support; set providers.<name>.capabilities to adapt requests", "provider", name, "model", pcfg.Model)
+	}
+	logger.Info("provider initialized", "name", name, "type", pcfg.Type, "model", pcfg.Model)
+	return prov
+}
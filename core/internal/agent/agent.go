@@ -2,6 +2,8 @@ package agent
 
 import (
 	"log/slog"
+	"sync/atomic"
+	"time"
 
 	"github.com/h1v3-io/h1v3/internal/memory"
 	"github.com/h1v3-io/h1v3/internal/provider"
@@ -11,25 +13,69 @@ import (
 
 const defaultMaxIterations = 20
 
+// defaultMaxParallelTools bounds concurrent tool calls within one turn.
+const defaultMaxParallelTools = 4
+
 // Agent is a single AI agent with its own spec, provider, and tools.
 type Agent struct {
-	Spec          protocol.AgentSpec
-	Provider      provider.Provider
-	Tools         *tool.Registry
-	Logger        *slog.Logger
-	MaxIterations int
-	Memory         *memory.Store // optional, injected at startup
-	SkillDirs      []string      // parent dirs (scanned as {dir}/skills/), reloaded each prompt
-	ExtraSkillDirs []string      // direct skill dirs (scanned as-is), from skill_paths config
+	Spec             protocol.AgentSpec
+	Provider         provider.Provider
+	Tools            *tool.Registry
+	Logger           *slog.Logger
+	MaxIterations    int
+	MaxParallelTools int           // concurrent tool calls per turn; 1 runs them sequentially
+	Memory           *memory.Store // optional, injected at startup
+	SkillDirs        []string      // parent dirs (scanned as {dir}/skills/), reloaded each prompt
+	ExtraSkillDirs   []string      // direct skill dirs (scanned as-is), from skill_paths config
+	RequestTimeout   time.Duration // per provider call (streams: between chunks); 0 = none
+
+	// CompactThreshold is the estimated prompt size, in tokens, above which
+	// the oldest messages are summarized into a note before a provider call.
+	// 0 disables compaction.
+	CompactThreshold int
+	compactions      compactionCache
+
+	// OnStream, when set, receives response text and tool calls as they are
+	// generated. With a provider that cannot stream, each response arrives
+	// as a single content chunk followed by its tool calls.
+	OnStream func(protocol.StreamChunk)
+
+	// live holds instructions set by SetInstructions; nil means Spec's.
+	live atomic.Pointer[instructions]
+}
+
+// instructions are the parts of a spec that can change while the agent runs.
+type instructions struct {
+	core   string
+	scoped map[string]string
 }
 
 // New creates a new Agent with sensible defaults.
 func New(spec protocol.AgentSpec, prov provider.Provider, tools *tool.Registry) *Agent {
 	return &Agent{
-		Spec:          spec,
-		Provider:      prov,
-		Tools:         tools,
-		Logger:        slog.Default(),
-		MaxIterations: defaultMaxIterations,
+		Spec:             spec,
+		Provider:         prov,
+		Tools:            tools,
+		Logger:           slog.Default(),
+		MaxIterations:    defaultMaxIterations,
+		MaxParallelTools: defaultMaxParallelTools,
+		RequestTimeout:   time.Duration(spec.RequestTimeoutSeconds) * time.Second,
+	}
+}
+
+// SetInstructions replaces the agent's core instructions and scoped
+// contexts, as a config reload does. It is safe to call while the agent is
+// working; prompts built afterwards use the new text. Spec keeps the values
+// the agent was created with.
+func (a *Agent) SetInstructions(core string, scoped map[string]string) {
+	a.live.Store(&instructions{core: core, scoped: scoped})
+}
+
+// Instructions returns the agent's current core instructions and scoped
+// contexts. The map must not be modified.
+func (a *Agent) Instructions() (string, map[string]string) {
+	if in := a.live.Load(); in != nil {
+		return in.core, in.scoped
 	}
+	return a.Spec.CoreInstructions, a.Spec.ScopedContexts
 }
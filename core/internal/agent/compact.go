@@ -0,0 +1,191 @@
+package agent
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/h1v3-io/h1v3/internal/provider"
+	"github.com/h1v3-io/h1v3/internal/tool"
+	"github.com/h1v3-io/h1v3/pkg/protocol"
+)
+
+// compactSummaryPrompt instructs the provider when summarizing history.
+const compactSummaryPrompt = "You compact the history of a conversation between AI agents so it fits in a smaller context. " +
+	"Summarize the transcript below, preserving decisions, open questions, action items, file paths, identifiers and results of tool calls that later steps rely on. " +
+	"If it starts with an earlier summary, merge it in. Reply with the summary only."
+
+// compactNotePrefix starts the synthetic note that replaces compacted messages.
+const compactNotePrefix = "[system] Summary of earlier conversation (older messages were compacted to save context; use get_ticket for the full history):\n"
+
+const (
+	compactMaxTokens  = 1024 // length limit for a summary
+	maxCompactCache   = 256  // cached summaries kept per agent
+	compactKeepFactor = 2    // after compacting, recent messages fill at most threshold/compactKeepFactor
+)
+
+// compaction is a cached summary of a conversation's oldest messages.
+type compaction struct {
+	last    [32]byte // hash of the last message the summary covers
+	summary string
+}
+
+// compactionCache holds the latest compaction per ticket.
+type compactionCache struct {
+	mu      sync.Mutex
+	entries map[string]compaction
+}
+
+func (c *compactionCache) get(key string) (compaction, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.entries[key]
+	return e, ok
+}
+
+func (c *compactionCache) put(key string, e compaction) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.entries == nil {
+		c.entries = make(map[string]compaction)
+	}
+	if _, ok := c.entries[key]; !ok && len(c.entries) >= maxCompactCache {
+		for k := range c.entries { // evict an arbitrary entry
+			delete(c.entries, k)
+			break
+		}
+	}
+	c.entries[key] = e
+}
+
+// compact returns messages with the oldest non-system messages replaced by
+// a summary note when their estimated size exceeds CompactThreshold. Only
+// as many recent messages as fit in half the threshold are kept. The
+// summary is cached per ticket and reused until the messages after it
+// exceed the threshold themselves, so a long ticket is not re-summarized
+// every turn; the cached summary is then extended with those messages. If
+// summarizing fails, messages are returned unchanged.
+func (a *Agent) compact(ctx context.Context, messages []protocol.ChatMessage) []protocol.ChatMessage {
+	threshold := a.CompactThreshold
+	if threshold <= 0 || provider.EstimateTokens(messages) <= threshold {
+		return messages
+	}
+
+	// Leading system messages (the system prompt) are always kept.
+	head := 0
+	for head < len(messages) && messages[head].Role == "system" {
+		head++
+	}
+	body := messages[head:]
+
+	key := tool.CurrentTicketFromContext(ctx)
+	prev, covered := "", 0 // cached summary and how many body messages it covers
+	if e, ok := a.compactions.get(key); ok {
+		for i := len(body) - 1; i >= 0; i-- {
+			if hashMessage(body[i]) == e.last {
+				prev, covered = e.summary, i+1
+				break
+			}
+		}
+	}
+	if prev != "" && provider.EstimateTokens(body[covered:]) <= threshold {
+		return compacted(messages[:head], prev, body[covered:])
+	}
+
+	split := compactSplit(body, threshold/compactKeepFactor)
+	if split <= covered {
+		// The recent messages alone are over budget; the cached summary is
+		// the best we can do.
+		if prev != "" {
+			return compacted(messages[:head], prev, body[covered:])
+		}
+		return messages
+	}
+
+	summary, err := a.summarize(ctx, prev, body[covered:split])
+	if err != nil {
+		a.Logger.Warn("history compaction failed, sending full history",
+			"agent", a.Spec.ID,
+			"ticket", key,
+			"error", err,
+		)
+		return messages
+	}
+	a.compactions.put(key, compaction{last: hashMessage(body[split-1]), summary: summary})
+	a.Logger.Info("compacted conversation history",
+		"agent", a.Spec.ID,
+		"ticket", key,
+		"messages", split,
+		"kept", len(body)-split,
+	)
+	return compacted(messages[:head], summary, body[split:])
+}
+
+// compactSplit returns the index in body where the kept recent messages
+// start: as many as fit in budget, at least the last one. A tool result is
+// never separated from the assistant message that called it.
+func compactSplit(body []protocol.ChatMessage, budget int) int {
+	split := len(body) - 1
+	for split > 0 && provider.EstimateTokens(body[split-1:]) <= budget {
+		split--
+	}
+	for split > 0 && body[split].Role == "tool" {
+		split--
+	}
+	return split
+}
+
+// compacted assembles head, a summary note, and the kept messages.
+func compacted(head []protocol.ChatMessage, summary string, rest []protocol.ChatMessage) []protocol.ChatMessage {
+	out := make([]protocol.ChatMessage, 0, len(head)+1+len(rest))
+	out = append(out, head...)
+	out = append(out, protocol.ChatMessage{Role: "system", Content: compactNotePrefix + summary})
+	return append(out, rest...)
+}
+
+// summarize asks the agent's provider to fold msgs into prev, an earlier
+// summary (may be empty).
+func (a *Agent) summarize(ctx context.Context, prev string, msgs []protocol.ChatMessage) (string, error) {
+	var conv strings.Builder
+	if prev != "" {
+		fmt.Fprintf(&conv, "Earlier summary:\n%s\n\nLater messages:\n", prev)
+	}
+	for _, m := range msgs {
+		fmt.Fprintf(&conv, "[%s]: %s\n", m.Role, m.Content)
+		for _, tc := range m.ToolCalls {
+			args, _ := json.Marshal(tc.Arguments)
+			fmt.Fprintf(&conv, "[%s called %s]: %s\n", m.Role, tc.Name, args)
+		}
+	}
+
+	temperature := 0.2
+	req := protocol.ChatRequest{
+		Model: a.Spec.Model,
+		Messages: []protocol.ChatMessage{
+			{Role: "system", Content: compactSummaryPrompt},
+			{Role: "user", Content: conv.String()},
+		},
+		MaxTokens:   compactMaxTokens,
+		Temperature: &temperature,
+	}
+	resp, err := a.withRequestTimeout(ctx, func(ctx context.Context, _ func()) (*protocol.ChatResponse, error) {
+		return a.Provider.Chat(ctx, req)
+	})
+	if err != nil {
+		return "", err
+	}
+	reportUsage(ctx, resp)
+	summary := strings.TrimSpace(resp.Content)
+	if summary == "" {
+		return "", fmt.Errorf("empty summary")
+	}
+	return summary, nil
+}
+
+func hashMessage(m protocol.ChatMessage) [32]byte {
+	data, _ := json.Marshal(m)
+	return sha256.Sum256(data)
+}
@@ -0,0 +1,114 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/h1v3-io/h1v3/internal/tool"
+	"github.com/h1v3-io/h1v3/pkg/protocol"
+)
+
+// longHistory returns a system prompt followed by n alternating messages of
+// about 100 estimated tokens each.
+func longHistory(n int) []protocol.ChatMessage {
+	msgs := []protocol.ChatMessage{{Role: "system", Content: "You are a coder."}}
+	for i := range n {
+		role := "user"
+		if i%2 == 1 {
+			role = "assistant"
+		}
+		msgs = append(msgs, protocol.ChatMessage{Role: role, Content: fmt.Sprintf("message %d: %s", i, strings.Repeat("word ", 75))})
+	}
+	return msgs
+}
+
+func TestRunWithHistory_CompactsLongHistory(t *testing.T) {
+	prov := &mockProvider{responses: []*protocol.ChatResponse{
+		{Content: "The agents agreed to refactor the parser."},
+		{Content: "done"},
+	}}
+	a := &Agent{
+		Spec:             protocol.AgentSpec{ID: "coder"},
+		Provider:         prov,
+		Tools:            tool.NewRegistry(),
+		Logger:           slog.Default(),
+		CompactThreshold: 1000,
+	}
+
+	history := longHistory(100) // ~10000 tokens
+	ctx := tool.WithCurrentTicket(context.Background(), "t-1")
+	if _, err := a.RunWithHistory(ctx, history); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+
+	if len(prov.calls) != 2 {
+		t.Fatalf("expected a summary call and a chat call, got %d calls", len(prov.calls))
+	}
+	if summaryReq := prov.calls[0]; !strings.Contains(summaryReq.Messages[1].Content, "message 0:") {
+		t.Errorf("expected the oldest messages to be summarized, got %q", summaryReq.Messages[1].Content)
+	}
+	sent := prov.calls[1].Messages
+	if len(sent) >= len(history) {
+		t.Fatalf("expected fewer messages after compaction, sent %d of %d", len(sent), len(history))
+	}
+	if sent[0].Content != "You are a coder." {
+		t.Errorf("expected the system prompt kept first, got %q", sent[0].Content)
+	}
+	if sent[1].Role != "system" || !strings.Contains(sent[1].Content, "refactor the parser") {
+		t.Errorf("expected the summary note after the system prompt, got %+v", sent[1])
+	}
+	if last := sent[len(sent)-1].Content; last != history[len(history)-1].Content {
+		t.Errorf("expected the newest message kept, got %q", last)
+	}
+
+	// The next turn reuses the cached summary instead of summarizing again.
+	prov.responses = append(prov.responses, &protocol.ChatResponse{Content: "again"})
+	history = append(history, protocol.ChatMessage{Role: "user", Content: "one more thing"})
+	if _, err := a.RunWithHistory(ctx, history); err != nil {
+		t.Fatalf("second run: %v", err)
+	}
+	if len(prov.calls) != 3 {
+		t.Fatalf("expected one more chat call and no new summary, got %d calls", len(prov.calls))
+	}
+	if sent := prov.calls[2].Messages; len(sent) >= len(history) || !strings.HasPrefix(sent[1].Content, compactNotePrefix) {
+		t.Errorf("expected the cached summary in the second turn, sent %d messages", len(sent))
+	}
+}
+
+func TestCompact_BelowThresholdOrDisabled(t *testing.T) {
+	prov := &mockProvider{}
+	a := &Agent{Provider: prov, Logger: slog.Default()}
+	history := longHistory(20)
+	if got := a.compact(context.Background(), history); len(got) != len(history) {
+		t.Errorf("expected no compaction with threshold 0, got %d messages", len(got))
+	}
+	a.CompactThreshold = 100000
+	if got := a.compact(context.Background(), history); len(got) != len(history) {
+		t.Errorf("expected no compaction below threshold, got %d messages", len(got))
+	}
+	if len(prov.calls) != 0 {
+		t.Errorf("expected no provider calls, got %d", len(prov.calls))
+	}
+}
+
+func TestCompact_FailureSendsFullHistory(t *testing.T) {
+	a := &Agent{Provider: &mockProvider{}, Logger: slog.Default(), CompactThreshold: 500}
+	history := longHistory(20)
+	if got := a.compact(context.Background(), history); len(got) != len(history) {
+		t.Errorf("expected the full history when summarizing fails, got %d messages", len(got))
+	}
+}
+
+func TestCompactSplit_KeepsToolResultsWithTheirCall(t *testing.T) {
+	body := []protocol.ChatMessage{
+		{Role: "user", Content: strings.Repeat("word ", 500)},
+		{Role: "assistant", ToolCalls: []protocol.ToolCall{{ID: "c1", Name: "read_file"}}},
+		{Role: "tool", Content: strings.Repeat("line ", 500), ToolCallID: "c1"},
+	}
+	if split := compactSplit(body, 100); split != 1 {
+		t.Errorf("expected the split before the tool call, got %d", split)
+	}
+}
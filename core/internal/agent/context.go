@@ -2,6 +2,8 @@ package agent
 
 import (
 	"fmt"
+	"maps"
+	"slices"
 	"sort"
 	"strings"
 	"time"
@@ -9,11 +11,27 @@ import (
 	"github.com/h1v3-io/h1v3/pkg/protocol"
 )
 
+// DefaultRules are the "Core Behavior" rules every agent gets unless its spec
+// sets its own Rules (without KeepDefaultRules). The ticket lifecycle rules
+// that follow them are always included, since the protocol depends on them.
+var DefaultRules = []string{
+	"You are an autonomous agent. ACT, don't describe. When a task requires running commands, fetching data, reading files, or making changes — use your tools to DO it, don't explain how it could be done.",
+	"When given instructions (install steps, setup commands, scripts to run), EXECUTE them using your tools. Do not repeat instructions back to the user.",
+	"Use `exec` to run shell commands. Use `web_fetch` to retrieve URLs. Use `read_file`/`write_file` to work with files. Use tools proactively to accomplish goals.",
+	"Stay focused on the current task or ticket.",
+	"Be concise in responses — report results, not process.",
+	"Use write_memory to persist important information you learn or decide (your name, user preferences, key facts). Memory survives across sessions — anything not written to memory will be forgotten.",
+	"Your home directory is `~`. Use it for storing files and installing tools.",
+}
+
 // BuildSystemPrompt assembles the system prompt from layered context.
 // The ticket parameter is optional — pass nil for non-ticket interactions.
-// subTickets are child tickets of the current ticket (may be nil).
+// subTickets are child tickets of the current ticket (may be nil). Nothing
+// here changes from one turn to the next on its own, so the prompt can be
+// served from the provider's prompt cache; per-turn details go in TurnNote.
 func (a *Agent) BuildSystemPrompt(ticket *protocol.Ticket, subTickets []*protocol.Ticket) string {
 	var b strings.Builder
+	core, scoped := a.Instructions()
 
 	// 1. Agent identity
 	fmt.Fprintf(&b, "# Agent: %s\n", a.Spec.ID)
@@ -21,22 +39,19 @@ func (a *Agent) BuildSystemPrompt(ticket *protocol.Ticket, subTickets []*protoco
 		fmt.Fprintf(&b, "Role: %s\n", a.Spec.Role)
 	}
 	b.WriteString("\n")
-	b.WriteString(a.Spec.CoreInstructions)
+	b.WriteString(core)
 	b.WriteString("\n\n")
 
-	// 2. Current time
-	now := time.Now()
-	fmt.Fprintf(&b, "# Current Time\n%s\n\n", now.Format("2006-01-02 15:04:05 MST"))
-
-	// 3. Scoped contexts (memory, config, etc.)
-	if len(a.Spec.ScopedContexts) > 0 {
+	// 2. Scoped contexts (memory, config, etc.), sorted so the prompt is
+	// the same from turn to turn and stays cacheable
+	if len(scoped) > 0 {
 		b.WriteString("# Context\n")
-		for scope, content := range a.Spec.ScopedContexts {
-			fmt.Fprintf(&b, "## %s\n%s\n\n", scope, content)
+		for _, scope := range slices.Sorted(maps.Keys(scoped)) {
+			fmt.Fprintf(&b, "## %s\n%s\n\n", scope, scoped[scope])
 		}
 	}
 
-	// 3b. Dynamic memory (from memory store)
+	// 2b. Dynamic memory (from memory store)
 	if a.Memory != nil {
 		scopes := a.Memory.List()
 		if len(scopes) > 0 {
@@ -52,7 +67,7 @@ func (a *Agent) BuildSystemPrompt(ticket *protocol.Ticket, subTickets []*protoco
 		}
 	}
 
-	// 3c. Skills (reloaded from disk each time to pick up new installs)
+	// 2c. Skills (reloaded from disk each time to pick up new installs)
 	if len(a.SkillDirs) > 0 || len(a.ExtraSkillDirs) > 0 {
 		skills := LoadSkills(a.SkillDirs, a.ExtraSkillDirs)
 		if summary := skills.BuildSkillsSummary(); summary != "" {
@@ -68,7 +83,7 @@ func (a *Agent) BuildSystemPrompt(ticket *protocol.Ticket, subTickets []*protoco
 		}
 	}
 
-	// 4. Ticket context
+	// 3. Ticket context
 	if ticket != nil {
 		b.WriteString("# Current Ticket\n")
 		fmt.Fprintf(&b, "ID: %s\n", ticket.ID)
@@ -78,18 +93,18 @@ func (a *Agent) BuildSystemPrompt(ticket *protocol.Ticket, subTickets []*protoco
 		}
 		fmt.Fprintf(&b, "Status: %s\n", ticket.Status)
 		fmt.Fprintf(&b, "You are: %s\n", func() string {
-			if ticket.CreatedBy == a.Spec.ID {
+			switch {
+			case ticket.CreatedBy == a.Spec.ID:
 				return "creator"
+			case ticket.IsWatcher(a.Spec.ID):
+				return "watcher (read-only: you see its messages but cannot respond or change its status; call wait unless you need to act elsewhere)"
 			}
 			return "responder"
 		}())
-		if len(ticket.Messages) > 0 {
-			fmt.Fprintf(&b, "Messages: %d\n", len(ticket.Messages))
-		}
 		b.WriteString("\n")
 	}
 
-	// 4b. Sub-tickets
+	// 3b. Sub-tickets
 	if len(subTickets) > 0 {
 		b.WriteString("# Sub-tickets\n")
 		for _, st := range subTickets {
@@ -102,7 +117,7 @@ func (a *Agent) BuildSystemPrompt(ticket *protocol.Ticket, subTickets []*protoco
 		b.WriteString("\n")
 	}
 
-	// 5. Available tools
+	// 4. Available tools
 	toolNames := a.Tools.List()
 	if len(toolNames) > 0 {
 		b.WriteString("# Available Tools\n")
@@ -113,16 +128,20 @@ func (a *Agent) BuildSystemPrompt(ticket *protocol.Ticket, subTickets []*protoco
 		b.WriteString("\n")
 	}
 
-	// 6. Platform rules
+	// 5. Platform rules
 	b.WriteString("# Rules\n")
-	b.WriteString("\n## Core Behavior\n")
-	b.WriteString("- You are an autonomous agent. ACT, don't describe. When a task requires running commands, fetching data, reading files, or making changes — use your tools to DO it, don't explain how it could be done.\n")
-	b.WriteString("- When given instructions (install steps, setup commands, scripts to run), EXECUTE them using your tools. Do not repeat instructions back to the user.\n")
-	b.WriteString("- Use `exec` to run shell commands. Use `web_fetch` to retrieve URLs. Use `read_file`/`write_file` to work with files. Use tools proactively to accomplish goals.\n")
-	b.WriteString("- Stay focused on the current task or ticket.\n")
-	b.WriteString("- Be concise in responses — report results, not process.\n")
-	b.WriteString("- Use write_memory to persist important information you learn or decide (your name, user preferences, key facts). Memory survives across sessions — anything not written to memory will be forgotten.\n")
-	b.WriteString("- Your home directory is `~`. Use it for storing files and installing tools.\n")
+	if len(a.Spec.Rules) == 0 || a.Spec.KeepDefaultRules {
+		b.WriteString("\n## Core Behavior\n")
+		for _, rule := range DefaultRules {
+			fmt.Fprintf(&b, "- %s\n", rule)
+		}
+	}
+	if len(a.Spec.Rules) > 0 {
+		b.WriteString("\n## Operating Rules\n")
+		for _, rule := range a.Spec.Rules {
+			fmt.Fprintf(&b, "- %s\n", rule)
+		}
+	}
 	b.WriteString("\n# Ticket Lifecycle\n")
 	b.WriteString("- Always respond to tickets using respond_to_ticket (it automatically targets the current ticket). Do not output bare text as a response — use the tool so you can set goal_met when appropriate.\n")
 	b.WriteString("- To delegate work to another agent, use create_ticket with a clear title and a concrete goal (the specific condition that would satisfy the ticket). Use the optional `message` field to pass supporting data (e.g. research results, context) so the assignee has everything in the first message.\n")
@@ -137,7 +156,7 @@ func (a *Agent) BuildSystemPrompt(ticket *protocol.Ticket, subTickets []*protoco
 	b.WriteString("- IMPORTANT: When your response fully satisfies the ticket's goal, you MUST set `goal_met=true` on `respond_to_ticket`. This is required — without it the creator will not know the work is done.\n")
 
 	// Prominent reminder for the active responder
-	if ticket != nil && ticket.CreatedBy != a.Spec.ID && ticket.Goal != "" && ticket.Status == protocol.TicketOpen {
+	if ticket != nil && ticket.CreatedBy != a.Spec.ID && !ticket.IsWatcher(a.Spec.ID) && ticket.Goal != "" && ticket.Status == protocol.TicketOpen {
 		b.WriteString("\n## REMINDER: You are the responder on this ticket.\n")
 		fmt.Fprintf(&b, "The goal is: %s\n", ticket.Goal)
 		b.WriteString("When your response satisfies this goal, call `respond_to_ticket` with `goal_met=true`. Do NOT omit this flag.\n")
@@ -160,5 +179,24 @@ func (a *Agent) BuildSystemPrompt(ticket *protocol.Ticket, subTickets []*protoco
 		b.WriteString("Do NOT create sub-tickets unless absolutely necessary.\n")
 	}
 
+	// 6. Output format
+	if instr := a.outputInstruction(); instr != "" {
+		b.WriteString("\n# Output Format\n")
+		b.WriteString(instr)
+		b.WriteString("\n")
+	}
+
 	return b.String()
 }
+
+// TurnNote carries the details that change every turn, the current time and
+// the ticket's total message count (including any left out of the history
+// window), which the worker sends after the conversation rather than in the
+// system prompt so the prompt stays cacheable.
+func TurnNote(messages int, now time.Time) string {
+	note := "[system]: Current time: " + now.Format("2006-01-02 15:04:05 MST") + "."
+	if messages > 0 {
+		note += fmt.Sprintf(" Messages on this ticket: %d.", messages)
+	}
+	return note
+}
@@ -2,8 +2,11 @@ package agent
 
 import (
 	"log/slog"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/h1v3-io/h1v3/internal/memory"
 	"github.com/h1v3-io/h1v3/internal/tool"
@@ -33,14 +36,60 @@ func TestBuildSystemPrompt_Basic(t *testing.T) {
 	if !strings.Contains(prompt, "You write Go code.") {
 		t.Error("expected core instructions in prompt")
 	}
-	if !strings.Contains(prompt, "# Current Time") {
-		t.Error("expected current time section")
+	if strings.Contains(prompt, "# Current Time") {
+		t.Error("the current time belongs in the turn note, not the system prompt")
 	}
 	if !strings.Contains(prompt, "# Rules") {
 		t.Error("expected rules section")
 	}
 }
 
+func TestBuildSystemPrompt_OutputSchema(t *testing.T) {
+	a := &Agent{
+		Spec:   protocol.AgentSpec{ID: "classifier"},
+		Tools:  tool.NewRegistry(),
+		Logger: slog.Default(),
+	}
+	if prompt := a.BuildSystemPrompt(nil, nil); strings.Contains(prompt, "# Output Format") {
+		t.Error("expected no output format section without an output schema")
+	}
+
+	a.Spec.OutputSchema = map[string]any{"type": "object", "required": []any{"label"}}
+	prompt := a.BuildSystemPrompt(nil, nil)
+	if !strings.Contains(prompt, "# Output Format\nRespond with a single valid JSON object") {
+		t.Errorf("expected the JSON instruction, got:\n%s", prompt)
+	}
+	if !strings.Contains(prompt, `"required":["label"]`) {
+		t.Error("expected the schema in the output format section")
+	}
+}
+
+func TestBuildSystemPrompt_Rules(t *testing.T) {
+	a := &Agent{Spec: protocol.AgentSpec{ID: "coder"}, Tools: tool.NewRegistry(), Logger: slog.Default()}
+	prompt := a.BuildSystemPrompt(nil, nil)
+	if !strings.Contains(prompt, "## Core Behavior\n- "+DefaultRules[0]) || strings.Contains(prompt, "## Operating Rules") {
+		t.Error("expected only the built-in rules when none are configured")
+	}
+
+	a.Spec.Rules = []string{"Never delete files.", "Always cite sources."}
+	prompt = a.BuildSystemPrompt(nil, nil)
+	if !strings.Contains(prompt, "## Operating Rules\n- Never delete files.\n- Always cite sources.\n") {
+		t.Errorf("expected configured rules, got:\n%s", prompt)
+	}
+	if strings.Contains(prompt, DefaultRules[0]) {
+		t.Error("configured rules should replace the built-in ones")
+	}
+	if !strings.Contains(prompt, "# Ticket Lifecycle") {
+		t.Error("ticket lifecycle rules must always be included")
+	}
+
+	a.Spec.KeepDefaultRules = true
+	prompt = a.BuildSystemPrompt(nil, nil)
+	if !strings.Contains(prompt, DefaultRules[0]) || !strings.Contains(prompt, "- Never delete files.") {
+		t.Error("expected both built-in and configured rules with keep_default_rules")
+	}
+}
+
 func TestBuildSystemPrompt_WithScopedContexts(t *testing.T) {
 	reg := tool.NewRegistry()
 	a := &Agent{
@@ -101,8 +150,25 @@ func TestBuildSystemPrompt_WithTicket(t *testing.T) {
 	if !strings.Contains(prompt, "Title: Fix the bug") {
 		t.Error("expected ticket title")
 	}
-	if !strings.Contains(prompt, "Messages: 2") {
-		t.Error("expected message count")
+	if note := TurnNote(len(ticket.Messages), time.Date(2026, 3, 1, 9, 30, 0, 0, time.UTC)); note != "[system]: Current time: 2026-03-01 09:30:00 UTC. Messages on this ticket: 2." {
+		t.Errorf("unexpected turn note %q", note)
+	}
+}
+
+func TestBuildSystemPrompt_StableAcrossTurns(t *testing.T) {
+	a := &Agent{
+		Spec:   protocol.AgentSpec{ID: "agent1", CoreInstructions: "test"},
+		Tools:  tool.NewRegistry(),
+		Logger: slog.Default(),
+	}
+	ticket := &protocol.Ticket{ID: "t-001", Title: "Fix the bug", Status: protocol.TicketOpen, CreatedBy: "lead",
+		Messages: []protocol.Message{{Content: "msg1"}}}
+
+	first := a.BuildSystemPrompt(ticket, nil)
+	time.Sleep(1100 * time.Millisecond) // past a second boundary
+	ticket.Messages = append(ticket.Messages, protocol.Message{Content: "msg2"})
+	if second := a.BuildSystemPrompt(ticket, nil); second != first {
+		t.Errorf("system prompt changed between turns, breaking the prompt cache:\n%s\n---\n%s", first, second)
 	}
 }
 
@@ -194,6 +260,36 @@ func TestBuildSystemPrompt_WithMemoryEmpty(t *testing.T) {
 	}
 }
 
+func TestBuildSystemPrompt_ReloadsEditedSkills(t *testing.T) {
+	dir := setupSkillsDir(t)
+	a := &Agent{
+		Spec:      protocol.AgentSpec{ID: "writer"},
+		Tools:     tool.NewRegistry(),
+		Logger:    slog.Default(),
+		SkillDirs: []string{dir},
+	}
+
+	prompt := a.BuildSystemPrompt(nil, nil)
+	if !strings.Contains(prompt, "Use active voice") {
+		t.Fatalf("expected the always-loaded skill in the prompt, got:\n%s", prompt)
+	}
+
+	// Edit the skill on disk between turns; the next prompt picks it up
+	// without a reload.
+	skill := filepath.Join(dir, "skills", "writing-style", "SKILL.md")
+	if err := os.WriteFile(skill, []byte("---\nname: Writing Style\nalways_load: true\n---\n\nPrefer plain words.\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	prompt = a.BuildSystemPrompt(nil, nil)
+	if !strings.Contains(prompt, "Prefer plain words.") {
+		t.Errorf("expected the edited skill in the prompt, got:\n%s", prompt)
+	}
+	if strings.Contains(prompt, "Use active voice") {
+		t.Error("the old skill text should be gone")
+	}
+}
+
 func TestBuildSystemPrompt_NoTicketNoContexts(t *testing.T) {
 	reg := tool.NewRegistry()
 	a := &Agent{
@@ -1,7 +1,10 @@
 package agent
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"log/slog"
+	"slices"
 	"sync"
 	"time"
 
@@ -13,20 +16,48 @@ type ExternalRouter interface {
 	RouteMessage(msg protocol.Message) error
 	GetTicket(ticketID string) (*protocol.Ticket, error)
 	CreateTicket(from, title, goal, parentID string, to []string, tags []string) (*protocol.Ticket, error)
-	CloseTicket(ticketID, summary string) error
+	CloseTicket(ticketID, summary, by string) error
 }
 
+// ReplyPolicy decides which front agent's replies reach the external user
+// when a session fans out to several front agents.
+type ReplyPolicy string
+
+const (
+	// ReplyPrimary forwards only replies from FrontAgentID; the other front
+	// agents are effectively CC'd. This is the default.
+	ReplyPrimary ReplyPolicy = "primary"
+	// ReplyFirst forwards replies from whichever front agent answers an
+	// inbound message first; the others' replies to it are dropped.
+	ReplyFirst ReplyPolicy = "first"
+)
+
 // SessionManager tracks external chat sessions and routes inbound messages
 // to the front agent's inbox via RouteMessage (async — no inline LLM execution).
 type SessionManager struct {
 	FrontAgentID     string
+	Connector        string // connector name, recorded as a "connector:" tag on session tickets
 	Router           ExternalRouter
 	Logger           *slog.Logger
 	OnSessionCreated func(chatID, ticketID string)
 	OnSessionClosed  func(chatID string)
 
+	// DedupWindow drops an inbound message that repeats an event ID or the
+	// exact content (and attachments) seen for the same chat within the
+	// window. Zero disables dedup.
+	DedupWindow time.Duration
+
+	// CCAgentIDs are extra front agents that are assigned to every session
+	// ticket and receive every inbound message alongside FrontAgentID.
+	// ReplyPolicy picks whose replies are forwarded to the user; see
+	// AllowReply.
+	CCAgentIDs  []string
+	ReplyPolicy ReplyPolicy
+
 	mu       sync.Mutex
-	sessions map[string]string // chatID → ticketID
+	sessions map[string]string    // chatID → ticketID
+	seen     map[string]time.Time // dedup key → first seen
+	repliers map[string]string    // ticketID → front agent answering the latest inbound (ReplyFirst)
 }
 
 // NewSessionManager creates a SessionManager for the given front agent.
@@ -36,38 +67,150 @@ func NewSessionManager(frontAgentID string, router ExternalRouter, logger *slog.
 		Router:       router,
 		Logger:       logger,
 		sessions:     make(map[string]string),
+		seen:         make(map[string]time.Time),
+		repliers:     make(map[string]string),
+	}
+}
+
+// frontAgents returns FrontAgentID followed by the CC agents, without
+// duplicates.
+func (sm *SessionManager) frontAgents() []string {
+	ids := []string{sm.FrontAgentID}
+	for _, id := range sm.CCAgentIDs {
+		if !slices.Contains(ids, id) {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// AllowReply reports whether a message addressed to the external user should
+// be forwarded to them. Messages from agents other than the front agents are
+// always forwarded. With a single front agent every reply is forwarded.
+func (sm *SessionManager) AllowReply(msg protocol.Message) bool {
+	fronts := sm.frontAgents()
+	if len(fronts) == 1 || !slices.Contains(fronts, msg.From) {
+		return true
+	}
+	if sm.ReplyPolicy != ReplyFirst {
+		return msg.From == sm.FrontAgentID
+	}
+
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	if replier, ok := sm.repliers[msg.TicketID]; ok && replier != msg.From {
+		return false
+	}
+	sm.repliers[msg.TicketID] = msg.From
+	return true
+}
+
+// routeToFront sends an external message to all front agents, opening the
+// floor for the ReplyFirst race again.
+func (sm *SessionManager) routeToFront(msg protocol.Message) error {
+	msg.To = sm.frontAgents()
+	sm.mu.Lock()
+	delete(sm.repliers, msg.TicketID)
+	sm.mu.Unlock()
+	return sm.Router.RouteMessage(msg)
+}
+
+// HandleInbound routes an external message, with any attachments, to the
+// front agent's inbox. It returns immediately — the agent processes the
+// message asynchronously.
+func (sm *SessionManager) HandleInbound(chatID, content string, attachments ...protocol.Attachment) error {
+	return sm.HandleInboundEvent(chatID, "", content, attachments...)
+}
+
+// HandleInboundEvent is HandleInbound for connectors that know the platform's
+// event ID. With DedupWindow set, a redelivered event is dropped by its ID as
+// well as by content.
+func (sm *SessionManager) HandleInboundEvent(chatID, eventID, content string, attachments ...protocol.Attachment) error {
+	keys, dup := sm.checkDuplicate(chatID, eventID, content, attachments)
+	if dup {
+		sm.Logger.Info("dropping duplicate inbound message", "chat_id", chatID, "event_id", eventID)
+		return nil
+	}
+
+	if err := sm.routeInbound(chatID, content, attachments); err != nil {
+		// Let a redelivery of a failed message through.
+		sm.forget(keys)
+		return err
 	}
+	return nil
 }
 
-// HandleInbound routes an external message to the front agent's inbox.
-// It returns immediately — the agent processes the message asynchronously.
-func (sm *SessionManager) HandleInbound(chatID, content string) error {
+func (sm *SessionManager) routeInbound(chatID, content string, attachments []protocol.Attachment) error {
 	ticketID, err := sm.getOrCreateSession(chatID, content)
 	if err != nil {
 		return err
 	}
 
 	msg := protocol.Message{
-		From:      "_external",
-		To:        []string{sm.FrontAgentID},
-		Content:   content,
-		TicketID:  ticketID,
-		Timestamp: time.Now(),
+		From:        "_external",
+		Content:     content,
+		TicketID:    ticketID,
+		Timestamp:   time.Now(),
+		Attachments: attachments,
 	}
 
-	return sm.Router.RouteMessage(msg)
+	return sm.routeToFront(msg)
+}
+
+// checkDuplicate reports whether an inbound message repeats one seen within
+// DedupWindow, recording it otherwise. It returns the keys it recorded.
+func (sm *SessionManager) checkDuplicate(chatID, eventID, content string, attachments []protocol.Attachment) ([]string, bool) {
+	if sm.DedupWindow <= 0 {
+		return nil, false
+	}
+
+	h := sha256.New()
+	h.Write([]byte(chatID + "\x00" + content))
+	for _, a := range attachments {
+		h.Write([]byte("\x00" + a.Name + "\x00" + a.MimeType))
+		h.Write(a.Data)
+	}
+	keys := []string{"content:" + hex.EncodeToString(h.Sum(nil))}
+	if eventID != "" {
+		keys = append(keys, "event:"+chatID+"\x00"+eventID)
+	}
+
+	now := time.Now()
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	for k, at := range sm.seen {
+		if now.Sub(at) >= sm.DedupWindow {
+			delete(sm.seen, k)
+		}
+	}
+	for _, k := range keys {
+		if _, ok := sm.seen[k]; ok {
+			return nil, true
+		}
+	}
+	for _, k := range keys {
+		sm.seen[k] = now
+	}
+	return keys, false
+}
+
+func (sm *SessionManager) forget(keys []string) {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	for _, k := range keys {
+		delete(sm.seen, k)
+	}
 }
 
 // SendToTicket routes a message to a specific ticket, bypassing session lookup.
 func (sm *SessionManager) SendToTicket(ticketID, content string) error {
 	msg := protocol.Message{
 		From:      "_external",
-		To:        []string{sm.FrontAgentID},
 		Content:   content,
 		TicketID:  ticketID,
 		Timestamp: time.Now(),
 	}
-	return sm.Router.RouteMessage(msg)
+	return sm.routeToFront(msg)
 }
 
 // CloseSession closes the active ticket for a chat and removes the session mapping.
@@ -76,11 +219,12 @@ func (sm *SessionManager) CloseSession(chatID string) {
 	ticketID, ok := sm.sessions[chatID]
 	if ok {
 		delete(sm.sessions, chatID)
+		delete(sm.repliers, ticketID)
 	}
 	sm.mu.Unlock()
 
 	if ok {
-		if err := sm.Router.CloseTicket(ticketID, "session reset by user"); err != nil {
+		if err := sm.Router.CloseTicket(ticketID, "session reset by user", "_external"); err != nil {
 			sm.Logger.Error("failed to close ticket", "ticket", ticketID, "error", err)
 		}
 		if sm.OnSessionClosed != nil {
@@ -111,8 +255,8 @@ func (sm *SessionManager) getOrCreateSession(chatID, content string) (string, er
 		truncate(content, 60),
 		"",  // external sessions have no predefined goal
 		"",  // no parent ticket
-		[]string{sm.FrontAgentID},
-		[]string{"external", "chat:" + chatID},
+		sm.frontAgents(),
+		sm.sessionTags(chatID),
 	)
 	if err != nil {
 		return "", err
@@ -139,8 +283,8 @@ func (sm *SessionManager) StartParallelSession(chatID, content string) (string,
 		truncate(content, 60),
 		"",  // external sessions have no predefined goal
 		"",  // no parent ticket
-		[]string{sm.FrontAgentID},
-		[]string{"external", "chat:" + chatID},
+		sm.frontAgents(),
+		sm.sessionTags(chatID),
 	)
 	if err != nil {
 		return "", err
@@ -160,9 +304,19 @@ func (sm *SessionManager) StartParallelSession(chatID, content string) (string,
 	return ticket.ID, nil
 }
 
+// sessionTags labels a session ticket with its chat and, when set, its
+// connector, so replies can be routed back to the right platform.
+func (sm *SessionManager) sessionTags(chatID string) []string {
+	tags := []string{"external", "chat:" + chatID}
+	if sm.Connector != "" {
+		tags = append(tags, "connector:"+sm.Connector)
+	}
+	return tags
+}
+
 // CloseTicket closes an arbitrary ticket by ID.
 func (sm *SessionManager) CloseTicket(ticketID, summary string) error {
-	return sm.Router.CloseTicket(ticketID, summary)
+	return sm.Router.CloseTicket(ticketID, summary, "_external")
 }
 
 func truncate(s string, max int) string {
@@ -3,8 +3,10 @@ package agent
 import (
 	"fmt"
 	"log/slog"
+	"slices"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/h1v3-io/h1v3/pkg/protocol"
 )
@@ -63,7 +65,7 @@ func (r *mockExternalRouter) CreateTicket(from, title, goal, parentID string, to
 	return t, nil
 }
 
-func (r *mockExternalRouter) CloseTicket(ticketID, summary string) error {
+func (r *mockExternalRouter) CloseTicket(ticketID, summary, _ string) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 	r.closed[ticketID] = summary
@@ -154,6 +156,41 @@ func TestSessionManager_SessionReuse(t *testing.T) {
 	}
 }
 
+func TestSessionManager_Dedup(t *testing.T) {
+	sm, router := newTestSessionManager()
+	sm.DedupWindow = 50 * time.Millisecond
+
+	sm.HandleInbound("chat-1", "hello")
+	sm.HandleInbound("chat-1", "hello")          // double tap: dropped
+	sm.HandleInbound("chat-2", "hello")          // other chat: kept
+	sm.HandleInboundEvent("chat-1", "42", "hi")  // new event: kept
+	sm.HandleInboundEvent("chat-1", "42", "hi!") // redelivered event ID: dropped
+
+	ticketID, _ := sm.GetSession("chat-1")
+	if count := router.messageCount(ticketID); count != 2 {
+		t.Fatalf("expected 2 routed messages, got %d", count)
+	}
+
+	// The same question outside the window goes through.
+	time.Sleep(60 * time.Millisecond)
+	sm.HandleInbound("chat-1", "hello")
+	if count := router.messageCount(ticketID); count != 3 {
+		t.Errorf("expected repeat outside window to be routed, got %d messages", count)
+	}
+}
+
+func TestSessionManager_DedupDisabled(t *testing.T) {
+	sm, router := newTestSessionManager()
+
+	sm.HandleInbound("chat-1", "hello")
+	sm.HandleInbound("chat-1", "hello")
+
+	ticketID, _ := sm.GetSession("chat-1")
+	if count := router.messageCount(ticketID); count != 2 {
+		t.Errorf("expected both messages without a dedup window, got %d", count)
+	}
+}
+
 func TestSessionManager_CloseSession(t *testing.T) {
 	sm, router := newTestSessionManager()
 
@@ -229,3 +266,87 @@ func TestTruncate(t *testing.T) {
 		t.Errorf("expected truncated string, got %q", got)
 	}
 }
+
+func TestSessionManager_FanOut(t *testing.T) {
+	sm, router := newTestSessionManager()
+	sm.CCAgentIDs = []string{"front", "logger"}
+
+	if err := sm.HandleInbound("chat-1", "hello"); err != nil {
+		t.Fatalf("handle inbound: %v", err)
+	}
+	ticketID, _ := sm.GetSession("chat-1")
+
+	tk, _ := router.GetTicket(ticketID)
+	if fmt.Sprint(tk.WaitingOn) != "[front logger]" {
+		t.Errorf("expected ticket assigned to [front logger], got %v", tk.WaitingOn)
+	}
+	if msg := router.lastMessage(ticketID); fmt.Sprint(msg.To) != "[front logger]" {
+		t.Errorf("expected message to [front logger], got %v", msg.To)
+	}
+}
+
+func TestSessionManager_AllowReply(t *testing.T) {
+	reply := func(from string) protocol.Message {
+		return protocol.Message{From: from, To: []string{"_external"}, TicketID: "t-001"}
+	}
+
+	t.Run("single front forwards everything", func(t *testing.T) {
+		sm, _ := newTestSessionManager()
+		if !sm.AllowReply(reply("front")) || !sm.AllowReply(reply("other")) {
+			t.Error("expected all replies forwarded")
+		}
+	})
+
+	t.Run("primary", func(t *testing.T) {
+		sm, _ := newTestSessionManager()
+		sm.CCAgentIDs = []string{"logger"}
+		if !sm.AllowReply(reply("front")) {
+			t.Error("expected primary reply forwarded")
+		}
+		if sm.AllowReply(reply("logger")) {
+			t.Error("expected CC reply suppressed")
+		}
+		if !sm.AllowReply(reply("coder")) {
+			t.Error("expected non-front reply forwarded")
+		}
+	})
+
+	t.Run("first", func(t *testing.T) {
+		sm, _ := newTestSessionManager()
+		sm.CCAgentIDs = []string{"logger"}
+		sm.ReplyPolicy = ReplyFirst
+		if err := sm.SendToTicket("t-001", "question"); err != nil {
+			t.Fatalf("send: %v", err)
+		}
+		if !sm.AllowReply(reply("logger")) || !sm.AllowReply(reply("logger")) {
+			t.Error("expected first replier's messages forwarded")
+		}
+		if sm.AllowReply(reply("front")) {
+			t.Error("expected later replier suppressed")
+		}
+
+		// A new inbound message reopens the race.
+		if err := sm.SendToTicket("t-001", "follow-up"); err != nil {
+			t.Fatalf("send: %v", err)
+		}
+		if !sm.AllowReply(reply("front")) {
+			t.Error("expected front to win after new inbound message")
+		}
+	})
+}
+
+func TestSessionManager_ConnectorTag(t *testing.T) {
+	sm, router := newTestSessionManager()
+	sm.Connector = "slack"
+
+	sm.HandleInbound("C1:171.2", "Hello")
+	ticketID, _ := sm.GetSession("C1:171.2")
+	tk, err := router.GetTicket(ticketID)
+	if err != nil {
+		t.Fatalf("get ticket: %v", err)
+	}
+	want := []string{"external", "chat:C1:171.2", "connector:slack"}
+	if !slices.Equal(tk.Tags, want) {
+		t.Errorf("expected tags %v, got %v", want, tk.Tags)
+	}
+}
@@ -0,0 +1,37 @@
+package agent
+
+import (
+	"os"
+	"strings"
+
+	"github.com/h1v3-io/h1v3/pkg/protocol"
+)
+
+// maxImageBytes is the largest attached image shown to the model inline,
+// the Anthropic API's per-image limit. Larger ones stay readable through
+// read_attachment.
+const maxImageBytes = 5 << 20
+
+// imageParts returns content followed by the message's image attachments as
+// chat message parts, or nil if it has none that can be loaded. Providers
+// whose model has no vision drop the parts and send content alone.
+func imageParts(content string, attachments []protocol.Attachment) []protocol.ContentPart {
+	var images []protocol.ContentPart
+	for _, a := range attachments {
+		if !strings.HasPrefix(a.MimeType, "image/") {
+			continue
+		}
+		data := a.Data
+		if data == nil && a.Path != "" && a.Size <= maxImageBytes {
+			data, _ = os.ReadFile(a.Path)
+		}
+		if len(data) == 0 || len(data) > maxImageBytes {
+			continue
+		}
+		images = append(images, protocol.ContentPart{Type: protocol.PartImage, MimeType: a.MimeType, Data: data})
+	}
+	if len(images) == 0 {
+		return nil
+	}
+	return append([]protocol.ContentPart{{Type: protocol.PartText, Text: content}}, images...)
+}
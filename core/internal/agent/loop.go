@@ -3,8 +3,12 @@ package agent
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"sync"
+	"time"
 
+	"github.com/h1v3-io/h1v3/internal/provider"
 	"github.com/h1v3-io/h1v3/internal/tool"
 	"github.com/h1v3-io/h1v3/pkg/protocol"
 )
@@ -13,8 +17,12 @@ import (
 // tool calls, and loop until the LLM returns a final text response or the
 // iteration limit is reached.
 func (a *Agent) Run(ctx context.Context, userMessage string) (string, error) {
+	core, _ := a.Instructions()
+	if instr := a.outputInstruction(); instr != "" {
+		core += "\n\n" + instr
+	}
 	messages := []protocol.ChatMessage{
-		{Role: "system", Content: a.Spec.CoreInstructions},
+		{Role: "system", Content: core},
 		{Role: "user", Content: userMessage},
 	}
 	return a.runLoop(ctx, messages)
@@ -39,20 +47,25 @@ func (a *Agent) runLoop(ctx context.Context, messages []protocol.ChatMessage) (s
 		}
 
 		req := protocol.ChatRequest{
-			Messages: messages,
-			Tools:    toolDefs,
+			Model:          a.Spec.Model,
+			Messages:       a.compact(ctx, messages),
+			Tools:          toolDefs,
+			MaxTokens:      a.Spec.MaxTokens,
+			Temperature:    a.Spec.Temperature,
+			ResponseFormat: a.outputFormat(),
 		}
 
 		a.Logger.Debug("agent chat request",
 			"agent", a.Spec.ID,
 			"iteration", i+1,
-			"messages", len(messages),
+			"messages", len(req.Messages),
 		)
 
-		resp, err := a.Provider.Chat(ctx, req)
+		resp, err := a.chat(ctx, req)
 		if err != nil {
 			return "", fmt.Errorf("agent %s: provider error: %w", a.Spec.ID, err)
 		}
+		reportUsage(ctx, resp)
 
 		if !resp.HasToolCalls() {
 			a.Logger.Debug("agent final response",
@@ -70,37 +83,12 @@ func (a *Agent) runLoop(ctx context.Context, messages []protocol.ChatMessage) (s
 			ToolCalls: resp.ToolCalls,
 		})
 
-		// Execute each tool call and append results
-		ticketID := tool.CurrentTicketFromContext(ctx)
-		for _, tc := range resp.ToolCalls {
-			argsJSON, _ := json.Marshal(tc.Arguments)
-			a.Logger.Info(fmt.Sprintf("tool call: %s", tc.Name),
-				"agent", a.Spec.ID,
-				"ticket", ticketID,
-				"call_id", tc.ID,
-				"args", string(argsJSON),
-			)
-
-			result, err := a.Tools.Execute(ctx, tc.Name, tc.Arguments)
-			if err != nil {
-				// Return error as tool result so the LLM can recover
-				result = fmt.Sprintf("Error: %v", err)
-				a.Logger.Warn(fmt.Sprintf("tool error: %s", tc.Name),
-					"agent", a.Spec.ID,
-					"ticket", ticketID,
-					"error", err,
-				)
-			} else {
-				a.Logger.Info(fmt.Sprintf("tool result: %s", tc.Name),
-					"agent", a.Spec.ID,
-					"ticket", ticketID,
-					"result", result,
-				)
-			}
-
+		// Execute the tool calls and append results in call order
+		results := a.executeToolCalls(ctx, resp.ToolCalls)
+		for i, tc := range resp.ToolCalls {
 			messages = append(messages, protocol.ChatMessage{
 				Role:       "tool",
-				Content:    result,
+				Content:    results[i],
 				ToolCallID: tc.ID,
 				Name:       tc.Name,
 			})
@@ -115,3 +103,182 @@ func (a *Agent) runLoop(ctx context.Context, messages []protocol.ChatMessage) (s
 
 	return "", fmt.Errorf("agent %s: exceeded max iterations (%d)", a.Spec.ID, maxIter)
 }
+
+// usageHookKey is the context key for the function a turn's provider
+// responses are reported to.
+type usageHookKey struct{}
+
+// withUsageHook returns a context under which the agent loop passes each
+// provider response to report, for usage accounting.
+func withUsageHook(ctx context.Context, report func(*protocol.ChatResponse)) context.Context {
+	return context.WithValue(ctx, usageHookKey{}, report)
+}
+
+// reportUsage passes resp to the context's usage hook, if any.
+func reportUsage(ctx context.Context, resp *protocol.ChatResponse) {
+	if report, ok := ctx.Value(usageHookKey{}).(func(*protocol.ChatResponse)); ok {
+		report(resp)
+	}
+}
+
+// ErrRequestTimeout is returned when a provider call exceeds the agent's
+// RequestTimeout; for streamed calls, when no chunk arrives within it.
+// Like any provider error it fails the turn, so the worker retries it.
+var ErrRequestTimeout = errors.New("provider request timed out")
+
+// withRequestTimeout runs call under the agent's request timeout, if set.
+// call must invoke progress each time a stream chunk arrives, which restarts
+// the timer, so a long but steadily streaming response is not cut off.
+func (a *Agent) withRequestTimeout(ctx context.Context, call func(ctx context.Context, progress func()) (*protocol.ChatResponse, error)) (*protocol.ChatResponse, error) {
+	timeout := a.RequestTimeout
+	if timeout <= 0 {
+		return call(ctx, func() {})
+	}
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+	timer := time.AfterFunc(timeout, func() { cancel(ErrRequestTimeout) })
+	defer timer.Stop()
+
+	resp, err := call(ctx, func() { timer.Reset(timeout) })
+	if err != nil && errors.Is(context.Cause(ctx), ErrRequestTimeout) {
+		return nil, fmt.Errorf("%w: no response within %s", ErrRequestTimeout, timeout)
+	}
+	return resp, err
+}
+
+// chat sends one request to the provider, streaming it to OnStream if set.
+func (a *Agent) chat(ctx context.Context, req protocol.ChatRequest) (*protocol.ChatResponse, error) {
+	return a.withRequestTimeout(ctx, func(ctx context.Context, progress func()) (*protocol.ChatResponse, error) {
+		return a.send(ctx, req, progress)
+	})
+}
+
+func (a *Agent) send(ctx context.Context, req protocol.ChatRequest, progress func()) (*protocol.ChatResponse, error) {
+	if a.OnStream == nil {
+		return a.Provider.Chat(ctx, req)
+	}
+	if sp, ok := a.Provider.(provider.StreamingProvider); ok {
+		ch, err := sp.ChatStream(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+		return provider.CollectStream(watchStream(ctx, ch, progress), a.OnStream)
+	}
+
+	resp, err := a.Provider.Chat(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.Content != "" {
+		a.OnStream(protocol.StreamChunk{ContentDelta: resp.Content})
+	}
+	for i, tc := range resp.ToolCalls {
+		args, _ := json.Marshal(tc.Arguments)
+		a.OnStream(protocol.StreamChunk{ToolCallDelta: &protocol.ToolCallDelta{
+			Index:          i,
+			ID:             tc.ID,
+			Name:           tc.Name,
+			ArgumentsDelta: string(args),
+		}})
+	}
+	a.OnStream(protocol.StreamChunk{Done: true, Usage: &resp.Usage, Model: resp.Model})
+	return resp, nil
+}
+
+// executeToolCalls runs one turn's tool calls and returns their results in
+// call order. Calls run concurrently on up to MaxParallelTools workers, but a
+// serial tool (see tool.SerialTool) waits for the calls before it and runs
+// alone, so it sees their effects and later calls see its own.
+func (a *Agent) executeToolCalls(ctx context.Context, calls []protocol.ToolCall) []string {
+	workers := a.MaxParallelTools
+	if workers <= 0 {
+		workers = defaultMaxParallelTools
+	}
+
+	results := make([]string, len(calls))
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, workers)
+	for i, tc := range calls {
+		if workers == 1 || a.Tools.IsSerial(tc.Name) {
+			wg.Wait()
+			results[i] = a.executeToolCall(ctx, tc)
+			continue
+		}
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, tc protocol.ToolCall) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			results[i] = a.executeToolCall(ctx, tc)
+		}(i, tc)
+	}
+	wg.Wait()
+	return results
+}
+
+// executeToolCall runs a single tool call. Errors are returned as the result
+// text so the LLM can recover.
+func (a *Agent) executeToolCall(ctx context.Context, tc protocol.ToolCall) string {
+	ticketID := tool.CurrentTicketFromContext(ctx)
+	if tc.ArgumentsError != "" {
+		a.Logger.Warn(fmt.Sprintf("tool call with malformed arguments: %s", tc.Name),
+			"agent", a.Spec.ID,
+			"ticket", ticketID,
+			"call_id", tc.ID,
+			"error", tc.ArgumentsError,
+		)
+		return fmt.Sprintf("Error: the arguments for this %s call are not valid JSON (%s). The tool was not run. Call %s again with the complete arguments as a single valid JSON object.",
+			tc.Name, tc.ArgumentsError, tc.Name)
+	}
+	argsJSON, _ := json.Marshal(tc.Arguments)
+	a.Logger.Info(fmt.Sprintf("tool call: %s", tc.Name),
+		"agent", a.Spec.ID,
+		"ticket", ticketID,
+		"call_id", tc.ID,
+		"args", string(argsJSON),
+	)
+
+	result, err := a.Tools.Execute(ctx, tc.Name, tc.Arguments)
+	if err != nil {
+		a.Logger.Warn(fmt.Sprintf("tool error: %s", tc.Name),
+			"agent", a.Spec.ID,
+			"ticket", ticketID,
+			"error", err,
+		)
+		return fmt.Sprintf("Error: %v", err)
+	}
+	a.Logger.Info(fmt.Sprintf("tool result: %s", tc.Name),
+		"agent", a.Spec.ID,
+		"ticket", ticketID,
+		"result", result,
+	)
+	return result
+}
+
+// watchStream forwards ch, calling progress for each chunk, and ends the
+// stream with the context's error if ctx is done first, so a provider that
+// stops sending cannot stall the turn.
+func watchStream(ctx context.Context, ch <-chan protocol.StreamChunk, progress func()) <-chan protocol.StreamChunk {
+	out := make(chan protocol.StreamChunk)
+	go func() {
+		defer close(out)
+		for {
+			select {
+			case chunk, ok := <-ch:
+				if !ok {
+					return
+				}
+				progress()
+				select {
+				case out <- chunk:
+				case <-ctx.Done():
+					return
+				}
+			case <-ctx.Done():
+				out <- protocol.StreamChunk{Err: context.Cause(ctx)}
+				return
+			}
+		}
+	}()
+	return out
+}
@@ -2,10 +2,16 @@ package agent
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
+	"reflect"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/h1v3-io/h1v3/internal/provider"
 	"github.com/h1v3-io/h1v3/internal/tool"
 	"github.com/h1v3-io/h1v3/pkg/protocol"
 )
@@ -177,6 +183,91 @@ func TestLoop_MultipleToolCalls(t *testing.T) {
 	}
 }
 
+// slowTool records how many calls overlap and the order calls finish in.
+type slowTool struct {
+	name    string
+	serial  bool
+	mu      sync.Mutex
+	active  int
+	peak    int
+	order   *[]string
+	orderMu *sync.Mutex
+}
+
+func (t *slowTool) Name() string               { return t.name }
+func (t *slowTool) Description() string        { return "slow" }
+func (t *slowTool) Parameters() map[string]any { return map[string]any{"type": "object"} }
+func (t *slowTool) Serial() bool               { return t.serial }
+func (t *slowTool) Execute(_ context.Context, params map[string]any) (string, error) {
+	t.mu.Lock()
+	t.active++
+	if t.active > t.peak {
+		t.peak = t.active
+	}
+	t.mu.Unlock()
+
+	time.Sleep(20 * time.Millisecond)
+
+	t.mu.Lock()
+	t.active--
+	t.mu.Unlock()
+	id, _ := params["id"].(string)
+	t.orderMu.Lock()
+	*t.order = append(*t.order, id)
+	t.orderMu.Unlock()
+	return "result " + id, nil
+}
+
+func TestLoop_ParallelToolCalls(t *testing.T) {
+	var order []string
+	var orderMu sync.Mutex
+	slow := &slowTool{name: "slow", order: &order, orderMu: &orderMu}
+	mutate := &slowTool{name: "mutate", serial: true, order: &order, orderMu: &orderMu}
+
+	prov := &mockProvider{
+		responses: []*protocol.ChatResponse{
+			{
+				ToolCalls: []protocol.ToolCall{
+					{ID: "c1", Name: "mutate", Arguments: map[string]any{"id": "m1"}},
+					{ID: "c2", Name: "slow", Arguments: map[string]any{"id": "s1"}},
+					{ID: "c3", Name: "slow", Arguments: map[string]any{"id": "s2"}},
+					{ID: "c4", Name: "mutate", Arguments: map[string]any{"id": "m2"}},
+					{ID: "c5", Name: "slow", Arguments: map[string]any{"id": "s3"}},
+				},
+			},
+			{Content: "done"},
+		},
+	}
+
+	reg := tool.NewRegistry()
+	reg.Register(slow)
+	reg.Register(mutate)
+
+	a := New(protocol.AgentSpec{ID: "test"}, prov, reg)
+	if _, err := a.Run(context.Background(), "go"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if slow.peak < 2 {
+		t.Errorf("expected slow calls to overlap, peak concurrency %d", slow.peak)
+	}
+	// Serial calls keep their place: m1 before everything, m2 after s1 and
+	// s2 and before s3.
+	if len(order) != 5 || order[0] != "m1" || order[3] != "m2" || order[4] != "s3" {
+		t.Errorf("serial tools should run in call order, got %v", order)
+	}
+
+	// Results are appended in call order with their call IDs.
+	msgs := prov.calls[1].Messages[3:]
+	for i, want := range []struct{ id, content string }{
+		{"c1", "result m1"}, {"c2", "result s1"}, {"c3", "result s2"}, {"c4", "result m2"}, {"c5", "result s3"},
+	} {
+		if msgs[i].ToolCallID != want.id || msgs[i].Content != want.content {
+			t.Errorf("message %d = %s/%q, want %s/%q", i, msgs[i].ToolCallID, msgs[i].Content, want.id, want.content)
+		}
+	}
+}
+
 func TestLoop_MaxIterations(t *testing.T) {
 	// Provider always returns tool calls, never converges
 	infToolCall := &protocol.ChatResponse{
@@ -250,6 +341,70 @@ func TestLoop_UnknownTool(t *testing.T) {
 	}
 }
 
+// countingTool records how many times it ran.
+type countingTool struct {
+	echoTool
+	runs int
+}
+
+func (t *countingTool) Execute(ctx context.Context, params map[string]any) (string, error) {
+	t.runs++
+	return t.echoTool.Execute(ctx, params)
+}
+
+func TestLoop_MalformedToolArguments(t *testing.T) {
+	prov := &mockProvider{
+		responses: []*protocol.ChatResponse{
+			{
+				ToolCalls: []protocol.ToolCall{{
+					ID:             "c1",
+					Name:           "echo",
+					Arguments:      map[string]any{},
+					ArgumentsError: `unexpected end of JSON input in "{\"text\": \"hi"`,
+				}},
+			},
+			{
+				ToolCalls: []protocol.ToolCall{
+					{ID: "c2", Name: "echo", Arguments: map[string]any{"text": "hi"}},
+				},
+			},
+			{Content: "done"},
+		},
+	}
+
+	echo := &countingTool{}
+	reg := tool.NewRegistry()
+	reg.Register(echo)
+	a := &Agent{
+		Spec:          protocol.AgentSpec{ID: "test", CoreInstructions: "test"},
+		Provider:      prov,
+		Tools:         reg,
+		Logger:        slog.Default(),
+		MaxIterations: 10,
+	}
+
+	result, err := a.Run(context.Background(), "echo hi")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result != "done" {
+		t.Errorf("expected 'done', got %q", result)
+	}
+	if echo.runs != 1 {
+		t.Errorf("tool ran %d times, want 1 (only the re-emitted call)", echo.runs)
+	}
+
+	toolMsg := prov.calls[1].Messages[3]
+	if toolMsg.Role != "tool" || toolMsg.ToolCallID != "c1" {
+		t.Fatalf("expected tool result for c1, got %+v", toolMsg)
+	}
+	for _, want := range []string{"not valid JSON", "unexpected end of JSON input", "Call echo again"} {
+		if !strings.Contains(toolMsg.Content, want) {
+			t.Errorf("recovery message %q missing %q", toolMsg.Content, want)
+		}
+	}
+}
+
 func TestLoop_ContextCancelled(t *testing.T) {
 	prov := &mockProvider{
 		responses: []*protocol.ChatResponse{{Content: "should not reach"}},
@@ -272,3 +427,188 @@ func TestLoop_ContextCancelled(t *testing.T) {
 		t.Fatal("expected context cancellation error")
 	}
 }
+
+func TestLoop_SamplingFromSpec(t *testing.T) {
+	prov := &mockProvider{responses: []*protocol.ChatResponse{{Content: "ok"}}}
+	temp := 0.0
+	a := &Agent{
+		Spec:     protocol.AgentSpec{ID: "coder", Temperature: &temp, MaxTokens: 1024, Model: "claude-haiku-4-5"},
+		Provider: prov,
+		Tools:    tool.NewRegistry(),
+		Logger:   slog.Default(),
+	}
+
+	if _, err := a.Run(context.Background(), "Hi"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	req := prov.calls[0]
+	if req.Temperature == nil || *req.Temperature != 0 {
+		t.Errorf("expected temperature 0 to be sent, got %v", req.Temperature)
+	}
+	if req.MaxTokens != 1024 {
+		t.Errorf("expected max_tokens 1024, got %d", req.MaxTokens)
+	}
+	if req.Model != "claude-haiku-4-5" {
+		t.Errorf("expected the agent's model, got %q", req.Model)
+	}
+}
+
+func TestLoop_OutputSchema(t *testing.T) {
+	schema := map[string]any{"type": "object", "properties": map[string]any{"label": map[string]any{"type": "string"}}}
+	for name, tc := range map[string]struct {
+		schema map[string]any
+		want   *protocol.ResponseFormat
+	}{
+		"unset":  {nil, nil},
+		"empty":  {map[string]any{}, &protocol.ResponseFormat{Type: protocol.FormatJSONObject}},
+		"schema": {schema, &protocol.ResponseFormat{Type: protocol.FormatJSONSchema, Name: "output", Schema: schema}},
+	} {
+		prov := &mockProvider{responses: []*protocol.ChatResponse{{Content: `{"label":"bug"}`}}}
+		a := &Agent{
+			Spec:     protocol.AgentSpec{ID: "classifier", OutputSchema: tc.schema},
+			Provider: prov,
+			Tools:    tool.NewRegistry(),
+			Logger:   slog.Default(),
+		}
+		if _, err := a.Run(context.Background(), "Classify this"); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if got := prov.calls[0].ResponseFormat; !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%s: response format = %+v, want %+v", name, got, tc.want)
+		}
+		// json_object mode needs "JSON" in the prompt, so the instruction
+		// goes into the system prompt whenever a format is requested.
+		system := prov.calls[0].Messages[0].Content
+		if got := strings.Contains(system, "valid JSON object"); got != (tc.want != nil) {
+			t.Errorf("%s: JSON instruction in system prompt = %v:\n%s", name, got, system)
+		}
+	}
+}
+
+// streamingProvider streams each response as word-sized chunks.
+type streamingProvider struct {
+	mockProvider
+}
+
+func (s *streamingProvider) ChatStream(ctx context.Context, req protocol.ChatRequest) (<-chan protocol.StreamChunk, error) {
+	resp, err := s.Chat(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	ch := make(chan protocol.StreamChunk, 16)
+	for _, word := range strings.SplitAfter(resp.Content, " ") {
+		ch <- protocol.StreamChunk{ContentDelta: word}
+	}
+	for i, tc := range resp.ToolCalls {
+		ch <- protocol.StreamChunk{ToolCallDelta: &protocol.ToolCallDelta{Index: i, ID: tc.ID, Name: tc.Name, ArgumentsDelta: `{"text":"x"}`}}
+	}
+	ch <- protocol.StreamChunk{Done: true}
+	close(ch)
+	return ch, nil
+}
+
+func TestLoop_OnStream(t *testing.T) {
+	responses := func() []*protocol.ChatResponse {
+		return []*protocol.ChatResponse{
+			{ToolCalls: []protocol.ToolCall{{ID: "c1", Name: "echo", Arguments: map[string]any{"text": "x"}}}},
+			{Content: "all done now"},
+		}
+	}
+
+	for _, tc := range []struct {
+		name   string
+		prov   provider.Provider
+		deltas int
+	}{
+		{"streaming", &streamingProvider{mockProvider{responses: responses()}}, 3},
+		{"non-streaming", &mockProvider{responses: responses()}, 1},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			reg := tool.NewRegistry()
+			reg.Register(&echoTool{})
+			a := New(protocol.AgentSpec{ID: "test"}, tc.prov, reg)
+
+			var text string
+			var deltas int
+			var tools []string
+			a.OnStream = func(c protocol.StreamChunk) {
+				if c.ContentDelta != "" {
+					text += c.ContentDelta
+					deltas++
+				}
+				if c.ToolCallDelta != nil {
+					tools = append(tools, c.ToolCallDelta.Name)
+				}
+			}
+
+			result, err := a.Run(context.Background(), "go")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if result != "all done now" || text != result {
+				t.Errorf("result = %q, streamed = %q", result, text)
+			}
+			if deltas != tc.deltas {
+				t.Errorf("content chunks = %d, want %d", deltas, tc.deltas)
+			}
+			if len(tools) != 1 || tools[0] != "echo" {
+				t.Errorf("tool call chunks = %v", tools)
+			}
+		})
+	}
+}
+
+// stallingProvider blocks until the request context is done.
+type stallingProvider struct{ mockProvider }
+
+func (s *stallingProvider) Chat(ctx context.Context, _ protocol.ChatRequest) (*protocol.ChatResponse, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+// tricklingProvider streams words with a pause before each, ignoring ctx.
+type tricklingProvider struct {
+	mockProvider
+	pause time.Duration
+	words []string
+}
+
+func (s *tricklingProvider) ChatStream(_ context.Context, _ protocol.ChatRequest) (<-chan protocol.StreamChunk, error) {
+	ch := make(chan protocol.StreamChunk)
+	go func() {
+		defer close(ch)
+		for _, w := range s.words {
+			time.Sleep(s.pause)
+			ch <- protocol.StreamChunk{ContentDelta: w}
+		}
+		ch <- protocol.StreamChunk{Done: true}
+	}()
+	return ch, nil
+}
+
+func TestLoop_RequestTimeout(t *testing.T) {
+	a := New(protocol.AgentSpec{ID: "front"}, &stallingProvider{}, tool.NewRegistry())
+	a.RequestTimeout = 50 * time.Millisecond
+	_, err := a.Run(context.Background(), "hi")
+	if !errors.Is(err, ErrRequestTimeout) {
+		t.Fatalf("expected ErrRequestTimeout, got %v", err)
+	}
+
+	// A stream that keeps producing chunks outlives the timeout overall...
+	words := []string{"slow ", "but ", "steady ", "wins"}
+	a = New(protocol.AgentSpec{ID: "thinker"}, &tricklingProvider{pause: 30 * time.Millisecond, words: words}, tool.NewRegistry())
+	a.RequestTimeout = 50 * time.Millisecond
+	a.OnStream = func(protocol.StreamChunk) {}
+	result, err := a.Run(context.Background(), "hi")
+	if err != nil || result != "slow but steady wins" {
+		t.Fatalf("steady stream: result %q, err %v", result, err)
+	}
+
+	// ...but one that goes quiet longer than the timeout is cut off.
+	a = New(protocol.AgentSpec{ID: "thinker"}, &tricklingProvider{pause: 200 * time.Millisecond, words: words}, tool.NewRegistry())
+	a.RequestTimeout = 50 * time.Millisecond
+	a.OnStream = func(protocol.StreamChunk) {}
+	if _, err := a.Run(context.Background(), "hi"); !errors.Is(err, ErrRequestTimeout) {
+		t.Errorf("stalled stream: expected ErrRequestTimeout, got %v", err)
+	}
+}
@@ -1,10 +1,13 @@
 package agent
 
 import (
+	"context"
 	"os"
 	"path/filepath"
 	"strings"
 	"testing"
+
+	"github.com/h1v3-io/h1v3/internal/tool"
 )
 
 func setupSkillsDir(t *testing.T) string {
@@ -132,6 +135,24 @@ func TestSkillReferences(t *testing.T) {
 	}
 }
 
+func TestLoadSkillTool_OnDemand(t *testing.T) {
+	dir := setupSkillsDir(t)
+	refs := filepath.Join(dir, "skills", "linear-api", "references")
+	os.MkdirAll(refs, 0o755)
+	os.WriteFile(filepath.Join(refs, "graphql.md"), []byte("query { issues { id } }"), 0o644)
+
+	lt := &tool.LoadSkillTool{Provider: &DynamicSkillProvider{Dirs: []string{dir}}}
+	out, err := lt.Execute(context.Background(), map[string]any{"slug": "linear-api"})
+	if err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	for _, want := range []string{"# Linear API", "Use the linear tools to create and manage issues.", "## Reference: graphql.md", "query { issues { id } }"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in %q", want, out)
+		}
+	}
+}
+
 func TestSkillScripts(t *testing.T) {
 	dir := setupSkillsDir(t)
 	loader := LoadSkills([]string{dir}, nil)
@@ -0,0 +1,100 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/h1v3-io/h1v3/internal/provider"
+	"github.com/h1v3-io/h1v3/pkg/protocol"
+)
+
+// outputFormat is the response format the agent loop requests, from the
+// spec's OutputSchema: nil for free text, any JSON object for an empty
+// schema, otherwise replies matching the schema.
+func (a *Agent) outputFormat() *protocol.ResponseFormat {
+	switch {
+	case a.Spec.OutputSchema == nil:
+		return nil
+	case len(a.Spec.OutputSchema) == 0:
+		return &protocol.ResponseFormat{Type: protocol.FormatJSONObject}
+	}
+	return &protocol.ResponseFormat{Type: protocol.FormatJSONSchema, Name: "output", Schema: a.Spec.OutputSchema}
+}
+
+// outputInstruction is the system prompt addition for an agent with an
+// OutputSchema, empty otherwise. The response format alone is not enough:
+// OpenAI's json_object mode rejects a prompt that never mentions JSON.
+func (a *Agent) outputInstruction() string {
+	if f := a.outputFormat(); f != nil {
+		return provider.JSONInstruction(f)
+	}
+	return ""
+}
+
+// ChatJSON makes a single tool-free LLM call that must answer in JSON, for
+// sub-calls such as verification or extraction that parse the reply. A nil
+// format requests any JSON object, and the system prompt is given the JSON
+// instruction unless a message already carries it. If the reply does not
+// parse, the model is shown the error and asked to repair it once.
+func (a *Agent) ChatJSON(ctx context.Context, messages []protocol.ChatMessage, format *protocol.ResponseFormat) (json.RawMessage, error) {
+	if format == nil {
+		format = &protocol.ResponseFormat{Type: protocol.FormatJSONObject}
+	}
+	req := protocol.ChatRequest{
+		Model:          a.Spec.Model,
+		Messages:       withJSONInstruction(messages, format),
+		MaxTokens:      a.Spec.MaxTokens,
+		Temperature:    a.Spec.Temperature,
+		ResponseFormat: format,
+	}
+
+	for attempt := 0; attempt < 2; attempt++ {
+		resp, err := a.withRequestTimeout(ctx, func(ctx context.Context, _ func()) (*protocol.ChatResponse, error) {
+			return a.Provider.Chat(ctx, req)
+		})
+		if err != nil {
+			return nil, fmt.Errorf("agent %s: provider error: %w", a.Spec.ID, err)
+		}
+
+		content := strings.TrimSpace(resp.Content)
+		var parsed json.RawMessage
+		err = json.Unmarshal([]byte(content), &parsed)
+		if err == nil {
+			return parsed, nil
+		}
+
+		a.Logger.Warn("model returned invalid JSON",
+			"agent", a.Spec.ID,
+			"attempt", attempt+1,
+			"error", err,
+		)
+		req.Messages = append(append([]protocol.ChatMessage{}, req.Messages...),
+			protocol.ChatMessage{Role: "assistant", Content: resp.Content},
+			protocol.ChatMessage{
+				Role:    "user",
+				Content: fmt.Sprintf("[system] That reply is not valid JSON (%v). Reply again with only the corrected JSON.", err),
+			},
+		)
+	}
+	return nil, fmt.Errorf("agent %s: response is not valid JSON after repair attempt", a.Spec.ID)
+}
+
+// withJSONInstruction returns messages with format's JSON instruction added
+// to the leading system message, or as a new one, so providers in json_object
+// mode see JSON mentioned. The caller's slice is not modified.
+func withJSONInstruction(messages []protocol.ChatMessage, format *protocol.ResponseFormat) []protocol.ChatMessage {
+	instr := provider.JSONInstruction(format)
+	for _, m := range messages {
+		if strings.Contains(m.Content, instr) {
+			return messages
+		}
+	}
+	if len(messages) > 0 && messages[0].Role == "system" {
+		out := append([]protocol.ChatMessage{}, messages...)
+		out[0].Content += "\n\n" + instr
+		return out
+	}
+	return append([]protocol.ChatMessage{{Role: "system", Content: instr}}, messages...)
+}
@@ -0,0 +1,86 @@
+package agent
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/h1v3-io/h1v3/internal/provider"
+	"github.com/h1v3-io/h1v3/internal/tool"
+	"github.com/h1v3-io/h1v3/pkg/protocol"
+)
+
+func TestChatJSON_RepairsInvalidJSON(t *testing.T) {
+	prov := &mockProvider{responses: []*protocol.ChatResponse{
+		{Content: `{"verdict": "yes",}`},
+		{Content: ` {"verdict": "yes"} `},
+	}}
+	ag := &Agent{Spec: protocol.AgentSpec{ID: "verifier"}, Provider: prov, Tools: tool.NewRegistry(), Logger: slog.Default()}
+
+	got, err := ag.ChatJSON(context.Background(), []protocol.ChatMessage{{Role: "user", Content: "Is the goal met?"}}, nil)
+	if err != nil {
+		t.Fatalf("ChatJSON: %v", err)
+	}
+	if string(got) != `{"verdict": "yes"}` {
+		t.Errorf("got %s", got)
+	}
+
+	if len(prov.calls) != 2 {
+		t.Fatalf("expected 2 provider calls, got %d", len(prov.calls))
+	}
+	first := prov.calls[0]
+	if first.ResponseFormat == nil || first.ResponseFormat.Type != protocol.FormatJSONObject || len(first.Tools) != 0 {
+		t.Errorf("first request should ask for a JSON object without tools: %+v", first)
+	}
+	if msgs := first.Messages; len(msgs) != 2 || msgs[0].Role != "system" || !strings.Contains(msgs[0].Content, "valid JSON object") {
+		t.Errorf("first request should carry the JSON instruction as a system message: %+v", msgs)
+	}
+	repair := prov.calls[1].Messages
+	if len(repair) != 4 || !strings.Contains(repair[3].Content, "not valid JSON") {
+		t.Errorf("repair request should include the bad reply and a correction prompt: %+v", repair)
+	}
+}
+
+func TestChatJSON_GivesUpAfterOneRepair(t *testing.T) {
+	prov := &mockProvider{responses: []*protocol.ChatResponse{{Content: "sure!"}, {Content: "still prose"}}}
+	ag := &Agent{Spec: protocol.AgentSpec{ID: "verifier"}, Provider: prov, Tools: tool.NewRegistry(), Logger: slog.Default()}
+
+	if _, err := ag.ChatJSON(context.Background(), []protocol.ChatMessage{{Role: "user", Content: "?"}}, nil); err == nil {
+		t.Fatal("expected error after failed repair")
+	}
+	if len(prov.calls) != 2 {
+		t.Errorf("expected exactly 2 provider calls, got %d", len(prov.calls))
+	}
+}
+
+func TestChatJSON_InstructionInSystemPrompt(t *testing.T) {
+	format := &protocol.ResponseFormat{Type: protocol.FormatJSONSchema, Name: "verdict", Schema: map[string]any{"type": "object"}}
+	instr := provider.JSONInstruction(format)
+	ag := &Agent{Spec: protocol.AgentSpec{ID: "verifier"}, Tools: tool.NewRegistry(), Logger: slog.Default()}
+
+	// Appended to an existing system prompt, without touching the caller's slice.
+	prov := &mockProvider{responses: []*protocol.ChatResponse{{Content: `{}`}}}
+	ag.Provider = prov
+	msgs := []protocol.ChatMessage{{Role: "system", Content: "You verify goals."}, {Role: "user", Content: "?"}}
+	if _, err := ag.ChatJSON(context.Background(), msgs, format); err != nil {
+		t.Fatalf("ChatJSON: %v", err)
+	}
+	if got := prov.calls[0].Messages; len(got) != 2 || got[0].Content != "You verify goals.\n\n"+instr {
+		t.Errorf("expected the instruction appended to the system prompt, got %+v", got)
+	}
+	if msgs[0].Content != "You verify goals." {
+		t.Errorf("caller's messages were modified: %q", msgs[0].Content)
+	}
+
+	// Not repeated when a message already carries it.
+	prov = &mockProvider{responses: []*protocol.ChatResponse{{Content: `{}`}}}
+	ag.Provider = prov
+	msgs = []protocol.ChatMessage{{Role: "system", Content: "Verify.\n\n" + instr}, {Role: "user", Content: "?"}}
+	if _, err := ag.ChatJSON(context.Background(), msgs, format); err != nil {
+		t.Fatalf("ChatJSON: %v", err)
+	}
+	if n := strings.Count(prov.calls[0].Messages[0].Content, instr); n != 1 {
+		t.Errorf("expected the instruction once, got %d", n)
+	}
+}
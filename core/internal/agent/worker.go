@@ -4,7 +4,9 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"slices"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/h1v3-io/h1v3/internal/tool"
@@ -22,7 +24,25 @@ type MessageRouter interface {
 	RouteMessage(msg protocol.Message) error
 	GetTicket(ticketID string) (*protocol.Ticket, error)
 	ListSubTickets(parentID string) ([]*protocol.Ticket, error)
-	UpdateTicketStatus(ticketID string, status protocol.TicketStatus) error
+	UpdateTicketStatus(ticketID string, status protocol.TicketStatus, by string) error
+}
+
+// InFlightTracker records which messages an agent is processing, so a turn
+// interrupted by a crash or restart can be redelivered on startup.
+type InFlightTracker interface {
+	MarkInFlight(agentID, ticketID, messageID string) error
+	ClearInFlight(agentID, messageID string) error
+}
+
+// UsageRecorder stores the token usage of provider calls per ticket.
+type UsageRecorder interface {
+	RecordUsage(ticketID, model string, u protocol.Usage) error
+}
+
+// TicketWindowLoader is implemented by routers that can load a ticket with
+// only its most recent messages.
+type TicketWindowLoader interface {
+	GetTicketWithMessages(ticketID string, limit int) (*protocol.Ticket, int, error)
 }
 
 // Worker runs an agent's event loop, processing messages from an inbox channel.
@@ -30,6 +50,51 @@ type Worker struct {
 	Agent  *Agent
 	Inbox  <-chan protocol.Message
 	Router MessageRouter
+
+	// InFlight, if set, marks each message while it is being processed.
+	// The marker is cleared once the message reaches a final outcome
+	// (handled, or given up on), but not when the worker is stopped mid-turn.
+	InFlight InFlightTracker
+
+	// Usage, if set, records the token usage of every provider call made
+	// while handling a message against the message's ticket.
+	Usage UsageRecorder
+
+	// HistoryLimit caps how many of the ticket's most recent messages are
+	// loaded into the prompt each turn (0 = all). Older messages are replaced
+	// by a note pointing at get_ticket. Requires a Router implementing
+	// TicketWindowLoader.
+	HistoryLimit int
+
+	// IdleTimeout, if set together with Hibernate, stops the worker after
+	// this long without messages. Hibernate is asked first and may refuse
+	// (e.g. a message just arrived); when it agrees, Start returns nil and
+	// the caller is responsible for restarting the worker on the next message.
+	IdleTimeout time.Duration
+	Hibernate   func() bool
+
+	// Nudge controls what happens when a turn ends in plain text instead of
+	// a respond_to_ticket call. The zero value re-prompts once with
+	// DefaultNudgeMessage and drops the text if the agent still ignores it.
+	Nudge NudgePolicy
+
+	active atomic.Int32 // messages being handled or waiting for a retry
+}
+
+// Idle reports whether the worker has no message in hand: none being
+// handled and none waiting for a retry. Messages still in the inbox are not
+// counted.
+func (w *Worker) Idle() bool { return w.active.Load() == 0 }
+
+// DefaultNudgeMessage re-prompts an agent that answered in plain text.
+const DefaultNudgeMessage = "[system] Do not reply with plain text. Use the respond_to_ticket tool to send your response. Set goal_met=true if the goal is satisfied."
+
+// NudgePolicy configures the worker's handling of plain-text turns.
+type NudgePolicy struct {
+	Message         string // re-prompt text; empty = DefaultNudgeMessage
+	MaxRetries      int    // re-prompts before giving up; 0 = 1
+	AutoWrap        bool   // send the plain text via respond_to_ticket right away, without re-prompting
+	DeliverOnGiveUp bool   // after the last re-prompt, send the plain text instead of dropping it
 }
 
 // Start runs the agent's message processing loop. It blocks until the context
@@ -37,6 +102,15 @@ type Worker struct {
 func (w *Worker) Start(ctx context.Context) error {
 	w.Agent.Logger.Info("agent worker started", "agent", w.Agent.Spec.ID)
 
+	// idle stays nil (never fires) unless hibernation is enabled.
+	var idle <-chan time.Time
+	var idleTimer *time.Timer
+	if w.IdleTimeout > 0 && w.Hibernate != nil {
+		idleTimer = time.NewTimer(w.IdleTimeout)
+		defer idleTimer.Stop()
+		idle = idleTimer.C
+	}
+
 	for {
 		select {
 		case msg, ok := <-w.Inbox:
@@ -45,6 +119,16 @@ func (w *Worker) Start(ctx context.Context) error {
 				return nil
 			}
 			w.handleMessage(ctx, msg, 0)
+			if idleTimer != nil {
+				idleTimer.Reset(w.IdleTimeout)
+			}
+
+		case <-idle:
+			if w.Hibernate() {
+				w.Agent.Logger.Info("agent worker hibernated", "agent", w.Agent.Spec.ID)
+				return nil
+			}
+			idleTimer.Reset(w.IdleTimeout)
 
 		case <-ctx.Done():
 			w.Agent.Logger.Info("agent worker stopping", "agent", w.Agent.Spec.ID)
@@ -54,6 +138,8 @@ func (w *Worker) Start(ctx context.Context) error {
 }
 
 func (w *Worker) handleMessage(ctx context.Context, msg protocol.Message, attempt int) {
+	w.active.Add(1)
+	defer w.active.Add(-1)
 	agentID := w.Agent.Spec.ID
 	w.Agent.Logger.Debug("processing message",
 		"agent", agentID,
@@ -61,14 +147,19 @@ func (w *Worker) handleMessage(ctx context.Context, msg protocol.Message, attemp
 		"from", msg.From,
 	)
 
+	if attempt == 0 {
+		w.markInFlight(msg)
+	}
+
 	// Load ticket context
-	ticket, err := w.Router.GetTicket(msg.TicketID)
+	ticket, omitted, err := w.loadTicket(msg.TicketID)
 	if err != nil {
 		w.Agent.Logger.Error("failed to load ticket",
 			"agent", agentID,
 			"ticket", msg.TicketID,
 			"error", err,
 		)
+		w.clearInFlight(msg)
 		return
 	}
 
@@ -86,6 +177,13 @@ func (w *Worker) handleMessage(ctx context.Context, msg protocol.Message, attemp
 		{Role: "system", Content: systemPrompt},
 	}
 
+	if omitted > 0 {
+		messages = append(messages, protocol.ChatMessage{
+			Role:    "user",
+			Content: fmt.Sprintf("[system]: %d earlier messages on this ticket are not shown. Use get_ticket to read the full history.", omitted),
+		})
+	}
+
 	// Include ticket messages as conversation context.
 	// The incoming message is already persisted by RouteMessage, so it's in ticket.Messages.
 	for _, m := range ticket.Messages {
@@ -93,17 +191,33 @@ func (w *Worker) handleMessage(ctx context.Context, msg protocol.Message, attemp
 		if m.From == agentID {
 			role = "assistant"
 		}
-		messages = append(messages, protocol.ChatMessage{
+		content := fmt.Sprintf("[%s]: %s", m.From, m.Content)
+		for _, a := range m.Attachments {
+			content += fmt.Sprintf("\n[attachment: %s (%s, %d bytes) — use read_attachment to view]", a.Name, a.MimeType, a.Size)
+		}
+		cm := protocol.ChatMessage{
 			Role:    role,
-			Content: fmt.Sprintf("[%s]: %s", m.From, m.Content),
-		})
+			Content: content,
+		}
+		// Images on the incoming message are shown to the model directly;
+		// earlier ones stay behind read_attachment to keep the prompt small.
+		if m.ID == msg.ID && role == "user" {
+			cm.Parts = imageParts(content, m.Attachments)
+		}
+		messages = append(messages, cm)
 	}
+	messages = append(messages, protocol.ChatMessage{Role: "user", Content: TurnNote(omitted+len(ticket.Messages), time.Now())})
 
 	// Run the ReAct loop with current ticket ID and input messages in context
 	ticketCtx := tool.WithCurrentTicket(ctx, msg.TicketID)
 	ticketCtx = tool.WithInputMessages(ticketCtx, messages)
 	ticketCtx, responded := tool.WithRespondedFlag(ticketCtx)
 	ticketCtx, deferredMsgs := tool.WithDeferredMessages(ticketCtx)
+	if w.Usage != nil {
+		ticketCtx = withUsageHook(ticketCtx, func(resp *protocol.ChatResponse) {
+			w.recordUsage(msg.TicketID, resp)
+		})
+	}
 	response, err := w.Agent.RunWithHistory(ticketCtx, messages)
 	if err != nil {
 		errContextID := fmt.Sprintf("err-%d", time.Now().UnixNano())
@@ -138,7 +252,9 @@ func (w *Worker) handleMessage(ctx context.Context, msg protocol.Message, attemp
 				"attempt", attempt+1,
 				"delay", retryDelay,
 			)
+			w.active.Add(1)
 			go func() {
+				defer w.active.Add(-1)
 				select {
 				case <-time.After(retryDelay):
 					w.handleMessage(ctx, msg, attempt+1)
@@ -151,32 +267,15 @@ func (w *Worker) handleMessage(ctx context.Context, msg protocol.Message, attemp
 				"ticket", msg.TicketID,
 				"attempts", attempt+1,
 			)
+			w.clearInFlight(msg)
 		}
 		return
 	}
 
 	// If the agent returned plain text without calling respond_to_ticket,
-	// nudge it to use the tool and re-run once.
+	// nudge it to use the tool (or wrap the text) per the nudge policy.
 	if !*responded && strings.TrimSpace(response) != "" {
-		w.Agent.Logger.Warn("agent returned plain text without calling respond_to_ticket, retrying with nudge",
-			"agent", agentID,
-			"ticket", msg.TicketID,
-		)
-		nudgeMessages := append(messages,
-			protocol.ChatMessage{Role: "assistant", Content: response},
-			protocol.ChatMessage{
-				Role:    "user",
-				Content: "[system] Do not reply with plain text. Use the respond_to_ticket tool to send your response. Set goal_met=true if the goal is satisfied.",
-			},
-		)
-		_, err = w.Agent.RunWithHistory(ticketCtx, nudgeMessages)
-		if err != nil {
-			w.Agent.Logger.Error("nudge retry failed",
-				"agent", agentID,
-				"ticket", msg.TicketID,
-				"error", err,
-			)
-		}
+		w.handlePlainText(ticketCtx, msg, messages, response, responded)
 	}
 
 	// Flush deferred messages (respond_to_ticket on the current ticket).
@@ -190,4 +289,139 @@ func (w *Worker) handleMessage(ctx context.Context, msg protocol.Message, attemp
 			)
 		}
 	}
+
+	w.clearInFlight(msg)
+}
+
+// recordUsage stores one provider response's token usage. Failures are
+// logged; accounting never fails a turn.
+func (w *Worker) recordUsage(ticketID string, resp *protocol.ChatResponse) {
+	model := resp.Model
+	if model == "" {
+		model = w.Agent.Provider.Name()
+	}
+	if err := w.Usage.RecordUsage(ticketID, model, resp.Usage); err != nil {
+		w.Agent.Logger.Warn("failed to record token usage",
+			"agent", w.Agent.Spec.ID,
+			"ticket", ticketID,
+			"error", err,
+		)
+	}
+}
+
+// handlePlainText deals with a turn that ended in plain text instead of a
+// respond_to_ticket call. Unless the policy auto-wraps, the agent is
+// re-prompted up to MaxRetries times; if it still hasn't responded and
+// DeliverOnGiveUp is set, its last text is sent as the response.
+func (w *Worker) handlePlainText(ctx context.Context, msg protocol.Message, messages []protocol.ChatMessage, response string, responded *bool) {
+	agentID := w.Agent.Spec.ID
+	policy := w.Nudge
+
+	if !policy.AutoWrap {
+		nudge := policy.Message
+		if nudge == "" {
+			nudge = DefaultNudgeMessage
+		}
+		retries := policy.MaxRetries
+		if retries <= 0 {
+			retries = 1
+		}
+		history := slices.Clone(messages)
+		for attempt := 1; attempt <= retries && !*responded; attempt++ {
+			w.Agent.Logger.Warn("agent returned plain text without calling respond_to_ticket, retrying with nudge",
+				"agent", agentID,
+				"ticket", msg.TicketID,
+				"attempt", attempt,
+			)
+			history = append(history,
+				protocol.ChatMessage{Role: "assistant", Content: response},
+				protocol.ChatMessage{Role: "user", Content: nudge},
+			)
+			text, err := w.Agent.RunWithHistory(ctx, history)
+			if err != nil {
+				w.Agent.Logger.Error("nudge retry failed",
+					"agent", agentID,
+					"ticket", msg.TicketID,
+					"error", err,
+				)
+				break
+			}
+			if strings.TrimSpace(text) == "" {
+				break
+			}
+			response = text
+		}
+		if *responded {
+			return
+		}
+		if !policy.DeliverOnGiveUp {
+			w.Agent.Logger.Warn("agent ignored nudges, plain-text response dropped",
+				"agent", agentID,
+				"ticket", msg.TicketID,
+			)
+			return
+		}
+	}
+
+	respond, ok := w.Agent.Tools.Get("respond_to_ticket")
+	if !ok {
+		w.Agent.Logger.Warn("cannot deliver plain-text response: respond_to_ticket not available",
+			"agent", agentID,
+			"ticket", msg.TicketID,
+		)
+		return
+	}
+	if _, err := respond.Execute(ctx, map[string]any{"message": response}); err != nil {
+		w.Agent.Logger.Error("failed to deliver plain-text response",
+			"agent", agentID,
+			"ticket", msg.TicketID,
+			"error", err,
+		)
+		return
+	}
+	w.Agent.Logger.Info("plain-text response delivered via respond_to_ticket",
+		"agent", agentID,
+		"ticket", msg.TicketID,
+	)
+}
+
+func (w *Worker) markInFlight(msg protocol.Message) {
+	if w.InFlight == nil || msg.ID == "" {
+		return
+	}
+	if err := w.InFlight.MarkInFlight(w.Agent.Spec.ID, msg.TicketID, msg.ID); err != nil {
+		w.Agent.Logger.Warn("failed to mark message in-flight",
+			"agent", w.Agent.Spec.ID,
+			"ticket", msg.TicketID,
+			"error", err,
+		)
+	}
+}
+
+func (w *Worker) clearInFlight(msg protocol.Message) {
+	if w.InFlight == nil || msg.ID == "" {
+		return
+	}
+	if err := w.InFlight.ClearInFlight(w.Agent.Spec.ID, msg.ID); err != nil {
+		w.Agent.Logger.Warn("failed to clear in-flight marker",
+			"agent", w.Agent.Spec.ID,
+			"ticket", msg.TicketID,
+			"error", err,
+		)
+	}
+}
+
+// loadTicket loads the ticket for a turn, bounded to HistoryLimit messages
+// when the router supports it. It returns how many older messages were left
+// out.
+func (w *Worker) loadTicket(ticketID string) (*protocol.Ticket, int, error) {
+	if loader, ok := w.Router.(TicketWindowLoader); ok && w.HistoryLimit > 0 {
+		ticket, total, err := loader.GetTicketWithMessages(ticketID, w.HistoryLimit)
+		if err != nil {
+			return nil, 0, err
+		}
+		return ticket, total - len(ticket.Messages), nil
+	}
+	ticket, err := w.Router.GetTicket(ticketID)
+	return ticket, 0, err
 }
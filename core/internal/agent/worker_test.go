@@ -4,6 +4,9 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -42,7 +45,7 @@ func (r *mockRouter) GetTicket(ticketID string) (*protocol.Ticket, error) {
 	return t, nil
 }
 
-func (r *mockRouter) UpdateTicketStatus(ticketID string, status protocol.TicketStatus) error {
+func (r *mockRouter) UpdateTicketStatus(ticketID string, status protocol.TicketStatus, _ string) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 	t, ok := r.tickets[ticketID]
@@ -139,6 +142,78 @@ func TestWorker_PlainTextDropped(t *testing.T) {
 	}
 }
 
+// respondRecorder stands in for respond_to_ticket and records each message.
+type respondRecorder struct{ sent []string }
+
+func (r *respondRecorder) Name() string               { return "respond_to_ticket" }
+func (r *respondRecorder) Description() string        { return "Respond" }
+func (r *respondRecorder) Parameters() map[string]any { return map[string]any{"type": "object"} }
+func (r *respondRecorder) Execute(_ context.Context, params map[string]any) (string, error) {
+	msg, _ := params["message"].(string)
+	r.sent = append(r.sent, msg)
+	return "sent", nil
+}
+
+func TestWorker_PlainTextNudgePolicy(t *testing.T) {
+	incoming := protocol.Message{ID: "m-001", From: "agent-a", To: []string{"agent-b"}, Content: "Task", TicketID: "t-001"}
+	setup := func(policy NudgePolicy, replies ...string) (*Worker, *mockProvider, *respondRecorder) {
+		router := newMockRouter()
+		router.tickets["t-001"] = &protocol.Ticket{
+			ID: "t-001", Status: protocol.TicketOpen, CreatedBy: "agent-a",
+			WaitingOn: []string{"agent-b"}, Messages: []protocol.Message{incoming},
+		}
+		prov := &mockProvider{}
+		for _, r := range replies {
+			prov.responses = append(prov.responses, &protocol.ChatResponse{Content: r})
+		}
+		rec := &respondRecorder{}
+		tools := tool.NewRegistry()
+		tools.Register(rec)
+		ag := &Agent{
+			Spec:          protocol.AgentSpec{ID: "agent-b", CoreInstructions: "test"},
+			Provider:      prov,
+			Tools:         tools,
+			Logger:        slog.Default(),
+			MaxIterations: 10,
+		}
+		return &Worker{Agent: ag, Router: router, Nudge: policy}, prov, rec
+	}
+
+	t.Run("auto-wrap", func(t *testing.T) {
+		w, prov, rec := setup(NudgePolicy{AutoWrap: true}, "Hola, ya está hecho.")
+		w.handleMessage(context.Background(), incoming, 0)
+		if len(prov.calls) != 1 {
+			t.Errorf("expected no re-prompt, got %d provider calls", len(prov.calls))
+		}
+		if len(rec.sent) != 1 || rec.sent[0] != "Hola, ya está hecho." {
+			t.Errorf("expected the plain text wrapped into respond_to_ticket, got %q", rec.sent)
+		}
+	})
+
+	t.Run("deliver on give up", func(t *testing.T) {
+		w, prov, rec := setup(NudgePolicy{Message: "Usa respond_to_ticket.", MaxRetries: 2, DeliverOnGiveUp: true}, "one", "two", "three")
+		w.handleMessage(context.Background(), incoming, 0)
+		if len(prov.calls) != 3 {
+			t.Fatalf("expected 1 turn + 2 nudges, got %d provider calls", len(prov.calls))
+		}
+		last := prov.calls[2].Messages
+		if got := last[len(last)-1].Content; got != "Usa respond_to_ticket." {
+			t.Errorf("expected the configured nudge text, got %q", got)
+		}
+		if len(rec.sent) != 1 || rec.sent[0] != "three" {
+			t.Errorf("expected the last plain text delivered, got %q", rec.sent)
+		}
+	})
+
+	t.Run("default drops", func(t *testing.T) {
+		w, prov, rec := setup(NudgePolicy{}, "one", "two")
+		w.handleMessage(context.Background(), incoming, 0)
+		if len(prov.calls) != 2 || len(rec.sent) != 0 {
+			t.Errorf("expected one nudge and nothing delivered, got %d calls, %q sent", len(prov.calls), rec.sent)
+		}
+	})
+}
+
 func TestWorker_EmptyResponse_SkipsRoute(t *testing.T) {
 	router := newMockRouter()
 
@@ -259,3 +334,260 @@ func TestWorker_ContextCancelled(t *testing.T) {
 		t.Fatal("worker did not stop after context cancel")
 	}
 }
+
+// recordingTracker implements InFlightTracker, recording calls in order.
+type recordingTracker struct {
+	mu    sync.Mutex
+	calls []string
+}
+
+func (r *recordingTracker) MarkInFlight(agentID, ticketID, messageID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls = append(r.calls, "mark "+agentID+" "+ticketID+" "+messageID)
+	return nil
+}
+
+func (r *recordingTracker) ClearInFlight(agentID, messageID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls = append(r.calls, "clear "+agentID+" "+messageID)
+	return nil
+}
+
+func TestWorker_InFlightMarkedAndCleared(t *testing.T) {
+	router := newMockRouter()
+	msg := protocol.Message{ID: "m-010", From: "agent-a", To: []string{"agent-b"}, Content: "hi", TicketID: "t-010"}
+	router.tickets["t-010"] = &protocol.Ticket{
+		ID:        "t-010",
+		Status:    protocol.TicketOpen,
+		CreatedBy: "agent-a",
+		WaitingOn: []string{"agent-b"},
+		Messages:  []protocol.Message{msg},
+	}
+
+	tracker := &recordingTracker{}
+	worker := &Worker{
+		Agent: &Agent{
+			Spec:          protocol.AgentSpec{ID: "agent-b"},
+			Provider:      &mockProvider{responses: []*protocol.ChatResponse{{Content: ""}}},
+			Tools:         tool.NewRegistry(),
+			Logger:        slog.Default(),
+			MaxIterations: 10,
+		},
+		Router:   router,
+		InFlight: tracker,
+	}
+
+	worker.handleMessage(context.Background(), msg, 0)
+
+	want := []string{"mark agent-b t-010 m-010", "clear agent-b m-010"}
+	if fmt.Sprint(tracker.calls) != fmt.Sprint(want) {
+		t.Errorf("tracker calls = %v, want %v", tracker.calls, want)
+	}
+}
+
+func TestWorker_InFlightKeptOnCancel(t *testing.T) {
+	router := newMockRouter()
+	msg := protocol.Message{ID: "m-011", From: "agent-a", To: []string{"agent-b"}, Content: "hi", TicketID: "t-011"}
+	router.tickets["t-011"] = &protocol.Ticket{ID: "t-011", Status: protocol.TicketOpen, Messages: []protocol.Message{msg}}
+
+	tracker := &recordingTracker{}
+	worker := &Worker{
+		Agent: &Agent{
+			Spec:          protocol.AgentSpec{ID: "agent-b"},
+			Provider:      &mockProvider{}, // no responses: every call fails
+			Tools:         tool.NewRegistry(),
+			Logger:        slog.Default(),
+			MaxIterations: 10,
+		},
+		Router:   router,
+		InFlight: tracker,
+	}
+
+	// The LLM call fails and a retry is scheduled; shutting down before it
+	// runs must leave the marker so the message is resumed on restart.
+	ctx, cancel := context.WithCancel(context.Background())
+	worker.handleMessage(ctx, msg, 0)
+	cancel()
+
+	want := []string{"mark agent-b t-011 m-011"}
+	if fmt.Sprint(tracker.calls) != fmt.Sprint(want) {
+		t.Errorf("tracker calls = %v, want %v", tracker.calls, want)
+	}
+}
+
+// usageRecorder implements UsageRecorder.
+type usageRecorder struct{ calls []string }
+
+func (r *usageRecorder) RecordUsage(ticketID, model string, u protocol.Usage) error {
+	r.calls = append(r.calls, fmt.Sprintf("%s %s %d/%d", ticketID, model, u.PromptTokens, u.CompletionTokens))
+	return nil
+}
+
+func TestWorker_RecordsUsage(t *testing.T) {
+	router := newMockRouter()
+	msg := protocol.Message{ID: "m-012", From: "agent-a", To: []string{"agent-b"}, Content: "hi", TicketID: "t-012"}
+	router.tickets["t-012"] = &protocol.Ticket{ID: "t-012", Status: protocol.TicketOpen, Messages: []protocol.Message{msg}}
+
+	usage := &usageRecorder{}
+	worker := &Worker{
+		Agent: &Agent{
+			Spec: protocol.AgentSpec{ID: "agent-b"},
+			Provider: &mockProvider{responses: []*protocol.ChatResponse{
+				{ToolCalls: []protocol.ToolCall{{ID: "c1", Name: "echo", Arguments: map[string]any{"text": "x"}}}, Usage: protocol.Usage{PromptTokens: 10, CompletionTokens: 2}, Model: "gpt-4o"},
+				{Content: "", Usage: protocol.Usage{PromptTokens: 15, CompletionTokens: 1}},
+			}},
+			Tools:         tool.NewRegistry(),
+			Logger:        slog.Default(),
+			MaxIterations: 10,
+		},
+		Router: router,
+		Usage:  usage,
+	}
+	worker.Agent.Tools.Register(&echoTool{})
+
+	worker.handleMessage(context.Background(), msg, 0)
+
+	// A response without a model is recorded under the provider's name.
+	want := []string{"t-012 gpt-4o 10/2", "t-012 mock 15/1"}
+	if fmt.Sprint(usage.calls) != fmt.Sprint(want) {
+		t.Errorf("usage calls = %v, want %v", usage.calls, want)
+	}
+}
+
+// windowRouter adds TicketWindowLoader to mockRouter.
+type windowRouter struct {
+	*mockRouter
+}
+
+func (r *windowRouter) GetTicketWithMessages(ticketID string, limit int) (*protocol.Ticket, int, error) {
+	t, err := r.GetTicket(ticketID)
+	if err != nil {
+		return nil, 0, err
+	}
+	cp := *t
+	total := len(t.Messages)
+	if limit > 0 && total > limit {
+		cp.Messages = t.Messages[total-limit:]
+	}
+	return &cp, total, nil
+}
+
+func TestWorker_HistoryLimit(t *testing.T) {
+	router := &windowRouter{newMockRouter()}
+
+	var history []protocol.Message
+	for i := 1; i <= 5; i++ {
+		history = append(history, protocol.Message{
+			ID: fmt.Sprintf("m-%d", i), From: "agent-a", To: []string{"agent-b"},
+			Content: fmt.Sprintf("message %d", i), TicketID: "t-001",
+		})
+	}
+	router.tickets["t-001"] = &protocol.Ticket{
+		ID: "t-001", Title: "Long ticket", Status: protocol.TicketOpen,
+		CreatedBy: "agent-a", WaitingOn: []string{"agent-b"}, Messages: history,
+	}
+
+	prov := &mockProvider{responses: []*protocol.ChatResponse{{Content: ""}}}
+	ag := &Agent{
+		Spec:          protocol.AgentSpec{ID: "agent-b", CoreInstructions: "test"},
+		Provider:      prov,
+		Tools:         tool.NewRegistry(),
+		Logger:        slog.Default(),
+		MaxIterations: 10,
+	}
+	worker := &Worker{Agent: ag, Router: router, HistoryLimit: 2}
+	worker.handleMessage(context.Background(), history[4], 0)
+
+	if len(prov.calls) == 0 {
+		t.Fatal("expected a provider call")
+	}
+	msgs := prov.calls[0].Messages
+	// system prompt + omitted note + 2 recent messages + turn note
+	if len(msgs) != 5 {
+		t.Fatalf("expected 5 messages, got %d: %+v", len(msgs), msgs)
+	}
+	if !strings.Contains(msgs[1].Content, "3 earlier messages") {
+		t.Errorf("expected omitted-messages note, got %q", msgs[1].Content)
+	}
+	if !strings.Contains(msgs[2].Content, "message 4") || !strings.Contains(msgs[3].Content, "message 5") {
+		t.Errorf("expected the two most recent messages, got %q, %q", msgs[2].Content, msgs[3].Content)
+	}
+	if !strings.HasPrefix(msgs[4].Content, "[system]: Current time: ") {
+		t.Errorf("expected the turn note last, got %q", msgs[4].Content)
+	}
+	if !strings.HasSuffix(msgs[4].Content, "Messages on this ticket: 5.") {
+		t.Errorf("expected the ticket's full message count, got %q", msgs[4].Content)
+	}
+}
+
+func TestWorker_ImageAttachments(t *testing.T) {
+	router := newMockRouter()
+	path := filepath.Join(t.TempDir(), "old.png")
+	os.WriteFile(path, []byte("old"), 0o644)
+	history := []protocol.Message{
+		{ID: "m-1", From: "_external", Content: "first", TicketID: "t-001",
+			Attachments: []protocol.Attachment{{Name: "old.png", MimeType: "image/png", Path: path, Size: 3}}},
+		{ID: "m-2", From: "_external", Content: "what is this?", TicketID: "t-001",
+			Attachments: []protocol.Attachment{
+				{Name: "photo.jpg", MimeType: "image/jpeg", Path: path, Size: 3},
+				{Name: "notes.txt", MimeType: "text/plain", Data: []byte("notes")},
+			}},
+	}
+	router.tickets["t-001"] = &protocol.Ticket{
+		ID: "t-001", Title: "Chat", Status: protocol.TicketOpen,
+		CreatedBy: "_external", WaitingOn: []string{"front"}, Messages: history,
+	}
+
+	prov := &mockProvider{responses: []*protocol.ChatResponse{{Content: ""}}}
+	ag := &Agent{
+		Spec:     protocol.AgentSpec{ID: "front", CoreInstructions: "test"},
+		Provider: prov,
+		Tools:    tool.NewRegistry(),
+		Logger:   slog.Default(),
+	}
+	worker := &Worker{Agent: ag, Router: router}
+	worker.handleMessage(context.Background(), history[1], 0)
+
+	msgs := prov.calls[0].Messages
+	if msgs[1].Parts != nil {
+		t.Errorf("earlier message images should not be inlined, got %+v", msgs[1].Parts)
+	}
+	parts := msgs[2].Parts
+	if len(parts) != 2 || parts[0].Text != msgs[2].Content || parts[1].Type != protocol.PartImage || string(parts[1].Data) != "old" {
+		t.Errorf("expected the text and the one image, got %+v", parts)
+	}
+}
+
+func TestWorker_Hibernate(t *testing.T) {
+	ag := &Agent{
+		Spec:   protocol.AgentSpec{ID: "agent-b"},
+		Logger: slog.Default(),
+	}
+	var asked int
+	worker := &Worker{
+		Agent:       ag,
+		Inbox:       make(chan protocol.Message),
+		Router:      newMockRouter(),
+		IdleTimeout: 10 * time.Millisecond,
+		Hibernate: func() bool {
+			asked++
+			return asked > 1 // refuse once, then agree
+		},
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- worker.Start(context.Background()) }()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("expected nil on hibernation, got %v", err)
+		}
+		if asked != 2 {
+			t.Errorf("expected hibernate asked twice, got %d", asked)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("worker did not hibernate")
+	}
+}
@@ -0,0 +1,205 @@
+package api
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/h1v3-io/h1v3/internal/connector/httpchat"
+)
+
+// ChatService is implemented by hive services that can run HTTP chat
+// sessions. Both methods return ErrChatDisabled when the hive has no
+// connectors.http configured.
+type ChatService interface {
+	// ChatPost sends a client message on a session, creating the session's
+	// ticket on first use. The cursor it returns marks the replies queued
+	// before the message.
+	ChatPost(ctx context.Context, sessionID, content, eventID string) (cursor int64, err error)
+	// ChatWait returns the session's replies queued after cursor (0 for
+	// all), blocking until there is at least one or ctx ends.
+	ChatWait(ctx context.Context, sessionID string, cursor int64) ([]httpchat.Reply, error)
+}
+
+// ErrChatDisabled reports that a hive does not accept HTTP chat.
+var ErrChatDisabled = errors.New("http chat connector is not enabled")
+
+const (
+	defaultChatWait = 30 * time.Second
+	maxChatWait     = 5 * time.Minute
+	sseKeepalive    = 15 * time.Second
+)
+
+type postChatRequest struct {
+	SessionID   string `json:"session_id"`             // empty = start a new session
+	Content     string `json:"content"`                // message text, or a command such as /new
+	EventID     string `json:"event_id,omitempty"`     // client message ID; retries with the same ID are dropped
+	WaitSeconds *int   `json:"wait_seconds,omitempty"` // how long to wait for replies; default 30, 0 = don't wait
+	Stream      bool   `json:"stream,omitempty"`       // stream replies as server-sent events for the wait period
+}
+
+type chatResponse struct {
+	SessionID string           `json:"session_id"`
+	Replies   []httpchat.Reply `json:"replies"`
+}
+
+func (s *Server) chatService(w http.ResponseWriter, r *http.Request) (ChatService, bool) {
+	cs, ok := s.service(r).(ChatService)
+	if !ok {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": ErrChatDisabled.Error()})
+	}
+	return cs, ok
+}
+
+func (s *Server) handlePostChat(w http.ResponseWriter, r *http.Request) {
+	cs, ok := s.chatService(w, r)
+	if !ok {
+		return
+	}
+	var req postChatRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid JSON"})
+		return
+	}
+	if req.Content == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "content is required"})
+		return
+	}
+	if req.SessionID == "" {
+		req.SessionID = newSessionID()
+	}
+	if !httpchat.ValidSessionID(req.SessionID) {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "session_id must be 1-128 letters, digits or _.:-"})
+		return
+	}
+	wait := defaultChatWait
+	if req.WaitSeconds != nil {
+		wait = min(time.Duration(max(*req.WaitSeconds, 0))*time.Second, maxChatWait)
+	}
+
+	// Only replies queued after this message are returned; earlier ones,
+	// e.g. from a poll that timed out, stay queued for GET.
+	cursor, err := cs.ChatPost(r.Context(), req.SessionID, req.Content, req.EventID)
+	if err != nil {
+		writeChatError(w, err)
+		return
+	}
+
+	if req.Stream || wantsEventStream(r) {
+		ctx, cancel := context.WithTimeout(r.Context(), wait)
+		defer cancel()
+		s.streamChat(ctx, w, cs, req.SessionID, cursor)
+		return
+	}
+	if wait == 0 {
+		writeJSON(w, http.StatusAccepted, chatResponse{SessionID: req.SessionID, Replies: []httpchat.Reply{}})
+		return
+	}
+	s.pollChat(w, r, cs, req.SessionID, cursor, wait)
+}
+
+// handleGetChat collects replies that arrived after the POST returned:
+// a long poll (?wait=seconds, default 30), or with Accept:
+// text/event-stream a stream that stays open until the client leaves.
+func (s *Server) handleGetChat(w http.ResponseWriter, r *http.Request) {
+	cs, ok := s.chatService(w, r)
+	if !ok {
+		return
+	}
+	sessionID := r.PathValue("session")
+	if !httpchat.ValidSessionID(sessionID) {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid session_id"})
+		return
+	}
+	if wantsEventStream(r) {
+		s.streamChat(r.Context(), w, cs, sessionID, 0)
+		return
+	}
+	wait := defaultChatWait
+	if v := r.URL.Query().Get("wait"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "wait must be a non-negative number of seconds"})
+			return
+		}
+		wait = min(time.Duration(n)*time.Second, maxChatWait)
+	}
+	s.pollChat(w, r, cs, sessionID, 0, wait)
+}
+
+// pollChat waits up to wait for replies after cursor and returns them as
+// JSON. A poll that times out returns an empty list.
+func (s *Server) pollChat(w http.ResponseWriter, r *http.Request, cs ChatService, sessionID string, cursor int64, wait time.Duration) {
+	ctx, cancel := context.WithTimeout(r.Context(), wait)
+	defer cancel()
+	replies, err := cs.ChatWait(ctx, sessionID, cursor)
+	if err != nil {
+		writeChatError(w, err)
+		return
+	}
+	if replies == nil {
+		replies = []httpchat.Reply{}
+	}
+	writeJSON(w, http.StatusOK, chatResponse{SessionID: sessionID, Replies: replies})
+}
+
+// streamChat sends replies after cursor as "reply" events until ctx ends,
+// then a "done" event. Comment lines keep idle connections from being
+// dropped by proxies.
+func (s *Server) streamChat(ctx context.Context, w http.ResponseWriter, cs ChatService, sessionID string, cursor int64) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "streaming not supported"})
+		return
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Session-ID", sessionID)
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	for ctx.Err() == nil {
+		waitCtx, cancel := context.WithTimeout(ctx, sseKeepalive)
+		replies, err := cs.ChatWait(waitCtx, sessionID, cursor)
+		cancel()
+		if err != nil {
+			s.logger.Warn("chat stream failed", "session", sessionID, "error", err)
+			break
+		}
+		if len(replies) == 0 {
+			fmt.Fprint(w, ": keepalive\n\n")
+		}
+		for _, rep := range replies {
+			data, _ := json.Marshal(rep)
+			fmt.Fprintf(w, "event: reply\nid: %s\ndata: %s\n\n", rep.ID, data)
+		}
+		flusher.Flush()
+	}
+	fmt.Fprintf(w, "event: done\ndata: {\"session_id\":%q}\n\n", sessionID)
+	flusher.Flush()
+}
+
+func writeChatError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	if errors.Is(err, ErrChatDisabled) {
+		status = http.StatusNotFound
+	}
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
+
+func wantsEventStream(r *http.Request) bool {
+	return strings.Contains(r.Header.Get("Accept"), "text/event-stream")
+}
+
+func newSessionID() string {
+	b := make([]byte, 12)
+	rand.Read(b)
+	return "web-" + hex.EncodeToString(b)
+}
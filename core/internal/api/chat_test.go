@@ -0,0 +1,151 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/h1v3-io/h1v3/internal/connector"
+	"github.com/h1v3-io/h1v3/internal/connector/httpchat"
+)
+
+// chatHiveService adds an echoing HTTP chat connector to the mock hive.
+type chatHiveService struct {
+	*mockHiveService
+	chat *httpchat.Connector
+}
+
+func newChatHiveService() *chatHiveService {
+	svc := &chatHiveService{mockHiveService: &mockHiveService{}}
+	svc.chat = httpchat.New(httpchat.Config{}, func(ctx context.Context, msg connector.InboundMessage) error {
+		go svc.chat.Send(ctx, connector.OutboundMessage{ChatID: msg.ChatID, Content: "echo: " + msg.Content})
+		return nil
+	}, nil)
+	return svc
+}
+
+func (s *chatHiveService) ChatPost(ctx context.Context, sessionID, content, eventID string) (int64, error) {
+	return s.chat.Post(ctx, sessionID, content, eventID)
+}
+
+func (s *chatHiveService) ChatWait(ctx context.Context, sessionID string, cursor int64) ([]httpchat.Reply, error) {
+	return s.chat.WaitAfter(ctx, sessionID, cursor), nil
+}
+
+func postChat(t *testing.T, srv *Server, body string, accept string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest("POST", "/api/chat", strings.NewReader(body))
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+	w := httptest.NewRecorder()
+	srv.Handler().ServeHTTP(w, req)
+	return w
+}
+
+func TestPostChat_ReturnsReply(t *testing.T) {
+	srv := newTestServer(newChatHiveService(), "")
+
+	w := postChat(t, srv, `{"session_id":"user-1","content":"hi"}`, "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", w.Code, w.Body)
+	}
+	var resp chatResponse
+	json.NewDecoder(w.Body).Decode(&resp)
+	if resp.SessionID != "user-1" || len(resp.Replies) != 1 || resp.Replies[0].Content != "echo: hi" {
+		t.Errorf("unexpected response %+v", resp)
+	}
+
+	// Nothing left to collect.
+	req := httptest.NewRequest("GET", "/api/chat/user-1?wait=0", nil)
+	w = httptest.NewRecorder()
+	srv.Handler().ServeHTTP(w, req)
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"replies":[]`) {
+		t.Errorf("expected empty poll, got %d %s", w.Code, w.Body)
+	}
+}
+
+func TestPostChat_SkipsEarlierReplies(t *testing.T) {
+	svc := newChatHiveService()
+	srv := newTestServer(svc, "")
+
+	// A reply left over from before this message must not be returned as
+	// its answer.
+	svc.chat.Send(context.Background(), connector.OutboundMessage{ChatID: "user-1", Content: "stale"})
+
+	w := postChat(t, srv, `{"session_id":"user-1","content":"hi"}`, "")
+	var resp chatResponse
+	json.NewDecoder(w.Body).Decode(&resp)
+	if len(resp.Replies) != 1 || resp.Replies[0].Content != "echo: hi" {
+		t.Errorf("expected only the reply to this message, got %+v", resp.Replies)
+	}
+
+	// The earlier reply is still there for a poll.
+	req := httptest.NewRequest("GET", "/api/chat/user-1?wait=0", nil)
+	w = httptest.NewRecorder()
+	srv.Handler().ServeHTTP(w, req)
+	if !strings.Contains(w.Body.String(), "stale") {
+		t.Errorf("expected the earlier reply in the poll, got %s", w.Body)
+	}
+}
+
+func TestPostChat_NewSessionAndNoWait(t *testing.T) {
+	svc := newChatHiveService()
+	srv := newTestServer(svc, "")
+
+	w := postChat(t, srv, `{"content":"hi","wait_seconds":0}`, "")
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("status = %d: %s", w.Code, w.Body)
+	}
+	var resp chatResponse
+	json.NewDecoder(w.Body).Decode(&resp)
+	if !strings.HasPrefix(resp.SessionID, "web-") {
+		t.Fatalf("expected a generated session ID, got %q", resp.SessionID)
+	}
+
+	// The reply is collected by a later poll.
+	req := httptest.NewRequest("GET", "/api/chat/"+resp.SessionID+"?wait=5", nil)
+	w = httptest.NewRecorder()
+	srv.Handler().ServeHTTP(w, req)
+	if !strings.Contains(w.Body.String(), "echo: hi") {
+		t.Errorf("expected reply in poll, got %s", w.Body)
+	}
+}
+
+func TestPostChat_Stream(t *testing.T) {
+	srv := newTestServer(newChatHiveService(), "")
+
+	w := postChat(t, srv, `{"session_id":"s","content":"hi","wait_seconds":1}`, "text/event-stream")
+	if ct := w.Header().Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("content type = %q", ct)
+	}
+	body := w.Body.String()
+	if !strings.Contains(body, "event: reply\nid: 1\ndata: ") || !strings.Contains(body, "echo: hi") {
+		t.Errorf("expected reply event, got %q", body)
+	}
+	if !strings.HasSuffix(body, "event: done\ndata: {\"session_id\":\"s\"}\n\n") {
+		t.Errorf("expected done event at the end, got %q", body)
+	}
+}
+
+func TestPostChat_Errors(t *testing.T) {
+	// A hive without the chat connector.
+	srv := newTestServer(&mockHiveService{}, "")
+	if w := postChat(t, srv, `{"session_id":"s","content":"hi"}`, ""); w.Code != http.StatusNotFound {
+		t.Errorf("expected 404 without chat connector, got %d", w.Code)
+	}
+
+	srv = newTestServer(newChatHiveService(), "")
+	for _, body := range []string{
+		`{"session_id":"s"}`,
+		`{"session_id":"no spaces","content":"hi"}`,
+		`not json`,
+	} {
+		if w := postChat(t, srv, body, ""); w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", body, w.Code)
+		}
+	}
+}
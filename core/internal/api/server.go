@@ -3,27 +3,32 @@ package api
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
 	"net/http"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/h1v3-io/h1v3/internal/logbuf"
+	"github.com/h1v3-io/h1v3/internal/memory"
 	"github.com/h1v3-io/h1v3/internal/ticket"
+	"github.com/h1v3-io/h1v3/internal/tool"
 	"github.com/h1v3-io/h1v3/pkg/protocol"
 )
 
 // LogQuerier abstracts log entry querying to avoid coupling to logbuf directly.
 type LogQuerier interface {
-	Query(since time.Time, minLevel slog.Level, limit int) []logbuf.Entry
+	Query(f logbuf.Filter) []logbuf.Entry
 }
 
 // AgentInfo describes an agent for API responses.
 type AgentInfo struct {
-	ID   string `json:"id"`
-	Role string `json:"role"`
+	ID     string `json:"id"`
+	Role   string `json:"role"`
+	Status string `json:"status,omitempty"` // "active" or "dormant" (hibernating)
 }
 
 // HiveService is the interface the API server needs from the hive.
@@ -32,25 +37,65 @@ type HiveService interface {
 	GetAgent(id string) (*AgentInfo, bool)
 	ListTickets(filter ticket.Filter) ([]*protocol.Ticket, error)
 	GetTicket(id string) (*protocol.Ticket, error)
+	TicketEvents(id string) ([]protocol.TicketEvent, error)
+	TicketUsage(id string) (*protocol.TicketUsage, error)
 	InjectMessage(from, ticketID, content string) (string, error) // returns ticket ID
+	CreateTicket(req CreateTicketRequest) (*protocol.Ticket, error)
+	CloseTicket(id, summary string) error
+
+	// AgentMemory returns an agent's memory scopes and their content.
+	// SetAgentMemory replaces a scope; empty content deletes it.
+	AgentMemory(id string) (map[string]string, error)
+	SetAgentMemory(id, scope, content string) error
+
+	// AgentToolStats returns the agent's per-tool call counters.
+	AgentToolStats(id string) ([]tool.ToolStat, error)
+
+	// ListDeadLetters returns the messages that could not be delivered;
+	// ReplayDeadLetter delivers one again to the recipient it missed,
+	// failing with ticket.ErrDeadLetterNotFound for an unknown ID.
+	ListDeadLetters() ([]ticket.DeadLetter, error)
+	ReplayDeadLetter(id string) error
+
+	// SubscribeTicket streams messages added to a ticket from now on until
+	// the returned func is called.
+	SubscribeTicket(id string) (<-chan protocol.Message, func())
 }
 
-// Config holds API server configuration.
+// Token scopes. A write token can also read.
+const (
+	ScopeRead  = "read"
+	ScopeWrite = "write"
+)
+
+// Token is an additional API key limited to a scope.
+type Token struct {
+	Key   string
+	Scope string // ScopeRead or ScopeWrite
+}
+
+// Config holds API server configuration. With neither Key nor Tokens set,
+// the API is open.
 type Config struct {
-	Host string
-	Port int
-	Key  string // API key for Bearer auth
+	Host   string
+	Port   int
+	Key    string  // API key for Bearer auth, with full access
+	Tokens []Token // scoped keys, e.g. read-only tokens for dashboards
 }
 
 // Server is the h1v3 REST API server.
 type Server struct {
 	svc    HiveService
+	hives  map[string]HiveService
 	cfg    Config
 	logger *slog.Logger
 	logs   LogQuerier
 	srv    *http.Server
 }
 
+// hiveContextKey carries the hive resolved from /api/hives/{hive}/... routes.
+type hiveContextKey struct{}
+
 // NewServer creates a new API server. logs may be nil.
 func NewServer(svc HiveService, cfg Config, logger *slog.Logger, logs LogQuerier) *Server {
 	if logger == nil {
@@ -58,18 +103,44 @@ func NewServer(svc HiveService, cfg Config, logger *slog.Logger, logs LogQuerier
 	}
 	s := &Server{
 		svc:    svc,
+		hives:  make(map[string]HiveService),
 		cfg:    cfg,
 		logger: logger,
 		logs:   logs,
 	}
 	mux := http.NewServeMux()
 	mux.HandleFunc("GET /api/health", s.handleHealth)
-	mux.HandleFunc("GET /api/agents", s.requireAuth(s.handleListAgents))
-	mux.HandleFunc("GET /api/agents/{id}", s.requireAuth(s.handleGetAgent))
-	mux.HandleFunc("GET /api/tickets", s.requireAuth(s.handleListTickets))
-	mux.HandleFunc("GET /api/tickets/{id}", s.requireAuth(s.handleGetTicket))
-	mux.HandleFunc("POST /api/messages", s.requireAuth(s.handlePostMessage))
-	mux.HandleFunc("GET /api/logs", s.requireAuth(s.handleGetLogs))
+	mux.HandleFunc("GET /api/logs", s.requireAuth(ScopeRead, s.handleGetLogs))
+	mux.HandleFunc("GET /api/hives", s.requireAuth(ScopeRead, s.handleListHives))
+
+	// Hive routes are served for the default hive at /api/... and for any
+	// added hive at /api/hives/{hive}/....
+	hiveRoutes := []struct {
+		method, path, scope string
+		handler             http.HandlerFunc
+	}{
+		{"GET", "/agents", ScopeRead, s.handleListAgents},
+		{"GET", "/agents/{id}", ScopeRead, s.handleGetAgent},
+		{"GET", "/agents/{id}/memory", ScopeRead, s.handleGetAgentMemory},
+		{"PUT", "/agents/{id}/memory/{scope}", ScopeWrite, s.handlePutAgentMemory},
+		{"GET", "/agents/{id}/tools/stats", ScopeRead, s.handleGetAgentToolStats},
+		{"GET", "/tickets", ScopeRead, s.handleListTickets},
+		{"GET", "/tickets/{id}", ScopeRead, s.handleGetTicket},
+		{"GET", "/tickets/{id}/events", ScopeRead, s.handleGetTicketEvents},
+		{"GET", "/tickets/{id}/tree", ScopeRead, s.handleGetTicketTree},
+		{"GET", "/tickets/{id}/prompts", ScopeRead, s.handleGetTicketPrompts},
+		{"POST", "/tickets", ScopeWrite, s.handlePostTicket},
+		{"POST", "/tickets/{id}/close", ScopeWrite, s.handleCloseTicket},
+		{"POST", "/messages", ScopeWrite, s.handlePostMessage},
+		{"GET", "/deadletters", ScopeRead, s.handleListDeadLetters},
+		{"POST", "/deadletters/{id}/replay", ScopeWrite, s.handleReplayDeadLetter},
+		{"POST", "/chat", ScopeWrite, s.handlePostChat},
+		{"GET", "/chat/{session}", ScopeWrite, s.handleGetChat},
+	}
+	for _, rt := range hiveRoutes {
+		mux.HandleFunc(rt.method+" /api"+rt.path, s.requireAuth(rt.scope, rt.handler))
+		mux.HandleFunc(rt.method+" /api/hives/{hive}"+rt.path, s.requireAuth(rt.scope, s.hiveScoped(rt.handler)))
+	}
 
 	s.srv = &http.Server{
 		Addr:              fmt.Sprintf("%s:%d", cfg.Host, cfg.Port),
@@ -79,6 +150,11 @@ func NewServer(svc HiveService, cfg Config, logger *slog.Logger, logs LogQuerier
 	return s
 }
 
+// AddHive exposes another hive under /api/hives/{id}/. Call before Start.
+func (s *Server) AddHive(id string, svc HiveService) {
+	s.hives[id] = svc
+}
+
 // Start begins listening. Blocks until context is cancelled.
 func (s *Server) Start(ctx context.Context) error {
 	go func() {
@@ -105,7 +181,7 @@ func (s *Server) Handler() http.Handler {
 func (s *Server) corsMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, OPTIONS")
 		w.Header().Set("Access-Control-Allow-Headers", "Authorization, Content-Type")
 
 		if r.Method == http.MethodOptions {
@@ -116,35 +192,92 @@ func (s *Server) corsMiddleware(next http.Handler) http.Handler {
 	})
 }
 
-func (s *Server) requireAuth(next http.HandlerFunc) http.HandlerFunc {
+// requireAuth checks the Bearer token: 401 if it is missing or unknown, 403
+// if it is valid but lacks the route's scope.
+func (s *Server) requireAuth(scope string, next http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		if s.cfg.Key == "" {
+		if s.cfg.Key == "" && len(s.cfg.Tokens) == 0 {
 			next(w, r)
 			return
 		}
 		auth := r.Header.Get("Authorization")
-		if !strings.HasPrefix(auth, "Bearer ") || strings.TrimPrefix(auth, "Bearer ") != s.cfg.Key {
+		if !strings.HasPrefix(auth, "Bearer ") {
+			writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+			return
+		}
+		granted, ok := s.tokenScope(strings.TrimPrefix(auth, "Bearer "))
+		if !ok {
 			writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
 			return
 		}
+		if scope == ScopeWrite && granted != ScopeWrite {
+			writeJSON(w, http.StatusForbidden, map[string]string{"error": "forbidden: token lacks write scope"})
+			return
+		}
 		next(w, r)
 	}
 }
 
+// tokenScope returns the scope granted to a key. The main Key has write scope.
+func (s *Server) tokenScope(key string) (string, bool) {
+	if key == "" {
+		return "", false
+	}
+	if key == s.cfg.Key {
+		return ScopeWrite, true
+	}
+	for _, t := range s.cfg.Tokens {
+		if key == t.Key {
+			return t.Scope, true
+		}
+	}
+	return "", false
+}
+
+// hiveScoped resolves the {hive} path segment to a registered hive.
+func (s *Server) hiveScoped(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		svc, ok := s.hives[r.PathValue("hive")]
+		if !ok {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "hive not found"})
+			return
+		}
+		next(w, r.WithContext(context.WithValue(r.Context(), hiveContextKey{}, svc)))
+	}
+}
+
+// service returns the hive a request targets: the one resolved by
+// hiveScoped, or the default hive.
+func (s *Server) service(r *http.Request) HiveService {
+	if svc, ok := r.Context().Value(hiveContextKey{}).(HiveService); ok {
+		return svc
+	}
+	return s.svc
+}
+
 // --- Handlers ---
 
 func (s *Server) handleHealth(w http.ResponseWriter, _ *http.Request) {
 	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
 }
 
-func (s *Server) handleListAgents(w http.ResponseWriter, _ *http.Request) {
-	agents := s.svc.ListAgents()
+func (s *Server) handleListHives(w http.ResponseWriter, _ *http.Request) {
+	ids := make([]string, 0, len(s.hives))
+	for id := range s.hives {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	writeJSON(w, http.StatusOK, ids)
+}
+
+func (s *Server) handleListAgents(w http.ResponseWriter, r *http.Request) {
+	agents := s.service(r).ListAgents()
 	writeJSON(w, http.StatusOK, agents)
 }
 
 func (s *Server) handleGetAgent(w http.ResponseWriter, r *http.Request) {
 	id := r.PathValue("id")
-	agent, ok := s.svc.GetAgent(id)
+	agent, ok := s.service(r).GetAgent(id)
 	if !ok {
 		writeJSON(w, http.StatusNotFound, map[string]string{"error": "agent not found"})
 		return
@@ -152,6 +285,70 @@ func (s *Server) handleGetAgent(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, http.StatusOK, agent)
 }
 
+func (s *Server) handleGetAgentMemory(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if _, ok := s.service(r).GetAgent(id); !ok {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "agent not found"})
+		return
+	}
+	scopes, err := s.service(r).AgentMemory(id)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	if scopes == nil {
+		scopes = map[string]string{}
+	}
+	writeJSON(w, http.StatusOK, scopes)
+}
+
+// handleGetAgentToolStats returns how often the agent called each tool, most
+// used first. Tools it never called are left out.
+func (s *Server) handleGetAgentToolStats(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if _, ok := s.service(r).GetAgent(id); !ok {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "agent not found"})
+		return
+	}
+	stats, err := s.service(r).AgentToolStats(id)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	if stats == nil {
+		stats = []tool.ToolStat{}
+	}
+	writeJSON(w, http.StatusOK, stats)
+}
+
+type putMemoryRequest struct {
+	Content string `json:"content"`
+}
+
+// handlePutAgentMemory replaces one memory scope of an agent, or deletes it
+// when content is empty.
+func (s *Server) handlePutAgentMemory(w http.ResponseWriter, r *http.Request) {
+	id, scope := r.PathValue("id"), r.PathValue("scope")
+	if !memory.ValidScope(scope) {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid scope name"})
+		return
+	}
+	if _, ok := s.service(r).GetAgent(id); !ok {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "agent not found"})
+		return
+	}
+	var req putMemoryRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid JSON"})
+		return
+	}
+	if err := s.service(r).SetAgentMemory(id, scope, req.Content); err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok", "scope": scope})
+}
+
 func (s *Server) handleListTickets(w http.ResponseWriter, r *http.Request) {
 	filter := ticket.Filter{}
 	if status := r.URL.Query().Get("status"); status != "" {
@@ -164,13 +361,25 @@ func (s *Server) handleListTickets(w http.ResponseWriter, r *http.Request) {
 	if parentID := r.URL.Query().Get("parent_id"); parentID != "" {
 		filter.ParentID = parentID
 	}
+	if tags := r.URL.Query().Get("tags"); tags != "" {
+		filter.Tags = splitList(tags)
+	}
+	if anyTags := r.URL.Query().Get("any_tags"); anyTags != "" {
+		filter.AnyTags = splitList(anyTags)
+	}
 	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
 		if n, err := strconv.Atoi(limitStr); err == nil {
 			filter.Limit = n
 		}
 	}
+	if v := r.URL.Query().Get("include_archived"); v != "" {
+		filter.IncludeArchived, _ = strconv.ParseBool(v)
+	}
+	if v := r.URL.Query().Get("overdue"); v != "" {
+		filter.OverdueOnly, _ = strconv.ParseBool(v)
+	}
 
-	tickets, err := s.svc.ListTickets(filter)
+	tickets, err := s.service(r).ListTickets(filter)
 	if err != nil {
 		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
 		return
@@ -178,14 +387,180 @@ func (s *Server) handleListTickets(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, http.StatusOK, tickets)
 }
 
+// handleGetTicket returns the ticket with its messages and token usage.
 func (s *Server) handleGetTicket(w http.ResponseWriter, r *http.Request) {
 	id := r.PathValue("id")
-	t, err := s.svc.GetTicket(id)
+	t, err := s.service(r).GetTicket(id)
+	if err != nil {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "ticket not found"})
+		return
+	}
+	usage, err := s.service(r).TicketUsage(id)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, struct {
+		*protocol.Ticket
+		Usage *protocol.TicketUsage `json:"usage"`
+	}{t, usage})
+}
+
+// handleGetTicketEvents returns the ticket's event timeline, or with Accept:
+// text/event-stream streams its new messages and status changes live.
+func (s *Server) handleGetTicketEvents(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	t, err := s.service(r).GetTicket(id)
 	if err != nil {
 		writeJSON(w, http.StatusNotFound, map[string]string{"error": "ticket not found"})
 		return
 	}
-	writeJSON(w, http.StatusOK, t)
+	if wantsEventStream(r) {
+		s.streamTicket(w, r, t)
+		return
+	}
+	events, err := s.service(r).TicketEvents(id)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	if events == nil {
+		events = []protocol.TicketEvent{}
+	}
+	writeJSON(w, http.StatusOK, events)
+}
+
+// ticketStatusPoll is how often a ticket event stream checks for status
+// changes that came without a message.
+var ticketStatusPoll = 2 * time.Second
+
+// streamTicket sends a "status" event with the ticket's current status, then
+// a "message" event per new message and a "status" event whenever the status
+// changes, until the client disconnects. Status is checked after each
+// message and every ticketStatusPoll.
+func (s *Server) streamTicket(w http.ResponseWriter, r *http.Request, t *protocol.Ticket) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "streaming not supported"})
+		return
+	}
+	svc := s.service(r)
+	msgs, unsubscribe := svc.SubscribeTicket(t.ID)
+	defer unsubscribe()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+
+	status := t.Status
+	writeStatus := func() {
+		fmt.Fprintf(w, "event: status\ndata: {\"ticket_id\":%q,\"status\":%q}\n\n", t.ID, status)
+	}
+	checkStatus := func() bool {
+		cur, err := svc.GetTicket(t.ID)
+		if err != nil || cur.Status == status {
+			return false
+		}
+		status = cur.Status
+		writeStatus()
+		return true
+	}
+	writeStatus()
+	flusher.Flush()
+
+	poll := time.NewTicker(ticketStatusPoll)
+	defer poll.Stop()
+	lastWrite := time.Now()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case msg, ok := <-msgs:
+			if !ok {
+				return
+			}
+			data, _ := json.Marshal(msg)
+			fmt.Fprintf(w, "event: message\nid: %s\ndata: %s\n\n", msg.ID, data)
+			checkStatus()
+		case <-poll.C:
+			if !checkStatus() {
+				if time.Since(lastWrite) < sseKeepalive {
+					continue
+				}
+				fmt.Fprint(w, ": keepalive\n\n")
+			}
+		}
+		lastWrite = time.Now()
+		flusher.Flush()
+	}
+}
+
+// handleGetTicketTree returns the ticket's sub-ticket tree, nested up to the
+// depth query parameter, with each ticket's status and summary.
+func (s *Server) handleGetTicketTree(w http.ResponseWriter, r *http.Request) {
+	svc := s.service(r)
+	t, err := svc.GetTicket(r.PathValue("id"))
+	if err != nil {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "ticket not found"})
+		return
+	}
+	depth := ticket.DefaultTreeDepth
+	if d := r.URL.Query().Get("depth"); d != "" {
+		if n, err := strconv.Atoi(d); err == nil && n > 0 {
+			depth = n
+		}
+	}
+	tree, err := ticket.BuildTree(svc.ListTickets, t, depth)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, tree)
+}
+
+// PromptContext is the LLM input recorded for one message on a ticket.
+type PromptContext struct {
+	MsgID    string                 `json:"msg_id"`
+	Agent    string                 `json:"agent"`
+	Time     time.Time              `json:"time"`
+	Messages []protocol.ChatMessage `json:"messages"`
+}
+
+// handleGetTicketPrompts returns the prompt_context log entries recorded for
+// a ticket, with secrets redacted. Only entries still in the log buffer are
+// available.
+func (s *Server) handleGetTicketPrompts(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if _, err := s.service(r).GetTicket(id); err != nil {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "ticket not found"})
+		return
+	}
+
+	prompts := []PromptContext{}
+	if s.logs != nil {
+		for _, e := range s.logs.Query(logbuf.Filter{MinLevel: slog.LevelDebug, Ticket: id}) {
+			if e.Message != "prompt_context" {
+				continue
+			}
+			raw, _ := e.Attrs["context"].(string)
+			var msgs []protocol.ChatMessage
+			if err := json.Unmarshal([]byte(raw), &msgs); err != nil {
+				continue
+			}
+			for i := range msgs {
+				redactChatMessage(&msgs[i])
+			}
+			msgID, _ := e.Attrs["msg_id"].(string)
+			agentID, _ := e.Attrs["agent"].(string)
+			prompts = append(prompts, PromptContext{
+				MsgID:    msgID,
+				Agent:    agentID,
+				Time:     e.Time,
+				Messages: msgs,
+			})
+		}
+	}
+	writeJSON(w, http.StatusOK, prompts)
 }
 
 type postMessageRequest struct {
@@ -205,7 +580,7 @@ func (s *Server) handlePostMessage(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	ticketID, err := s.svc.InjectMessage(req.From, req.TicketID, req.Content)
+	ticketID, err := s.service(r).InjectMessage(req.From, req.TicketID, req.Content)
 	if err != nil {
 		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
 		return
@@ -213,6 +588,120 @@ func (s *Server) handlePostMessage(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, http.StatusAccepted, map[string]string{"status": "accepted", "ticket_id": ticketID})
 }
 
+// CreateTicketRequest is the body of POST /api/tickets. Message is the
+// ticket's first message; it defaults to the goal.
+type CreateTicketRequest struct {
+	From     string     `json:"from"`
+	Title    string     `json:"title"`
+	Goal     string     `json:"goal"`
+	Message  string     `json:"message,omitempty"`
+	To       []string   `json:"to"`
+	Tags     []string   `json:"tags,omitempty"`
+	Priority int        `json:"priority,omitempty"`
+	DueAt    *time.Time `json:"due_at,omitempty"` // RFC3339
+}
+
+// handlePostTicket creates a ticket for the given agents and routes its
+// first message to them.
+func (s *Server) handlePostTicket(w http.ResponseWriter, r *http.Request) {
+	var req CreateTicketRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid JSON"})
+		return
+	}
+	if req.Title == "" || req.Goal == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "title and goal are required"})
+		return
+	}
+	if len(req.To) == 0 {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "to must name at least one agent"})
+		return
+	}
+	svc := s.service(r)
+	var unknown []string
+	for _, id := range req.To {
+		if _, ok := svc.GetAgent(id); !ok {
+			unknown = append(unknown, id)
+		}
+	}
+	if len(unknown) > 0 {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error": "unknown agents", "unknown_agents": unknown})
+		return
+	}
+	if req.From == "" {
+		req.From = "api"
+	}
+	if req.Message == "" {
+		req.Message = req.Goal
+	}
+
+	t, err := svc.CreateTicket(req)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusCreated, map[string]string{"ticket_id": t.ID, "status": string(t.Status)})
+}
+
+type closeTicketRequest struct {
+	Summary string `json:"summary"`
+}
+
+// handleCloseTicket closes a ticket on an operator's behalf. As with
+// close_ticket, the summary is relayed to the parent ticket's creator.
+func (s *Server) handleCloseTicket(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	svc := s.service(r)
+	if _, err := svc.GetTicket(id); err != nil {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "ticket not found"})
+		return
+	}
+	var req closeTicketRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid JSON"})
+		return
+	}
+	if req.Summary == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "summary is required"})
+		return
+	}
+	if err := svc.CloseTicket(id, req.Summary); err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"ticket_id": id, "status": string(protocol.TicketClosed)})
+}
+
+// handleListDeadLetters returns the messages that could not be delivered,
+// oldest first.
+func (s *Server) handleListDeadLetters(w http.ResponseWriter, r *http.Request) {
+	letters, err := s.service(r).ListDeadLetters()
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	if letters == nil {
+		letters = []ticket.DeadLetter{}
+	}
+	writeJSON(w, http.StatusOK, letters)
+}
+
+// handleReplayDeadLetter delivers a dead letter again. It is kept, and the
+// request fails with 409, while its target is still missing or its ticket
+// is closed.
+func (s *Server) handleReplayDeadLetter(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if err := s.service(r).ReplayDeadLetter(id); err != nil {
+		if errors.Is(err, ticket.ErrDeadLetterNotFound) {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "dead letter not found"})
+			return
+		}
+		writeJSON(w, http.StatusConflict, map[string]string{"error": err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"status": "replayed", "id": id})
+}
+
 func (s *Server) handleGetLogs(w http.ResponseWriter, r *http.Request) {
 	if s.logs == nil {
 		writeJSON(w, http.StatusOK, []logbuf.Entry{})
@@ -244,8 +733,19 @@ func (s *Server) handleGetLogs(w http.ResponseWriter, r *http.Request) {
 			since = time.UnixMilli(ms)
 		}
 	}
+	var after uint64
+	if s := r.URL.Query().Get("after"); s != "" {
+		after, _ = strconv.ParseUint(s, 10, 64)
+	}
 
-	entries := s.logs.Query(since, minLevel, limit)
+	entries := s.logs.Query(logbuf.Filter{
+		Since:    since,
+		After:    after,
+		MinLevel: minLevel,
+		Limit:    limit,
+		Agent:    r.URL.Query().Get("agent"),
+		Ticket:   r.URL.Query().Get("ticket"),
+	})
 	if entries == nil {
 		entries = []logbuf.Entry{}
 	}
@@ -259,3 +759,26 @@ func writeJSON(w http.ResponseWriter, status int, v any) {
 	w.WriteHeader(status)
 	json.NewEncoder(w).Encode(v)
 }
+
+// redactChatMessage masks secrets in a message's content and tool arguments.
+func redactChatMessage(m *protocol.ChatMessage) {
+	m.Content = logbuf.Redact(m.Content)
+	for _, tc := range m.ToolCalls {
+		for k, v := range tc.Arguments {
+			if str, ok := v.(string); ok {
+				tc.Arguments[k] = logbuf.Redact(str)
+			}
+		}
+	}
+}
+
+// splitList parses a comma-separated query value, dropping empty entries.
+func splitList(s string) []string {
+	var out []string
+	for _, part := range strings.Split(s, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			out = append(out, part)
+		}
+	}
+	return out
+}
@@ -1,22 +1,38 @@
 package api
 
 import (
+	"bufio"
 	"encoding/json"
 	"fmt"
+	"log/slog"
 	"net/http"
 	"net/http/httptest"
+	"slices"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/h1v3-io/h1v3/internal/logbuf"
 	"github.com/h1v3-io/h1v3/internal/ticket"
+	"github.com/h1v3-io/h1v3/internal/tool"
 	"github.com/h1v3-io/h1v3/pkg/protocol"
 )
 
 // mockHiveService implements HiveService for testing.
 type mockHiveService struct {
-	agents   []AgentInfo
-	tickets  []*protocol.Ticket
-	injected []postMessageRequest
+	agents    []AgentInfo
+	tickets   []*protocol.Ticket
+	events    []protocol.TicketEvent
+	usage     map[string]*protocol.TicketUsage
+	injected  []postMessageRequest
+	created   []CreateTicketRequest
+	closed    map[string]string            // ticket ID -> summary
+	memory    map[string]map[string]string // agent ID -> scope -> content
+	toolStats map[string][]tool.ToolStat
+	dead      []ticket.DeadLetter
+	replayed  []string
+	stream    chan protocol.Message // messages for SubscribeTicket
+	unsub     chan struct{}         // closed when the subscription ends
 }
 
 func (m *mockHiveService) ListAgents() []AgentInfo { return m.agents }
@@ -28,8 +44,17 @@ func (m *mockHiveService) GetAgent(id string) (*AgentInfo, bool) {
 	}
 	return nil, false
 }
-func (m *mockHiveService) ListTickets(_ ticket.Filter) ([]*protocol.Ticket, error) {
-	return m.tickets, nil
+func (m *mockHiveService) ListTickets(filter ticket.Filter) ([]*protocol.Ticket, error) {
+	if filter.ParentID == "" {
+		return m.tickets, nil
+	}
+	var out []*protocol.Ticket
+	for _, t := range m.tickets {
+		if t.ParentID == filter.ParentID {
+			out = append(out, t)
+		}
+	}
+	return out, nil
 }
 func (m *mockHiveService) GetTicket(id string) (*protocol.Ticket, error) {
 	for _, t := range m.tickets {
@@ -39,6 +64,21 @@ func (m *mockHiveService) GetTicket(id string) (*protocol.Ticket, error) {
 	}
 	return nil, fmt.Errorf("not found")
 }
+func (m *mockHiveService) TicketEvents(id string) ([]protocol.TicketEvent, error) {
+	var out []protocol.TicketEvent
+	for _, ev := range m.events {
+		if ev.TicketID == id {
+			out = append(out, ev)
+		}
+	}
+	return out, nil
+}
+func (m *mockHiveService) TicketUsage(id string) (*protocol.TicketUsage, error) {
+	if u, ok := m.usage[id]; ok {
+		return u, nil
+	}
+	return &protocol.TicketUsage{Models: []protocol.ModelUsage{}}, nil
+}
 func (m *mockHiveService) InjectMessage(from, ticketID, content string) (string, error) {
 	m.injected = append(m.injected, postMessageRequest{From: from, TicketID: ticketID, Content: content})
 	if ticketID == "" {
@@ -47,6 +87,65 @@ func (m *mockHiveService) InjectMessage(from, ticketID, content string) (string,
 	return ticketID, nil
 }
 
+func (m *mockHiveService) CreateTicket(req CreateTicketRequest) (*protocol.Ticket, error) {
+	m.created = append(m.created, req)
+	return &protocol.Ticket{ID: "new-ticket-1", Title: req.Title, Status: protocol.TicketOpen}, nil
+}
+
+func (m *mockHiveService) CloseTicket(id, summary string) error {
+	if m.closed == nil {
+		m.closed = make(map[string]string)
+	}
+	m.closed[id] = summary
+	return nil
+}
+
+func (m *mockHiveService) SubscribeTicket(id string) (<-chan protocol.Message, func()) {
+	if m.stream == nil {
+		m.stream = make(chan protocol.Message)
+	}
+	return m.stream, func() {
+		if m.unsub != nil {
+			close(m.unsub)
+		}
+	}
+}
+
+func (m *mockHiveService) AgentMemory(id string) (map[string]string, error) {
+	return m.memory[id], nil
+}
+func (m *mockHiveService) AgentToolStats(id string) ([]tool.ToolStat, error) {
+	return m.toolStats[id], nil
+}
+func (m *mockHiveService) SetAgentMemory(id, scope, content string) error {
+	if m.memory == nil {
+		m.memory = make(map[string]map[string]string)
+	}
+	if m.memory[id] == nil {
+		m.memory[id] = make(map[string]string)
+	}
+	if content == "" {
+		delete(m.memory[id], scope)
+	} else {
+		m.memory[id][scope] = content
+	}
+	return nil
+}
+
+func (m *mockHiveService) ListDeadLetters() ([]ticket.DeadLetter, error) {
+	return m.dead, nil
+}
+func (m *mockHiveService) ReplayDeadLetter(id string) error {
+	if id == "d-stuck" {
+		return fmt.Errorf("target %q not found", "ghost")
+	}
+	if !slices.ContainsFunc(m.dead, func(d ticket.DeadLetter) bool { return d.ID == id }) {
+		return fmt.Errorf("replay: %w", ticket.ErrDeadLetterNotFound)
+	}
+	m.replayed = append(m.replayed, id)
+	return nil
+}
+
 func newTestServer(svc HiveService, key string) *Server {
 	return NewServer(svc, Config{Host: "127.0.0.1", Port: 0, Key: key}, nil, nil)
 }
@@ -144,6 +243,36 @@ func TestGetTicket(t *testing.T) {
 	}
 }
 
+func TestGetTicket_Usage(t *testing.T) {
+	cost := 0.5
+	svc := &mockHiveService{
+		tickets: []*protocol.Ticket{{ID: "t1", Title: "Task 1"}},
+		usage: map[string]*protocol.TicketUsage{"t1": {
+			Models:           []protocol.ModelUsage{{Model: "gpt-4o", Calls: 2, PromptTokens: 300, CompletionTokens: 40}},
+			Calls:            2,
+			PromptTokens:     300,
+			CompletionTokens: 40,
+			TotalTokens:      340,
+			EstimatedCostUSD: &cost,
+		}},
+	}
+	srv := newTestServer(svc, "")
+	req := httptest.NewRequest("GET", "/api/tickets/t1", nil)
+	w := httptest.NewRecorder()
+	srv.Handler().ServeHTTP(w, req)
+
+	var got struct {
+		Title string               `json:"title"`
+		Usage protocol.TicketUsage `json:"usage"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if got.Title != "Task 1" || got.Usage.TotalTokens != 340 || got.Usage.EstimatedCostUSD == nil || *got.Usage.EstimatedCostUSD != 0.5 {
+		t.Errorf("expected ticket with usage summary, got %s", w.Body.String())
+	}
+}
+
 func TestGetTicket_NotFound(t *testing.T) {
 	srv := newTestServer(&mockHiveService{}, "")
 	req := httptest.NewRequest("GET", "/api/tickets/nope", nil)
@@ -155,6 +284,177 @@ func TestGetTicket_NotFound(t *testing.T) {
 	}
 }
 
+func TestGetTicketEvents(t *testing.T) {
+	svc := &mockHiveService{
+		tickets: []*protocol.Ticket{{ID: "t1", Title: "Task 1"}},
+		events: []protocol.TicketEvent{
+			{ID: 1, TicketID: "t1", Type: protocol.EventCreated, Actor: "front"},
+			{ID: 2, TicketID: "t1", Type: protocol.EventStatusChanged, Actor: "coder", Detail: "open → awaiting_close"},
+		},
+	}
+	srv := newTestServer(svc, "")
+	req := httptest.NewRequest("GET", "/api/tickets/t1/events", nil)
+	w := httptest.NewRecorder()
+	srv.Handler().ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d", w.Code)
+	}
+	var events []protocol.TicketEvent
+	json.NewDecoder(w.Body).Decode(&events)
+	if len(events) != 2 || events[1].Type != protocol.EventStatusChanged {
+		t.Errorf("events = %+v", events)
+	}
+}
+
+func TestGetTicketEvents_NotFound(t *testing.T) {
+	srv := newTestServer(&mockHiveService{}, "")
+	req := httptest.NewRequest("GET", "/api/tickets/nope/events", nil)
+	w := httptest.NewRecorder()
+	srv.Handler().ServeHTTP(w, req)
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("status = %d, want 404", w.Code)
+	}
+}
+
+func TestGetTicketEvents_Stream(t *testing.T) {
+	svc := &mockHiveService{
+		tickets: []*protocol.Ticket{{ID: "t1", Title: "Task 1", Status: protocol.TicketOpen}},
+		stream:  make(chan protocol.Message, 1),
+		unsub:   make(chan struct{}),
+	}
+	ts := httptest.NewServer(newTestServer(svc, "").Handler())
+	defer ts.Close()
+
+	req, _ := http.NewRequest("GET", ts.URL+"/api/tickets/t1/events", nil)
+	req.Header.Set("Accept", "text/event-stream")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("request: %v", err)
+	}
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Errorf("Content-Type = %q", ct)
+	}
+	body := bufio.NewReader(resp.Body)
+	readEvent := func() string {
+		var ev strings.Builder
+		for {
+			line, err := body.ReadString('\n')
+			if err != nil {
+				t.Fatalf("read event: %v", err)
+			}
+			if line == "\n" {
+				return ev.String()
+			}
+			ev.WriteString(line)
+		}
+	}
+
+	if ev := readEvent(); !strings.Contains(ev, "event: status") || !strings.Contains(ev, `"status":"open"`) {
+		t.Errorf("expected the current status first, got %q", ev)
+	}
+	svc.stream <- protocol.Message{ID: "m1", From: "coder", TicketID: "t1", Content: "done"}
+	if ev := readEvent(); !strings.Contains(ev, "event: message\nid: m1\n") || !strings.Contains(ev, `"content":"done"`) {
+		t.Errorf("expected the message event, got %q", ev)
+	}
+
+	resp.Body.Close()
+	select {
+	case <-svc.unsub:
+	case <-time.After(3 * time.Second):
+		t.Error("subscription not ended after the client disconnected")
+	}
+}
+
+func TestGetTicketTree(t *testing.T) {
+	svc := &mockHiveService{
+		tickets: []*protocol.Ticket{
+			{ID: "t1", Title: "Root", Status: protocol.TicketOpen},
+			{ID: "t2", Title: "Child", Status: protocol.TicketClosed, Summary: "done", ParentID: "t1"},
+			{ID: "t3", Title: "Grandchild", Status: protocol.TicketOpen, ParentID: "t2"},
+		},
+	}
+	srv := newTestServer(svc, "")
+	req := httptest.NewRequest("GET", "/api/tickets/t1/tree", nil)
+	w := httptest.NewRecorder()
+	srv.Handler().ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d", w.Code)
+	}
+	var tree ticket.Node
+	json.NewDecoder(w.Body).Decode(&tree)
+	if tree.ID != "t1" || len(tree.Children) != 1 || tree.Children[0].Summary != "done" {
+		t.Fatalf("tree = %+v", tree)
+	}
+	if kids := tree.Children[0].Children; len(kids) != 1 || kids[0].ID != "t3" {
+		t.Errorf("expected t3 under t2, got %+v", kids)
+	}
+
+	req = httptest.NewRequest("GET", "/api/tickets/nope/tree", nil)
+	w = httptest.NewRecorder()
+	srv.Handler().ServeHTTP(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("status = %d, want 404", w.Code)
+	}
+}
+
+func TestHiveScopedRoutes(t *testing.T) {
+	alpha := &mockHiveService{agents: []AgentInfo{{ID: "front", Role: "Alpha front"}}}
+	beta := &mockHiveService{agents: []AgentInfo{{ID: "front", Role: "Beta front"}, {ID: "coder", Role: "Dev"}}}
+	srv := newTestServer(alpha, "")
+	srv.AddHive("alpha", alpha)
+	srv.AddHive("beta", beta)
+
+	req := httptest.NewRequest("GET", "/api/hives/beta/agents", nil)
+	w := httptest.NewRecorder()
+	srv.Handler().ServeHTTP(w, req)
+	var agents []AgentInfo
+	json.NewDecoder(w.Body).Decode(&agents)
+	if w.Code != http.StatusOK || len(agents) != 2 {
+		t.Errorf("beta agents: status = %d, got %v", w.Code, agents)
+	}
+
+	// Unscoped routes serve the default hive.
+	req = httptest.NewRequest("GET", "/api/agents", nil)
+	w = httptest.NewRecorder()
+	srv.Handler().ServeHTTP(w, req)
+	agents = nil
+	json.NewDecoder(w.Body).Decode(&agents)
+	if len(agents) != 1 || agents[0].Role != "Alpha front" {
+		t.Errorf("default agents = %v", agents)
+	}
+
+	body := `{"from":"user","content":"hi"}`
+	req = httptest.NewRequest("POST", "/api/hives/beta/messages", strings.NewReader(body))
+	w = httptest.NewRecorder()
+	srv.Handler().ServeHTTP(w, req)
+	if len(beta.injected) != 1 || len(alpha.injected) != 0 {
+		t.Errorf("message routed to wrong hive: alpha=%d beta=%d", len(alpha.injected), len(beta.injected))
+	}
+
+	req = httptest.NewRequest("GET", "/api/hives", nil)
+	w = httptest.NewRecorder()
+	srv.Handler().ServeHTTP(w, req)
+	var ids []string
+	json.NewDecoder(w.Body).Decode(&ids)
+	if len(ids) != 2 || ids[0] != "alpha" || ids[1] != "beta" {
+		t.Errorf("hives = %v", ids)
+	}
+}
+
+func TestHiveScopedRoutes_UnknownHive(t *testing.T) {
+	srv := newTestServer(&mockHiveService{}, "")
+	req := httptest.NewRequest("GET", "/api/hives/ghost/tickets", nil)
+	w := httptest.NewRecorder()
+	srv.Handler().ServeHTTP(w, req)
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("status = %d, want 404", w.Code)
+	}
+}
+
 func TestPostMessage(t *testing.T) {
 	svc := &mockHiveService{}
 	srv := newTestServer(svc, "")
@@ -188,6 +488,248 @@ func TestPostMessage_EmptyContent(t *testing.T) {
 	}
 }
 
+func TestPostTicket(t *testing.T) {
+	svc := &mockHiveService{agents: []AgentInfo{{ID: "coder"}, {ID: "reviewer"}}}
+	srv := newTestServer(svc, "")
+	body := `{"title":"Fix login","goal":"Users can log in","to":["coder"],"tags":["bug"]}`
+	req := httptest.NewRequest("POST", "/api/tickets", strings.NewReader(body))
+	w := httptest.NewRecorder()
+	srv.Handler().ServeHTTP(w, req)
+
+	if w.Code != http.StatusCreated {
+		t.Fatalf("status = %d, want 201, body = %s", w.Code, w.Body.String())
+	}
+	var resp map[string]string
+	json.NewDecoder(w.Body).Decode(&resp)
+	if resp["ticket_id"] != "new-ticket-1" || resp["status"] != "open" {
+		t.Errorf("unexpected response %v", resp)
+	}
+	if len(svc.created) != 1 {
+		t.Fatalf("expected 1 created ticket, got %d", len(svc.created))
+	}
+	if got := svc.created[0]; got.From != "api" || got.Message != "Users can log in" || len(got.Tags) != 1 {
+		t.Errorf("expected defaults for from and message, got %+v", got)
+	}
+}
+
+func TestPostTicket_Validation(t *testing.T) {
+	svc := &mockHiveService{agents: []AgentInfo{{ID: "coder"}}}
+	srv := newTestServer(svc, "")
+	post := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/api/tickets", strings.NewReader(body))
+		w := httptest.NewRecorder()
+		srv.Handler().ServeHTTP(w, req)
+		return w
+	}
+
+	w := post(`{"title":"T","goal":"G","to":["coder","ghost","nobody"]}`)
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want 400", w.Code)
+	}
+	var resp struct {
+		Unknown []string `json:"unknown_agents"`
+	}
+	json.NewDecoder(w.Body).Decode(&resp)
+	if strings.Join(resp.Unknown, ",") != "ghost,nobody" {
+		t.Errorf("unknown_agents = %v", resp.Unknown)
+	}
+
+	for _, body := range []string{`{"goal":"G","to":["coder"]}`, `{"title":"T","goal":"G"}`, `not json`} {
+		if w := post(body); w.Code != http.StatusBadRequest {
+			t.Errorf("%s: status = %d, want 400", body, w.Code)
+		}
+	}
+	if len(svc.created) != 0 {
+		t.Errorf("expected no tickets created, got %d", len(svc.created))
+	}
+}
+
+func TestDeadLetters(t *testing.T) {
+	svc := &mockHiveService{dead: []ticket.DeadLetter{
+		{ID: "d-1", Target: "support", Reason: "target not found", Message: protocol.Message{TicketID: "t1", Content: "hi"}},
+		{ID: "d-stuck", Target: "ghost", Reason: "target not found"},
+	}}
+	srv := newTestServer(svc, "")
+
+	w := httptest.NewRecorder()
+	srv.Handler().ServeHTTP(w, httptest.NewRequest("GET", "/api/deadletters", nil))
+	var got []ticket.DeadLetter
+	if err := json.NewDecoder(w.Body).Decode(&got); err != nil || len(got) != 2 || got[0].Message.Content != "hi" {
+		t.Fatalf("list = %d %+v, %v", w.Code, got, err)
+	}
+
+	replay := func(id string) int {
+		w := httptest.NewRecorder()
+		srv.Handler().ServeHTTP(w, httptest.NewRequest("POST", "/api/deadletters/"+id+"/replay", nil))
+		return w.Code
+	}
+	if code := replay("d-1"); code != http.StatusOK || len(svc.replayed) != 1 {
+		t.Errorf("replay: status = %d, replayed %v", code, svc.replayed)
+	}
+	if code := replay("d-stuck"); code != http.StatusConflict {
+		t.Errorf("replay to a missing target: status = %d, want 409", code)
+	}
+	if code := replay("d-nope"); code != http.StatusNotFound {
+		t.Errorf("unknown dead letter: status = %d, want 404", code)
+	}
+}
+
+func TestCloseTicket(t *testing.T) {
+	svc := &mockHiveService{tickets: []*protocol.Ticket{{ID: "t1", Status: protocol.TicketOpen}}}
+	srv := newTestServer(svc, "secret")
+	post := func(path, key, body string) int {
+		req := httptest.NewRequest("POST", path, strings.NewReader(body))
+		if key != "" {
+			req.Header.Set("Authorization", "Bearer "+key)
+		}
+		w := httptest.NewRecorder()
+		srv.Handler().ServeHTTP(w, req)
+		return w.Code
+	}
+
+	if code := post("/api/tickets/t1/close", "", `{"summary":"stuck"}`); code != http.StatusUnauthorized {
+		t.Errorf("without a key: status = %d, want 401", code)
+	}
+	if code := post("/api/tickets/nope/close", "secret", `{"summary":"stuck"}`); code != http.StatusNotFound {
+		t.Errorf("unknown ticket: status = %d, want 404", code)
+	}
+	if code := post("/api/tickets/t1/close", "secret", `{}`); code != http.StatusBadRequest {
+		t.Errorf("no summary: status = %d, want 400", code)
+	}
+	if len(svc.closed) != 0 {
+		t.Fatalf("expected nothing closed yet, got %v", svc.closed)
+	}
+	if code := post("/api/tickets/t1/close", "secret", `{"summary":"Resolved by ops"}`); code != http.StatusOK {
+		t.Errorf("status = %d, want 200", code)
+	}
+	if svc.closed["t1"] != "Resolved by ops" {
+		t.Errorf("closed = %v", svc.closed)
+	}
+}
+
+func TestAuth_ScopedTokens(t *testing.T) {
+	svc := &mockHiveService{}
+	srv := NewServer(svc, Config{
+		Key:    "admin-key",
+		Tokens: []Token{{Key: "dash-key", Scope: ScopeRead}, {Key: "bot-key", Scope: ScopeWrite}},
+	}, nil, nil)
+
+	do := func(method, path, key string) int {
+		var body *strings.Reader
+		if method == "POST" {
+			body = strings.NewReader(`{"content":"hi"}`)
+		} else {
+			body = strings.NewReader("")
+		}
+		req := httptest.NewRequest(method, path, body)
+		if key != "" {
+			req.Header.Set("Authorization", "Bearer "+key)
+		}
+		w := httptest.NewRecorder()
+		srv.Handler().ServeHTTP(w, req)
+		return w.Code
+	}
+
+	cases := []struct {
+		method, path, key string
+		want              int
+	}{
+		{"GET", "/api/tickets", "dash-key", http.StatusOK},
+		{"GET", "/api/logs", "dash-key", http.StatusOK},
+		{"POST", "/api/messages", "dash-key", http.StatusForbidden},
+		{"POST", "/api/messages", "bot-key", http.StatusAccepted},
+		{"POST", "/api/messages", "admin-key", http.StatusAccepted},
+		{"POST", "/api/messages", "nope", http.StatusUnauthorized},
+		{"GET", "/api/tickets", "", http.StatusUnauthorized},
+	}
+	for _, c := range cases {
+		if got := do(c.method, c.path, c.key); got != c.want {
+			t.Errorf("%s %s with %q: status = %d, want %d", c.method, c.path, c.key, got, c.want)
+		}
+	}
+	if len(svc.injected) != 2 {
+		t.Errorf("expected 2 injected messages, got %d", len(svc.injected))
+	}
+}
+
+func TestAgentMemory(t *testing.T) {
+	svc := &mockHiveService{
+		agents: []AgentInfo{{ID: "coder", Role: "Dev"}},
+		memory: map[string]map[string]string{"coder": {"project": "Uses Go 1.24"}},
+	}
+	srv := NewServer(svc, Config{
+		Key:    "admin-key",
+		Tokens: []Token{{Key: "dash-key", Scope: ScopeRead}},
+	}, nil, nil)
+
+	do := func(method, path, key, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer "+key)
+		w := httptest.NewRecorder()
+		srv.Handler().ServeHTTP(w, req)
+		return w
+	}
+
+	w := do("GET", "/api/agents/coder/memory", "dash-key", "")
+	var scopes map[string]string
+	json.NewDecoder(w.Body).Decode(&scopes)
+	if w.Code != http.StatusOK || scopes["project"] != "Uses Go 1.24" {
+		t.Errorf("GET memory: status %d, scopes %v", w.Code, scopes)
+	}
+
+	cases := []struct {
+		name, method, path, key, body string
+		want                          int
+	}{
+		{"unknown agent", "GET", "/api/agents/ghost/memory", "dash-key", "", http.StatusNotFound},
+		{"read token cannot write", "PUT", "/api/agents/coder/memory/team", "dash-key", `{"content":"x"}`, http.StatusForbidden},
+		{"invalid scope", "PUT", "/api/agents/coder/memory/..secret", "admin-key", `{"content":"x"}`, http.StatusBadRequest},
+		{"invalid JSON", "PUT", "/api/agents/coder/memory/team", "admin-key", `nope`, http.StatusBadRequest},
+		{"unknown agent write", "PUT", "/api/agents/ghost/memory/team", "admin-key", `{"content":"x"}`, http.StatusNotFound},
+		{"set", "PUT", "/api/agents/coder/memory/team", "admin-key", `{"content":"Alice owns billing"}`, http.StatusOK},
+		{"clear", "PUT", "/api/agents/coder/memory/project", "admin-key", `{"content":""}`, http.StatusOK},
+	}
+	for _, tc := range cases {
+		if w := do(tc.method, tc.path, tc.key, tc.body); w.Code != tc.want {
+			t.Errorf("%s: status = %d, want %d (%s)", tc.name, w.Code, tc.want, w.Body.String())
+		}
+	}
+
+	got := svc.memory["coder"]
+	if got["team"] != "Alice owns billing" {
+		t.Errorf("team scope = %q", got["team"])
+	}
+	if _, ok := got["project"]; ok {
+		t.Error("expected project scope to be cleared")
+	}
+}
+
+func TestAgentToolStats(t *testing.T) {
+	svc := &mockHiveService{
+		agents:    []AgentInfo{{ID: "coder", Role: "Dev"}, {ID: "idle", Role: "Dev"}},
+		toolStats: map[string][]tool.ToolStat{"coder": {{Name: "exec", Calls: 3, Failures: 1, TotalMillis: 120}}},
+	}
+	srv := newTestServer(svc, "")
+	get := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		srv.Handler().ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		return w
+	}
+
+	w := get("/api/agents/coder/tools/stats")
+	var stats []tool.ToolStat
+	json.NewDecoder(w.Body).Decode(&stats)
+	if w.Code != http.StatusOK || len(stats) != 1 || stats[0].Name != "exec" || stats[0].Failures != 1 {
+		t.Errorf("coder stats: status %d, %+v", w.Code, stats)
+	}
+	if w := get("/api/agents/idle/tools/stats"); w.Code != http.StatusOK || strings.TrimSpace(w.Body.String()) != "[]" {
+		t.Errorf("idle agent: status %d, body %s", w.Code, w.Body.String())
+	}
+	if w := get("/api/agents/ghost/tools/stats"); w.Code != http.StatusNotFound {
+		t.Errorf("unknown agent: status %d", w.Code)
+	}
+}
+
 func TestAuth_Required(t *testing.T) {
 	srv := newTestServer(&mockHiveService{}, "secret-key")
 
@@ -246,3 +788,67 @@ func TestCORS(t *testing.T) {
 		t.Errorf("CORS origin = %q", got)
 	}
 }
+
+// stubLogs records the filter it was queried with and returns entries.
+type stubLogs struct {
+	entries []logbuf.Entry
+	got     logbuf.Filter
+}
+
+func (l *stubLogs) Query(f logbuf.Filter) []logbuf.Entry {
+	l.got = f
+	return l.entries
+}
+
+func TestGetLogs_AgentAndTicketFilter(t *testing.T) {
+	logs := &stubLogs{}
+	srv := NewServer(&mockHiveService{}, Config{}, nil, logs)
+
+	req := httptest.NewRequest("GET", "/api/logs?agent=coder&ticket=tk-1&level=warn&limit=5&after=42", nil)
+	w := httptest.NewRecorder()
+	srv.Handler().ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d", w.Code)
+	}
+	want := logbuf.Filter{After: 42, MinLevel: slog.LevelWarn, Limit: 5, Agent: "coder", Ticket: "tk-1"}
+	if logs.got != want {
+		t.Errorf("filter = %+v, want %+v", logs.got, want)
+	}
+}
+
+func TestGetTicketPrompts(t *testing.T) {
+	ctxJSON := `[{"role":"system","content":"be nice"},{"role":"tool","content":"token=abcdef123456 ok"}]`
+	logs := &stubLogs{entries: []logbuf.Entry{
+		{Message: "tool call: exec", Attrs: map[string]any{"ticket": "t1"}},
+		{Message: "prompt_context", Attrs: map[string]any{"msg_id": "m1", "agent": "coder", "ticket": "t1", "context": ctxJSON}},
+	}}
+	svc := &mockHiveService{tickets: []*protocol.Ticket{{ID: "t1"}}}
+	srv := NewServer(svc, Config{}, nil, logs)
+
+	req := httptest.NewRequest("GET", "/api/tickets/t1/prompts", nil)
+	w := httptest.NewRecorder()
+	srv.Handler().ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d", w.Code)
+	}
+	if logs.got.Ticket != "t1" {
+		t.Errorf("expected logs filtered by ticket, got %+v", logs.got)
+	}
+	var prompts []PromptContext
+	json.NewDecoder(w.Body).Decode(&prompts)
+	if len(prompts) != 1 || prompts[0].MsgID != "m1" || prompts[0].Agent != "coder" || len(prompts[0].Messages) != 2 {
+		t.Fatalf("prompts = %+v", prompts)
+	}
+	if got := prompts[0].Messages[1].Content; got != "token=[REDACTED] ok" {
+		t.Errorf("expected secret redacted, got %q", got)
+	}
+
+	req = httptest.NewRequest("GET", "/api/tickets/nope/prompts", nil)
+	w = httptest.NewRecorder()
+	srv.Handler().ServeHTTP(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("unknown ticket status = %d", w.Code)
+	}
+}
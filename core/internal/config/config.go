@@ -3,10 +3,14 @@ package config
 import (
 	"encoding/json"
 	"fmt"
+	"net/url"
 	"os"
 	"path/filepath"
+	"regexp"
+	"slices"
 	"strconv"
 	"strings"
+	"unicode"
 
 	"github.com/h1v3-io/h1v3/pkg/protocol"
 )
@@ -15,10 +19,26 @@ import (
 type Config struct {
 	Hive       HiveConfig                `json:"hive"`
 	Agents     []protocol.AgentSpec      `json:"agents"`
+	Templates  []protocol.TicketTemplate `json:"templates,omitempty"`
 	Providers  map[string]ProviderConfig `json:"providers"`
 	Connectors ConnectorConfig           `json:"connectors"`
 	Tools      ToolsConfig               `json:"tools"`
 	API        APIConfig                 `json:"api"`
+	Logging    LoggingConfig             `json:"logging,omitempty"`
+	HTTP       HTTPConfig                `json:"http,omitempty"`
+
+	// Hives runs several isolated hives in one daemon. When empty, the
+	// top-level Hive, Agents and Connectors form the only hive.
+	Hives []HiveSpec `json:"hives,omitempty"`
+}
+
+// HiveSpec describes one tenant hive. Providers, tools and the API server are
+// shared across hives; agents, tickets and connectors are not.
+type HiveSpec struct {
+	Hive       HiveConfig                `json:"hive"`
+	Agents     []protocol.AgentSpec      `json:"agents"`
+	Templates  []protocol.TicketTemplate `json:"templates,omitempty"` // default: the top-level templates
+	Connectors ConnectorConfig           `json:"connectors"`
 }
 
 // HiveConfig holds hive-level settings.
@@ -29,24 +49,177 @@ type HiveConfig struct {
 	CompactThreshold int      `json:"compact_threshold"`
 	PresetFile       string   `json:"preset_file,omitempty"`
 	SkillPaths       []string `json:"skill_paths,omitempty"` // extra relative paths to scan for skills per agent
+	DBPath           string   `json:"db_path,omitempty"`     // ticket database; relative paths are under data_dir (default "tickets.db")
+
+	TicketRetentionDays  int `json:"ticket_retention_days,omitempty"`  // archive tickets closed longer than this; 0 = keep forever
+	InboundDedupSeconds  int `json:"inbound_dedup_seconds,omitempty"`  // drop repeated inbound messages within this window; 0 = off
+	HistoryWindow        int `json:"history_window,omitempty"`         // recent ticket messages loaded per agent turn; 0 = default (100)
+	IdleHibernateSeconds int `json:"idle_hibernate_seconds,omitempty"` // stop idle agent workers after this long, restarting on the next message; 0 = off
+	ShutdownGraceSeconds int `json:"shutdown_grace_seconds,omitempty"` // on SIGINT/SIGTERM, wait this long for agents to finish their work; 0 = default (30)
+
+	// FrontAgentIDs fans inbound chat messages out to several front agents;
+	// the first is the primary. FrontReplyPolicy picks whose replies reach
+	// the user: "primary" (default) or "first" to answer.
+	FrontAgentIDs    []string `json:"front_agent_ids,omitempty"`
+	FrontReplyPolicy string   `json:"front_reply_policy,omitempty"`
+
+	// BaseInstructions and BaseScopedContexts are hive-wide policy merged
+	// into every agent at load time. BaseMode picks how: "append" (default)
+	// puts the base first and the agent's own text after it; "override"
+	// uses the agent's value instead of the base wherever the agent sets one.
+	BaseInstructions   string            `json:"base_instructions,omitempty"`
+	BaseScopedContexts map[string]string `json:"base_scoped_contexts,omitempty"`
+	BaseMode           string            `json:"base_mode,omitempty"`
+
+	// Rules populate the "# Rules" section of every agent's system prompt,
+	// merged with each agent's own rules per BaseMode. Configured rules
+	// replace the built-in core rules unless KeepDefaultRules is set.
+	Rules            []string `json:"rules,omitempty"`
+	KeepDefaultRules bool     `json:"keep_default_rules,omitempty"`
+
+	// Nudge controls how workers handle a turn that ends in plain text
+	// instead of a respond_to_ticket call. Nil re-prompts once in English.
+	Nudge *NudgeConfig `json:"nudge,omitempty"`
+
+	// RelayMode picks what a closed sub-ticket relays into its parent:
+	// "full" (default) the whole conversation, "compact" the summary and
+	// last message, "condensed" an LLM-written handoff.
+	RelayMode string `json:"relay_mode,omitempty"`
+
+	// InboxPolicy is the backpressure policy for agents that do not set
+	// inbox_policy themselves; InboxBlockSeconds is how long "block" waits
+	// for space (default 5).
+	InboxPolicy       string `json:"inbox_policy,omitempty"`
+	InboxBlockSeconds int    `json:"inbox_block_seconds,omitempty"`
+
+	// SelfDelivery lets a message addressed to its own sender reach the
+	// sender's inbox. By default the sender is skipped.
+	SelfDelivery bool `json:"self_delivery,omitempty"`
+}
+
+// NudgeConfig configures the plain-text nudge. With AutoWrap the text is sent
+// via respond_to_ticket without re-prompting; otherwise the agent is
+// re-prompted with Message up to MaxRetries times (default 1), and
+// DeliverOnGiveUp sends its last text rather than dropping it.
+type NudgeConfig struct {
+	Message         string `json:"message,omitempty"`
+	MaxRetries      int    `json:"max_retries,omitempty"`
+	AutoWrap        bool   `json:"auto_wrap,omitempty"`
+	DeliverOnGiveUp bool   `json:"deliver_on_give_up,omitempty"`
+}
+
+// TicketDBPath returns where the hive's ticket database lives: DBPath, taken
+// relative to DataDir unless absolute, or {data_dir}/tickets.db.
+func (h HiveConfig) TicketDBPath() string {
+	if h.DBPath == "" {
+		return filepath.Join(h.DataDir, "tickets.db")
+	}
+	if filepath.IsAbs(h.DBPath) {
+		return h.DBPath
+	}
+	return filepath.Join(h.DataDir, h.DBPath)
+}
+
+// Base merge modes for HiveConfig.BaseMode.
+const (
+	BaseAppend   = "append"
+	BaseOverride = "override"
+)
+
+// Sub-ticket relay modes for HiveConfig.RelayMode.
+const (
+	RelayFull      = "full"
+	RelayCompact   = "compact"
+	RelayCondensed = "condensed"
+)
+
+// HiveSpecs returns the hives to run. A hive without its own data_dir gets
+// {hive.data_dir}/hives/{id} so tenants never share a ticket store.
+func (c *Config) HiveSpecs() []HiveSpec {
+	if len(c.Hives) == 0 {
+		return []HiveSpec{{Hive: c.Hive, Agents: c.Agents, Templates: c.Templates, Connectors: c.Connectors}}
+	}
+	specs := make([]HiveSpec, len(c.Hives))
+	for i, hs := range c.Hives {
+		hs.Hive.DataDir = c.hiveDataDir(hs.Hive)
+		if len(hs.Templates) == 0 {
+			hs.Templates = c.Templates
+		}
+		specs[i] = hs
+	}
+	return specs
+}
+
+// hiveDataDir returns h's data_dir, or {hive.data_dir}/hives/{id} if unset.
+func (c *Config) hiveDataDir(h HiveConfig) string {
+	if h.DataDir != "" {
+		return h.DataDir
+	}
+	return filepath.Join(c.Hive.DataDir, "hives", h.ID)
 }
 
 // PresetFile is the structure of a preset JSON file.
 type PresetFile struct {
-	Agents []protocol.AgentSpec `json:"agents"`
+	Agents    []protocol.AgentSpec      `json:"agents"`
+	Templates []protocol.TicketTemplate `json:"templates,omitempty"`
 }
 
 // ProviderConfig holds LLM provider settings.
 type ProviderConfig struct {
-	Type    string `json:"type,omitempty"` // "openai" (default) or "anthropic"
-	APIKey  string `json:"api_key"`
+	Type    string `json:"type,omitempty"` // "openai" (default), "anthropic" or "ollama"
+	APIKey  string `json:"api_key"`        // optional for "ollama"
 	BaseURL string `json:"base_url,omitempty"`
 	Model   string `json:"model"`
+
+	// MaxTokens is the completion limit sent when an agent does not set
+	// max_tokens. 0 = provider default (4096 for Anthropic, none for OpenAI).
+	MaxTokens int `json:"max_tokens,omitempty"`
+
+	// EmbeddingModel is the model OpenAI-compatible providers embed memory
+	// notes with. Setting it gives the provider's agents
+	// semantic_search_memory; unset, the tool is not offered.
+	EmbeddingModel string `json:"embedding_model,omitempty"`
+
+	// PromptCaching marks Anthropic tool definitions and system prompts as
+	// cacheable, cutting the input cost of repeated prompts.
+	PromptCaching bool `json:"prompt_caching,omitempty"`
+
+	// Reasoning forces OpenAI reasoning-model request handling (developer
+	// role, no temperature) on or off; unset decides from the model name.
+	Reasoning *bool `json:"reasoning,omitempty"`
+
+	// Capabilities overrides what the built-in table says the model
+	// supports; unset fields keep the built-in value.
+	Capabilities *ModelCapabilities `json:"capabilities,omitempty"`
+
+	// MaxRetries is how often a call that failed with a network error, 429
+	// or 502/503/504 is retried before giving up; unset = 2, 0 = never.
+	MaxRetries *int `json:"max_retries,omitempty"`
+	// RetryBaseDelayMS is the wait before the first retry, doubled for each
+	// further one (default 1000).
+	RetryBaseDelayMS int `json:"retry_base_delay_ms,omitempty"`
+}
+
+// ModelCapabilities overrides a provider model's capabilities, e.g. for an
+// OpenAI-compatible endpoint serving a model the daemon does not know.
+type ModelCapabilities struct {
+	SupportsTools  *bool `json:"supports_tools,omitempty"`
+	SupportsJSON   *bool `json:"supports_json,omitempty"`
+	SupportsVision *bool `json:"supports_vision,omitempty"`
+	MaxContext     int   `json:"max_context,omitempty"` // tokens
+	MaxOutput      int   `json:"max_output,omitempty"`  // tokens
 }
 
 // ConnectorConfig holds settings for external platform connectors.
 type ConnectorConfig struct {
 	Telegram *TelegramConfig `json:"telegram,omitempty"`
+	Slack    *SlackConfig    `json:"slack,omitempty"`
+	Discord  *DiscordConfig  `json:"discord,omitempty"`
+	HTTP     *HTTPChatConfig `json:"http,omitempty"`
+
+	// OutboundWebhooks are callback URLs, by name, that the "_webhook"
+	// participant posts ticket messages to.
+	OutboundWebhooks map[string]OutboundWebhookConfig `json:"outbound_webhooks,omitempty"`
 }
 
 // TelegramConfig holds Telegram bot settings.
@@ -54,20 +227,140 @@ type TelegramConfig struct {
 	Token     string  `json:"token"`
 	AgentID   string  `json:"agent_id,omitempty"`
 	AllowFrom []int64 `json:"allow_from,omitempty"`
+
+	// DeliveryReceipts appends a _system note to the ticket each time a
+	// reply is delivered to the chat.
+	DeliveryReceipts bool `json:"delivery_receipts,omitempty"`
+}
+
+// SlackConfig holds Slack Socket Mode bot settings.
+type SlackConfig struct {
+	BotToken string   `json:"bot_token"`
+	AppToken string   `json:"app_token"`
+	AgentID  string   `json:"agent_id,omitempty"`
+	Channels []string `json:"channels,omitempty"` // empty = all channels the bot is in
+
+	// DeliveryReceipts appends a _system note to the ticket each time a
+	// reply is delivered to the channel.
+	DeliveryReceipts bool `json:"delivery_receipts,omitempty"`
+}
+
+// DiscordConfig holds Discord bot settings. A message is accepted if its
+// server is in Guilds or its channel (or a thread's parent channel) is in
+// Channels; with both empty, every message the bot can see is accepted.
+type DiscordConfig struct {
+	Token    string   `json:"token"`
+	AgentID  string   `json:"agent_id,omitempty"`
+	Guilds   []string `json:"guilds,omitempty"`
+	Channels []string `json:"channels,omitempty"`
+
+	// DeliveryReceipts appends a _system note to the ticket each time a
+	// reply is delivered to the channel.
+	DeliveryReceipts bool `json:"delivery_receipts,omitempty"`
+}
+
+// HTTPChatConfig enables chat sessions over the API (/api/chat) for custom
+// frontends.
+type HTTPChatConfig struct {
+	AgentID string `json:"agent_id,omitempty"`
+
+	// MaxQueued caps the replies kept for a session until a client fetches
+	// them (default 100); IdleTimeoutSeconds drops sessions nobody has
+	// polled for that long (default 3600).
+	MaxQueued          int `json:"max_queued,omitempty"`
+	IdleTimeoutSeconds int `json:"idle_timeout_seconds,omitempty"`
+
+	// DeliveryReceipts appends a _system note to the ticket each time a
+	// reply is queued for the session.
+	DeliveryReceipts bool `json:"delivery_receipts,omitempty"`
+}
+
+// OutboundWebhookConfig is one outbound webhook endpoint. Secret signs the
+// body (X-Hub-Signature-256); BearerToken is sent as Authorization.
+type OutboundWebhookConfig struct {
+	URL         string `json:"url"`
+	Secret      string `json:"secret,omitempty"`
+	BearerToken string `json:"bearer_token,omitempty"`
 }
 
 // ToolsConfig holds tool-level settings.
 type ToolsConfig struct {
-	ShellTimeout   int      `json:"shell_timeout,omitempty"`    // seconds, default 30
+	ShellTimeout   int      `json:"shell_timeout,omitempty"`    // seconds, default 60
 	BlockedCommands []string `json:"blocked_commands,omitempty"`
 	BraveAPIKey    string   `json:"brave_api_key,omitempty"`
+	ExecMaxOutput  int      `json:"exec_max_output,omitempty"` // bytes of exec output returned to the model, default 10240
+	ExecLogOutput  bool     `json:"exec_log_output,omitempty"` // log exec output lines as they arrive
+
+	// ModelCosts prices models in US dollars per million tokens, keyed by
+	// model name, for the estimated cost in ticket usage summaries.
+	ModelCosts map[string]protocol.ModelCost `json:"model_costs,omitempty"`
 }
 
 // APIConfig holds REST API server settings.
 type APIConfig struct {
-	Host string `json:"host"`
-	Port int    `json:"port"`
-	Key  string `json:"api_key"`
+	Host   string     `json:"host"`
+	Port   int        `json:"port"`
+	Key    string     `json:"api_key"`
+	Tokens []APIToken `json:"tokens,omitempty"` // extra keys with limited scope
+}
+
+// APIToken is an API key limited to a scope: "read" (GET routes only) or
+// "write" (everything).
+type APIToken struct {
+	Key   string `json:"key"`
+	Scope string `json:"scope"`
+}
+
+// LoggingConfig holds log output settings.
+type LoggingConfig struct {
+	// RedactPatterns are regular expressions masked in log messages and
+	// attributes, on top of the built-in credential patterns. A pattern with
+	// a capture group keeps the group and masks the rest of the match.
+	RedactPatterns []string `json:"redact_patterns,omitempty"`
+
+	Format     string `json:"format,omitempty"`       // "json" (default) or "text"
+	File       string `json:"file,omitempty"`         // log file path; empty writes to stdout
+	MaxSizeMB  int    `json:"max_size_mb,omitempty"`  // rotate the file at this size (default 100)
+	MaxBackups int    `json:"max_backups,omitempty"`  // rotated files kept; 0 keeps all
+	MaxAgeDays int    `json:"max_age_days,omitempty"` // rotated files older than this are removed; 0 keeps all
+	// Buffer keeps recent entries in memory for /api/logs and ticket
+	// timelines (default true).
+	Buffer *bool `json:"buffer,omitempty"`
+	// BufferFile persists the buffer as JSON lines so recent entries are
+	// reloaded after a restart or crash.
+	BufferFile string `json:"buffer_file,omitempty"`
+}
+
+// BufferEnabled reports whether the in-memory log buffer is kept.
+func (l LoggingConfig) BufferEnabled() bool {
+	return l.Buffer == nil || *l.Buffer
+}
+
+// Secrets returns the credentials configured for the daemon (provider keys,
+// connector tokens, API key) so they can be redacted from logs.
+func (c *Config) Secrets() []string {
+	secrets := []string{c.API.Key, c.Tools.BraveAPIKey}
+	for _, t := range c.API.Tokens {
+		secrets = append(secrets, t.Key)
+	}
+	for _, p := range c.Providers {
+		secrets = append(secrets, p.APIKey)
+	}
+	for _, hs := range c.HiveSpecs() {
+		if hs.Connectors.Telegram != nil {
+			secrets = append(secrets, hs.Connectors.Telegram.Token)
+		}
+		if hs.Connectors.Slack != nil {
+			secrets = append(secrets, hs.Connectors.Slack.BotToken, hs.Connectors.Slack.AppToken)
+		}
+		if hs.Connectors.Discord != nil {
+			secrets = append(secrets, hs.Connectors.Discord.Token)
+		}
+		for _, w := range hs.Connectors.OutboundWebhooks {
+			secrets = append(secrets, w.Secret, w.BearerToken)
+		}
+	}
+	return secrets
 }
 
 // Load reads configuration from a JSON file.
@@ -84,7 +377,9 @@ func Load(path string) (*Config, error) {
 
 	cfg.resolveEnvRefs()
 
-	if cfg.Hive.PresetFile != "" {
+	// With hives, a top-level preset would only fill agents nobody runs;
+	// Validate rejects it instead.
+	if cfg.Hive.PresetFile != "" && len(cfg.Hives) == 0 {
 		configDir := filepath.Dir(path)
 		pf, err := loadPresetFile(configDir, cfg.Hive.DataDir, cfg.Hive.PresetFile)
 		if err != nil {
@@ -92,6 +387,22 @@ func Load(path string) (*Config, error) {
 		}
 		applyPresetFile(&cfg, pf)
 	}
+	for i := range cfg.Hives {
+		hs := &cfg.Hives[i]
+		if hs.Hive.PresetFile == "" || len(hs.Agents) > 0 {
+			continue
+		}
+		pf, err := loadPresetFile(filepath.Dir(path), cfg.hiveDataDir(hs.Hive), hs.Hive.PresetFile)
+		if err != nil {
+			return nil, err
+		}
+		hs.Agents = pf.Agents
+		if len(hs.Templates) == 0 {
+			hs.Templates = pf.Templates
+		}
+	}
+
+	cfg.applyBase()
 
 	if err := cfg.Validate(); err != nil {
 		return nil, err
@@ -120,14 +431,96 @@ func loadPresetFile(configDir, dataDir string, presetFile string) (*PresetFile,
 	return &pf, nil
 }
 
-// applyPresetFile merges agents and providers from the preset file into the config.
+// applyPresetFile merges agents and templates from the preset file into the config.
 // Preset file values are used only when the config doesn't already define them.
 func applyPresetFile(cfg *Config, pf *PresetFile) {
 	if len(cfg.Agents) == 0 {
 		cfg.Agents = pf.Agents
 	}
+	if len(cfg.Templates) == 0 {
+		cfg.Templates = pf.Templates
+	}
+}
+
+// applyBase merges each hive's base instructions, scoped contexts and rules
+// into its agents. Hives without a base leave their agents unchanged.
+func (c *Config) applyBase() {
+	if len(c.Hives) == 0 {
+		mergeBase(c.Hive, c.Agents)
+		return
+	}
+	for i := range c.Hives {
+		mergeBase(c.Hives[i].Hive, c.Hives[i].Agents)
+	}
+}
+
+// mergeBase applies h's base to each agent spec in place.
+func mergeBase(h HiveConfig, agents []protocol.AgentSpec) {
+	if h.BaseInstructions == "" && len(h.BaseScopedContexts) == 0 && len(h.Rules) == 0 && !h.KeepDefaultRules {
+		return
+	}
+	override := h.BaseMode == BaseOverride
+	merge := func(base, own string) string {
+		switch {
+		case own == "":
+			return base
+		case base == "" || override:
+			return own
+		default:
+			return base + "\n\n" + own
+		}
+	}
+	for i := range agents {
+		a := &agents[i]
+		a.CoreInstructions = merge(h.BaseInstructions, a.CoreInstructions)
+		if len(a.Rules) == 0 || !override {
+			a.Rules = append(slices.Clip(h.Rules), a.Rules...)
+		}
+		a.KeepDefaultRules = a.KeepDefaultRules || h.KeepDefaultRules
+		if len(h.BaseScopedContexts) == 0 {
+			continue
+		}
+		scoped := make(map[string]string, len(h.BaseScopedContexts)+len(a.ScopedContexts))
+		for k, v := range h.BaseScopedContexts {
+			scoped[k] = v
+		}
+		for k, v := range a.ScopedContexts {
+			scoped[k] = merge(scoped[k], v)
+		}
+		a.ScopedContexts = scoped
+	}
+}
+
+func validBaseMode(mode string) bool {
+	return mode == "" || mode == BaseAppend || mode == BaseOverride
+}
+
+func validRelayMode(mode string) bool {
+	return mode == "" || mode == RelayFull || mode == RelayCompact || mode == RelayCondensed
 }
 
+// inboxPolicyError describes the accepted values of an inbox_policy.
+const inboxPolicyError = "inbox_policy must be \"drop\", \"drop_oldest\", \"block\" or \"spill\""
+
+func validInboxPolicy(policy string) bool {
+	switch policy {
+	case "", protocol.InboxDrop, protocol.InboxDropOldest, protocol.InboxBlock, protocol.InboxSpill:
+		return true
+	}
+	return false
+}
+
+// validateInbox checks a hive's default inbox policy.
+func validateInbox(path string, h HiveConfig) []string {
+	var errs []string
+	if !validInboxPolicy(h.InboxPolicy) {
+		errs = append(errs, path+"."+inboxPolicyError)
+	}
+	if h.InboxBlockSeconds < 0 {
+		errs = append(errs, path+".inbox_block_seconds must not be negative")
+	}
+	return errs
+}
 
 // LoadFromEnv builds a minimal config from environment variables with H1V3_ prefix.
 func LoadFromEnv() (*Config, error) {
@@ -174,9 +567,31 @@ func LoadFromEnv() (*Config, error) {
 		}
 	}
 
+	// Slack connector from env
+	if botToken := os.Getenv("H1V3_SLACK_BOT_TOKEN"); botToken != "" {
+		cfg.Connectors.Slack = &SlackConfig{
+			BotToken: botToken,
+			AppToken: os.Getenv("H1V3_SLACK_APP_TOKEN"),
+		}
+	}
+
+	// Discord connector from env
+	if token := os.Getenv("H1V3_DISCORD_TOKEN"); token != "" {
+		cfg.Connectors.Discord = &DiscordConfig{
+			Token: token,
+		}
+	}
+
 	cfg.Hive.FrontAgentID = getenv("H1V3_FRONT_AGENT_ID", "front")
 	cfg.Hive.CompactThreshold = getenvInt("H1V3_COMPACT_THRESHOLD", 8000)
+	cfg.Hive.TicketRetentionDays = getenvInt("H1V3_TICKET_RETENTION_DAYS", 0)
+	cfg.Hive.InboundDedupSeconds = getenvInt("H1V3_INBOUND_DEDUP_SECONDS", 0)
+	cfg.Hive.HistoryWindow = getenvInt("H1V3_HISTORY_WINDOW", 0)
+	cfg.Hive.IdleHibernateSeconds = getenvInt("H1V3_IDLE_HIBERNATE_SECONDS", 0)
+	cfg.Hive.ShutdownGraceSeconds = getenvInt("H1V3_SHUTDOWN_GRACE_SECONDS", 0)
 	cfg.Tools.BraveAPIKey = os.Getenv("H1V3_BRAVE_API_KEY")
+	cfg.HTTP.ProxyURL = os.Getenv("H1V3_HTTP_PROXY")
+	cfg.HTTP.CAFile = os.Getenv("H1V3_CA_FILE")
 
 	return cfg, nil
 }
@@ -191,41 +606,244 @@ func (c *Config) Validate() error {
 	if c.Hive.DataDir == "" {
 		errs = append(errs, "hive.data_dir is required")
 	}
+	if c.Hive.TicketRetentionDays < 0 {
+		errs = append(errs, "hive.ticket_retention_days must not be negative")
+	}
+	if c.Hive.InboundDedupSeconds < 0 {
+		errs = append(errs, "hive.inbound_dedup_seconds must not be negative")
+	}
+	if c.Hive.HistoryWindow < 0 {
+		errs = append(errs, "hive.history_window must not be negative")
+	}
+	if c.Hive.IdleHibernateSeconds < 0 {
+		errs = append(errs, "hive.idle_hibernate_seconds must not be negative")
+	}
+	if c.Hive.ShutdownGraceSeconds < 0 {
+		errs = append(errs, "hive.shutdown_grace_seconds must not be negative")
+	}
+	if c.Tools.ShellTimeout < 0 {
+		errs = append(errs, "tools.shell_timeout must not be negative")
+	}
+	if c.Tools.ExecMaxOutput < 0 {
+		errs = append(errs, "tools.exec_max_output must not be negative")
+	}
+	for model, c := range c.Tools.ModelCosts {
+		if c.PromptPerMillion < 0 || c.CompletionPerMillion < 0 {
+			errs = append(errs, fmt.Sprintf("tools.model_costs.%s prices must not be negative", model))
+		}
+	}
+	if len(c.Hives) == 0 {
+		errs = append(errs, validateFront("hive", c.Hive, c.Agents)...)
+	}
+	if !validBaseMode(c.Hive.BaseMode) {
+		errs = append(errs, "hive.base_mode must be \"append\" or \"override\"")
+	}
+	if c.Hive.Nudge != nil && c.Hive.Nudge.MaxRetries < 0 {
+		errs = append(errs, "hive.nudge.max_retries must not be negative")
+	}
+	if !validRelayMode(c.Hive.RelayMode) {
+		errs = append(errs, "hive.relay_mode must be \"full\", \"compact\" or \"condensed\"")
+	}
+	errs = append(errs, validateInbox("hive", c.Hive)...)
 
 	if len(c.Providers) == 0 {
 		errs = append(errs, "at least one provider is required")
 	}
 	for name, p := range c.Providers {
-		if p.APIKey == "" {
+		switch p.Type {
+		case "", "openai", "anthropic", "ollama":
+		default:
+			errs = append(errs, fmt.Sprintf("providers.%s.type must be \"openai\", \"anthropic\" or \"ollama\"", name))
+		}
+		if p.APIKey == "" && p.Type != "ollama" {
 			errs = append(errs, fmt.Sprintf("providers.%s.api_key is required", name))
 		}
+		if p.MaxTokens < 0 {
+			errs = append(errs, fmt.Sprintf("providers.%s.max_tokens must be positive", name))
+		}
 		if p.Model == "" {
 			errs = append(errs, fmt.Sprintf("providers.%s.model is required", name))
 		}
+		if c := p.Capabilities; c != nil && (c.MaxContext < 0 || c.MaxOutput < 0) {
+			errs = append(errs, fmt.Sprintf("providers.%s.capabilities limits must not be negative", name))
+		}
+		if (p.MaxRetries != nil && *p.MaxRetries < 0) || p.RetryBaseDelayMS < 0 {
+			errs = append(errs, fmt.Sprintf("providers.%s.max_retries and retry_base_delay_ms must not be negative", name))
+		}
+	}
+
+	errs = append(errs, c.validateAgents("agents", c.Agents)...)
+	if len(c.Hives) == 0 {
+		errs = append(errs, validateTemplates("templates", c.Templates, c.Agents)...)
+	}
+
+	errs = append(errs, validateConnectors("connectors", c.Connectors)...)
+
+	for i, t := range c.API.Tokens {
+		if t.Key == "" {
+			errs = append(errs, fmt.Sprintf("api.tokens[%d].key is required", i))
+		}
+		if t.Scope != "read" && t.Scope != "write" {
+			errs = append(errs, fmt.Sprintf("api.tokens[%d].scope must be \"read\" or \"write\"", i))
+		}
+	}
+
+	errs = append(errs, c.HTTP.validate()...)
+
+	for i, p := range c.Logging.RedactPatterns {
+		if _, err := regexp.Compile(p); err != nil {
+			errs = append(errs, fmt.Sprintf("logging.redact_patterns[%d] is not a valid regexp: %v", i, err))
+		}
+	}
+	if f := c.Logging.Format; f != "" && f != "json" && f != "text" {
+		errs = append(errs, fmt.Sprintf("logging.format must be \"json\" or \"text\", got %q", f))
+	}
+	if c.Logging.MaxSizeMB < 0 || c.Logging.MaxBackups < 0 || c.Logging.MaxAgeDays < 0 {
+		errs = append(errs, "logging.max_size_mb, max_backups and max_age_days must not be negative")
+	}
+
+	// Multi-hive: tenants need distinct IDs and data dirs, and agents live
+	// in each hive, not at the top level.
+	if len(c.Hives) > 0 {
+		if len(c.Agents) > 0 {
+			errs = append(errs, "agents must be empty when hives is set; move them into hives[].agents")
+		}
+		if c.Hive.PresetFile != "" {
+			errs = append(errs, "hive.preset_file is not used when hives is set; set hives[].hive.preset_file instead")
+		}
+		seenHives := make(map[string]bool)
+		seenDirs := make(map[string]bool)
+		for i, hs := range c.HiveSpecs() {
+			prefix := fmt.Sprintf("hives[%d]", i)
+			if hs.Hive.ID == "" {
+				errs = append(errs, prefix+".hive.id is required")
+			} else if seenHives[hs.Hive.ID] {
+				errs = append(errs, fmt.Sprintf("%s.hive.id %q is duplicated", prefix, hs.Hive.ID))
+			}
+			seenHives[hs.Hive.ID] = true
+			if seenDirs[hs.Hive.DataDir] {
+				errs = append(errs, fmt.Sprintf("%s.hive.data_dir %q is shared with another hive", prefix, hs.Hive.DataDir))
+			}
+			seenDirs[hs.Hive.DataDir] = true
+			errs = append(errs, c.validateAgents(prefix+".agents", hs.Agents)...)
+			errs = append(errs, validateFront(prefix+".hive", hs.Hive, hs.Agents)...)
+			if !validBaseMode(hs.Hive.BaseMode) {
+				errs = append(errs, prefix+".hive.base_mode must be \"append\" or \"override\"")
+			}
+			if hs.Hive.Nudge != nil && hs.Hive.Nudge.MaxRetries < 0 {
+				errs = append(errs, prefix+".hive.nudge.max_retries must not be negative")
+			}
+			if !validRelayMode(hs.Hive.RelayMode) {
+				errs = append(errs, prefix+".hive.relay_mode must be \"full\", \"compact\" or \"condensed\"")
+			}
+			errs = append(errs, validateInbox(prefix+".hive", hs.Hive)...)
+			errs = append(errs, validateTemplates(prefix+".templates", hs.Templates, hs.Agents)...)
+			errs = append(errs, validateConnectors(prefix+".connectors", hs.Connectors)...)
+		}
 	}
 
-	for i, a := range c.Agents {
+	if len(errs) > 0 {
+		return fmt.Errorf("config validation failed:\n  - %s", strings.Join(errs, "\n  - "))
+	}
+	return nil
+}
+
+// validateAgents checks agent specs, prefixing errors with the config path.
+func (c *Config) validateAgents(path string, agents []protocol.AgentSpec) []string {
+	var errs []string
+	for i, a := range agents {
 		if a.ID == "" {
-			errs = append(errs, fmt.Sprintf("agents[%d].id is required", i))
+			errs = append(errs, fmt.Sprintf("%s[%d].id is required", path, i))
 		}
 		if a.Role == "" {
-			errs = append(errs, fmt.Sprintf("agents[%d].role is required", i))
+			errs = append(errs, fmt.Sprintf("%s[%d].role is required", path, i))
 		}
 		if a.Provider != "" {
 			if _, ok := c.Providers[a.Provider]; !ok {
-				errs = append(errs, fmt.Sprintf("agents[%d].provider references unknown provider %q", i, a.Provider))
+				errs = append(errs, fmt.Sprintf("%s[%d].provider references unknown provider %q", path, i, a.Provider))
+			}
+		}
+		for _, name := range a.FallbackProviders {
+			if _, ok := c.Providers[name]; !ok {
+				errs = append(errs, fmt.Sprintf("%s[%d].fallback_providers references unknown provider %q", path, i, name))
+			}
+		}
+		if a.Temperature != nil && (*a.Temperature < 0 || *a.Temperature > 2) {
+			errs = append(errs, fmt.Sprintf("%s[%d].temperature must be between 0 and 2", path, i))
+		}
+		if a.MaxTokens < 0 {
+			errs = append(errs, fmt.Sprintf("%s[%d].max_tokens must be positive", path, i))
+		}
+		if strings.ContainsFunc(a.Model, unicode.IsSpace) {
+			errs = append(errs, fmt.Sprintf("%s[%d].model must not contain whitespace", path, i))
+		}
+		if a.InboxSize < 0 {
+			errs = append(errs, fmt.Sprintf("%s[%d].inbox_size must be positive", path, i))
+		}
+		if a.RequestTimeoutSeconds < 0 {
+			errs = append(errs, fmt.Sprintf("%s[%d].request_timeout_seconds must not be negative", path, i))
+		}
+		if a.DiskQuotaMB < 0 {
+			errs = append(errs, fmt.Sprintf("%s[%d].disk_quota_mb must not be negative", path, i))
+		}
+		if !validInboxPolicy(a.InboxPolicy) {
+			errs = append(errs, fmt.Sprintf("%s[%d].%s", path, i, inboxPolicyError))
+		}
+		for _, id := range a.CanDelegateTo {
+			if !slices.ContainsFunc(agents, func(b protocol.AgentSpec) bool { return b.ID == id }) {
+				errs = append(errs, fmt.Sprintf("%s[%d].can_delegate_to references unknown agent %q", path, i, id))
+			}
+		}
+		for _, id := range a.CanReceiveFrom {
+			if !slices.ContainsFunc(agents, func(b protocol.AgentSpec) bool { return b.ID == id }) {
+				errs = append(errs, fmt.Sprintf("%s[%d].can_receive_from references unknown agent %q", path, i, id))
 			}
 		}
 	}
+	return errs
+}
 
-	if c.Connectors.Telegram != nil && c.Connectors.Telegram.Token == "" {
-		errs = append(errs, "connectors.telegram.token is required")
+// validateTemplates checks ticket templates: names must be unique, title and
+// goal set, and every "to" agent must exist in the hive.
+func validateTemplates(path string, templates []protocol.TicketTemplate, agents []protocol.AgentSpec) []string {
+	var errs []string
+	seen := make(map[string]bool)
+	for i, t := range templates {
+		if t.Name == "" {
+			errs = append(errs, fmt.Sprintf("%s[%d].name is required", path, i))
+		} else if seen[t.Name] {
+			errs = append(errs, fmt.Sprintf("%s[%d].name %q is duplicated", path, i, t.Name))
+		}
+		seen[t.Name] = true
+		if t.Title == "" {
+			errs = append(errs, fmt.Sprintf("%s[%d].title is required", path, i))
+		}
+		if t.Goal == "" {
+			errs = append(errs, fmt.Sprintf("%s[%d].goal is required", path, i))
+		}
+		for _, id := range t.To {
+			if !slices.ContainsFunc(agents, func(a protocol.AgentSpec) bool { return a.ID == id }) {
+				errs = append(errs, fmt.Sprintf("%s[%d].to references unknown agent %q", path, i, id))
+			}
+		}
 	}
+	return errs
+}
 
-	if len(errs) > 0 {
-		return fmt.Errorf("config validation failed:\n  - %s", strings.Join(errs, "\n  - "))
+// validateFront checks the front-agent fan-out settings of a hive.
+func validateFront(path string, h HiveConfig, agents []protocol.AgentSpec) []string {
+	var errs []string
+	for _, id := range h.FrontAgentIDs {
+		if !slices.ContainsFunc(agents, func(a protocol.AgentSpec) bool { return a.ID == id }) {
+			errs = append(errs, fmt.Sprintf("%s.front_agent_ids references unknown agent %q", path, id))
+		}
 	}
-	return nil
+	switch h.FrontReplyPolicy {
+	case "", "primary", "first":
+	default:
+		errs = append(errs, fmt.Sprintf("%s.front_reply_policy must be \"primary\" or \"first\"", path))
+	}
+	return errs
 }
 
 // resolveEnv checks if s is an env var reference (e.g. "$VAR" or "${VAR}")
@@ -243,16 +861,66 @@ func resolveEnv(s string) string {
 	return s
 }
 
+// validateConnectors checks the connector settings at prefix.
+func validateConnectors(prefix string, cc ConnectorConfig) []string {
+	var errs []string
+	if cc.Telegram != nil && cc.Telegram.Token == "" {
+		errs = append(errs, prefix+".telegram.token is required")
+	}
+	if cc.Slack != nil {
+		if cc.Slack.BotToken == "" {
+			errs = append(errs, prefix+".slack.bot_token is required")
+		}
+		if cc.Slack.AppToken == "" {
+			errs = append(errs, prefix+".slack.app_token is required (Socket Mode)")
+		}
+	}
+	if cc.Discord != nil && cc.Discord.Token == "" {
+		errs = append(errs, prefix+".discord.token is required")
+	}
+	if cc.HTTP != nil && (cc.HTTP.MaxQueued < 0 || cc.HTTP.IdleTimeoutSeconds < 0) {
+		errs = append(errs, prefix+".http.max_queued and idle_timeout_seconds must not be negative")
+	}
+	for name, w := range cc.OutboundWebhooks {
+		if u, err := url.Parse(w.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			errs = append(errs, fmt.Sprintf("%s.outbound_webhooks.%s.url must be an http(s) URL", prefix, name))
+		}
+	}
+	return errs
+}
+
+func (cc *ConnectorConfig) resolveEnvRefs() {
+	if cc.Telegram != nil {
+		cc.Telegram.Token = resolveEnv(cc.Telegram.Token)
+	}
+	if cc.Slack != nil {
+		cc.Slack.BotToken = resolveEnv(cc.Slack.BotToken)
+		cc.Slack.AppToken = resolveEnv(cc.Slack.AppToken)
+	}
+	if cc.Discord != nil {
+		cc.Discord.Token = resolveEnv(cc.Discord.Token)
+	}
+	for name, w := range cc.OutboundWebhooks {
+		w.Secret = resolveEnv(w.Secret)
+		w.BearerToken = resolveEnv(w.BearerToken)
+		cc.OutboundWebhooks[name] = w
+	}
+}
+
 // resolveEnvRefs resolves env var references in secret fields.
 func (c *Config) resolveEnvRefs() {
 	for name, p := range c.Providers {
 		p.APIKey = resolveEnv(p.APIKey)
 		c.Providers[name] = p
 	}
-	if c.Connectors.Telegram != nil {
-		c.Connectors.Telegram.Token = resolveEnv(c.Connectors.Telegram.Token)
+	c.Connectors.resolveEnvRefs()
+	for i := range c.Hives {
+		c.Hives[i].Connectors.resolveEnvRefs()
 	}
 	c.API.Key = resolveEnv(c.API.Key)
+	for i := range c.API.Tokens {
+		c.API.Tokens[i].Key = resolveEnv(c.API.Tokens[i].Key)
+	}
 	c.Tools.BraveAPIKey = resolveEnv(c.Tools.BraveAPIKey)
 }
 
@@ -7,6 +7,7 @@ import (
 	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 	"testing"
 
@@ -122,6 +123,301 @@ func TestValidate_MissingHiveID(t *testing.T) {
 	}
 }
 
+func TestValidate_NegativeRetention(t *testing.T) {
+	cfg := &Config{
+		Hive:      HiveConfig{ID: "h", DataDir: "/data", TicketRetentionDays: -1},
+		Providers: map[string]ProviderConfig{"default": {APIKey: "k", Model: "m"}},
+	}
+	err := cfg.Validate()
+	if err == nil || !strings.Contains(err.Error(), "ticket_retention_days") {
+		t.Errorf("expected retention error, got %v", err)
+	}
+}
+
+func TestHiveSpecs_SingleHiveDefault(t *testing.T) {
+	cfg := &Config{
+		Hive:   HiveConfig{ID: "h", DataDir: "/data"},
+		Agents: []protocol.AgentSpec{{ID: "front", Role: "Front"}},
+	}
+	specs := cfg.HiveSpecs()
+	if len(specs) != 1 || specs[0].Hive.ID != "h" || len(specs[0].Agents) != 1 {
+		t.Errorf("unexpected specs: %+v", specs)
+	}
+}
+
+func TestHiveSpecs_DefaultDataDir(t *testing.T) {
+	cfg := &Config{
+		Hive: HiveConfig{ID: "h", DataDir: "/data"},
+		Hives: []HiveSpec{
+			{Hive: HiveConfig{ID: "alpha"}},
+			{Hive: HiveConfig{ID: "beta", DataDir: "/srv/beta"}},
+		},
+	}
+	specs := cfg.HiveSpecs()
+	if specs[0].Hive.DataDir != filepath.Join("/data", "hives", "alpha") {
+		t.Errorf("alpha data dir = %q", specs[0].Hive.DataDir)
+	}
+	if specs[1].Hive.DataDir != "/srv/beta" {
+		t.Errorf("beta data dir = %q", specs[1].Hive.DataDir)
+	}
+}
+
+func TestValidate_DuplicateHive(t *testing.T) {
+	cfg := &Config{
+		Hive:      HiveConfig{ID: "h", DataDir: "/data"},
+		Providers: map[string]ProviderConfig{"default": {APIKey: "k", Model: "m"}},
+		Hives: []HiveSpec{
+			{Hive: HiveConfig{ID: "alpha"}},
+			{Hive: HiveConfig{ID: "alpha"}},
+		},
+	}
+	err := cfg.Validate()
+	if err == nil || !strings.Contains(err.Error(), "duplicated") {
+		t.Errorf("expected duplicate hive error, got %v", err)
+	}
+}
+
+func TestValidate_TopLevelAgentsWithHives(t *testing.T) {
+	cfg := &Config{
+		Hive:      HiveConfig{ID: "h", DataDir: "/data", PresetFile: "preset.json"},
+		Providers: map[string]ProviderConfig{"default": {APIKey: "k", Model: "m"}},
+		Agents:    []protocol.AgentSpec{{ID: "front", Role: "Front"}},
+		Hives:     []HiveSpec{{Hive: HiveConfig{ID: "alpha"}}},
+	}
+	err := cfg.Validate()
+	if err == nil || !strings.Contains(err.Error(), "agents must be empty when hives is set") {
+		t.Errorf("expected top-level agents error, got %v", err)
+	}
+	if err == nil || !strings.Contains(err.Error(), "hive.preset_file is not used when hives is set") {
+		t.Errorf("expected top-level preset_file error, got %v", err)
+	}
+}
+
+func TestLoad_HivePresetInDefaultDataDir(t *testing.T) {
+	dir := t.TempDir()
+	cfgDir := filepath.Join(dir, "etc")
+	hiveDir := filepath.Join(dir, "data", "hives", "alpha")
+	os.MkdirAll(cfgDir, 0o755)
+	os.MkdirAll(hiveDir, 0o755)
+	os.WriteFile(filepath.Join(hiveDir, "preset.json"), []byte(`{"agents": [{"id": "front", "role": "Front"}]}`), 0o644)
+
+	config := fmt.Sprintf(`{
+  "hive": {"id": "daemon", "data_dir": %q},
+  "providers": {"default": {"api_key": "k", "model": "m"}},
+  "hives": [{"hive": {"id": "alpha", "preset_file": "preset.json"}}]
+}`, filepath.Join(dir, "data"))
+	os.WriteFile(filepath.Join(cfgDir, "config.json"), []byte(config), 0o644)
+
+	cfg, err := Load(filepath.Join(cfgDir, "config.json"))
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if agents := cfg.HiveSpecs()[0].Agents; len(agents) != 1 || agents[0].ID != "front" {
+		t.Errorf("expected the preset from the hive's default data dir, got %+v", agents)
+	}
+}
+
+func TestValidate_AgentSamplingRanges(t *testing.T) {
+	hot := 2.5
+	cfg := &Config{
+		Hive:      HiveConfig{ID: "h", DataDir: "/data"},
+		Providers: map[string]ProviderConfig{"default": {APIKey: "k", Model: "m"}},
+		Agents:    []protocol.AgentSpec{{ID: "a", Role: "r", Temperature: &hot, MaxTokens: -1, Model: "gpt 4o"}},
+	}
+	err := cfg.Validate()
+	if err == nil || !strings.Contains(err.Error(), "temperature") || !strings.Contains(err.Error(), "max_tokens") || !strings.Contains(err.Error(), "model") {
+		t.Errorf("expected temperature, max_tokens and model errors, got %v", err)
+	}
+}
+
+func TestLoad_AgentSamplingOverrides(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	os.WriteFile(path, []byte(`{
+		"hive": {"id": "h", "data_dir": "/tmp/h1v3-test"},
+		"providers": {"default": {"api_key": "k", "model": "gpt-4o"}},
+		"agents": [
+			{"id": "writer", "role": "Writer", "temperature": 0.9, "max_tokens": 4000, "model": "gpt-4.1"},
+			{"id": "reviewer", "role": "Reviewer", "temperature": 0}
+		]
+	}`), 0o644)
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	writer, reviewer := cfg.Agents[0], cfg.Agents[1]
+	if writer.Temperature == nil || *writer.Temperature != 0.9 || writer.MaxTokens != 4000 || writer.Model != "gpt-4.1" {
+		t.Errorf("writer overrides = %v/%d/%q", writer.Temperature, writer.MaxTokens, writer.Model)
+	}
+	if reviewer.Temperature == nil || *reviewer.Temperature != 0 || reviewer.Model != "" {
+		t.Errorf("expected reviewer at temperature 0 on the provider's model, got %v/%q", reviewer.Temperature, reviewer.Model)
+	}
+}
+
+func TestValidate_AgentInbox(t *testing.T) {
+	cfg := &Config{
+		Hive:      HiveConfig{ID: "h", DataDir: "/data"},
+		Providers: map[string]ProviderConfig{"default": {APIKey: "k", Model: "m"}},
+		Agents: []protocol.AgentSpec{
+			{ID: "a", Role: "r", InboxSize: -1, InboxPolicy: "queue"},
+			{ID: "b", Role: "r", InboxSize: 512, InboxPolicy: protocol.InboxSpill},
+		},
+	}
+	err := cfg.Validate()
+	if err == nil || !strings.Contains(err.Error(), "agents[0].inbox_size") || !strings.Contains(err.Error(), "agents[0].inbox_policy") {
+		t.Errorf("expected inbox_size and inbox_policy errors, got %v", err)
+	}
+	if strings.Contains(err.Error(), "agents[1]") {
+		t.Errorf("valid inbox settings rejected: %v", err)
+	}
+
+	cfg.Agents = cfg.Agents[1:]
+	cfg.Hive.InboxPolicy = protocol.InboxDropOldest
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("valid hive inbox policy rejected: %v", err)
+	}
+	cfg.Hive.InboxPolicy, cfg.Hive.InboxBlockSeconds = "queue", -1
+	err = cfg.Validate()
+	if err == nil || !strings.Contains(err.Error(), "hive.inbox_policy") || !strings.Contains(err.Error(), "hive.inbox_block_seconds") {
+		t.Errorf("expected hive inbox_policy and inbox_block_seconds errors, got %v", err)
+	}
+}
+
+func TestValidate_DelegationLists(t *testing.T) {
+	cfg := &Config{
+		Hive:      HiveConfig{ID: "h", DataDir: "/data"},
+		Providers: map[string]ProviderConfig{"default": {APIKey: "k", Model: "m"}},
+		Agents: []protocol.AgentSpec{
+			{ID: "front", Role: "r", CanDelegateTo: []string{"coder", "ghost"}},
+			{ID: "coder", Role: "r", CanReceiveFrom: []string{"front"}},
+		},
+	}
+	err := cfg.Validate()
+	if err == nil || !strings.Contains(err.Error(), `agents[0].can_delegate_to references unknown agent "ghost"`) {
+		t.Errorf("expected unknown can_delegate_to error, got %v", err)
+	}
+	if strings.Contains(err.Error(), "agents[1]") {
+		t.Errorf("valid can_receive_from rejected: %v", err)
+	}
+}
+
+func TestValidate_UnknownFallbackProvider(t *testing.T) {
+	cfg := &Config{
+		Hive:      HiveConfig{ID: "h", DataDir: "/data"},
+		Providers: map[string]ProviderConfig{"default": {APIKey: "k", Model: "m"}},
+		Agents:    []protocol.AgentSpec{{ID: "a", Role: "r", FallbackProviders: []string{"default", "cheap"}}},
+	}
+	err := cfg.Validate()
+	if err == nil || !strings.Contains(err.Error(), `unknown provider "cheap"`) {
+		t.Errorf("expected unknown fallback provider error, got %v", err)
+	}
+}
+
+func TestValidate_InvalidRedactPattern(t *testing.T) {
+	cfg := &Config{
+		Hive:      HiveConfig{ID: "h", DataDir: "/data"},
+		Providers: map[string]ProviderConfig{"default": {APIKey: "k", Model: "m"}},
+		Logging:   LoggingConfig{RedactPatterns: []string{`ok-\d+`, `(unclosed`}},
+	}
+	err := cfg.Validate()
+	if err == nil || !strings.Contains(err.Error(), "logging.redact_patterns[1]") {
+		t.Errorf("expected redact pattern error, got %v", err)
+	}
+}
+
+func TestValidate_LoggingOutput(t *testing.T) {
+	cfg := &Config{
+		Hive:      HiveConfig{ID: "h", DataDir: "/data"},
+		Providers: map[string]ProviderConfig{"default": {APIKey: "k", Model: "m"}},
+		Logging:   LoggingConfig{Format: "xml", MaxBackups: -1},
+	}
+	err := cfg.Validate()
+	if err == nil || !strings.Contains(err.Error(), "logging.format") || !strings.Contains(err.Error(), "must not be negative") {
+		t.Errorf("expected logging errors, got %v", err)
+	}
+	if !cfg.Logging.BufferEnabled() {
+		t.Error("expected the log buffer on by default")
+	}
+}
+
+func TestSecrets(t *testing.T) {
+	cfg := &Config{
+		Providers:  map[string]ProviderConfig{"default": {APIKey: "prov-key"}},
+		Connectors: ConnectorConfig{Telegram: &TelegramConfig{Token: "tg-token"}},
+		API:        APIConfig{Key: "api-key"},
+	}
+	got := strings.Join(cfg.Secrets(), ",")
+	for _, want := range []string{"prov-key", "tg-token", "api-key"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Secrets() = %q, missing %q", got, want)
+		}
+	}
+}
+
+func TestValidate_APITokens(t *testing.T) {
+	cfg := &Config{
+		Hive:      HiveConfig{ID: "h", DataDir: "/data"},
+		Providers: map[string]ProviderConfig{"default": {APIKey: "k", Model: "m"}},
+		API:       APIConfig{Tokens: []APIToken{{Key: "a", Scope: "read"}, {Scope: "admin"}}},
+	}
+	err := cfg.Validate()
+	if err == nil || !strings.Contains(err.Error(), "api.tokens[1].key is required") || !strings.Contains(err.Error(), "api.tokens[1].scope") {
+		t.Errorf("expected token errors, got %v", err)
+	}
+	if strings.Contains(err.Error(), "api.tokens[0]") {
+		t.Errorf("valid token reported as invalid: %v", err)
+	}
+}
+
+func TestValidate_FrontAgentIDs(t *testing.T) {
+	cfg := &Config{
+		Hive: HiveConfig{
+			ID: "h", DataDir: "/data",
+			FrontAgentIDs:    []string{"front", "ghost"},
+			FrontReplyPolicy: "loudest",
+		},
+		Providers: map[string]ProviderConfig{"default": {APIKey: "k", Model: "m"}},
+		Agents:    []protocol.AgentSpec{{ID: "front", Role: "r"}},
+	}
+	err := cfg.Validate()
+	if err == nil || !strings.Contains(err.Error(), `front_agent_ids references unknown agent "ghost"`) || !strings.Contains(err.Error(), "front_reply_policy") {
+		t.Errorf("expected front agent errors, got %v", err)
+	}
+	if strings.Contains(err.Error(), `"front"`) {
+		t.Errorf("known agent reported as unknown: %v", err)
+	}
+}
+
+func TestValidate_Templates(t *testing.T) {
+	cfg := &Config{
+		Hive:      HiveConfig{ID: "h", DataDir: "/data"},
+		Providers: map[string]ProviderConfig{"default": {APIKey: "k", Model: "m"}},
+		Agents:    []protocol.AgentSpec{{ID: "coder", Role: "r"}},
+		Templates: []protocol.TicketTemplate{
+			{Name: "review", Title: "Review {{file}}", Goal: "List bugs", To: []string{"coder"}},
+			{Name: "review", Title: "t", Goal: "g", To: []string{"ghost"}},
+			{Title: "t"},
+		},
+	}
+	err := cfg.Validate()
+	if err == nil {
+		t.Fatal("expected template errors")
+	}
+	for _, want := range []string{
+		`templates[1].name "review" is duplicated`,
+		`templates[1].to references unknown agent "ghost"`,
+		"templates[2].name is required",
+		"templates[2].goal is required",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("missing %q in %v", want, err)
+		}
+	}
+	if strings.Contains(err.Error(), "templates[0]") {
+		t.Errorf("valid template reported: %v", err)
+	}
+}
+
 func TestValidate_MissingProvider(t *testing.T) {
 	cfg := &Config{
 		Hive:      HiveConfig{ID: "h", DataDir: "/data"},
@@ -144,6 +440,20 @@ func TestValidate_MissingProviderAPIKey(t *testing.T) {
 	}
 }
 
+func TestValidate_ProviderType(t *testing.T) {
+	cfg := &Config{
+		Hive: HiveConfig{ID: "h", DataDir: "/data"},
+		Providers: map[string]ProviderConfig{
+			"default": {Type: "ollama", Model: "llama3.2"}, // no key needed
+			"other":   {Type: "gemini", APIKey: "k", Model: "m"},
+		},
+	}
+	err := cfg.Validate()
+	if err == nil || !strings.Contains(err.Error(), "providers.other.type") || strings.Contains(err.Error(), "api_key") {
+		t.Errorf("expected only a type error, got %v", err)
+	}
+}
+
 func TestValidate_MissingAgentID(t *testing.T) {
 	cfg := &Config{
 		Hive: HiveConfig{ID: "h", DataDir: "/data"},
@@ -172,6 +482,61 @@ func TestValidate_TelegramNoToken(t *testing.T) {
 	}
 }
 
+func TestValidate_SlackTokens(t *testing.T) {
+	cfg := &Config{
+		Hive: HiveConfig{ID: "h", DataDir: "/data"},
+		Providers: map[string]ProviderConfig{
+			"default": {APIKey: "k", Model: "m"},
+		},
+		Connectors: ConnectorConfig{Slack: &SlackConfig{BotToken: "xoxb-1"}},
+	}
+	err := cfg.Validate()
+	if err == nil || !strings.Contains(err.Error(), "connectors.slack.app_token") || strings.Contains(err.Error(), "bot_token") {
+		t.Errorf("expected only a slack app_token error, got %v", err)
+	}
+}
+
+func TestValidate_DiscordToken(t *testing.T) {
+	cfg := &Config{
+		Hive: HiveConfig{ID: "h", DataDir: "/data"},
+		Providers: map[string]ProviderConfig{
+			"default": {APIKey: "k", Model: "m"},
+		},
+		Connectors: ConnectorConfig{Discord: &DiscordConfig{Channels: []string{"123"}}},
+	}
+	err := cfg.Validate()
+	if err == nil || !strings.Contains(err.Error(), "connectors.discord.token is required") {
+		t.Errorf("expected discord token error, got %v", err)
+	}
+	cfg.Connectors.Discord.Token = "t"
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestValidate_OutboundWebhookURL(t *testing.T) {
+	cfg := &Config{
+		Hive: HiveConfig{ID: "h", DataDir: "/data"},
+		Providers: map[string]ProviderConfig{
+			"default": {APIKey: "k", Model: "m"},
+		},
+		Connectors: ConnectorConfig{OutboundWebhooks: map[string]OutboundWebhookConfig{
+			"ci": {URL: "ftp://example.com/hook"},
+		}},
+	}
+	err := cfg.Validate()
+	if err == nil || !strings.Contains(err.Error(), "connectors.outbound_webhooks.ci.url must be an http(s) URL") {
+		t.Errorf("expected outbound webhook url error, got %v", err)
+	}
+	cfg.Connectors.OutboundWebhooks["ci"] = OutboundWebhookConfig{URL: "https://example.com/hook", Secret: "s"}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if !slices.Contains(cfg.Secrets(), "s") {
+		t.Error("expected the webhook secret among the secrets")
+	}
+}
+
 func TestValidate_UnknownAgentProvider(t *testing.T) {
 	cfg := &Config{
 		Hive: HiveConfig{ID: "h", DataDir: "/data"},
@@ -224,6 +589,9 @@ func TestLoad_WithPresetFile(t *testing.T) {
       "core_instructions": "Write code.",
       "directory": "/tmp/test/agents/dev"
     }
+  ],
+  "templates": [
+    { "name": "bugfix", "title": "Fix {{issue}}", "goal": "Tests pass", "to": ["dev-agent"] }
   ]
 }`
 	os.WriteFile(filepath.Join(dir, "preset.json"), []byte(presetFile), 0o644)
@@ -253,6 +621,9 @@ func TestLoad_WithPresetFile(t *testing.T) {
 	if cfg.Agents[0].CoreInstructions != "Write code." {
 		t.Errorf("expected core_instructions from preset file, got %q", cfg.Agents[0].CoreInstructions)
 	}
+	if len(cfg.Templates) != 1 || cfg.HiveSpecs()[0].Templates[0].Name != "bugfix" {
+		t.Errorf("expected templates from preset file, got %+v", cfg.Templates)
+	}
 }
 
 func TestLoad_ConfigAgentsOverridePresetFile(t *testing.T) {
@@ -299,6 +670,140 @@ func TestLoad_ConfigAgentsOverridePresetFile(t *testing.T) {
 	}
 }
 
+func TestLoad_BaseInstructions(t *testing.T) {
+	dir := t.TempDir()
+	config := fmt.Sprintf(`{
+  "hive": {
+    "id": "test-hive",
+    "data_dir": %q,
+    "base_instructions": "Never share credentials.",
+    "base_scoped_contexts": {"style": "Be brief.", "team": "Alice leads."}
+  },
+  "agents": [
+    {"id": "coder", "role": "Dev", "core_instructions": "Write Go.", "scoped_contexts": {"team": "You report to Alice."}},
+    {"id": "front", "role": "Front"}
+  ],
+  "providers": {
+    "default": { "api_key": "k", "model": "m" }
+  }
+}`, dir)
+	os.WriteFile(filepath.Join(dir, "config.json"), []byte(config), 0o644)
+
+	cfg, err := Load(filepath.Join(dir, "config.json"))
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	coder, front := cfg.Agents[0], cfg.Agents[1]
+	if coder.CoreInstructions != "Never share credentials.\n\nWrite Go." {
+		t.Errorf("coder instructions = %q", coder.CoreInstructions)
+	}
+	if coder.ScopedContexts["style"] != "Be brief." || coder.ScopedContexts["team"] != "Alice leads.\n\nYou report to Alice." {
+		t.Errorf("coder scoped contexts = %v", coder.ScopedContexts)
+	}
+	if front.CoreInstructions != "Never share credentials." || len(front.ScopedContexts) != 2 {
+		t.Errorf("front should inherit the base, got %q / %v", front.CoreInstructions, front.ScopedContexts)
+	}
+
+	// Override mode: the agent's own values replace the base.
+	h := HiveConfig{BaseInstructions: "base", BaseScopedContexts: map[string]string{"team": "base team"}, BaseMode: BaseOverride}
+	agents := []protocol.AgentSpec{{ID: "a", CoreInstructions: "own", ScopedContexts: map[string]string{"team": "own team"}}, {ID: "b"}}
+	mergeBase(h, agents)
+	if agents[0].CoreInstructions != "own" || agents[0].ScopedContexts["team"] != "own team" {
+		t.Errorf("override: got %q / %v", agents[0].CoreInstructions, agents[0].ScopedContexts)
+	}
+	if agents[1].CoreInstructions != "base" {
+		t.Errorf("override: agent without instructions should get the base, got %q", agents[1].CoreInstructions)
+	}
+
+	// No base: agents are left unchanged.
+	agents = []protocol.AgentSpec{{ID: "a", CoreInstructions: "own"}}
+	mergeBase(HiveConfig{}, agents)
+	if agents[0].CoreInstructions != "own" || agents[0].ScopedContexts != nil {
+		t.Errorf("no base: agent changed to %+v", agents[0])
+	}
+}
+
+func TestValidate_BaseMode(t *testing.T) {
+	cfg := &Config{
+		Hive:      HiveConfig{ID: "h", DataDir: "/data", BaseMode: "merge"},
+		Providers: map[string]ProviderConfig{"default": {APIKey: "k", Model: "m"}},
+	}
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "hive.base_mode") {
+		t.Errorf("expected base_mode error, got %v", err)
+	}
+}
+
+func TestValidate_Nudge(t *testing.T) {
+	cfg := &Config{
+		Hive:      HiveConfig{ID: "h", DataDir: "/data", Nudge: &NudgeConfig{MaxRetries: -1}},
+		Providers: map[string]ProviderConfig{"default": {APIKey: "k", Model: "m"}},
+	}
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "hive.nudge.max_retries") {
+		t.Errorf("expected nudge.max_retries error, got %v", err)
+	}
+}
+
+func TestMergeBase_Rules(t *testing.T) {
+	h := HiveConfig{Rules: []string{"Never delete files."}, KeepDefaultRules: true}
+	agents := []protocol.AgentSpec{{ID: "a", Rules: []string{"Cite sources."}}, {ID: "b"}}
+	mergeBase(h, agents)
+	if !slices.Equal(agents[0].Rules, []string{"Never delete files.", "Cite sources."}) || !agents[0].KeepDefaultRules {
+		t.Errorf("append: got %v / %v", agents[0].Rules, agents[0].KeepDefaultRules)
+	}
+	if !slices.Equal(agents[1].Rules, []string{"Never delete files."}) {
+		t.Errorf("agent without rules should inherit the hive's, got %v", agents[1].Rules)
+	}
+
+	h.BaseMode = BaseOverride
+	agents = []protocol.AgentSpec{{ID: "a", Rules: []string{"Cite sources."}}, {ID: "b"}}
+	mergeBase(h, agents)
+	if !slices.Equal(agents[0].Rules, []string{"Cite sources."}) || !slices.Equal(agents[1].Rules, []string{"Never delete files."}) {
+		t.Errorf("override: got %v / %v", agents[0].Rules, agents[1].Rules)
+	}
+}
+
+func TestValidate_RelayMode(t *testing.T) {
+	cfg := &Config{
+		Hive:      HiveConfig{ID: "h", DataDir: "/data", RelayMode: "summary"},
+		Providers: map[string]ProviderConfig{"default": {APIKey: "k", Model: "m"}},
+	}
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "hive.relay_mode") {
+		t.Errorf("expected relay_mode error, got %v", err)
+	}
+	cfg.Hive.RelayMode = RelayCondensed
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("condensed relay_mode should be valid: %v", err)
+	}
+}
+
+func TestHiveConfig_TicketDBPath(t *testing.T) {
+	for _, tc := range []struct{ dbPath, want string }{
+		{"", "/data/tickets.db"},
+		{"db/hive.db", "/data/db/hive.db"},
+		{"/var/lib/h1v3/t.db", "/var/lib/h1v3/t.db"},
+	} {
+		h := HiveConfig{DataDir: "/data", DBPath: tc.dbPath}
+		if got := h.TicketDBPath(); got != tc.want {
+			t.Errorf("db_path %q: got %q, want %q", tc.dbPath, got, tc.want)
+		}
+	}
+}
+
+func TestValidate_AgentLimits(t *testing.T) {
+	cfg := &Config{
+		Hive:      HiveConfig{ID: "h", DataDir: "/data"},
+		Providers: map[string]ProviderConfig{"default": {APIKey: "k", Model: "m"}},
+		Agents:    []protocol.AgentSpec{{ID: "a", Role: "r", DiskQuotaMB: -5, RequestTimeoutSeconds: -1}},
+	}
+	err := cfg.Validate()
+	if err == nil || !strings.Contains(err.Error(), "agents[0].disk_quota_mb") {
+		t.Errorf("expected disk_quota_mb error, got %v", err)
+	}
+	if err == nil || !strings.Contains(err.Error(), "agents[0].request_timeout_seconds") {
+		t.Errorf("expected request_timeout_seconds error, got %v", err)
+	}
+}
+
 func TestLoad_NoPresetsBackwardCompat(t *testing.T) {
 	dir := t.TempDir()
 	os.WriteFile(filepath.Join(dir, "config.json"), []byte(validJSON), 0o644)
@@ -0,0 +1,94 @@
+package config
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+)
+
+// HTTPConfig configures the outbound HTTP client shared by LLM providers and
+// network tools (web_fetch, web_search, MCP over HTTP).
+type HTTPConfig struct {
+	ProxyURL            string `json:"proxy_url,omitempty"`               // default: HTTPS_PROXY / HTTP_PROXY / NO_PROXY env
+	CAFile              string `json:"ca_file,omitempty"`                 // PEM bundle trusted in addition to the system roots
+	TimeoutSeconds      int    `json:"timeout_seconds,omitempty"`         // per-request timeout; 0 = each caller's default
+	DialTimeoutSeconds  int    `json:"dial_timeout_seconds,omitempty"`    // 0 = 30
+	MaxIdleConns        int    `json:"max_idle_conns,omitempty"`          // 0 = 100
+	MaxIdleConnsPerHost int    `json:"max_idle_conns_per_host,omitempty"` // 0 = Go default (2)
+}
+
+// Transport builds an http.Transport from the config. Unset fields keep the
+// behavior of http.DefaultTransport.
+func (h HTTPConfig) Transport() (*http.Transport, error) {
+	tr := http.DefaultTransport.(*http.Transport).Clone()
+
+	if h.ProxyURL != "" {
+		proxy, err := url.Parse(h.ProxyURL)
+		if err != nil {
+			return nil, fmt.Errorf("config: http.proxy_url: %w", err)
+		}
+		tr.Proxy = http.ProxyURL(proxy)
+	}
+
+	if h.CAFile != "" {
+		pem, err := os.ReadFile(h.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("config: http.ca_file: %w", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("config: http.ca_file %s: no PEM certificates found", h.CAFile)
+		}
+		tr.TLSClientConfig = &tls.Config{RootCAs: pool}
+	}
+
+	if h.DialTimeoutSeconds > 0 {
+		tr.DialContext = (&net.Dialer{
+			Timeout:   time.Duration(h.DialTimeoutSeconds) * time.Second,
+			KeepAlive: 30 * time.Second,
+		}).DialContext
+	}
+	if h.MaxIdleConns > 0 {
+		tr.MaxIdleConns = h.MaxIdleConns
+	}
+	if h.MaxIdleConnsPerHost > 0 {
+		tr.MaxIdleConnsPerHost = h.MaxIdleConnsPerHost
+	}
+	return tr, nil
+}
+
+// Client returns a client using rt, with the configured timeout or def when
+// none is set.
+func (h HTTPConfig) Client(rt http.RoundTripper, def time.Duration) *http.Client {
+	timeout := def
+	if h.TimeoutSeconds > 0 {
+		timeout = time.Duration(h.TimeoutSeconds) * time.Second
+	}
+	return &http.Client{Transport: rt, Timeout: timeout}
+}
+
+// validate checks the fields that can be checked without touching the
+// filesystem or network.
+func (h HTTPConfig) validate() []string {
+	var errs []string
+	if h.ProxyURL != "" {
+		if u, err := url.Parse(h.ProxyURL); err != nil || u.Scheme == "" || u.Host == "" {
+			errs = append(errs, fmt.Sprintf("http.proxy_url %q must be an absolute URL like http://proxy:3128", h.ProxyURL))
+		}
+	}
+	if h.TimeoutSeconds < 0 || h.DialTimeoutSeconds < 0 {
+		errs = append(errs, "http timeouts must not be negative")
+	}
+	if h.MaxIdleConns < 0 || h.MaxIdleConnsPerHost < 0 {
+		errs = append(errs, "http idle connection limits must not be negative")
+	}
+	return errs
+}
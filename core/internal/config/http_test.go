@@ -0,0 +1,73 @@
+package config
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHTTPConfig_Proxy(t *testing.T) {
+	var proxied string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied = r.URL.String() // absolute-form request target when proxied
+		io.WriteString(w, "via proxy")
+	}))
+	defer proxy.Close()
+
+	h := HTTPConfig{ProxyURL: proxy.URL, MaxIdleConns: 10, MaxIdleConnsPerHost: 4}
+	tr, err := h.Transport()
+	if err != nil {
+		t.Fatalf("transport: %v", err)
+	}
+	if tr.MaxIdleConns != 10 || tr.MaxIdleConnsPerHost != 4 {
+		t.Errorf("idle limits not applied: %d, %d", tr.MaxIdleConns, tr.MaxIdleConnsPerHost)
+	}
+
+	resp, err := h.Client(tr, time.Second).Get("http://upstream.invalid/path")
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	defer resp.Body.Close()
+	if proxied != "http://upstream.invalid/path" {
+		t.Errorf("expected request to go through proxy, got %q", proxied)
+	}
+}
+
+func TestHTTPConfig_Client(t *testing.T) {
+	if c := (HTTPConfig{}).Client(nil, 42*time.Second); c.Timeout != 42*time.Second {
+		t.Errorf("default timeout = %v", c.Timeout)
+	}
+	if c := (HTTPConfig{TimeoutSeconds: 5}).Client(nil, 42*time.Second); c.Timeout != 5*time.Second {
+		t.Errorf("configured timeout = %v", c.Timeout)
+	}
+}
+
+func TestHTTPConfig_CAFile(t *testing.T) {
+	if _, err := (HTTPConfig{CAFile: filepath.Join(t.TempDir(), "missing.pem")}).Transport(); err == nil {
+		t.Error("expected error for missing CA file")
+	}
+
+	bad := filepath.Join(t.TempDir(), "bad.pem")
+	os.WriteFile(bad, []byte("not a certificate"), 0o644)
+	_, err := (HTTPConfig{CAFile: bad}).Transport()
+	if err == nil || !strings.Contains(err.Error(), "no PEM certificates") {
+		t.Errorf("expected PEM error, got %v", err)
+	}
+}
+
+func TestValidate_HTTPConfig(t *testing.T) {
+	cfg := &Config{
+		Hive:      HiveConfig{ID: "h", DataDir: "/data"},
+		Providers: map[string]ProviderConfig{"default": {APIKey: "k", Model: "m"}},
+		HTTP:      HTTPConfig{ProxyURL: "proxy:3128", TimeoutSeconds: -1},
+	}
+	err := cfg.Validate()
+	if err == nil || !strings.Contains(err.Error(), "http.proxy_url") || !strings.Contains(err.Error(), "timeouts") {
+		t.Errorf("expected proxy_url and timeout errors, got %v", err)
+	}
+}
@@ -1,12 +1,18 @@
 package config
 
 import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 )
 
@@ -16,8 +22,28 @@ type PlatformOptions struct {
 	HiveID      string
 	APIKey      string
 	DataDir     string // local data directory, default /data
+
+	// SigningSecret, if set, requires every platform response to carry a
+	// valid X-H1v3-Signature header ("sha256=" + hex HMAC-SHA256 of the body
+	// under this secret). Responses that fail verification are rejected and
+	// never cached.
+	SigningSecret string
+
+	// Logger receives the warning when a cached response is used because the
+	// platform could not be reached. Defaults to slog.Default().
+	Logger *slog.Logger
 }
 
+// PlatformSignatureHeader carries the HMAC signature of a platform response.
+const PlatformSignatureHeader = "X-H1v3-Signature"
+
+// platformCacheDir is where verified platform responses are kept, relative
+// to the data directory, so the daemon can start during a platform outage.
+const platformCacheDir = ".platform-cache"
+
+// errPlatformSignature marks a response that failed signature verification.
+var errPlatformSignature = errors.New("invalid response signature")
+
 // LoadFromPlatform fetches the hive configuration from the dashboard API,
 // sets up agent workspaces, and returns the parsed Config.
 func LoadFromPlatform(opts PlatformOptions) (*Config, error) {
@@ -61,6 +87,8 @@ func loadFromPlatformWithClient(opts PlatformOptions, client *http.Client) (*Con
 		applyPresetFile(cfg, preset)
 	}
 
+	cfg.applyBase()
+
 	// 3. Set up agent workspaces
 	for i, spec := range cfg.Agents {
 		agentDir := filepath.Join(opts.DataDir, "agents", spec.ID)
@@ -86,7 +114,49 @@ func loadFromPlatformWithClient(opts PlatformOptions, client *http.Client) (*Con
 }
 
 // fetchPlatformJSON fetches and parses a JSON endpoint from the platform.
+// Each verified response is cached under the data directory; when the
+// platform can't be reached or errors, the cached copy is used instead with
+// a staleness warning. Signature failures never fall back to the cache.
 func fetchPlatformJSON[T any](client *http.Client, opts PlatformOptions, path string) (*T, error) {
+	cachePath := filepath.Join(opts.DataDir, platformCacheDir, strings.ReplaceAll(strings.Trim(path, "/"), "/", "_")+".json")
+
+	body, err := fetchPlatform(client, opts, path)
+	fresh := err == nil
+	if !fresh {
+		if errors.Is(err, errPlatformSignature) {
+			return nil, err
+		}
+		cached, readErr := os.ReadFile(cachePath)
+		if readErr != nil {
+			return nil, err
+		}
+		logger := opts.Logger
+		if logger == nil {
+			logger = slog.Default()
+		}
+		age := "unknown"
+		if info, statErr := os.Stat(cachePath); statErr == nil {
+			age = time.Since(info.ModTime()).Round(time.Second).String()
+		}
+		logger.Warn("platform fetch failed, using cached response", "path", path, "age", age, "error", err)
+		body = cached
+	}
+
+	var result T
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, fmt.Errorf("platform: parse %s: %w", path, err)
+	}
+	if fresh {
+		// Config holds provider keys, so keep the cache private.
+		if err := os.MkdirAll(filepath.Dir(cachePath), 0o700); err == nil {
+			os.WriteFile(cachePath, body, 0o600)
+		}
+	}
+	return &result, nil
+}
+
+// fetchPlatform GETs a platform endpoint and returns its verified body.
+func fetchPlatform(client *http.Client, opts PlatformOptions, path string) ([]byte, error) {
 	url := fmt.Sprintf("%s%s", opts.PlatformURL, path)
 	req, err := http.NewRequest("GET", url, nil)
 	if err != nil {
@@ -110,9 +180,18 @@ func fetchPlatformJSON[T any](client *http.Client, opts PlatformOptions, path st
 		return nil, fmt.Errorf("platform: %s HTTP %d: %s", path, resp.StatusCode, string(body))
 	}
 
-	var result T
-	if err := json.Unmarshal(body, &result); err != nil {
-		return nil, fmt.Errorf("platform: parse %s: %w", path, err)
+	if opts.SigningSecret != "" {
+		want := SignPlatformResponse(opts.SigningSecret, body)
+		if !hmac.Equal([]byte(resp.Header.Get(PlatformSignatureHeader)), []byte(want)) {
+			return nil, fmt.Errorf("platform: %s: %w", path, errPlatformSignature)
+		}
 	}
-	return &result, nil
+	return body, nil
+}
+
+// SignPlatformResponse returns the PlatformSignatureHeader value for body.
+func SignPlatformResponse(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
 }
@@ -5,6 +5,7 @@ import (
 	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -155,3 +156,55 @@ func TestLoadFromPlatform_SOULNotOverwritten(t *testing.T) {
 		t.Errorf("SOUL.md was overwritten, got %q", string(data))
 	}
 }
+
+func TestLoadFromPlatform_CachedOnFailure(t *testing.T) {
+	up := true
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !up {
+			http.Error(w, "maintenance", http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(platformConfigJSON))
+	}))
+	defer srv.Close()
+
+	dataDir := t.TempDir()
+	opts := PlatformOptions{PlatformURL: srv.URL, HiveID: "x", APIKey: "k", DataDir: dataDir}
+	if _, err := LoadFromPlatform(opts); err != nil {
+		t.Fatalf("first load: %v", err)
+	}
+
+	up = false
+	cfg, err := LoadFromPlatform(opts)
+	if err != nil {
+		t.Fatalf("expected cached config during outage, got %v", err)
+	}
+	if cfg.Hive.ID != "test-hive" {
+		t.Errorf("hive.id = %q", cfg.Hive.ID)
+	}
+
+	if _, err := LoadFromPlatform(PlatformOptions{PlatformURL: srv.URL, HiveID: "x", APIKey: "k", DataDir: t.TempDir()}); err == nil {
+		t.Error("expected error during outage without a cache")
+	}
+}
+
+func TestLoadFromPlatform_Signature(t *testing.T) {
+	signature := SignPlatformResponse("s3cret", []byte(platformConfigJSON))
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(PlatformSignatureHeader, signature)
+		w.Write([]byte(platformConfigJSON))
+	}))
+	defer srv.Close()
+
+	dataDir := t.TempDir()
+	opts := PlatformOptions{PlatformURL: srv.URL, HiveID: "x", APIKey: "k", DataDir: dataDir, SigningSecret: "s3cret"}
+	if _, err := LoadFromPlatform(opts); err != nil {
+		t.Fatalf("signed load: %v", err)
+	}
+
+	// A tampered or unsigned response is rejected, even with a good cache.
+	signature = "sha256=00"
+	if _, err := LoadFromPlatform(opts); err == nil || !strings.Contains(err.Error(), "invalid response signature") {
+		t.Errorf("expected signature error, got %v", err)
+	}
+}
@@ -1,6 +1,12 @@
 package connector
 
-import "context"
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/h1v3-io/h1v3/pkg/protocol"
+)
 
 // Connector is the interface for external messaging platforms (Telegram, Slack, etc.).
 type Connector interface {
@@ -19,6 +25,8 @@ type OutboundMessage struct {
 	ChatID  string   // Platform-specific chat identifier
 	Content string   // Message text (Markdown)
 	Media   []string // Optional media file paths
+
+	Attachments []protocol.Attachment // Files to send alongside the text
 }
 
 // InboundMessage is a message received from an external platform.
@@ -28,8 +36,41 @@ type InboundMessage struct {
 	ChatID   string   // Platform-specific chat identifier
 	Content  string   // Message text
 	Media    []string // Downloaded media file paths
+	EventID  string   // Platform event ID (e.g. Telegram update ID), used to drop redeliveries
+
+	Attachments []protocol.Attachment // Downloaded files, with inline data
 }
 
 // InboundHandler processes messages received from external platforms.
 // Implementations typically create or append to tickets via the Front Agent.
 type InboundHandler func(ctx context.Context, msg InboundMessage) error
+
+// Receipt records that an outbound message reached the external platform.
+type Receipt struct {
+	Channel   string    // Connector name (e.g., "telegram")
+	ChatID    string    // Platform-specific chat identifier
+	MessageID string    // Platform message ID, if the platform returned one
+	Time      time.Time // When the platform accepted the message
+}
+
+// ReceiptSender is implemented by connectors that report what they
+// delivered. A nil receipt with a nil error means nothing was sent.
+type ReceiptSender interface {
+	SendWithReceipt(ctx context.Context, msg OutboundMessage) (*Receipt, error)
+}
+
+// ReceiptMessage builds the _system note recording r on a ticket. It is
+// meant to be persisted, not routed: it has no recipients, so it never
+// reaches an agent inbox or goes back out through a sink.
+func ReceiptMessage(ticketID string, r Receipt) protocol.Message {
+	content := fmt.Sprintf("[delivery receipt] Delivered to %s chat %s at %s", r.Channel, r.ChatID, r.Time.UTC().Format(time.RFC3339))
+	if r.MessageID != "" {
+		content += fmt.Sprintf(" (message ID %s)", r.MessageID)
+	}
+	return protocol.Message{
+		From:      "_system",
+		Content:   content,
+		TicketID:  ticketID,
+		Timestamp: r.Time,
+	}
+}
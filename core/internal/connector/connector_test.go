@@ -0,0 +1,29 @@
+package connector
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReceiptMessage(t *testing.T) {
+	at := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	msg := ReceiptMessage("t-1", Receipt{Channel: "telegram", ChatID: "42", MessageID: "777", Time: at})
+
+	if msg.From != "_system" || msg.TicketID != "t-1" || !msg.Timestamp.Equal(at) {
+		t.Errorf("unexpected message header: %+v", msg)
+	}
+	if len(msg.To) != 0 {
+		t.Errorf("receipt must have no recipients so it is never routed, got %v", msg.To)
+	}
+	for _, want := range []string{"telegram chat 42", "2026-03-01T12:00:00Z", "message ID 777"} {
+		if !strings.Contains(msg.Content, want) {
+			t.Errorf("content %q missing %q", msg.Content, want)
+		}
+	}
+
+	msg = ReceiptMessage("t-1", Receipt{Channel: "slack", ChatID: "C1", Time: at})
+	if strings.Contains(msg.Content, "message ID") {
+		t.Errorf("no message ID expected, got %q", msg.Content)
+	}
+}
@@ -0,0 +1,555 @@
+package discord
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/h1v3-io/h1v3/internal/connector"
+)
+
+const (
+	defaultAPIURL = "https://discord.com/api/v10"
+
+	// maxMessageLen is Discord's limit on a message's content, in characters.
+	maxMessageLen = 2000
+
+	// gatewayIntents subscribes to guilds, guild messages, direct messages
+	// and message content. Message content is a privileged intent that must
+	// be enabled for the bot in the developer portal.
+	gatewayIntents = 1<<0 | 1<<9 | 1<<12 | 1<<15
+
+	reconnectDelay = 5 * time.Second
+	maxRetryAfter  = 30 * time.Second // longer rate-limit waits fail the request
+)
+
+// Gateway opcodes.
+const (
+	opDispatch       = 0
+	opHeartbeat      = 1
+	opIdentify       = 2
+	opResume         = 6
+	opReconnect      = 7
+	opInvalidSession = 9
+	opHello          = 10
+)
+
+// Config holds Discord connector configuration.
+type Config struct {
+	Token    string   // bot token
+	Guilds   []string // Optional: only respond in these servers
+	Channels []string // Optional: only respond in these channels and their threads
+	APIURL   string   // REST base URL (default https://discord.com/api/v10)
+}
+
+// Connector implements connector.Connector for Discord via the bot gateway.
+type Connector struct {
+	config  Config
+	client  *http.Client
+	handler connector.InboundHandler
+	logger  *slog.Logger
+	cancel  context.CancelFunc
+	botID   string
+
+	parentsMu sync.Mutex
+	parents   map[string]string // channel ID → parent channel ID ("" if not a thread)
+}
+
+// New creates a new Discord connector and verifies the bot token.
+func New(cfg Config, handler connector.InboundHandler, logger *slog.Logger) (*Connector, error) {
+	if cfg.Token == "" {
+		return nil, fmt.Errorf("discord: token is required")
+	}
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	c := newConnector(cfg)
+	c.handler = handler
+	c.logger = logger
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	me, err := c.me(ctx)
+	if err != nil {
+		return nil, err
+	}
+	c.botID = me.ID
+	logger.Info("discord bot authorized", "user", me.Username)
+	return c, nil
+}
+
+func newConnector(cfg Config) *Connector {
+	if cfg.APIURL == "" {
+		cfg.APIURL = defaultAPIURL
+	}
+	cfg.APIURL = strings.TrimSuffix(cfg.APIURL, "/")
+	return &Connector{
+		config:  cfg,
+		client:  &http.Client{Timeout: 30 * time.Second},
+		parents: make(map[string]string),
+	}
+}
+
+// CheckAuth verifies the bot token without connecting to the gateway. It
+// returns the bot's username.
+func CheckAuth(ctx context.Context, cfg Config) (string, error) {
+	me, err := newConnector(cfg).me(ctx)
+	if err != nil {
+		return "", err
+	}
+	return me.Username, nil
+}
+
+func (c *Connector) Name() string { return "discord" }
+
+// Start connects to the gateway and dispatches messages until the context is
+// cancelled, reconnecting (and resuming the session where Discord allows)
+// whenever the connection drops. It returns early only if Discord rejects
+// the token or the requested intents.
+func (c *Connector) Start(ctx context.Context) error {
+	ctx, c.cancel = context.WithCancel(ctx)
+	c.logger.Info("discord connector started (gateway)")
+
+	var st gatewayState
+	for {
+		err := c.runGateway(ctx, &st)
+		if ctx.Err() != nil {
+			return nil
+		}
+		var closeErr *websocket.CloseError
+		if errors.As(err, &closeErr) && fatalCloseCode(closeErr.Code) {
+			c.logger.Error("discord gateway refused the connection", "code", closeErr.Code, "reason", closeErr.Text)
+			return fmt.Errorf("discord: gateway: %w", err)
+		}
+		c.logger.Warn("discord gateway disconnected, reconnecting", "error", err, "resume", st.resumable())
+
+		timer := time.NewTimer(reconnectDelay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil
+		case <-timer.C:
+		}
+	}
+}
+
+// Stop gracefully shuts down the connector.
+func (c *Connector) Stop() error {
+	if c.cancel != nil {
+		c.cancel()
+	}
+	return nil
+}
+
+// Send delivers a message to a Discord channel or thread.
+func (c *Connector) Send(ctx context.Context, msg connector.OutboundMessage) error {
+	_, err := c.SendWithReceipt(ctx, msg)
+	return err
+}
+
+// SendWithReceipt implements connector.ReceiptSender. Content longer than
+// Discord's limit is sent as several messages; the receipt carries the ID
+// of the first.
+func (c *Connector) SendWithReceipt(ctx context.Context, msg connector.OutboundMessage) (*connector.Receipt, error) {
+	var first string
+	for _, chunk := range splitMessage(MarkdownToDiscord(msg.Content), maxMessageLen) {
+		if strings.TrimSpace(chunk) == "" {
+			continue
+		}
+		body := map[string]any{
+			"content": chunk,
+			// Agents' text must not ping @everyone, roles or users.
+			"allowed_mentions": map[string]any{"parse": []string{}},
+		}
+		var sent struct {
+			ID string `json:"id"`
+		}
+		if err := c.api(ctx, http.MethodPost, "/channels/"+msg.ChatID+"/messages", body, &sent); err != nil {
+			return nil, fmt.Errorf("discord: send message: %w", err)
+		}
+		if first == "" {
+			first = sent.ID
+		}
+	}
+	if first == "" {
+		return nil, nil
+	}
+	return &connector.Receipt{Channel: "discord", ChatID: msg.ChatID, MessageID: first, Time: time.Now()}, nil
+}
+
+// --- Gateway ---
+
+// gatewayState survives reconnects so a dropped session can be resumed.
+type gatewayState struct {
+	sessionID string
+	resumeURL string
+	seq       atomic.Int64
+}
+
+func (st *gatewayState) resumable() bool { return st.sessionID != "" }
+
+type gatewayPayload struct {
+	Op int             `json:"op"`
+	D  json.RawMessage `json:"d,omitempty"`
+	S  *int64          `json:"s,omitempty"`
+	T  string          `json:"t,omitempty"`
+}
+
+// fatalCloseCode reports whether a gateway close code means reconnecting
+// cannot help: authentication failed, or the intents are invalid or not
+// enabled for the bot.
+func fatalCloseCode(code int) bool {
+	return code == 4004 || (code >= 4010 && code <= 4014)
+}
+
+// runGateway holds one gateway connection until it fails or ctx is done.
+func (c *Connector) runGateway(ctx context.Context, st *gatewayState) error {
+	url := st.resumeURL
+	if !st.resumable() || url == "" {
+		var gw struct {
+			URL string `json:"url"`
+		}
+		if err := c.api(ctx, http.MethodGet, "/gateway/bot", nil, &gw); err != nil {
+			return fmt.Errorf("get gateway: %w", err)
+		}
+		url = gw.URL
+	}
+
+	conn, _, err := websocket.DefaultDialer.DialContext(ctx, url+"?v=10&encoding=json", nil)
+	if err != nil {
+		return fmt.Errorf("dial: %w", err)
+	}
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-done:
+		}
+		conn.Close()
+	}()
+
+	var writeMu sync.Mutex
+	send := func(op int, d any) error {
+		writeMu.Lock()
+		defer writeMu.Unlock()
+		return conn.WriteJSON(map[string]any{"op": op, "d": d})
+	}
+	heartbeat := func() error {
+		var seq any
+		if n := st.seq.Load(); n > 0 {
+			seq = n
+		}
+		return send(opHeartbeat, seq)
+	}
+
+	var hello gatewayPayload
+	if err := conn.ReadJSON(&hello); err != nil {
+		return fmt.Errorf("read hello: %w", err)
+	}
+	var h struct {
+		HeartbeatInterval int `json:"heartbeat_interval"`
+	}
+	if hello.Op != opHello || json.Unmarshal(hello.D, &h) != nil || h.HeartbeatInterval <= 0 {
+		return fmt.Errorf("expected hello, got op %d", hello.Op)
+	}
+	go func() {
+		ticker := time.NewTicker(time.Duration(h.HeartbeatInterval) * time.Millisecond)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				if err := heartbeat(); err != nil {
+					return
+				}
+			}
+		}
+	}()
+
+	if st.resumable() {
+		err = send(opResume, map[string]any{"token": c.config.Token, "session_id": st.sessionID, "seq": st.seq.Load()})
+	} else {
+		err = send(opIdentify, map[string]any{
+			"token":   c.config.Token,
+			"intents": gatewayIntents,
+			"properties": map[string]string{
+				"os":      runtime.GOOS,
+				"browser": "h1v3",
+				"device":  "h1v3",
+			},
+		})
+	}
+	if err != nil {
+		return fmt.Errorf("identify: %w", err)
+	}
+
+	for {
+		var p gatewayPayload
+		if err := conn.ReadJSON(&p); err != nil {
+			return err
+		}
+		if p.S != nil {
+			st.seq.Store(*p.S)
+		}
+		switch p.Op {
+		case opDispatch:
+			c.dispatch(ctx, st, p.T, p.D)
+		case opHeartbeat:
+			if err := heartbeat(); err != nil {
+				return err
+			}
+		case opReconnect:
+			return fmt.Errorf("gateway requested a reconnect")
+		case opInvalidSession:
+			var resumable bool
+			json.Unmarshal(p.D, &resumable)
+			if !resumable {
+				st.sessionID, st.resumeURL = "", ""
+				st.seq.Store(0)
+			}
+			return fmt.Errorf("invalid session (resumable: %t)", resumable)
+		}
+	}
+}
+
+// dispatch handles a gateway event.
+func (c *Connector) dispatch(ctx context.Context, st *gatewayState, event string, data json.RawMessage) {
+	switch event {
+	case "READY":
+		var ready struct {
+			SessionID string `json:"session_id"`
+			ResumeURL string `json:"resume_gateway_url"`
+			User      user   `json:"user"`
+		}
+		if err := json.Unmarshal(data, &ready); err != nil {
+			c.logger.Error("discord: bad READY event", "error", err)
+			return
+		}
+		st.sessionID, st.resumeURL = ready.SessionID, ready.ResumeURL
+		if ready.User.ID != "" {
+			c.botID = ready.User.ID
+		}
+	case "THREAD_CREATE", "THREAD_UPDATE":
+		var thread struct {
+			ID       string `json:"id"`
+			ParentID string `json:"parent_id"`
+		}
+		if json.Unmarshal(data, &thread) == nil && thread.ID != "" {
+			c.parentsMu.Lock()
+			c.parents[thread.ID] = thread.ParentID
+			c.parentsMu.Unlock()
+		}
+	case "MESSAGE_CREATE":
+		var m message
+		if err := json.Unmarshal(data, &m); err != nil {
+			c.logger.Error("discord: bad MESSAGE_CREATE event", "error", err)
+			return
+		}
+		c.handleMessage(ctx, m)
+	}
+}
+
+type user struct {
+	ID       string `json:"id"`
+	Username string `json:"username"`
+	Bot      bool   `json:"bot"`
+}
+
+type message struct {
+	ID        string `json:"id"`
+	Type      int    `json:"type"`
+	ChannelID string `json:"channel_id"`
+	GuildID   string `json:"guild_id"`
+	Author    user   `json:"author"`
+	Content   string `json:"content"`
+}
+
+func (c *Connector) handleMessage(ctx context.Context, m message) {
+	// Ignore bots (including ourselves) and system messages; 0 is a plain
+	// message, 19 a reply.
+	if m.Author.Bot || m.Author.ID == "" || m.Author.ID == c.botID {
+		return
+	}
+	if m.Type != 0 && m.Type != 19 {
+		return
+	}
+	if !c.isAllowed(ctx, m.GuildID, m.ChannelID) {
+		return
+	}
+
+	text := StripMention(m.Content, c.botID)
+	if text == "" {
+		return
+	}
+
+	// Threads are channels of their own, so replies in a thread stay there.
+	inbound := connector.InboundMessage{
+		Channel:  "discord",
+		SenderID: m.Author.ID,
+		ChatID:   m.ChannelID,
+		Content:  text,
+		EventID:  m.ID,
+	}
+	if err := c.handler(ctx, inbound); err != nil {
+		c.logger.Error("discord inbound handler error",
+			"channel", m.ChannelID,
+			"user", m.Author.ID,
+			"error", err,
+		)
+	}
+}
+
+// isAllowed applies the allow-lists: a message is accepted if its server is
+// in Guilds, or its channel, or a thread's parent channel, is in Channels.
+// With both lists empty every message is accepted.
+func (c *Connector) isAllowed(ctx context.Context, guildID, channelID string) bool {
+	if len(c.config.Guilds) == 0 && len(c.config.Channels) == 0 {
+		return true
+	}
+	if guildID != "" && slices.Contains(c.config.Guilds, guildID) {
+		return true
+	}
+	if slices.Contains(c.config.Channels, channelID) {
+		return true
+	}
+	if len(c.config.Channels) == 0 {
+		return false
+	}
+	parent := c.parentOf(ctx, channelID)
+	return parent != "" && slices.Contains(c.config.Channels, parent)
+}
+
+// parentOf returns the parent channel of a thread, or "" for a channel that
+// is not a thread. Lookups are cached.
+func (c *Connector) parentOf(ctx context.Context, channelID string) string {
+	c.parentsMu.Lock()
+	parent, ok := c.parents[channelID]
+	c.parentsMu.Unlock()
+	if ok {
+		return parent
+	}
+
+	var ch struct {
+		Type     int    `json:"type"`
+		ParentID string `json:"parent_id"`
+	}
+	if err := c.api(ctx, http.MethodGet, "/channels/"+channelID, nil, &ch); err != nil {
+		c.logger.Warn("discord: channel lookup failed", "channel", channelID, "error", err)
+		return ""
+	}
+	// Only threads (types 10-12) inherit their parent's allow-listing; a
+	// channel's parent is its category.
+	if ch.Type >= 10 && ch.Type <= 12 {
+		parent = ch.ParentID
+	}
+	c.parentsMu.Lock()
+	c.parents[channelID] = parent
+	c.parentsMu.Unlock()
+	return parent
+}
+
+// StripMention removes mentions of the bot (<@ID> or <@!ID>) from message
+// text.
+func StripMention(text, botID string) string {
+	if botID != "" {
+		text = strings.ReplaceAll(text, "<@"+botID+">", "")
+		text = strings.ReplaceAll(text, "<@!"+botID+">", "")
+	}
+	return strings.TrimSpace(text)
+}
+
+// --- REST ---
+
+func (c *Connector) me(ctx context.Context) (*user, error) {
+	var me user
+	if err := c.api(ctx, http.MethodGet, "/users/@me", nil, &me); err != nil {
+		return nil, fmt.Errorf("discord: auth: %w", err)
+	}
+	return &me, nil
+}
+
+// api calls a REST endpoint, decoding the JSON response into out. A
+// rate-limited request is retried once after the wait Discord asks for.
+func (c *Connector) api(ctx context.Context, method, path string, body, out any) error {
+	var payload []byte
+	if body != nil {
+		var err error
+		if payload, err = json.Marshal(body); err != nil {
+			return err
+		}
+	}
+
+	for attempt := 0; ; attempt++ {
+		req, err := http.NewRequestWithContext(ctx, method, c.config.APIURL+path, bytes.NewReader(payload))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Authorization", "Bot "+c.config.Token)
+		req.Header.Set("User-Agent", "DiscordBot (https://github.com/h1v3-io/h1v3, 1)")
+		if body != nil {
+			req.Header.Set("Content-Type", "application/json")
+		}
+
+		resp, err := c.client.Do(req)
+		if err != nil {
+			return err
+		}
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+		resp.Body.Close()
+
+		if resp.StatusCode == http.StatusTooManyRequests && attempt == 0 {
+			if wait, ok := retryAfter(resp.Header, data); ok {
+				timer := time.NewTimer(wait)
+				select {
+				case <-ctx.Done():
+					timer.Stop()
+					return ctx.Err()
+				case <-timer.C:
+				}
+				continue
+			}
+		}
+		if resp.StatusCode/100 != 2 {
+			return fmt.Errorf("%s %s: %s: %s", method, path, resp.Status, strings.TrimSpace(string(data)))
+		}
+		if out != nil {
+			if err := json.Unmarshal(data, out); err != nil {
+				return fmt.Errorf("%s %s: decode: %w", method, path, err)
+			}
+		}
+		return nil
+	}
+}
+
+// retryAfter returns how long a 429 response asks to wait, from the JSON
+// body or the Retry-After header, if it is short enough to wait out.
+func retryAfter(h http.Header, body []byte) (time.Duration, bool) {
+	var rl struct {
+		RetryAfter float64 `json:"retry_after"` // seconds
+	}
+	secs := -1.0
+	if json.Unmarshal(body, &rl) == nil && rl.RetryAfter > 0 {
+		secs = rl.RetryAfter
+	} else if v, err := strconv.ParseFloat(h.Get("Retry-After"), 64); err == nil {
+		secs = v
+	}
+	wait := time.Duration(secs * float64(time.Second))
+	return wait, secs >= 0 && wait <= maxRetryAfter
+}
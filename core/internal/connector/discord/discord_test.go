@@ -0,0 +1,213 @@
+package discord
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/h1v3-io/h1v3/internal/connector"
+)
+
+// fakeDiscord serves the REST endpoints the connector uses and a gateway
+// that says hello, checks the identify payload and then sends events.
+type fakeDiscord struct {
+	t      *testing.T
+	srv    *httptest.Server
+	events []string // dispatch payloads sent after identify
+
+	mu       sync.Mutex
+	identify map[string]any
+	posted   []map[string]any
+}
+
+func newFakeDiscord(t *testing.T, events ...string) *fakeDiscord {
+	f := &fakeDiscord{t: t, events: events}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /users/@me", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bot tok" {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"message":"401: Unauthorized","code":0}`))
+			return
+		}
+		w.Write([]byte(`{"id":"bot-1","username":"hivebot","bot":true}`))
+	})
+	mux.HandleFunc("GET /gateway/bot", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{"url": "ws" + strings.TrimPrefix(f.srv.URL, "http") + "/gateway"})
+	})
+	mux.HandleFunc("GET /channels/{id}", func(w http.ResponseWriter, r *http.Request) {
+		switch r.PathValue("id") {
+		case "thread-1":
+			w.Write([]byte(`{"id":"thread-1","type":11,"parent_id":"chan-ok"}`))
+		default:
+			w.Write([]byte(`{"id":"` + r.PathValue("id") + `","type":0,"parent_id":"category-1"}`))
+		}
+	})
+	mux.HandleFunc("POST /channels/{id}/messages", func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		json.NewDecoder(r.Body).Decode(&body)
+		f.mu.Lock()
+		f.posted = append(f.posted, body)
+		n := len(f.posted)
+		f.mu.Unlock()
+		json.NewEncoder(w).Encode(map[string]string{"id": fmt.Sprintf("msg-%d", n)})
+	})
+	mux.HandleFunc("/gateway", f.gateway)
+	f.srv = httptest.NewServer(mux)
+	t.Cleanup(f.srv.Close)
+	return f
+}
+
+func (f *fakeDiscord) gateway(w http.ResponseWriter, r *http.Request) {
+	conn, err := (&websocket.Upgrader{}).Upgrade(w, r, nil)
+	if err != nil {
+		f.t.Errorf("upgrade: %v", err)
+		return
+	}
+	defer conn.Close()
+
+	conn.WriteJSON(map[string]any{"op": opHello, "d": map[string]any{"heartbeat_interval": 45000}})
+	var identify struct {
+		Op int            `json:"op"`
+		D  map[string]any `json:"d"`
+	}
+	if err := conn.ReadJSON(&identify); err != nil || identify.Op != opIdentify {
+		f.t.Errorf("expected identify, got op %d, err %v", identify.Op, err)
+		return
+	}
+	f.mu.Lock()
+	f.identify = identify.D
+	f.mu.Unlock()
+
+	conn.WriteMessage(websocket.TextMessage, []byte(`{"op":0,"s":1,"t":"READY","d":{"session_id":"sess-1","resume_gateway_url":"ws://unused","user":{"id":"bot-1","username":"hivebot","bot":true}}}`))
+	for i, ev := range f.events {
+		conn.WriteMessage(websocket.TextMessage, []byte(fmt.Sprintf(`{"op":0,"s":%d,"t":"MESSAGE_CREATE","d":%s}`, i+2, ev)))
+	}
+	// Hold the connection until the client goes away.
+	for {
+		if _, _, err := conn.ReadMessage(); err != nil {
+			return
+		}
+	}
+}
+
+func TestConnector_InboundMessages(t *testing.T) {
+	f := newFakeDiscord(t,
+		`{"id":"m1","type":0,"channel_id":"chan-ok","guild_id":"g1","author":{"id":"u1"},"content":"<@bot-1> deploy the app"}`,
+		`{"id":"m2","type":0,"channel_id":"chan-ok","guild_id":"g1","author":{"id":"other-bot","bot":true},"content":"beep"}`,
+		`{"id":"m3","type":0,"channel_id":"chan-other","guild_id":"g1","author":{"id":"u1"},"content":"not here"}`,
+		`{"id":"m4","type":7,"channel_id":"chan-ok","guild_id":"g1","author":{"id":"u2"},"content":""}`,
+		`{"id":"m5","type":19,"channel_id":"thread-1","guild_id":"g1","author":{"id":"u2"},"content":"in a thread"}`,
+	)
+
+	got := make(chan connector.InboundMessage, 10)
+	c, err := New(Config{Token: "tok", Channels: []string{"chan-ok"}, APIURL: f.srv.URL},
+		func(_ context.Context, msg connector.InboundMessage) error {
+			got <- msg
+			return nil
+		}, nil)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	stopped := make(chan error, 1)
+	go func() { stopped <- c.Start(ctx) }()
+
+	want := []connector.InboundMessage{
+		{Channel: "discord", SenderID: "u1", ChatID: "chan-ok", Content: "deploy the app", EventID: "m1"},
+		{Channel: "discord", SenderID: "u2", ChatID: "thread-1", Content: "in a thread", EventID: "m5"},
+	}
+	for _, w := range want {
+		select {
+		case msg := <-got:
+			if msg.Channel != w.Channel || msg.SenderID != w.SenderID || msg.ChatID != w.ChatID || msg.Content != w.Content || msg.EventID != w.EventID {
+				t.Errorf("got %+v, want %+v", msg, w)
+			}
+		case <-time.After(3 * time.Second):
+			t.Fatalf("timed out waiting for %s", w.EventID)
+		}
+	}
+	select {
+	case msg := <-got:
+		t.Errorf("unexpected message %+v", msg)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	f.mu.Lock()
+	if f.identify["token"] != "tok" || f.identify["intents"] != float64(gatewayIntents) {
+		t.Errorf("unexpected identify payload %v", f.identify)
+	}
+	f.mu.Unlock()
+
+	c.Stop()
+	select {
+	case err := <-stopped:
+		if err != nil {
+			t.Errorf("Start returned %v after Stop", err)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("Start did not return after Stop")
+	}
+}
+
+func TestConnector_Send(t *testing.T) {
+	f := newFakeDiscord(t)
+	c, err := New(Config{Token: "tok", APIURL: f.srv.URL}, nil, nil)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	long := "Report\n\n" + strings.Repeat("A line of the report.\n", 150) // over 2000 characters
+	receipt, err := c.SendWithReceipt(context.Background(), connector.OutboundMessage{ChatID: "chan-ok", Content: long})
+	if err != nil {
+		t.Fatalf("send: %v", err)
+	}
+	if receipt == nil || receipt.Channel != "discord" || receipt.ChatID != "chan-ok" || receipt.MessageID != "msg-1" {
+		t.Errorf("unexpected receipt %+v", receipt)
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if len(f.posted) != 2 {
+		t.Fatalf("expected the message split in 2, got %d", len(f.posted))
+	}
+	for _, p := range f.posted {
+		if n := len([]rune(p["content"].(string))); n > maxMessageLen {
+			t.Errorf("chunk of %d characters exceeds the limit", n)
+		}
+		if am, _ := p["allowed_mentions"].(map[string]any); am == nil || len(am["parse"].([]any)) != 0 {
+			t.Errorf("expected mentions disabled, got %v", p["allowed_mentions"])
+		}
+	}
+}
+
+func TestCheckAuth(t *testing.T) {
+	f := newFakeDiscord(t)
+	if name, err := CheckAuth(context.Background(), Config{Token: "tok", APIURL: f.srv.URL}); err != nil || name != "hivebot" {
+		t.Errorf("expected hivebot, got %q, %v", name, err)
+	}
+	if _, err := CheckAuth(context.Background(), Config{Token: "bad", APIURL: f.srv.URL}); err == nil || !strings.Contains(err.Error(), "401") {
+		t.Errorf("expected 401 error, got %v", err)
+	}
+}
+
+func TestStripMention(t *testing.T) {
+	for in, want := range map[string]string{
+		"<@bot-1> hello":     "hello",
+		"hi <@!bot-1>":       "hi",
+		"<@someone-else> yo": "<@someone-else> yo",
+	} {
+		if got := StripMention(in, "bot-1"); got != want {
+			t.Errorf("StripMention(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,144 @@
+package discord
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/h1v3-io/h1v3/internal/connector/markdown"
+)
+
+// MarkdownToDiscord converts standard Markdown to the dialect Discord
+// renders. Most of it carries over; headings deeper than ### become bold
+// lines, and tables (which Discord cannot show) are aligned in a code block.
+func MarkdownToDiscord(md string) string {
+	blocks := markdown.Parse(md)
+	lines := make([]string, 0, len(blocks))
+	for _, bl := range blocks {
+		switch bl.Kind {
+		case markdown.Blank:
+			lines = append(lines, "")
+		case markdown.Heading:
+			text := inline(markdown.ParseInline(bl.Text))
+			if bl.Level <= 3 {
+				lines = append(lines, strings.Repeat("#", bl.Level)+" "+text)
+			} else {
+				lines = append(lines, "**"+text+"**")
+			}
+		case markdown.ListItem:
+			bullet := "-"
+			if bl.Ordered {
+				bullet = bl.Marker
+			}
+			lines = append(lines, strings.Repeat("  ", bl.Level)+bullet+" "+inline(markdown.ParseInline(bl.Text)))
+		case markdown.Quote:
+			for _, q := range strings.Split(bl.Text, "\n") {
+				lines = append(lines, "> "+inline(markdown.ParseInline(q)))
+			}
+		case markdown.Code:
+			lines = append(lines, "```"+bl.Lang+"\n"+bl.Text+"\n```")
+		case markdown.Table:
+			lines = append(lines, "```\n"+markdown.AlignTable(bl.Rows)+"\n```")
+		case markdown.Rule:
+			lines = append(lines, "──────────")
+		default:
+			lines = append(lines, inline(markdown.ParseInline(bl.Text)))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// inline renders inline spans in Discord Markdown.
+func inline(spans []markdown.Span) string {
+	var b strings.Builder
+	for _, sp := range spans {
+		switch sp.Kind {
+		case markdown.Bold:
+			b.WriteString("**" + inline(sp.Children) + "**")
+		case markdown.Italic:
+			b.WriteString("*" + inline(sp.Children) + "*")
+		case markdown.Strike:
+			b.WriteString("~~" + inline(sp.Children) + "~~")
+		case markdown.Mono:
+			b.WriteString("`" + sp.Text + "`")
+		case markdown.Link:
+			fmt.Fprintf(&b, "[%s](%s)", sp.Text, sp.URL)
+		default:
+			b.WriteString(sp.Text)
+		}
+	}
+	return b.String()
+}
+
+// splitMessage breaks text into chunks of at most limit characters, at line
+// boundaries where possible. A code block cut by a chunk boundary is closed
+// at the end of the chunk and reopened at the start of the next.
+func splitMessage(text string, limit int) []string {
+	if utf8.RuneCountInString(text) <= limit {
+		return []string{text}
+	}
+
+	const closing = "\n```"
+	budget := limit - utf8.RuneCountInString(closing)
+	var chunks []string
+	var cur strings.Builder
+	curLen := 0
+	fence := "" // opening line of the code block open at the current position
+
+	flush := func() {
+		s := cur.String()
+		if fence != "" {
+			s += closing
+		}
+		chunks = append(chunks, s)
+		cur.Reset()
+		curLen = 0
+		if fence != "" {
+			cur.WriteString(fence)
+			curLen = utf8.RuneCountInString(fence)
+		}
+	}
+
+	for _, line := range strings.Split(text, "\n") {
+		// A line too long for a chunk of its own is cut, leaving room for a
+		// reopened fence.
+		maxPiece := budget - utf8.RuneCountInString(fence) - 1
+		for _, piece := range cutRunes(line, maxPiece) {
+			n := utf8.RuneCountInString(piece)
+			if curLen > 0 && curLen+1+n > budget {
+				flush()
+			}
+			if curLen > 0 {
+				cur.WriteByte('\n')
+				curLen++
+			}
+			cur.WriteString(piece)
+			curLen += n
+		}
+		if trimmed := strings.TrimSpace(line); strings.HasPrefix(trimmed, "```") {
+			if fence == "" {
+				fence = trimmed
+			} else {
+				fence = ""
+			}
+		}
+	}
+	if curLen > 0 {
+		chunks = append(chunks, cur.String())
+	}
+	return chunks
+}
+
+// cutRunes splits s into pieces of at most n runes.
+func cutRunes(s string, n int) []string {
+	if n <= 0 || utf8.RuneCountInString(s) <= n {
+		return []string{s}
+	}
+	var pieces []string
+	runes := []rune(s)
+	for len(runes) > n {
+		pieces = append(pieces, string(runes[:n]))
+		runes = runes[n:]
+	}
+	return append(pieces, string(runes))
+}
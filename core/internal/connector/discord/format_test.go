@@ -0,0 +1,59 @@
+package discord
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestMarkdownToDiscord(t *testing.T) {
+	tests := []struct {
+		name, in, want string
+	}{
+		{"inline", "**bold**, *italic*, ~~gone~~ and `code`", "**bold**, *italic*, ~~gone~~ and `code`"},
+		{"link", "See [docs](https://example.com)", "See [docs](https://example.com)"},
+		{"headings", "# Title\n#### Deep", "# Title\n**Deep**"},
+		{"nested list", "- one\n  - two\n1. first", "- one\n  - two\n1. first"},
+		{"quote", "> quoted **text**", "> quoted **text**"},
+		{"code", "```go\nx := 1\n```", "```go\nx := 1\n```"},
+		{"table", "| a | b |\n|---|---|\n| 1 | 2 |", "```\na  b\n-  -\n1  2\n```"},
+		{"rule", "---", "──────────"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := MarkdownToDiscord(tt.in); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSplitMessage(t *testing.T) {
+	if got := splitMessage("short", 2000); len(got) != 1 || got[0] != "short" {
+		t.Errorf("expected short text unchanged, got %q", got)
+	}
+
+	text := "intro\n```go\n" + strings.Repeat("fmt.Println(\"line\")\n", 10) + "```\noutro"
+	chunks := splitMessage(text, 80)
+	if len(chunks) < 3 {
+		t.Fatalf("expected several chunks, got %d", len(chunks))
+	}
+	for i, c := range chunks {
+		if n := utf8.RuneCountInString(c); n > 80 {
+			t.Errorf("chunk %d has %d characters", i, n)
+		}
+		if strings.Count(c, "```")%2 != 0 {
+			t.Errorf("chunk %d leaves a code block open: %q", i, c)
+		}
+	}
+	if !strings.HasPrefix(chunks[1], "```go\n") {
+		t.Errorf("expected the code block reopened in the next chunk, got %q", chunks[1])
+	}
+
+	long := strings.Repeat("é", 250)
+	for i, c := range splitMessage(long, 100) {
+		if n := utf8.RuneCountInString(c); n > 100 {
+			t.Errorf("piece %d has %d characters", i, n)
+		}
+	}
+}
@@ -0,0 +1,212 @@
+// Package httpchat is a connector for custom chat frontends. Clients post
+// messages for a session ID of their choosing and collect the replies over
+// HTTP, by long-polling or as a server-sent event stream; the API server
+// exposes it at /api/chat. Sessions get the same lifecycle as Telegram or
+// Slack chats, including /new and /parallel.
+package httpchat
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/h1v3-io/h1v3/internal/connector"
+	"github.com/h1v3-io/h1v3/pkg/protocol"
+)
+
+// Defaults for Config.
+const (
+	DefaultMaxQueued   = 100
+	DefaultIdleTimeout = time.Hour
+)
+
+// Config holds HTTP chat connector settings.
+type Config struct {
+	MaxQueued   int           // undelivered replies kept per session; the oldest are dropped beyond it
+	IdleTimeout time.Duration // sessions untouched this long lose their queued replies
+}
+
+// Reply is one message from the hive to a session.
+type Reply struct {
+	ID          string                `json:"id"`
+	Content     string                `json:"content"`
+	Attachments []protocol.Attachment `json:"attachments,omitempty"`
+	Time        time.Time             `json:"time"`
+
+	seq int64 // position in the connector's reply sequence, for WaitAfter
+}
+
+// validSessionID keeps session IDs usable as ticket tags and in URLs.
+var validSessionID = regexp.MustCompile(`^[A-Za-z0-9_.:-]{1,128}$`)
+
+// ValidSessionID reports whether id can be used as a session ID.
+func ValidSessionID(id string) bool { return validSessionID.MatchString(id) }
+
+// Connector queues replies per session until a client collects them.
+type Connector struct {
+	config  Config
+	handler connector.InboundHandler
+	logger  *slog.Logger
+
+	mu       sync.Mutex
+	sessions map[string]*session
+	seq      int64
+}
+
+type session struct {
+	replies  []Reply
+	notify   chan struct{} // closed and replaced whenever a reply is queued
+	lastUsed time.Time
+}
+
+// New creates an HTTP chat connector that passes posted messages to handler.
+func New(cfg Config, handler connector.InboundHandler, logger *slog.Logger) *Connector {
+	if cfg.MaxQueued <= 0 {
+		cfg.MaxQueued = DefaultMaxQueued
+	}
+	if cfg.IdleTimeout <= 0 {
+		cfg.IdleTimeout = DefaultIdleTimeout
+	}
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &Connector{
+		config:   cfg,
+		handler:  handler,
+		logger:   logger,
+		sessions: make(map[string]*session),
+	}
+}
+
+func (c *Connector) Name() string { return "http" }
+
+// Start expires idle sessions. Blocks until ctx is cancelled.
+func (c *Connector) Start(ctx context.Context) error {
+	ticker := time.NewTicker(c.config.IdleTimeout / 4)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case now := <-ticker.C:
+			c.expire(now)
+		}
+	}
+}
+
+func (c *Connector) Stop() error { return nil }
+
+func (c *Connector) expire(now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for id, s := range c.sessions {
+		if now.Sub(s.lastUsed) > c.config.IdleTimeout {
+			delete(c.sessions, id)
+			c.logger.Debug("http chat session expired", "session", id, "dropped", len(s.replies))
+		}
+	}
+}
+
+// Post hands a client message to the inbound handler. eventID, if set, lets
+// the session manager drop client retries. The returned cursor marks the
+// replies already queued; WaitAfter with it skips them, so a reply to an
+// earlier message is not mistaken for the answer to this one.
+func (c *Connector) Post(ctx context.Context, sessionID, content, eventID string) (int64, error) {
+	if !ValidSessionID(sessionID) {
+		return 0, fmt.Errorf("httpchat: invalid session ID %q", sessionID)
+	}
+	c.mu.Lock()
+	c.session(sessionID).lastUsed = time.Now()
+	cursor := c.seq
+	c.mu.Unlock()
+	return cursor, c.handler(ctx, connector.InboundMessage{
+		Channel:  "http",
+		SenderID: sessionID,
+		ChatID:   sessionID,
+		Content:  content,
+		EventID:  eventID,
+	})
+}
+
+// Send queues a reply for the session in msg.ChatID.
+func (c *Connector) Send(ctx context.Context, msg connector.OutboundMessage) error {
+	_, err := c.SendWithReceipt(ctx, msg)
+	return err
+}
+
+// SendWithReceipt queues a reply and reports it as delivered. The receipt
+// means the reply is ready for the client, not that the client fetched it.
+func (c *Connector) SendWithReceipt(_ context.Context, msg connector.OutboundMessage) (*connector.Receipt, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.seq++
+	r := Reply{
+		ID:          strconv.FormatInt(c.seq, 10),
+		Content:     msg.Content,
+		Attachments: msg.Attachments,
+		Time:        time.Now(),
+		seq:         c.seq,
+	}
+	s := c.session(msg.ChatID)
+	s.replies = append(s.replies, r)
+	if over := len(s.replies) - c.config.MaxQueued; over > 0 {
+		c.logger.Warn("http chat queue full, dropping oldest replies", "session", msg.ChatID, "dropped", over)
+		s.replies = s.replies[over:]
+	}
+	close(s.notify)
+	s.notify = make(chan struct{})
+	return &connector.Receipt{Channel: "http", ChatID: msg.ChatID, MessageID: r.ID, Time: r.Time}, nil
+}
+
+// Wait returns the session's queued replies, removing them from the queue.
+// If none are queued it blocks until one arrives or ctx ends, in which case
+// it returns nil.
+func (c *Connector) Wait(ctx context.Context, sessionID string) []Reply {
+	return c.WaitAfter(ctx, sessionID, 0)
+}
+
+// WaitAfter is Wait for the replies queued after cursor, as returned by
+// Post. Older replies stay queued for a later Wait.
+func (c *Connector) WaitAfter(ctx context.Context, sessionID string, cursor int64) []Reply {
+	for {
+		c.mu.Lock()
+		s := c.session(sessionID)
+		s.lastUsed = time.Now()
+		i := len(s.replies)
+		for i > 0 && s.replies[i-1].seq > cursor {
+			i--
+		}
+		if i < len(s.replies) {
+			out := s.replies[i:]
+			s.replies = s.replies[:i:i]
+			if len(s.replies) == 0 {
+				s.replies = nil
+			}
+			c.mu.Unlock()
+			return out
+		}
+		notify := s.notify
+		c.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-notify:
+		}
+	}
+}
+
+// session returns the session, creating it if needed. Callers hold c.mu.
+func (c *Connector) session(id string) *session {
+	s, ok := c.sessions[id]
+	if !ok {
+		s = &session{notify: make(chan struct{}), lastUsed: time.Now()}
+		c.sessions[id] = s
+	}
+	return s
+}
@@ -0,0 +1,91 @@
+package httpchat
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/h1v3-io/h1v3/internal/connector"
+)
+
+func TestPostAndWait(t *testing.T) {
+	var got []connector.InboundMessage
+	c := New(Config{}, func(_ context.Context, msg connector.InboundMessage) error {
+		got = append(got, msg)
+		return nil
+	}, nil)
+
+	if _, err := c.Post(context.Background(), "user-1", "hello", "evt-1"); err != nil {
+		t.Fatalf("post: %v", err)
+	}
+	if len(got) != 1 || got[0].ChatID != "user-1" || got[0].Channel != "http" || got[0].EventID != "evt-1" {
+		t.Fatalf("unexpected inbound %+v", got)
+	}
+	if _, err := c.Post(context.Background(), "bad id", "hello", ""); err == nil {
+		t.Error("expected error for invalid session ID")
+	}
+
+	// Wait blocks until a reply is queued.
+	done := make(chan []Reply)
+	go func() { done <- c.Wait(context.Background(), "user-1") }()
+	time.Sleep(20 * time.Millisecond)
+	receipt, err := c.SendWithReceipt(context.Background(), connector.OutboundMessage{ChatID: "user-1", Content: "hi there"})
+	if err != nil || receipt.Channel != "http" || receipt.MessageID != "1" {
+		t.Fatalf("unexpected receipt %+v, %v", receipt, err)
+	}
+	select {
+	case replies := <-done:
+		if len(replies) != 1 || replies[0].Content != "hi there" {
+			t.Errorf("unexpected replies %+v", replies)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Wait did not return after a reply was queued")
+	}
+
+	// Replies are handed out once.
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if replies := c.Wait(ctx, "user-1"); replies != nil {
+		t.Errorf("expected no replies left, got %+v", replies)
+	}
+}
+
+func TestQueueCapAndExpiry(t *testing.T) {
+	c := New(Config{MaxQueued: 2, IdleTimeout: time.Minute}, nil, nil)
+	for _, content := range []string{"one", "two", "three"} {
+		c.Send(context.Background(), connector.OutboundMessage{ChatID: "s", Content: content})
+	}
+	replies := c.Wait(context.Background(), "s")
+	if len(replies) != 2 || replies[0].Content != "two" || replies[1].Content != "three" {
+		t.Errorf("expected the two newest replies, got %+v", replies)
+	}
+
+	c.Send(context.Background(), connector.OutboundMessage{ChatID: "s", Content: "late"})
+	c.expire(time.Now().Add(2 * time.Minute))
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if replies := c.Wait(ctx, "s"); replies != nil {
+		t.Errorf("expected expired session to lose its replies, got %+v", replies)
+	}
+}
+
+func TestWaitAfterSkipsEarlierReplies(t *testing.T) {
+	var c *Connector
+	c = New(Config{}, func(ctx context.Context, msg connector.InboundMessage) error {
+		return c.Send(ctx, connector.OutboundMessage{ChatID: msg.ChatID, Content: "re: " + msg.Content})
+	}, nil)
+
+	// A reply nobody collected, e.g. after a poll timed out.
+	c.Send(context.Background(), connector.OutboundMessage{ChatID: "s", Content: "stale"})
+
+	cursor, err := c.Post(context.Background(), "s", "hello", "")
+	if err != nil {
+		t.Fatalf("post: %v", err)
+	}
+	if replies := c.WaitAfter(context.Background(), "s", cursor); len(replies) != 1 || replies[0].Content != "re: hello" {
+		t.Errorf("expected only the reply to this post, got %+v", replies)
+	}
+	if replies := c.Wait(context.Background(), "s"); len(replies) != 1 || replies[0].Content != "stale" {
+		t.Errorf("expected the earlier reply to stay queued, got %+v", replies)
+	}
+}
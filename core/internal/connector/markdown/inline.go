@@ -0,0 +1,167 @@
+package markdown
+
+import "strings"
+
+// SpanKind identifies an inline construct.
+type SpanKind int
+
+const (
+	Text   SpanKind = iota // literal text
+	Bold                   // **x**
+	Italic                 // *x* or _x_
+	Strike                 // ~~x~~
+	Mono                   // `x`
+	Link                   // [text](url)
+)
+
+// Span is one inline element. Bold, Italic and Strike hold their content in
+// Children; Text and Mono hold it in Text; Link has Text and URL.
+type Span struct {
+	Kind     SpanKind
+	Text     string
+	URL      string
+	Children []Span
+}
+
+// ParseInline tokenizes inline Markdown. Delimiters without a match, or that
+// open onto whitespace (as in "2 * 3"), stay literal.
+func ParseInline(s string) []Span {
+	var spans []Span
+	var text strings.Builder
+	flush := func() {
+		if text.Len() > 0 {
+			spans = append(spans, Span{Kind: Text, Text: text.String()})
+			text.Reset()
+		}
+	}
+
+	for i := 0; i < len(s); {
+		switch {
+		case s[i] == '`':
+			if end := strings.IndexByte(s[i+1:], '`'); end >= 0 {
+				flush()
+				spans = append(spans, Span{Kind: Mono, Text: s[i+1 : i+1+end]})
+				i += end + 2
+				continue
+			}
+		case s[i] == '[':
+			if label, url, n, ok := parseLink(s[i:]); ok {
+				flush()
+				spans = append(spans, Span{Kind: Link, Text: label, URL: url})
+				i += n
+				continue
+			}
+		case strings.HasPrefix(s[i:], "**"):
+			if inner, n, ok := delimited(s[i:], "**", false); ok {
+				flush()
+				spans = append(spans, Span{Kind: Bold, Children: ParseInline(inner)})
+				i += n
+				continue
+			}
+		case strings.HasPrefix(s[i:], "~~"):
+			if inner, n, ok := delimited(s[i:], "~~", false); ok {
+				flush()
+				spans = append(spans, Span{Kind: Strike, Children: ParseInline(inner)})
+				i += n
+				continue
+			}
+		case s[i] == '*':
+			if inner, n, ok := delimited(s[i:], "*", false); ok {
+				flush()
+				spans = append(spans, Span{Kind: Italic, Children: ParseInline(inner)})
+				i += n
+				continue
+			}
+		case s[i] == '_' && (i == 0 || !isWordByte(s[i-1])):
+			if inner, n, ok := delimited(s[i:], "_", true); ok {
+				flush()
+				spans = append(spans, Span{Kind: Italic, Children: ParseInline(inner)})
+				i += n
+				continue
+			}
+		}
+		text.WriteByte(s[i])
+		i++
+	}
+	flush()
+	return spans
+}
+
+// delimited finds the content between an opening delim at the start of s and
+// its closing match, skipping code spans. With wordBound the closing delim
+// must not be followed by a word character, so snake_case stays literal.
+// It returns the content and the number of bytes consumed.
+func delimited(s, delim string, wordBound bool) (string, int, bool) {
+	body := s[len(delim):]
+	if body == "" || body[0] == ' ' || strings.HasPrefix(body, delim) {
+		return "", 0, false
+	}
+	for j := 0; j < len(body); j++ {
+		if body[j] == '`' {
+			if end := strings.IndexByte(body[j+1:], '`'); end >= 0 {
+				j += end + 1
+				continue
+			}
+		}
+		if !strings.HasPrefix(body[j:], delim) || body[j-1] == ' ' {
+			continue
+		}
+		// "**" must not close a single "*" span.
+		if delim == "*" && strings.HasPrefix(body[j:], "**") {
+			j++
+			continue
+		}
+		end := j + len(delim)
+		// In "***" after "**x *y", the inner "*" closes first.
+		for delim == "**" && end < len(body) && body[end] == '*' {
+			j++
+			end++
+		}
+		if wordBound && end < len(body) && isWordByte(body[end]) {
+			continue
+		}
+		return body[:j], len(delim) + end, true
+	}
+	return "", 0, false
+}
+
+// parseLink matches "[label](url)" at the start of s.
+func parseLink(s string) (label, url string, n int, ok bool) {
+	closeB := strings.Index(s, "](")
+	if closeB < 1 || strings.ContainsRune(s[:closeB], '\n') {
+		return "", "", 0, false
+	}
+	closeP := strings.IndexByte(s[closeB+2:], ')')
+	if closeP < 0 {
+		return "", "", 0, false
+	}
+	url = s[closeB+2 : closeB+2+closeP]
+	if url == "" || strings.ContainsAny(url, " \n") {
+		return "", "", 0, false
+	}
+	return s[1:closeB], url, closeB + 3 + closeP, true
+}
+
+func isWordByte(c byte) bool {
+	return c == '_' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= 0x80
+}
+
+// Plain renders spans without formatting; links become "text (url)".
+func Plain(spans []Span) string {
+	var b strings.Builder
+	for _, sp := range spans {
+		switch sp.Kind {
+		case Text, Mono:
+			b.WriteString(sp.Text)
+		case Link:
+			if sp.Text == sp.URL {
+				b.WriteString(sp.URL)
+			} else {
+				b.WriteString(sp.Text + " (" + sp.URL + ")")
+			}
+		default:
+			b.WriteString(Plain(sp.Children))
+		}
+	}
+	return b.String()
+}
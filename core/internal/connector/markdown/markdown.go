@@ -0,0 +1,210 @@
+// Package markdown tokenizes the Markdown agents write so each connector can
+// render it in its platform's own format. It covers the subset agents
+// actually produce: headings, nested and ordered lists, blockquotes, fenced
+// code, pipe tables, rules, and inline emphasis, code and links. Anything it
+// does not recognize is kept as literal text.
+package markdown
+
+import (
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// BlockKind identifies a line-level construct.
+type BlockKind int
+
+const (
+	Paragraph BlockKind = iota // one line of inline text
+	Blank                      // an empty line
+	Heading                    // Level is 1-6
+	ListItem                   // Level is the nesting depth (0 = top); Marker is "-", "1." etc.
+	Quote                      // consecutive "> " lines, joined by "\n" in Text
+	Code                       // fenced code; Text is the raw content, Lang the info string
+	Table                      // Rows holds the header row first; the separator row is dropped
+	Rule                       // a horizontal rule (---, ***, ___)
+)
+
+// Block is one parsed construct. Text holds inline Markdown except for Code,
+// whose Text is verbatim.
+type Block struct {
+	Kind    BlockKind
+	Text    string
+	Level   int
+	Marker  string
+	Ordered bool
+	Lang    string
+	Rows    [][]string
+}
+
+var (
+	reHeading  = regexp.MustCompile(`^(#{1,6})\s+(.*?)\s*#*\s*$`)
+	reListItem = regexp.MustCompile(`^([ \t]*)([-*+]|\d{1,9}[.)])\s+(.*)$`)
+	reRule     = regexp.MustCompile(`^\s*([-*_])(\s*[-*_]){2,}\s*$`)
+	reTableSep = regexp.MustCompile(`^\s*\|?\s*:?-+:?\s*(\|\s*:?-+:?\s*)*\|?\s*$`)
+)
+
+// Parse splits md into blocks, one per source line except for code fences,
+// quotes and tables, which span several lines.
+func Parse(md string) []Block {
+	lines := strings.Split(md, "\n")
+	var blocks []Block
+	var listIndents []int // indentation of each open list level
+
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		trimmed := strings.TrimSpace(line)
+
+		if strings.HasPrefix(trimmed, "```") {
+			lang := strings.TrimSpace(strings.TrimPrefix(trimmed, "```"))
+			var body []string
+			for i++; i < len(lines) && !strings.HasPrefix(strings.TrimSpace(lines[i]), "```"); i++ {
+				body = append(body, lines[i])
+			}
+			blocks = append(blocks, Block{Kind: Code, Lang: lang, Text: strings.Join(body, "\n")})
+			listIndents = nil
+			continue
+		}
+
+		if trimmed == "" {
+			blocks = append(blocks, Block{Kind: Blank})
+			continue // a blank line does not end a list
+		}
+
+		if m := reListItem.FindStringSubmatch(line); m != nil && !reRule.MatchString(line) {
+			indent := indentWidth(m[1])
+			for len(listIndents) > 0 && indent < listIndents[len(listIndents)-1] {
+				listIndents = listIndents[:len(listIndents)-1]
+			}
+			if len(listIndents) == 0 || indent > listIndents[len(listIndents)-1] {
+				listIndents = append(listIndents, indent)
+			}
+			marker := m[2]
+			blocks = append(blocks, Block{
+				Kind:    ListItem,
+				Text:    m[3],
+				Level:   len(listIndents) - 1,
+				Marker:  marker,
+				Ordered: marker[0] >= '0' && marker[0] <= '9',
+			})
+			continue
+		}
+		listIndents = nil
+
+		switch {
+		case reRule.MatchString(line):
+			blocks = append(blocks, Block{Kind: Rule})
+		case reHeading.MatchString(trimmed):
+			m := reHeading.FindStringSubmatch(trimmed)
+			blocks = append(blocks, Block{Kind: Heading, Level: len(m[1]), Text: m[2]})
+		case strings.HasPrefix(trimmed, ">"):
+			var quoted []string
+			for ; i < len(lines) && strings.HasPrefix(strings.TrimSpace(lines[i]), ">"); i++ {
+				quoted = append(quoted, stripQuote(lines[i]))
+			}
+			i--
+			blocks = append(blocks, Block{Kind: Quote, Text: strings.Join(quoted, "\n")})
+		case strings.Contains(trimmed, "|") && i+1 < len(lines) && strings.Contains(lines[i+1], "-") && reTableSep.MatchString(lines[i+1]):
+			rows := [][]string{splitRow(trimmed)}
+			for i += 2; i < len(lines) && strings.Contains(lines[i], "|") && strings.TrimSpace(lines[i]) != ""; i++ {
+				rows = append(rows, splitRow(strings.TrimSpace(lines[i])))
+			}
+			i--
+			blocks = append(blocks, Block{Kind: Table, Rows: rows})
+		default:
+			blocks = append(blocks, Block{Kind: Paragraph, Text: line})
+		}
+	}
+	return blocks
+}
+
+// indentWidth counts leading whitespace, a tab as four columns.
+func indentWidth(s string) int {
+	n := 0
+	for _, r := range s {
+		if r == '\t' {
+			n += 4
+		} else {
+			n++
+		}
+	}
+	return n
+}
+
+// stripQuote removes all leading quote markers, flattening nested quotes.
+func stripQuote(line string) string {
+	s := strings.TrimSpace(line)
+	for strings.HasPrefix(s, ">") {
+		s = strings.TrimPrefix(s, ">")
+		s = strings.TrimPrefix(s, " ")
+	}
+	return s
+}
+
+// splitRow splits a pipe table row into trimmed cells, honouring "\|".
+func splitRow(row string) []string {
+	row = strings.TrimPrefix(row, "|")
+	if strings.HasSuffix(row, "|") && !strings.HasSuffix(row, `\|`) {
+		row = strings.TrimSuffix(row, "|")
+	}
+	var cells []string
+	var cell strings.Builder
+	for i := 0; i < len(row); i++ {
+		switch {
+		case row[i] == '\\' && i+1 < len(row) && row[i+1] == '|':
+			cell.WriteByte('|')
+			i++
+		case row[i] == '|':
+			cells = append(cells, strings.TrimSpace(cell.String()))
+			cell.Reset()
+		default:
+			cell.WriteByte(row[i])
+		}
+	}
+	return append(cells, strings.TrimSpace(cell.String()))
+}
+
+// AlignTable lays out rows as space-padded columns for monospace display,
+// with a dashed line under the header. Cells are reduced to plain text.
+func AlignTable(rows [][]string) string {
+	cols := 0
+	for _, r := range rows {
+		cols = max(cols, len(r))
+	}
+	plain := make([][]string, len(rows))
+	widths := make([]int, cols)
+	for i, r := range rows {
+		plain[i] = make([]string, cols)
+		for j := range cols {
+			if j < len(r) {
+				plain[i][j] = Plain(ParseInline(r[j]))
+			}
+			widths[j] = max(widths[j], utf8.RuneCountInString(plain[i][j]))
+		}
+	}
+
+	var b strings.Builder
+	writeRow := func(cells []string) {
+		for j, c := range cells {
+			if j > 0 {
+				b.WriteString("  ")
+			}
+			b.WriteString(c)
+			if j < len(cells)-1 {
+				b.WriteString(strings.Repeat(" ", widths[j]-utf8.RuneCountInString(c)))
+			}
+		}
+		b.WriteString("\n")
+	}
+	for i, r := range plain {
+		writeRow(r)
+		if i == 0 && len(plain) > 1 {
+			seps := make([]string, cols)
+			for j, w := range widths {
+				seps[j] = strings.Repeat("-", w)
+			}
+			writeRow(seps)
+		}
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
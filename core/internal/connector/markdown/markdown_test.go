@@ -0,0 +1,76 @@
+package markdown
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParse_Blocks(t *testing.T) {
+	md := "# Plan\n" +
+		"1. Build\n" +
+		"   - unit tests\n" +
+		"     * fast ones\n" +
+		"2. Ship\n" +
+		"\n" +
+		"> note one\n" +
+		">> nested\n" +
+		"| a | b |\n" +
+		"|---|:-:|\n" +
+		"| 1 | x \\| y |\n" +
+		"---\n" +
+		"```sh\n" +
+		"# not a heading\n" +
+		"```\n" +
+		"plain"
+	got := Parse(md)
+	want := []Block{
+		{Kind: Heading, Level: 1, Text: "Plan"},
+		{Kind: ListItem, Text: "Build", Marker: "1.", Ordered: true},
+		{Kind: ListItem, Text: "unit tests", Level: 1, Marker: "-"},
+		{Kind: ListItem, Text: "fast ones", Level: 2, Marker: "*"},
+		{Kind: ListItem, Text: "Ship", Marker: "2.", Ordered: true},
+		{Kind: Blank},
+		{Kind: Quote, Text: "note one\nnested"},
+		{Kind: Table, Rows: [][]string{{"a", "b"}, {"1", "x | y"}}},
+		{Kind: Rule},
+		{Kind: Code, Lang: "sh", Text: "# not a heading"},
+		{Kind: Paragraph, Text: "plain"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Parse mismatch:\n got %+v\nwant %+v", got, want)
+	}
+}
+
+func TestParseInline(t *testing.T) {
+	for _, tc := range []struct {
+		in, plain string
+		kinds     []SpanKind
+	}{
+		{"**bold *and italic***", "bold and italic", []SpanKind{Bold}},
+		{"see [docs](https://x.io) now", "see docs (https://x.io) now", []SpanKind{Text, Link, Text}},
+		{"`*raw*` and ~~gone~~", "*raw* and gone", []SpanKind{Mono, Text, Strike}},
+		{"2 * 3 * 4", "2 * 3 * 4", []SpanKind{Text}},
+		{"snake_case_name and _it_", "snake_case_name and it", []SpanKind{Text, Italic}},
+		{"[no link here", "[no link here", []SpanKind{Text}},
+	} {
+		spans := ParseInline(tc.in)
+		var kinds []SpanKind
+		for _, sp := range spans {
+			kinds = append(kinds, sp.Kind)
+		}
+		if !reflect.DeepEqual(kinds, tc.kinds) || Plain(spans) != tc.plain {
+			t.Errorf("%q: kinds %v plain %q, want %v %q", tc.in, kinds, Plain(spans), tc.kinds, tc.plain)
+		}
+	}
+}
+
+func TestAlignTable(t *testing.T) {
+	got := AlignTable([][]string{{"Name", "**Status**"}, {"api-gateway", "ok"}, {"db"}})
+	want := "Name         Status\n" +
+		"-----------  ------\n" +
+		"api-gateway  ok\n" +
+		"db           "
+	if got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
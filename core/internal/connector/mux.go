@@ -0,0 +1,169 @@
+package connector
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+
+	"github.com/h1v3-io/h1v3/pkg/protocol"
+)
+
+// Ticket tags recording where an external session came from. Session tickets
+// carry both, so a reply can find its way back even when the in-memory
+// mapping is gone (e.g. after a restart).
+const (
+	ConnectorTagPrefix = "connector:"
+	ChatTagPrefix      = "chat:"
+)
+
+// Target is one connector as seen by a Mux.
+type Target struct {
+	Send        func(ctx context.Context, msg OutboundMessage) (*Receipt, error)
+	Allow       func(msg protocol.Message) bool  // optional reply filter for multi-front sessions
+	OnDelivered func(ticketID string, r Receipt) // optional, for delivery receipts
+}
+
+// Origin is the connector and chat an external session ticket belongs to.
+type Origin struct {
+	Connector string
+	ChatID    string
+}
+
+// Mux is the hive's "_external" sink. It remembers which connector and chat
+// each session ticket came from and delivers replies there, so a hive can run
+// several connectors at once without their replies crossing over.
+type Mux struct {
+	getTicket func(ticketID string) (*protocol.Ticket, error)
+	logger    *slog.Logger
+
+	mu      sync.Mutex
+	targets map[string]Target // connector name → target
+	origins map[string]Origin // ticket ID → origin
+}
+
+// NewMux creates an empty Mux. getTicket is used to label replies with the
+// ticket title and to recover a ticket's origin from its tags; it may be nil.
+func NewMux(getTicket func(ticketID string) (*protocol.Ticket, error), logger *slog.Logger) *Mux {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &Mux{
+		getTicket: getTicket,
+		logger:    logger,
+		targets:   make(map[string]Target),
+		origins:   make(map[string]Origin),
+	}
+}
+
+// Add registers a connector under name, replacing any previous target.
+func (m *Mux) Add(name string, t Target) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.targets[name] = t
+}
+
+// Len returns the number of registered connectors.
+func (m *Mux) Len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.targets)
+}
+
+// MapTicket records that ticketID is a session on the given connector's chat.
+func (m *Mux) MapTicket(connector, ticketID, chatID string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.origins[ticketID] = Origin{Connector: connector, ChatID: chatID}
+}
+
+// UnmapChat forgets every ticket mapped to the given connector's chat.
+func (m *Mux) UnmapChat(connector, chatID string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for tid, o := range m.origins {
+		if o == (Origin{Connector: connector, ChatID: chatID}) {
+			delete(m.origins, tid)
+		}
+	}
+}
+
+// Deliver implements registry.Sink.
+func (m *Mux) Deliver(msg protocol.Message) error {
+	var tk *protocol.Ticket
+	if m.getTicket != nil {
+		tk, _ = m.getTicket(msg.TicketID)
+	}
+
+	origin, ok := m.origin(msg.TicketID, tk)
+	if !ok {
+		m.logger.Warn("no chat mapping for ticket", "ticket", msg.TicketID)
+		return fmt.Errorf("external sink: no chat mapping for ticket %s", msg.TicketID)
+	}
+	m.mu.Lock()
+	target, ok := m.targets[origin.Connector]
+	m.mu.Unlock()
+	if !ok {
+		return fmt.Errorf("external sink: ticket %s belongs to connector %q, which is not running", msg.TicketID, origin.Connector)
+	}
+
+	if target.Allow != nil && !target.Allow(msg) {
+		m.logger.Debug("reply suppressed by front reply policy", "ticket", msg.TicketID, "from", msg.From)
+		return nil
+	}
+
+	// Prepend ticket ID and title so the user knows which conversation this belongs to.
+	content := msg.Content
+	if tk != nil {
+		content = fmt.Sprintf("[`%s` — %s]\n%s", tk.ID, tk.Title, content)
+	}
+
+	receipt, err := target.Send(context.Background(), OutboundMessage{
+		ChatID:      origin.ChatID,
+		Content:     content,
+		Attachments: msg.Attachments,
+	})
+	if err != nil {
+		return err
+	}
+	if receipt != nil && target.OnDelivered != nil {
+		target.OnDelivered(msg.TicketID, *receipt)
+	}
+	return nil
+}
+
+// origin looks a ticket up in the session mapping, falling back to its
+// connector and chat tags. Tickets tagged before connector tags existed
+// resolve only when a single connector is running.
+func (m *Mux) origin(ticketID string, tk *protocol.Ticket) (Origin, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if o, ok := m.origins[ticketID]; ok {
+		return o, true
+	}
+	if tk == nil {
+		return Origin{}, false
+	}
+	var o Origin
+	for _, tag := range tk.Tags {
+		if v, ok := strings.CutPrefix(tag, ConnectorTagPrefix); ok {
+			o.Connector = v
+		} else if v, ok := strings.CutPrefix(tag, ChatTagPrefix); ok {
+			o.ChatID = v
+		}
+	}
+	if o.ChatID == "" {
+		return Origin{}, false
+	}
+	if o.Connector == "" {
+		if len(m.targets) != 1 {
+			return Origin{}, false
+		}
+		for name := range m.targets {
+			o.Connector = name
+		}
+	}
+	m.origins[ticketID] = o
+	return o, true
+}
@@ -0,0 +1,99 @@
+package connector
+
+import (
+	"context"
+	"testing"
+
+	"github.com/h1v3-io/h1v3/pkg/protocol"
+)
+
+type recordingTarget struct {
+	sent []OutboundMessage
+}
+
+func (r *recordingTarget) send(_ context.Context, msg OutboundMessage) (*Receipt, error) {
+	r.sent = append(r.sent, msg)
+	return nil, nil
+}
+
+func TestMux_RoutesToOriginConnector(t *testing.T) {
+	tickets := map[string]*protocol.Ticket{
+		"t-tg": {ID: "t-tg", Title: "From Telegram"},
+		"t-sl": {ID: "t-sl", Title: "From Slack"},
+	}
+	getTicket := func(id string) (*protocol.Ticket, error) { return tickets[id], nil }
+
+	m := NewMux(getTicket, nil)
+	var tg, sl recordingTarget
+	m.Add("telegram", Target{Send: tg.send})
+	m.Add("slack", Target{Send: sl.send})
+
+	// Same chat ID on both platforms must not collide.
+	m.MapTicket("telegram", "t-tg", "42")
+	m.MapTicket("slack", "t-sl", "42")
+
+	if err := m.Deliver(protocol.Message{TicketID: "t-sl", Content: "hi slack"}); err != nil {
+		t.Fatalf("deliver: %v", err)
+	}
+	if err := m.Deliver(protocol.Message{TicketID: "t-tg", Content: "hi telegram"}); err != nil {
+		t.Fatalf("deliver: %v", err)
+	}
+	if len(tg.sent) != 1 || len(sl.sent) != 1 {
+		t.Fatalf("expected one message per connector, got telegram=%d slack=%d", len(tg.sent), len(sl.sent))
+	}
+	if got := sl.sent[0].Content; got != "[`t-sl` — From Slack]\nhi slack" {
+		t.Errorf("unexpected slack content %q", got)
+	}
+
+	m.UnmapChat("slack", "42")
+	if err := m.Deliver(protocol.Message{TicketID: "t-sl", Content: "again"}); err == nil {
+		t.Error("expected error after the slack chat was unmapped")
+	}
+	if err := m.Deliver(protocol.Message{TicketID: "t-tg", Content: "still here"}); err != nil {
+		t.Errorf("telegram mapping must survive unmapping slack's chat 42: %v", err)
+	}
+}
+
+func TestMux_OriginFromTags(t *testing.T) {
+	tickets := map[string]*protocol.Ticket{
+		"t-1": {ID: "t-1", Tags: []string{"external", "chat:C9", "connector:slack"}},
+		"t-2": {ID: "t-2", Tags: []string{"external", "chat:77"}},
+	}
+	getTicket := func(id string) (*protocol.Ticket, error) { return tickets[id], nil }
+
+	m := NewMux(getTicket, nil)
+	var tg, sl recordingTarget
+	m.Add("telegram", Target{Send: tg.send})
+	m.Add("slack", Target{Send: sl.send})
+
+	if err := m.Deliver(protocol.Message{TicketID: "t-1", Content: "x"}); err != nil {
+		t.Fatalf("deliver: %v", err)
+	}
+	if len(sl.sent) != 1 || sl.sent[0].ChatID != "C9" {
+		t.Errorf("expected delivery to slack chat C9, got %+v", sl.sent)
+	}
+
+	// Without a connector tag the origin is ambiguous with two connectors.
+	if err := m.Deliver(protocol.Message{TicketID: "t-2", Content: "x"}); err == nil {
+		t.Error("expected error for an untagged ticket with several connectors")
+	}
+
+	single := NewMux(getTicket, nil)
+	single.Add("telegram", Target{Send: tg.send})
+	if err := single.Deliver(protocol.Message{TicketID: "t-2", Content: "x"}); err != nil {
+		t.Errorf("untagged ticket should go to the only connector: %v", err)
+	}
+}
+
+func TestMux_AllowFilter(t *testing.T) {
+	m := NewMux(nil, nil)
+	var tg recordingTarget
+	m.Add("telegram", Target{Send: tg.send, Allow: func(msg protocol.Message) bool { return msg.From == "front" }})
+	m.MapTicket("telegram", "t-1", "42")
+
+	m.Deliver(protocol.Message{TicketID: "t-1", From: "cc", Content: "dropped"})
+	m.Deliver(protocol.Message{TicketID: "t-1", From: "front", Content: "kept"})
+	if len(tg.sent) != 1 || tg.sent[0].Content != "kept" {
+		t.Errorf("expected only the front agent's reply, got %+v", tg.sent)
+	}
+}
@@ -5,12 +5,14 @@ import (
 	"fmt"
 	"log/slog"
 	"strings"
+	"time"
 
 	"github.com/slack-go/slack"
 	"github.com/slack-go/slack/slackevents"
 	"github.com/slack-go/slack/socketmode"
 
 	"github.com/h1v3-io/h1v3/internal/connector"
+	"github.com/h1v3-io/h1v3/internal/connector/markdown"
 )
 
 // Config holds Slack connector configuration.
@@ -66,6 +68,21 @@ func New(cfg Config, handler connector.InboundHandler, logger *slog.Logger) (*Co
 	}, nil
 }
 
+// CheckAuth verifies both tokens without starting the connector: the bot
+// token with auth.test and the app token by requesting a Socket Mode URL,
+// which is not connected to. It returns the bot user and team.
+func CheckAuth(ctx context.Context, cfg Config) (string, error) {
+	api := slack.New(cfg.BotToken, slack.OptionAppLevelToken(cfg.AppToken))
+	authResp, err := api.AuthTestContext(ctx)
+	if err != nil {
+		return "", fmt.Errorf("slack: auth test: %w", err)
+	}
+	if _, _, err := api.StartSocketModeContext(ctx); err != nil {
+		return "", fmt.Errorf("slack: app token: %w", err)
+	}
+	return authResp.User + "@" + authResp.Team, nil
+}
+
 func (c *Connector) Name() string { return "slack" }
 
 // Start begins listening for events via Socket Mode. Blocks until context is cancelled.
@@ -87,18 +104,31 @@ func (c *Connector) Stop() error {
 }
 
 // Send delivers a message to a Slack channel.
-func (c *Connector) Send(_ context.Context, msg connector.OutboundMessage) error {
+func (c *Connector) Send(ctx context.Context, msg connector.OutboundMessage) error {
+	_, err := c.SendWithReceipt(ctx, msg)
+	return err
+}
+
+// SendWithReceipt implements connector.ReceiptSender; the message ID is the
+// Slack message timestamp.
+func (c *Connector) SendWithReceipt(_ context.Context, msg connector.OutboundMessage) (*connector.Receipt, error) {
 	text := MarkdownToMrkdwn(msg.Content)
 
 	opts := []slack.MsgOption{
 		slack.MsgOptionText(text, false),
 	}
 
-	_, _, err := c.api.PostMessage(msg.ChatID, opts...)
+	// Threaded chats are "channel:thread_ts"; reply in the thread.
+	channel, threadTS, threaded := strings.Cut(msg.ChatID, ":")
+	if threaded {
+		opts = append(opts, slack.MsgOptionTS(threadTS))
+	}
+
+	_, ts, err := c.api.PostMessage(channel, opts...)
 	if err != nil {
-		return fmt.Errorf("slack: send message: %w", err)
+		return nil, fmt.Errorf("slack: send message: %w", err)
 	}
-	return nil
+	return &connector.Receipt{Channel: "slack", ChatID: msg.ChatID, MessageID: ts, Time: time.Now()}, nil
 }
 
 func (c *Connector) handleEvents(ctx context.Context) {
@@ -164,6 +194,7 @@ func (c *Connector) handleMessage(ctx context.Context, ev *slackevents.MessageEv
 		SenderID: ev.User,
 		ChatID:   chatID,
 		Content:  text,
+		EventID:  ev.Channel + ":" + ev.TimeStamp,
 	}
 
 	if err := c.handler(ctx, inbound); err != nil {
@@ -196,6 +227,7 @@ func (c *Connector) handleMention(ctx context.Context, ev *slackevents.AppMentio
 		SenderID: ev.User,
 		ChatID:   chatID,
 		Content:  text,
+		EventID:  ev.Channel + ":" + ev.TimeStamp,
 	}
 
 	if err := c.handler(ctx, inbound); err != nil {
@@ -225,6 +257,7 @@ func (c *Connector) handleSlashCommand(ctx context.Context, event socketmode.Eve
 		SenderID: cmd.UserID,
 		ChatID:   cmd.ChannelID,
 		Content:  text,
+		EventID:  cmd.TriggerID,
 	}
 
 	if err := c.handler(ctx, inbound); err != nil {
@@ -256,77 +289,59 @@ func StripMention(text, botID string) string {
 }
 
 // MarkdownToMrkdwn converts standard Markdown to Slack's mrkdwn format.
+// Headings become bold lines, lists keep their numbering and nesting as
+// indented text, and tables (which Slack cannot show) are aligned in a
+// code block.
 func MarkdownToMrkdwn(md string) string {
-	result := md
-
-	// Convert emphasis markers in a single pass
-	result = convertEmphasis(result)
-	// Convert strikethrough: ~~text~~ → ~text~
-	result = strings.ReplaceAll(result, "~~", "~")
-	// Convert links: [text](url) → <url|text>
-	result = convertLinks(result)
-
-	return result
-}
-
-// convertEmphasis handles both bold (**text** → *text*) and italic (*text* → _text_)
-// in a single pass, correctly distinguishing between the two.
-func convertEmphasis(s string) string {
-	var b strings.Builder
-	inCode := false
-	i := 0
-	for i < len(s) {
-		ch := s[i]
-		if ch == '`' {
-			inCode = !inCode
-			b.WriteByte(ch)
-			i++
-		} else if ch == '*' && !inCode {
-			if i+1 < len(s) && s[i+1] == '*' {
-				// Bold: ** → * (Slack bold)
-				b.WriteByte('*')
-				i += 2
-			} else {
-				// Italic: * → _ (Slack italic)
-				b.WriteByte('_')
-				i++
+	blocks := markdown.Parse(md)
+	lines := make([]string, 0, len(blocks))
+	for _, bl := range blocks {
+		switch bl.Kind {
+		case markdown.Blank:
+			lines = append(lines, "")
+		case markdown.Heading:
+			lines = append(lines, "*"+mrkdwn(markdown.ParseInline(bl.Text))+"*")
+		case markdown.ListItem:
+			bullet := "•"
+			if bl.Ordered {
+				bullet = bl.Marker
+			}
+			lines = append(lines, strings.Repeat("    ", bl.Level)+bullet+" "+mrkdwn(markdown.ParseInline(bl.Text)))
+		case markdown.Quote:
+			for _, q := range strings.Split(bl.Text, "\n") {
+				lines = append(lines, "> "+mrkdwn(markdown.ParseInline(q)))
 			}
-		} else {
-			b.WriteByte(ch)
-			i++
+		case markdown.Code:
+			lines = append(lines, "```\n"+bl.Text+"\n```")
+		case markdown.Table:
+			lines = append(lines, "```\n"+markdown.AlignTable(bl.Rows)+"\n```")
+		case markdown.Rule:
+			lines = append(lines, "──────────")
+		default:
+			lines = append(lines, mrkdwn(markdown.ParseInline(bl.Text)))
 		}
 	}
-	return b.String()
+	return strings.Join(lines, "\n")
 }
 
-// convertLinks converts [text](url) to <url|text>.
-func convertLinks(s string) string {
+// mrkdwn renders inline spans: **bold** → *bold*, *italic* → _italic_,
+// ~~strike~~ → ~strike~, [text](url) → <url|text>.
+func mrkdwn(spans []markdown.Span) string {
 	var b strings.Builder
-	i := 0
-	for i < len(s) {
-		if s[i] == '[' {
-			closeB := strings.Index(s[i:], "](")
-			if closeB == -1 {
-				b.WriteByte(s[i])
-				i++
-				continue
-			}
-			closeB += i
-			closeP := strings.Index(s[closeB:], ")")
-			if closeP == -1 {
-				b.WriteByte(s[i])
-				i++
-				continue
-			}
-			closeP += closeB
-
-			text := s[i+1 : closeB]
-			url := s[closeB+2 : closeP]
-			fmt.Fprintf(&b, "<%s|%s>", url, text)
-			i = closeP + 1
-		} else {
-			b.WriteByte(s[i])
-			i++
+	for _, sp := range spans {
+		switch sp.Kind {
+		case markdown.Bold:
+			b.WriteString("*" + mrkdwn(sp.Children) + "*")
+		case markdown.Italic:
+			b.WriteString("_" + mrkdwn(sp.Children) + "_")
+		case markdown.Strike:
+			b.WriteString("~" + mrkdwn(sp.Children) + "~")
+		case markdown.Mono:
+			b.WriteString("`" + sp.Text + "`")
+		case markdown.Link:
+			fmt.Fprintf(&b, "<%s|%s>", sp.URL, sp.Text)
+		default:
+			b.WriteString(sp.Text)
 		}
 	}
 	return b.String()
@@ -108,17 +108,17 @@ func TestIsAllowedChannel_Empty(t *testing.T) {
 	}
 }
 
-func TestConvertLinks_Multiple(t *testing.T) {
-	got := convertLinks("[a](http://a.com) and [b](http://b.com)")
+func TestMarkdownToMrkdwn_MultipleLinks(t *testing.T) {
+	got := MarkdownToMrkdwn("[a](http://a.com) and [b](http://b.com)")
 	want := "<http://a.com|a> and <http://b.com|b>"
 	if got != want {
 		t.Errorf("got %q, want %q", got, want)
 	}
 }
 
-func TestConvertLinks_Incomplete(t *testing.T) {
+func TestMarkdownToMrkdwn_IncompleteLink(t *testing.T) {
 	// Incomplete link syntax should be left as-is
-	got := convertLinks("[no link here")
+	got := MarkdownToMrkdwn("[no link here")
 	want := "[no link here"
 	if got != want {
 		t.Errorf("got %q, want %q", got, want)
@@ -131,3 +131,41 @@ func TestConnectorName(t *testing.T) {
 		t.Errorf("Name() = %q", c.Name())
 	}
 }
+
+func TestMarkdownToMrkdwn_MixedAgentReply(t *testing.T) {
+	md := "## Deploy status\n" +
+		"Rolled out **v2.4.1** to staging. Remaining steps:\n" +
+		"1. Run `make migrate`\n" +
+		"   - check the *orders* table\n" +
+		"   - confirm row counts\n" +
+		"2. Flip the flag\n" +
+		"\n" +
+		"> Heads up: the cache is cold for ~10 min.\n" +
+		"\n" +
+		"| Service | Status |\n" +
+		"|---|---|\n" +
+		"| api | ok |\n" +
+		"| worker_pool | degraded |\n" +
+		"\n" +
+		"See [runbook](https://wiki.example.com/deploy) for details."
+	want := "*Deploy status*\n" +
+		"Rolled out *v2.4.1* to staging. Remaining steps:\n" +
+		"1. Run `make migrate`\n" +
+		"    • check the _orders_ table\n" +
+		"    • confirm row counts\n" +
+		"2. Flip the flag\n" +
+		"\n" +
+		"> Heads up: the cache is cold for ~10 min.\n" +
+		"\n" +
+		"```\n" +
+		"Service      Status\n" +
+		"-----------  --------\n" +
+		"api          ok\n" +
+		"worker_pool  degraded\n" +
+		"```\n" +
+		"\n" +
+		"See <https://wiki.example.com/deploy|runbook> for details."
+	if got := MarkdownToMrkdwn(md); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
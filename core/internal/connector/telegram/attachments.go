@@ -0,0 +1,83 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"github.com/h1v3-io/h1v3/pkg/protocol"
+)
+
+// downloadAttachments fetches the photo and/or document on a message.
+// For photos only the largest size is kept.
+func (c *Connector) downloadAttachments(ctx context.Context, msg *tgbotapi.Message) ([]protocol.Attachment, error) {
+	var atts []protocol.Attachment
+
+	if len(msg.Photo) > 0 {
+		photo := msg.Photo[len(msg.Photo)-1]
+		a, err := c.downloadAttachment(ctx, photo.FileID, fmt.Sprintf("photo_%d.jpg", msg.MessageID), "image/jpeg")
+		if err != nil {
+			return nil, err
+		}
+		atts = append(atts, a)
+	}
+	if msg.Document != nil {
+		name := msg.Document.FileName
+		if name == "" {
+			name = fmt.Sprintf("document_%d", msg.MessageID)
+		}
+		a, err := c.downloadAttachment(ctx, msg.Document.FileID, name, msg.Document.MimeType)
+		if err != nil {
+			return nil, err
+		}
+		atts = append(atts, a)
+	}
+	return atts, nil
+}
+
+func (c *Connector) downloadAttachment(ctx context.Context, fileID, name, mimeType string) (protocol.Attachment, error) {
+	fileURL, err := c.bot.GetFileDirectURL(fileID)
+	if err != nil {
+		return protocol.Attachment{}, fmt.Errorf("get file URL: %w", err)
+	}
+	data, err := downloadFile(ctx, fileURL)
+	if err != nil {
+		return protocol.Attachment{}, fmt.Errorf("download %s: %w", name, err)
+	}
+	if mimeType == "" {
+		mimeType = http.DetectContentType(data)
+	}
+	return protocol.Attachment{
+		Name:     name,
+		MimeType: mimeType,
+		Size:     int64(len(data)),
+		Data:     data,
+	}, nil
+}
+
+// sendAttachment uploads a single attachment to a chat, as a photo for images
+// and as a document otherwise.
+func (c *Connector) sendAttachment(chatID int64, a protocol.Attachment) error {
+	var file tgbotapi.RequestFileData
+	if a.Data != nil {
+		file = tgbotapi.FileBytes{Name: a.Name, Bytes: a.Data}
+	} else if a.Path != "" {
+		file = tgbotapi.FilePath(a.Path)
+	} else {
+		return fmt.Errorf("telegram: attachment %q has no content", a.Name)
+	}
+
+	var cfg tgbotapi.Chattable
+	if strings.HasPrefix(a.MimeType, "image/") {
+		cfg = tgbotapi.NewPhoto(chatID, file)
+	} else {
+		cfg = tgbotapi.NewDocument(chatID, file)
+	}
+	if _, err := c.bot.Send(cfg); err != nil {
+		return fmt.Errorf("telegram: send attachment %q: %w", a.Name, err)
+	}
+	return nil
+}
@@ -1,117 +1,73 @@
 package telegram
 
 import (
-	"regexp"
 	"strings"
+
+	"github.com/h1v3-io/h1v3/internal/connector/markdown"
 )
 
 // MarkdownToTelegramHTML converts standard Markdown to Telegram's HTML subset.
-// If conversion produces invalid output, falls back to plain text.
+// Headings become bold lines, lists keep their numbering and nesting as
+// indented text, quotes use <blockquote>, and tables (which Telegram cannot
+// show) are aligned in a <pre> block.
 func MarkdownToTelegramHTML(md string) string {
-	result := convertMarkdown(md)
-	return result
-}
-
-func convertMarkdown(md string) string {
-	// Process line by line for code blocks
-	lines := strings.Split(md, "\n")
-	var out strings.Builder
-	inCodeBlock := false
-	codeLang := ""
-
-	for i, line := range lines {
-		if strings.HasPrefix(line, "```") {
-			if !inCodeBlock {
-				// Opening code fence
-				codeLang = strings.TrimPrefix(line, "```")
-				codeLang = strings.TrimSpace(codeLang)
-				if codeLang != "" {
-					out.WriteString(`<pre><code class="language-` + escapeHTML(codeLang) + `">`)
-				} else {
-					out.WriteString("<pre><code>")
-				}
-				inCodeBlock = true
-			} else {
-				// Closing code fence
-				out.WriteString("</code></pre>")
-				inCodeBlock = false
-				codeLang = ""
+	blocks := markdown.Parse(md)
+	lines := make([]string, 0, len(blocks))
+	for _, bl := range blocks {
+		switch bl.Kind {
+		case markdown.Blank:
+			lines = append(lines, "")
+		case markdown.Heading:
+			lines = append(lines, "<b>"+inlineHTML(markdown.ParseInline(bl.Text))+"</b>")
+		case markdown.ListItem:
+			bullet := "•"
+			if bl.Ordered {
+				bullet = bl.Marker
 			}
-			if i < len(lines)-1 {
-				out.WriteString("\n")
+			lines = append(lines, strings.Repeat("    ", bl.Level)+bullet+" "+inlineHTML(markdown.ParseInline(bl.Text)))
+		case markdown.Quote:
+			quoted := strings.Split(bl.Text, "\n")
+			for i, q := range quoted {
+				quoted[i] = inlineHTML(markdown.ParseInline(q))
 			}
-			continue
-		}
-
-		if inCodeBlock {
-			// Inside code block — escape HTML but don't process markdown
-			out.WriteString(escapeHTML(line))
-			if i < len(lines)-1 {
-				out.WriteString("\n")
+			lines = append(lines, "<blockquote>"+strings.Join(quoted, "\n")+"</blockquote>")
+		case markdown.Code:
+			open := "<pre><code>"
+			if bl.Lang != "" {
+				open = `<pre><code class="language-` + escapeHTML(bl.Lang) + `">`
 			}
-			continue
-		}
-
-		// Process inline formatting
-		processed := processInline(line)
-		out.WriteString(processed)
-		if i < len(lines)-1 {
-			out.WriteString("\n")
+			lines = append(lines, open+escapeHTML(bl.Text)+"</code></pre>")
+		case markdown.Table:
+			lines = append(lines, "<pre>"+escapeHTML(markdown.AlignTable(bl.Rows))+"</pre>")
+		case markdown.Rule:
+			lines = append(lines, "──────────")
+		default:
+			lines = append(lines, inlineHTML(markdown.ParseInline(bl.Text)))
 		}
 	}
-
-	// If we ended inside a code block, close it
-	if inCodeBlock {
-		out.WriteString("</code></pre>")
-	}
-
-	return out.String()
+	return strings.Join(lines, "\n")
 }
 
-var (
-	// Order matters — process code first to avoid conflicts
-	reInlineCode = regexp.MustCompile("`([^`]+)`")
-	reBold       = regexp.MustCompile(`\*\*(.+?)\*\*`)
-	reItalic     = regexp.MustCompile(`\*(.+?)\*`)
-	reLink       = regexp.MustCompile(`\[([^\]]+)\]\(([^)]+)\)`)
-)
-
-func processInline(line string) string {
-	// Protect inline code spans first
-	type codeSpan struct {
-		placeholder string
-		html        string
-	}
-	var spans []codeSpan
-	counter := 0
-
-	line = reInlineCode.ReplaceAllStringFunc(line, func(match string) string {
-		inner := reInlineCode.FindStringSubmatch(match)[1]
-		placeholder := "\x00CODE" + string(rune('A'+counter)) + "\x00"
-		counter++
-		spans = append(spans, codeSpan{
-			placeholder: placeholder,
-			html:        "<code>" + escapeHTML(inner) + "</code>",
-		})
-		return placeholder
-	})
-
-	// Escape HTML in non-code content
-	line = escapeHTML(line)
-
-	// Process bold before italic (** before *)
-	line = reBold.ReplaceAllString(line, "<b>$1</b>")
-	line = reItalic.ReplaceAllString(line, "<i>$1</i>")
-
-	// Process links
-	line = reLink.ReplaceAllString(line, `<a href="$2">$1</a>`)
-
-	// Restore code spans
-	for _, s := range spans {
-		line = strings.Replace(line, escapeHTML(s.placeholder), s.html, 1)
+// inlineHTML renders inline spans as Telegram HTML, escaping all text.
+func inlineHTML(spans []markdown.Span) string {
+	var b strings.Builder
+	for _, sp := range spans {
+		switch sp.Kind {
+		case markdown.Bold:
+			b.WriteString("<b>" + inlineHTML(sp.Children) + "</b>")
+		case markdown.Italic:
+			b.WriteString("<i>" + inlineHTML(sp.Children) + "</i>")
+		case markdown.Strike:
+			b.WriteString("<s>" + inlineHTML(sp.Children) + "</s>")
+		case markdown.Mono:
+			b.WriteString("<code>" + escapeHTML(sp.Text) + "</code>")
+		case markdown.Link:
+			b.WriteString(`<a href="` + escapeAttr(sp.URL) + `">` + escapeHTML(sp.Text) + "</a>")
+		default:
+			b.WriteString(escapeHTML(sp.Text))
+		}
 	}
-
-	return line
+	return b.String()
 }
 
 func escapeHTML(s string) string {
@@ -121,28 +77,39 @@ func escapeHTML(s string) string {
 	return s
 }
 
+func escapeAttr(s string) string {
+	return strings.ReplaceAll(escapeHTML(s), `"`, "&quot;")
+}
+
 // StripMarkdown removes all Markdown formatting, returning plain text.
 func StripMarkdown(md string) string {
-	// Remove code blocks
-	re := regexp.MustCompile("```[\\s\\S]*?```")
-	result := re.ReplaceAllStringFunc(md, func(match string) string {
-		inner := strings.TrimPrefix(match, "```")
-		inner = strings.TrimSuffix(inner, "```")
-		// Remove language identifier from first line
-		if idx := strings.Index(inner, "\n"); idx >= 0 {
-			inner = inner[idx+1:]
+	blocks := markdown.Parse(md)
+	lines := make([]string, 0, len(blocks))
+	for _, bl := range blocks {
+		switch bl.Kind {
+		case markdown.Blank:
+			lines = append(lines, "")
+		case markdown.ListItem:
+			bullet := "-"
+			if bl.Ordered {
+				bullet = bl.Marker
+			}
+			lines = append(lines, strings.Repeat("  ", bl.Level)+bullet+" "+markdown.Plain(markdown.ParseInline(bl.Text)))
+		case markdown.Code:
+			lines = append(lines, bl.Text)
+		case markdown.Table:
+			lines = append(lines, markdown.AlignTable(bl.Rows))
+		case markdown.Rule:
+			lines = append(lines, "---")
+		case markdown.Quote:
+			quoted := strings.Split(bl.Text, "\n")
+			for i, q := range quoted {
+				quoted[i] = markdown.Plain(markdown.ParseInline(q))
+			}
+			lines = append(lines, strings.Join(quoted, "\n"))
+		default:
+			lines = append(lines, markdown.Plain(markdown.ParseInline(bl.Text)))
 		}
-		return inner
-	})
-
-	// Remove inline code
-	result = reInlineCode.ReplaceAllString(result, "$1")
-	// Remove bold
-	result = reBold.ReplaceAllString(result, "$1")
-	// Remove italic
-	result = reItalic.ReplaceAllString(result, "$1")
-	// Convert links to "text (url)"
-	result = reLink.ReplaceAllString(result, "$1 ($2)")
-
-	return result
+	}
+	return strings.Join(lines, "\n")
 }
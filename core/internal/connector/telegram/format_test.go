@@ -111,3 +111,39 @@ func TestCodeBlockHTMLEscaping(t *testing.T) {
 		t.Errorf("expected escaped HTML in code block, got %q", got)
 	}
 }
+
+func TestMixedAgentReply(t *testing.T) {
+	md := "## Deploy status\n" +
+		"Rolled out **v2.4.1** to staging. Remaining steps:\n" +
+		"1. Run `make migrate`\n" +
+		"   - check the *orders* table\n" +
+		"   - confirm row counts\n" +
+		"2. Flip the flag\n" +
+		"\n" +
+		"> Heads up: the cache is cold for ~10 min.\n" +
+		"\n" +
+		"| Service | Status |\n" +
+		"|---|---|\n" +
+		"| api | ok |\n" +
+		"| worker_pool | degraded |\n" +
+		"\n" +
+		"See [runbook](https://wiki.example.com/deploy) for details."
+	want := "<b>Deploy status</b>\n" +
+		"Rolled out <b>v2.4.1</b> to staging. Remaining steps:\n" +
+		"1. Run <code>make migrate</code>\n" +
+		"    • check the <i>orders</i> table\n" +
+		"    • confirm row counts\n" +
+		"2. Flip the flag\n" +
+		"\n" +
+		"<blockquote>Heads up: the cache is cold for ~10 min.</blockquote>\n" +
+		"\n" +
+		"<pre>Service      Status\n" +
+		"-----------  --------\n" +
+		"api          ok\n" +
+		"worker_pool  degraded</pre>\n" +
+		"\n" +
+		"See <a href=\"https://wiki.example.com/deploy\">runbook</a> for details."
+	if got := MarkdownToTelegramHTML(md); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
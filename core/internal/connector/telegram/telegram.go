@@ -6,10 +6,12 @@ import (
 	"log/slog"
 	"strconv"
 	"strings"
+	"time"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 
 	"github.com/h1v3-io/h1v3/internal/connector"
+	"github.com/h1v3-io/h1v3/pkg/protocol"
 )
 
 // Config holds Telegram connector configuration.
@@ -49,6 +51,16 @@ func New(cfg Config, handler connector.InboundHandler, logger *slog.Logger) (*Co
 	}, nil
 }
 
+// CheckAuth verifies a bot token without starting the connector and
+// returns the bot's username.
+func CheckAuth(token string) (string, error) {
+	bot, err := tgbotapi.NewBotAPI(token)
+	if err != nil {
+		return "", fmt.Errorf("telegram: init bot: %w", err)
+	}
+	return bot.Self.UserName, nil
+}
+
 func (c *Connector) Name() string { return "telegram" }
 
 // Start begins long-polling for updates. Blocks until context is cancelled.
@@ -87,15 +99,31 @@ func (c *Connector) Stop() error {
 }
 
 // Send delivers a message to a Telegram chat.
-func (c *Connector) Send(_ context.Context, msg connector.OutboundMessage) error {
+func (c *Connector) Send(ctx context.Context, msg connector.OutboundMessage) error {
+	_, err := c.SendWithReceipt(ctx, msg)
+	return err
+}
+
+// SendWithReceipt implements connector.ReceiptSender. The receipt carries
+// the ID of the text message, or is nil when there was nothing to send.
+func (c *Connector) SendWithReceipt(_ context.Context, msg connector.OutboundMessage) (*connector.Receipt, error) {
 	chatID, err := strconv.ParseInt(msg.ChatID, 10, 64)
 	if err != nil {
-		return fmt.Errorf("telegram: invalid chat_id %q: %w", msg.ChatID, err)
+		return nil, fmt.Errorf("telegram: invalid chat_id %q: %w", msg.ChatID, err)
+	}
+
+	for _, a := range msg.Attachments {
+		if err := c.sendAttachment(chatID, a); err != nil {
+			c.logger.Error("attachment send failed", "chat_id", msg.ChatID, "name", a.Name, "error", err)
+		}
 	}
 
 	if strings.TrimSpace(msg.Content) == "" {
-		c.logger.Warn("skipping empty message", "chat_id", msg.ChatID)
-		return nil
+		if len(msg.Attachments) == 0 {
+			c.logger.Warn("skipping empty message", "chat_id", msg.ChatID)
+			return nil, nil
+		}
+		return &connector.Receipt{Channel: "telegram", ChatID: msg.ChatID, Time: time.Now()}, nil
 	}
 
 	// Convert Markdown to Telegram HTML
@@ -105,7 +133,7 @@ func (c *Connector) Send(_ context.Context, msg connector.OutboundMessage) error
 	tgMsg.ParseMode = "HTML"
 	tgMsg.DisableWebPagePreview = true
 
-	_, err = c.bot.Send(tgMsg)
+	sent, err := c.bot.Send(tgMsg)
 	if err != nil {
 		// Fallback to plain text if HTML fails
 		c.logger.Warn("HTML send failed, falling back to plain text",
@@ -114,10 +142,18 @@ func (c *Connector) Send(_ context.Context, msg connector.OutboundMessage) error
 		)
 		tgMsg.Text = StripMarkdown(msg.Content)
 		tgMsg.ParseMode = ""
-		_, err = c.bot.Send(tgMsg)
+		sent, err = c.bot.Send(tgMsg)
+	}
+	if err != nil {
+		return nil, err
 	}
 
-	return err
+	return &connector.Receipt{
+		Channel:   "telegram",
+		ChatID:    msg.ChatID,
+		MessageID: strconv.Itoa(sent.MessageID),
+		Time:      time.Now(),
+	}, nil
 }
 
 func (c *Connector) handleUpdate(ctx context.Context, update tgbotapi.Update) {
@@ -160,6 +196,25 @@ func (c *Connector) handleUpdate(ctx context.Context, update tgbotapi.Update) {
 		}
 	}
 
+	// Download photos and documents into the ticket
+	var attachments []protocol.Attachment
+	if len(msg.Photo) > 0 || msg.Document != nil {
+		atts, err := c.downloadAttachments(ctx, msg)
+		if err != nil {
+			c.logger.Error("attachment download failed",
+				"chat_id", chatID,
+				"error", err,
+			)
+			reply := tgbotapi.NewMessage(chatID, "Sorry, I couldn't download that file.")
+			c.bot.Send(reply)
+			return
+		}
+		attachments = atts
+		if text == "" {
+			text = fmt.Sprintf("[Sent %d attachment(s)]", len(atts))
+		}
+	}
+
 	if text == "" {
 		return
 	}
@@ -174,6 +229,9 @@ func (c *Connector) handleUpdate(ctx context.Context, update tgbotapi.Update) {
 		SenderID: strconv.FormatInt(userID, 10),
 		ChatID:   strconv.FormatInt(chatID, 10),
 		Content:  text,
+		EventID:  strconv.Itoa(update.UpdateID),
+
+		Attachments: attachments,
 	}
 
 	if err := c.handler(ctx, inbound); err != nil {
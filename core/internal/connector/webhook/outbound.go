@@ -0,0 +1,223 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/h1v3-io/h1v3/pkg/protocol"
+)
+
+// SinkName is the participant ID agents route to for outbound webhooks.
+const SinkName = "_webhook"
+
+// TicketTagPrefix tags a ticket with the outbound endpoint its messages go
+// to, e.g. "webhook:ci".
+const TicketTagPrefix = "webhook:"
+
+// OutboundEndpoint is a callback URL the sink posts messages to.
+type OutboundEndpoint struct {
+	URL string `json:"url"`
+	// Secret signs the body as in SchemeGitHub: "sha256=<hex>" in
+	// X-Hub-Signature-256, computed with ComputeSignature.
+	Secret string `json:"secret,omitempty"`
+	// BearerToken is sent in the Authorization header.
+	BearerToken string `json:"bearer_token,omitempty"`
+}
+
+// Outbound delivery defaults.
+const (
+	defaultMaxRetries = 3
+	defaultRetryDelay = time.Second
+	outboundTimeout   = 30 * time.Second
+)
+
+// Sink is the hive's "_webhook" sink. It posts each message it receives, as
+// JSON, to the endpoint its ticket is mapped to. Posts run in the
+// background, so a slow receiver never holds up routing, but each endpoint
+// gets them one at a time in the order they were delivered; a 5xx or
+// network error is retried with a doubling delay.
+type Sink struct {
+	endpoints map[string]OutboundEndpoint
+	getTicket func(ticketID string) (*protocol.Ticket, error)
+	client    *http.Client
+	logger    *slog.Logger
+
+	maxRetries int
+	retryDelay time.Duration
+
+	mu     sync.Mutex
+	queues map[string][]outboundPost // endpoint name → pending posts, oldest first
+	wg     sync.WaitGroup
+}
+
+// outboundPost is a message waiting in an endpoint's queue.
+type outboundPost struct {
+	msg  protocol.Message
+	body []byte
+}
+
+// NewSink creates a sink for the given endpoints. getTicket is used to
+// resolve a ticket's endpoint from its tags; it may be nil.
+func NewSink(endpoints map[string]OutboundEndpoint, getTicket func(ticketID string) (*protocol.Ticket, error), logger *slog.Logger) *Sink {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &Sink{
+		endpoints:  endpoints,
+		getTicket:  getTicket,
+		client:     &http.Client{Timeout: outboundTimeout},
+		logger:     logger,
+		maxRetries: defaultMaxRetries,
+		retryDelay: defaultRetryDelay,
+		queues:     make(map[string][]outboundPost),
+	}
+}
+
+// Deliver implements registry.Sink. It returns an error only when the
+// ticket has no endpoint; failed posts are logged.
+func (s *Sink) Deliver(msg protocol.Message) error {
+	name, ok := s.endpointFor(msg.TicketID)
+	if !ok {
+		return fmt.Errorf("webhook sink: no endpoint for ticket %s", msg.TicketID)
+	}
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("webhook sink: encode message: %w", err)
+	}
+
+	s.wg.Add(1)
+	s.mu.Lock()
+	idle := len(s.queues[name]) == 0
+	s.queues[name] = append(s.queues[name], outboundPost{msg: msg, body: body})
+	s.mu.Unlock()
+	if idle {
+		go s.run(name)
+	}
+	return nil
+}
+
+// run posts the endpoint's queue in order until it is empty. A queue is
+// non-empty exactly while its run goroutine is alive: the head stays queued
+// until its post finishes.
+func (s *Sink) run(name string) {
+	for {
+		s.mu.Lock()
+		p := s.queues[name][0]
+		s.mu.Unlock()
+
+		if err := s.post(s.endpoints[name], p.body); err != nil {
+			s.logger.Error("outbound webhook failed", "endpoint", name, "ticket", p.msg.TicketID, "msg_id", p.msg.ID, "error", err)
+		}
+
+		s.mu.Lock()
+		rest := s.queues[name][1:]
+		if len(rest) == 0 {
+			delete(s.queues, name)
+		} else {
+			s.queues[name] = rest
+		}
+		s.mu.Unlock()
+		s.wg.Done()
+		if len(rest) == 0 {
+			return
+		}
+	}
+}
+
+// Wait blocks until queued posts have finished or ctx ends.
+func (s *Sink) Wait(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		s.mu.Lock()
+		pending := 0
+		for _, q := range s.queues {
+			pending += len(q)
+		}
+		s.mu.Unlock()
+		return fmt.Errorf("webhook sink: %w; %d post(s) pending", ctx.Err(), pending)
+	}
+}
+
+// endpointFor finds a ticket's endpoint: the ticket's TicketTagPrefix tag,
+// then the only endpoint if there is one.
+func (s *Sink) endpointFor(ticketID string) (string, bool) {
+	if s.getTicket != nil {
+		if tk, err := s.getTicket(ticketID); err == nil {
+			for _, tag := range tk.Tags {
+				if v, ok := strings.CutPrefix(tag, TicketTagPrefix); ok {
+					if _, known := s.endpoints[v]; known {
+						return v, true
+					}
+				}
+			}
+		}
+	}
+	if len(s.endpoints) == 1 {
+		for name := range s.endpoints {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+// post sends body to the endpoint, retrying network errors and 5xx.
+func (s *Sink) post(ep OutboundEndpoint, body []byte) error {
+	delay := s.retryDelay
+	var lastErr error
+	for attempt := 0; attempt <= s.maxRetries; attempt++ {
+		if attempt > 0 {
+			time.Sleep(delay)
+			delay *= 2
+		}
+		retry, err := s.postOnce(ep, body)
+		if err == nil {
+			return nil
+		}
+		lastErr = err
+		if !retry {
+			break
+		}
+	}
+	return lastErr
+}
+
+// postOnce makes one attempt, reporting whether a failure is worth retrying.
+func (s *Sink) postOnce(ep OutboundEndpoint, body []byte) (bool, error) {
+	req, err := http.NewRequest(http.MethodPost, ep.URL, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if ep.Secret != "" {
+		req.Header.Set("X-Hub-Signature-256", ComputeSignature(body, ep.Secret))
+	}
+	if ep.BearerToken != "" {
+		req.Header.Set("Authorization", "Bearer "+ep.BearerToken)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<16))
+	if resp.StatusCode >= 300 {
+		return resp.StatusCode >= 500, fmt.Errorf("%s returned %s", ep.URL, resp.Status)
+	}
+	return false, nil
+}
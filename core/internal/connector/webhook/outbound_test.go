@@ -0,0 +1,174 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/h1v3-io/h1v3/pkg/protocol"
+)
+
+// received is one request seen by a test receiver.
+type received struct {
+	body      []byte
+	signature string
+	auth      string
+}
+
+// newReceiver answers with the given statuses in turn (200 once they run
+// out) and records each request.
+func newReceiver(t *testing.T, statuses ...int) (*httptest.Server, chan received, *atomic.Int32) {
+	t.Helper()
+	got := make(chan received, 10)
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := int(calls.Add(1))
+		body, _ := io.ReadAll(r.Body)
+		if n <= len(statuses) && statuses[n-1] != http.StatusOK {
+			w.WriteHeader(statuses[n-1])
+			return
+		}
+		got <- received{body: body, signature: r.Header.Get("X-Hub-Signature-256"), auth: r.Header.Get("Authorization")}
+	}))
+	t.Cleanup(srv.Close)
+	return srv, got, &calls
+}
+
+func TestSink_SignedDelivery(t *testing.T) {
+	srv, got, _ := newReceiver(t)
+	s := NewSink(map[string]OutboundEndpoint{
+		"ci":    {URL: srv.URL, Secret: "whsec", BearerToken: "tok"},
+		"other": {URL: "http://127.0.0.1:1"},
+	}, func(id string) (*protocol.Ticket, error) {
+		return &protocol.Ticket{ID: id, Tags: []string{TicketTagPrefix + "ci"}}, nil
+	}, nil)
+
+	msg := protocol.Message{ID: "m1", From: "builder", To: []string{SinkName}, TicketID: "tk-1", Content: "Build passed"}
+	if err := s.Deliver(msg); err != nil {
+		t.Fatalf("Deliver: %v", err)
+	}
+
+	select {
+	case r := <-got:
+		if r.signature != ComputeSignature(r.body, "whsec") {
+			t.Errorf("signature %q does not match the body", r.signature)
+		}
+		if r.auth != "Bearer tok" {
+			t.Errorf("expected bearer token, got %q", r.auth)
+		}
+		var m protocol.Message
+		if err := json.Unmarshal(r.body, &m); err != nil || m.ID != "m1" || m.TicketID != "tk-1" || m.Content != "Build passed" {
+			t.Errorf("unexpected payload %s (%v)", r.body, err)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("message not delivered")
+	}
+}
+
+func TestSink_RetriesServerErrors(t *testing.T) {
+	srv, got, calls := newReceiver(t, http.StatusBadGateway, http.StatusServiceUnavailable)
+	s := NewSink(map[string]OutboundEndpoint{"ci": {URL: srv.URL}}, nil, nil)
+	s.retryDelay = time.Millisecond
+
+	if err := s.Deliver(protocol.Message{ID: "m1", TicketID: "tk-1"}); err != nil {
+		t.Fatalf("Deliver: %v", err)
+	}
+	s.Wait(context.Background())
+	if len(got) != 1 || calls.Load() != 3 {
+		t.Errorf("expected delivery on the third attempt, got %d deliveries in %d calls", len(got), calls.Load())
+	}
+
+	// Client errors are not retried.
+	srv, got, calls = newReceiver(t, http.StatusBadRequest)
+	s = NewSink(map[string]OutboundEndpoint{"ci": {URL: srv.URL}}, nil, nil)
+	s.retryDelay = time.Millisecond
+	s.Deliver(protocol.Message{ID: "m2", TicketID: "tk-1"})
+	s.Wait(context.Background())
+	if len(got) != 0 || calls.Load() != 1 {
+		t.Errorf("expected a single failed attempt, got %d deliveries in %d calls", len(got), calls.Load())
+	}
+}
+
+func TestSink_EndpointFromTicketTag(t *testing.T) {
+	srvA, gotA, _ := newReceiver(t)
+	srvB, gotB, _ := newReceiver(t)
+	tickets := map[string]*protocol.Ticket{
+		"tk-b": {ID: "tk-b", Tags: []string{"deploy", TicketTagPrefix + "b"}},
+		"tk-x": {ID: "tk-x"},
+	}
+	s := NewSink(map[string]OutboundEndpoint{"a": {URL: srvA.URL}, "b": {URL: srvB.URL}},
+		func(id string) (*protocol.Ticket, error) {
+			if tk, ok := tickets[id]; ok {
+				return tk, nil
+			}
+			return nil, errors.New("not found")
+		}, nil)
+
+	if err := s.Deliver(protocol.Message{ID: "m1", TicketID: "tk-b"}); err != nil {
+		t.Fatalf("Deliver: %v", err)
+	}
+	s.Wait(context.Background())
+	if len(gotA) != 0 || len(gotB) != 1 {
+		t.Errorf("expected delivery to endpoint b only, got a=%d b=%d", len(gotA), len(gotB))
+	}
+
+	if err := s.Deliver(protocol.Message{ID: "m2", TicketID: "tk-x"}); err == nil {
+		t.Error("expected an error for a ticket without an endpoint")
+	}
+}
+
+func TestSink_PreservesOrderPerEndpoint(t *testing.T) {
+	// The first post fails once and is retried; later messages must still
+	// arrive after it.
+	srv, got, _ := newReceiver(t, http.StatusBadGateway)
+	s := NewSink(map[string]OutboundEndpoint{"ci": {URL: srv.URL}}, nil, nil)
+	s.retryDelay = 20 * time.Millisecond
+
+	for i := range 5 {
+		if err := s.Deliver(protocol.Message{ID: "m" + strconv.Itoa(i), TicketID: "tk-1"}); err != nil {
+			t.Fatalf("Deliver: %v", err)
+		}
+	}
+	if err := s.Wait(context.Background()); err != nil {
+		t.Fatalf("Wait: %v", err)
+	}
+	close(got)
+	var ids []string
+	for r := range got {
+		var m protocol.Message
+		json.Unmarshal(r.body, &m)
+		ids = append(ids, m.ID)
+	}
+	if len(ids) != 5 {
+		t.Fatalf("expected 5 deliveries, got %v", ids)
+	}
+	for i, id := range ids {
+		if id != "m"+strconv.Itoa(i) {
+			t.Fatalf("messages arrived out of order: %v", ids)
+		}
+	}
+}
+
+func TestSink_WaitHonoursContext(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	t.Cleanup(srv.Close)
+	t.Cleanup(func() { close(release) })
+	s := NewSink(map[string]OutboundEndpoint{"ci": {URL: srv.URL}}, nil, nil)
+
+	s.Deliver(protocol.Message{ID: "m1", TicketID: "tk-1"})
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := s.Wait(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected a deadline error, got %v", err)
+	}
+}
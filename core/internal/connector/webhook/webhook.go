@@ -2,14 +2,21 @@ package webhook
 
 import (
 	"crypto/hmac"
+	"crypto/sha1"
 	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"hash"
 	"io"
 	"log/slog"
+	"math"
 	"net/http"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/h1v3-io/h1v3/internal/connector"
 )
@@ -23,18 +30,48 @@ type Config struct {
 
 // EndpointConfig holds per-endpoint webhook configuration.
 type EndpointConfig struct {
-	// Secret for HMAC-SHA256 signature verification (X-Hub-Signature-256 header).
-	// If empty, Bearer auth is used instead.
+	// Secret for HMAC signature verification, checked according to
+	// SignatureScheme. If empty, Bearer auth is used instead.
 	Secret string `json:"secret,omitempty"`
 	// BearerToken for Authorization header auth. Used if Secret is empty.
 	BearerToken string `json:"bearer_token,omitempty"`
+	// SignatureScheme selects how Secret is checked: SchemeGitHub (default),
+	// SchemeStripe or SchemeHMAC.
+	SignatureScheme string `json:"signature_scheme,omitempty"`
+	// SignatureHeader, Algorithm and Encoding configure SchemeHMAC: the
+	// header carrying the signature (default "X-Signature"), the hash
+	// ("sha1", "sha256" (default) or "sha512") and how the MAC is written
+	// ("hex" (default) or "base64").
+	SignatureHeader string `json:"signature_header,omitempty"`
+	Algorithm       string `json:"algorithm,omitempty"`
+	Encoding        string `json:"encoding,omitempty"`
+	// ToleranceSeconds is how old a SchemeStripe signature timestamp may be
+	// before the request is rejected as a replay. Default 300.
+	ToleranceSeconds int `json:"tolerance_seconds,omitempty"`
 }
 
+// Signature schemes for EndpointConfig.SignatureScheme.
+const (
+	// SchemeGitHub: "sha256=<hex>" HMAC-SHA256 of the body in
+	// X-Hub-Signature-256 (or X-Signature-256).
+	SchemeGitHub = "github"
+	// SchemeStripe: Stripe-Signature "t=<unix>,v1=<hex>", an HMAC-SHA256 of
+	// "<t>.<body>", with a timestamp tolerance against replays.
+	SchemeStripe = "stripe"
+	// SchemeHMAC: an HMAC of the body in a configurable header, algorithm
+	// and encoding, optionally prefixed "<algorithm>=".
+	SchemeHMAC = "hmac"
+)
+
+// defaultStripeTolerance is the default maximum age of a Stripe signature.
+const defaultStripeTolerance = 5 * time.Minute
+
 // WebhookPayload is the expected JSON body for webhook requests.
 type WebhookPayload struct {
 	SenderID string         `json:"sender_id"`
 	ChatID   string         `json:"chat_id"`
 	Content  string         `json:"content"`
+	EventID  string         `json:"event_id,omitempty"` // sender's delivery ID; retries with the same ID are dropped
 	Metadata map[string]any `json:"metadata,omitempty"`
 }
 
@@ -115,6 +152,7 @@ func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		SenderID: payload.SenderID,
 		ChatID:   payload.ChatID,
 		Content:  content,
+		EventID:  payload.EventID,
 	}
 
 	if payload.SenderID == "" {
@@ -141,11 +179,29 @@ func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 func (h *Handler) authenticate(r *http.Request, endpoint EndpointConfig, body []byte) bool {
 	// HMAC signature verification
 	if endpoint.Secret != "" {
-		sig := r.Header.Get("X-Hub-Signature-256")
-		if sig == "" {
-			sig = r.Header.Get("X-Signature-256")
+		switch endpoint.SignatureScheme {
+		case "", SchemeGitHub:
+			sig := r.Header.Get("X-Hub-Signature-256")
+			if sig == "" {
+				sig = r.Header.Get("X-Signature-256")
+			}
+			return verifyHMAC(body, endpoint.Secret, sig)
+		case SchemeStripe:
+			tolerance := defaultStripeTolerance
+			if endpoint.ToleranceSeconds > 0 {
+				tolerance = time.Duration(endpoint.ToleranceSeconds) * time.Second
+			}
+			return verifyStripe(body, endpoint.Secret, r.Header.Get("Stripe-Signature"), tolerance, time.Now())
+		case SchemeHMAC:
+			ok, err := verifyGenericHMAC(r, body, endpoint)
+			if err != nil {
+				h.logger.Error("webhook signature config error", "error", err)
+			}
+			return ok
+		default:
+			h.logger.Error("unknown webhook signature scheme", "scheme", endpoint.SignatureScheme)
+			return false
 		}
-		return verifyHMAC(body, endpoint.Secret, sig)
 	}
 
 	// Bearer token
@@ -158,7 +214,7 @@ func (h *Handler) authenticate(r *http.Request, endpoint EndpointConfig, body []
 	return true
 }
 
-// verifyHMAC checks an HMAC-SHA256 signature.
+// verifyHMAC checks a GitHub-style HMAC-SHA256 signature.
 // Signature format: "sha256=<hex>"
 func verifyHMAC(body []byte, secret, signature string) bool {
 	if signature == "" {
@@ -171,11 +227,91 @@ func verifyHMAC(body []byte, secret, signature string) bool {
 		return false
 	}
 
-	mac := hmac.New(sha256.New, []byte(secret))
-	mac.Write(body)
-	computedMAC := mac.Sum(nil)
+	return hmac.Equal(computeMAC(sha256.New, secret, body), expectedMAC)
+}
+
+// verifyStripe checks a Stripe-Signature header ("t=<unix>,v1=<hex>,...")
+// against the body, rejecting timestamps further than tolerance from now.
+// Any of several v1 signatures (sent during secret rotation) may match.
+func verifyStripe(body []byte, secret, header string, tolerance time.Duration, now time.Time) bool {
+	var ts string
+	var sigs []string
+	for _, part := range strings.Split(header, ",") {
+		k, v, _ := strings.Cut(strings.TrimSpace(part), "=")
+		switch k {
+		case "t":
+			ts = v
+		case "v1":
+			sigs = append(sigs, v)
+		}
+	}
+	unix, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil || len(sigs) == 0 {
+		return false
+	}
+	if math.Abs(now.Sub(time.Unix(unix, 0)).Seconds()) > tolerance.Seconds() {
+		return false
+	}
+
+	expected := computeMAC(sha256.New, secret, []byte(ts+"."), body)
+	for _, sig := range sigs {
+		if got, err := hex.DecodeString(sig); err == nil && hmac.Equal(expected, got) {
+			return true
+		}
+	}
+	return false
+}
 
-	return hmac.Equal(computedMAC, expectedMAC)
+// hashes are the algorithms available to SchemeHMAC.
+var hashes = map[string]func() hash.Hash{
+	"sha1":   sha1.New,
+	"sha256": sha256.New,
+	"sha512": sha512.New,
+}
+
+// verifyGenericHMAC checks a SchemeHMAC signature. The error reports a
+// misconfigured endpoint rather than a bad signature.
+func verifyGenericHMAC(r *http.Request, body []byte, endpoint EndpointConfig) (bool, error) {
+	alg := endpoint.Algorithm
+	if alg == "" {
+		alg = "sha256"
+	}
+	newHash, ok := hashes[alg]
+	if !ok {
+		return false, fmt.Errorf("unsupported algorithm %q", alg)
+	}
+	header := endpoint.SignatureHeader
+	if header == "" {
+		header = "X-Signature"
+	}
+
+	sig := strings.TrimPrefix(r.Header.Get(header), alg+"=")
+	if sig == "" {
+		return false, nil
+	}
+	var got []byte
+	var err error
+	switch endpoint.Encoding {
+	case "", "hex":
+		got, err = hex.DecodeString(sig)
+	case "base64":
+		got, err = base64.StdEncoding.DecodeString(sig)
+	default:
+		return false, fmt.Errorf("unsupported encoding %q", endpoint.Encoding)
+	}
+	if err != nil {
+		return false, nil
+	}
+	return hmac.Equal(computeMAC(newHash, endpoint.Secret, body), got), nil
+}
+
+// computeMAC returns the HMAC of the concatenated parts.
+func computeMAC(newHash func() hash.Hash, secret string, parts ...[]byte) []byte {
+	mac := hmac.New(newHash, []byte(secret))
+	for _, p := range parts {
+		mac.Write(p)
+	}
+	return mac.Sum(nil)
 }
 
 // extractName gets the last path segment from /api/webhook/{name}.
@@ -190,7 +326,12 @@ func extractName(path string) string {
 
 // ComputeSignature generates an HMAC-SHA256 signature for testing/external use.
 func ComputeSignature(body []byte, secret string) string {
-	mac := hmac.New(sha256.New, []byte(secret))
-	mac.Write(body)
-	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+	return "sha256=" + hex.EncodeToString(computeMAC(sha256.New, secret, body))
+}
+
+// ComputeStripeSignature generates a Stripe-Signature header value for a body
+// sent at ts, for testing/external use.
+func ComputeStripeSignature(body []byte, secret string, ts time.Time) string {
+	t := strconv.FormatInt(ts.Unix(), 10)
+	return "t=" + t + ",v1=" + hex.EncodeToString(computeMAC(sha256.New, secret, []byte(t+"."), body))
 }
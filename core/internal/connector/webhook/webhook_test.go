@@ -2,12 +2,19 @@ package webhook
 
 import (
 	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
+	"hash"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/h1v3-io/h1v3/internal/connector"
 )
@@ -134,6 +141,90 @@ func TestWebhook_HMACAuth(t *testing.T) {
 	}
 }
 
+// postSigned posts payload to /api/webhook/{name} with the given headers and
+// returns the status code.
+func postSigned(h *Handler, name string, payload []byte, headers map[string]string) int {
+	req := httptest.NewRequest(http.MethodPost, "/api/webhook/"+name, strings.NewReader(string(payload)))
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	return w.Code
+}
+
+func TestWebhook_StripeAuth(t *testing.T) {
+	secret := "whsec_test"
+	h, _ := newTestHandler(map[string]EndpointConfig{
+		"stripe": {Secret: secret, SignatureScheme: SchemeStripe},
+		"strict": {Secret: secret, SignatureScheme: SchemeStripe, ToleranceSeconds: 10},
+	})
+	payload := []byte(`{"content":"invoice.paid"}`)
+	now := time.Now()
+
+	cases := []struct {
+		name, endpoint, header string
+		want                   int
+	}{
+		{"valid", "stripe", ComputeStripeSignature(payload, secret, now), http.StatusOK},
+		{"rotated secrets", "stripe", ComputeStripeSignature(payload, secret, now) + ",v1=" + strings.Repeat("ab", 32), http.StatusOK},
+		{"wrong secret", "stripe", ComputeStripeSignature(payload, "other", now), http.StatusUnauthorized},
+		{"tampered body", "stripe", ComputeStripeSignature([]byte(`{"content":"x"}`), secret, now), http.StatusUnauthorized},
+		{"replayed", "stripe", ComputeStripeSignature(payload, secret, now.Add(-10*time.Minute)), http.StatusUnauthorized},
+		{"within custom tolerance", "strict", ComputeStripeSignature(payload, secret, now.Add(-5*time.Second)), http.StatusOK},
+		{"outside custom tolerance", "strict", ComputeStripeSignature(payload, secret, now.Add(-time.Minute)), http.StatusUnauthorized},
+		{"missing timestamp", "stripe", "v1=" + strings.Repeat("ab", 32), http.StatusUnauthorized},
+		{"missing header", "stripe", "", http.StatusUnauthorized},
+	}
+	for _, tc := range cases {
+		if got := postSigned(h, tc.endpoint, payload, map[string]string{"Stripe-Signature": tc.header}); got != tc.want {
+			t.Errorf("%s: status = %d, want %d", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestWebhook_GenericHMACAuth(t *testing.T) {
+	secret := "s3cret"
+	payload := []byte(`{"content":"alert"}`)
+	sign := func(newHash func() hash.Hash) []byte {
+		m := hmac.New(newHash, []byte(secret))
+		m.Write(payload)
+		return m.Sum(nil)
+	}
+	sha1Mac, sha512Mac := sign(sha1.New), sign(sha512.New)
+	sha256Hex := strings.TrimPrefix(ComputeSignature(payload, secret), "sha256=")
+
+	h, _ := newTestHandler(map[string]EndpointConfig{
+		"default": {Secret: secret, SignatureScheme: SchemeHMAC},
+		"sha1":    {Secret: secret, SignatureScheme: SchemeHMAC, SignatureHeader: "X-Vendor-Sig", Algorithm: "sha1"},
+		"b64":     {Secret: secret, SignatureScheme: SchemeHMAC, Algorithm: "sha512", Encoding: "base64"},
+		"badalg":  {Secret: secret, SignatureScheme: SchemeHMAC, Algorithm: "md5"},
+		"unknown": {Secret: secret, SignatureScheme: "carrier-pigeon"},
+	})
+
+	cases := []struct {
+		name, endpoint string
+		headers        map[string]string
+		want           int
+	}{
+		{"default header, hex", "default", map[string]string{"X-Signature": sha256Hex}, http.StatusOK},
+		{"algorithm prefix", "default", map[string]string{"X-Signature": "sha256=" + sha256Hex}, http.StatusOK},
+		{"wrong signature", "default", map[string]string{"X-Signature": strings.Repeat("00", 32)}, http.StatusUnauthorized},
+		{"github header ignored", "default", map[string]string{"X-Hub-Signature-256": "sha256=" + sha256Hex}, http.StatusUnauthorized},
+		{"custom header sha1", "sha1", map[string]string{"X-Vendor-Sig": hex.EncodeToString(sha1Mac)}, http.StatusOK},
+		{"custom header wrong alg", "sha1", map[string]string{"X-Vendor-Sig": sha256Hex}, http.StatusUnauthorized},
+		{"base64 sha512", "b64", map[string]string{"X-Signature": base64.StdEncoding.EncodeToString(sha512Mac)}, http.StatusOK},
+		{"base64 expected, hex sent", "b64", map[string]string{"X-Signature": hex.EncodeToString(sha512Mac)}, http.StatusUnauthorized},
+		{"unsupported algorithm", "badalg", map[string]string{"X-Signature": sha256Hex}, http.StatusUnauthorized},
+		{"unknown scheme", "unknown", map[string]string{"X-Hub-Signature-256": "sha256=" + sha256Hex}, http.StatusUnauthorized},
+	}
+	for _, tc := range cases {
+		if got := postSigned(h, tc.endpoint, payload, tc.headers); got != tc.want {
+			t.Errorf("%s: status = %d, want %d", tc.name, got, tc.want)
+		}
+	}
+}
+
 func TestWebhook_UnknownEndpoint(t *testing.T) {
 	h, _ := newTestHandler(map[string]EndpointConfig{
 		"github": {},
@@ -3,29 +3,58 @@ package logbuf
 import (
 	"context"
 	"log/slog"
+	"sync/atomic"
 )
 
 // Handler is an slog.Handler that captures entries into a Buffer
-// and delegates to an inner handler.
+// and delegates to an inner handler. Secrets in messages and string
+// attributes are redacted before either sees them.
 type Handler struct {
-	inner  slog.Handler
-	buf    *Buffer
-	attrs  []slog.Attr
-	groups []string
+	inner    slog.Handler
+	buf      *Buffer
+	attrs    []slog.Attr
+	groups   []string
+	redactor *atomic.Pointer[Redactor] // shared by handlers derived via With*
 }
 
-// NewHandler creates a handler that writes to both buf and inner.
+// NewHandler creates a handler that writes to both buf and inner, redacting
+// with the built-in patterns until SetRedactor is called. A nil buf only
+// redacts and forwards to inner.
 func NewHandler(inner slog.Handler, buf *Buffer) *Handler {
-	return &Handler{inner: inner, buf: buf}
+	h := &Handler{inner: inner, buf: buf, redactor: new(atomic.Pointer[Redactor])}
+	h.redactor.Store(defaultRedactor)
+	return h
 }
 
-func (h *Handler) Enabled(_ context.Context, _ slog.Level) bool {
+// SetRedactor replaces the redactor for this handler and every handler
+// derived from it. Attributes already bound with WithAttrs keep the
+// redaction they were bound with.
+func (h *Handler) SetRedactor(r *Redactor) {
+	h.redactor.Store(r)
+}
+
+// Redact masks secrets in s with the handler's current redactor, for text
+// that must be redacted before it is cut down and logged.
+func (h *Handler) Redact(s string) string {
+	return h.redactor.Load().Redact(s)
+}
+
+func (h *Handler) Enabled(ctx context.Context, level slog.Level) bool {
+	if h.buf == nil {
+		return h.inner.Enabled(ctx, level)
+	}
 	// Always return true so the buffer captures all log levels,
 	// regardless of the inner handler's level filter.
 	return true
 }
 
 func (h *Handler) Handle(ctx context.Context, r slog.Record) error {
+	red := h.redactor.Load()
+	r = redactRecord(red, r)
+	if h.buf == nil {
+		return h.inner.Handle(ctx, r)
+	}
+
 	// Collect attributes
 	attrs := make(map[string]any)
 	// Pre-bound attrs from WithAttrs
@@ -66,6 +95,38 @@ func (h *Handler) Handle(ctx context.Context, r slog.Record) error {
 	return nil
 }
 
+// redactRecord returns a copy of r with its message and string attributes
+// redacted.
+func redactRecord(red *Redactor, r slog.Record) slog.Record {
+	out := slog.NewRecord(r.Time, r.Level, red.Redact(r.Message), r.PC)
+	r.Attrs(func(a slog.Attr) bool {
+		out.AddAttrs(redactAttr(red, a))
+		return true
+	})
+	return out
+}
+
+// redactAttr redacts string and error values, recursing into groups.
+func redactAttr(red *Redactor, a slog.Attr) slog.Attr {
+	v := a.Value.Resolve()
+	switch v.Kind() {
+	case slog.KindString:
+		return slog.String(a.Key, red.Redact(v.String()))
+	case slog.KindGroup:
+		group := v.Group()
+		redacted := make([]slog.Attr, len(group))
+		for i, ga := range group {
+			redacted[i] = redactAttr(red, ga)
+		}
+		return slog.Attr{Key: a.Key, Value: slog.GroupValue(redacted...)}
+	case slog.KindAny:
+		if err, ok := v.Any().(error); ok {
+			return slog.String(a.Key, red.Redact(err.Error()))
+		}
+	}
+	return slog.Attr{Key: a.Key, Value: v}
+}
+
 // resolveAttrValue converts slog values to JSON-safe types.
 // Errors are converted to their string representation so they don't
 // serialize to {} when JSON-marshaled.
@@ -79,19 +140,26 @@ func resolveAttrValue(v slog.Value) any {
 }
 
 func (h *Handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	red := h.redactor.Load()
+	redacted := make([]slog.Attr, len(attrs))
+	for i, a := range attrs {
+		redacted[i] = redactAttr(red, a)
+	}
 	return &Handler{
-		inner:  h.inner.WithAttrs(attrs),
-		buf:    h.buf,
-		attrs:  append(h.attrs[:len(h.attrs):len(h.attrs)], attrs...),
-		groups: h.groups,
+		inner:    h.inner.WithAttrs(redacted),
+		buf:      h.buf,
+		attrs:    append(h.attrs[:len(h.attrs):len(h.attrs)], redacted...),
+		groups:   h.groups,
+		redactor: h.redactor,
 	}
 }
 
 func (h *Handler) WithGroup(name string) slog.Handler {
 	return &Handler{
-		inner:  h.inner.WithGroup(name),
-		buf:    h.buf,
-		attrs:  h.attrs,
-		groups: append(h.groups[:len(h.groups):len(h.groups)], name),
+		inner:    h.inner.WithGroup(name),
+		buf:      h.buf,
+		attrs:    h.attrs,
+		groups:   append(h.groups[:len(h.groups):len(h.groups)], name),
+		redactor: h.redactor,
 	}
 }
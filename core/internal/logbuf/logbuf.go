@@ -1,6 +1,7 @@
 package logbuf
 
 import (
+	"fmt"
 	"log/slog"
 	"sync"
 	"time"
@@ -8,19 +9,34 @@ import (
 
 // Entry is a single log entry captured from slog.
 type Entry struct {
+	Seq     uint64         `json:"seq"`
 	Time    time.Time      `json:"time"`
 	Level   string         `json:"level"`
 	Message string         `json:"message"`
 	Attrs   map[string]any `json:"attrs,omitempty"`
 }
 
-// Buffer is a thread-safe ring buffer for log entries.
+// Filter selects entries in Query. Empty fields don't filter, except that
+// the zero MinLevel is slog.LevelInfo.
+type Filter struct {
+	Since    time.Time  // only entries at or after this time
+	After    uint64     // only entries with a higher Seq; ignored when ahead of the buffer (after a restart)
+	MinLevel slog.Level // only entries at or above this level
+	Limit    int        // keep the newest Limit entries; <= 0 returns all
+	Agent    string     // only entries tagged with this agent ("agent" or "agent_id" attr)
+	Ticket   string     // only entries tagged with this ticket ("ticket" or "ticket_id" attr)
+}
+
+// Buffer is a thread-safe ring buffer for log entries, optionally backed
+// by a file (see NewPersistent).
 type Buffer struct {
 	mu      sync.Mutex
 	entries []Entry
 	size    int
 	pos     int
 	count   int
+	seq     uint64       // Seq of the newest entry
+	persist *persistence // nil for in-memory buffers
 }
 
 // New creates a new ring buffer that holds up to size entries.
@@ -31,24 +47,34 @@ func New(size int) *Buffer {
 	}
 }
 
-// Write appends an entry to the ring buffer.
+// Write appends an entry to the ring buffer, numbering it with the next
+// sequence number. Sequence numbers start over when the buffer is created,
+// including entries reloaded by NewPersistent.
 func (b *Buffer) Write(e Entry) {
 	b.mu.Lock()
+	b.seq++
+	e.Seq = b.seq
 	b.entries[b.pos] = e
 	b.pos = (b.pos + 1) % b.size
 	if b.count < b.size {
 		b.count++
 	}
+	if b.persist != nil {
+		b.persist.append(e)
+	}
 	b.mu.Unlock()
 }
 
-// Query returns entries matching the given filters, oldest first.
-// If since is zero, all entries are considered. If limit <= 0, all matching entries are returned.
-func (b *Buffer) Query(since time.Time, minLevel slog.Level, limit int) []Entry {
+// Query returns entries matching the filter, oldest first.
+func (b *Buffer) Query(f Filter) []Entry {
 	b.mu.Lock()
 	defer b.mu.Unlock()
 
 	var result []Entry
+	after := f.After
+	if after > b.seq {
+		after = 0
+	}
 
 	// Walk the ring buffer oldest-first
 	start := 0
@@ -61,21 +87,40 @@ func (b *Buffer) Query(since time.Time, minLevel slog.Level, limit int) []Entry
 		idx := (start + i) % b.size
 		e := b.entries[idx]
 
-		if !since.IsZero() && e.Time.Before(since) {
+		if e.Seq <= after {
+			continue
+		}
+		if !f.Since.IsZero() && e.Time.Before(f.Since) {
+			continue
+		}
+		if parseSlogLevel(e.Level) < f.MinLevel {
+			continue
+		}
+		if f.Agent != "" && !e.hasAttr(f.Agent, "agent", "agent_id") {
 			continue
 		}
-		if parseSlogLevel(e.Level) < minLevel {
+		if f.Ticket != "" && !e.hasAttr(f.Ticket, "ticket", "ticket_id") {
 			continue
 		}
 		result = append(result, e)
 	}
 
-	if limit > 0 && len(result) > limit {
-		result = result[len(result)-limit:]
+	if f.Limit > 0 && len(result) > f.Limit {
+		result = result[len(result)-f.Limit:]
 	}
 	return result
 }
 
+// hasAttr reports whether any of the given attribute keys has value want.
+func (e Entry) hasAttr(want string, keys ...string) bool {
+	for _, k := range keys {
+		if v, ok := e.Attrs[k]; ok && fmt.Sprint(v) == want {
+			return true
+		}
+	}
+	return false
+}
+
 // parseSlogLevel converts a level string back to slog.Level.
 func parseSlogLevel(s string) slog.Level {
 	switch s {
@@ -3,6 +3,7 @@ package logbuf
 import (
 	"context"
 	"log/slog"
+	"strings"
 	"testing"
 	"time"
 )
@@ -19,7 +20,7 @@ func TestBufferWriteAndQuery(t *testing.T) {
 		})
 	}
 
-	entries := buf.Query(time.Time{}, slog.LevelDebug, 0)
+	entries := buf.Query(Filter{MinLevel: slog.LevelDebug})
 	if len(entries) != 3 {
 		t.Fatalf("expected 3 entries, got %d", len(entries))
 	}
@@ -38,7 +39,7 @@ func TestBufferRingOverwrite(t *testing.T) {
 		})
 	}
 
-	entries := buf.Query(time.Time{}, slog.LevelDebug, 0)
+	entries := buf.Query(Filter{MinLevel: slog.LevelDebug})
 	if len(entries) != 3 {
 		t.Fatalf("expected 3 entries (ring buffer size), got %d", len(entries))
 	}
@@ -64,12 +65,45 @@ func TestBufferQuerySince(t *testing.T) {
 	}
 
 	since := now.Add(3 * time.Second)
-	entries := buf.Query(since, slog.LevelDebug, 0)
+	entries := buf.Query(Filter{Since: since, MinLevel: slog.LevelDebug})
 	if len(entries) != 2 {
 		t.Fatalf("expected 2 entries since t+3s, got %d", len(entries))
 	}
 }
 
+func TestBufferQueryAfter(t *testing.T) {
+	buf := New(10)
+	now := time.Now()
+
+	// Entries can arrive out of time order; After still returns every
+	// entry written since the given sequence number.
+	for i, msg := range []string{"a", "b", "c", "d"} {
+		buf.Write(Entry{Time: now.Add(-time.Duration(i) * time.Second), Level: "INFO", Message: msg})
+	}
+	messages := func(entries []Entry) string {
+		var s string
+		for _, e := range entries {
+			s += e.Message
+		}
+		return s
+	}
+
+	entries := buf.Query(Filter{})
+	if entries[1].Seq != 2 {
+		t.Fatalf("expected seq 2, got %d", entries[1].Seq)
+	}
+	if got := messages(buf.Query(Filter{After: entries[1].Seq})); got != "cd" {
+		t.Fatalf("After: got %q", got)
+	}
+	if got := messages(buf.Query(Filter{After: 4})); got != "" {
+		t.Fatalf("After newest: got %q", got)
+	}
+	// A cursor from before a restart is ahead of the buffer and is ignored.
+	if got := messages(buf.Query(Filter{After: 99})); got != "abcd" {
+		t.Fatalf("After ahead of buffer: got %q", got)
+	}
+}
+
 func TestBufferQueryLevel(t *testing.T) {
 	buf := New(10)
 	now := time.Now()
@@ -79,7 +113,7 @@ func TestBufferQueryLevel(t *testing.T) {
 	buf.Write(Entry{Time: now, Level: "WARN", Message: "warn"})
 	buf.Write(Entry{Time: now, Level: "ERROR", Message: "error"})
 
-	entries := buf.Query(time.Time{}, slog.LevelWarn, 0)
+	entries := buf.Query(Filter{MinLevel: slog.LevelWarn})
 	if len(entries) != 2 {
 		t.Fatalf("expected 2 entries at WARN+, got %d", len(entries))
 	}
@@ -96,12 +130,45 @@ func TestBufferQueryLimit(t *testing.T) {
 		buf.Write(Entry{Time: now.Add(time.Duration(i) * time.Second), Level: "INFO", Message: "msg"})
 	}
 
-	entries := buf.Query(time.Time{}, slog.LevelDebug, 3)
+	entries := buf.Query(Filter{MinLevel: slog.LevelDebug, Limit: 3})
 	if len(entries) != 3 {
 		t.Fatalf("expected 3 entries with limit, got %d", len(entries))
 	}
 }
 
+func TestBufferQueryAgentAndTicket(t *testing.T) {
+	buf := New(10)
+	inner := slog.NewTextHandler(&discardWriter{}, nil)
+	logger := slog.New(NewHandler(inner, buf))
+
+	logger.Info("a", "agent", "coder", "ticket", "tk-1")
+	logger.Info("b", "agent", "front", "ticket", "tk-1")
+	logger.With("agent", "coder").Info("c", "ticket", "tk-2")
+	logger.Info("d", "agent_id", "coder")
+	logger.Info("e")
+
+	messages := func(entries []Entry) string {
+		var s string
+		for _, e := range entries {
+			s += e.Message
+		}
+		return s
+	}
+
+	if got := messages(buf.Query(Filter{Agent: "coder"})); got != "acd" {
+		t.Errorf("agent filter: got %q, want %q", got, "acd")
+	}
+	if got := messages(buf.Query(Filter{Ticket: "tk-1"})); got != "ab" {
+		t.Errorf("ticket filter: got %q, want %q", got, "ab")
+	}
+	if got := messages(buf.Query(Filter{Agent: "coder", Ticket: "tk-2"})); got != "c" {
+		t.Errorf("combined filter: got %q, want %q", got, "c")
+	}
+	if got := messages(buf.Query(Filter{})); got != "abcde" {
+		t.Errorf("no filter: got %q, want %q", got, "abcde")
+	}
+}
+
 func TestHandlerCaptures(t *testing.T) {
 	buf := New(10)
 	inner := slog.NewTextHandler(&discardWriter{}, nil)
@@ -111,7 +178,7 @@ func TestHandlerCaptures(t *testing.T) {
 	logger.Info("hello", "key", "value")
 	logger.Warn("warning")
 
-	entries := buf.Query(time.Time{}, slog.LevelDebug, 0)
+	entries := buf.Query(Filter{MinLevel: slog.LevelDebug})
 	if len(entries) != 2 {
 		t.Fatalf("expected 2 entries, got %d", len(entries))
 	}
@@ -134,7 +201,7 @@ func TestHandlerWithAttrs(t *testing.T) {
 
 	logger.Info("msg")
 
-	entries := buf.Query(time.Time{}, slog.LevelDebug, 0)
+	entries := buf.Query(Filter{MinLevel: slog.LevelDebug})
 	if len(entries) != 1 {
 		t.Fatalf("expected 1 entry, got %d", len(entries))
 	}
@@ -169,12 +236,26 @@ func TestHandlerCapturesAllLevels(t *testing.T) {
 	logger.Warn("warn msg")
 
 	// Buffer should have all 3 even though inner only allows WARN+
-	entries := buf.Query(time.Time{}, slog.LevelDebug, 0)
+	entries := buf.Query(Filter{MinLevel: slog.LevelDebug})
 	if len(entries) != 3 {
 		t.Fatalf("expected 3 entries in buffer, got %d", len(entries))
 	}
 }
 
+func TestHandlerWithoutBuffer(t *testing.T) {
+	var out strings.Builder
+	inner := slog.NewTextHandler(&out, &slog.HandlerOptions{Level: slog.LevelInfo})
+	logger := slog.New(NewHandler(inner, nil))
+
+	if logger.Enabled(context.Background(), slog.LevelDebug) {
+		t.Error("without a buffer, levels should follow the inner handler")
+	}
+	logger.Info("using sk-abcdefghijklmnopqrst")
+	if !strings.Contains(out.String(), "using [REDACTED]") {
+		t.Errorf("expected redacted output, got %q", out.String())
+	}
+}
+
 type discardWriter struct{}
 
 func (d *discardWriter) Write(p []byte) (int, error) { return len(p), nil }
@@ -0,0 +1,143 @@
+package logbuf
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// defaultMaxFileSize is when a persistent buffer's file is rotated.
+const defaultMaxFileSize = 16 << 20
+
+// persistence is the on-disk backing of a Buffer: one JSON entry per line
+// in path, moved to path+".1" once it outgrows maxSize. The previous file
+// is kept so a reload right after rotation still finds a full ring.
+type persistence struct {
+	path    string
+	maxSize int64
+	file    *os.File
+	size    int64
+}
+
+// NewPersistent creates a ring buffer of size entries that also appends
+// every entry to path as a JSON line. The newest size entries already in
+// the file (and its rotated predecessor) are loaded back into the ring, so
+// recent logs survive a restart or crash.
+func NewPersistent(size int, path string) (*Buffer, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("logbuf: create dir: %w", err)
+	}
+	b := New(size)
+	for _, p := range []string{path + ".1", path} {
+		if err := b.load(p); err != nil {
+			return nil, err
+		}
+	}
+	b.persist = &persistence{path: path, maxSize: defaultMaxFileSize}
+	if err := b.persist.open(); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// load writes the entries in path into the ring. A missing file is not an
+// error; unreadable lines (such as one cut short by a crash) are skipped.
+func (b *Buffer) load(path string) error {
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("logbuf: open %s: %w", path, err)
+	}
+	defer f.Close()
+
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 0, 64<<10), 1<<20)
+	for sc.Scan() {
+		var e Entry
+		if json.Unmarshal(sc.Bytes(), &e) == nil {
+			b.Write(e)
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return fmt.Errorf("logbuf: read %s: %w", path, err)
+	}
+	return nil
+}
+
+// open opens path for appending. A file whose last line was cut short by a
+// crash gets a newline first, so the next entry starts on a line of its own
+// instead of being glued to the broken one and skipped on load.
+func (p *persistence) open() error {
+	f, err := os.OpenFile(p.path, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("logbuf: open %s: %w", p.path, err)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return fmt.Errorf("logbuf: stat %s: %w", p.path, err)
+	}
+	size := info.Size()
+	if size > 0 {
+		last := make([]byte, 1)
+		if _, err := f.ReadAt(last, size-1); err != nil {
+			f.Close()
+			return fmt.Errorf("logbuf: read %s: %w", p.path, err)
+		}
+		if last[0] != '\n' {
+			n, err := f.Write([]byte{'\n'})
+			if err != nil {
+				f.Close()
+				return fmt.Errorf("logbuf: write %s: %w", p.path, err)
+			}
+			size += int64(n)
+		}
+	}
+	p.file, p.size = f, size
+	return nil
+}
+
+// append writes one entry, rotating first if it would take the file past
+// maxSize. Failures are dropped: losing a line on disk must not break
+// logging.
+func (p *persistence) append(e Entry) {
+	if p.file == nil {
+		return
+	}
+	line, err := json.Marshal(e)
+	if err != nil {
+		return
+	}
+	line = append(line, '\n')
+	if p.size > 0 && p.size+int64(len(line)) > p.maxSize {
+		p.rotate()
+	}
+	n, _ := p.file.Write(line)
+	p.size += int64(n)
+}
+
+func (p *persistence) rotate() {
+	p.file.Close()
+	p.file = nil
+	os.Rename(p.path, p.path+".1")
+	p.open()
+}
+
+// Close closes a persistent buffer's file. Later entries are kept in memory
+// only. It is a no-op for in-memory buffers.
+func (b *Buffer) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.persist == nil || b.persist.file == nil {
+		return nil
+	}
+	err := b.persist.file.Close()
+	b.persist.file = nil
+	return err
+}
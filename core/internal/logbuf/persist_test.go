@@ -0,0 +1,120 @@
+package logbuf
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestPersistent_ReloadsRecentEntries(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "logs", "buffer.jsonl")
+	buf, err := NewPersistent(3, path)
+	if err != nil {
+		t.Fatalf("NewPersistent: %v", err)
+	}
+	now := time.Now().Truncate(time.Millisecond)
+	for i := range 5 {
+		buf.Write(Entry{Time: now.Add(time.Duration(i) * time.Second), Level: "INFO", Message: fmt.Sprintf("msg %d", i), Attrs: map[string]any{"agent": "coder"}})
+	}
+	buf.Close()
+
+	// Simulate a crash mid-write.
+	f, _ := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0o644)
+	f.WriteString(`{"time":"2026-`)
+	f.Close()
+
+	reopened, err := NewPersistent(3, path)
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	defer reopened.Close()
+	got := reopened.Query(Filter{MinLevel: slog.LevelDebug})
+	if len(got) != 3 || got[0].Message != "msg 2" || got[2].Message != "msg 4" {
+		t.Fatalf("expected the last 3 entries, got %+v", got)
+	}
+	if !got[2].Time.Equal(now.Add(4*time.Second)) || got[2].Attrs["agent"] != "coder" {
+		t.Errorf("entry not restored intact: %+v", got[2])
+	}
+	if agent := reopened.Query(Filter{Agent: "coder", Limit: 1}); len(agent) != 1 || agent[0].Message != "msg 4" {
+		t.Errorf("filtered query = %+v", agent)
+	}
+
+	// New entries are appended after the reloaded ones.
+	reopened.Write(Entry{Time: now.Add(10 * time.Second), Level: "WARN", Message: "after restart"})
+	if last := reopened.Query(Filter{Limit: 1}); len(last) != 1 || last[0].Message != "after restart" {
+		t.Errorf("expected the new entry last, got %+v", last)
+	}
+}
+
+func TestPersistent_TruncatedLastLine(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "buffer.jsonl")
+	buf, err := NewPersistent(10, path)
+	if err != nil {
+		t.Fatalf("NewPersistent: %v", err)
+	}
+	buf.Write(Entry{Time: time.Now(), Level: "INFO", Message: "before crash"})
+	buf.Close()
+
+	// A crash leaves a partial line with no trailing newline.
+	f, _ := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0o644)
+	f.WriteString(`{"time":"2026-`)
+	f.Close()
+
+	reopened, err := NewPersistent(10, path)
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	reopened.Write(Entry{Time: time.Now(), Level: "INFO", Message: "after restart"})
+	reopened.Close()
+
+	// The first entry after the restart must not be glued to the partial
+	// line and lost on the next load.
+	again, err := NewPersistent(10, path)
+	if err != nil {
+		t.Fatalf("second reopen: %v", err)
+	}
+	defer again.Close()
+	got := again.Query(Filter{})
+	if len(got) != 2 || got[0].Message != "before crash" || got[1].Message != "after restart" {
+		t.Fatalf("expected both entries to survive, got %+v", got)
+	}
+}
+
+func TestPersistent_Rotation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "buffer.jsonl")
+	buf, err := NewPersistent(10, path)
+	if err != nil {
+		t.Fatalf("NewPersistent: %v", err)
+	}
+	buf.persist.maxSize = 300 // a few entries per file
+	now := time.Now()
+	for i := range 10 {
+		buf.Write(Entry{Time: now.Add(time.Duration(i) * time.Second), Level: "INFO", Message: fmt.Sprintf("msg %d", i)})
+	}
+	buf.Close()
+
+	if _, err := os.Stat(path + ".1"); err != nil {
+		t.Fatalf("expected a rotated file: %v", err)
+	}
+	if info, _ := os.Stat(path); info.Size() > 300 {
+		t.Errorf("current file is %d bytes, over the limit", info.Size())
+	}
+
+	reopened, err := NewPersistent(10, path)
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	defer reopened.Close()
+	got := reopened.Query(Filter{})
+	if len(got) == 0 || got[len(got)-1].Message != "msg 9" {
+		t.Fatalf("expected the newest entry recovered, got %+v", got)
+	}
+	for i := 1; i < len(got); i++ {
+		if got[i].Time.Before(got[i-1].Time) {
+			t.Errorf("entries out of order: %+v", got)
+		}
+	}
+}
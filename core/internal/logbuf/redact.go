@@ -0,0 +1,95 @@
+package logbuf
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// redacted replaces secret values.
+const redacted = "[REDACTED]"
+
+// minSecretLen is the shortest configured secret that is masked literally;
+// shorter values would mask too much unrelated text.
+const minSecretLen = 6
+
+// builtinPatterns match common credential formats. Patterns with a capture
+// group keep the group (e.g. the "api_key=" prefix) and mask the rest.
+var builtinPatterns = []string{
+	`sk-[A-Za-z0-9_\-]{16,}`,                  // OpenAI / Anthropic keys
+	`xox[abprs]-[A-Za-z0-9\-]{10,}`,           // Slack tokens
+	`gh[pousr]_[A-Za-z0-9]{30,}`,              // GitHub tokens
+	`AKIA[0-9A-Z]{16}`,                        // AWS access key IDs
+	`\b[0-9]{8,10}:[A-Za-z0-9_\-]{35}\b`,      // Telegram bot tokens
+	`(?i)(bearer\s+)[A-Za-z0-9._~+/\-]{8,}=*`, // Authorization headers
+	`(?i)((?:api[_-]?key|token|secret|password)["']?\s*[:=]\s*["']?)[^\s"',\\]{6,}`,
+}
+
+// Redactor masks secrets in log text: values matching the built-in and
+// configured patterns, plus literal secrets such as the hive's own keys.
+type Redactor struct {
+	patterns []*regexp.Regexp
+	any      *regexp.Regexp // union of patterns, so clean text is scanned once
+	secrets  []string
+}
+
+var defaultRedactor = mustRedactor(NewRedactor(nil, nil))
+
+// NewRedactor creates a Redactor from the built-in patterns plus extra
+// regular expressions, masking the given secrets literally as well.
+func NewRedactor(extraPatterns, secrets []string) (*Redactor, error) {
+	r := &Redactor{}
+	sources := append(builtinPatterns[:len(builtinPatterns):len(builtinPatterns)], extraPatterns...)
+	alts := make([]string, len(sources))
+	for i, src := range sources {
+		re, err := regexp.Compile(src)
+		if err != nil {
+			return nil, fmt.Errorf("logbuf: redact pattern %q: %w", src, err)
+		}
+		r.patterns = append(r.patterns, re)
+		alts[i] = "(?:" + src + ")"
+	}
+	r.any = regexp.MustCompile(strings.Join(alts, "|"))
+
+	for _, s := range secrets {
+		if len(s) >= minSecretLen {
+			r.secrets = append(r.secrets, s)
+		}
+	}
+	return r, nil
+}
+
+func mustRedactor(r *Redactor, err error) *Redactor {
+	if err != nil {
+		panic(err)
+	}
+	return r
+}
+
+// Redact masks secrets in s.
+func (r *Redactor) Redact(s string) string {
+	if len(s) < minSecretLen {
+		return s
+	}
+	for _, secret := range r.secrets {
+		if strings.Contains(s, secret) {
+			s = strings.ReplaceAll(s, secret, redacted)
+		}
+	}
+	if !r.any.MatchString(s) {
+		return s
+	}
+	for _, re := range r.patterns {
+		if re.NumSubexp() > 0 {
+			s = re.ReplaceAllString(s, "${1}"+redacted)
+		} else {
+			s = re.ReplaceAllLiteralString(s, redacted)
+		}
+	}
+	return s
+}
+
+// Redact masks secrets in s using the built-in patterns.
+func Redact(s string) string {
+	return defaultRedactor.Redact(s)
+}
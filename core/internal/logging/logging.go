@@ -0,0 +1,57 @@
+// Package logging builds the daemon's log output: a JSON or text slog
+// handler writing to stdout or a size-rotated file.
+package logging
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"time"
+)
+
+// Defaults for Options.
+const DefaultMaxSizeMB = 100
+
+// Options selects the log format and destination.
+type Options struct {
+	Format     string // "json" (default) or "text"
+	File       string // log file path; empty writes to stdout
+	MaxSizeMB  int    // rotate the file at this size (default DefaultMaxSizeMB)
+	MaxBackups int    // rotated files kept; 0 keeps all
+	MaxAgeDays int    // rotated files older than this are removed; 0 keeps all
+	Level      slog.Leveler
+}
+
+// NewHandler returns a handler for opts and the writer behind it. The
+// closer is the log file when one is open, otherwise a no-op.
+func NewHandler(opts Options) (slog.Handler, io.Closer, error) {
+	var w io.Writer = os.Stdout
+	var closer io.Closer = nopCloser{}
+	if opts.File != "" {
+		maxSize := opts.MaxSizeMB
+		if maxSize <= 0 {
+			maxSize = DefaultMaxSizeMB
+		}
+		f, err := OpenRotating(opts.File, int64(maxSize)<<20, opts.MaxBackups, time.Duration(opts.MaxAgeDays)*24*time.Hour)
+		if err != nil {
+			return nil, nil, err
+		}
+		w, closer = f, f
+	}
+
+	hopts := &slog.HandlerOptions{Level: opts.Level}
+	switch opts.Format {
+	case "", "json":
+		return slog.NewJSONHandler(w, hopts), closer, nil
+	case "text":
+		return slog.NewTextHandler(w, hopts), closer, nil
+	default:
+		closer.Close()
+		return nil, nil, fmt.Errorf("logging: unknown format %q", opts.Format)
+	}
+}
+
+type nopCloser struct{}
+
+func (nopCloser) Close() error { return nil }
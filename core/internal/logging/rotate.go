@@ -0,0 +1,152 @@
+package logging
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// backupTimeFormat names rotated files so they sort by age.
+const backupTimeFormat = "20060102T150405.000"
+
+// RotatingFile is an io.Writer that appends to a file and moves it aside
+// once it reaches MaxSize, as name-<timestamp>.ext next to the original.
+// Old backups are pruned by count and age after each rotation.
+type RotatingFile struct {
+	path       string
+	maxSize    int64         // rotate before a write would exceed this; <= 0 never rotates
+	maxBackups int           // backups kept; <= 0 keeps all
+	maxAge     time.Duration // backups older than this are removed; <= 0 keeps all
+
+	mu   sync.Mutex
+	file *os.File
+	size int64
+	now  func() time.Time
+}
+
+// OpenRotating opens path for appending, creating it and its directory if
+// needed.
+func OpenRotating(path string, maxSize int64, maxBackups int, maxAge time.Duration) (*RotatingFile, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("logging: create log dir: %w", err)
+	}
+	r := &RotatingFile{path: path, maxSize: maxSize, maxBackups: maxBackups, maxAge: maxAge, now: time.Now}
+	if err := r.open(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *RotatingFile) open() error {
+	f, err := os.OpenFile(r.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("logging: open log file: %w", err)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return fmt.Errorf("logging: stat log file: %w", err)
+	}
+	r.file, r.size = f, info.Size()
+	return nil
+}
+
+// Write appends p, rotating first if p would take the file past its
+// maximum size. A single write larger than the maximum still goes to one
+// file.
+func (r *RotatingFile) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.file == nil {
+		return 0, os.ErrClosed
+	}
+	if r.maxSize > 0 && r.size > 0 && r.size+int64(len(p)) > r.maxSize {
+		if err := r.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := r.file.Write(p)
+	r.size += int64(n)
+	return n, err
+}
+
+// Close closes the current file. Later writes fail.
+func (r *RotatingFile) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.file == nil {
+		return nil
+	}
+	err := r.file.Close()
+	r.file = nil
+	return err
+}
+
+// rotate moves the current file aside and starts a new one. Callers hold r.mu.
+func (r *RotatingFile) rotate() error {
+	if err := r.file.Close(); err != nil {
+		return fmt.Errorf("logging: close log file: %w", err)
+	}
+	r.file = nil
+	if err := os.Rename(r.path, r.backupName(r.now())); err != nil {
+		return fmt.Errorf("logging: rotate log file: %w", err)
+	}
+	if err := r.open(); err != nil {
+		return err
+	}
+	r.prune()
+	return nil
+}
+
+func (r *RotatingFile) backupName(t time.Time) string {
+	ext := filepath.Ext(r.path)
+	return strings.TrimSuffix(r.path, ext) + "-" + t.UTC().Format(backupTimeFormat) + ext
+}
+
+// prune removes backups beyond maxBackups and older than maxAge. Errors
+// are ignored; a leftover backup is harmless.
+func (r *RotatingFile) prune() {
+	if r.maxBackups <= 0 && r.maxAge <= 0 {
+		return
+	}
+	backups := r.backups()
+	cutoff := r.now().Add(-r.maxAge)
+	for i, b := range backups { // newest first
+		if (r.maxBackups > 0 && i >= r.maxBackups) || (r.maxAge > 0 && b.time.Before(cutoff)) {
+			os.Remove(b.path)
+		}
+	}
+}
+
+type backup struct {
+	path string
+	time time.Time
+}
+
+// backups lists this file's rotated copies, newest first.
+func (r *RotatingFile) backups() []backup {
+	ext := filepath.Ext(r.path)
+	prefix := filepath.Base(strings.TrimSuffix(r.path, ext)) + "-"
+	entries, err := os.ReadDir(filepath.Dir(r.path))
+	if err != nil {
+		return nil
+	}
+	var out []backup
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasPrefix(name, prefix) || !strings.HasSuffix(name, ext) {
+			continue
+		}
+		t, err := time.Parse(backupTimeFormat, strings.TrimSuffix(strings.TrimPrefix(name, prefix), ext))
+		if err != nil {
+			continue
+		}
+		out = append(out, backup{path: filepath.Join(filepath.Dir(r.path), name), time: t})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].time.After(out[j].time) })
+	return out
+}
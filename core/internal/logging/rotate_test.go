@@ -0,0 +1,86 @@
+package logging
+
+import (
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRotatingFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "h1v3d.log")
+	r, err := OpenRotating(path, 10, 2, 0)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	defer r.Close()
+	clock := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	r.now = func() time.Time { clock = clock.Add(time.Second); return clock }
+
+	for _, line := range []string{"aaaaaaaa\n", "bbbbbbbb\n", "cccccccc\n", "dddddddd\n"} {
+		if _, err := r.Write([]byte(line)); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+	}
+
+	if data, _ := os.ReadFile(path); string(data) != "dddddddd\n" {
+		t.Errorf("expected current file to hold the last line, got %q", data)
+	}
+	backups := r.backups()
+	if len(backups) != 2 {
+		t.Fatalf("expected 2 backups kept, got %d", len(backups))
+	}
+	if data, _ := os.ReadFile(backups[0].path); string(data) != "cccccccc\n" {
+		t.Errorf("expected newest backup to hold the previous line, got %q", data)
+	}
+	if !strings.HasPrefix(filepath.Base(backups[0].path), "h1v3d-20260102T") {
+		t.Errorf("unexpected backup name %s", backups[0].path)
+	}
+}
+
+func TestRotatingFile_MaxAge(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "h1v3d.log")
+	r, err := OpenRotating(path, 4, 0, 24*time.Hour)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	defer r.Close()
+	clock := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	r.now = func() time.Time { return clock }
+
+	r.Write([]byte("old\n"))
+	r.Write([]byte("mid\n")) // rotates "old" on day 1
+	clock = clock.Add(3 * 24 * time.Hour)
+	r.Write([]byte("new\n")) // rotates "mid" on day 4 and prunes day 1
+
+	backups := r.backups()
+	if len(backups) != 1 {
+		t.Fatalf("expected the stale backup pruned, got %d backups", len(backups))
+	}
+	if data, _ := os.ReadFile(backups[0].path); string(data) != "mid\n" {
+		t.Errorf("expected the recent backup kept, got %q", data)
+	}
+}
+
+func TestNewHandler(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "logs", "h1v3d.log")
+	h, closer, err := NewHandler(Options{Format: "text", File: path})
+	if err != nil {
+		t.Fatalf("new handler: %v", err)
+	}
+	slog.New(h).Info("hello", "agent", "coder")
+	closer.Close()
+
+	data, _ := os.ReadFile(path)
+	if !strings.Contains(string(data), "msg=hello agent=coder") {
+		t.Errorf("expected text output in the log file, got %q", data)
+	}
+
+	if _, _, err := NewHandler(Options{Format: "xml"}); err == nil {
+		t.Error("expected error for unknown format")
+	}
+}
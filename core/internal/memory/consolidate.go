@@ -41,6 +41,7 @@ func (c *Consolidator) Consolidate(ctx context.Context, ticket *protocol.Ticket,
 		}
 	}
 
+	temperature := 0.2
 	req := protocol.ChatRequest{
 		Model: c.Model,
 		Messages: []protocol.ChatMessage{
@@ -65,7 +66,7 @@ Do not include trivial or transient information. Focus on reusable knowledge.`,
 			},
 		},
 		MaxTokens:   512,
-		Temperature: 0.2,
+		Temperature: &temperature,
 	}
 
 	resp, err := c.Provider.Chat(ctx, req)
@@ -84,11 +85,7 @@ Do not include trivial or transient information. Focus on reusable knowledge.`,
 		if newContent == "" {
 			continue
 		}
-		existing := store.Get(scope)
-		if existing != "" {
-			newContent = existing + "\n\n" + newContent
-		}
-		if err := store.Set(scope, newContent); err != nil {
+		if err := store.Append(scope, newContent); err != nil {
 			return fmt.Errorf("consolidate: update scope %q: %w", scope, err)
 		}
 	}
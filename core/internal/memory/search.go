@@ -0,0 +1,77 @@
+package memory
+
+import (
+	"sort"
+	"strings"
+)
+
+const (
+	// MaxHits bounds how many scopes Search returns.
+	MaxHits = 20
+	// snippetRadius is how many bytes of context a snippet keeps on each
+	// side of the match.
+	snippetRadius = 80
+)
+
+// Hit is one scope matched by Search.
+type Hit struct {
+	Scope   string
+	Snippet string // the content around the first match, on one line
+}
+
+// Search returns the scopes whose name or content contains every word of
+// query, ignoring case, sorted by scope name and capped at MaxHits. Expired
+// scopes are skipped, as in List.
+func (s *Store) Search(query string) []Hit {
+	words := strings.Fields(strings.ToLower(query))
+	if len(words) == 0 {
+		return nil
+	}
+
+	var hits []Hit
+	for scope, content := range s.List() {
+		name, lower := strings.ToLower(scope), strings.ToLower(content)
+		first := -1
+		matched := true
+		for _, w := range words {
+			i := strings.Index(lower, w)
+			if i < 0 && !strings.Contains(name, w) {
+				matched = false
+				break
+			}
+			if i >= 0 && (first < 0 || i < first) {
+				first = i
+			}
+		}
+		if matched {
+			hits = append(hits, Hit{Scope: scope, Snippet: snippet(content, max(first, 0))})
+		}
+	}
+	sort.Slice(hits, func(i, j int) bool { return hits[i].Scope < hits[j].Scope })
+	if len(hits) > MaxHits {
+		hits = hits[:MaxHits]
+	}
+	return hits
+}
+
+// snippet cuts content down to snippetRadius bytes either side of at, on
+// rune boundaries, with whitespace collapsed.
+func snippet(content string, at int) string {
+	start, end := max(at-snippetRadius, 0), min(at+snippetRadius, len(content))
+	for start > 0 && !isRuneStart(content[start]) {
+		start--
+	}
+	for end < len(content) && !isRuneStart(content[end]) {
+		end++
+	}
+	out := strings.Join(strings.Fields(content[start:end]), " ")
+	if start > 0 {
+		out = "…" + out
+	}
+	if end < len(content) {
+		out += "…"
+	}
+	return out
+}
+
+func isRuneStart(b byte) bool { return b&0xC0 != 0x80 }
@@ -0,0 +1,50 @@
+package memory
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestSearch(t *testing.T) {
+	s := NewStore(t.TempDir())
+	s.Set("deployment", "Use blue/green.")
+	s.Set("project", strings.Repeat("filler ", 40)+"We DEPLOY with\nArgo CD on Fridays."+strings.Repeat(" more", 40))
+	s.Set("team", "Alice owns billing.")
+
+	hits := s.Search("deploy")
+	if len(hits) != 2 || hits[0].Scope != "deployment" || hits[1].Scope != "project" {
+		t.Fatalf("expected deployment and project, got %+v", hits)
+	}
+	// A name-only match snippets the start of the content.
+	if hits[0].Snippet != "Use blue/green." {
+		t.Errorf("name match snippet = %q", hits[0].Snippet)
+	}
+	if s := hits[1].Snippet; !strings.Contains(s, "We DEPLOY with Argo CD") || !strings.HasPrefix(s, "…") || !strings.HasSuffix(s, "…") {
+		t.Errorf("value match snippet = %q", s)
+	}
+
+	// Every word must match, in the name or the content.
+	if hits := s.Search("argo fridays"); len(hits) != 1 || hits[0].Scope != "project" {
+		t.Errorf("multi-word search = %+v", hits)
+	}
+	if hits := s.Search("team billing"); len(hits) != 1 || hits[0].Scope != "team" {
+		t.Errorf("name and content search = %+v", hits)
+	}
+	if hits := s.Search("argo billing"); len(hits) != 0 {
+		t.Errorf("expected no hits, got %+v", hits)
+	}
+	if hits := s.Search("  "); hits != nil {
+		t.Errorf("expected no hits for an empty query, got %+v", hits)
+	}
+}
+
+func TestSearch_Bounded(t *testing.T) {
+	s := NewStore(t.TempDir())
+	for i := range MaxHits + 5 {
+		s.Set(fmt.Sprintf("note-%02d", i), "same")
+	}
+	if hits := s.Search("same"); len(hits) != MaxHits || hits[0].Scope != "note-00" {
+		t.Errorf("expected the first %d scopes, got %d", MaxHits, len(hits))
+	}
+}
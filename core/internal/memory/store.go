@@ -1,50 +1,140 @@
 package memory
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
 	"strings"
 	"sync"
+	"time"
 )
 
+// ErrInvalidScope is returned for scope names that are not safe file names.
+var ErrInvalidScope = errors.New("invalid memory scope name")
+
+var scopeRe = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]{0,63}$`)
+
+// ValidScope reports whether scope can be used as a memory scope name:
+// letters, digits, '_', '-' and '.', not starting with a dot.
+func ValidScope(scope string) bool {
+	return scopeRe.MatchString(scope)
+}
+
+// expiryFile holds the expiry time of scopes written with a TTL, keyed by
+// scope. Its leading dot keeps it from being read as a scope.
+const expiryFile = ".expiry.json"
+
 // Store provides scoped persistent memory backed by .md files.
-// Each scope maps to a file at {dir}/memory/{scope}.md.
+// Each scope maps to a file at {dir}/memory/{scope}.md. Scopes written with
+// SetWithTTL expire: once past their deadline they are no longer returned
+// and are deleted the next time they are read.
 type Store struct {
-	dir    string // base agent directory (memory files live in {dir}/memory/)
-	mu     sync.RWMutex
-	scopes map[string]string // scope_name → content
+	dir     string // base agent directory (memory files live in {dir}/memory/)
+	mu      sync.RWMutex
+	scopes  map[string]string    // scope_name → content
+	expires map[string]time.Time // scope_name → expiry, for scopes with a TTL
+	now     func() time.Time
 }
 
 // NewStore creates a memory store and loads all existing .md files from {dir}/memory/.
 // If the directory doesn't exist yet, it will be created on the first Set call.
 func NewStore(dir string) *Store {
 	s := &Store{
-		dir:    dir,
-		scopes: make(map[string]string),
+		dir:     dir,
+		scopes:  make(map[string]string),
+		expires: make(map[string]time.Time),
+		now:     time.Now,
 	}
 	s.load()
 	return s
 }
 
-// Get returns the content of a scope, or empty string if it doesn't exist.
+// Get returns the content of a scope, or empty string if it doesn't exist
+// or has expired.
 func (s *Store) Get(scope string) string {
 	s.mu.RLock()
-	defer s.mu.RUnlock()
-	return s.scopes[scope]
+	content, expired := s.scopes[scope], s.expired(scope)
+	s.mu.RUnlock()
+	if expired {
+		s.purge([]string{scope})
+		return ""
+	}
+	return content
 }
 
-// Set writes content to a scope and persists it to disk.
+// Set writes content to a scope and persists it to disk. The scope no
+// longer expires.
 func (s *Store) Set(scope, content string) error {
+	return s.SetWithTTL(scope, content, 0)
+}
+
+// SetWithTTL writes content to a scope that expires after ttl. A ttl of
+// zero or less keeps it until deleted, like Set.
+func (s *Store) SetWithTTL(scope, content string, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.write(scope, content); err != nil {
+		return err
+	}
+	_, had := s.expires[scope]
+	if ttl <= 0 && !had {
+		return nil
+	}
+	if ttl > 0 {
+		s.expires[scope] = s.now().Add(ttl)
+	} else {
+		delete(s.expires, scope)
+	}
+	return s.writeExpiry()
+}
+
+// Append adds content to the end of a scope, separated from existing content
+// by a blank line. The read and write happen under one lock, so concurrent
+// appends and sets (from tools, consolidation or the API) are never lost.
+// A scope with a TTL keeps its expiry; an expired one starts afresh.
+func (s *Store) Append(scope, content string) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
+	if s.expired(scope) {
+		s.remove(scope)
+	}
+	if existing := s.scopes[scope]; existing != "" {
+		content = existing + "\n\n" + content
+	}
+	return s.write(scope, content)
+}
 
+// write persists a scope via a temp file and rename, so readers of the file
+// never see partial content. Callers must hold s.mu.
+func (s *Store) write(scope, content string) error {
+	if !ValidScope(scope) {
+		return fmt.Errorf("%w: %q", ErrInvalidScope, scope)
+	}
 	memDir := filepath.Join(s.dir, "memory")
 	if err := os.MkdirAll(memDir, 0o755); err != nil {
 		return err
 	}
 
-	path := filepath.Join(memDir, scope+".md")
-	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+	tmp, err := os.CreateTemp(memDir, "."+scope+"-*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) // no-op once renamed
+	if _, err := tmp.WriteString(content); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(0o644); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), filepath.Join(memDir, scope+".md")); err != nil {
 		return err
 	}
 
@@ -52,32 +142,112 @@ func (s *Store) Set(scope, content string) error {
 	return nil
 }
 
-// List returns a copy of all scopes and their content.
+// List returns a copy of all unexpired scopes and their content.
 func (s *Store) List() map[string]string {
 	s.mu.RLock()
-	defer s.mu.RUnlock()
-
 	out := make(map[string]string, len(s.scopes))
+	var expired []string
 	for k, v := range s.scopes {
+		if s.expired(k) {
+			expired = append(expired, k)
+			continue
+		}
 		out[k] = v
 	}
+	s.mu.RUnlock()
+
+	if len(expired) > 0 {
+		s.purge(expired)
+	}
 	return out
 }
 
+// Export returns copies of the unexpired scopes and of the expiry times of
+// those written with a TTL. Unlike List it never purges expired scopes, so
+// it is safe for a reader that must not change the directory, such as a
+// backup taken while the daemon runs.
+func (s *Store) Export() (scopes map[string]string, expires map[string]time.Time) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	scopes = make(map[string]string, len(s.scopes))
+	expires = make(map[string]time.Time)
+	for k, v := range s.scopes {
+		if s.expired(k) {
+			continue
+		}
+		scopes[k] = v
+		if at, ok := s.expires[k]; ok {
+			expires[k] = at
+		}
+	}
+	return scopes, expires
+}
+
+// expired reports whether scope has a TTL that has run out. Callers must
+// hold s.mu.
+func (s *Store) expired(scope string) bool {
+	at, ok := s.expires[scope]
+	return ok && !s.now().Before(at)
+}
+
+// purge deletes the given scopes that are still expired; one may have been
+// rewritten since the caller looked.
+func (s *Store) purge(scopes []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, scope := range scopes {
+		if s.expired(scope) {
+			s.remove(scope)
+		}
+	}
+}
+
 // Delete removes a scope from memory and disk.
 func (s *Store) Delete(scope string) error {
+	if !ValidScope(scope) {
+		return fmt.Errorf("%w: %q", ErrInvalidScope, scope)
+	}
 	s.mu.Lock()
 	defer s.mu.Unlock()
+	return s.remove(scope)
+}
 
+// remove deletes a scope's file and expiry. Callers must hold s.mu.
+func (s *Store) remove(scope string) error {
 	path := filepath.Join(s.dir, "memory", scope+".md")
 	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
 		return err
 	}
 
 	delete(s.scopes, scope)
+	if _, ok := s.expires[scope]; ok {
+		delete(s.expires, scope)
+		return s.writeExpiry()
+	}
 	return nil
 }
 
+// writeExpiry persists the expiry times, removing the file once none are
+// left. Callers must hold s.mu.
+func (s *Store) writeExpiry() error {
+	path := filepath.Join(s.dir, "memory", expiryFile)
+	if len(s.expires) == 0 {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+	data, err := json.MarshalIndent(s.expires, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
 // load reads all .md files from the memory directory into the scopes map.
 func (s *Store) load() {
 	memDir := filepath.Join(s.dir, "memory")
@@ -97,4 +267,16 @@ func (s *Store) load() {
 		scope := strings.TrimSuffix(e.Name(), ".md")
 		s.scopes[scope] = string(data)
 	}
+
+	// Expiry times of scopes whose file has since gone are dropped.
+	if data, err := os.ReadFile(filepath.Join(memDir, expiryFile)); err == nil {
+		var expires map[string]time.Time
+		if json.Unmarshal(data, &expires) == nil {
+			for scope, at := range expires {
+				if _, ok := s.scopes[scope]; ok {
+					s.expires[scope] = at
+				}
+			}
+		}
+	}
 }
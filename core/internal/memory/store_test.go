@@ -1,9 +1,14 @@
 package memory
 
 import (
+	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 )
 
 func TestNewStore_EmptyDir(t *testing.T) {
@@ -145,3 +150,123 @@ func TestListReturnsCopy(t *testing.T) {
 		t.Errorf("List returned reference instead of copy, Get = %q", got)
 	}
 }
+
+func TestInvalidScope(t *testing.T) {
+	s := NewStore(t.TempDir())
+	for _, scope := range []string{"", "../escape", "a/b", ".hidden"} {
+		if err := s.Set(scope, "x"); !errors.Is(err, ErrInvalidScope) {
+			t.Errorf("Set(%q) error = %v, want ErrInvalidScope", scope, err)
+		}
+	}
+	if err := s.Delete("../escape"); !errors.Is(err, ErrInvalidScope) {
+		t.Errorf("Delete error = %v, want ErrInvalidScope", err)
+	}
+}
+
+func TestAppend_Concurrent(t *testing.T) {
+	dir := t.TempDir()
+	s := NewStore(dir)
+
+	var wg sync.WaitGroup
+	for i := range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := s.Append("project", fmt.Sprintf("note %d", i)); err != nil {
+				t.Errorf("Append: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	got := s.Get("project")
+	if n := strings.Count(got, "note "); n != 20 {
+		t.Errorf("expected 20 notes, got %d", n)
+	}
+	data, _ := os.ReadFile(filepath.Join(dir, "memory", "project.md"))
+	if string(data) != got {
+		t.Error("file content differs from in-memory content")
+	}
+	entries, _ := os.ReadDir(filepath.Join(dir, "memory"))
+	if len(entries) != 1 {
+		t.Errorf("expected only project.md on disk, got %d entries", len(entries))
+	}
+}
+
+func TestSetWithTTL_Expires(t *testing.T) {
+	dir := t.TempDir()
+	s := NewStore(dir)
+	now := time.Now()
+	s.now = func() time.Time { return now }
+
+	if err := s.SetWithTTL("deploy", "freeze until Friday", time.Minute); err != nil {
+		t.Fatalf("SetWithTTL: %v", err)
+	}
+	s.Set("project", "kept")
+	if got := s.Get("deploy"); got != "freeze until Friday" {
+		t.Fatalf("before the deadline Get = %q", got)
+	}
+
+	// The expiry survives a reload.
+	reloaded := NewStore(dir)
+	reloaded.now = s.now
+	now = now.Add(time.Minute)
+	for name, st := range map[string]*Store{"store": s, "reloaded": reloaded} {
+		if scopes := st.List(); len(scopes) != 1 || scopes["project"] != "kept" {
+			t.Errorf("%s: after the deadline List = %v", name, scopes)
+		}
+		if got := st.Get("deploy"); got != "" {
+			t.Errorf("%s: after the deadline Get = %q", name, got)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, "memory", "deploy.md")); !os.IsNotExist(err) {
+		t.Errorf("expected the expired scope's file deleted, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "memory", expiryFile)); !os.IsNotExist(err) {
+		t.Errorf("expected the expiry file removed once empty, got %v", err)
+	}
+}
+
+func TestExport_KeepsExpiryAndDoesNotPurge(t *testing.T) {
+	dir := t.TempDir()
+	s := NewStore(dir)
+	now := time.Now()
+	s.now = func() time.Time { return now }
+
+	s.SetWithTTL("deploy", "freeze until Friday", time.Minute)
+	s.SetWithTTL("stale", "old news", time.Second)
+	s.Set("project", "kept")
+	now = now.Add(2 * time.Second)
+
+	scopes, expires := s.Export()
+	if len(scopes) != 2 || scopes["deploy"] == "" || scopes["project"] != "kept" {
+		t.Errorf("Export scopes = %v", scopes)
+	}
+	if len(expires) != 1 || !expires["deploy"].Equal(now.Add(-2*time.Second).Add(time.Minute)) {
+		t.Errorf("Export expires = %v", expires)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "memory", "stale.md")); err != nil {
+		t.Errorf("Export must not delete expired scopes: %v", err)
+	}
+}
+
+func TestSetWithTTL_SetClearsExpiry(t *testing.T) {
+	dir := t.TempDir()
+	s := NewStore(dir)
+	now := time.Now()
+	s.now = func() time.Time { return now }
+
+	s.SetWithTTL("notes", "temporary", time.Second)
+	s.Append("notes", "more")
+	now = now.Add(2 * time.Second)
+	if got := s.Get("notes"); got != "" {
+		t.Errorf("expected Append to keep the expiry, got %q", got)
+	}
+
+	s.SetWithTTL("notes", "temporary", time.Second)
+	s.Set("notes", "permanent")
+	now = now.Add(time.Hour)
+	if got := NewStore(dir).Get("notes"); got != "permanent" {
+		t.Errorf("expected Set to clear the expiry, got %q", got)
+	}
+}
@@ -0,0 +1,187 @@
+package memory
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+// vectorFile holds the note embeddings of a VectorStore, keyed by scope.
+const vectorFile = ".vectors.json"
+
+// embedBatch bounds how many notes are embedded in one call.
+const embedBatch = 64
+
+// EmbedFunc turns texts into embedding vectors, one per text and in order,
+// e.g. provider.Embedder.Embed.
+type EmbedFunc func(ctx context.Context, texts []string) ([][]float32, error)
+
+// Match is one scope returned by VectorStore.Search.
+type Match struct {
+	Scope   string
+	Score   float64 // cosine similarity to the query, at most 1
+	Snippet string  // the start of the content, on one line
+}
+
+// VectorStore keeps an embedding of every note in a Store, in a file next
+// to the notes, for semantic search. Embeddings are brought up to date
+// lazily by Search: notes that are new or changed since they were embedded
+// are embedded then, and those of deleted notes are dropped.
+type VectorStore struct {
+	store *Store
+	embed EmbedFunc
+
+	mu      sync.Mutex
+	entries map[string]vectorEntry // scope → embedding
+}
+
+type vectorEntry struct {
+	Hash   string    `json:"hash"` // of the content embedded
+	Vector []float32 `json:"vector"`
+}
+
+// NewVectorStore returns a VectorStore over store's notes, loading the
+// embeddings saved by an earlier one.
+func NewVectorStore(store *Store, embed EmbedFunc) *VectorStore {
+	v := &VectorStore{store: store, embed: embed, entries: make(map[string]vectorEntry)}
+	if data, err := os.ReadFile(v.path()); err == nil {
+		json.Unmarshal(data, &v.entries)
+	}
+	return v
+}
+
+func (v *VectorStore) path() string {
+	return filepath.Join(v.store.dir, "memory", vectorFile)
+}
+
+// Search returns up to k notes most similar in meaning to query, best
+// first.
+func (v *VectorStore) Search(ctx context.Context, query string, k int) ([]Match, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	q, err := v.embed(ctx, []string{query})
+	if err != nil {
+		return nil, fmt.Errorf("embed query: %w", err)
+	}
+	if len(q) != 1 || len(q[0]) == 0 {
+		return nil, fmt.Errorf("embed query: no vector returned")
+	}
+	notes := v.store.List()
+	if err := v.refresh(ctx, notes, len(q[0])); err != nil {
+		return nil, err
+	}
+
+	matches := make([]Match, 0, len(notes))
+	for scope, content := range notes {
+		matches = append(matches, Match{
+			Scope:   scope,
+			Score:   cosine(q[0], v.entries[scope].Vector),
+			Snippet: snippet(content, 0),
+		})
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].Score != matches[j].Score {
+			return matches[i].Score > matches[j].Score
+		}
+		return matches[i].Scope < matches[j].Scope
+	})
+	if len(matches) > k {
+		matches = matches[:k]
+	}
+	return matches, nil
+}
+
+// refresh embeds the notes that have no current embedding of dimension
+// dim, drops the embeddings of notes that are gone, and saves the result.
+// Callers must hold v.mu.
+func (v *VectorStore) refresh(ctx context.Context, notes map[string]string, dim int) error {
+	changed := false
+	for scope := range v.entries {
+		if _, ok := notes[scope]; !ok {
+			delete(v.entries, scope)
+			changed = true
+		}
+	}
+
+	var stale []string
+	for scope, content := range notes {
+		e, ok := v.entries[scope]
+		if !ok || e.Hash != contentHash(content) || len(e.Vector) != dim {
+			stale = append(stale, scope)
+		}
+	}
+	sort.Strings(stale)
+	for len(stale) > 0 {
+		batch := stale[:min(embedBatch, len(stale))]
+		stale = stale[len(batch):]
+		texts := make([]string, len(batch))
+		for i, scope := range batch {
+			// The name often says what a note is about, so it is embedded too.
+			texts[i] = scope + "\n\n" + notes[scope]
+		}
+		vectors, err := v.embed(ctx, texts)
+		if err != nil {
+			return fmt.Errorf("embed notes: %w", err)
+		}
+		if len(vectors) != len(batch) {
+			return fmt.Errorf("embed notes: got %d vectors for %d notes", len(vectors), len(batch))
+		}
+		for i, scope := range batch {
+			v.entries[scope] = vectorEntry{Hash: contentHash(notes[scope]), Vector: vectors[i]}
+		}
+		changed = true
+	}
+
+	if !changed {
+		return nil
+	}
+	return v.save()
+}
+
+// save writes the embeddings via a temp file and rename. Callers must hold
+// v.mu.
+func (v *VectorStore) save() error {
+	if err := os.MkdirAll(filepath.Dir(v.path()), 0o755); err != nil {
+		return err
+	}
+	data, err := json.Marshal(v.entries)
+	if err != nil {
+		return err
+	}
+	tmp := v.path() + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, v.path())
+}
+
+func contentHash(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
+
+// cosine returns the cosine similarity of a and b, or 0 if they differ in
+// length or either is zero.
+func cosine(a, b []float32) float64 {
+	if len(a) != len(b) {
+		return 0
+	}
+	var dot, na, nb float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		na += float64(a[i]) * float64(a[i])
+		nb += float64(b[i]) * float64(b[i])
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(na) * math.Sqrt(nb))
+}
@@ -0,0 +1,80 @@
+package memory
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+// wordEmbedder embeds a text as counts of a few fixed words and records
+// every text it is asked to embed.
+type wordEmbedder struct {
+	words []string
+	seen  []string
+}
+
+func (e *wordEmbedder) embed(_ context.Context, texts []string) ([][]float32, error) {
+	out := make([][]float32, len(texts))
+	for i, text := range texts {
+		e.seen = append(e.seen, text)
+		v := make([]float32, len(e.words)+1)
+		v[len(e.words)] = 0.1 // so no text embeds to zero
+		for j, w := range e.words {
+			v[j] = float32(strings.Count(strings.ToLower(text), w))
+		}
+		out[i] = v
+	}
+	return out, nil
+}
+
+func TestVectorStore_Search(t *testing.T) {
+	dir := t.TempDir()
+	s := NewStore(dir)
+	s.Set("deployment", "Releases go out with Argo CD on Fridays.")
+	s.Set("team", "Alice owns billing and invoices.")
+	s.Set("stack", "Go services on Kubernetes, deployed by Argo.")
+
+	e := &wordEmbedder{words: []string{"argo", "billing", "invoice"}}
+	v := NewVectorStore(s, e.embed)
+	matches, err := v.Search(context.Background(), "who handles invoices and billing?", 2)
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	if len(matches) != 2 || matches[0].Scope != "team" {
+		t.Fatalf("expected team first of 2, got %+v", matches)
+	}
+	if matches[0].Score <= matches[1].Score || matches[0].Snippet != "Alice owns billing and invoices." {
+		t.Errorf("unexpected best match %+v", matches[0])
+	}
+	if len(e.seen) != 4 { // the query and three notes
+		t.Fatalf("expected 4 texts embedded, got %q", e.seen)
+	}
+
+	// Only the changed note is embedded again, and a deleted one is dropped,
+	// also by a store loaded from disk.
+	s.Set("team", "Bob owns billing now.")
+	s.Delete("stack")
+	e.seen = nil
+	v = NewVectorStore(s, e.embed)
+	matches, err = v.Search(context.Background(), "argo", 5)
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	if len(e.seen) != 2 || e.seen[1] != "team\n\nBob owns billing now." {
+		t.Errorf("expected the query and team embedded, got %q", e.seen)
+	}
+	if len(matches) != 2 || matches[0].Scope != "deployment" {
+		t.Errorf("expected deployment then team, got %+v", matches)
+	}
+	if _, ok := v.entries["stack"]; ok {
+		t.Error("expected the deleted note's embedding dropped")
+	}
+}
+
+func TestVectorStore_Empty(t *testing.T) {
+	e := &wordEmbedder{words: []string{"argo"}}
+	matches, err := NewVectorStore(NewStore(t.TempDir()), e.embed).Search(context.Background(), "argo", 5)
+	if err != nil || len(matches) != 0 {
+		t.Errorf("expected no matches, got %+v, %v", matches, err)
+	}
+}
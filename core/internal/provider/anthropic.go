@@ -3,10 +3,13 @@ package provider
 import (
 	"bytes"
 	"context"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/h1v3-io/h1v3/pkg/protocol"
@@ -14,14 +17,28 @@ import (
 
 const anthropicAPIVersion = "2023-06-01"
 
+// jsonOutputTool is the tool a requested JSON reply is given through: its
+// input schema is the response format's, so the API returns the reply as a
+// schema-shaped tool input rather than free text.
+const jsonOutputTool = "json_output"
+
 // AnthropicProvider implements Provider for the Anthropic Messages API.
 type AnthropicProvider struct {
-	client  *http.Client
-	baseURL string
-	apiKey  string
-	model   string
+	client     *http.Client
+	baseURL    string
+	apiKey     string
+	model      string
+	maxTokens  int // used when a request does not set MaxTokens
+	requestLog RequestLogger
+	guard      capabilityGuard
+	retry      retryPolicy
+	cache      bool // mark the tools and system prompt cacheable
 }
 
+// defaultAnthropicMaxTokens is sent when neither the request nor
+// WithAnthropicMaxTokens sets a limit; the Messages API requires one.
+const defaultAnthropicMaxTokens = 4096
+
 // AnthropicOption configures an AnthropicProvider.
 type AnthropicOption func(*AnthropicProvider)
 
@@ -35,13 +52,71 @@ func WithAnthropicModel(model string) AnthropicOption {
 	return func(p *AnthropicProvider) { p.model = model }
 }
 
+// WithAnthropicHTTPClient sets a custom HTTP client, e.g. one with a proxy
+// or custom CA configured.
+func WithAnthropicHTTPClient(c *http.Client) AnthropicOption {
+	return func(p *AnthropicProvider) { p.client = c }
+}
+
+// WithAnthropicMaxTokens sets the max_tokens sent when a request does not
+// set MaxTokens (default 4096). Values <= 0 keep the default.
+func WithAnthropicMaxTokens(n int) AnthropicOption {
+	return func(p *AnthropicProvider) {
+		if n > 0 {
+			p.maxTokens = n
+		}
+	}
+}
+
+// WithAnthropicRequestLogger sets a function that receives every raw request
+// and response, e.g. SlogRequestLogger for verbose mode.
+func WithAnthropicRequestLogger(fn RequestLogger) AnthropicOption {
+	return func(p *AnthropicProvider) { p.requestLog = fn }
+}
+
+// WithAnthropicPromptCaching marks the tool definitions and the system prompt
+// with cache_control breakpoints, so repeated requests with the same prefix
+// are billed at the cached input rate. The prefix must be unchanged for a
+// cache hit, so it pays off across the calls of one agent turn and across
+// turns whose system prompt did not change.
+func WithAnthropicPromptCaching(on bool) AnthropicOption {
+	return func(p *AnthropicProvider) { p.cache = on }
+}
+
+// WithAnthropicCapabilities sets the model capabilities requests are adapted
+// to, replacing the built-in entry from LookupCapabilities.
+func WithAnthropicCapabilities(c Capabilities) AnthropicOption {
+	return func(p *AnthropicProvider) { p.guard.caps = &c }
+}
+
+// WithAnthropicMaxRetries sets how many times a request that failed with a
+// network error, 429, 502/503/504 or 529 is retried (default 2); 0
+// disables retrying.
+func WithAnthropicMaxRetries(n int) AnthropicOption {
+	return func(p *AnthropicProvider) { p.retry = p.retry.withMax(n) }
+}
+
+// WithAnthropicRetryBaseDelay sets the wait before the first retry (default
+// 1s). Each further retry waits about twice as long, unless the server
+// sends Retry-After.
+func WithAnthropicRetryBaseDelay(d time.Duration) AnthropicOption {
+	return func(p *AnthropicProvider) { p.retry.base = d }
+}
+
+// WithAnthropicLogger sets the logger for retries and capability
+// adaptations (default slog.Default()).
+func WithAnthropicLogger(l *slog.Logger) AnthropicOption {
+	return func(p *AnthropicProvider) { p.retry.logger, p.guard.logger = l, l }
+}
+
 // NewAnthropic creates a new Anthropic Messages API provider.
 func NewAnthropic(apiKey string, opts ...AnthropicOption) *AnthropicProvider {
 	p := &AnthropicProvider{
-		client:  &http.Client{Timeout: 120 * time.Second},
-		baseURL: "https://api.anthropic.com",
-		apiKey:  apiKey,
-		model:   "claude-sonnet-4-20250514",
+		client:    &http.Client{Timeout: 120 * time.Second},
+		baseURL:   "https://api.anthropic.com",
+		apiKey:    apiKey,
+		model:     "claude-sonnet-4-20250514",
+		maxTokens: defaultAnthropicMaxTokens,
 	}
 	for _, opt := range opts {
 		opt(p)
@@ -51,28 +126,111 @@ func NewAnthropic(apiKey string, opts ...AnthropicOption) *AnthropicProvider {
 
 func (p *AnthropicProvider) Name() string { return "anthropic" }
 
-func (p *AnthropicProvider) Chat(ctx context.Context, req protocol.ChatRequest) (*protocol.ChatResponse, error) {
+func (p *AnthropicProvider) Chat(ctx context.Context, req protocol.ChatRequest) (result *protocol.ChatResponse, err error) {
+	body, jsonReply, err := p.buildRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("anthropic: marshal: %w", err)
+	}
+
+	url := p.baseURL + "/v1/messages"
+	ex := Exchange{Provider: "anthropic", URL: url, Request: string(payload)}
+	start := time.Now()
+	defer func() {
+		ex.Duration = time.Since(start)
+		ex.Err = err
+		if result != nil {
+			ex.Usage = result.Usage
+		}
+		logExchange(p.requestLog, p.apiKey, ex)
+	}()
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return nil, fmt.Errorf("anthropic: create request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("x-api-key", p.apiKey)
+	httpReq.Header.Set("anthropic-version", anthropicAPIVersion)
+
+	resp, err := p.retry.do("anthropic", p.client, httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("anthropic: http request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	ex.Status, ex.Response = resp.StatusCode, string(respBody)
+	if err != nil {
+		return nil, fmt.Errorf("anthropic: read response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, &APIError{Provider: "anthropic", StatusCode: resp.StatusCode, Body: string(respBody)}
+	}
+
+	var anthResp anthropicResponse
+	if err := json.Unmarshal(respBody, &anthResp); err != nil {
+		return nil, fmt.Errorf("anthropic: unmarshal response: %w", err)
+	}
+
+	result, err = parseAnthropicResponse(&anthResp)
+	if err != nil {
+		return nil, err
+	}
+	if jsonReply {
+		takeJSONOutput(result)
+	}
+	result.Model = body.Model
+	return result, nil
+}
+
+// buildRequest adapts req to the model's capabilities and converts it to
+// the wire format. jsonReply reports whether a JSON reply was asked for.
+func (p *AnthropicProvider) buildRequest(req protocol.ChatRequest) (body anthropicRequest, jsonReply bool, err error) {
 	model := req.Model
 	if model == "" {
 		model = p.model
 	}
+	if req.MaxTokens <= 0 {
+		req.MaxTokens = p.maxTokens // Anthropic requires max_tokens
+	}
+	req, err = p.guard.adapt("anthropic", p.model, model, req)
+	if err != nil {
+		return anthropicRequest{}, false, err
+	}
 
 	// Convert protocol messages to Anthropic format
 	system, messages := toAnthropicMessages(req.Messages)
+	if req.ResponseFormat != nil {
+		// Anthropic has no JSON mode; ask for it in the system prompt and
+		// clean up the reply in Chat. jsonOutputTool, when the schema
+		// allows, makes the API enforce the shape.
+		// Agents with an output schema already carry the instruction.
+		if instr := JSONInstruction(req.ResponseFormat); !strings.Contains(system, instr) {
+			if system != "" {
+				system += "\n\n"
+			}
+			system += instr
+		}
+	}
+	outputTool := jsonOutputToolFor(req.ResponseFormat)
+	if outputTool != nil && len(req.Tools) > 0 {
+		system += " Give that object by calling the " + jsonOutputTool + " tool when you have your final answer."
+	}
 
-	body := anthropicRequest{
+	body = anthropicRequest{
 		Model:    model,
 		Messages: messages,
-		System:   system,
+		System:   anthropicSystem{Text: system, Cache: p.cache && system != ""},
 	}
 
-	if req.MaxTokens > 0 {
-		body.MaxTokens = req.MaxTokens
-	} else {
-		body.MaxTokens = 4096 // Anthropic requires max_tokens
-	}
-	if req.Temperature > 0 {
-		body.Temperature = &req.Temperature
+	body.MaxTokens = req.MaxTokens
+	if req.Temperature != nil {
+		body.Temperature = req.Temperature
 	}
 
 	// Convert tools to Anthropic format
@@ -85,52 +243,283 @@ func (p *AnthropicProvider) Chat(ctx context.Context, req protocol.ChatRequest)
 			})
 		}
 	}
+	if outputTool != nil {
+		// Forcing the tool would rule out the other tools, so it is only
+		// forced when it is the only one.
+		if len(body.Tools) == 0 {
+			body.ToolChoice = &anthropicToolChoice{Type: "tool", Name: jsonOutputTool}
+		}
+		body.Tools = append(body.Tools, *outputTool)
+	}
+	if p.cache && len(body.Tools) > 0 {
+		// Tools come before the system prompt in the cached prefix, so this
+		// breakpoint still hits when only the system prompt changed.
+		body.Tools[len(body.Tools)-1].CacheControl = &cacheControl{Type: "ephemeral"}
+	}
+	return body, req.ResponseFormat != nil, nil
+}
+
+// jsonOutputToolFor returns the jsonOutputTool definition for a response
+// format, or nil if there is none or its schema is not an object, which
+// tool inputs must be.
+func jsonOutputToolFor(f *protocol.ResponseFormat) *anthropicTool {
+	if f == nil {
+		return nil
+	}
+	schema := map[string]any{"type": "object"}
+	if f.Type == protocol.FormatJSONSchema && f.Schema != nil {
+		if f.Schema["type"] != "object" {
+			return nil
+		}
+		schema = f.Schema
+	}
+	return &anthropicTool{
+		Name:        jsonOutputTool,
+		Description: "Give your final answer as a JSON object",
+		InputSchema: schema,
+	}
+}
+
+// takeJSONOutput turns a jsonOutputTool call into the reply content, or
+// strips a code fence from a reply written as text instead.
+func takeJSONOutput(resp *protocol.ChatResponse) {
+	for i, tc := range resp.ToolCalls {
+		if tc.Name != jsonOutputTool {
+			continue
+		}
+		out, _ := json.Marshal(tc.Arguments)
+		resp.Content = string(out)
+		resp.ToolCalls = append(resp.ToolCalls[:i:i], resp.ToolCalls[i+1:]...)
+		if len(resp.ToolCalls) == 0 {
+			resp.ToolCalls = nil
+		}
+		return
+	}
+	resp.Content = stripCodeFence(resp.Content)
+}
 
+// ChatStream is Chat with the response streamed as it is generated: text
+// and tool input deltas arrive as chunks, followed by a Done chunk. A JSON
+// reply given through jsonOutputTool streams as content; one written as
+// text is streamed as written, code fence included.
+func (p *AnthropicProvider) ChatStream(ctx context.Context, req protocol.ChatRequest) (<-chan protocol.StreamChunk, error) {
+	body, _, err := p.buildRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	body.Stream = true
 	payload, err := json.Marshal(body)
 	if err != nil {
 		return nil, fmt.Errorf("anthropic: marshal: %w", err)
 	}
 
-	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, p.baseURL+"/v1/messages", bytes.NewReader(payload))
+	url := p.baseURL + "/v1/messages"
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload))
 	if err != nil {
 		return nil, fmt.Errorf("anthropic: create request: %w", err)
 	}
 	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Accept", "text/event-stream")
 	httpReq.Header.Set("x-api-key", p.apiKey)
 	httpReq.Header.Set("anthropic-version", anthropicAPIVersion)
 
-	resp, err := p.client.Do(httpReq)
-	if err != nil {
-		return nil, fmt.Errorf("anthropic: http request: %w", err)
+	s := &eventStream{
+		client: p.client,
+		req:    httpReq,
+		ex:     Exchange{Provider: "anthropic", URL: url, Request: string(payload)},
+		model:  body.Model,
+		log:    p.requestLog,
+		apiKey: p.apiKey,
+		prefix: "anthropic: ",
+		retry:  p.retry,
+		parse:  parseAnthropicStream,
 	}
-	defer resp.Body.Close()
+	if req.ResponseFormat != nil {
+		s.parse = func(r io.Reader, emit func(protocol.StreamChunk) bool, ex *Exchange) error {
+			return parseAnthropicStreamAs(r, emit, ex, jsonOutputTool)
+		}
+	}
+	return s.start(ctx)
+}
 
-	respBody, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("anthropic: read response: %w", err)
+// parseAnthropicStream turns Messages API stream events into stream chunks.
+// Tool calls are numbered in the order their blocks start, skipping text
+// blocks.
+func parseAnthropicStream(r io.Reader, emit func(protocol.StreamChunk) bool, ex *Exchange) error {
+	return parseAnthropicStreamAs(r, emit, ex, "")
+}
+
+// parseAnthropicStreamAs is parseAnthropicStream with the input of calls to
+// the tool named contentTool streamed as content instead of a tool call.
+func parseAnthropicStreamAs(r io.Reader, emit func(protocol.StreamChunk) bool, ex *Exchange, contentTool string) error {
+	toolIndex := map[int]int{} // content block index → tool call index
+	contentBlocks := map[int]bool{}
+	var stopped bool
+	var streamErr error
+	err := readSSE(r, func(event, data string) bool {
+		var ev anthropicStreamEvent
+		if err := json.Unmarshal([]byte(data), &ev); err != nil {
+			streamErr = fmt.Errorf("unmarshal %s event: %w", event, err)
+			return false
+		}
+		switch ev.Type {
+		case "message_start":
+			ex.Usage.PromptTokens = ev.Message.Usage.InputTokens
+			ex.Usage.CacheCreationTokens = ev.Message.Usage.CacheCreationInputTokens
+			ex.Usage.CacheReadTokens = ev.Message.Usage.CacheReadInputTokens
+		case "content_block_start":
+			if ev.ContentBlock.Type == "tool_use" && contentTool != "" && ev.ContentBlock.Name == contentTool {
+				contentBlocks[ev.Index] = true
+				return true
+			}
+			if ev.ContentBlock.Type == "tool_use" {
+				idx := len(toolIndex)
+				toolIndex[ev.Index] = idx
+				return emit(protocol.StreamChunk{ToolCallDelta: &protocol.ToolCallDelta{Index: idx, ID: ev.ContentBlock.ID, Name: ev.ContentBlock.Name}})
+			}
+			if ev.ContentBlock.Text != "" {
+				return emit(protocol.StreamChunk{ContentDelta: ev.ContentBlock.Text})
+			}
+		case "content_block_delta":
+			switch ev.Delta.Type {
+			case "text_delta":
+				return emit(protocol.StreamChunk{ContentDelta: ev.Delta.Text})
+			case "input_json_delta":
+				if contentBlocks[ev.Index] && ev.Delta.PartialJSON != "" {
+					return emit(protocol.StreamChunk{ContentDelta: ev.Delta.PartialJSON})
+				}
+				if idx, ok := toolIndex[ev.Index]; ok && ev.Delta.PartialJSON != "" {
+					return emit(protocol.StreamChunk{ToolCallDelta: &protocol.ToolCallDelta{Index: idx, ArgumentsDelta: ev.Delta.PartialJSON}})
+				}
+			}
+		case "message_delta":
+			ex.Usage.CompletionTokens = ev.Usage.OutputTokens
+		case "message_stop":
+			stopped = true
+			return false
+		case "error":
+			streamErr = streamAPIError(ev.Error.Type, ev.Error.Message)
+			return false
+		}
+		return true
+	})
+	switch {
+	case streamErr != nil:
+		return streamErr
+	case err != nil:
+		return err
+	case !stopped:
+		return io.ErrUnexpectedEOF
 	}
+	return nil
+}
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("anthropic: api error (status %d): %s", resp.StatusCode, string(respBody))
+// streamAPIError turns a mid-stream error event into an error. Overload,
+// rate limit and server errors become an APIError with the status the API
+// would have answered with, so IsRetryable treats them like one.
+func streamAPIError(typ, message string) error {
+	var status int
+	switch typ {
+	case "overloaded_error":
+		status = 529
+	case "rate_limit_error":
+		status = http.StatusTooManyRequests
+	case "api_error":
+		status = http.StatusInternalServerError
+	default:
+		return fmt.Errorf("api error: %s: %s", typ, message)
 	}
+	return &APIError{StatusCode: status, Body: typ + ": " + message}
+}
 
-	var anthResp anthropicResponse
-	if err := json.Unmarshal(respBody, &anthResp); err != nil {
-		return nil, fmt.Errorf("anthropic: unmarshal response: %w", err)
+// JSONInstruction is the system prompt addition that asks for a response
+// format in words. Providers without a native JSON mode rely on it, and
+// agents put it in their own prompt, since OpenAI's json_object mode
+// rejects requests that never mention JSON.
+func JSONInstruction(f *protocol.ResponseFormat) string {
+	instr := "Respond with a single valid JSON object and nothing else: no prose, no markdown code fences."
+	if f.Type == protocol.FormatJSONSchema && f.Schema != nil {
+		if schema, err := json.Marshal(f.Schema); err == nil {
+			instr += " The object must match this JSON schema:\n" + string(schema)
+		}
 	}
+	return instr
+}
 
-	return parseAnthropicResponse(&anthResp)
+// stripCodeFence removes a markdown code fence wrapped around a JSON reply.
+func stripCodeFence(s string) string {
+	t := strings.TrimSpace(s)
+	if !strings.HasPrefix(t, "```") || !strings.HasSuffix(t, "```") || len(t) < 6 {
+		return s
+	}
+	t = strings.TrimSuffix(t[3:], "```")
+	if i := strings.IndexByte(t, '\n'); i >= 0 && !strings.ContainsAny(t[:i], "{[") {
+		t = t[i+1:] // drop the language tag line, e.g. "json"
+	}
+	return strings.TrimSpace(t)
 }
 
 // --- Anthropic wire format types ---
 
 type anthropicRequest struct {
-	Model       string             `json:"model"`
-	Messages    []anthropicMessage `json:"messages"`
-	System      string             `json:"system,omitempty"`
-	MaxTokens   int                `json:"max_tokens"`
-	Temperature *float64           `json:"temperature,omitempty"`
-	Tools       []anthropicTool    `json:"tools,omitempty"`
+	Model       string               `json:"model"`
+	Messages    []anthropicMessage   `json:"messages"`
+	System      anthropicSystem      `json:"system,omitzero"`
+	MaxTokens   int                  `json:"max_tokens"`
+	Temperature *float64             `json:"temperature,omitempty"`
+	Tools       []anthropicTool      `json:"tools,omitempty"`
+	ToolChoice  *anthropicToolChoice `json:"tool_choice,omitempty"`
+	Stream      bool                 `json:"stream,omitempty"`
+}
+
+// anthropicSystem is the system prompt: a plain string, or with Cache a
+// single text block carrying a cache_control breakpoint.
+type anthropicSystem struct {
+	Text  string
+	Cache bool
+}
+
+func (s anthropicSystem) IsZero() bool { return s.Text == "" }
+
+func (s anthropicSystem) MarshalJSON() ([]byte, error) {
+	if !s.Cache {
+		return json.Marshal(s.Text)
+	}
+	return json.Marshal([]struct {
+		Type         string        `json:"type"`
+		Text         string        `json:"text"`
+		CacheControl *cacheControl `json:"cache_control"`
+	}{{"text", s.Text, &cacheControl{Type: "ephemeral"}}})
+}
+
+func (s *anthropicSystem) UnmarshalJSON(data []byte) error {
+	if err := json.Unmarshal(data, &s.Text); err == nil {
+		return nil
+	}
+	var blocks []struct {
+		Text         string        `json:"text"`
+		CacheControl *cacheControl `json:"cache_control"`
+	}
+	if err := json.Unmarshal(data, &blocks); err != nil {
+		return err
+	}
+	s.Text = ""
+	for _, b := range blocks {
+		s.Text += b.Text
+		s.Cache = s.Cache || b.CacheControl != nil
+	}
+	return nil
+}
+
+// cacheControl marks the end of a cacheable prompt prefix.
+type cacheControl struct {
+	Type string `json:"type"` // "ephemeral"
+}
+
+// anthropicToolChoice makes the model call a tool; Type "tool" names one.
+type anthropicToolChoice struct {
+	Type string `json:"type"`
+	Name string `json:"name,omitempty"`
 }
 
 type anthropicMessage struct {
@@ -148,6 +537,16 @@ type contentBlock struct {
 	Input     map[string]any `json:"-"`
 	ToolUseID string         `json:"-"`
 	Content   string         `json:"-"` // used for tool_result content
+	Parts     []contentBlock `json:"-"` // tool_result content as blocks; takes precedence over Content
+	Source    *imageSource   `json:"-"` // used for image blocks
+}
+
+// imageSource is the source of an image block: inline base64 or a URL.
+type imageSource struct {
+	Type      string `json:"type"` // "base64" or "url"
+	MediaType string `json:"media_type,omitempty"`
+	Data      string `json:"data,omitempty"`
+	URL       string `json:"url,omitempty"`
 }
 
 func (b contentBlock) MarshalJSON() ([]byte, error) {
@@ -164,11 +563,23 @@ func (b contentBlock) MarshalJSON() ([]byte, error) {
 			Input map[string]any `json:"input"`
 		}{b.Type, b.ID, b.Name, input})
 	case "tool_result":
+		if len(b.Parts) > 0 {
+			return json.Marshal(struct {
+				Type      string         `json:"type"`
+				ToolUseID string         `json:"tool_use_id"`
+				Content   []contentBlock `json:"content"`
+			}{b.Type, b.ToolUseID, b.Parts})
+		}
 		return json.Marshal(struct {
 			Type      string `json:"type"`
 			ToolUseID string `json:"tool_use_id"`
 			Content   string `json:"content"`
 		}{b.Type, b.ToolUseID, b.Content})
+	case "image":
+		return json.Marshal(struct {
+			Type   string       `json:"type"`
+			Source *imageSource `json:"source"`
+		}{b.Type, b.Source})
 	default: // "text"
 		return json.Marshal(struct {
 			Type string `json:"type"`
@@ -179,31 +590,40 @@ func (b contentBlock) MarshalJSON() ([]byte, error) {
 
 func (b *contentBlock) UnmarshalJSON(data []byte) error {
 	var raw struct {
-		Type      string         `json:"type"`
-		Text      string         `json:"text"`
-		ID        string         `json:"id"`
-		Name      string         `json:"name"`
-		Input     map[string]any `json:"input"`
-		ToolUseID string         `json:"tool_use_id"`
-		Content   string         `json:"content"`
+		Type      string          `json:"type"`
+		Text      string          `json:"text"`
+		ID        string          `json:"id"`
+		Name      string          `json:"name"`
+		Input     map[string]any  `json:"input"`
+		ToolUseID string          `json:"tool_use_id"`
+		Content   json.RawMessage `json:"content"` // string or array of blocks
+		Source    *imageSource    `json:"source"`
 	}
 	if err := json.Unmarshal(data, &raw); err != nil {
 		return err
 	}
+	if len(raw.Content) > 0 {
+		if err := json.Unmarshal(raw.Content, &b.Content); err != nil {
+			if err := json.Unmarshal(raw.Content, &b.Parts); err != nil {
+				return err
+			}
+		}
+	}
 	b.Type = raw.Type
 	b.Text = raw.Text
 	b.ID = raw.ID
 	b.Name = raw.Name
 	b.Input = raw.Input
 	b.ToolUseID = raw.ToolUseID
-	b.Content = raw.Content
+	b.Source = raw.Source
 	return nil
 }
 
 type anthropicTool struct {
-	Name        string         `json:"name"`
-	Description string         `json:"description"`
-	InputSchema map[string]any `json:"input_schema"`
+	Name         string         `json:"name"`
+	Description  string         `json:"description"`
+	InputSchema  map[string]any `json:"input_schema"`
+	CacheControl *cacheControl  `json:"cache_control,omitempty"`
 }
 
 type anthropicResponse struct {
@@ -212,9 +632,32 @@ type anthropicResponse struct {
 	StopReason string      `json:"stop_reason"`
 }
 
+// anthropicStreamEvent is one event of a streamed Messages API response.
+// Fields are set according to Type.
+type anthropicStreamEvent struct {
+	Type    string `json:"type"`
+	Index   int    `json:"index"`
+	Message struct {
+		Usage anthropicUsage `json:"usage"`
+	} `json:"message"`
+	ContentBlock contentBlock `json:"content_block"`
+	Delta        struct {
+		Type        string `json:"type"`
+		Text        string `json:"text"`
+		PartialJSON string `json:"partial_json"`
+	} `json:"delta"`
+	Usage anthropicUsage `json:"usage"`
+	Error struct {
+		Type    string `json:"type"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
 type anthropicUsage struct {
-	InputTokens  int `json:"input_tokens"`
-	OutputTokens int `json:"output_tokens"`
+	InputTokens              int `json:"input_tokens"`
+	OutputTokens             int `json:"output_tokens"`
+	CacheCreationInputTokens int `json:"cache_creation_input_tokens,omitempty"`
+	CacheReadInputTokens     int `json:"cache_read_input_tokens,omitempty"`
 }
 
 // --- Conversion helpers ---
@@ -242,6 +685,7 @@ func toAnthropicMessages(msgs []protocol.ChatMessage) (string, []anthropicMessag
 					Type:      "tool_result",
 					ToolUseID: m.ToolCallID,
 					Content:   m.Content,
+					Parts:     toAnthropicParts(m.Parts),
 				}},
 			})
 			continue
@@ -269,16 +713,45 @@ func toAnthropicMessages(msgs []protocol.ChatMessage) (string, []anthropicMessag
 			continue
 		}
 
-		// Regular text message
-		result = append(result, anthropicMessage{
-			Role:    m.Role,
-			Content: []contentBlock{{Type: "text", Text: m.Content}},
-		})
+		// Regular message, as typed parts if it has them
+		blocks := toAnthropicParts(m.Parts)
+		if len(blocks) == 0 {
+			blocks = []contentBlock{{Type: "text", Text: m.Content}}
+		}
+		result = append(result, anthropicMessage{Role: m.Role, Content: blocks})
 	}
 
 	return system, result
 }
 
+// toAnthropicParts converts typed content parts to Anthropic content blocks.
+// JSON parts are sent as text, since Anthropic has no JSON block type.
+func toAnthropicParts(parts []protocol.ContentPart) []contentBlock {
+	var blocks []contentBlock
+	for _, p := range parts {
+		switch p.Type {
+		case protocol.PartImage:
+			if p.URL != "" {
+				blocks = append(blocks, contentBlock{Type: "image", Source: &imageSource{Type: "url", URL: p.URL}})
+				continue
+			}
+			blocks = append(blocks, contentBlock{
+				Type: "image",
+				Source: &imageSource{
+					Type:      "base64",
+					MediaType: p.MimeType,
+					Data:      base64.StdEncoding.EncodeToString(p.Data),
+				},
+			})
+		case protocol.PartJSON:
+			blocks = append(blocks, contentBlock{Type: "text", Text: string(p.Data)})
+		default:
+			blocks = append(blocks, contentBlock{Type: "text", Text: p.Text})
+		}
+	}
+	return blocks
+}
+
 func parseAnthropicResponse(resp *anthropicResponse) (*protocol.ChatResponse, error) {
 	var content string
 	var toolCalls []protocol.ToolCall
@@ -300,8 +773,10 @@ func parseAnthropicResponse(resp *anthropicResponse) (*protocol.ChatResponse, er
 		Content:   content,
 		ToolCalls: toolCalls,
 		Usage: protocol.Usage{
-			PromptTokens:     resp.Usage.InputTokens,
-			CompletionTokens: resp.Usage.OutputTokens,
+			PromptTokens:        resp.Usage.InputTokens,
+			CompletionTokens:    resp.Usage.OutputTokens,
+			CacheCreationTokens: resp.Usage.CacheCreationInputTokens,
+			CacheReadTokens:     resp.Usage.CacheReadInputTokens,
 		},
 	}, nil
 }
@@ -3,8 +3,11 @@ package provider
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/h1v3-io/h1v3/pkg/protocol"
@@ -92,8 +95,8 @@ func TestAnthropicChat_SystemPrompt(t *testing.T) {
 		t.Fatalf("unexpected error: %v", err)
 	}
 
-	if capturedReq.System != "You are a helpful assistant." {
-		t.Errorf("system = %q", capturedReq.System)
+	if capturedReq.System.Text != "You are a helpful assistant." {
+		t.Errorf("system = %q", capturedReq.System.Text)
 	}
 	// System message should NOT appear in messages array
 	if len(capturedReq.Messages) != 1 {
@@ -239,7 +242,7 @@ func TestAnthropicChat_APIError(t *testing.T) {
 	}))
 	defer srv.Close()
 
-	p := NewAnthropic("test-key", WithAnthropicBaseURL(srv.URL))
+	p := NewAnthropic("test-key", WithAnthropicBaseURL(srv.URL), WithAnthropicMaxRetries(0))
 
 	_, err := p.Chat(context.Background(), protocol.ChatRequest{
 		Messages: []protocol.ChatMessage{{Role: "user", Content: "Hi"}},
@@ -328,3 +331,362 @@ func TestToAnthropicMessages_MultipleSystemMessages(t *testing.T) {
 		t.Errorf("expected 1 message, got %d", len(msgs))
 	}
 }
+
+func TestToAnthropicMessages_ToolResultWireFormat(t *testing.T) {
+	_, msgs := toAnthropicMessages([]protocol.ChatMessage{
+		{Role: "tool", ToolCallID: "toolu_1", Content: "plain"},
+		{Role: "tool", ToolCallID: "toolu_2", Content: "chart", Parts: []protocol.ContentPart{
+			{Type: protocol.PartText, Text: "Here is the chart"},
+			{Type: protocol.PartImage, MimeType: "image/png", Data: []byte("PNG")},
+			{Type: protocol.PartJSON, Data: []byte(`{"rows":3}`)},
+		}},
+	})
+
+	// Text-only results keep the string content form.
+	got, _ := json.Marshal(msgs[0].Content[0])
+	if want := `{"type":"tool_result","tool_use_id":"toolu_1","content":"plain"}`; string(got) != want {
+		t.Errorf("text tool_result = %s, want %s", got, want)
+	}
+
+	got, _ = json.Marshal(msgs[1].Content[0])
+	want := `{"type":"tool_result","tool_use_id":"toolu_2","content":[` +
+		`{"type":"text","text":"Here is the chart"},` +
+		`{"type":"image","source":{"type":"base64","media_type":"image/png","data":"UE5H"}},` +
+		`{"type":"text","text":"{\"rows\":3}"}]}`
+	if string(got) != want {
+		t.Errorf("multi-part tool_result =\n%s\nwant\n%s", got, want)
+	}
+
+	// The array form decodes back into parts.
+	var decoded contentBlock
+	if err := json.Unmarshal(got, &decoded); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if len(decoded.Parts) != 3 || decoded.Parts[1].Source == nil || decoded.Parts[1].Source.MediaType != "image/png" {
+		t.Errorf("decoded parts = %+v", decoded.Parts)
+	}
+}
+
+func TestAnthropicChat_ImageParts(t *testing.T) {
+	var raw map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&raw)
+		json.NewEncoder(w).Encode(anthropicResponse{Content: []contentBlock{{Type: "text", Text: "A cat."}}})
+	}))
+	defer srv.Close()
+
+	_, err := NewAnthropic("test-key", WithAnthropicBaseURL(srv.URL)).Chat(context.Background(), protocol.ChatRequest{
+		Messages: []protocol.ChatMessage{
+			{Role: "user", Content: "What is this? [attachment: cat.png]", Parts: []protocol.ContentPart{
+				{Type: protocol.PartText, Text: "What is this?"},
+				{Type: protocol.PartImage, MimeType: "image/png", Data: []byte("png")},
+			}},
+			{Role: "assistant", Content: "A cat."},
+			{Role: "user", Content: "And this?", Parts: []protocol.ContentPart{
+				{Type: protocol.PartImage, URL: "https://example.com/dog.jpg"},
+			}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("chat: %v", err)
+	}
+
+	msgs := raw["messages"].([]any)
+	got, _ := json.Marshal(msgs[0].(map[string]any)["content"])
+	want := `[{"text":"What is this?","type":"text"},{"source":{"data":"cG5n","media_type":"image/png","type":"base64"},"type":"image"}]`
+	if string(got) != want {
+		t.Errorf("content = %s\nwant %s", got, want)
+	}
+	got, _ = json.Marshal(msgs[2].(map[string]any)["content"])
+	if want := `[{"source":{"type":"url","url":"https://example.com/dog.jpg"},"type":"image"}]`; string(got) != want {
+		t.Errorf("url image content = %s\nwant %s", got, want)
+	}
+}
+
+func TestAnthropicChat_ResponseFormat(t *testing.T) {
+	var capturedReq anthropicRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&capturedReq)
+		json.NewEncoder(w).Encode(anthropicResponse{
+			Content: []contentBlock{{Type: "text", Text: "```json\n{\"ok\": true}\n```"}},
+		})
+	}))
+	defer srv.Close()
+
+	p := NewAnthropic("test-key", WithAnthropicBaseURL(srv.URL))
+	got, err := p.Chat(context.Background(), protocol.ChatRequest{
+		Messages:       []protocol.ChatMessage{{Role: "system", Content: "Be brief."}, {Role: "user", Content: "Hi"}},
+		ResponseFormat: &protocol.ResponseFormat{Type: protocol.FormatJSONObject},
+	})
+	if err != nil {
+		t.Fatalf("chat: %v", err)
+	}
+
+	if !strings.HasPrefix(capturedReq.System.Text, "Be brief.\n\n") || !strings.Contains(capturedReq.System.Text, "valid JSON object") {
+		t.Errorf("system prompt should carry the JSON instruction, got %q", capturedReq.System.Text)
+	}
+	if got.Content != `{"ok": true}` {
+		t.Errorf("code fence should be stripped, got %q", got.Content)
+	}
+
+	// A system prompt that already asks for JSON is not asked twice.
+	instr := JSONInstruction(&protocol.ResponseFormat{Type: protocol.FormatJSONObject})
+	_, err = p.Chat(context.Background(), protocol.ChatRequest{
+		Messages:       []protocol.ChatMessage{{Role: "system", Content: "Be brief.\n\n" + instr}, {Role: "user", Content: "Hi"}},
+		ResponseFormat: &protocol.ResponseFormat{Type: protocol.FormatJSONObject},
+	})
+	if err != nil {
+		t.Fatalf("chat: %v", err)
+	}
+	if n := strings.Count(capturedReq.System.Text, instr); n != 1 {
+		t.Errorf("expected the JSON instruction once, got %d in %q", n, capturedReq.System.Text)
+	}
+}
+
+func TestAnthropicChat_ResponseFormatTool(t *testing.T) {
+	schema := map[string]any{"type": "object", "properties": map[string]any{"label": map[string]any{"type": "string"}}}
+	var captured []anthropicRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req anthropicRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		captured = append(captured, req)
+		json.NewEncoder(w).Encode(anthropicResponse{
+			Content: []contentBlock{{Type: "tool_use", ID: "tu_1", Name: jsonOutputTool, Input: map[string]any{"label": "bug"}}},
+		})
+	}))
+	defer srv.Close()
+
+	p := NewAnthropic("test-key", WithAnthropicBaseURL(srv.URL))
+	format := &protocol.ResponseFormat{Type: protocol.FormatJSONSchema, Name: "verdict", Schema: schema}
+	msgs := []protocol.ChatMessage{{Role: "user", Content: "Classify"}}
+	got, err := p.Chat(context.Background(), protocol.ChatRequest{Messages: msgs, ResponseFormat: format})
+	if err != nil {
+		t.Fatalf("chat: %v", err)
+	}
+	if got.Content != `{"label":"bug"}` || got.ToolCalls != nil {
+		t.Errorf("expected the tool input as content, got %q with calls %+v", got.Content, got.ToolCalls)
+	}
+
+	// Alongside other tools the output tool is offered but not forced.
+	tools := []protocol.ToolDefinition{{Type: "function", Function: protocol.ToolFunctionSchema{Name: "read_file", Parameters: map[string]any{"type": "object"}}}}
+	if _, err := p.Chat(context.Background(), protocol.ChatRequest{Messages: msgs, Tools: tools, ResponseFormat: format}); err != nil {
+		t.Fatalf("chat: %v", err)
+	}
+	// A schema that is not an object cannot be a tool input.
+	array := &protocol.ResponseFormat{Type: protocol.FormatJSONSchema, Schema: map[string]any{"type": "array"}}
+	if _, err := p.Chat(context.Background(), protocol.ChatRequest{Messages: msgs, ResponseFormat: array}); err != nil {
+		t.Fatalf("chat: %v", err)
+	}
+
+	forced, mixed, fallback := captured[0], captured[1], captured[2]
+	if forced.ToolChoice == nil || forced.ToolChoice.Type != "tool" || forced.ToolChoice.Name != jsonOutputTool {
+		t.Errorf("expected the output tool forced, got %+v", forced.ToolChoice)
+	}
+	if len(forced.Tools) != 1 || !reflect.DeepEqual(forced.Tools[0].InputSchema, schema) {
+		t.Errorf("expected the schema as the tool input schema, got %+v", forced.Tools)
+	}
+	if mixed.ToolChoice != nil || len(mixed.Tools) != 2 || mixed.Tools[1].Name != jsonOutputTool {
+		t.Errorf("expected the output tool offered after read_file, got %+v / %+v", mixed.ToolChoice, mixed.Tools)
+	}
+	if !strings.Contains(mixed.System.Text, "calling the "+jsonOutputTool+" tool") {
+		t.Errorf("system prompt should point at the output tool, got %q", mixed.System.Text)
+	}
+	if fallback.ToolChoice != nil || len(fallback.Tools) != 0 || !strings.Contains(fallback.System.Text, "valid JSON object") {
+		t.Errorf("expected the prompt-only fallback, got %+v", fallback)
+	}
+}
+
+func TestAnthropicChatStream_ResponseFormatTool(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, ev := range [][2]string{
+			{"content_block_start", `{"type":"content_block_start","index":0,"content_block":{"type":"tool_use","id":"tu_1","name":"json_output","input":{}}}`},
+			{"content_block_delta", `{"type":"content_block_delta","index":0,"delta":{"type":"input_json_delta","partial_json":"{\"label\": "}}`},
+			{"content_block_delta", `{"type":"content_block_delta","index":0,"delta":{"type":"input_json_delta","partial_json":"\"bug\"}"}}`},
+			{"message_stop", `{"type":"message_stop"}`},
+		} {
+			fmt.Fprintf(w, "event: %s\ndata: %s\n\n", ev[0], ev[1])
+		}
+	}))
+	defer srv.Close()
+
+	ch, err := NewAnthropic("k", WithAnthropicBaseURL(srv.URL)).ChatStream(context.Background(), protocol.ChatRequest{
+		Messages:       []protocol.ChatMessage{{Role: "user", Content: "Classify"}},
+		ResponseFormat: &protocol.ResponseFormat{Type: protocol.FormatJSONObject},
+	})
+	if err != nil {
+		t.Fatalf("ChatStream: %v", err)
+	}
+	resp, err := CollectStream(ch, nil)
+	if err != nil {
+		t.Fatalf("CollectStream: %v", err)
+	}
+	if resp.Content != `{"label": "bug"}` || len(resp.ToolCalls) != 0 {
+		t.Errorf("expected the tool input streamed as content, got %q with calls %+v", resp.Content, resp.ToolCalls)
+	}
+}
+
+func TestAnthropicChat_PromptCaching(t *testing.T) {
+	var raw []map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		json.NewDecoder(r.Body).Decode(&body)
+		raw = append(raw, body)
+		fmt.Fprint(w, `{"content":[{"type":"text","text":"ok"}],"usage":{"input_tokens":5,"output_tokens":2,"cache_creation_input_tokens":900,"cache_read_input_tokens":1200}}`)
+	}))
+	defer srv.Close()
+
+	req := protocol.ChatRequest{
+		Messages: []protocol.ChatMessage{{Role: "system", Content: "Big prompt."}, {Role: "user", Content: "Hi"}},
+		Tools: []protocol.ToolDefinition{
+			{Type: "function", Function: protocol.ToolFunctionSchema{Name: "read_file", Parameters: map[string]any{"type": "object"}}},
+			{Type: "function", Function: protocol.ToolFunctionSchema{Name: "write_file", Parameters: map[string]any{"type": "object"}}},
+		},
+	}
+	got, err := NewAnthropic("k", WithAnthropicBaseURL(srv.URL), WithAnthropicPromptCaching(true)).Chat(context.Background(), req)
+	if err != nil {
+		t.Fatalf("chat: %v", err)
+	}
+	if _, err := NewAnthropic("k", WithAnthropicBaseURL(srv.URL)).Chat(context.Background(), req); err != nil {
+		t.Fatalf("chat: %v", err)
+	}
+
+	system, _ := json.Marshal(raw[0]["system"])
+	if string(system) != `[{"cache_control":{"type":"ephemeral"},"text":"Big prompt.","type":"text"}]` {
+		t.Errorf("system = %s", system)
+	}
+	tools := raw[0]["tools"].([]any)
+	if _, ok := tools[0].(map[string]any)["cache_control"]; ok {
+		t.Error("only the last tool should carry a breakpoint")
+	}
+	if cc, _ := json.Marshal(tools[1].(map[string]any)["cache_control"]); string(cc) != `{"type":"ephemeral"}` {
+		t.Errorf("last tool cache_control = %s", cc)
+	}
+	if got.Usage.CacheCreationTokens != 900 || got.Usage.CacheReadTokens != 1200 || got.Usage.PromptTokens != 5 {
+		t.Errorf("usage = %+v", got.Usage)
+	}
+
+	// Without the option the system prompt stays a plain string.
+	if raw[1]["system"] != "Big prompt." {
+		t.Errorf("uncached system = %v", raw[1]["system"])
+	}
+	if _, ok := raw[1]["tools"].([]any)[1].(map[string]any)["cache_control"]; ok {
+		t.Error("uncached request should not mark tools")
+	}
+}
+
+func TestStripCodeFence(t *testing.T) {
+	cases := map[string]string{
+		`{"a":1}`:                  `{"a":1}`,
+		"```\n{\"a\":1}\n```":      `{"a":1}`,
+		"```json\n{\"a\":1}\n```":  `{"a":1}`,
+		"  ```{\"a\":1}```  ":      `{"a":1}`,
+		"see ```{\"a\":1}``` here": "see ```{\"a\":1}``` here",
+	}
+	for in, want := range cases {
+		if got := stripCodeFence(in); got != want {
+			t.Errorf("stripCodeFence(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestAnthropicChat_DefaultMaxTokens(t *testing.T) {
+	var got anthropicRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&got)
+		w.Write([]byte(`{"content":[{"type":"text","text":"ok"}],"stop_reason":"end_turn"}`))
+	}))
+	defer srv.Close()
+
+	msgs := []protocol.ChatMessage{{Role: "user", Content: "Hi"}}
+	cases := []struct {
+		name string
+		opts []AnthropicOption
+		req  int
+		want int
+	}{
+		{"built-in default", nil, 0, 4096},
+		{"provider default", []AnthropicOption{WithAnthropicMaxTokens(1024)}, 0, 1024},
+		{"request wins", []AnthropicOption{WithAnthropicMaxTokens(1024)}, 200, 200},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			opts := append([]AnthropicOption{WithAnthropicBaseURL(srv.URL), WithAnthropicHTTPClient(srv.Client())}, tc.opts...)
+			p := NewAnthropic("test-key", opts...)
+			if _, err := p.Chat(context.Background(), protocol.ChatRequest{Messages: msgs, MaxTokens: tc.req}); err != nil {
+				t.Fatalf("chat: %v", err)
+			}
+			if got.MaxTokens != tc.want {
+				t.Errorf("max_tokens = %d, want %d", got.MaxTokens, tc.want)
+			}
+		})
+	}
+}
+
+func TestAnthropicChatStream(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req anthropicRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		if !req.Stream {
+			t.Error("expected stream:true in request")
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, ev := range [][2]string{
+			{"message_start", `{"type":"message_start","message":{"usage":{"input_tokens":12}}}`},
+			{"content_block_start", `{"type":"content_block_start","index":0,"content_block":{"type":"text","text":""}}`},
+			{"ping", `{"type":"ping"}`},
+			{"content_block_delta", `{"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"Checking"}}`},
+			{"content_block_stop", `{"type":"content_block_stop","index":0}`},
+			{"content_block_start", `{"type":"content_block_start","index":1,"content_block":{"type":"tool_use","id":"tu_1","name":"list_dir","input":{}}}`},
+			{"content_block_delta", `{"type":"content_block_delta","index":1,"delta":{"type":"input_json_delta","partial_json":"{\"path\": "}}`},
+			{"content_block_delta", `{"type":"content_block_delta","index":1,"delta":{"type":"input_json_delta","partial_json":"\"src\"}"}}`},
+			{"content_block_stop", `{"type":"content_block_stop","index":1}`},
+			{"message_delta", `{"type":"message_delta","delta":{"stop_reason":"tool_use"},"usage":{"output_tokens":30}}`},
+			{"message_stop", `{"type":"message_stop"}`},
+		} {
+			fmt.Fprintf(w, "event: %s\ndata: %s\n\n", ev[0], ev[1])
+		}
+	}))
+	defer srv.Close()
+
+	var logged Exchange
+	p := NewAnthropic("test-key", WithAnthropicBaseURL(srv.URL), WithAnthropicRequestLogger(func(ex Exchange) { logged = ex }))
+	ch, err := p.ChatStream(context.Background(), protocol.ChatRequest{
+		Messages: []protocol.ChatMessage{{Role: "user", Content: "Hi"}},
+	})
+	if err != nil {
+		t.Fatalf("ChatStream: %v", err)
+	}
+	resp, err := CollectStream(ch, nil)
+	if err != nil {
+		t.Fatalf("CollectStream: %v", err)
+	}
+	if resp.Content != "Checking" {
+		t.Errorf("content = %q", resp.Content)
+	}
+	if len(resp.ToolCalls) != 1 || resp.ToolCalls[0].ID != "tu_1" || resp.ToolCalls[0].Arguments["path"] != "src" {
+		t.Errorf("tool calls = %+v", resp.ToolCalls)
+	}
+	if logged.Usage.PromptTokens != 12 || logged.Usage.CompletionTokens != 30 || !strings.Contains(logged.Response, "message_stop") {
+		t.Errorf("unexpected logged exchange %+v", logged)
+	}
+}
+
+func TestAnthropicChatStream_ErrorEvent(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "event: error\ndata: {\"type\":\"error\",\"error\":{\"type\":\"overloaded_error\",\"message\":\"Overloaded\"}}\n\n")
+	}))
+	defer srv.Close()
+
+	ch, err := NewAnthropic("k", WithAnthropicBaseURL(srv.URL)).ChatStream(context.Background(), protocol.ChatRequest{})
+	if err != nil {
+		t.Fatalf("ChatStream: %v", err)
+	}
+	_, err = CollectStream(ch, nil)
+	if err == nil || !strings.Contains(err.Error(), "anthropic: stream: api error (status 529): overloaded_error: Overloaded") {
+		t.Errorf("expected error event, got %v", err)
+	}
+	if !IsRetryable(err) {
+		t.Errorf("expected an overloaded stream to be retryable: %v", err)
+	}
+}
@@ -0,0 +1,167 @@
+package provider
+
+import (
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/h1v3-io/h1v3/pkg/protocol"
+)
+
+// Capabilities describes what a model accepts. Providers consult it before
+// each request to adapt what the model cannot take instead of letting the
+// API reject it with a 400.
+type Capabilities struct {
+	Tools      bool // function calling
+	JSON       bool // native response_format
+	Vision     bool // image content parts
+	MaxContext int  // context window in tokens; 0 = unknown
+	MaxOutput  int  // completion token limit; 0 = unknown
+}
+
+// DefaultCapabilities is assumed for models not in the built-in table:
+// everything is allowed and nothing is clamped.
+var DefaultCapabilities = Capabilities{Tools: true, JSON: true, Vision: true}
+
+// knownModels maps model families to their capabilities. A key matches the
+// model with that exact name or with a "-" suffix (dated snapshots, sizes);
+// the longest matching key wins. Operators fill gaps via config.
+var knownModels = map[string]Capabilities{
+	// OpenAI
+	"gpt-3.5-turbo": {Tools: true, JSON: true, MaxContext: 16385, MaxOutput: 4096},
+	"gpt-4-turbo":   {Tools: true, JSON: true, Vision: true, MaxContext: 128000, MaxOutput: 4096},
+	"gpt-4o":        {Tools: true, JSON: true, Vision: true, MaxContext: 128000, MaxOutput: 16384},
+	"gpt-4o-mini":   {Tools: true, JSON: true, Vision: true, MaxContext: 128000, MaxOutput: 16384},
+	"gpt-4.1":       {Tools: true, JSON: true, Vision: true, MaxContext: 1047576, MaxOutput: 32768},
+	"gpt-5":         {Tools: true, JSON: true, Vision: true, MaxContext: 400000, MaxOutput: 128000},
+	"o1":            {Tools: true, JSON: true, Vision: true, MaxContext: 200000, MaxOutput: 100000},
+	"o1-mini":       {MaxContext: 128000, MaxOutput: 65536},
+	"o1-preview":    {MaxContext: 128000, MaxOutput: 32768},
+	"o3":            {Tools: true, JSON: true, Vision: true, MaxContext: 200000, MaxOutput: 100000},
+	"o3-mini":       {Tools: true, JSON: true, MaxContext: 200000, MaxOutput: 100000},
+	"o4-mini":       {Tools: true, JSON: true, Vision: true, MaxContext: 200000, MaxOutput: 100000},
+
+	// Anthropic (JSON is approximated through the system prompt and a tool)
+	"claude-3-haiku":    {Tools: true, JSON: true, Vision: true, MaxContext: 200000, MaxOutput: 4096},
+	"claude-3-opus":     {Tools: true, JSON: true, Vision: true, MaxContext: 200000, MaxOutput: 4096},
+	"claude-3-5-haiku":  {Tools: true, JSON: true, MaxContext: 200000, MaxOutput: 8192},
+	"claude-3-5-sonnet": {Tools: true, JSON: true, Vision: true, MaxContext: 200000, MaxOutput: 8192},
+	"claude-3-7-sonnet": {Tools: true, JSON: true, Vision: true, MaxContext: 200000, MaxOutput: 64000},
+	"claude-sonnet-4":   {Tools: true, JSON: true, Vision: true, MaxContext: 200000, MaxOutput: 64000},
+	"claude-opus-4":     {Tools: true, JSON: true, Vision: true, MaxContext: 200000, MaxOutput: 32000},
+
+	// DeepSeek
+	"deepseek-chat":     {Tools: true, JSON: true, MaxContext: 65536, MaxOutput: 8192},
+	"deepseek-reasoner": {MaxContext: 65536, MaxOutput: 8192},
+}
+
+// LookupCapabilities returns the built-in capabilities for model, ignoring a
+// gateway prefix such as OpenRouter's "openai/". ok is false for unknown
+// models, in which case DefaultCapabilities is returned.
+func LookupCapabilities(model string) (caps Capabilities, ok bool) {
+	if i := strings.LastIndexByte(model, '/'); i >= 0 {
+		model = model[i+1:]
+	}
+	best := ""
+	for key := range knownModels {
+		if (model == key || strings.HasPrefix(model, key+"-")) && len(key) > len(best) {
+			best = key
+		}
+	}
+	if best == "" {
+		return DefaultCapabilities, false
+	}
+	return knownModels[best], true
+}
+
+// capabilityGuard adapts requests to a model's capabilities. Each adaptation
+// is logged once per model so a long agent loop does not repeat it.
+type capabilityGuard struct {
+	caps   *Capabilities // set via options for the provider's own model; nil = LookupCapabilities per model
+	logger *slog.Logger  // nil = slog.Default()
+	warned sync.Map      // "model|what" → struct{}
+}
+
+// adapt returns req reshaped for model: tools are dropped for models without
+// function calling, response_format becomes a prompt instruction for models
+// without JSON mode, image parts fall back to the message text for models
+// without vision, and MaxTokens is clamped to the model's output limit. A
+// prompt that cannot fit the context window fails fast; name prefixes that
+// error. Configured capabilities describe the provider's defaultModel, so a
+// request that overrides the model is adapted to that model's table entry.
+func (g *capabilityGuard) adapt(name, defaultModel, model string, req protocol.ChatRequest) (protocol.ChatRequest, error) {
+	caps := DefaultCapabilities
+	if g.caps != nil && model == defaultModel {
+		caps = *g.caps
+	} else {
+		caps, _ = LookupCapabilities(model)
+	}
+
+	if len(req.Tools) > 0 && !caps.Tools {
+		g.warnOnce(model, "tools", "model does not support tools, sending request without them", "tools", len(req.Tools))
+		req.Tools = nil
+	}
+	if req.ResponseFormat != nil && !caps.JSON {
+		g.warnOnce(model, "json", "model has no JSON mode, asking for JSON in the prompt instead")
+		instr := JSONInstruction(req.ResponseFormat)
+		if !slices.ContainsFunc(req.Messages, func(m protocol.ChatMessage) bool { return strings.Contains(m.Content, instr) }) {
+			req.Messages = append(append([]protocol.ChatMessage{}, req.Messages...),
+				protocol.ChatMessage{Role: "system", Content: instr})
+		}
+		req.ResponseFormat = nil
+	}
+	if !caps.Vision && hasImageParts(req.Messages) {
+		g.warnOnce(model, "vision", "model does not support images, sending their text descriptions instead")
+		msgs := make([]protocol.ChatMessage, len(req.Messages))
+		for i, m := range req.Messages {
+			m.Parts = nil
+			msgs[i] = m
+		}
+		req.Messages = msgs
+	}
+	if caps.MaxOutput > 0 && req.MaxTokens > caps.MaxOutput {
+		g.warnOnce(model, "max_tokens", "max_tokens exceeds the model's output limit, clamping", "max_tokens", req.MaxTokens, "limit", caps.MaxOutput)
+		req.MaxTokens = caps.MaxOutput
+	}
+	if caps.MaxContext > 0 {
+		if n := EstimateTokens(req.Messages); n > caps.MaxContext {
+			return req, fmt.Errorf("%s: request is about %d tokens but model %s has a %d-token context window; lower the agent's history window or enable compaction", name, n, model, caps.MaxContext)
+		}
+	}
+	return req, nil
+}
+
+func (g *capabilityGuard) warnOnce(model, what, msg string, args ...any) {
+	if _, seen := g.warned.LoadOrStore(model+"|"+what, struct{}{}); seen {
+		return
+	}
+	logger := g.logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+	logger.Warn(msg, append([]any{"model", model}, args...)...)
+}
+
+func hasImageParts(msgs []protocol.ChatMessage) bool {
+	for _, m := range msgs {
+		for _, p := range m.Parts {
+			if p.Type == protocol.PartImage {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// EstimateTokens roughly counts the tokens in msgs (words × 1.3), erring
+// low so only prompts that clearly cannot fit are refused. The agent uses
+// the same estimate to decide when to compact history.
+func EstimateTokens(msgs []protocol.ChatMessage) int {
+	words := 0
+	for _, m := range msgs {
+		words += len(strings.Fields(m.Content))
+	}
+	return int(float64(words) * 1.3)
+}
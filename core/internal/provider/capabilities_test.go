@@ -0,0 +1,165 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/h1v3-io/h1v3/pkg/protocol"
+)
+
+func TestLookupCapabilities(t *testing.T) {
+	for _, tc := range []struct {
+		model     string
+		known     bool
+		tools     bool
+		maxOutput int
+	}{
+		{"gpt-4o", true, true, 16384},
+		{"gpt-4o-2024-08-06", true, true, 16384},
+		{"openai/gpt-4o-mini", true, true, 16384},
+		{"o1-mini", true, false, 65536},
+		{"o1-2024-12-17", true, true, 100000},
+		{"claude-3-5-sonnet-20241022", true, true, 8192},
+		{"gpt-4oops", false, true, 0},
+		{"llama3.1:8b", false, true, 0},
+	} {
+		caps, ok := LookupCapabilities(tc.model)
+		if ok != tc.known || caps.Tools != tc.tools || caps.MaxOutput != tc.maxOutput {
+			t.Errorf("%s: got %+v known=%v", tc.model, caps, ok)
+		}
+	}
+}
+
+// captureOpenAI returns a provider whose server records the last request.
+func captureOpenAI(t *testing.T, opts ...OpenAIOption) (*OpenAIProvider, *openaiRequest) {
+	t.Helper()
+	var got openaiRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = openaiRequest{}
+		json.NewDecoder(r.Body).Decode(&got)
+		json.NewEncoder(w).Encode(openaiResponse{Choices: []openaiChoice{{Message: openaiMessage{Role: "assistant", Content: "{}"}}}})
+	}))
+	t.Cleanup(srv.Close)
+	return NewOpenAI("k", append([]OpenAIOption{WithBaseURL(srv.URL)}, opts...)...), &got
+}
+
+func TestCapabilities_AdaptsRequest(t *testing.T) {
+	p, got := captureOpenAI(t, WithModel("local-model"), WithOpenAICapabilities(Capabilities{MaxOutput: 1000}))
+
+	_, err := p.Chat(context.Background(), protocol.ChatRequest{
+		Messages:       []protocol.ChatMessage{{Role: "user", Content: "hi"}},
+		Tools:          []protocol.ToolDefinition{{Type: "function"}},
+		MaxTokens:      4000,
+		ResponseFormat: &protocol.ResponseFormat{Type: protocol.FormatJSONObject},
+	})
+	if err != nil {
+		t.Fatalf("chat: %v", err)
+	}
+	if len(got.Tools) != 0 {
+		t.Errorf("tools should be dropped, got %d", len(got.Tools))
+	}
+	if got.MaxTokens == nil || *got.MaxTokens != 1000 {
+		t.Errorf("max_tokens should be clamped to 1000, got %v", got.MaxTokens)
+	}
+	if got.ResponseFormat != nil {
+		t.Error("response_format should be dropped")
+	}
+	last := got.Messages[len(got.Messages)-1]
+	if last.Role != "system" || !strings.Contains(last.Content, "valid JSON") {
+		t.Errorf("expected JSON instruction as last message, got %+v", last)
+	}
+}
+
+func TestCapabilities_LogsToInjectedLogger(t *testing.T) {
+	var logs bytes.Buffer
+	p, _ := captureOpenAI(t, WithModel("local-model"), WithOpenAICapabilities(Capabilities{}),
+		WithLogger(slog.New(slog.NewTextHandler(&logs, nil))))
+
+	_, err := p.Chat(context.Background(), protocol.ChatRequest{
+		Messages: []protocol.ChatMessage{{Role: "user", Content: "hi"}},
+		Tools:    []protocol.ToolDefinition{{Type: "function"}},
+	})
+	if err != nil {
+		t.Fatalf("chat: %v", err)
+	}
+	if !strings.Contains(logs.String(), "model does not support tools") {
+		t.Errorf("expected the adaptation in the injected logger, got:\n%s", logs.String())
+	}
+}
+
+func TestCapabilities_OverridesOnlyApplyToConfiguredModel(t *testing.T) {
+	p, got := captureOpenAI(t, WithModel("local-model"), WithOpenAICapabilities(Capabilities{MaxOutput: 1000, MaxContext: 10}))
+
+	// An agent-level model override gets its own table entry, not the
+	// limits configured for local-model.
+	_, err := p.Chat(context.Background(), protocol.ChatRequest{
+		Model:     "gpt-4o",
+		Messages:  []protocol.ChatMessage{{Role: "user", Content: strings.Repeat("word ", 50)}},
+		Tools:     []protocol.ToolDefinition{{Type: "function"}},
+		MaxTokens: 4000,
+	})
+	if err != nil {
+		t.Fatalf("chat: %v", err)
+	}
+	if len(got.Tools) != 1 || got.MaxTokens == nil || *got.MaxTokens != 4000 {
+		t.Errorf("gpt-4o request should pass through, got tools=%d max_tokens=%v", len(got.Tools), got.MaxTokens)
+	}
+
+	// The configured model still gets the overrides.
+	_, err = p.Chat(context.Background(), protocol.ChatRequest{
+		Messages: []protocol.ChatMessage{{Role: "user", Content: strings.Repeat("word ", 50)}},
+	})
+	if err == nil || !strings.Contains(err.Error(), "context window") {
+		t.Errorf("expected the configured context limit to apply, got %v", err)
+	}
+}
+
+func TestCapabilities_DropsImagePartsWithoutVision(t *testing.T) {
+	var g capabilityGuard
+	msgs := []protocol.ChatMessage{{
+		Role:    "tool",
+		Content: "[image: chart.png]",
+		Parts:   []protocol.ContentPart{{Type: protocol.PartImage, MimeType: "image/png", Data: []byte{1}}},
+	}}
+	req, err := g.adapt("test", "deepseek-chat", "deepseek-chat", protocol.ChatRequest{Messages: msgs})
+	if err != nil {
+		t.Fatalf("adapt: %v", err)
+	}
+	if req.Messages[0].Parts != nil || req.Messages[0].Content != "[image: chart.png]" {
+		t.Errorf("expected text-only message, got %+v", req.Messages[0])
+	}
+	if msgs[0].Parts == nil {
+		t.Error("caller's messages must not be modified")
+	}
+}
+
+func TestCapabilities_KnownModelUnchanged(t *testing.T) {
+	p, got := captureOpenAI(t)
+	_, err := p.Chat(context.Background(), protocol.ChatRequest{
+		Messages:  []protocol.ChatMessage{{Role: "user", Content: "hi"}},
+		Tools:     []protocol.ToolDefinition{{Type: "function"}},
+		MaxTokens: 4000,
+	})
+	if err != nil {
+		t.Fatalf("chat: %v", err)
+	}
+	if len(got.Tools) != 1 || *got.MaxTokens != 4000 {
+		t.Errorf("gpt-4o request should pass through, got tools=%d max_tokens=%d", len(got.Tools), *got.MaxTokens)
+	}
+}
+
+func TestCapabilities_ContextOverflowFailsFast(t *testing.T) {
+	p, _ := captureOpenAI(t, WithOpenAICapabilities(Capabilities{Tools: true, MaxContext: 10}))
+	_, err := p.Chat(context.Background(), protocol.ChatRequest{
+		Messages: []protocol.ChatMessage{{Role: "user", Content: strings.Repeat("word ", 50)}},
+	})
+	if err == nil || !strings.Contains(err.Error(), "10-token context window") {
+		t.Fatalf("expected context window error, got %v", err)
+	}
+}
@@ -0,0 +1,106 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// Embedder is implemented by providers that can turn text into vector
+// embeddings, e.g. for semantic memory search. Vectors are returned in the
+// order of texts and all have the model's dimension.
+type Embedder interface {
+	Embed(ctx context.Context, texts []string) ([][]float32, error)
+}
+
+// defaultEmbeddingModel is used by OpenAIProvider.Embed unless
+// WithEmbeddingModel sets another.
+const defaultEmbeddingModel = "text-embedding-3-small"
+
+// WithEmbeddingModel sets the model Embed uses (default
+// text-embedding-3-small).
+func WithEmbeddingModel(model string) OpenAIOption {
+	return func(p *OpenAIProvider) { p.embedModel = model }
+}
+
+type openaiEmbeddingRequest struct {
+	Model string   `json:"model"`
+	Input []string `json:"input"`
+}
+
+type openaiEmbeddingResponse struct {
+	Data []struct {
+		Index     int       `json:"index"`
+		Embedding []float32 `json:"embedding"`
+	} `json:"data"`
+	Usage openaiUsage `json:"usage"`
+}
+
+// Embed returns an embedding for each text from the /embeddings endpoint.
+func (p *OpenAIProvider) Embed(ctx context.Context, texts []string) (vectors [][]float32, err error) {
+	if len(texts) == 0 {
+		return nil, nil
+	}
+	model := p.embedModel
+	if model == "" {
+		model = defaultEmbeddingModel
+	}
+	payload, err := json.Marshal(openaiEmbeddingRequest{Model: model, Input: texts})
+	if err != nil {
+		return nil, fmt.Errorf("marshal embeddings request: %w", err)
+	}
+
+	url := p.baseURL + "/embeddings"
+	ex := Exchange{Provider: "openai", URL: url, Request: string(payload)}
+	start := time.Now()
+	defer func() {
+		ex.Duration = time.Since(start)
+		ex.Err = err
+		logExchange(p.requestLog, p.apiKey, ex)
+	}()
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Authorization", "Bearer "+p.apiKey)
+
+	resp, err := p.retry.do("openai", p.client, httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("http request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	ex.Status, ex.Response = resp.StatusCode, string(respBody)
+	if err != nil {
+		return nil, fmt.Errorf("read response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, &APIError{StatusCode: resp.StatusCode, Body: string(respBody)}
+	}
+
+	var out openaiEmbeddingResponse
+	if err := json.Unmarshal(respBody, &out); err != nil {
+		return nil, fmt.Errorf("unmarshal embeddings response: %w", err)
+	}
+	ex.Usage.PromptTokens = out.Usage.PromptTokens
+	vectors = make([][]float32, len(texts))
+	for _, d := range out.Data {
+		if d.Index < 0 || d.Index >= len(texts) {
+			return nil, fmt.Errorf("embeddings response: index %d out of range", d.Index)
+		}
+		vectors[d.Index] = d.Embedding
+	}
+	for i, v := range vectors {
+		if v == nil {
+			return nil, fmt.Errorf("embeddings response: no embedding for input %d", i)
+		}
+	}
+	return vectors, nil
+}
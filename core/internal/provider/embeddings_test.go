@@ -0,0 +1,61 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestOpenAIEmbed(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/embeddings" {
+			t.Errorf("path = %s", r.URL.Path)
+		}
+		var req openaiEmbeddingRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatalf("decode request: %v", err)
+		}
+		if req.Model != "embed-small" || !reflect.DeepEqual(req.Input, []string{"a", "b"}) {
+			t.Errorf("unexpected request %+v", req)
+		}
+		// Out of order, as the API allows.
+		w.Write([]byte(`{"data":[{"index":1,"embedding":[0,1]},{"index":0,"embedding":[1,0]}],"usage":{"prompt_tokens":2}}`))
+	}))
+	defer srv.Close()
+
+	p := NewOpenAI("test-key", WithBaseURL(srv.URL), WithEmbeddingModel("embed-small"))
+	got, err := p.Embed(context.Background(), []string{"a", "b"})
+	if err != nil {
+		t.Fatalf("Embed: %v", err)
+	}
+	if want := [][]float32{{1, 0}, {0, 1}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestOpenAIEmbed_Errors(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req openaiEmbeddingRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		if len(req.Input) > 1 {
+			w.Write([]byte(`{"data":[{"index":0,"embedding":[1]}]}`))
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"error":"no such model"}`))
+	}))
+	defer srv.Close()
+
+	p := NewOpenAI("test-key", WithBaseURL(srv.URL), WithMaxRetries(0))
+	var apiErr *APIError
+	if _, err := p.Embed(context.Background(), []string{"a"}); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusNotFound {
+		t.Errorf("expected a 404 APIError, got %v", err)
+	}
+	if _, err := p.Embed(context.Background(), []string{"a", "b"}); err == nil {
+		t.Error("expected an error for a missing embedding")
+	}
+}
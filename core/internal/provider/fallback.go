@@ -0,0 +1,97 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/h1v3-io/h1v3/pkg/protocol"
+)
+
+// FallbackProvider tries a chain of providers in order, moving on to the next
+// only when the previous one failed with a retryable error. A model named in
+// the request is meant for the primary; the others use their own default.
+type FallbackProvider struct {
+	Logger *slog.Logger // optional; defaults to slog.Default()
+
+	chain []Provider
+}
+
+// NewFallback wraps primary with secondaries tried in order on retryable
+// failures (see IsRetryable).
+func NewFallback(primary Provider, secondaries ...Provider) *FallbackProvider {
+	return &FallbackProvider{chain: append([]Provider{primary}, secondaries...)}
+}
+
+func (f *FallbackProvider) Name() string {
+	names := make([]string, len(f.chain))
+	for i, p := range f.chain {
+		names[i] = p.Name()
+	}
+	return "fallback(" + strings.Join(names, ",") + ")"
+}
+
+func (f *FallbackProvider) Chat(ctx context.Context, req protocol.ChatRequest) (*protocol.ChatResponse, error) {
+	var resp *protocol.ChatResponse
+	err := f.each(req, func(p Provider, req protocol.ChatRequest) (err error) {
+		resp, err = p.Chat(ctx, req)
+		return err
+	})
+	return resp, err
+}
+
+// ChatStream streams from the first provider that accepts the request,
+// falling back as Chat does. Providers that cannot stream are called with
+// Chat and their response replayed as chunks. Once a stream has started,
+// a failure is reported on the channel rather than retried.
+func (f *FallbackProvider) ChatStream(ctx context.Context, req protocol.ChatRequest) (<-chan protocol.StreamChunk, error) {
+	var ch <-chan protocol.StreamChunk
+	err := f.each(req, func(p Provider, req protocol.ChatRequest) error {
+		if sp, ok := p.(StreamingProvider); ok {
+			var err error
+			ch, err = sp.ChatStream(ctx, req)
+			return err
+		}
+		resp, err := p.Chat(ctx, req)
+		if err == nil {
+			ch = responseStream(resp)
+		}
+		return err
+	})
+	return ch, err
+}
+
+// each calls try with each provider in turn until one succeeds or fails
+// with an error that is not retryable. Providers after the first get req
+// without its model.
+func (f *FallbackProvider) each(req protocol.ChatRequest, try func(Provider, protocol.ChatRequest) error) error {
+	logger := f.Logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	var lastErr error
+	for i, p := range f.chain {
+		if i == 1 {
+			req.Model = ""
+		}
+		err := try(p, req)
+		if err == nil {
+			if i > 0 {
+				logger.Info("fallback provider served the request", "provider", p.Name(), "primary", f.chain[0].Name())
+			}
+			return nil
+		}
+		lastErr = err
+		if !IsRetryable(err) || i == len(f.chain)-1 {
+			break
+		}
+		logger.Warn("provider failed, falling back",
+			"provider", p.Name(),
+			"next", f.chain[i+1].Name(),
+			"error", err,
+		)
+	}
+	return fmt.Errorf("fallback: %w", lastErr)
+}
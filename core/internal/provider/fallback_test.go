@@ -0,0 +1,129 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"strings"
+	"syscall"
+	"testing"
+
+	"github.com/h1v3-io/h1v3/pkg/protocol"
+)
+
+type stubProvider struct {
+	name  string
+	err   error
+	calls int
+	model string // of the last request
+}
+
+func (s *stubProvider) Name() string { return s.name }
+
+func (s *stubProvider) Chat(_ context.Context, req protocol.ChatRequest) (*protocol.ChatResponse, error) {
+	s.calls++
+	s.model = req.Model
+	if s.err != nil {
+		return nil, s.err
+	}
+	return &protocol.ChatResponse{Content: "from " + s.name}, nil
+}
+
+func TestFallback_RetryableFallsThrough(t *testing.T) {
+	primary := &stubProvider{name: "a", err: &APIError{StatusCode: 503, Body: "overloaded"}}
+	secondary := &stubProvider{name: "b"}
+
+	var logs bytes.Buffer
+	f := NewFallback(primary, secondary)
+	f.Logger = slog.New(slog.NewTextHandler(&logs, nil))
+	resp, err := f.Chat(context.Background(), protocol.ChatRequest{Model: "a-large"})
+	if err != nil {
+		t.Fatalf("Chat: %v", err)
+	}
+	if resp.Content != "from b" {
+		t.Errorf("expected secondary response, got %q", resp.Content)
+	}
+	if primary.calls != 1 || secondary.calls != 1 {
+		t.Errorf("calls = %d/%d, want 1/1", primary.calls, secondary.calls)
+	}
+	if primary.model != "a-large" || secondary.model != "" {
+		t.Errorf("models = %q/%q, want the override on the primary only", primary.model, secondary.model)
+	}
+	if !strings.Contains(logs.String(), `msg="fallback provider served the request" provider=b`) {
+		t.Errorf("expected the serving provider logged, got:\n%s", logs.String())
+	}
+}
+
+func TestFallback_NonRetryableStops(t *testing.T) {
+	primary := &stubProvider{name: "a", err: &APIError{StatusCode: 400, Body: "bad request"}}
+	secondary := &stubProvider{name: "b"}
+
+	_, err := NewFallback(primary, secondary).Chat(context.Background(), protocol.ChatRequest{})
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != 400 {
+		t.Fatalf("expected 400 APIError, got %v", err)
+	}
+	if secondary.calls != 0 {
+		t.Errorf("secondary should not be called on a bad request")
+	}
+}
+
+func TestFallback_AllFail(t *testing.T) {
+	a := &stubProvider{name: "a", err: &APIError{StatusCode: 429}}
+	b := &stubProvider{name: "b", err: fmt.Errorf("connection refused")}
+
+	p := NewFallback(a, b)
+	_, err := p.Chat(context.Background(), protocol.ChatRequest{})
+	if err == nil || err.Error() != "fallback: connection refused" {
+		t.Errorf("expected last error, got %v", err)
+	}
+	if p.Name() != "fallback(a,b)" {
+		t.Errorf("Name = %q", p.Name())
+	}
+}
+
+func TestIsRetryable(t *testing.T) {
+	cases := []struct {
+		err  error
+		want bool
+	}{
+		{nil, false},
+		{context.Canceled, false},
+		{&APIError{StatusCode: 400}, false},
+		{&APIError{StatusCode: 401}, false},
+		{&APIError{StatusCode: 408}, true},
+		{&APIError{StatusCode: 429}, true},
+		{&APIError{StatusCode: 500}, true},
+		{fmt.Errorf("wrapped: %w", &APIError{StatusCode: 502}), true},
+		{&APIError{StatusCode: 529}, true},
+		{fmt.Errorf("http request: %w", &net.OpError{Op: "dial", Net: "tcp", Err: syscall.ECONNREFUSED}), true},
+		{fmt.Errorf("read response: %w", io.ErrUnexpectedEOF), true},
+		{fmt.Errorf("no data for 1m: %w", context.DeadlineExceeded), true},
+		{fmt.Errorf("unmarshal response: %w", &json.SyntaxError{}), false},
+		{errors.New("no choices in response"), false},
+	}
+	for _, c := range cases {
+		if got := IsRetryable(c.err); got != c.want {
+			t.Errorf("IsRetryable(%v) = %v, want %v", c.err, got, c.want)
+		}
+	}
+}
+
+func TestFallback_ChatStream(t *testing.T) {
+	primary := &stubProvider{name: "a", err: &APIError{StatusCode: 529, Body: "overloaded"}}
+	secondary := &stubProvider{name: "b"} // cannot stream; its reply is replayed
+
+	ch, err := NewFallback(primary, secondary).ChatStream(context.Background(), protocol.ChatRequest{})
+	if err != nil {
+		t.Fatalf("ChatStream: %v", err)
+	}
+	resp, err := CollectStream(ch, nil)
+	if err != nil || resp.Content != "from b" {
+		t.Errorf("expected replayed secondary response, got %+v, %v", resp, err)
+	}
+}
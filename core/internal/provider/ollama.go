@@ -0,0 +1,308 @@
+package provider
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/h1v3-io/h1v3/pkg/protocol"
+)
+
+// defaultOllamaBaseURL is where a local Ollama server listens.
+const defaultOllamaBaseURL = "http://localhost:11434"
+
+// OllamaProvider implements Provider for Ollama's native /api/chat endpoint,
+// for models running locally. It needs no API key.
+type OllamaProvider struct {
+	client     *http.Client
+	baseURL    string
+	apiKey     string // optional, for servers behind an authenticating proxy
+	model      string
+	maxTokens  int // used when a request does not set MaxTokens; 0 = model default
+	requestLog RequestLogger
+	guard      capabilityGuard
+	retry      retryPolicy
+}
+
+// OllamaOption configures an OllamaProvider.
+type OllamaOption func(*OllamaProvider)
+
+// WithOllamaModel sets the default model.
+func WithOllamaModel(model string) OllamaOption {
+	return func(p *OllamaProvider) { p.model = model }
+}
+
+// WithOllamaAPIKey sends key as a Bearer token, for an Ollama server behind
+// an authenticating proxy. By default no Authorization header is sent.
+func WithOllamaAPIKey(key string) OllamaOption {
+	return func(p *OllamaProvider) { p.apiKey = key }
+}
+
+// WithOllamaHTTPClient sets a custom HTTP client.
+func WithOllamaHTTPClient(c *http.Client) OllamaOption {
+	return func(p *OllamaProvider) { p.client = c }
+}
+
+// WithOllamaMaxTokens sets the completion token limit (num_predict) sent
+// when a request does not set MaxTokens.
+func WithOllamaMaxTokens(n int) OllamaOption {
+	return func(p *OllamaProvider) { p.maxTokens = n }
+}
+
+// WithOllamaRequestLogger sets a function that receives every raw request
+// and response, e.g. SlogRequestLogger for verbose mode.
+func WithOllamaRequestLogger(fn RequestLogger) OllamaOption {
+	return func(p *OllamaProvider) { p.requestLog = fn }
+}
+
+// WithOllamaCapabilities sets the model capabilities requests are adapted
+// to, replacing the built-in entry from LookupCapabilities.
+func WithOllamaCapabilities(c Capabilities) OllamaOption {
+	return func(p *OllamaProvider) { p.guard.caps = &c }
+}
+
+// WithOllamaMaxRetries sets how many times a request that failed with a
+// network error, 429 or 502/503/504 is retried (default 2); 0 disables
+// retrying.
+func WithOllamaMaxRetries(n int) OllamaOption {
+	return func(p *OllamaProvider) { p.retry = p.retry.withMax(n) }
+}
+
+// WithOllamaRetryBaseDelay sets the wait before the first retry (default
+// 1s). Each further retry waits about twice as long.
+func WithOllamaRetryBaseDelay(d time.Duration) OllamaOption {
+	return func(p *OllamaProvider) { p.retry.base = d }
+}
+
+// WithOllamaLogger sets the logger for retries and capability adaptations
+// (default slog.Default()).
+func WithOllamaLogger(l *slog.Logger) OllamaOption {
+	return func(p *OllamaProvider) { p.retry.logger, p.guard.logger = l, l }
+}
+
+// NewOllama creates a provider for the Ollama server at baseURL (default
+// http://localhost:11434).
+func NewOllama(baseURL string, opts ...OllamaOption) *OllamaProvider {
+	if baseURL == "" {
+		baseURL = defaultOllamaBaseURL
+	}
+	p := &OllamaProvider{
+		client:  &http.Client{Timeout: 120 * time.Second},
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		model:   "llama3.2",
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+func (p *OllamaProvider) Name() string { return "ollama" }
+
+// Chat sends the request with streaming on and concatenates the NDJSON
+// chunks, so a slow local model is bounded by the client timeout between
+// chunks rather than for the whole answer.
+func (p *OllamaProvider) Chat(ctx context.Context, req protocol.ChatRequest) (*protocol.ChatResponse, error) {
+	ch, err := p.ChatStream(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return CollectStream(ch, nil)
+}
+
+// ChatStream is Chat with the response streamed as it is generated: content
+// deltas arrive as chunks, each tool call as one chunk, followed by a Done
+// chunk.
+func (p *OllamaProvider) ChatStream(ctx context.Context, req protocol.ChatRequest) (<-chan protocol.StreamChunk, error) {
+	body, err := p.buildRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("ollama: marshal: %w", err)
+	}
+
+	url := p.baseURL + "/api/chat"
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return nil, fmt.Errorf("ollama: create request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	if p.apiKey != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+p.apiKey)
+	}
+
+	s := &eventStream{
+		client: p.client,
+		req:    httpReq,
+		ex:     Exchange{Provider: "ollama", URL: url, Request: string(payload)},
+		model:  body.Model,
+		log:    p.requestLog,
+		apiKey: p.apiKey,
+		prefix: "ollama: ",
+		retry:  p.retry,
+		parse:  parseOllamaStream,
+	}
+	return s.start(ctx)
+}
+
+// buildRequest adapts req to the model's capabilities and converts it to
+// the wire format.
+func (p *OllamaProvider) buildRequest(req protocol.ChatRequest) (ollamaRequest, error) {
+	model := req.Model
+	if model == "" {
+		model = p.model
+	}
+	if req.MaxTokens <= 0 {
+		req.MaxTokens = p.maxTokens
+	}
+	req, err := p.guard.adapt("ollama", p.model, model, req)
+	if err != nil {
+		return ollamaRequest{}, err
+	}
+
+	body := ollamaRequest{
+		Model:    model,
+		Messages: toOllamaMessages(req.Messages),
+		Tools:    req.Tools,
+		Stream:   true,
+	}
+	if req.MaxTokens > 0 || req.Temperature != nil {
+		body.Options = &ollamaOptions{NumPredict: req.MaxTokens, Temperature: req.Temperature}
+	}
+	if f := req.ResponseFormat; f != nil {
+		if f.Type == protocol.FormatJSONSchema && f.Schema != nil {
+			body.Format = f.Schema
+		} else {
+			body.Format = "json"
+		}
+	}
+	return body, nil
+}
+
+// parseOllamaStream turns /api/chat NDJSON chunks into stream chunks. Tool
+// calls arrive whole and get sequential IDs, since Ollama assigns none. The
+// last chunk has done set and carries the token counts.
+func parseOllamaStream(r io.Reader, emit func(protocol.StreamChunk) bool, ex *Exchange) error {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 64<<10), 8<<20)
+	calls := 0
+	for sc.Scan() {
+		line := bytes.TrimSpace(sc.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		var chunk ollamaChunk
+		if err := json.Unmarshal(line, &chunk); err != nil {
+			return fmt.Errorf("unmarshal chunk: %w", err)
+		}
+		if chunk.Error != "" {
+			return fmt.Errorf("api error: %s", chunk.Error)
+		}
+		if c := chunk.Message.Content; c != "" && !emit(protocol.StreamChunk{ContentDelta: c}) {
+			return nil
+		}
+		for _, tc := range chunk.Message.ToolCalls {
+			delta := &protocol.ToolCallDelta{
+				Index:          calls,
+				ID:             fmt.Sprintf("call_%d", calls),
+				Name:           tc.Function.Name,
+				ArgumentsDelta: ollamaArguments(tc.Function.Arguments),
+			}
+			calls++
+			if !emit(protocol.StreamChunk{ToolCallDelta: delta}) {
+				return nil
+			}
+		}
+		if chunk.Done {
+			ex.Usage = protocol.Usage{PromptTokens: chunk.PromptEvalCount, CompletionTokens: chunk.EvalCount}
+			return nil
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return err
+	}
+	return io.ErrUnexpectedEOF
+}
+
+// ollamaArguments returns tool call arguments as a JSON object. Ollama sends
+// an object, but some models produce a JSON-encoded string instead.
+func ollamaArguments(raw json.RawMessage) string {
+	var s string
+	if json.Unmarshal(raw, &s) == nil {
+		return s
+	}
+	return string(raw)
+}
+
+// --- Ollama wire format types ---
+
+type ollamaRequest struct {
+	Model    string                    `json:"model"`
+	Messages []ollamaMessage           `json:"messages"`
+	Tools    []protocol.ToolDefinition `json:"tools,omitempty"`
+	Stream   bool                      `json:"stream"`
+	Format   any                       `json:"format,omitempty"` // "json" or a JSON schema
+	Options  *ollamaOptions            `json:"options,omitempty"`
+}
+
+type ollamaOptions struct {
+	NumPredict  int      `json:"num_predict,omitempty"`
+	Temperature *float64 `json:"temperature,omitempty"`
+}
+
+type ollamaMessage struct {
+	Role      string           `json:"role"`
+	Content   string           `json:"content"`
+	Images    [][]byte         `json:"images,omitempty"` // base64 in JSON
+	ToolCalls []ollamaToolCall `json:"tool_calls,omitempty"`
+	ToolName  string           `json:"tool_name,omitempty"` // tool results
+}
+
+type ollamaToolCall struct {
+	Function struct {
+		Name      string          `json:"name"`
+		Arguments json.RawMessage `json:"arguments"`
+	} `json:"function"`
+}
+
+type ollamaChunk struct {
+	Message         ollamaMessage `json:"message"`
+	Done            bool          `json:"done"`
+	PromptEvalCount int           `json:"prompt_eval_count"`
+	EvalCount       int           `json:"eval_count"`
+	Error           string        `json:"error"`
+}
+
+// --- Conversion helpers ---
+
+func toOllamaMessages(msgs []protocol.ChatMessage) []ollamaMessage {
+	out := make([]ollamaMessage, len(msgs))
+	for i, m := range msgs {
+		om := ollamaMessage{Role: m.Role, Content: m.Content}
+		if m.Role == "tool" {
+			om.ToolName = m.Name
+		}
+		for _, part := range m.Parts {
+			if part.Type == protocol.PartImage && len(part.Data) > 0 { // Ollama takes no image URLs
+				om.Images = append(om.Images, part.Data)
+			}
+		}
+		for _, tc := range m.ToolCalls {
+			var call ollamaToolCall
+			call.Function.Name = tc.Name
+			call.Function.Arguments, _ = json.Marshal(tc.Arguments)
+			om.ToolCalls = append(om.ToolCalls, call)
+		}
+		out[i] = om
+	}
+	return out
+}
@@ -0,0 +1,167 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/h1v3-io/h1v3/pkg/protocol"
+)
+
+// ndjsonServer answers /api/chat with the given lines and records the
+// request.
+func ndjsonServer(t *testing.T, got *ollamaRequest, lines ...string) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/chat" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		if auth := r.Header.Get("Authorization"); auth != "" {
+			t.Errorf("expected no Authorization header, got %q", auth)
+		}
+		if got != nil {
+			json.NewDecoder(r.Body).Decode(got)
+		}
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		for _, line := range lines {
+			fmt.Fprintln(w, line)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestOllamaChat(t *testing.T) {
+	var req ollamaRequest
+	srv := ndjsonServer(t, &req,
+		`{"model":"qwen3","message":{"role":"assistant","content":"Let me "},"done":false}`,
+		`{"model":"qwen3","message":{"role":"assistant","content":"check."},"done":false}`,
+		`{"model":"qwen3","message":{"role":"assistant","content":"","tool_calls":[{"function":{"name":"read_file","arguments":{"path":"a.go"}}}]},"done":false}`,
+		`{"model":"qwen3","message":{"role":"assistant","content":""},"done":true,"done_reason":"stop","prompt_eval_count":42,"eval_count":7}`,
+	)
+
+	temp := 0.1
+	p := NewOllama(srv.URL, WithOllamaModel("qwen3"))
+	resp, err := p.Chat(context.Background(), protocol.ChatRequest{
+		Messages: []protocol.ChatMessage{
+			{Role: "system", Content: "Be brief."},
+			{Role: "user", Content: "Read a.go"},
+			{Role: "assistant", ToolCalls: []protocol.ToolCall{{ID: "call_0", Name: "list_dir", Arguments: map[string]any{"path": "."}}}},
+			{Role: "tool", Content: "a.go", ToolCallID: "call_0", Name: "list_dir"},
+		},
+		Tools:       []protocol.ToolDefinition{protocol.NewToolDefinition("read_file", "Read a file", map[string]any{"type": "object"})},
+		MaxTokens:   256,
+		Temperature: &temp,
+	})
+	if err != nil {
+		t.Fatalf("Chat: %v", err)
+	}
+
+	if req.Model != "qwen3" || !req.Stream || len(req.Tools) != 1 || req.Tools[0].Function.Name != "read_file" {
+		t.Errorf("unexpected request: %+v", req)
+	}
+	if req.Options == nil || req.Options.NumPredict != 256 || *req.Options.Temperature != 0.1 {
+		t.Errorf("expected num_predict and temperature in options, got %+v", req.Options)
+	}
+	if tc := req.Messages[2].ToolCalls; len(tc) != 1 || tc[0].Function.Name != "list_dir" || string(tc[0].Function.Arguments) != `{"path":"."}` {
+		t.Errorf("expected assistant tool call with object arguments, got %+v", req.Messages[2])
+	}
+	if req.Messages[3].ToolName != "list_dir" {
+		t.Errorf("expected tool_name on the tool result, got %+v", req.Messages[3])
+	}
+
+	if resp.Content != "Let me check." {
+		t.Errorf("content = %q", resp.Content)
+	}
+	if len(resp.ToolCalls) != 1 || resp.ToolCalls[0].ID != "call_0" || resp.ToolCalls[0].Name != "read_file" || resp.ToolCalls[0].Arguments["path"] != "a.go" {
+		t.Errorf("tool calls = %+v", resp.ToolCalls)
+	}
+	if resp.Usage.PromptTokens != 42 || resp.Usage.CompletionTokens != 7 || resp.Model != "qwen3" {
+		t.Errorf("usage = %+v, model = %q", resp.Usage, resp.Model)
+	}
+}
+
+func TestOllamaChat_StringArgumentsAndJSONFormat(t *testing.T) {
+	var req ollamaRequest
+	srv := ndjsonServer(t, &req,
+		`{"message":{"role":"assistant","content":"","tool_calls":[{"function":{"name":"exec","arguments":"{\"command\":\"ls\"}"}}]},"done":false}`,
+		`{"message":{"role":"assistant","content":""},"done":true}`,
+	)
+
+	p := NewOllama(srv.URL)
+	resp, err := p.Chat(context.Background(), protocol.ChatRequest{
+		Messages:       []protocol.ChatMessage{{Role: "user", Content: "hi"}},
+		ResponseFormat: &protocol.ResponseFormat{Type: protocol.FormatJSONObject},
+	})
+	if err != nil {
+		t.Fatalf("Chat: %v", err)
+	}
+	if req.Format != "json" {
+		t.Errorf("expected format json, got %v", req.Format)
+	}
+	if req.Options != nil {
+		t.Errorf("expected no options by default, got %+v", req.Options)
+	}
+	if len(resp.ToolCalls) != 1 || resp.ToolCalls[0].Arguments["command"] != "ls" {
+		t.Errorf("expected string-encoded arguments decoded, got %+v", resp.ToolCalls)
+	}
+}
+
+func TestOllamaChat_Errors(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"error":"model \"nope\" not found, try pulling it first"}`))
+	}))
+	defer srv.Close()
+
+	_, err := NewOllama(srv.URL, WithOllamaModel("nope")).Chat(context.Background(), protocol.ChatRequest{})
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusNotFound || !strings.Contains(err.Error(), "ollama:") {
+		t.Fatalf("expected 404 APIError, got %v", err)
+	}
+
+	srv = ndjsonServer(t, nil,
+		`{"message":{"role":"assistant","content":"par"},"done":false}`,
+		`{"error":"model runner crashed"}`,
+	)
+	if _, err := NewOllama(srv.URL).Chat(context.Background(), protocol.ChatRequest{}); err == nil || !strings.Contains(err.Error(), "model runner crashed") {
+		t.Errorf("expected error chunk surfaced, got %v", err)
+	}
+
+	srv = ndjsonServer(t, nil, `{"message":{"role":"assistant","content":"cut"},"done":false}`)
+	if _, err := NewOllama(srv.URL).Chat(context.Background(), protocol.ChatRequest{}); err == nil {
+		t.Error("expected error for a stream without a done chunk")
+	}
+}
+
+func TestOllamaChatStream(t *testing.T) {
+	srv := ndjsonServer(t, nil,
+		`{"message":{"role":"assistant","content":"Hel"},"done":false}`,
+		`{"message":{"role":"assistant","content":"lo"},"done":false}`,
+		`{"message":{"role":"assistant","content":""},"done":true,"prompt_eval_count":3,"eval_count":2}`,
+	)
+
+	ch, err := NewOllama(srv.URL).ChatStream(context.Background(), protocol.ChatRequest{})
+	if err != nil {
+		t.Fatalf("ChatStream: %v", err)
+	}
+	var deltas []string
+	var last protocol.StreamChunk
+	for c := range ch {
+		if c.ContentDelta != "" {
+			deltas = append(deltas, c.ContentDelta)
+		}
+		last = c
+	}
+	if strings.Join(deltas, "|") != "Hel|lo" {
+		t.Errorf("deltas = %q", deltas)
+	}
+	if !last.Done || last.Usage == nil || last.Usage.TotalTokens() != 5 {
+		t.Errorf("expected Done chunk with usage, got %+v", last)
+	}
+}
@@ -3,10 +3,13 @@ package provider
 import (
 	"bytes"
 	"context"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/h1v3-io/h1v3/pkg/protocol"
@@ -15,10 +18,32 @@ import (
 // OpenAIProvider implements Provider for any OpenAI-compatible API
 // (OpenAI, OpenRouter, DeepSeek, Groq, etc.).
 type OpenAIProvider struct {
-	client  *http.Client
-	baseURL string
-	apiKey  string
-	model   string
+	client     *http.Client
+	baseURL    string
+	apiKey     string
+	model      string
+	maxTokens  int // used when a request does not set MaxTokens; 0 = omit
+	requestLog RequestLogger
+	reasoning  *bool // nil = decide per model with isReasoningModel
+	guard      capabilityGuard
+	retry      retryPolicy
+	embedModel string // see WithEmbeddingModel
+}
+
+// reasoningModelPrefixes are OpenAI model families that reject the system
+// role in favor of developer and do not accept sampling parameters.
+var reasoningModelPrefixes = []string{"o1", "o3", "o4", "gpt-5"}
+
+// isReasoningModel reports whether model belongs to an OpenAI reasoning
+// family. Prefixed names (e.g. OpenRouter's "openai/o3") do not match, since
+// such gateways translate roles themselves.
+func isReasoningModel(model string) bool {
+	for _, prefix := range reasoningModelPrefixes {
+		if model == prefix || strings.HasPrefix(model, prefix+"-") {
+			return true
+		}
+	}
+	return false
 }
 
 // OpenAIOption configures an OpenAIProvider.
@@ -39,6 +64,51 @@ func WithHTTPClient(c *http.Client) OpenAIOption {
 	return func(p *OpenAIProvider) { p.client = c }
 }
 
+// WithOpenAIDefaultMaxTokens sets the completion token limit sent when a
+// request does not set MaxTokens. By default no limit is sent.
+func WithOpenAIDefaultMaxTokens(n int) OpenAIOption {
+	return func(p *OpenAIProvider) { p.maxTokens = n }
+}
+
+// WithRequestLogger sets a function that receives every raw request and
+// response, e.g. SlogRequestLogger for verbose mode.
+func WithRequestLogger(fn RequestLogger) OpenAIOption {
+	return func(p *OpenAIProvider) { p.requestLog = fn }
+}
+
+// WithReasoningModel overrides the model-name heuristic for reasoning-model
+// request quirks. When enabled, system messages are sent with the developer
+// role, temperature is dropped, and max_tokens is sent as
+// max_completion_tokens.
+func WithReasoningModel(enabled bool) OpenAIOption {
+	return func(p *OpenAIProvider) { p.reasoning = &enabled }
+}
+
+// WithOpenAICapabilities sets the model capabilities requests are adapted
+// to, replacing the built-in entry from LookupCapabilities.
+func WithOpenAICapabilities(c Capabilities) OpenAIOption {
+	return func(p *OpenAIProvider) { p.guard.caps = &c }
+}
+
+// WithMaxRetries sets how many times a request that failed with a network
+// error, 429 or 502/503/504 is retried (default 2); 0 disables retrying.
+func WithMaxRetries(n int) OpenAIOption {
+	return func(p *OpenAIProvider) { p.retry = p.retry.withMax(n) }
+}
+
+// WithRetryBaseDelay sets the wait before the first retry (default 1s).
+// Each further retry waits about twice as long, unless the server sends
+// Retry-After.
+func WithRetryBaseDelay(d time.Duration) OpenAIOption {
+	return func(p *OpenAIProvider) { p.retry.base = d }
+}
+
+// WithLogger sets the logger for retries and capability adaptations
+// (default slog.Default()).
+func WithLogger(l *slog.Logger) OpenAIOption {
+	return func(p *OpenAIProvider) { p.retry.logger, p.guard.logger = l, l }
+}
+
 // NewOpenAI creates a new OpenAI-compatible provider.
 func NewOpenAI(apiKey string, opts ...OpenAIOption) *OpenAIProvider {
 	p := &OpenAIProvider{
@@ -55,51 +125,49 @@ func NewOpenAI(apiKey string, opts ...OpenAIOption) *OpenAIProvider {
 
 func (p *OpenAIProvider) Name() string { return "openai" }
 
-func (p *OpenAIProvider) Chat(ctx context.Context, req protocol.ChatRequest) (*protocol.ChatResponse, error) {
-	model := req.Model
-	if model == "" {
-		model = p.model
-	}
-
-	body := openaiRequest{
-		Model:    model,
-		Messages: toOpenAIMessages(req.Messages),
-	}
-	if len(req.Tools) > 0 {
-		body.Tools = req.Tools
-	}
-	if req.MaxTokens > 0 {
-		body.MaxTokens = &req.MaxTokens
-	}
-	if req.Temperature > 0 {
-		body.Temperature = &req.Temperature
+func (p *OpenAIProvider) Chat(ctx context.Context, req protocol.ChatRequest) (result *protocol.ChatResponse, err error) {
+	body, err := p.buildRequest(req)
+	if err != nil {
+		return nil, err
 	}
-
 	payload, err := json.Marshal(body)
 	if err != nil {
 		return nil, fmt.Errorf("marshal request: %w", err)
 	}
 
-	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, p.baseURL+"/chat/completions", bytes.NewReader(payload))
+	url := p.baseURL + "/chat/completions"
+	ex := Exchange{Provider: "openai", URL: url, Request: string(payload)}
+	start := time.Now()
+	defer func() {
+		ex.Duration = time.Since(start)
+		ex.Err = err
+		if result != nil {
+			ex.Usage = result.Usage
+		}
+		logExchange(p.requestLog, p.apiKey, ex)
+	}()
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload))
 	if err != nil {
 		return nil, fmt.Errorf("create request: %w", err)
 	}
 	httpReq.Header.Set("Content-Type", "application/json")
 	httpReq.Header.Set("Authorization", "Bearer "+p.apiKey)
 
-	resp, err := p.client.Do(httpReq)
+	resp, err := p.retry.do("openai", p.client, httpReq)
 	if err != nil {
 		return nil, fmt.Errorf("http request: %w", err)
 	}
 	defer resp.Body.Close()
 
 	respBody, err := io.ReadAll(resp.Body)
+	ex.Status, ex.Response = resp.StatusCode, string(respBody)
 	if err != nil {
 		return nil, fmt.Errorf("read response: %w", err)
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("api error (status %d): %s", resp.StatusCode, string(respBody))
+		return nil, &APIError{StatusCode: resp.StatusCode, Body: string(respBody)}
 	}
 
 	var oaiResp openaiResponse
@@ -107,17 +175,178 @@ func (p *OpenAIProvider) Chat(ctx context.Context, req protocol.ChatRequest) (*p
 		return nil, fmt.Errorf("unmarshal response: %w", err)
 	}
 
-	return parseResponse(&oaiResp)
+	result, err = parseResponse(&oaiResp)
+	if err != nil {
+		return nil, err
+	}
+	result.Model = body.Model
+	return result, nil
+}
+
+// buildRequest adapts req to the model's capabilities and converts it to
+// the wire format, applying the reasoning-model quirks.
+func (p *OpenAIProvider) buildRequest(req protocol.ChatRequest) (openaiRequest, error) {
+	model := req.Model
+	if model == "" {
+		model = p.model
+	}
+
+	if req.MaxTokens <= 0 {
+		req.MaxTokens = p.maxTokens
+	}
+	req, err := p.guard.adapt("openai", p.model, model, req)
+	if err != nil {
+		return openaiRequest{}, err
+	}
+
+	reasoning := isReasoningModel(model)
+	if p.reasoning != nil {
+		reasoning = *p.reasoning
+	}
+	maxTokens := req.MaxTokens
+
+	body := openaiRequest{
+		Model:    model,
+		Messages: toOpenAIMessages(req.Messages),
+	}
+	if len(req.Tools) > 0 {
+		body.Tools = req.Tools
+	}
+	if reasoning {
+		for i := range body.Messages {
+			if body.Messages[i].Role == "system" {
+				body.Messages[i].Role = "developer"
+			}
+		}
+		if maxTokens > 0 {
+			body.MaxCompletionTokens = &maxTokens
+		}
+	} else {
+		if maxTokens > 0 {
+			body.MaxTokens = &maxTokens
+		}
+		if req.Temperature != nil {
+			body.Temperature = req.Temperature
+		}
+	}
+	body.ResponseFormat = toOpenAIResponseFormat(req.ResponseFormat)
+	return body, nil
+}
+
+// ChatStream is Chat with the response streamed as it is generated: content
+// and tool-call argument deltas arrive as chunks, followed by a Done chunk.
+func (p *OpenAIProvider) ChatStream(ctx context.Context, req protocol.ChatRequest) (<-chan protocol.StreamChunk, error) {
+	body, err := p.buildRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	body.Stream = true
+	body.StreamOptions = &openaiStreamOptions{IncludeUsage: true}
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("marshal request: %w", err)
+	}
+
+	url := p.baseURL + "/chat/completions"
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Accept", "text/event-stream")
+	httpReq.Header.Set("Authorization", "Bearer "+p.apiKey)
+
+	s := &eventStream{
+		client: p.client,
+		req:    httpReq,
+		ex:     Exchange{Provider: "openai", URL: url, Request: string(payload)},
+		model:  body.Model,
+		log:    p.requestLog,
+		apiKey: p.apiKey,
+		retry:  p.retry,
+		parse:  parseOpenAIStream,
+	}
+	return s.start(ctx)
+}
+
+// parseOpenAIStream turns chat.completion.chunk events into stream chunks.
+// The stream ends with a [DONE] event; servers that omit it must at least
+// have sent a finish reason.
+func parseOpenAIStream(r io.Reader, emit func(protocol.StreamChunk) bool, ex *Exchange) error {
+	var finished, done bool
+	var parseErr error
+	err := readSSE(r, func(_, data string) bool {
+		if data == "[DONE]" {
+			done = true
+			return false
+		}
+		var chunk openaiStreamChunk
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			parseErr = fmt.Errorf("unmarshal chunk: %w", err)
+			return false
+		}
+		if chunk.Error != nil {
+			parseErr = fmt.Errorf("api error: %s", chunk.Error.Message)
+			return false
+		}
+		if chunk.Usage != nil {
+			ex.Usage = protocol.Usage{PromptTokens: chunk.Usage.PromptTokens, CompletionTokens: chunk.Usage.CompletionTokens}
+		}
+		for _, choice := range chunk.Choices {
+			if choice.FinishReason != nil {
+				finished = true
+			}
+			if choice.Delta.Content != "" && !emit(protocol.StreamChunk{ContentDelta: choice.Delta.Content}) {
+				return false
+			}
+			for _, tc := range choice.Delta.ToolCalls {
+				delta := &protocol.ToolCallDelta{Index: tc.Index, ID: tc.ID, Name: tc.Function.Name, ArgumentsDelta: tc.Function.Arguments}
+				if !emit(protocol.StreamChunk{ToolCallDelta: delta}) {
+					return false
+				}
+			}
+		}
+		return true
+	})
+	switch {
+	case parseErr != nil:
+		return parseErr
+	case err != nil:
+		return err
+	case !done && !finished:
+		return io.ErrUnexpectedEOF
+	}
+	return nil
 }
 
 // --- OpenAI wire format types ---
 
 type openaiRequest struct {
-	Model       string                 `json:"model"`
-	Messages    []openaiMessage        `json:"messages"`
-	Tools       []protocol.ToolDefinition `json:"tools,omitempty"`
-	MaxTokens   *int                   `json:"max_tokens,omitempty"`
-	Temperature *float64               `json:"temperature,omitempty"`
+	Model          string                    `json:"model"`
+	Messages       []openaiMessage           `json:"messages"`
+	Tools          []protocol.ToolDefinition `json:"tools,omitempty"`
+	MaxTokens      *int                      `json:"max_tokens,omitempty"`
+	Temperature    *float64                  `json:"temperature,omitempty"`
+	ResponseFormat *openaiResponseFormat     `json:"response_format,omitempty"`
+
+	MaxCompletionTokens *int                 `json:"max_completion_tokens,omitempty"` // reasoning models
+	Stream              bool                 `json:"stream,omitempty"`
+	StreamOptions       *openaiStreamOptions `json:"stream_options,omitempty"`
+}
+
+// openaiStreamOptions asks for a final chunk carrying the token usage.
+type openaiStreamOptions struct {
+	IncludeUsage bool `json:"include_usage"`
+}
+
+type openaiResponseFormat struct {
+	Type       string            `json:"type"`
+	JSONSchema *openaiJSONSchema `json:"json_schema,omitempty"`
+}
+
+type openaiJSONSchema struct {
+	Name   string         `json:"name"`
+	Schema map[string]any `json:"schema,omitempty"`
 }
 
 type openaiMessage struct {
@@ -126,6 +355,32 @@ type openaiMessage struct {
 	ToolCalls  []openaiToolCall     `json:"tool_calls,omitempty"`
 	ToolCallID string              `json:"tool_call_id,omitempty"`
 	Name       string              `json:"name,omitempty"`
+
+	// Parts replaces Content on the wire when set; see MarshalJSON.
+	Parts []openaiContentPart `json:"-"`
+}
+
+// MarshalJSON sends Content as an array of parts when Parts is set.
+func (m openaiMessage) MarshalJSON() ([]byte, error) {
+	type plain openaiMessage
+	if len(m.Parts) == 0 {
+		return json.Marshal(plain(m))
+	}
+	return json.Marshal(struct {
+		plain
+		Content []openaiContentPart `json:"content"`
+	}{plain(m), m.Parts})
+}
+
+// openaiContentPart is one element of an array message content.
+type openaiContentPart struct {
+	Type     string          `json:"type"` // "text" or "image_url"
+	Text     string          `json:"text,omitempty"`
+	ImageURL *openaiImageURL `json:"image_url,omitempty"`
+}
+
+type openaiImageURL struct {
+	URL string `json:"url"` // http(s) or a base64 data URL
 }
 
 type openaiToolCall struct {
@@ -148,6 +403,26 @@ type openaiChoice struct {
 	Message openaiMessage `json:"message"`
 }
 
+// openaiStreamChunk is one chat.completion.chunk event of a streamed
+// response.
+type openaiStreamChunk struct {
+	Choices []struct {
+		Delta struct {
+			Content   string `json:"content"`
+			ToolCalls []struct {
+				Index    int                `json:"index"`
+				ID       string             `json:"id"`
+				Function openaiToolFunction `json:"function"`
+			} `json:"tool_calls"`
+		} `json:"delta"`
+		FinishReason *string `json:"finish_reason"`
+	} `json:"choices"`
+	Usage *openaiUsage `json:"usage"`
+	Error *struct {
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
 type openaiUsage struct {
 	PromptTokens     int `json:"prompt_tokens"`
 	CompletionTokens int `json:"completion_tokens"`
@@ -164,6 +439,9 @@ func toOpenAIMessages(msgs []protocol.ChatMessage) []openaiMessage {
 			ToolCallID: m.ToolCallID,
 			Name:       m.Name,
 		}
+		if m.Role == "user" {
+			om.Parts = toOpenAIParts(m.Parts)
+		}
 		for _, tc := range m.ToolCalls {
 			args, _ := json.Marshal(tc.Arguments)
 			om.ToolCalls = append(om.ToolCalls, openaiToolCall{
@@ -180,6 +458,44 @@ func toOpenAIMessages(msgs []protocol.ChatMessage) []openaiMessage {
 	return out
 }
 
+func toOpenAIResponseFormat(f *protocol.ResponseFormat) *openaiResponseFormat {
+	if f == nil {
+		return nil
+	}
+	if f.Type != protocol.FormatJSONSchema {
+		return &openaiResponseFormat{Type: protocol.FormatJSONObject}
+	}
+	name := f.Name
+	if name == "" {
+		name = "response"
+	}
+	return &openaiResponseFormat{
+		Type:       protocol.FormatJSONSchema,
+		JSONSchema: &openaiJSONSchema{Name: name, Schema: f.Schema},
+	}
+}
+
+// toOpenAIParts converts typed content parts for a user message, the only
+// role whose content may hold images. JSON parts are sent as text.
+func toOpenAIParts(parts []protocol.ContentPart) []openaiContentPart {
+	var out []openaiContentPart
+	for _, p := range parts {
+		switch p.Type {
+		case protocol.PartImage:
+			url := p.URL
+			if url == "" {
+				url = "data:" + p.MimeType + ";base64," + base64.StdEncoding.EncodeToString(p.Data)
+			}
+			out = append(out, openaiContentPart{Type: "image_url", ImageURL: &openaiImageURL{URL: url}})
+		case protocol.PartJSON:
+			out = append(out, openaiContentPart{Type: "text", Text: string(p.Data)})
+		default:
+			out = append(out, openaiContentPart{Type: "text", Text: p.Text})
+		}
+	}
+	return out
+}
+
 func parseResponse(resp *openaiResponse) (*protocol.ChatResponse, error) {
 	if len(resp.Choices) == 0 {
 		return nil, fmt.Errorf("no choices in response")
@@ -188,15 +504,9 @@ func parseResponse(resp *openaiResponse) (*protocol.ChatResponse, error) {
 
 	var toolCalls []protocol.ToolCall
 	for _, tc := range msg.ToolCalls {
-		var args map[string]any
-		if err := json.Unmarshal([]byte(tc.Function.Arguments), &args); err != nil {
-			args = map[string]any{"_raw": tc.Function.Arguments}
-		}
-		toolCalls = append(toolCalls, protocol.ToolCall{
-			ID:        tc.ID,
-			Name:      tc.Function.Name,
-			Arguments: args,
-		})
+		call := protocol.ToolCall{ID: tc.ID, Name: tc.Function.Name}
+		parseToolArguments(&call, tc.Function.Arguments)
+		toolCalls = append(toolCalls, call)
 	}
 
 	return &protocol.ChatResponse{
@@ -3,8 +3,11 @@ package provider
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/h1v3-io/h1v3/pkg/protocol"
@@ -119,6 +122,42 @@ func TestOpenAIChat_ToolCallResponse(t *testing.T) {
 	}
 }
 
+func TestOpenAIChat_MalformedToolArguments(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		resp := openaiResponse{
+			Choices: []openaiChoice{{
+				Message: openaiMessage{
+					Role: "assistant",
+					ToolCalls: []openaiToolCall{
+						{ID: "c1", Type: "function", Function: openaiToolFunction{Name: "read_file", Arguments: `{"path": "/tmp/`}},
+						{ID: "c2", Type: "function", Function: openaiToolFunction{Name: "list_dir", Arguments: ""}},
+					},
+				},
+			}},
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(resp)
+	}))
+	defer srv.Close()
+
+	p := NewOpenAI("test-key", WithBaseURL(srv.URL))
+	got, err := p.Chat(context.Background(), protocol.ChatRequest{
+		Messages: []protocol.ChatMessage{{Role: "user", Content: "Read the file"}},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(got.ToolCalls) != 2 {
+		t.Fatalf("expected 2 tool calls, got %d", len(got.ToolCalls))
+	}
+	if tc := got.ToolCalls[0]; tc.ArgumentsError == "" || len(tc.Arguments) != 0 {
+		t.Errorf("expected ArgumentsError and empty arguments, got %+v", tc)
+	}
+	if tc := got.ToolCalls[1]; tc.ArgumentsError != "" || tc.Arguments == nil {
+		t.Errorf("empty arguments should decode to an empty map, got %+v", tc)
+	}
+}
+
 func TestOpenAIChat_APIError(t *testing.T) {
 	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusTooManyRequests)
@@ -126,7 +165,7 @@ func TestOpenAIChat_APIError(t *testing.T) {
 	}))
 	defer srv.Close()
 
-	p := NewOpenAI("test-key", WithBaseURL(srv.URL))
+	p := NewOpenAI("test-key", WithBaseURL(srv.URL), WithMaxRetries(0))
 
 	_, err := p.Chat(context.Background(), protocol.ChatRequest{
 		Messages: []protocol.ChatMessage{{Role: "user", Content: "Hi"}},
@@ -135,3 +174,239 @@ func TestOpenAIChat_APIError(t *testing.T) {
 		t.Fatal("expected error for 429 status")
 	}
 }
+
+func TestOpenAIChat_ImageParts(t *testing.T) {
+	var raw map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&raw)
+		json.NewEncoder(w).Encode(openaiResponse{
+			Choices: []openaiChoice{{Message: openaiMessage{Role: "assistant", Content: "A cat."}}},
+		})
+	}))
+	defer srv.Close()
+
+	_, err := NewOpenAI("test-key", WithBaseURL(srv.URL), WithModel("gpt-4o")).Chat(context.Background(), protocol.ChatRequest{
+		Messages: []protocol.ChatMessage{
+			{Role: "system", Content: "Be brief."},
+			{Role: "user", Content: "What is this? [attachment: cat.png]", Parts: []protocol.ContentPart{
+				{Type: protocol.PartText, Text: "What is this?"},
+				{Type: protocol.PartImage, MimeType: "image/png", Data: []byte("png")},
+			}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("chat: %v", err)
+	}
+
+	msgs := raw["messages"].([]any)
+	if system := msgs[0].(map[string]any)["content"]; system != "Be brief." {
+		t.Errorf("system content should stay a string, got %v", system)
+	}
+	got, _ := json.Marshal(msgs[1].(map[string]any)["content"])
+	want := `[{"text":"What is this?","type":"text"},{"image_url":{"url":"data:image/png;base64,cG5n"},"type":"image_url"}]`
+	if string(got) != want {
+		t.Errorf("user content = %s\nwant %s", got, want)
+	}
+}
+
+func TestOpenAIChat_ResponseFormat(t *testing.T) {
+	var raw []map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req map[string]any
+		json.NewDecoder(r.Body).Decode(&req)
+		raw = append(raw, req)
+		json.NewEncoder(w).Encode(openaiResponse{
+			Choices: []openaiChoice{{Message: openaiMessage{Role: "assistant", Content: `{"ok":true}`}}},
+		})
+	}))
+	defer srv.Close()
+
+	p := NewOpenAI("test-key", WithBaseURL(srv.URL))
+	msgs := []protocol.ChatMessage{{Role: "user", Content: "Hi"}}
+	schema := map[string]any{"type": "object", "properties": map[string]any{"ok": map[string]any{"type": "boolean"}}}
+
+	for _, req := range []protocol.ChatRequest{
+		{Messages: msgs},
+		{Messages: msgs, ResponseFormat: &protocol.ResponseFormat{Type: protocol.FormatJSONObject}},
+		{Messages: msgs, ResponseFormat: &protocol.ResponseFormat{Type: protocol.FormatJSONSchema, Name: "verdict", Schema: schema}},
+	} {
+		if _, err := p.Chat(context.Background(), req); err != nil {
+			t.Fatalf("chat: %v", err)
+		}
+	}
+
+	if _, ok := raw[0]["response_format"]; ok {
+		t.Errorf("response_format should be omitted by default, got %v", raw[0]["response_format"])
+	}
+	got, _ := json.Marshal(raw[1]["response_format"])
+	if string(got) != `{"type":"json_object"}` {
+		t.Errorf("json_object response_format = %s", got)
+	}
+	got, _ = json.Marshal(raw[2]["response_format"])
+	want := `{"json_schema":{"name":"verdict","schema":{"properties":{"ok":{"type":"boolean"}},"type":"object"}},"type":"json_schema"}`
+	if string(got) != want {
+		t.Errorf("json_schema response_format = %s, want %s", got, want)
+	}
+}
+
+func TestOpenAIChat_ReasoningModels(t *testing.T) {
+	var raw map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		raw = nil
+		json.NewDecoder(r.Body).Decode(&raw)
+		json.NewEncoder(w).Encode(openaiResponse{
+			Choices: []openaiChoice{{Message: openaiMessage{Role: "assistant", Content: "ok"}}},
+		})
+	}))
+	defer srv.Close()
+
+	temp := 0.2
+	req := protocol.ChatRequest{
+		Messages:    []protocol.ChatMessage{{Role: "system", Content: "Be brief."}, {Role: "user", Content: "Hi"}},
+		MaxTokens:   100,
+		Temperature: &temp,
+	}
+	firstRole := func() string {
+		msgs, _ := raw["messages"].([]any)
+		m, _ := msgs[0].(map[string]any)
+		role, _ := m["role"].(string)
+		return role
+	}
+
+	cases := []struct {
+		name      string
+		opts      []OpenAIOption
+		reasoning bool
+	}{
+		{"gpt-4o keeps system role", []OpenAIOption{WithModel("gpt-4o")}, false},
+		{"o3-mini uses developer role", []OpenAIOption{WithModel("o3-mini")}, true},
+		{"gateway-prefixed name is left alone", []OpenAIOption{WithModel("openai/o3")}, false},
+		{"option overrides heuristic", []OpenAIOption{WithModel("my-finetune"), WithReasoningModel(true)}, true},
+		{"option disables heuristic", []OpenAIOption{WithModel("o1"), WithReasoningModel(false)}, false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			p := NewOpenAI("test-key", append([]OpenAIOption{WithBaseURL(srv.URL)}, tc.opts...)...)
+			if _, err := p.Chat(context.Background(), req); err != nil {
+				t.Fatalf("chat: %v", err)
+			}
+			_, hasTemp := raw["temperature"]
+			_, hasMax := raw["max_tokens"]
+			_, hasMaxCompletion := raw["max_completion_tokens"]
+			if tc.reasoning {
+				if firstRole() != "developer" || hasTemp || hasMax || !hasMaxCompletion {
+					t.Errorf("expected reasoning request, got %v", raw)
+				}
+			} else {
+				if firstRole() != "system" || !hasTemp || !hasMax || hasMaxCompletion {
+					t.Errorf("expected standard request, got %v", raw)
+				}
+			}
+		})
+	}
+}
+
+func TestOpenAIChat_DefaultMaxTokens(t *testing.T) {
+	var raw map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		raw = nil
+		json.NewDecoder(r.Body).Decode(&raw)
+		json.NewEncoder(w).Encode(openaiResponse{
+			Choices: []openaiChoice{{Message: openaiMessage{Role: "assistant", Content: "ok"}}},
+		})
+	}))
+	defer srv.Close()
+
+	msgs := []protocol.ChatMessage{{Role: "user", Content: "Hi"}}
+	p := NewOpenAI("test-key", WithBaseURL(srv.URL))
+	p.Chat(context.Background(), protocol.ChatRequest{Messages: msgs})
+	if _, ok := raw["max_tokens"]; ok {
+		t.Errorf("expected no max_tokens by default, got %v", raw["max_tokens"])
+	}
+
+	p = NewOpenAI("test-key", WithBaseURL(srv.URL), WithOpenAIDefaultMaxTokens(512))
+	p.Chat(context.Background(), protocol.ChatRequest{Messages: msgs})
+	if raw["max_tokens"] != float64(512) {
+		t.Errorf("max_tokens = %v, want 512", raw["max_tokens"])
+	}
+	p.Chat(context.Background(), protocol.ChatRequest{Messages: msgs, MaxTokens: 64})
+	if raw["max_tokens"] != float64(64) {
+		t.Errorf("request max_tokens = %v, want 64", raw["max_tokens"])
+	}
+}
+
+func TestOpenAIChatStream(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req openaiRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		if !req.Stream || req.StreamOptions == nil || !req.StreamOptions.IncludeUsage {
+			t.Error("expected stream:true with include_usage in request")
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, data := range []string{
+			`{"choices":[{"delta":{"role":"assistant","content":"Let me "}}]}`,
+			`{"choices":[{"delta":{"content":"look."}}]}`,
+			`{"choices":[{"delta":{"tool_calls":[{"index":0,"id":"c1","function":{"name":"read_file","arguments":""}}]}}]}`,
+			`{"choices":[{"delta":{"tool_calls":[{"index":0,"function":{"arguments":"{\"path\":"}}]}}]}`,
+			`{"choices":[{"delta":{"tool_calls":[{"index":0,"function":{"arguments":"\"a.go\"}"}}]},"finish_reason":"tool_calls"}]}`,
+			`{"choices":[],"usage":{"prompt_tokens":12,"completion_tokens":8}}`,
+			`[DONE]`,
+		} {
+			fmt.Fprintf(w, "data: %s\n\n", data)
+		}
+	}))
+	defer srv.Close()
+
+	p := NewOpenAI("test-key", WithBaseURL(srv.URL))
+	ch, err := p.ChatStream(context.Background(), protocol.ChatRequest{
+		Messages: []protocol.ChatMessage{{Role: "user", Content: "Hi"}},
+	})
+	if err != nil {
+		t.Fatalf("ChatStream: %v", err)
+	}
+	var deltas []string
+	resp, err := CollectStream(ch, func(c protocol.StreamChunk) { deltas = append(deltas, c.ContentDelta) })
+	if err != nil {
+		t.Fatalf("CollectStream: %v", err)
+	}
+	if resp.Content != "Let me look." || deltas[0] != "Let me " {
+		t.Errorf("content = %q, deltas = %q", resp.Content, deltas)
+	}
+	if len(resp.ToolCalls) != 1 || resp.ToolCalls[0].Name != "read_file" || resp.ToolCalls[0].Arguments["path"] != "a.go" {
+		t.Errorf("tool calls = %+v", resp.ToolCalls)
+	}
+	if resp.Usage.TotalTokens() != 20 || resp.Model != "gpt-4o" {
+		t.Errorf("expected usage and model from the Done chunk, got %+v, %q", resp.Usage, resp.Model)
+	}
+}
+
+func TestOpenAIChatStream_Errors(t *testing.T) {
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			w.WriteHeader(http.StatusTooManyRequests)
+			w.Write([]byte(`{"error":{"message":"slow down"}}`))
+			return
+		}
+		fmt.Fprint(w, "data: {\"choices\":[{\"delta\":{\"content\":\"cut\"}}]}\n\n")
+	}))
+	defer srv.Close()
+
+	// API errors are returned before the stream starts, so fallbacks apply.
+	p := NewOpenAI("k", WithBaseURL(srv.URL), WithMaxRetries(0))
+	_, err := p.ChatStream(context.Background(), protocol.ChatRequest{})
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusTooManyRequests {
+		t.Fatalf("expected 429 APIError, got %v", err)
+	}
+
+	// A stream that ends without finishing is an error, not a short answer.
+	ch, err := p.ChatStream(context.Background(), protocol.ChatRequest{})
+	if err != nil {
+		t.Fatalf("ChatStream: %v", err)
+	}
+	if _, err := CollectStream(ch, nil); err == nil || !strings.Contains(err.Error(), "unexpected EOF") {
+		t.Errorf("expected truncated stream error, got %v", err)
+	}
+}
@@ -2,6 +2,15 @@ package provider
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"syscall"
 
 	"github.com/h1v3-io/h1v3/pkg/protocol"
 )
@@ -11,3 +20,66 @@ type Provider interface {
 	Chat(ctx context.Context, req protocol.ChatRequest) (*protocol.ChatResponse, error)
 	Name() string
 }
+
+// APIError is a non-200 response from an LLM API.
+type APIError struct {
+	Provider   string // prefix for the error message, e.g. "anthropic"; may be empty
+	StatusCode int
+	Body       string
+}
+
+func (e *APIError) Error() string {
+	msg := fmt.Sprintf("api error (status %d): %s", e.StatusCode, e.Body)
+	if e.Provider != "" {
+		return e.Provider + ": " + msg
+	}
+	return msg
+}
+
+// IsRetryable reports whether a Chat error is worth retrying, possibly on
+// another provider: timeouts, rate limits (429), server errors (5xx, incl.
+// Anthropic's 529) and network failures are. Anything else, such as a bad
+// request, an auth error, a response that does not decode, or cancellation,
+// would fail the same way again.
+func IsRetryable(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode == http.StatusTooManyRequests ||
+			apiErr.StatusCode == http.StatusRequestTimeout ||
+			apiErr.StatusCode >= 500
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) ||
+		errors.Is(err, context.DeadlineExceeded) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, io.EOF) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.ECONNREFUSED)
+}
+
+// parseToolArguments decodes a tool call's JSON arguments. Invalid JSON is
+// reported through ToolCall.ArgumentsError rather than failing the response,
+// so the agent can ask the model to retry that one call. Empty arguments
+// decode to an empty map.
+func parseToolArguments(tc *protocol.ToolCall, raw string) {
+	tc.Arguments = map[string]any{}
+	if strings.TrimSpace(raw) == "" {
+		return
+	}
+	if err := json.Unmarshal([]byte(raw), &tc.Arguments); err != nil {
+		tc.Arguments = map[string]any{}
+		tc.ArgumentsError = fmt.Sprintf("%v in %s", err, truncateArgs(raw))
+	}
+}
+
+// truncateArgs shortens raw arguments quoted in an error message.
+func truncateArgs(raw string) string {
+	const max = 200
+	if len(raw) > max {
+		raw = raw[:max] + "..."
+	}
+	return strconv.Quote(raw)
+}
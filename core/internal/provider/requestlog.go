@@ -0,0 +1,82 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/h1v3-io/h1v3/pkg/protocol"
+)
+
+// maxLoggedBody caps how much of a request or response body is logged.
+const maxLoggedBody = 8 << 10
+
+// Exchange is one raw round trip with an LLM API, passed to a RequestLogger.
+// Bodies have the provider's API key masked.
+type Exchange struct {
+	Provider string
+	URL      string
+	Request  string
+	Response string // empty if the request failed before a response arrived
+	Status   int
+	Duration time.Duration
+	Usage    protocol.Usage
+	Err      error
+}
+
+// RequestLogger receives every exchange a provider makes. Set it with
+// WithRequestLogger or WithAnthropicRequestLogger.
+type RequestLogger func(Exchange)
+
+// logExchange masks the API key in the bodies and hands the exchange to fn.
+func logExchange(fn RequestLogger, apiKey string, ex Exchange) {
+	if fn == nil {
+		return
+	}
+	if len(apiKey) >= 6 {
+		ex.Request = strings.ReplaceAll(ex.Request, apiKey, "[REDACTED]")
+		ex.Response = strings.ReplaceAll(ex.Response, apiKey, "[REDACTED]")
+	}
+	fn(ex)
+}
+
+// SlogRequestLogger returns a RequestLogger that writes each exchange to
+// logger at debug level, with bodies passed through redact (if non-nil) and
+// truncated to a readable size.
+func SlogRequestLogger(logger *slog.Logger, redact func(string) string) RequestLogger {
+	return func(ex Exchange) {
+		if !logger.Enabled(context.Background(), slog.LevelDebug) {
+			return
+		}
+		req, resp := ex.Request, ex.Response
+		if redact != nil {
+			req, resp = redact(req), redact(resp)
+		}
+		attrs := []any{
+			"provider", ex.Provider,
+			"url", ex.URL,
+			"status", ex.Status,
+			"duration", ex.Duration,
+			"prompt_tokens", ex.Usage.PromptTokens,
+			"completion_tokens", ex.Usage.CompletionTokens,
+			"request", truncateBody(req),
+			"response", truncateBody(resp),
+		}
+		if ex.Usage.CacheCreationTokens > 0 || ex.Usage.CacheReadTokens > 0 {
+			attrs = append(attrs, "cache_creation_tokens", ex.Usage.CacheCreationTokens, "cache_read_tokens", ex.Usage.CacheReadTokens)
+		}
+		if ex.Err != nil {
+			attrs = append(attrs, "error", ex.Err)
+		}
+		logger.Debug("provider exchange", attrs...)
+	}
+}
+
+func truncateBody(s string) string {
+	if len(s) <= maxLoggedBody {
+		return s
+	}
+	return s[:maxLoggedBody] + fmt.Sprintf("... [truncated, %d bytes total]", len(s))
+}
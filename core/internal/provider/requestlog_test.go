@@ -0,0 +1,105 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/h1v3-io/h1v3/pkg/protocol"
+)
+
+func TestRequestLogger_OpenAI(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(openaiResponse{
+			Choices: []openaiChoice{{Message: openaiMessage{Role: "assistant", Content: "echo sk-secret-key-123"}}},
+			Usage:   openaiUsage{PromptTokens: 7, CompletionTokens: 3},
+		})
+	}))
+	defer srv.Close()
+
+	var got []Exchange
+	p := NewOpenAI("sk-secret-key-123", WithBaseURL(srv.URL), WithRequestLogger(func(ex Exchange) { got = append(got, ex) }))
+	if _, err := p.Chat(context.Background(), protocol.ChatRequest{
+		Messages: []protocol.ChatMessage{{Role: "user", Content: "my key is sk-secret-key-123"}},
+	}); err != nil {
+		t.Fatalf("chat: %v", err)
+	}
+
+	if len(got) != 1 {
+		t.Fatalf("expected 1 exchange, got %d", len(got))
+	}
+	ex := got[0]
+	if ex.Provider != "openai" || ex.Status != http.StatusOK || ex.Usage.TotalTokens() != 10 || ex.Err != nil {
+		t.Errorf("unexpected exchange: %+v", ex)
+	}
+	if !strings.Contains(ex.Request, `"my key is [REDACTED]"`) || strings.Contains(ex.Response, "sk-secret-key-123") {
+		t.Errorf("API key should be masked:\nrequest: %s\nresponse: %s", ex.Request, ex.Response)
+	}
+}
+
+func TestRequestLogger_AnthropicError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTooManyRequests)
+		w.Write([]byte(`{"error":"slow down"}`))
+	}))
+	defer srv.Close()
+
+	var got []Exchange
+	p := NewAnthropic("test-key", WithAnthropicBaseURL(srv.URL), WithAnthropicMaxRetries(0), WithAnthropicRequestLogger(func(ex Exchange) { got = append(got, ex) }))
+	if _, err := p.Chat(context.Background(), protocol.ChatRequest{
+		Messages: []protocol.ChatMessage{{Role: "user", Content: "Hi"}},
+	}); err == nil {
+		t.Fatal("expected API error")
+	}
+
+	if len(got) != 1 || got[0].Status != http.StatusTooManyRequests || got[0].Err == nil || got[0].Response != `{"error":"slow down"}` {
+		t.Errorf("unexpected exchange: %+v", got)
+	}
+}
+
+func TestSlogRequestLogger(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	log := SlogRequestLogger(logger, func(s string) string { return strings.ReplaceAll(s, "hunter2", "***") })
+
+	log(Exchange{Provider: "openai", Request: "password hunter2", Response: strings.Repeat("x", maxLoggedBody+100)})
+
+	var entry map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("decode log: %v", err)
+	}
+	if entry["request"] != "password ***" {
+		t.Errorf("request not redacted: %v", entry["request"])
+	}
+	resp, _ := entry["response"].(string)
+	if !strings.HasSuffix(resp, "[truncated, 8292 bytes total]") || len(resp) > maxLoggedBody+64 {
+		t.Errorf("response not truncated: len %d, tail %q", len(resp), resp[len(resp)-40:])
+	}
+
+	// Nothing is logged above debug level.
+	buf.Reset()
+	quiet := SlogRequestLogger(slog.New(slog.NewJSONHandler(&buf, nil)), nil)
+	quiet(Exchange{Provider: "openai"})
+	if buf.Len() != 0 {
+		t.Errorf("expected no output at info level, got %s", buf.String())
+	}
+}
+
+func TestSlogRequestLogger_RedactsBeforeTruncating(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	log := SlogRequestLogger(logger, func(s string) string { return strings.ReplaceAll(s, "hunter2hunter2", "***") })
+
+	// The secret straddles the truncation point; cutting first would leave a
+	// prefix no redactor recognises.
+	log(Exchange{Provider: "openai", Response: strings.Repeat("x", maxLoggedBody-5) + "hunter2hunter2" + strings.Repeat("y", 100)})
+
+	if strings.Contains(buf.String(), "hunter") {
+		t.Errorf("secret leaked at the truncation point: %s", buf.String()[buf.Len()-200:])
+	}
+}
@@ -0,0 +1,136 @@
+package provider
+
+import (
+	"io"
+	"log/slog"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Defaults for retryPolicy.
+const (
+	defaultMaxRetries     = 2
+	defaultRetryBaseDelay = time.Second
+
+	// maxRetryDelay caps a wait. A server asking for a longer one gets its
+	// error returned instead, so a fallback provider can take over.
+	maxRetryDelay = 30 * time.Second
+)
+
+// retryPolicy retries transient failures of an API call. The zero value
+// uses the defaults.
+type retryPolicy struct {
+	max    int           // retries after the first attempt; < 0 disables
+	base   time.Duration // first backoff, doubled for each further retry
+	logger *slog.Logger  // nil = slog.Default()
+}
+
+func (r retryPolicy) maxRetries() int {
+	switch {
+	case r.max < 0:
+		return 0
+	case r.max == 0:
+		return defaultMaxRetries
+	}
+	return r.max
+}
+
+// withMax returns r retrying up to n times; n <= 0 disables retrying.
+func (r retryPolicy) withMax(n int) retryPolicy {
+	if n <= 0 {
+		n = -1
+	}
+	r.max = n
+	return r
+}
+
+// retryableStatus reports whether a response status is worth retrying:
+// rate limits, gateway errors and Anthropic's 529 overloaded.
+func retryableStatus(code int) bool {
+	switch code {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout, 529:
+		return true
+	}
+	return false
+}
+
+// do sends req with client, retrying network errors and retryable
+// statuses with exponential backoff and jitter, or after the server's
+// Retry-After. It returns the last response or error.
+func (r retryPolicy) do(name string, client *http.Client, req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	logger := r.logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+	for attempt := 0; ; attempt++ {
+		attemptReq := req
+		if attempt > 0 && req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			attemptReq = req.Clone(ctx)
+			attemptReq.Body = body
+		}
+		resp, err := client.Do(attemptReq)
+		if attempt >= r.maxRetries() || ctx.Err() != nil || (err == nil && !retryableStatus(resp.StatusCode)) {
+			return resp, err
+		}
+
+		delay := r.backoff(attempt)
+		reason := any(err)
+		if resp != nil {
+			if after, ok := retryAfter(resp.Header.Get("Retry-After"), time.Now()); ok {
+				delay = after
+			}
+			if delay > maxRetryDelay {
+				return resp, nil
+			}
+			io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+			resp.Body.Close()
+			reason = resp.Status
+		}
+		logger.Warn("provider request failed, retrying",
+			"provider", name,
+			"attempt", attempt+1,
+			"error", reason,
+			"delay", delay,
+		)
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// backoff returns the wait before retry attempt+1: base·2^attempt, of
+// which the upper half is random.
+func (r retryPolicy) backoff(attempt int) time.Duration {
+	base := r.base
+	if base <= 0 {
+		base = defaultRetryBaseDelay
+	}
+	d := min(base<<attempt, maxRetryDelay)
+	return d/2 + rand.N(d/2+1)
+}
+
+// retryAfter parses a Retry-After header given as seconds or an HTTP date.
+func retryAfter(v string, now time.Time) (time.Duration, bool) {
+	if v == "" {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(v); err == nil && secs >= 0 {
+		return time.Duration(secs) * time.Second, true
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		return max(t.Sub(now), 0), true
+	}
+	return 0, false
+}
@@ -0,0 +1,123 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/h1v3-io/h1v3/pkg/protocol"
+)
+
+// flakyServer fails the first failures requests with status, then answers
+// as an OpenAI-compatible API would.
+func flakyServer(t *testing.T, failures int32, status int, header http.Header) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) <= failures {
+			for k, v := range header {
+				w.Header()[k] = v
+			}
+			w.WriteHeader(status)
+			w.Write([]byte(`{"error":{"message":"try later"}}`))
+			return
+		}
+		w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"ok"}}],"content":[{"type":"text","text":"ok"}]}`))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &calls
+}
+
+func TestRetry_RateLimitedThenOK(t *testing.T) {
+	req := protocol.ChatRequest{Messages: []protocol.ChatMessage{{Role: "user", Content: "Hi"}}}
+
+	srv, calls := flakyServer(t, 2, http.StatusTooManyRequests, nil)
+	p := NewOpenAI("k", WithBaseURL(srv.URL), WithRetryBaseDelay(time.Millisecond))
+	resp, err := p.Chat(context.Background(), req)
+	if err != nil || resp.Content != "ok" || calls.Load() != 3 {
+		t.Errorf("openai: expected success on third call, got %v after %d calls", err, calls.Load())
+	}
+
+	srv, calls = flakyServer(t, 2, http.StatusTooManyRequests, nil)
+	a := NewAnthropic("k", WithAnthropicBaseURL(srv.URL), WithAnthropicRetryBaseDelay(time.Millisecond))
+	resp, err = a.Chat(context.Background(), req)
+	if err != nil || resp.Content != "ok" || calls.Load() != 3 {
+		t.Errorf("anthropic: expected success on third call, got %v after %d calls", err, calls.Load())
+	}
+}
+
+func TestRetry_LogsToInjectedLogger(t *testing.T) {
+	var logs bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&logs, nil))
+
+	srv, _ := flakyServer(t, 1, http.StatusServiceUnavailable, nil)
+	p := NewOpenAI("k", WithBaseURL(srv.URL), WithRetryBaseDelay(time.Millisecond), WithLogger(logger))
+	if _, err := p.Chat(context.Background(), protocol.ChatRequest{}); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(logs.String(), "provider request failed, retrying") {
+		t.Errorf("expected the retry in the injected logger, got:\n%s", logs.String())
+	}
+}
+
+func TestRetry_GivesUp(t *testing.T) {
+	srv, calls := flakyServer(t, 10, http.StatusServiceUnavailable, nil)
+	p := NewOpenAI("k", WithBaseURL(srv.URL), WithMaxRetries(1), WithRetryBaseDelay(time.Millisecond))
+	_, err := p.Chat(context.Background(), protocol.ChatRequest{})
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusServiceUnavailable || calls.Load() != 2 {
+		t.Errorf("expected 503 after 2 calls, got %v after %d", err, calls.Load())
+	}
+}
+
+func TestRetry_NotRetryable(t *testing.T) {
+	for _, status := range []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusInternalServerError} {
+		srv, calls := flakyServer(t, 10, status, nil)
+		p := NewOpenAI("k", WithBaseURL(srv.URL), WithRetryBaseDelay(time.Millisecond))
+		if _, err := p.Chat(context.Background(), protocol.ChatRequest{}); err == nil || calls.Load() != 1 {
+			t.Errorf("%d: expected one call and an error, got %v after %d", status, err, calls.Load())
+		}
+	}
+}
+
+func TestRetry_RetryAfter(t *testing.T) {
+	// A long Retry-After is not waited out; the error goes to the caller.
+	srv, calls := flakyServer(t, 1, http.StatusTooManyRequests, http.Header{"Retry-After": {"120"}})
+	p := NewOpenAI("k", WithBaseURL(srv.URL), WithRetryBaseDelay(time.Millisecond))
+	if _, err := p.Chat(context.Background(), protocol.ChatRequest{}); err == nil || calls.Load() != 1 {
+		t.Errorf("expected no retry past the delay cap, got %v after %d calls", err, calls.Load())
+	}
+
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	for v, want := range map[string]time.Duration{
+		"3":                             3 * time.Second,
+		"Thu, 01 Jan 2026 00:00:10 GMT": 10 * time.Second,
+		"Wed, 31 Dec 2025 00:00:00 GMT": 0,
+	} {
+		if got, ok := retryAfter(v, now); !ok || got != want {
+			t.Errorf("retryAfter(%q) = %v, %v; want %v", v, got, ok, want)
+		}
+	}
+	if _, ok := retryAfter("soon", now); ok {
+		t.Error("expected invalid Retry-After to be ignored")
+	}
+}
+
+func TestRetry_Backoff(t *testing.T) {
+	r := retryPolicy{base: 100 * time.Millisecond}
+	for attempt, want := range []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 400 * time.Millisecond} {
+		if d := r.backoff(attempt); d < want/2 || d > want {
+			t.Errorf("backoff(%d) = %v, want within [%v, %v]", attempt, d, want/2, want)
+		}
+	}
+	if d := r.backoff(20); d > maxRetryDelay {
+		t.Errorf("backoff not capped: %v", d)
+	}
+}
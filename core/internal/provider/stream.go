@@ -0,0 +1,249 @@
+package provider
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/h1v3-io/h1v3/pkg/protocol"
+)
+
+// StreamingProvider is a Provider that can also stream responses as they are
+// generated. The channel is closed after a Done chunk, a chunk with Err set,
+// or when ctx is cancelled.
+type StreamingProvider interface {
+	Provider
+	ChatStream(ctx context.Context, req protocol.ChatRequest) (<-chan protocol.StreamChunk, error)
+}
+
+// CollectStream drains a stream, passing each chunk to onChunk (which may be
+// nil), and assembles the complete response.
+func CollectStream(ch <-chan protocol.StreamChunk, onChunk func(protocol.StreamChunk)) (*protocol.ChatResponse, error) {
+	var content strings.Builder
+	var calls []protocol.ToolCall
+	var args []string // JSON argument fragments, per call
+	var usage protocol.Usage
+	var model string
+
+	for chunk := range ch {
+		if chunk.Err != nil {
+			return nil, chunk.Err
+		}
+		if onChunk != nil {
+			onChunk(chunk)
+		}
+		content.WriteString(chunk.ContentDelta)
+		if d := chunk.ToolCallDelta; d != nil {
+			for len(calls) <= d.Index {
+				calls = append(calls, protocol.ToolCall{})
+				args = append(args, "")
+			}
+			if d.ID != "" {
+				calls[d.Index].ID = d.ID
+			}
+			if d.Name != "" {
+				calls[d.Index].Name = d.Name
+			}
+			args[d.Index] += d.ArgumentsDelta
+		}
+		if chunk.Done {
+			if chunk.Usage != nil {
+				usage = *chunk.Usage
+			}
+			model = chunk.Model
+			break
+		}
+	}
+
+	// Arguments are only complete once every fragment has arrived.
+	for i := range calls {
+		parseToolArguments(&calls[i], args[i])
+	}
+	return &protocol.ChatResponse{Content: content.String(), ToolCalls: calls, Usage: usage, Model: model}, nil
+}
+
+// responseStream replays a complete response as a stream: its content,
+// one delta per tool call, then Done.
+func responseStream(resp *protocol.ChatResponse) <-chan protocol.StreamChunk {
+	ch := make(chan protocol.StreamChunk, len(resp.ToolCalls)+2)
+	if resp.Content != "" {
+		ch <- protocol.StreamChunk{ContentDelta: resp.Content}
+	}
+	for i, tc := range resp.ToolCalls {
+		args, _ := json.Marshal(tc.Arguments)
+		ch <- protocol.StreamChunk{ToolCallDelta: &protocol.ToolCallDelta{Index: i, ID: tc.ID, Name: tc.Name, ArgumentsDelta: string(args)}}
+	}
+	usage := resp.Usage
+	ch <- protocol.StreamChunk{Done: true, Usage: &usage, Model: resp.Model}
+	close(ch)
+	return ch
+}
+
+// eventStream is one streaming request to an LLM API that answers with
+// server-sent events.
+type eventStream struct {
+	client *http.Client
+	req    *http.Request
+	ex     Exchange // logged when the stream ends, with the raw events as the response
+	model  string   // reported on the Done chunk
+	log    RequestLogger
+	apiKey string
+	prefix string      // error prefix, e.g. "anthropic: "
+	retry  retryPolicy // applies until the stream starts
+
+	// parse reads events from r and passes chunks to emit, stopping early
+	// when emit returns false. It returns nil once the response is
+	// complete, without emitting Done. It may record usage in ex.
+	parse func(r io.Reader, emit func(protocol.StreamChunk) bool, ex *Exchange) error
+}
+
+// start sends the request and, once the API accepts it, parses the stream
+// in a goroutine that owns the returned channel. The client's timeout
+// bounds each wait for data rather than the whole stream, so long answers
+// are not cut off. Cancelling ctx closes the channel and the response body.
+func (s *eventStream) start(ctx context.Context) (<-chan protocol.StreamChunk, error) {
+	timeout := s.client.Timeout
+	client := *s.client
+	client.Timeout = 0
+
+	reqCtx, cancel := context.WithCancel(ctx)
+	var idle atomic.Bool
+	var timer *time.Timer
+	if timeout > 0 {
+		timer = time.AfterFunc(timeout, func() {
+			idle.Store(true)
+			cancel()
+		})
+	}
+	begin := time.Now()
+	// explain maps a failure to the error callers see.
+	explain := func(err error, what string) error {
+		switch {
+		case idle.Load():
+			return fmt.Errorf("%sno data for %s: %w", s.prefix, timeout, context.DeadlineExceeded)
+		case ctx.Err() != nil:
+			return ctx.Err()
+		default:
+			return fmt.Errorf("%s%s: %w", s.prefix, what, err)
+		}
+	}
+	finish := func(err error) {
+		if timer != nil {
+			timer.Stop()
+		}
+		cancel()
+		s.ex.Duration = time.Since(begin)
+		s.ex.Err = err
+		logExchange(s.log, s.apiKey, s.ex)
+	}
+
+	resp, err := s.retry.do(s.ex.Provider, &client, s.req.WithContext(reqCtx))
+	if err != nil {
+		err = explain(err, "http request")
+		finish(err)
+		return nil, err
+	}
+	s.ex.Status = resp.StatusCode
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		s.ex.Response = string(body)
+		err := &APIError{Provider: strings.TrimSuffix(s.prefix, ": "), StatusCode: resp.StatusCode, Body: string(body)}
+		finish(err)
+		return nil, err
+	}
+
+	ch := make(chan protocol.StreamChunk)
+	go func() {
+		defer close(ch)
+		defer resp.Body.Close()
+
+		emit := func(c protocol.StreamChunk) bool {
+			select {
+			case ch <- c:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+		var r io.Reader = &idleReader{r: resp.Body, timer: timer, timeout: timeout}
+		var raw strings.Builder
+		if s.log != nil {
+			r = io.TeeReader(r, &raw)
+		}
+		err := s.parse(r, emit, &s.ex)
+		s.ex.Response = raw.String()
+		if err != nil {
+			err = explain(err, "stream")
+		} else if err = ctx.Err(); err != nil {
+			finish(err) // parse stopped because the caller left
+			return
+		}
+		// Log before the last chunk, so the exchange is recorded by the
+		// time the caller sees the stream end.
+		finish(err)
+		last := protocol.StreamChunk{Done: err == nil, Err: err}
+		if err == nil {
+			last.Model = s.model
+			if s.ex.Usage.TotalTokens() > 0 {
+				usage := s.ex.Usage
+				last.Usage = &usage
+			}
+		}
+		emit(last)
+	}()
+	return ch, nil
+}
+
+// idleReader restarts timer on every read, so it only fires when the
+// stream stalls.
+type idleReader struct {
+	r       io.Reader
+	timer   *time.Timer // nil = no timeout
+	timeout time.Duration
+}
+
+func (r *idleReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	if r.timer != nil {
+		r.timer.Reset(r.timeout)
+	}
+	return n, err
+}
+
+// readSSE calls fn with the event name and data of each server-sent event
+// in r until fn returns false or r ends. Data split over several lines is
+// joined with newlines; comments and unknown fields are skipped.
+func readSSE(r io.Reader, fn func(event, data string) bool) error {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 64<<10), 8<<20)
+	var event string
+	var data []string
+	for sc.Scan() {
+		line := sc.Text()
+		switch {
+		case line == "":
+			if len(data) > 0 && !fn(event, strings.Join(data, "\n")) {
+				return nil
+			}
+			event, data = "", nil
+		case strings.HasPrefix(line, "event:"):
+			event = strings.TrimSpace(line[len("event:"):])
+		case strings.HasPrefix(line, "data:"):
+			data = append(data, strings.TrimPrefix(line[len("data:"):], " "))
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return err
+	}
+	if len(data) > 0 {
+		fn(event, strings.Join(data, "\n"))
+	}
+	return nil
+}
@@ -0,0 +1,128 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/h1v3-io/h1v3/pkg/protocol"
+)
+
+func TestCollectStream(t *testing.T) {
+	ch := make(chan protocol.StreamChunk, 8)
+	ch <- protocol.StreamChunk{ContentDelta: "Let me "}
+	ch <- protocol.StreamChunk{ContentDelta: "check."}
+	ch <- protocol.StreamChunk{ToolCallDelta: &protocol.ToolCallDelta{Index: 0, ID: "c1", Name: "read_file", ArgumentsDelta: `{"pa`}}
+	ch <- protocol.StreamChunk{ToolCallDelta: &protocol.ToolCallDelta{Index: 1, ID: "c2", Name: "list_dir"}}
+	ch <- protocol.StreamChunk{ToolCallDelta: &protocol.ToolCallDelta{Index: 0, ArgumentsDelta: `th":"a.go"}`}}
+	ch <- protocol.StreamChunk{Done: true}
+	close(ch)
+
+	var seen int
+	resp, err := CollectStream(ch, func(protocol.StreamChunk) { seen++ })
+	if err != nil {
+		t.Fatalf("CollectStream: %v", err)
+	}
+	if seen != 6 {
+		t.Errorf("onChunk called %d times, want 6", seen)
+	}
+	if resp.Content != "Let me check." {
+		t.Errorf("content = %q", resp.Content)
+	}
+	if len(resp.ToolCalls) != 2 || resp.ToolCalls[0].Arguments["path"] != "a.go" || resp.ToolCalls[1].Name != "list_dir" {
+		t.Errorf("tool calls = %+v", resp.ToolCalls)
+	}
+}
+
+func TestCollectStream_Error(t *testing.T) {
+	ch := make(chan protocol.StreamChunk, 2)
+	ch <- protocol.StreamChunk{ContentDelta: "partial"}
+	ch <- protocol.StreamChunk{Err: errors.New("connection reset")}
+	close(ch)
+
+	if _, err := CollectStream(ch, nil); err == nil || err.Error() != "connection reset" {
+		t.Errorf("expected stream error, got %v", err)
+	}
+}
+
+func TestCollectStream_MalformedArguments(t *testing.T) {
+	ch := make(chan protocol.StreamChunk, 4)
+	ch <- protocol.StreamChunk{ToolCallDelta: &protocol.ToolCallDelta{Index: 0, ID: "c1", Name: "write_file", ArgumentsDelta: `{"path":"a.go",`}}
+	ch <- protocol.StreamChunk{ToolCallDelta: &protocol.ToolCallDelta{Index: 0, ArgumentsDelta: `"content":"trunc`}}
+	ch <- protocol.StreamChunk{Done: true}
+	close(ch)
+
+	resp, err := CollectStream(ch, nil)
+	if err != nil {
+		t.Fatalf("CollectStream: %v", err)
+	}
+	if len(resp.ToolCalls) != 1 {
+		t.Fatalf("tool calls = %+v", resp.ToolCalls)
+	}
+	tc := resp.ToolCalls[0]
+	if tc.ArgumentsError == "" || len(tc.Arguments) != 0 {
+		t.Errorf("expected ArgumentsError and empty arguments, got %+v", tc)
+	}
+}
+
+func TestChatStream_CancelClosesStream(t *testing.T) {
+	closed := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "data: {\"choices\":[{\"delta\":{\"content\":\"first\"}}]}\n\n")
+		w.(http.Flusher).Flush()
+		<-r.Context().Done() // the client hung up
+		close(closed)
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	ch, err := NewOpenAI("k", WithBaseURL(srv.URL)).ChatStream(ctx, protocol.ChatRequest{})
+	if err != nil {
+		t.Fatalf("ChatStream: %v", err)
+	}
+	if c := <-ch; c.ContentDelta != "first" {
+		t.Fatalf("unexpected first chunk %+v", c)
+	}
+	cancel()
+
+	timeout := time.After(2 * time.Second)
+	for open := true; open; {
+		select {
+		case _, open = <-ch:
+		case <-timeout:
+			t.Fatal("channel not closed after cancel")
+		}
+	}
+	select {
+	case <-closed:
+	case <-timeout:
+		t.Fatal("response body not closed after cancel")
+	}
+}
+
+func TestChatStream_IdleTimeout(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Keep sending for longer than the client timeout, then stall.
+		for i := 0; i < 4; i++ {
+			fmt.Fprint(w, "data: {\"choices\":[{\"delta\":{\"content\":\".\"}}]}\n\n")
+			w.(http.Flusher).Flush()
+			time.Sleep(40 * time.Millisecond)
+		}
+		<-r.Context().Done()
+	}))
+	defer srv.Close()
+
+	client := &http.Client{Timeout: 100 * time.Millisecond}
+	ch, err := NewOpenAI("k", WithBaseURL(srv.URL), WithHTTPClient(client)).ChatStream(context.Background(), protocol.ChatRequest{})
+	if err != nil {
+		t.Fatalf("ChatStream: %v", err)
+	}
+	resp, err := CollectStream(ch, nil)
+	if !errors.Is(err, context.DeadlineExceeded) || !IsRetryable(err) {
+		t.Fatalf("expected retryable idle timeout, got %v (%+v)", err, resp)
+	}
+}
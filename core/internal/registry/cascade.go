@@ -0,0 +1,85 @@
+package registry
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/h1v3-io/h1v3/internal/ticket"
+	"github.com/h1v3-io/h1v3/pkg/protocol"
+)
+
+// CloseTicketTree closes a ticket and every unclosed descendant, deepest
+// first, with a shared summary. It returns the IDs it closed, the root last.
+//
+// Each descendant still leaves a relay on its parent, always in compact form,
+// but it is only persisted: the parent is about to close as well, so waking
+// its creator would just start work that gets thrown away. The root then
+// closes as usual, relaying to its own parent (if any) in the configured
+// relay mode. Permission checks are left to the caller.
+func (r *Registry) CloseTicketTree(rootID, summary, by string) ([]string, error) {
+	root, err := r.store.Get(rootID)
+	if err != nil {
+		return nil, fmt.Errorf("registry: close ticket tree: %w", err)
+	}
+
+	var order []*protocol.Ticket
+	if err := r.collectDescendants(root.ID, &order); err != nil {
+		return nil, fmt.Errorf("registry: close ticket tree: %w", err)
+	}
+
+	var closed []string
+	detail := fmt.Sprintf("closed with ancestor %s: %s", root.ID, summary)
+	for _, tk := range order {
+		if tk.Status == protocol.TicketClosed {
+			continue
+		}
+		if err := r.store.Close(tk.ID, summary); err != nil {
+			return closed, fmt.Errorf("registry: close ticket tree: %s: %w", tk.ID, err)
+		}
+		r.recordEvent(tk.ID, protocol.EventClosed, by, detail)
+		r.notifyWatchersClosed(tk, summary, by)
+		closed = append(closed, tk.ID)
+
+		relay := protocol.Message{
+			ID:        generateID(),
+			From:      "_system",
+			To:        []string{tk.CreatedBy},
+			Content:   compactRelay(tk, summary, ""),
+			TicketID:  tk.ParentID,
+			Timestamp: time.Now(),
+		}
+		if err := r.PersistMessage(tk.ParentID, relay); err != nil {
+			r.logger.Error("failed to record cascade relay", "child", tk.ID, "parent", tk.ParentID, "error", err)
+		}
+	}
+
+	if root.Status != protocol.TicketClosed {
+		if err := r.CloseTicket(root.ID, summary, by); err != nil {
+			return closed, err
+		}
+		closed = append(closed, root.ID)
+	}
+	r.logger.Info("ticket tree closed", "root", root.ID, "closed", len(closed), "by", by)
+	return closed, nil
+}
+
+// collectDescendants appends the descendants of parentID to out in
+// post-order, so every ticket comes after its own sub-tickets. Tickets are
+// loaded with their messages for the relay.
+func (r *Registry) collectDescendants(parentID string, out *[]*protocol.Ticket) error {
+	children, err := r.store.List(ticket.Filter{ParentID: parentID})
+	if err != nil {
+		return err
+	}
+	for _, c := range children {
+		if err := r.collectDescendants(c.ID, out); err != nil {
+			return err
+		}
+		full, err := r.store.Get(c.ID)
+		if err != nil {
+			return err
+		}
+		*out = append(*out, full)
+	}
+	return nil
+}
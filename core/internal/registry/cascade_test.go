@@ -0,0 +1,79 @@
+package registry
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/h1v3-io/h1v3/pkg/protocol"
+)
+
+func TestCloseTicketTree(t *testing.T) {
+	r := newTestRegistry(t)
+	for _, id := range []string{"front", "lead", "coder", "tester"} {
+		spec, ag := dummyAgent(id)
+		r.RegisterAgent(spec, ag)
+	}
+
+	// user → front: top; front → lead: root; lead → coder: mid;
+	// coder → tester: leaf, plus an already-closed sibling of mid.
+	top, _ := r.CreateTicket("_external", "User request", "", "", []string{"front"}, nil)
+	root, _ := r.CreateTicket("front", "Build feature", "", top.ID, []string{"lead"}, nil)
+	mid, _ := r.CreateTicket("lead", "Write code", "", root.ID, []string{"coder"}, nil)
+	leaf, _ := r.CreateTicket("coder", "Write tests", "", mid.ID, []string{"tester"}, nil)
+	done, _ := r.CreateTicket("lead", "Spike", "", root.ID, []string{"coder"}, nil)
+	r.RouteMessage(protocol.Message{From: "tester", To: []string{"coder"}, Content: "half the tests pass", TicketID: leaf.ID})
+	r.CloseTicket(done.ID, "spiked", "lead")
+
+	drain := func() {
+		for _, id := range []string{"front", "lead", "coder", "tester"} {
+			h, _ := r.GetAgent(id)
+			for len(h.Inbox) > 0 {
+				<-h.Inbox
+			}
+		}
+	}
+	drain()
+
+	closed, err := r.CloseTicketTree(root.ID, "Feature dropped", "front")
+	if err != nil {
+		t.Fatalf("close tree: %v", err)
+	}
+	if want := []string{leaf.ID, mid.ID, root.ID}; !slices.Equal(closed, want) {
+		t.Errorf("expected close order %v, got %v", want, closed)
+	}
+	for _, id := range closed {
+		tk, _ := r.GetTicket(id)
+		if tk.Status != protocol.TicketClosed || tk.Summary != "Feature dropped" {
+			t.Errorf("%s: expected closed with shared summary, got %s %q", id, tk.Status, tk.Summary)
+		}
+	}
+	if tk, _ := r.GetTicket(done.ID); tk.Summary != "spiked" {
+		t.Errorf("already-closed sub-ticket must keep its summary, got %q", tk.Summary)
+	}
+
+	// Relays inside the tree are recorded in compact form without waking
+	// anyone; only the root's relay reaches the top ticket's creator.
+	for _, id := range []string{"lead", "coder", "tester"} {
+		if h, _ := r.GetAgent(id); len(h.Inbox) != 0 {
+			t.Errorf("%s should not be woken by the cascade, has %d messages", id, len(h.Inbox))
+		}
+	}
+	midGot, _ := r.GetTicket(mid.ID)
+	last := midGot.Messages[len(midGot.Messages)-1]
+	if !strings.Contains(last.Content, "Sub-ticket resolved") || !strings.Contains(last.Content, "Last message [tester]: half the tests pass") {
+		t.Errorf("expected compact relay from leaf on mid, got %q", last.Content)
+	}
+	front, _ := r.GetAgent("front")
+	if len(front.Inbox) != 1 {
+		t.Fatalf("expected one relay to front, got %d", len(front.Inbox))
+	}
+	if msg := <-front.Inbox; msg.TicketID != top.ID || !strings.Contains(msg.Content, "Feature dropped") {
+		t.Errorf("unexpected relay to front: %+v", msg)
+	}
+
+	events, _ := r.TicketEvents(leaf.ID)
+	if ev := events[len(events)-1]; ev.Type != protocol.EventClosed || ev.Actor != "front" || !strings.Contains(ev.Detail, root.ID) {
+		t.Errorf("expected closed event naming the root, got %+v", ev)
+	}
+}
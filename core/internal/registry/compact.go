@@ -64,6 +64,7 @@ func (c *Compactor) Compact(ctx context.Context, ticket *protocol.Ticket) error
 	}
 
 	// Ask the LLM to summarize
+	temperature := 0.2
 	req := protocol.ChatRequest{
 		Model: c.Model,
 		Messages: []protocol.ChatMessage{
@@ -77,7 +78,7 @@ func (c *Compactor) Compact(ctx context.Context, ticket *protocol.Ticket) error
 			},
 		},
 		MaxTokens:   512,
-		Temperature: 0.2,
+		Temperature: &temperature,
 	}
 
 	resp, err := c.Provider.Chat(ctx, req)
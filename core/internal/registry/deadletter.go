@@ -0,0 +1,101 @@
+package registry
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/h1v3-io/h1v3/internal/ticket"
+	"github.com/h1v3-io/h1v3/pkg/protocol"
+)
+
+// failedTarget is a recipient deliver could not reach, and why.
+type failedTarget struct {
+	target, reason string
+}
+
+// undeliverable dead-letters msg for each failed target. Targets that were
+// never deliverable are skipped: "_system" and the ticket's creator when it
+// is not an agent, such as "api" or an operator's name on tickets opened
+// through the API, which replies address but nothing receives. Call it
+// without holding r.mu.
+func (r *Registry) undeliverable(msg protocol.Message, failed []failedTarget) {
+	if len(failed) == 0 {
+		return
+	}
+	creator := ""
+	if tk, _, err := r.store.GetWithMessages(msg.TicketID, 1); err == nil {
+		creator = tk.CreatedBy
+	}
+	for _, f := range failed {
+		if f.reason == targetNotFound && (f.target == "_system" || f.target == creator) {
+			r.logger.Debug("no recipient for message", "target", f.target, "ticket", msg.TicketID)
+			continue
+		}
+		r.deadLetter(msg, f.target, f.reason)
+	}
+}
+
+// targetNotFound is the dead letter reason for a target that is neither an
+// agent nor a sink.
+const targetNotFound = "target not found"
+
+// deadLetter records that msg could not be delivered to target, so it is
+// not lost from sight. The message itself is already on its ticket.
+func (r *Registry) deadLetter(msg protocol.Message, target, reason string) {
+	d := ticket.DeadLetter{
+		ID:        "d-" + generateID(),
+		Target:    target,
+		Reason:    reason,
+		Message:   msg,
+		CreatedAt: time.Now(),
+	}
+	if err := r.store.SaveDeadLetter(d); err != nil {
+		r.logger.Error("failed to save dead letter", "target", target, "ticket", msg.TicketID, "error", err)
+		return
+	}
+	r.logger.Warn("message dead-lettered", "dead_letter", d.ID, "target", target, "ticket", msg.TicketID, "reason", reason)
+}
+
+// ListDeadLetters returns the messages that could not be delivered, oldest
+// first.
+func (r *Registry) ListDeadLetters() ([]ticket.DeadLetter, error) {
+	out, err := r.store.ListDeadLetters()
+	if err != nil {
+		return nil, fmt.Errorf("registry: list dead letters: %w", err)
+	}
+	return out, nil
+}
+
+// ReplayDeadLetter delivers a dead letter's message again, to the target it
+// missed only, and removes the dead letter. It fails, keeping the dead
+// letter, while the target is still neither an agent nor a sink or once the
+// ticket is closed.
+func (r *Registry) ReplayDeadLetter(id string) error {
+	d, err := r.store.GetDeadLetter(id)
+	if err != nil {
+		return fmt.Errorf("registry: replay dead letter: %w", err)
+	}
+	r.mu.RLock()
+	_, isAgent := r.agents[d.Target]
+	_, isSink := r.sinks[d.Target]
+	r.mu.RUnlock()
+	if !isAgent && !isSink {
+		return fmt.Errorf("registry: replay dead letter: target %q not found", d.Target)
+	}
+	tk, err := r.store.Get(d.Message.TicketID)
+	if err != nil {
+		return fmt.Errorf("registry: replay dead letter: ticket lookup: %w", err)
+	}
+	if tk.Status == protocol.TicketClosed {
+		return fmt.Errorf("registry: replay dead letter: ticket %q is closed", tk.ID)
+	}
+
+	if err := r.store.DeleteDeadLetter(id); err != nil {
+		return fmt.Errorf("registry: replay dead letter: %w", err)
+	}
+	msg := d.Message
+	msg.To = []string{d.Target}
+	r.deliver(msg)
+	r.logger.Info("dead letter replayed", "dead_letter", id, "target", d.Target, "ticket", tk.ID)
+	return nil
+}
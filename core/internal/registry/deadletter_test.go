@@ -0,0 +1,84 @@
+package registry
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/h1v3-io/h1v3/pkg/protocol"
+)
+
+func TestRouteMessage_UnknownTargetDeadLettered(t *testing.T) {
+	r := newTestRegistry(t)
+	tk, err := r.CreateTicket("_external", "Help", "goal", "", []string{"front"}, nil)
+	if err != nil {
+		t.Fatalf("create ticket: %v", err)
+	}
+	msg := protocol.Message{From: "front", To: []string{"ghost"}, Content: "anyone there?", TicketID: tk.ID}
+	if err := r.RouteMessage(msg); err != nil {
+		t.Fatalf("route: %v", err)
+	}
+
+	letters, err := r.ListDeadLetters()
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	var ghost []string
+	for _, d := range letters {
+		if d.Target == "ghost" {
+			ghost = append(ghost, d.ID)
+			if d.Reason != "target not found" || d.Message.Content != "anyone there?" || d.Message.TicketID != tk.ID {
+				t.Errorf("unexpected dead letter %+v", d)
+			}
+		}
+	}
+	if len(ghost) != 1 {
+		t.Fatalf("expected one dead letter for ghost, got %+v", letters)
+	}
+
+	// Replay fails, keeping the dead letter, until the target exists.
+	if err := r.ReplayDeadLetter(ghost[0]); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Fatalf("expected target not found, got %v", err)
+	}
+	sink := &mockSink{}
+	r.RegisterSink("ghost", sink)
+	if err := r.ReplayDeadLetter(ghost[0]); err != nil {
+		t.Fatalf("replay: %v", err)
+	}
+	if got := sink.getMessages(); len(got) != 1 || got[0].Content != "anyone there?" {
+		t.Errorf("expected the message replayed to ghost, got %+v", got)
+	}
+	if err := r.ReplayDeadLetter(ghost[0]); err == nil {
+		t.Error("expected a replayed dead letter to be gone")
+	}
+
+	// Replays are refused once the ticket is closed.
+	r.RouteMessage(protocol.Message{From: "front", To: []string{"nobody"}, Content: "late", TicketID: tk.ID})
+	r.CloseTicket(tk.ID, "done", "front")
+	r.RegisterSink("nobody", sink)
+	letters, _ = r.ListDeadLetters()
+	for _, d := range letters {
+		if d.Target == "nobody" {
+			if err := r.ReplayDeadLetter(d.ID); err == nil || !strings.Contains(err.Error(), "closed") {
+				t.Errorf("expected closed ticket refused, got %v", err)
+			}
+		}
+	}
+}
+
+func TestRouteMessage_ReplyToAPICreatorNotDeadLettered(t *testing.T) {
+	r := newTestRegistry(t)
+	spec, ag := dummyAgent("support")
+	r.RegisterAgent(spec, ag)
+	tk, err := r.CreateAndRoute("ops-alice", "Help", "goal", "", []string{"support"}, nil, 0, nil,
+		protocol.Message{From: "ops-alice", To: []string{"support"}, Content: "please look"})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	// respond_to_ticket addresses the creator, which no agent or sink is.
+	r.RouteMessage(protocol.Message{From: "support", To: []string{"ops-alice"}, Content: "on it", TicketID: tk.ID})
+	r.RouteMessage(protocol.Message{From: "support", To: []string{"_system"}, Content: "note", TicketID: tk.ID})
+	if letters, _ := r.ListDeadLetters(); len(letters) != 0 {
+		t.Errorf("expected no dead letters, got %+v", letters)
+	}
+}
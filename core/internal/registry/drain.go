@@ -0,0 +1,71 @@
+package registry
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+)
+
+// drainPoll is how often Drain checks whether the agents are done.
+const drainPoll = 100 * time.Millisecond
+
+// Idle reports whether the agent has nothing left to do: an empty inbox and
+// spill queue, and a worker with no message in hand.
+func (h *AgentHandle) Idle() bool {
+	h.spillMu.Lock()
+	spilled := len(h.spill)
+	h.spillMu.Unlock()
+	if len(h.Inbox) > 0 || spilled > 0 {
+		return false
+	}
+	h.stateMu.Lock()
+	idle := h.idle
+	h.stateMu.Unlock()
+	return idle == nil || idle()
+}
+
+// Drain waits until every agent is idle, so work in progress (including the
+// messages it routes to other agents) can finish before shutdown, along with
+// any condensed relay still being written. Callers
+// stop inbound traffic first. A worker reports busy only once it has taken
+// a message from its inbox, so the hive must look idle on two polls in a
+// row. If ctx ends first, Drain returns an error naming the agents still
+// busy.
+func (r *Registry) Drain(ctx context.Context) error {
+	ticker := time.NewTicker(drainPoll)
+	defer ticker.Stop()
+	quiet := false
+	for {
+		busy := r.busyAgents()
+		if n := r.relaying.Load(); n > 0 {
+			busy = append(busy, fmt.Sprintf("%d condensed relay(s)", n))
+		}
+		if len(busy) == 0 && quiet {
+			return nil
+		}
+		quiet = len(busy) == 0
+		select {
+		case <-ctx.Done():
+			if len(busy) == 0 {
+				return nil
+			}
+			return fmt.Errorf("registry: drain: %w; still busy: %v", ctx.Err(), busy)
+		case <-ticker.C:
+		}
+	}
+}
+
+// busyAgents returns the IDs of agents that are not idle, sorted.
+func (r *Registry) busyAgents() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var busy []string
+	for id, h := range r.agents {
+		if !h.Idle() {
+			busy = append(busy, id)
+		}
+	}
+	slices.Sort(busy)
+	return busy
+}
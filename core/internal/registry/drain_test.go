@@ -0,0 +1,85 @@
+package registry
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/h1v3-io/h1v3/internal/agent"
+	"github.com/h1v3-io/h1v3/internal/tool"
+	"github.com/h1v3-io/h1v3/pkg/protocol"
+)
+
+// gatedProvider blocks every call until release is closed, then returns an
+// empty reply.
+type gatedProvider struct {
+	started chan struct{}
+	release chan struct{}
+}
+
+func (p *gatedProvider) Name() string { return "gated" }
+func (p *gatedProvider) Chat(ctx context.Context, _ protocol.ChatRequest) (*protocol.ChatResponse, error) {
+	select {
+	case p.started <- struct{}{}:
+	default:
+	}
+	select {
+	case <-p.release:
+		return &protocol.ChatResponse{}, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func TestDrain_WaitsForWorkerMidMessage(t *testing.T) {
+	r := newTestRegistry(t)
+	prov := &gatedProvider{started: make(chan struct{}, 1), release: make(chan struct{})}
+	spec := protocol.AgentSpec{ID: "a", CoreInstructions: "test"}
+	ag := &agent.Agent{Spec: spec, Provider: prov, Tools: tool.NewRegistry(), Logger: slog.Default(), MaxIterations: 3}
+	r.RegisterAgent(spec, ag)
+	h, _ := r.GetAgent("a")
+	w := &agent.Worker{Agent: ag, Inbox: h.Inbox, Router: r}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	r.StartWorker("a", func() { go w.Start(ctx) }, w.Idle)
+
+	tk, err := r.CreateTicket("x", "T", "goal", "", []string{"a"}, nil)
+	if err != nil {
+		t.Fatalf("create ticket: %v", err)
+	}
+	r.RouteMessage(protocol.Message{From: "x", To: []string{"a"}, TicketID: tk.ID, Content: "work"})
+	select {
+	case <-prov.started:
+	case <-time.After(5 * time.Second):
+		t.Fatal("worker never called the provider")
+	}
+
+	// The deadline passes while the worker is mid-message.
+	short, stop := context.WithTimeout(context.Background(), 250*time.Millisecond)
+	defer stop()
+	if err := r.Drain(short); err == nil || !strings.Contains(err.Error(), "still busy: [a]") {
+		t.Fatalf("expected a busy agent reported, got %v", err)
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- r.Drain(context.Background()) }()
+	select {
+	case err := <-done:
+		t.Fatalf("drain returned before the message was handled: %v", err)
+	case <-time.After(200 * time.Millisecond):
+	}
+	close(prov.release)
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("drain: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("drain did not finish after the message was handled")
+	}
+	if !w.Idle() || !h.Idle() {
+		t.Error("expected the worker idle after drain")
+	}
+}
@@ -0,0 +1,81 @@
+package registry
+
+import "fmt"
+
+// Agent run states reported by AgentHandle.State.
+const (
+	StateActive  = "active"
+	StateDormant = "dormant"
+)
+
+// StartWorker records how to run the agent's worker and starts it. The
+// registry calls start again to wake the agent after it hibernates, so start
+// must launch the worker loop in its own goroutine and return. idle, if not
+// nil, reports whether the worker has no message in hand, for Drain.
+func (r *Registry) StartWorker(agentID string, start func(), idle func() bool) error {
+	r.mu.RLock()
+	h, ok := r.agents[agentID]
+	r.mu.RUnlock()
+	if !ok {
+		return fmt.Errorf("registry: agent %q not found", agentID)
+	}
+
+	h.stateMu.Lock()
+	h.start = start
+	h.idle = idle
+	h.dormant = false
+	h.stateMu.Unlock()
+	start()
+	return nil
+}
+
+// Hibernate marks an idle agent dormant so its worker can exit. It refuses,
+// returning false, while messages are waiting in the inbox or spill queue, or
+// if the agent has no registered worker to wake it again. Messages routed to
+// a dormant agent restart its worker.
+func (r *Registry) Hibernate(agentID string) bool {
+	r.mu.RLock()
+	h, ok := r.agents[agentID]
+	r.mu.RUnlock()
+	if !ok {
+		return false
+	}
+
+	h.spillMu.Lock()
+	spilled := len(h.spill)
+	h.spillMu.Unlock()
+
+	h.stateMu.Lock()
+	defer h.stateMu.Unlock()
+	if h.start == nil || len(h.Inbox) > 0 || spilled > 0 {
+		return false
+	}
+	h.dormant = true
+	r.logger.Info("agent hibernating", "agent", agentID)
+	return true
+}
+
+// wakeIfDormant restarts a dormant agent's worker. Call it after putting a
+// message in the inbox: a worker that hibernated before the send sees the
+// message once restarted, and Hibernate refuses once the message is queued.
+func (r *Registry) wakeIfDormant(h *AgentHandle) {
+	h.stateMu.Lock()
+	defer h.stateMu.Unlock()
+	if !h.dormant {
+		return
+	}
+	h.dormant = false
+	r.logger.Info("waking dormant agent", "agent", h.Spec.ID)
+	h.start()
+}
+
+// State returns StateDormant while the agent is hibernating and StateActive
+// otherwise.
+func (h *AgentHandle) State() string {
+	h.stateMu.Lock()
+	defer h.stateMu.Unlock()
+	if h.dormant {
+		return StateDormant
+	}
+	return StateActive
+}
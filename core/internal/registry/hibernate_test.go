@@ -0,0 +1,105 @@
+package registry
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/h1v3-io/h1v3/pkg/protocol"
+)
+
+func TestHibernateAndWake(t *testing.T) {
+	r := newTestRegistry(t)
+	spec, ag := dummyAgent("a")
+	r.RegisterAgent(spec, ag)
+	tk, _ := r.CreateTicket("x", "T", "", "", []string{"a"}, nil)
+	h, _ := r.GetAgent("a")
+
+	if r.Hibernate("a") {
+		t.Fatal("expected hibernate to refuse without a registered worker")
+	}
+
+	var starts atomic.Int32
+	r.StartWorker("a", func() { starts.Add(1) }, nil)
+	if starts.Load() != 1 {
+		t.Fatalf("expected worker started once, got %d", starts.Load())
+	}
+
+	r.RouteMessage(protocol.Message{From: "x", To: []string{"a"}, TicketID: tk.ID, Content: "pending"})
+	if r.Hibernate("a") {
+		t.Fatal("expected hibernate to refuse with a queued message")
+	}
+	<-h.Inbox
+
+	if !r.Hibernate("a") {
+		t.Fatal("expected idle agent to hibernate")
+	}
+	if h.State() != StateDormant {
+		t.Errorf("state = %s, want dormant", h.State())
+	}
+
+	r.RouteMessage(protocol.Message{From: "x", To: []string{"a"}, TicketID: tk.ID, Content: "wake up"})
+	if starts.Load() != 2 {
+		t.Errorf("expected worker restarted on delivery, got %d starts", starts.Load())
+	}
+	if h.State() != StateActive {
+		t.Errorf("state = %s, want active", h.State())
+	}
+}
+
+// TestHibernate_ConcurrentRoute checks no message is stranded in a dormant
+// agent's inbox while workers hibernate aggressively under concurrent routing.
+func TestHibernate_ConcurrentRoute(t *testing.T) {
+	r := newTestRegistry(t)
+	spec, ag := dummyAgent("a")
+	spec.InboxSize = 256
+	r.RegisterAgent(spec, ag)
+	h, _ := r.GetAgent("a")
+
+	var received atomic.Int32
+	worker := func() {
+		go func() {
+			for {
+				select {
+				case <-h.Inbox:
+					received.Add(1)
+				case <-time.After(time.Millisecond):
+					if r.Hibernate("a") {
+						return
+					}
+				}
+			}
+		}()
+	}
+	r.StartWorker("a", worker, nil)
+
+	const senders, perSender = 8, 25
+	var wg sync.WaitGroup
+	for i := 0; i < senders; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < perSender; j++ {
+				// deliver is RouteMessage minus persistence, which would
+				// serialize senders on SQLite.
+				r.deliver(protocol.Message{
+					ID: fmt.Sprintf("m-%d-%d", i, j), From: "x", To: []string{"a"}, TicketID: "t",
+				})
+				time.Sleep(time.Duration(j%3) * time.Millisecond)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	deadline := time.After(5 * time.Second)
+	for received.Load() < senders*perSender {
+		select {
+		case <-deadline:
+			t.Fatalf("received %d of %d messages; state %s, %d left in inbox",
+				received.Load(), senders*perSender, h.State(), len(h.Inbox))
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package registry
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/h1v3-io/h1v3/pkg/protocol"
+)
+
+// ReassignTicket replaces a ticket's assignees with to on behalf of by.
+// Newly added assignees are sent the ticket's title and goal so they can
+// pick up from its history; assignees that were dropped are told their
+// assignment was removed.
+func (r *Registry) ReassignTicket(ticketID string, to []string, reason, by string) error {
+	to = dedupRecipients(to)
+	if len(to) == 0 {
+		return fmt.Errorf("registry: reassign ticket: no assignees given")
+	}
+	tk, err := r.store.Get(ticketID)
+	if err != nil {
+		return fmt.Errorf("registry: reassign ticket: %w", err)
+	}
+	if tk.Status == protocol.TicketClosed {
+		return fmt.Errorf("registry: reassign ticket: ticket %s is closed", ticketID)
+	}
+	if slices.Equal(tk.WaitingOn, to) {
+		return nil
+	}
+	if err := r.checkDelegation(by, to); err != nil {
+		return fmt.Errorf("registry: reassign ticket: %w", err)
+	}
+	if err := r.store.UpdateWaitingOn(ticketID, to); err != nil {
+		return fmt.Errorf("registry: reassign ticket: %w", err)
+	}
+	detail := fmt.Sprintf("%s → %s", strings.Join(tk.WaitingOn, ", "), strings.Join(to, ", "))
+	if reason != "" {
+		detail += ": " + reason
+	}
+	r.recordEvent(ticketID, protocol.EventReassigned, by, detail)
+	r.logger.Info("ticket reassigned", "ticket", ticketID, "from", tk.WaitingOn, "to", to, "by", by)
+
+	var added, removed []string
+	for _, id := range to {
+		if !slices.Contains(tk.WaitingOn, id) {
+			added = append(added, id)
+		}
+	}
+	for _, id := range tk.WaitingOn {
+		if !slices.Contains(to, id) {
+			removed = append(removed, id)
+		}
+	}
+
+	var why string
+	if reason != "" {
+		why = " Reason: " + reason
+	}
+	if len(added) > 0 {
+		content := fmt.Sprintf("Ticket %s (%s) has been assigned to you by %s.%s\n\nGoal: %s\n\nEarlier messages on the ticket are its history; continue from where it left off.",
+			tk.ID, tk.Title, by, why, tk.Goal)
+		if err := r.RouteMessage(protocol.Message{From: "_system", To: added, Content: content, TicketID: ticketID, Timestamp: time.Now()}); err != nil {
+			return fmt.Errorf("registry: reassign ticket: %w", err)
+		}
+	}
+	if len(removed) > 0 {
+		content := fmt.Sprintf("Your assignment on ticket %s (%s) was removed by %s; it is now assigned to %s. Stop any work on it.%s",
+			tk.ID, tk.Title, by, strings.Join(to, ", "), why)
+		if err := r.RouteMessage(protocol.Message{From: "_system", To: removed, Content: content, TicketID: ticketID, Timestamp: time.Now()}); err != nil {
+			return fmt.Errorf("registry: reassign ticket: %w", err)
+		}
+	}
+	return nil
+}
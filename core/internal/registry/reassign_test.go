@@ -0,0 +1,82 @@
+package registry
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/h1v3-io/h1v3/internal/ticket"
+	"github.com/h1v3-io/h1v3/pkg/protocol"
+)
+
+func TestReassignTicket(t *testing.T) {
+	r := newTestRegistry(t)
+	for _, id := range []string{"lead", "coder", "backup"} {
+		spec, ag := dummyAgent(id)
+		r.RegisterAgent(spec, ag)
+	}
+	tk, _ := r.CreateTicket("lead", "Fix build", "Build is green", "", []string{"coder"}, nil)
+
+	if err := r.ReassignTicket(tk.ID, []string{"backup"}, "coder is wedged", "lead"); err != nil {
+		t.Fatalf("reassign: %v", err)
+	}
+	got, _ := r.GetTicket(tk.ID)
+	if !slices.Equal(got.WaitingOn, []string{"backup"}) {
+		t.Errorf("expected waiting_on [backup], got %v", got.WaitingOn)
+	}
+
+	backup, _ := r.GetAgent("backup")
+	if len(backup.Inbox) != 1 {
+		t.Fatalf("expected the new assignee to get the ticket, got %d messages", len(backup.Inbox))
+	}
+	if msg := <-backup.Inbox; msg.TicketID != tk.ID || !strings.Contains(msg.Content, "assigned to you by lead") || !strings.Contains(msg.Content, "Build is green") {
+		t.Errorf("unexpected handover: %+v", msg)
+	}
+	coder, _ := r.GetAgent("coder")
+	if len(coder.Inbox) != 1 {
+		t.Fatalf("expected the previous assignee to be told, got %d messages", len(coder.Inbox))
+	}
+	if msg := <-coder.Inbox; !strings.Contains(msg.Content, "Your assignment on ticket "+tk.ID) || !strings.Contains(msg.Content, "now assigned to backup") {
+		t.Errorf("unexpected removal notice: %+v", msg)
+	}
+
+	events, _ := r.TicketEvents(tk.ID)
+	i := slices.IndexFunc(events, func(ev protocol.TicketEvent) bool { return ev.Type == protocol.EventReassigned })
+	if i < 0 || events[i].Detail != "coder → backup: coder is wedged" || events[i].Actor != "lead" {
+		t.Errorf("expected a reassigned event, got %+v", events)
+	}
+
+	// Tickets filtered by participant follow the new assignee.
+	if mine, _ := r.ListTickets(ticket.Filter{AgentID: "backup"}); len(mine) != 1 {
+		t.Errorf("expected the ticket listed for backup, got %d", len(mine))
+	}
+
+	r.CloseTicket(tk.ID, "done", "lead")
+	if err := r.ReassignTicket(tk.ID, []string{"coder"}, "", "lead"); err == nil {
+		t.Error("expected an error reassigning a closed ticket")
+	}
+}
+
+func TestReassignTicket_DelegationDenied(t *testing.T) {
+	r := newTestRegistry(t)
+	lead, ag := dummyAgent("lead")
+	lead.CanDelegateTo = []string{"coder"}
+	r.RegisterAgent(lead, ag)
+	for _, id := range []string{"coder", "admin"} {
+		spec, ag := dummyAgent(id)
+		r.RegisterAgent(spec, ag)
+	}
+	tk, err := r.CreateTicket("lead", "Fix build", "Build is green", "", []string{"coder"}, nil)
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	// Reassignment cannot reach an agent creation could not.
+	if err := r.ReassignTicket(tk.ID, []string{"admin"}, "", "lead"); !errors.Is(err, ErrDelegationDenied) {
+		t.Fatalf("expected ErrDelegationDenied, got %v", err)
+	}
+	if got, _ := r.GetTicket(tk.ID); !slices.Equal(got.WaitingOn, []string{"coder"}) {
+		t.Errorf("expected waiting_on unchanged, got %v", got.WaitingOn)
+	}
+}
@@ -1,10 +1,14 @@
 package registry
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log/slog"
+	"slices"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/h1v3-io/h1v3/internal/agent"
@@ -12,7 +16,27 @@ import (
 	"github.com/h1v3-io/h1v3/pkg/protocol"
 )
 
-const defaultInboxSize = 64
+const (
+	defaultInboxSize  = 64
+	inboxBlockTimeout = 5 * time.Second       // InboxBlock: default wait for space
+	spillRetry        = time.Second           // InboxSpill: how long the feeder waits per attempt
+	blockRetry        = 50 * time.Millisecond // InboxBlock: how long each send attempt holds r.mu
+)
+
+// ErrDelegationDenied is returned when an agent tries to create a ticket for
+// an agent outside its CanDelegateTo list, or for one whose CanReceiveFrom
+// list excludes it.
+var ErrDelegationDenied = errors.New("delegation not allowed")
+
+// ErrInboxFull is returned when a message was dropped because the agent's
+// inbox was full. RouteMessage returns it after persisting the message, so
+// the message stays on the ticket even though the agent never received it.
+var ErrInboxFull = errors.New("agent inbox full")
+
+// errWouldBlock is returned by deliverToAgent when an InboxBlock agent's
+// inbox is full. The caller finishes delivery with waitDeliver once it has
+// released r.mu.
+var errWouldBlock = errors.New("agent inbox full, sender must wait")
 
 // Sink receives messages for a non-agent participant (e.g. _external → Telegram).
 type Sink interface {
@@ -24,8 +48,26 @@ type AgentHandle struct {
 	Spec  protocol.AgentSpec
 	Agent *agent.Agent
 	Inbox chan protocol.Message
+
+	dropped atomic.Int64
+
+	// InboxSpill overflow, fed into Inbox in order by a single feeder.
+	spillMu sync.Mutex
+	spill   []protocol.Message
+	feeding bool
+	closed  bool // set under Registry.mu when the inbox is closed
+
+	// Hibernation and draining; see StartWorker, Hibernate and Drain.
+	stateMu sync.Mutex
+	start   func()
+	idle    func() bool
+	dormant bool
 }
 
+// Dropped returns how many messages were dropped because the agent's inbox
+// was full.
+func (h *AgentHandle) Dropped() int64 { return h.dropped.Load() }
+
 // Registry is the central ticket broker that routes messages between agents.
 type Registry struct {
 	mu       sync.RWMutex
@@ -33,7 +75,19 @@ type Registry struct {
 	agents   map[string]*AgentHandle
 	sinks    map[string]Sink
 	creators map[string]string // agent_id → creator_agent_id
+	relay    RelayMode
+	costs    map[string]protocol.ModelCost // per-model prices for usage estimates
+	watchMu  sync.Mutex                    // serializes watcher list read-modify-writes
 	logger   *slog.Logger
+	ctx      context.Context // bounds InboxBlock waits and condensed relays; see SetContext
+	relaying atomic.Int64    // condensed relays still running; see relayToParent
+
+	inboxPolicy  string        // default for agents without an InboxPolicy
+	blockTimeout time.Duration // InboxBlock wait; 0 = inboxBlockTimeout
+	selfDelivery bool          // deliver messages addressed to their own sender
+
+	subMu sync.Mutex
+	subs  map[string]map[chan protocol.Message]struct{} // ticket ID → Subscribe channels
 }
 
 // New creates a new Registry backed by the given ticket store.
@@ -47,6 +101,7 @@ func New(store ticket.Store, logger *slog.Logger) *Registry {
 		sinks:    make(map[string]Sink),
 		creators: make(map[string]string),
 		logger:   logger,
+		ctx:      context.Background(),
 	}
 }
 
@@ -59,12 +114,16 @@ func (r *Registry) RegisterAgent(spec protocol.AgentSpec, ag *agent.Agent) error
 		return fmt.Errorf("registry: agent %q already registered", spec.ID)
 	}
 
+	size := spec.InboxSize
+	if size <= 0 {
+		size = defaultInboxSize
+	}
 	r.agents[spec.ID] = &AgentHandle{
 		Spec:  spec,
 		Agent: ag,
-		Inbox: make(chan protocol.Message, defaultInboxSize),
+		Inbox: make(chan protocol.Message, size),
 	}
-	r.logger.Info("agent registered", "agent", spec.ID)
+	r.logger.Info("agent registered", "agent", spec.ID, "inbox_size", size, "inbox_policy", spec.InboxPolicy)
 	return nil
 }
 
@@ -77,6 +136,9 @@ func (r *Registry) DeregisterAgent(agentID string) error {
 	if !exists {
 		return fmt.Errorf("registry: agent %q not found", agentID)
 	}
+	h.spillMu.Lock()
+	h.closed = true
+	h.spillMu.Unlock()
 	close(h.Inbox)
 	delete(r.agents, agentID)
 	r.logger.Info("agent deregistered", "agent", agentID)
@@ -91,6 +153,14 @@ func (r *Registry) RegisterSink(name string, sink Sink) {
 	r.logger.Info("sink registered", "name", name)
 }
 
+// HasSink reports whether a sink is registered under name.
+func (r *Registry) HasSink(name string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	_, ok := r.sinks[name]
+	return ok
+}
+
 // GetAgent returns an agent handle by ID.
 func (r *Registry) GetAgent(agentID string) (*AgentHandle, bool) {
 	r.mu.RLock()
@@ -110,10 +180,275 @@ func (r *Registry) ListAgents() []string {
 	return ids
 }
 
-// CreateTicket creates a new ticket and routes an initial message to target agents.
+// CreateTicket creates a new ticket with no messages. Callers that send an
+// initial message should use CreateAndRoute so the two are saved together.
 func (r *Registry) CreateTicket(from, title, goal, parentID string, to []string, tags []string) (*protocol.Ticket, error) {
-	now := time.Now()
-	t := &protocol.Ticket{
+	if err := r.checkDelegation(from, to); err != nil {
+		return nil, fmt.Errorf("registry: create ticket: %w", err)
+	}
+	t := newTicket(from, title, goal, parentID, to, tags)
+	if err := r.store.Save(t); err != nil {
+		return nil, fmt.Errorf("registry: create ticket: %w", err)
+	}
+
+	r.recordEvent(t.ID, protocol.EventCreated, from, fmt.Sprintf("assigned to %s", strings.Join(to, ", ")))
+	r.logger.Info("ticket created", "ticket", t.ID, "from", from, "to", to, "title", title)
+	return t, nil
+}
+
+// CreateAndRoute creates a ticket and persists its first message in a single
+// store transaction, then delivers the message like RouteMessage. If the
+// store write fails, neither the ticket nor the message exists. Delivery is
+// best-effort and happens after the commit. Tickets with a higher priority
+// are listed first; 0 is normal.
+func (r *Registry) CreateAndRoute(from, title, goal, parentID string, to, tags []string, priority int, dueAt *time.Time, msg protocol.Message) (*protocol.Ticket, error) {
+	if err := r.checkDelegation(from, to); err != nil {
+		return nil, fmt.Errorf("registry: create ticket: %w", err)
+	}
+	t := newTicket(from, title, goal, parentID, to, tags)
+	t.Priority = priority
+	t.DueAt = dueAt
+	if msg.ID == "" {
+		msg.ID = generateID()
+	}
+	if msg.Timestamp.IsZero() {
+		msg.Timestamp = t.CreatedAt
+	}
+	msg.TicketID = t.ID
+	msg.To = dedupRecipients(msg.To)
+
+	if err := r.store.SaveWithMessage(t, msg); err != nil {
+		return nil, fmt.Errorf("registry: create ticket: %w", err)
+	}
+	t.Messages = []protocol.Message{msg}
+
+	r.recordEvent(t.ID, protocol.EventCreated, from, fmt.Sprintf("assigned to %s", strings.Join(to, ", ")))
+	r.recordEvent(t.ID, protocol.EventMessage, msg.From, fmt.Sprintf("%s to %s", msg.ID, strings.Join(msg.To, ", ")))
+	r.logger.Info("ticket created", "ticket", t.ID, "from", from, "to", to, "title", title, "priority", priority)
+
+	r.deliver(msg)
+	return t, nil
+}
+
+// checkDelegation enforces the agents' CanDelegateTo and CanReceiveFrom
+// lists when from assigns a ticket to to, on creation or reassignment. Only
+// tickets between registered agents are restricted; tickets opened or
+// reassigned by connectors, the API or the system are always allowed.
+func (r *Registry) checkDelegation(from string, to []string) error {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	sender, ok := r.agents[from]
+	if !ok {
+		return nil
+	}
+	for _, id := range to {
+		if !sender.Spec.MayDelegateTo(id) {
+			return fmt.Errorf("%w: %s may only assign tickets to %s",
+				ErrDelegationDenied, from, strings.Join(sender.Spec.CanDelegateTo, ", "))
+		}
+		if h, ok := r.agents[id]; ok && !h.Spec.AcceptsFrom(from) {
+			return fmt.Errorf("%w: %s only accepts tickets from %s",
+				ErrDelegationDenied, id, strings.Join(h.Spec.CanReceiveFrom, ", "))
+		}
+	}
+	return nil
+}
+
+// SetInboxPolicy sets the backpressure policy for agents whose spec does not
+// set InboxPolicy, and how long InboxBlock waits for space (0 = 5s). An
+// empty policy means InboxDrop.
+func (r *Registry) SetInboxPolicy(policy string, blockTimeout time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.inboxPolicy = policy
+	r.blockTimeout = blockTimeout
+}
+
+// SetContext bounds the waits the registry does on a sender's behalf: once
+// ctx ends, InboxBlock deliveries stop waiting for space and drop, and
+// condensed relays fall back to the compact form.
+func (r *Registry) SetContext(ctx context.Context) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.ctx = ctx
+}
+
+// SetSelfDelivery controls whether a message addressed to its own sender is
+// delivered to the sender. The default skips it.
+func (r *Registry) SetSelfDelivery(allow bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.selfDelivery = allow
+}
+
+// deliverToAgent puts msg in the agent's inbox, applying the agent's
+// backpressure policy when the inbox is full. It returns an error wrapping
+// ErrInboxFull if the message was dropped, and errWouldBlock if an
+// InboxBlock agent's inbox is full; a delivered or queued message returns
+// nil. The caller must hold r.mu for reading, and must release it before
+// calling waitDeliver on errWouldBlock.
+func (r *Registry) deliverToAgent(h *AgentHandle, msg protocol.Message) error {
+	policy := h.Spec.InboxPolicy
+	if policy == "" {
+		policy = r.inboxPolicy
+	}
+	switch policy {
+	case protocol.InboxSpill:
+		r.spill(h, msg)
+		return nil
+	case protocol.InboxDropOldest:
+		r.dropOldest(h, msg)
+		return nil
+	}
+
+	select {
+	case h.Inbox <- msg:
+		r.wakeIfDormant(h)
+		return nil
+	default:
+	}
+
+	if policy == protocol.InboxBlock {
+		return errWouldBlock
+	}
+	return r.drop(h, msg, policy)
+}
+
+// waitDeliver waits for space in an InboxBlock agent's inbox, up to the
+// block timeout or until the registry's context ends, then drops msg. The
+// caller must not hold r.mu: each attempt takes it only for blockRetry, so a
+// slow consumer never holds up RegisterAgent or DeregisterAgent for long.
+func (r *Registry) waitDeliver(h *AgentHandle, msg protocol.Message) error {
+	r.mu.RLock()
+	timeout := r.blockTimeout
+	if timeout <= 0 {
+		timeout = inboxBlockTimeout
+	}
+	ctx, cancel := context.WithTimeout(r.ctx, timeout)
+	r.mu.RUnlock()
+	defer cancel()
+
+	for {
+		// Hold r.mu so DeregisterAgent cannot close the inbox mid-send.
+		r.mu.RLock()
+		if h.closed {
+			r.mu.RUnlock()
+			return fmt.Errorf("registry: deliver %s to %s: agent deregistered", msg.ID, h.Spec.ID)
+		}
+		timer := time.NewTimer(blockRetry)
+		sent := false
+		select {
+		case h.Inbox <- msg:
+			sent = true
+			r.wakeIfDormant(h)
+		case <-timer.C:
+		case <-ctx.Done():
+		}
+		timer.Stop()
+		r.mu.RUnlock()
+
+		if sent {
+			return nil
+		}
+		if ctx.Err() != nil {
+			return r.drop(h, msg, protocol.InboxBlock)
+		}
+	}
+}
+
+// drop counts and logs a message the agent's inbox had no room for.
+func (r *Registry) drop(h *AgentHandle, msg protocol.Message, policy string) error {
+	n := h.dropped.Add(1)
+	r.logger.Warn("agent inbox full, dropping message",
+		"agent", h.Spec.ID, "ticket", msg.TicketID, "message", msg.ID, "policy", policy, "dropped_total", n)
+	return fmt.Errorf("registry: deliver %s to %s: %w", msg.ID, h.Spec.ID, ErrInboxFull)
+}
+
+// dropOldest delivers msg, evicting the oldest queued messages while the
+// inbox is full. Evicted messages count as dropped.
+func (r *Registry) dropOldest(h *AgentHandle, msg protocol.Message) {
+	for {
+		select {
+		case h.Inbox <- msg:
+			r.wakeIfDormant(h)
+			return
+		default:
+		}
+		select {
+		case old := <-h.Inbox:
+			n := h.dropped.Add(1)
+			r.logger.Warn("agent inbox full, dropping oldest message",
+				"agent", h.Spec.ID, "ticket", old.TicketID, "message", old.ID, "policy", protocol.InboxDropOldest, "dropped_total", n)
+		default: // the agent freed a slot in the meantime
+		}
+	}
+}
+
+// spill delivers msg directly when the inbox has room and nothing is queued
+// ahead of it; otherwise it records the message as in-flight in the store,
+// so it survives a restart, and queues it for the feeder.
+func (r *Registry) spill(h *AgentHandle, msg protocol.Message) {
+	h.spillMu.Lock()
+	defer h.spillMu.Unlock()
+
+	if len(h.spill) == 0 {
+		select {
+		case h.Inbox <- msg:
+			r.wakeIfDormant(h)
+			return
+		default:
+		}
+	}
+
+	if err := r.store.MarkInFlight(h.Spec.ID, msg.TicketID, msg.ID); err != nil {
+		r.logger.Error("failed to persist spilled message", "agent", h.Spec.ID, "ticket", msg.TicketID, "error", err)
+	}
+	h.spill = append(h.spill, msg)
+	r.logger.Info("agent inbox full, message spilled", "agent", h.Spec.ID, "ticket", msg.TicketID, "queued", len(h.spill))
+	if !h.feeding {
+		h.feeding = true
+		go r.feedSpill(h)
+	}
+}
+
+// feedSpill moves spilled messages into the agent's inbox as space frees,
+// until the queue is empty or the agent is deregistered.
+func (r *Registry) feedSpill(h *AgentHandle) {
+	for {
+		h.spillMu.Lock()
+		if len(h.spill) == 0 || h.closed {
+			h.feeding = false
+			h.spillMu.Unlock()
+			return
+		}
+		msg := h.spill[0]
+		h.spillMu.Unlock()
+
+		// Hold r.mu so DeregisterAgent cannot close the inbox mid-send.
+		sent := false
+		r.mu.RLock()
+		if !h.closed {
+			timer := time.NewTimer(spillRetry)
+			select {
+			case h.Inbox <- msg:
+				sent = true
+				r.wakeIfDormant(h)
+			case <-timer.C:
+			}
+			timer.Stop()
+		}
+		r.mu.RUnlock()
+
+		if sent {
+			h.spillMu.Lock()
+			h.spill = h.spill[1:]
+			h.spillMu.Unlock()
+		}
+	}
+}
+
+func newTicket(from, title, goal, parentID string, to, tags []string) *protocol.Ticket {
+	return &protocol.Ticket{
 		ID:        generateID(),
 		Title:     title,
 		Goal:      goal,
@@ -122,15 +457,8 @@ func (r *Registry) CreateTicket(from, title, goal, parentID string, to []string,
 		WaitingOn: to,
 		Tags:      tags,
 		ParentID:  parentID,
-		CreatedAt: now,
+		CreatedAt: time.Now(),
 	}
-
-	if err := r.store.Save(t); err != nil {
-		return nil, fmt.Errorf("registry: create ticket: %w", err)
-	}
-
-	r.logger.Info("ticket created", "ticket", t.ID, "from", from, "to", to, "title", title)
-	return t, nil
 }
 
 // RouteMessage persists a message to the ticket and delivers it to target agents' inboxes.
@@ -142,6 +470,7 @@ func (r *Registry) RouteMessage(msg protocol.Message) error {
 	if msg.ID == "" {
 		msg.ID = generateID()
 	}
+	msg.To = dedupRecipients(msg.To)
 
 	// Check ticket status — don't deliver messages on closed tickets
 	tk, err := r.store.Get(msg.TicketID)
@@ -152,6 +481,8 @@ func (r *Registry) RouteMessage(msg protocol.Message) error {
 	if err := r.store.AppendMessage(msg.TicketID, msg); err != nil {
 		return fmt.Errorf("registry: route message: %w", err)
 	}
+	r.recordEvent(msg.TicketID, protocol.EventMessage, msg.From, fmt.Sprintf("%s to %s", msg.ID, strings.Join(msg.To, ", ")))
+	r.publish(msg)
 
 	// Skip inbox delivery on closed tickets (message is still persisted for history)
 	if tk.Status == protocol.TicketClosed {
@@ -159,32 +490,76 @@ func (r *Registry) RouteMessage(msg protocol.Message) error {
 		return nil
 	}
 
-	// Deliver to target agents
+	err = r.deliver(msg)
+	r.notifyWatchers(tk, msg)
+	return err
+}
+
+// deliver hands a persisted message to its target agents' inboxes and sinks,
+// once per target. The sender does not receive its own message unless
+// SetSelfDelivery allows it. Targets that are neither, and sinks that fail,
+// get a dead letter instead (see undeliverable). InboxBlock agents are waited
+// for only after r.mu is released. The returned error joins one error
+// wrapping ErrInboxFull per agent that dropped the message.
+func (r *Registry) deliver(msg protocol.Message) error {
+	var (
+		failed  []failedTarget
+		waiting []*AgentHandle // InboxBlock agents with a full inbox
+		errs    []error
+	)
 	r.mu.RLock()
-	defer r.mu.RUnlock()
 
-	for _, target := range msg.To {
+	for _, target := range dedupRecipients(msg.To) {
+		if target == msg.From && !r.selfDelivery {
+			r.logger.Debug("skipping self-delivery", "agent", target, "ticket", msg.TicketID)
+			continue
+		}
 		if h, ok := r.agents[target]; ok {
-			select {
-			case h.Inbox <- msg:
+			switch err := r.deliverToAgent(h, msg); {
+			case err == nil:
 				r.logger.Debug("message delivered", "to", target, "ticket", msg.TicketID)
+			case errors.Is(err, errWouldBlock):
+				waiting = append(waiting, h)
 			default:
-				r.logger.Warn("agent inbox full, dropping message", "agent", target, "ticket", msg.TicketID)
+				errs = append(errs, err)
 			}
 			continue
 		}
 		if s, ok := r.sinks[target]; ok {
 			if err := s.Deliver(msg); err != nil {
 				r.logger.Error("sink delivery failed", "sink", target, "ticket", msg.TicketID, "error", err)
+				failed = append(failed, failedTarget{target, "sink delivery failed: " + err.Error()})
 			} else {
 				r.logger.Debug("message delivered to sink", "sink", target, "ticket", msg.TicketID)
 			}
 			continue
 		}
-		r.logger.Warn("target not found", "target", target, "ticket", msg.TicketID)
+		failed = append(failed, failedTarget{target, targetNotFound})
 	}
+	r.mu.RUnlock()
 
-	return nil
+	r.undeliverable(msg, failed)
+	for _, h := range waiting {
+		if err := r.waitDeliver(h, msg); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// dedupRecipients returns to without repeated IDs, keeping first occurrences
+// in order.
+func dedupRecipients(to []string) []string {
+	if len(to) < 2 {
+		return to
+	}
+	out := make([]string, 0, len(to))
+	for _, id := range to {
+		if !slices.Contains(out, id) {
+			out = append(out, id)
+		}
+	}
+	return out
 }
 
 // PersistMessage saves a message to the ticket store without routing to agent inboxes.
@@ -195,14 +570,15 @@ func (r *Registry) PersistMessage(ticketID string, msg protocol.Message) error {
 	if err := r.store.AppendMessage(ticketID, msg); err != nil {
 		return fmt.Errorf("registry: persist message: %w", err)
 	}
+	r.publish(msg)
 	return nil
 }
 
-// CloseTicket marks a ticket as closed with a summary.
+// CloseTicket marks a ticket as closed with a summary on behalf of by.
 // If the ticket has a parent, a summary message is injected into the parent
 // ticket and routed to the child ticket's creator so it can continue working
 // on the parent task.
-func (r *Registry) CloseTicket(ticketID, summary string) error {
+func (r *Registry) CloseTicket(ticketID, summary, by string) error {
 	// Load ticket before closing to get parent info
 	tk, err := r.store.Get(ticketID)
 	if err != nil {
@@ -218,7 +594,9 @@ func (r *Registry) CloseTicket(ticketID, summary string) error {
 	if err := r.store.Close(ticketID, summary); err != nil {
 		return fmt.Errorf("registry: close ticket: %w", err)
 	}
+	r.recordEvent(ticketID, protocol.EventClosed, by, summary)
 	r.logger.Info("ticket closed", "ticket", ticketID)
+	r.notifyWatchersClosed(tk, summary, by)
 
 	// If child ticket, relay summary to parent
 	if tk.ParentID != "" {
@@ -228,42 +606,40 @@ func (r *Registry) CloseTicket(ticketID, summary string) error {
 	return nil
 }
 
-// relayToParent injects the child ticket's full conversation into the parent
-// ticket, waking the creator agent in the parent context.
-func (r *Registry) relayToParent(child *protocol.Ticket, summary string) {
-	var b strings.Builder
-	fmt.Fprintf(&b, "[Sub-ticket resolved: %q]\n", child.Title)
-	fmt.Fprintf(&b, "Summary: %s\n", summary)
-	if len(child.Messages) > 0 {
-		b.WriteString("\nFull conversation:\n")
-		for _, m := range child.Messages {
-			fmt.Fprintf(&b, "[%s]: %s\n", m.From, m.Content)
-		}
+// ReopenTicket sets a closed ticket back to open on behalf of by, and routes
+// a system note into it so the creator and assignees (other than by) know
+// the work has resumed.
+func (r *Registry) ReopenTicket(ticketID, reason, by string) error {
+	tk, err := r.store.Get(ticketID)
+	if err != nil {
+		return fmt.Errorf("registry: reopen ticket: %w", err)
+	}
+	if tk.Status != protocol.TicketClosed {
+		return fmt.Errorf("registry: reopen ticket: ticket %s is %s, not closed", ticketID, tk.Status)
+	}
+	if err := r.store.Reopen(ticketID); err != nil {
+		return fmt.Errorf("registry: reopen ticket: %w", err)
 	}
-	content := b.String()
+	r.recordEvent(ticketID, protocol.EventReopened, by, reason)
+	r.logger.Info("ticket reopened", "ticket", ticketID, "by", by)
 
-	msg := protocol.Message{
-		ID:        generateID(),
+	var to []string
+	for _, id := range append([]string{tk.CreatedBy}, tk.WaitingOn...) {
+		if id != by {
+			to = append(to, id)
+		}
+	}
+	content := fmt.Sprintf("Ticket %s (%s) was reopened by %s.", tk.ID, tk.Title, by)
+	if reason != "" {
+		content += " Reason: " + reason
+	}
+	return r.RouteMessage(protocol.Message{
 		From:      "_system",
-		To:        []string{child.CreatedBy},
+		To:        to,
 		Content:   content,
-		TicketID:  child.ParentID,
+		TicketID:  ticketID,
 		Timestamp: time.Now(),
-	}
-
-	if err := r.RouteMessage(msg); err != nil {
-		r.logger.Error("failed to relay to parent ticket",
-			"child", child.ID,
-			"parent", child.ParentID,
-			"error", err,
-		)
-	} else {
-		r.logger.Info("relayed child summary to parent",
-			"child", child.ID,
-			"parent", child.ParentID,
-			"creator", child.CreatedBy,
-		)
-	}
+	})
 }
 
 // GetTicket retrieves a ticket by ID.
@@ -271,6 +647,13 @@ func (r *Registry) GetTicket(ticketID string) (*protocol.Ticket, error) {
 	return r.store.Get(ticketID)
 }
 
+// GetTicketWithMessages returns a ticket with only its most recent limit
+// messages, plus its total message count. Workers use it to keep per-turn
+// loading bounded on long tickets.
+func (r *Registry) GetTicketWithMessages(ticketID string, limit int) (*protocol.Ticket, int, error) {
+	return r.store.GetWithMessages(ticketID, limit)
+}
+
 // ListTickets returns tickets matching the filter.
 func (r *Registry) ListTickets(filter ticket.Filter) ([]*protocol.Ticket, error) {
 	return r.store.List(filter)
@@ -281,9 +664,61 @@ func (r *Registry) CountTickets(filter ticket.Filter) (int, error) {
 	return r.store.Count(filter)
 }
 
-// UpdateTicketStatus changes a ticket's status without closing it.
-func (r *Registry) UpdateTicketStatus(ticketID string, status protocol.TicketStatus) error {
-	return r.store.UpdateStatus(ticketID, status)
+// UpdateTicketStatus changes a ticket's status without closing it, recording
+// the transition on the ticket's timeline.
+func (r *Registry) UpdateTicketStatus(ticketID string, status protocol.TicketStatus, by string) error {
+	tk, err := r.store.Get(ticketID)
+	if err != nil {
+		return fmt.Errorf("registry: update status: %w", err)
+	}
+	if err := r.store.UpdateStatus(ticketID, status); err != nil {
+		return fmt.Errorf("registry: update status: %w", err)
+	}
+	if tk.Status != status {
+		r.recordEvent(ticketID, protocol.EventStatusChanged, by, fmt.Sprintf("%s → %s", tk.Status, status))
+	}
+	return nil
+}
+
+// TicketEvents returns the event timeline for a ticket.
+func (r *Registry) TicketEvents(ticketID string) ([]protocol.TicketEvent, error) {
+	return r.store.Events(ticketID)
+}
+
+// SetModelCosts sets the per-model prices TicketUsage estimates costs with.
+func (r *Registry) SetModelCosts(costs map[string]protocol.ModelCost) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.costs = costs
+}
+
+// TicketUsage returns a ticket's token usage per model, with estimated
+// costs for the models that have a price.
+func (r *Registry) TicketUsage(ticketID string) (*protocol.TicketUsage, error) {
+	usage, err := r.store.Usage(ticketID)
+	if err != nil {
+		return nil, err
+	}
+	r.mu.RLock()
+	costs := r.costs
+	r.mu.RUnlock()
+	usage.Price(costs)
+	return usage, nil
+}
+
+// recordEvent appends to a ticket's timeline. Failures are logged rather than
+// returned so auditing never blocks the operation being audited.
+func (r *Registry) recordEvent(ticketID string, typ protocol.TicketEventType, actor, detail string) {
+	ev := protocol.TicketEvent{
+		TicketID:  ticketID,
+		Type:      typ,
+		Actor:     actor,
+		Detail:    detail,
+		Timestamp: time.Now(),
+	}
+	if err := r.store.AppendEvent(ev); err != nil {
+		r.logger.Error("failed to record ticket event", "ticket", ticketID, "type", typ, "error", err)
+	}
 }
 
 // ListSubTickets returns tickets whose parent_id matches the given ID.
@@ -291,6 +726,100 @@ func (r *Registry) ListSubTickets(parentID string) ([]*protocol.Ticket, error) {
 	return r.store.List(ticket.Filter{ParentID: parentID})
 }
 
+// ArchiveClosedTickets moves tickets closed more than retention ago into the
+// store's archive. Parents of still-live sub-tickets are kept.
+func (r *Registry) ArchiveClosedTickets(retention time.Duration) (int, error) {
+	n, err := r.store.Archive(time.Now().Add(-retention))
+	if err != nil {
+		return 0, fmt.Errorf("registry: archive tickets: %w", err)
+	}
+	if n > 0 {
+		r.logger.Info("archived closed tickets", "count", n, "retention", retention)
+	}
+	return n, nil
+}
+
+// RunRetention sweeps closed tickets into the archive once immediately and
+// then every interval until ctx is cancelled.
+func (r *Registry) RunRetention(ctx context.Context, retention, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if _, err := r.ArchiveClosedTickets(retention); err != nil {
+			r.logger.Error("retention sweep failed", "error", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// ResumeInFlight redelivers messages an agent was processing when the daemon
+// last stopped. A marker is dropped instead when its ticket is gone or
+// closed, or when the agent already posted a reply after the message. Call it
+// once at startup, after agents are registered. Returns how many messages
+// were re-enqueued.
+func (r *Registry) ResumeInFlight() (int, error) {
+	markers, err := r.store.ListInFlight()
+	if err != nil {
+		return 0, fmt.Errorf("registry: resume in-flight: %w", err)
+	}
+
+	resumed := 0
+	for _, m := range markers {
+		msg, ok := r.pendingInFlight(m)
+		if !ok {
+			if err := r.store.ClearInFlight(m.AgentID, m.MessageID); err != nil {
+				r.logger.Error("failed to clear in-flight marker", "agent", m.AgentID, "ticket", m.TicketID, "error", err)
+			}
+			continue
+		}
+
+		var err error
+		r.mu.RLock()
+		h, ok := r.agents[m.AgentID]
+		if ok {
+			err = r.deliverToAgent(h, msg)
+		}
+		r.mu.RUnlock()
+		if !ok {
+			r.logger.Warn("in-flight message for unknown agent, leaving marker", "agent", m.AgentID, "ticket", m.TicketID)
+			continue
+		}
+		if errors.Is(err, errWouldBlock) {
+			err = r.waitDeliver(h, msg)
+		}
+		if err == nil {
+			resumed++
+			r.logger.Info("resumed in-flight message", "agent", m.AgentID, "ticket", m.TicketID, "message", m.MessageID)
+		}
+	}
+	return resumed, nil
+}
+
+// pendingInFlight returns the message behind an in-flight marker, and false
+// if the message no longer needs processing.
+func (r *Registry) pendingInFlight(m ticket.InFlight) (protocol.Message, bool) {
+	tk, err := r.store.Get(m.TicketID)
+	if err != nil || tk.Status == protocol.TicketClosed {
+		return protocol.Message{}, false
+	}
+	for i, msg := range tk.Messages {
+		if msg.ID != m.MessageID {
+			continue
+		}
+		for _, later := range tk.Messages[i+1:] {
+			if later.From == m.AgentID {
+				return protocol.Message{}, false
+			}
+		}
+		return msg, true
+	}
+	return protocol.Message{}, false
+}
+
 // Store returns the underlying ticket store.
 func (r *Registry) Store() ticket.Store {
 	return r.store
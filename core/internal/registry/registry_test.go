@@ -1,6 +1,8 @@
 package registry
 
 import (
+	"context"
+	"errors"
 	"path/filepath"
 	"strings"
 	"sync"
@@ -93,6 +95,62 @@ func TestCreateTicket(t *testing.T) {
 	}
 }
 
+func TestCreateAndRoute(t *testing.T) {
+	r := newTestRegistry(t)
+	spec, ag := dummyAgent("agent-b")
+	r.RegisterAgent(spec, ag)
+
+	tk, err := r.CreateAndRoute("agent-a", "Atomic", "", "", []string{"agent-b"}, nil, 0, nil, protocol.Message{
+		From: "agent-a", To: []string{"agent-b"}, Content: "Start here",
+	})
+	if err != nil {
+		t.Fatalf("create and route: %v", err)
+	}
+
+	h, _ := r.GetAgent("agent-b")
+	select {
+	case received := <-h.Inbox:
+		if received.TicketID != tk.ID || received.Content != "Start here" {
+			t.Errorf("unexpected delivery: %+v", received)
+		}
+	default:
+		t.Fatal("expected message in inbox")
+	}
+
+	got, _ := r.GetTicket(tk.ID)
+	if len(got.Messages) != 1 || got.Messages[0].Content != "Start here" {
+		t.Errorf("expected the first message persisted with the ticket, got %+v", got.Messages)
+	}
+}
+
+func TestCreateAndRoute_FailureLeavesNoOrphan(t *testing.T) {
+	r := newTestRegistry(t)
+
+	first, err := r.CreateAndRoute("agent-a", "First", "", "", []string{"agent-b"}, nil, 0, nil, protocol.Message{
+		ID: "m-dup", From: "agent-a", To: []string{"agent-b"}, Content: "one",
+	})
+	if err != nil {
+		t.Fatalf("create first: %v", err)
+	}
+
+	// Reusing the message ID makes the insert fail after the ticket row has
+	// been written inside the transaction.
+	_, err = r.CreateAndRoute("agent-a", "Second", "", "", []string{"agent-b"}, nil, 0, nil, protocol.Message{
+		ID: "m-dup", From: "agent-a", To: []string{"agent-b"}, Content: "two",
+	})
+	if err == nil {
+		t.Fatal("expected error for duplicate message ID")
+	}
+
+	tickets, err := r.ListTickets(ticket.Filter{})
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if len(tickets) != 1 || tickets[0].ID != first.ID {
+		t.Errorf("expected only the first ticket, got %d tickets", len(tickets))
+	}
+}
+
 func TestRouteMessage(t *testing.T) {
 	r := newTestRegistry(t)
 
@@ -132,6 +190,70 @@ func TestRouteMessage(t *testing.T) {
 	}
 }
 
+func TestRouteMessage_DuplicateAndSelfRecipients(t *testing.T) {
+	r := newTestRegistry(t)
+	for _, id := range []string{"agent-a", "agent-b"} {
+		spec, ag := dummyAgent(id)
+		r.RegisterAgent(spec, ag)
+	}
+	tk, _ := r.CreateTicket("agent-a", "Dedup", "", "", []string{"agent-b"}, nil)
+
+	err := r.RouteMessage(protocol.Message{
+		From:     "agent-a",
+		To:       []string{"agent-b", "agent-a", "agent-b"},
+		Content:  "once",
+		TicketID: tk.ID,
+	})
+	if err != nil {
+		t.Fatalf("route: %v", err)
+	}
+
+	a, _ := r.GetAgent("agent-a")
+	b, _ := r.GetAgent("agent-b")
+	if len(b.Inbox) != 1 {
+		t.Errorf("expected a single delivery to agent-b, got %d", len(b.Inbox))
+	}
+	if len(a.Inbox) != 0 {
+		t.Errorf("expected no self-delivery to agent-a, got %d", len(a.Inbox))
+	}
+	got, _ := r.GetTicket(tk.ID)
+	if len(got.Messages) != 1 {
+		t.Fatalf("expected 1 persisted message, got %d", len(got.Messages))
+	}
+	if to := got.Messages[0].To; len(to) != 2 {
+		t.Errorf("expected recipients deduplicated to [agent-b agent-a], got %v", to)
+	}
+}
+
+func TestRouteMessage_SelfDeliveryAllowed(t *testing.T) {
+	r := newTestRegistry(t)
+	r.SetSelfDelivery(true)
+	for _, id := range []string{"agent-a", "agent-b"} {
+		spec, ag := dummyAgent(id)
+		r.RegisterAgent(spec, ag)
+	}
+	tk, _ := r.CreateTicket("agent-a", "Self", "", "", []string{"agent-b"}, nil)
+
+	err := r.RouteMessage(protocol.Message{
+		From:     "agent-a",
+		To:       []string{"agent-b", "agent-a", "agent-a"},
+		Content:  "note to self",
+		TicketID: tk.ID,
+	})
+	if err != nil {
+		t.Fatalf("route: %v", err)
+	}
+
+	a, _ := r.GetAgent("agent-a")
+	b, _ := r.GetAgent("agent-b")
+	if len(a.Inbox) != 1 {
+		t.Errorf("expected a single self-delivery to agent-a, got %d", len(a.Inbox))
+	}
+	if len(b.Inbox) != 1 {
+		t.Errorf("expected a single delivery to agent-b, got %d", len(b.Inbox))
+	}
+}
+
 func TestRouteMessage_NoTicketID(t *testing.T) {
 	r := newTestRegistry(t)
 	err := r.RouteMessage(protocol.Message{Content: "no ticket"})
@@ -144,7 +266,7 @@ func TestCloseTicket(t *testing.T) {
 	r := newTestRegistry(t)
 
 	tk, _ := r.CreateTicket("agent-a", "Close test", "", "", nil, nil)
-	if err := r.CloseTicket(tk.ID, "All done"); err != nil {
+	if err := r.CloseTicket(tk.ID, "All done", "test"); err != nil {
 		t.Fatalf("close: %v", err)
 	}
 
@@ -157,6 +279,66 @@ func TestCloseTicket(t *testing.T) {
 	}
 }
 
+func TestReopenTicket(t *testing.T) {
+	r := newTestRegistry(t)
+	sink := &mockSink{}
+	r.RegisterSink("agent-b", sink)
+
+	tk, _ := r.CreateTicket("agent-a", "Reopen test", "", "", []string{"agent-b"}, nil)
+	if err := r.ReopenTicket(tk.ID, "", "agent-a"); err == nil {
+		t.Error("expected an error reopening an open ticket")
+	}
+	r.CloseTicket(tk.ID, "Done", "agent-a")
+
+	if err := r.ReopenTicket(tk.ID, "tests still fail", "agent-a"); err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	got, _ := r.GetTicket(tk.ID)
+	if got.Status != protocol.TicketOpen || got.ClosedAt != nil {
+		t.Errorf("expected open with no closed_at, got %s %v", got.Status, got.ClosedAt)
+	}
+	notes := sink.getMessages()
+	if len(notes) != 1 {
+		t.Fatalf("expected the assignee notified once, got %+v", notes)
+	}
+	if msg := notes[0]; msg.From != "_system" || !strings.Contains(msg.Content, "reopened by agent-a") || !strings.Contains(msg.Content, "tests still fail") {
+		t.Errorf("unexpected note: %+v", msg)
+	}
+	events, _ := r.TicketEvents(tk.ID)
+	if last := events[len(events)-2]; last.Type != protocol.EventReopened || last.Detail != "tests still fail" {
+		t.Errorf("expected a reopened event before the note, got %+v", events)
+	}
+}
+
+func TestTicketEvents(t *testing.T) {
+	r := newTestRegistry(t)
+
+	tk, _ := r.CreateTicket("front", "Audit", "", "", []string{"coder"}, nil)
+	r.RouteMessage(protocol.Message{From: "front", To: []string{"coder"}, Content: "hi", TicketID: tk.ID})
+	r.UpdateTicketStatus(tk.ID, protocol.TicketAwaitingClose, "coder")
+	r.UpdateTicketStatus(tk.ID, protocol.TicketOpen, "front")
+	r.CloseTicket(tk.ID, "done", "front")
+
+	events, err := r.TicketEvents(tk.ID)
+	if err != nil {
+		t.Fatalf("events: %v", err)
+	}
+	want := []protocol.TicketEventType{
+		protocol.EventCreated, protocol.EventMessage,
+		protocol.EventStatusChanged, protocol.EventStatusChanged, protocol.EventClosed,
+	}
+	if len(events) != len(want) {
+		t.Fatalf("expected %d events, got %d: %+v", len(want), len(events), events)
+	}
+	for i, typ := range want {
+		if events[i].Type != typ {
+			t.Errorf("event %d: expected %s, got %s", i, typ, events[i].Type)
+		}
+	}
+	if events[3].Actor != "front" || events[3].Detail != "awaiting_close → open" {
+		t.Errorf("expected reopen by front, got %+v", events[3])
+	}
+}
 
 // mockSink implements Sink for testing.
 type mockSink struct {
@@ -292,7 +474,7 @@ func TestCloseTicket_RelayToParent(t *testing.T) {
 	}
 
 	// Close child ticket — should relay summary to parent
-	if err := r.CloseTicket(child.ID, "Name is Neo"); err != nil {
+	if err := r.CloseTicket(child.ID, "Name is Neo", "test"); err != nil {
 		t.Fatalf("close: %v", err)
 	}
 
@@ -323,7 +505,7 @@ func TestCloseTicket_RelayToParent(t *testing.T) {
 	}
 
 	// Closing the same child again should be a no-op (no second relay)
-	if err := r.CloseTicket(child.ID, "Name is Neo again"); err != nil {
+	if err := r.CloseTicket(child.ID, "Name is Neo again", "test"); err != nil {
 		t.Fatalf("second close: %v", err)
 	}
 	select {
@@ -334,6 +516,112 @@ func TestCloseTicket_RelayToParent(t *testing.T) {
 	}
 }
 
+func TestCloseTicket_RelayModes(t *testing.T) {
+	for _, tc := range []struct {
+		mode     RelayMode
+		provider *mockCompactProvider
+		want     []string
+		notWant  []string
+	}{
+		{RelayFull, nil, []string{"Full conversation:", "step one", "all done"}, nil},
+		{RelayCompact, nil, []string{"Last message [coder]: all done", "get_ticket"}, []string{"step one"}},
+		{RelayCondensed, &mockCompactProvider{summary: "Neo, via the profile API"}, []string{"Handoff:\nNeo, via the profile API", "get_ticket"}, []string{"step one", "Last message"}},
+		{RelayCondensed, nil, []string{"Last message [coder]: all done"}, []string{"step one"}}, // no provider: compact fallback
+	} {
+		r := newTestRegistry(t)
+		r.SetRelayMode(tc.mode)
+		spec, ag := dummyAgent("front")
+		if tc.provider != nil {
+			ag.Provider = tc.provider
+		}
+		r.RegisterAgent(spec, ag)
+
+		parent, _ := r.CreateTicket("_external", "User question", "", "", []string{"front"}, nil)
+		child, _ := r.CreateTicket("front", "Get the name", "", parent.ID, []string{"coder"}, nil)
+		for _, content := range []string{"step one", "all done"} {
+			r.RouteMessage(protocol.Message{From: "coder", To: []string{"front"}, Content: content, TicketID: child.ID})
+		}
+		h, _ := r.GetAgent("front")
+		for len(h.Inbox) > 0 {
+			<-h.Inbox
+		}
+
+		if err := r.CloseTicket(child.ID, "Name is Neo", "coder"); err != nil {
+			t.Fatalf("%s: close: %v", tc.mode, err)
+		}
+		select {
+		case msg := <-h.Inbox:
+			if msg.TicketID != parent.ID || !strings.Contains(msg.Content, "Name is Neo") {
+				t.Errorf("%s: unexpected relay %+v", tc.mode, msg)
+			}
+			for _, w := range tc.want {
+				if !strings.Contains(msg.Content, w) {
+					t.Errorf("%s: relay missing %q:\n%s", tc.mode, w, msg.Content)
+				}
+			}
+			for _, w := range tc.notWant {
+				if strings.Contains(msg.Content, w) {
+					t.Errorf("%s: relay should not contain %q:\n%s", tc.mode, w, msg.Content)
+				}
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("%s: expected relay message", tc.mode)
+		}
+	}
+}
+
+// blockingProvider answers Chat only once release is closed or ctx ends.
+type blockingProvider struct{ release chan struct{} }
+
+func (p *blockingProvider) Name() string { return "blocking" }
+func (p *blockingProvider) Chat(ctx context.Context, _ protocol.ChatRequest) (*protocol.ChatResponse, error) {
+	select {
+	case <-p.release:
+		return &protocol.ChatResponse{Content: "Neo, eventually"}, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func TestCloseTicket_CondensedRelayIsAsync(t *testing.T) {
+	r := newTestRegistry(t)
+	r.SetRelayMode(RelayCondensed)
+	p := &blockingProvider{release: make(chan struct{})}
+	spec, ag := dummyAgent("front")
+	ag.Provider = p
+	r.RegisterAgent(spec, ag)
+
+	parent, _ := r.CreateTicket("_external", "User question", "", "", []string{"front"}, nil)
+	child, _ := r.CreateTicket("front", "Get the name", "", parent.ID, []string{"coder"}, nil)
+	r.RouteMessage(protocol.Message{From: "coder", To: []string{"front"}, Content: "all done", TicketID: child.ID})
+	h, _ := r.GetAgent("front")
+	<-h.Inbox
+
+	start := time.Now()
+	if err := r.CloseTicket(child.ID, "Name is Neo", "coder"); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+	if waited := time.Since(start); waited > time.Second {
+		t.Errorf("CloseTicket waited %v on the condense call", waited)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+	if err := r.Drain(ctx); err == nil || !strings.Contains(err.Error(), "condensed relay") {
+		t.Errorf("expected Drain to wait for the pending relay, got %v", err)
+	}
+
+	close(p.release)
+	select {
+	case msg := <-h.Inbox:
+		if msg.TicketID != parent.ID || !strings.Contains(msg.Content, "Neo, eventually") {
+			t.Errorf("unexpected relay %+v", msg)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("expected the condensed relay once the provider answered")
+	}
+}
+
 func TestCloseTicket_NoParent_NoRelay(t *testing.T) {
 	r := newTestRegistry(t)
 
@@ -343,7 +631,7 @@ func TestCloseTicket_NoParent_NoRelay(t *testing.T) {
 	// Create ticket without parent
 	tk, _ := r.CreateTicket("front", "No parent", "", "", nil, nil)
 
-	if err := r.CloseTicket(tk.ID, "Done"); err != nil {
+	if err := r.CloseTicket(tk.ID, "Done", "test"); err != nil {
 		t.Fatalf("close: %v", err)
 	}
 
@@ -356,3 +644,300 @@ func TestCloseTicket_NoParent_NoRelay(t *testing.T) {
 		// OK — no message
 	}
 }
+
+func TestResumeInFlight(t *testing.T) {
+	r := newTestRegistry(t)
+	spec, ag := dummyAgent("agent-b")
+	r.RegisterAgent(spec, ag)
+	store := r.Store()
+
+	route := func(tk *protocol.Ticket, id, from string) {
+		t.Helper()
+		msg := protocol.Message{ID: id, From: from, To: []string{"agent-b"}, Content: id, TicketID: tk.ID, Timestamp: time.Now()}
+		if err := store.AppendMessage(tk.ID, msg); err != nil {
+			t.Fatalf("append %s: %v", id, err)
+		}
+	}
+
+	// Interrupted mid-turn: no reply from agent-b after the message.
+	pending, _ := r.CreateTicket("agent-a", "Pending", "", "", []string{"agent-b"}, nil)
+	route(pending, "m-pending", "agent-a")
+	store.MarkInFlight("agent-b", pending.ID, "m-pending")
+
+	// agent-b already replied before the crash.
+	answered, _ := r.CreateTicket("agent-a", "Answered", "", "", []string{"agent-b"}, nil)
+	route(answered, "m-answered", "agent-a")
+	route(answered, "m-reply", "agent-b")
+	store.MarkInFlight("agent-b", answered.ID, "m-answered")
+
+	// Ticket closed in the meantime.
+	closed, _ := r.CreateTicket("agent-a", "Closed", "", "", []string{"agent-b"}, nil)
+	route(closed, "m-closed", "agent-a")
+	store.MarkInFlight("agent-b", closed.ID, "m-closed")
+	r.CloseTicket(closed.ID, "done", "agent-a")
+
+	n, err := r.ResumeInFlight()
+	if err != nil {
+		t.Fatalf("resume: %v", err)
+	}
+	if n != 1 {
+		t.Fatalf("expected 1 resumed message, got %d", n)
+	}
+
+	h, _ := r.GetAgent("agent-b")
+	select {
+	case msg := <-h.Inbox:
+		if msg.ID != "m-pending" {
+			t.Errorf("expected m-pending to be redelivered, got %s", msg.ID)
+		}
+	default:
+		t.Fatal("expected resumed message in inbox")
+	}
+
+	// Stale markers are dropped; the resumed one stays until the worker
+	// finishes with it.
+	markers, err := store.ListInFlight()
+	if err != nil {
+		t.Fatalf("list in-flight: %v", err)
+	}
+	if len(markers) != 1 || markers[0].MessageID != "m-pending" {
+		t.Errorf("expected only m-pending marker left, got %+v", markers)
+	}
+}
+
+func TestRegisterAgent_InboxSize(t *testing.T) {
+	r := newTestRegistry(t)
+	spec, ag := dummyAgent("busy")
+	spec.InboxSize = 256
+	r.RegisterAgent(spec, ag)
+
+	h, _ := r.GetAgent("busy")
+	if cap(h.Inbox) != 256 {
+		t.Errorf("inbox capacity = %d, want 256", cap(h.Inbox))
+	}
+}
+
+func TestRouteMessage_InboxPolicies(t *testing.T) {
+	route := func(r *Registry, tk *protocol.Ticket, to, id string) {
+		t.Helper()
+		if err := r.RouteMessage(protocol.Message{ID: id, From: "x", To: []string{to}, TicketID: tk.ID, Content: id}); err != nil {
+			t.Fatalf("route: %v", err)
+		}
+	}
+
+	t.Run("drop", func(t *testing.T) {
+		r := newTestRegistry(t)
+		spec, ag := dummyAgent("a")
+		spec.InboxSize = 1
+		r.RegisterAgent(spec, ag)
+		tk, _ := r.CreateTicket("x", "T", "", "", []string{"a"}, nil)
+
+		route(r, tk, "a", "m-1")
+		if err := r.RouteMessage(protocol.Message{ID: "m-2", From: "x", To: []string{"a"}, TicketID: tk.ID}); !errors.Is(err, ErrInboxFull) {
+			t.Errorf("expected RouteMessage to report ErrInboxFull, got %v", err)
+		}
+		h, _ := r.GetAgent("a")
+		if h.Dropped() != 1 || len(h.Inbox) != 1 {
+			t.Errorf("expected 1 queued and 1 dropped, got %d queued, %d dropped", len(h.Inbox), h.Dropped())
+		}
+		if err := r.deliverToAgent(h, protocol.Message{ID: "m-3", TicketID: tk.ID}); !errors.Is(err, ErrInboxFull) {
+			t.Errorf("expected ErrInboxFull, got %v", err)
+		}
+	})
+
+	t.Run("drop_oldest", func(t *testing.T) {
+		r := newTestRegistry(t)
+		spec, ag := dummyAgent("a")
+		spec.InboxSize = 2
+		spec.InboxPolicy = protocol.InboxDropOldest
+		r.RegisterAgent(spec, ag)
+		tk, _ := r.CreateTicket("x", "T", "", "", []string{"a"}, nil)
+		h, _ := r.GetAgent("a")
+
+		for _, id := range []string{"m-1", "m-2", "m-3", "m-4"} {
+			route(r, tk, "a", id)
+		}
+		if h.Dropped() != 2 || len(h.Inbox) != 2 {
+			t.Fatalf("expected 2 queued and 2 dropped, got %d queued, %d dropped", len(h.Inbox), h.Dropped())
+		}
+		for _, want := range []string{"m-3", "m-4"} {
+			if got := <-h.Inbox; got.ID != want {
+				t.Errorf("got %s, want %s", got.ID, want)
+			}
+		}
+	})
+
+	t.Run("hive default block with timeout", func(t *testing.T) {
+		r := newTestRegistry(t)
+		r.SetInboxPolicy(protocol.InboxBlock, 50*time.Millisecond)
+		spec, ag := dummyAgent("a")
+		spec.InboxSize = 1
+		r.RegisterAgent(spec, ag)
+		tk, _ := r.CreateTicket("x", "T", "", "", []string{"a"}, nil)
+		h, _ := r.GetAgent("a")
+
+		route(r, tk, "a", "m-1")
+		start := time.Now()
+		// Nobody reads: waits out the timeout, then drops.
+		if err := r.RouteMessage(protocol.Message{ID: "m-2", From: "x", To: []string{"a"}, TicketID: tk.ID}); !errors.Is(err, ErrInboxFull) {
+			t.Errorf("expected RouteMessage to report ErrInboxFull, got %v", err)
+		}
+		if waited := time.Since(start); waited < 50*time.Millisecond || waited > 2*time.Second {
+			t.Errorf("expected to block for about 50ms, blocked %v", waited)
+		}
+		if h.Dropped() != 1 {
+			t.Errorf("expected 1 drop after the timeout, got %d", h.Dropped())
+		}
+	})
+
+	t.Run("block", func(t *testing.T) {
+		r := newTestRegistry(t)
+		spec, ag := dummyAgent("a")
+		spec.InboxSize = 1
+		spec.InboxPolicy = protocol.InboxBlock
+		r.RegisterAgent(spec, ag)
+		tk, _ := r.CreateTicket("x", "T", "", "", []string{"a"}, nil)
+		h, _ := r.GetAgent("a")
+
+		route(r, tk, "a", "m-1")
+		go func() {
+			time.Sleep(50 * time.Millisecond)
+			<-h.Inbox
+		}()
+		route(r, tk, "a", "m-2") // blocks until the reader frees a slot
+		if got := <-h.Inbox; got.ID != "m-2" {
+			t.Errorf("expected m-2 after unblocking, got %s", got.ID)
+		}
+		if h.Dropped() != 0 {
+			t.Errorf("expected no drops, got %d", h.Dropped())
+		}
+	})
+
+	t.Run("block waits without holding the registry", func(t *testing.T) {
+		r := newTestRegistry(t)
+		r.SetInboxPolicy(protocol.InboxBlock, 2*time.Second)
+		spec, ag := dummyAgent("a")
+		spec.InboxSize = 1
+		r.RegisterAgent(spec, ag)
+		tk, _ := r.CreateTicket("x", "T", "", "", []string{"a"}, nil)
+		h, _ := r.GetAgent("a")
+
+		route(r, tk, "a", "m-1")
+		done := make(chan error, 1)
+		go func() {
+			done <- r.RouteMessage(protocol.Message{ID: "m-2", From: "x", To: []string{"a"}, TicketID: tk.ID})
+		}()
+		time.Sleep(20 * time.Millisecond)
+
+		start := time.Now()
+		spec, ag = dummyAgent("b")
+		r.RegisterAgent(spec, ag)
+		if waited := time.Since(start); waited > time.Second {
+			t.Errorf("RegisterAgent waited %v behind a blocked sender", waited)
+		}
+
+		<-h.Inbox
+		if err := <-done; err != nil {
+			t.Errorf("expected m-2 delivered once space freed, got %v", err)
+		}
+	})
+
+	t.Run("block gives up when the registry context ends", func(t *testing.T) {
+		r := newTestRegistry(t)
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		r.SetContext(ctx)
+		r.SetInboxPolicy(protocol.InboxBlock, 5*time.Second)
+		spec, ag := dummyAgent("a")
+		spec.InboxSize = 1
+		r.RegisterAgent(spec, ag)
+		tk, _ := r.CreateTicket("x", "T", "", "", []string{"a"}, nil)
+
+		route(r, tk, "a", "m-1")
+		start := time.Now()
+		err := r.RouteMessage(protocol.Message{ID: "m-2", From: "x", To: []string{"a"}, TicketID: tk.ID})
+		if !errors.Is(err, ErrInboxFull) {
+			t.Errorf("expected ErrInboxFull, got %v", err)
+		}
+		if waited := time.Since(start); waited > time.Second {
+			t.Errorf("expected an immediate drop, blocked %v", waited)
+		}
+	})
+
+	t.Run("spill", func(t *testing.T) {
+		r := newTestRegistry(t)
+		spec, ag := dummyAgent("a")
+		spec.InboxSize = 1
+		spec.InboxPolicy = protocol.InboxSpill
+		r.RegisterAgent(spec, ag)
+		tk, _ := r.CreateTicket("x", "T", "", "", []string{"a"}, nil)
+		h, _ := r.GetAgent("a")
+
+		for _, id := range []string{"m-1", "m-2", "m-3"} {
+			route(r, tk, "a", id)
+		}
+		markers, _ := r.Store().ListInFlight()
+		if len(markers) != 2 {
+			t.Errorf("expected 2 spilled messages persisted, got %d", len(markers))
+		}
+
+		for _, want := range []string{"m-1", "m-2", "m-3"} {
+			select {
+			case got := <-h.Inbox:
+				if got.ID != want {
+					t.Errorf("got %s, want %s", got.ID, want)
+				}
+			case <-time.After(3 * time.Second):
+				t.Fatalf("timed out waiting for %s", want)
+			}
+		}
+		if h.Dropped() != 0 {
+			t.Errorf("expected no drops, got %d", h.Dropped())
+		}
+	})
+}
+
+func TestCreateTicket_DelegationLists(t *testing.T) {
+	r := newTestRegistry(t)
+	front, ag := dummyAgent("front")
+	front.CanDelegateTo = []string{"coder"}
+	r.RegisterAgent(front, ag)
+	coder, ag := dummyAgent("coder")
+	coder.CanDelegateTo = []string{"tester"}
+	r.RegisterAgent(coder, ag)
+	tester, ag := dummyAgent("tester")
+	tester.CanReceiveFrom = []string{"coder"}
+	r.RegisterAgent(tester, ag)
+	free, ag := dummyAgent("free")
+	r.RegisterAgent(free, ag)
+
+	for _, tc := range []struct {
+		from string
+		to   []string
+		ok   bool
+	}{
+		{"front", []string{"coder"}, true},
+		{"front", []string{"coder", "tester"}, false}, // outside front's list
+		{"coder", []string{"front"}, false},           // specialists can't ticket the front
+		{"coder", []string{"tester"}, true},
+		{"free", []string{"tester"}, false}, // tester only accepts coder
+		{"free", []string{"front"}, true},
+		{"_external", []string{"tester"}, true}, // non-agents are unrestricted
+	} {
+		_, err := r.CreateTicket(tc.from, "T", "", "", tc.to, nil)
+		if tc.ok && err != nil {
+			t.Errorf("%s → %v: unexpected error %v", tc.from, tc.to, err)
+		}
+		if !tc.ok && !errors.Is(err, ErrDelegationDenied) {
+			t.Errorf("%s → %v: expected ErrDelegationDenied, got %v", tc.from, tc.to, err)
+		}
+	}
+
+	_, err := r.CreateAndRoute("coder", "T", "", "", []string{"front"}, nil, 0, nil, protocol.Message{From: "coder", To: []string{"front"}})
+	if !errors.Is(err, ErrDelegationDenied) {
+		t.Errorf("CreateAndRoute: expected ErrDelegationDenied, got %v", err)
+	}
+	if tickets, _ := r.ListTickets(ticket.Filter{}); len(tickets) != 4 {
+		t.Errorf("expected only the 4 allowed tickets to be created, got %d", len(tickets))
+	}
+}
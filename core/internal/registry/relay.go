@@ -0,0 +1,155 @@
+package registry
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/h1v3-io/h1v3/pkg/protocol"
+)
+
+// RelayMode controls what relayToParent injects into the parent ticket when
+// a sub-ticket closes. The child's full conversation stays on the child
+// ticket either way and can be read with get_ticket.
+type RelayMode string
+
+const (
+	RelayFull      RelayMode = "full"      // summary plus the whole child conversation (default)
+	RelayCompact   RelayMode = "compact"   // summary plus the child's last message
+	RelayCondensed RelayMode = "condensed" // an LLM-written handoff; falls back to compact
+)
+
+const condenseTimeout = 30 * time.Second
+
+// SetRelayMode picks how closed sub-tickets are relayed to their parent.
+// An empty mode means RelayFull.
+func (r *Registry) SetRelayMode(mode RelayMode) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.relay = mode
+}
+
+// relayToParent injects the child ticket's outcome into the parent ticket,
+// waking the creator agent in the parent context. A condensed relay waits on
+// an LLM call, so it runs in the background and CloseTicket returns at once;
+// Drain waits for it.
+func (r *Registry) relayToParent(child *protocol.Ticket, summary string) {
+	r.mu.RLock()
+	mode := r.relay
+	creator := r.agents[child.CreatedBy]
+	ctx := r.ctx
+	r.mu.RUnlock()
+
+	switch mode {
+	case RelayCompact:
+		r.sendRelay(child, mode, compactRelay(child, summary, ""))
+	case RelayCondensed:
+		r.relaying.Add(1)
+		go func() {
+			defer r.relaying.Add(-1)
+			handoff, err := r.condense(ctx, creator, child, summary)
+			if err != nil {
+				r.logger.Warn("condensed relay failed, relaying compact form", "child", child.ID, "error", err)
+			}
+			r.sendRelay(child, mode, compactRelay(child, summary, handoff))
+		}()
+	default:
+		r.sendRelay(child, mode, fullRelay(child, summary))
+	}
+}
+
+// sendRelay routes a relay's content to the child's creator on the parent
+// ticket.
+func (r *Registry) sendRelay(child *protocol.Ticket, mode RelayMode, content string) {
+	msg := protocol.Message{
+		ID:        generateID(),
+		From:      "_system",
+		To:        []string{child.CreatedBy},
+		Content:   content,
+		TicketID:  child.ParentID,
+		Timestamp: time.Now(),
+	}
+
+	if err := r.RouteMessage(msg); err != nil {
+		r.logger.Error("failed to relay to parent ticket",
+			"child", child.ID,
+			"parent", child.ParentID,
+			"error", err,
+		)
+	} else {
+		r.logger.Info("relayed child summary to parent",
+			"child", child.ID,
+			"parent", child.ParentID,
+			"creator", child.CreatedBy,
+			"mode", mode,
+		)
+	}
+}
+
+func fullRelay(child *protocol.Ticket, summary string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "[Sub-ticket resolved: %q]\n", child.Title)
+	fmt.Fprintf(&b, "Summary: %s\n", summary)
+	if len(child.Messages) > 0 {
+		b.WriteString("\nFull conversation:\n")
+		for _, m := range child.Messages {
+			fmt.Fprintf(&b, "[%s]: %s\n", m.From, m.Content)
+		}
+	}
+	return b.String()
+}
+
+// compactRelay carries the summary and either an LLM handoff or, when
+// handoff is empty, the child's last message.
+func compactRelay(child *protocol.Ticket, summary, handoff string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "[Sub-ticket resolved: %q (%s)]\n", child.Title, child.ID)
+	fmt.Fprintf(&b, "Summary: %s\n", summary)
+	if handoff != "" {
+		fmt.Fprintf(&b, "\nHandoff:\n%s\n", handoff)
+	} else if n := len(child.Messages); n > 0 {
+		last := child.Messages[n-1]
+		fmt.Fprintf(&b, "\nLast message [%s]: %s\n", last.From, last.Content)
+	}
+	fmt.Fprintf(&b, "\nThe full conversation is on ticket %s (get_ticket).\n", child.ID)
+	return b.String()
+}
+
+// condense asks the creator agent's provider for a short handoff note on the
+// child ticket, so the parent agent reads it in its own model's words. The
+// call gives up after condenseTimeout or when ctx ends.
+func (r *Registry) condense(ctx context.Context, creator *AgentHandle, child *protocol.Ticket, summary string) (string, error) {
+	if len(child.Messages) == 0 {
+		return "", nil
+	}
+	if creator == nil || creator.Agent == nil || creator.Agent.Provider == nil {
+		return "", fmt.Errorf("no provider for creator %q", child.CreatedBy)
+	}
+
+	var conv strings.Builder
+	fmt.Fprintf(&conv, "Ticket: %s\nGoal: %s\nClosing summary: %s\n\n", child.Title, child.Goal, summary)
+	for _, m := range child.Messages {
+		fmt.Fprintf(&conv, "[%s]: %s\n", m.From, m.Content)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, condenseTimeout)
+	defer cancel()
+	temperature := 0.2
+	resp, err := creator.Agent.Provider.Chat(ctx, protocol.ChatRequest{
+		Model: creator.Agent.Spec.Model,
+		Messages: []protocol.ChatMessage{
+			{
+				Role:    "system",
+				Content: "Condense this delegated ticket into a handoff note for the agent that delegated it: the outcome, concrete results (names, values, file paths, links), and anything left open. Leave out the back-and-forth. At most 150 words.",
+			},
+			{Role: "user", Content: conv.String()},
+		},
+		MaxTokens:   400,
+		Temperature: &temperature,
+	})
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(resp.Content), nil
+}
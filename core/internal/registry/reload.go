@@ -0,0 +1,104 @@
+package registry
+
+import (
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+
+	"github.com/h1v3-io/h1v3/internal/ticket"
+	"github.com/h1v3-io/h1v3/pkg/protocol"
+)
+
+// SpecChanges reports what ApplyAgentSpecs did, by agent ID.
+type SpecChanges struct {
+	Updated []string // new core instructions or scoped contexts
+	Added   []string
+	Removed []string
+	Skipped []string // "agent: reason" for changes left for a restart
+}
+
+// ApplyAgentSpecs brings the registered agents in line with a reloaded
+// config as far as can be done while they run. Agents whose core
+// instructions or scoped contexts changed get the new text on their next
+// turn; agents not registered yet are set up by start; agents missing from
+// specs are deregistered unless they still have unclosed tickets. Any other
+// change to an agent needs a restart and is logged and skipped. Agents made
+// with create_agent are not in the config and are left alone.
+func (r *Registry) ApplyAgentSpecs(specs []protocol.AgentSpec, start func(protocol.AgentSpec) error) SpecChanges {
+	var changes SpecChanges
+	skip := func(id, reason string) {
+		r.logger.Warn("config reload: change not applied", "agent", id, "reason", reason)
+		changes.Skipped = append(changes.Skipped, id+": "+reason)
+	}
+
+	wanted := make(map[string]bool, len(specs))
+	for _, s := range specs {
+		wanted[s.ID] = true
+	}
+	r.mu.RLock()
+	var gone []string
+	for id := range r.agents {
+		if _, dynamic := r.creators[id]; !dynamic && !wanted[id] {
+			gone = append(gone, id)
+		}
+	}
+	r.mu.RUnlock()
+	slices.Sort(gone)
+
+	for _, id := range gone {
+		n, err := r.unclosedTickets(id)
+		switch {
+		case err != nil:
+			skip(id, fmt.Sprintf("removal: count tickets: %v", err))
+		case n > 0:
+			skip(id, fmt.Sprintf("removal: %d unclosed ticket(s)", n))
+		default:
+			if err := r.DeregisterAgent(id); err != nil {
+				skip(id, "removal: "+err.Error())
+				continue
+			}
+			changes.Removed = append(changes.Removed, id)
+		}
+	}
+
+	for _, spec := range specs {
+		h, ok := r.GetAgent(spec.ID)
+		if !ok {
+			if err := start(spec); err != nil {
+				skip(spec.ID, "add: "+err.Error())
+				continue
+			}
+			changes.Added = append(changes.Added, spec.ID)
+			continue
+		}
+
+		core, scoped := h.Agent.Instructions()
+		if core != spec.CoreInstructions || !maps.Equal(scoped, spec.ScopedContexts) {
+			h.Agent.SetInstructions(spec.CoreInstructions, spec.ScopedContexts)
+			changes.Updated = append(changes.Updated, spec.ID)
+			r.logger.Info("config reload: agent instructions updated", "agent", spec.ID)
+		}
+		before, after := h.Spec, spec
+		before.CoreInstructions, before.ScopedContexts = "", nil
+		after.CoreInstructions, after.ScopedContexts = "", nil
+		if !reflect.DeepEqual(before, after) {
+			skip(spec.ID, "settings other than core_instructions and scoped_contexts changed; restart to apply them")
+		}
+	}
+	return changes
+}
+
+// unclosedTickets counts the open and awaiting-close tickets an agent
+// created or is assigned.
+func (r *Registry) unclosedTickets(agentID string) (int, error) {
+	total := 0
+	for _, status := range []protocol.TicketStatus{protocol.TicketOpen, protocol.TicketAwaitingClose} {
+		n, err := r.store.Count(ticket.Filter{Status: &status, AgentID: agentID})
+		if err != nil {
+			return 0, err
+		}
+		total += n
+	}
+	return total, nil
+}
@@ -0,0 +1,77 @@
+package registry
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/h1v3-io/h1v3/pkg/protocol"
+)
+
+func TestApplyAgentSpecs(t *testing.T) {
+	r := newTestRegistry(t)
+	for _, id := range []string{"front", "coder", "idle", "busy"} {
+		spec, ag := dummyAgent(id)
+		r.RegisterAgent(spec, ag)
+	}
+	spec, ag := dummyAgent("spawned")
+	r.RegisterAgent(spec, ag)
+	r.creators["spawned"] = "front"
+	if _, err := r.CreateTicket("front", "Ongoing", "", "", []string{"busy"}, nil); err != nil {
+		t.Fatalf("CreateTicket: %v", err)
+	}
+
+	var started []string
+	start := func(s protocol.AgentSpec) error {
+		if s.ID == "broken" {
+			return errors.New("no directory")
+		}
+		started = append(started, s.ID)
+		_, ag := dummyAgent(s.ID)
+		ag.Spec = s
+		return r.RegisterAgent(s, ag)
+	}
+	specs := []protocol.AgentSpec{
+		{ID: "front", CoreInstructions: "test"},
+		{ID: "coder", CoreInstructions: "Write Go.", ScopedContexts: map[string]string{"repo": "h1v3"}},
+		{ID: "reviewer", CoreInstructions: "Review."},
+		{ID: "broken"},
+	}
+	changes := r.ApplyAgentSpecs(specs, start)
+
+	if !slices.Equal(changes.Updated, []string{"coder"}) {
+		t.Errorf("updated = %v", changes.Updated)
+	}
+	if !slices.Equal(changes.Added, []string{"reviewer"}) || !slices.Equal(started, []string{"reviewer"}) {
+		t.Errorf("added = %v, started = %v", changes.Added, started)
+	}
+	if !slices.Equal(changes.Removed, []string{"idle"}) {
+		t.Errorf("removed = %v", changes.Removed)
+	}
+	if len(changes.Skipped) != 2 || !strings.HasPrefix(changes.Skipped[0], "busy: removal: 1 unclosed") || !strings.HasPrefix(changes.Skipped[1], "broken: add:") {
+		t.Errorf("skipped = %q", changes.Skipped)
+	}
+
+	h, _ := r.GetAgent("coder")
+	core, scoped := h.Agent.Instructions()
+	if core != "Write Go." || scoped["repo"] != "h1v3" {
+		t.Errorf("coder instructions = %q, %v", core, scoped)
+	}
+	if prompt := h.Agent.BuildSystemPrompt(nil, nil); !strings.Contains(prompt, "Write Go.") || !strings.Contains(prompt, "## repo\nh1v3") {
+		t.Errorf("system prompt does not use the new instructions:\n%s", prompt)
+	}
+	for id, want := range map[string]bool{"idle": false, "busy": true, "spawned": true, "reviewer": true} {
+		if _, ok := r.GetAgent(id); ok != want {
+			t.Errorf("agent %s registered = %v, want %v", id, ok, want)
+		}
+	}
+
+	// Settings that need a restart are reported, instructions still apply.
+	specs[1].Role = "senior coder"
+	specs[1].CoreInstructions = "Write careful Go."
+	changes = r.ApplyAgentSpecs(specs[:3], start)
+	if !slices.Equal(changes.Updated, []string{"coder"}) || len(changes.Skipped) != 2 || !strings.Contains(changes.Skipped[1], "coder: settings other than") {
+		t.Errorf("second reload = %+v", changes)
+	}
+}
@@ -0,0 +1,142 @@
+package registry
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/robfig/cron/v3"
+
+	"github.com/h1v3-io/h1v3/internal/ticket"
+	"github.com/h1v3-io/h1v3/pkg/protocol"
+)
+
+// ScheduleMessage persists a message to be routed to s.AgentID on s.TicketID
+// later. A schedule with Cron set recurs; otherwise it fires once at NextRun.
+// For recurring schedules a zero NextRun is computed from the cron expression.
+func (r *Registry) ScheduleMessage(s ticket.Schedule) (*ticket.Schedule, error) {
+	tk, err := r.store.Get(s.TicketID)
+	if err != nil {
+		return nil, fmt.Errorf("registry: schedule message: %w", err)
+	}
+	if tk.Status == protocol.TicketClosed {
+		return nil, fmt.Errorf("registry: schedule message: ticket %q is closed", s.TicketID)
+	}
+
+	now := time.Now()
+	if s.Cron != "" {
+		sched, err := cron.ParseStandard(s.Cron)
+		if err != nil {
+			return nil, fmt.Errorf("registry: schedule message: invalid cron %q: %w", s.Cron, err)
+		}
+		if s.NextRun.IsZero() {
+			s.NextRun = sched.Next(now)
+		}
+	}
+	if s.NextRun.IsZero() {
+		return nil, fmt.Errorf("registry: schedule message: a run time or cron expression is required")
+	}
+
+	s.ID = "s-" + generateID()
+	s.CreatedAt = now
+	if err := r.store.SaveSchedule(s); err != nil {
+		return nil, fmt.Errorf("registry: schedule message: %w", err)
+	}
+	r.logger.Info("message scheduled", "schedule", s.ID, "ticket", s.TicketID, "to", s.AgentID, "next_run", s.NextRun, "cron", s.Cron)
+	return &s, nil
+}
+
+// CancelSchedule removes a scheduled message. Only the agent that created it
+// may cancel it.
+func (r *Registry) CancelSchedule(id, by string) error {
+	s, err := r.store.GetSchedule(id)
+	if err != nil {
+		return fmt.Errorf("registry: cancel schedule: %w", err)
+	}
+	if s.CreatedBy != by {
+		return fmt.Errorf("registry: cancel schedule: %q was created by %s", id, s.CreatedBy)
+	}
+	if err := r.store.DeleteSchedule(id); err != nil {
+		return fmt.Errorf("registry: cancel schedule: %w", err)
+	}
+	r.logger.Info("schedule cancelled", "schedule", id, "by", by)
+	return nil
+}
+
+// FireDueSchedules routes every scheduled message due at or before now as a
+// _system message. One-shot schedules are removed after firing; recurring
+// ones advance to their next run. Schedules on closed tickets are dropped.
+// Returns how many messages were routed.
+func (r *Registry) FireDueSchedules(now time.Time) (int, error) {
+	schedules, err := r.store.ListSchedules()
+	if err != nil {
+		return 0, fmt.Errorf("registry: fire schedules: %w", err)
+	}
+
+	fired := 0
+	for _, s := range schedules {
+		if s.NextRun.After(now) {
+			break // sorted by next run
+		}
+
+		if tk, err := r.store.Get(s.TicketID); err != nil || tk.Status == protocol.TicketClosed {
+			r.logger.Info("dropping schedule for closed or missing ticket", "schedule", s.ID, "ticket", s.TicketID)
+			r.deleteSchedule(s.ID)
+			continue
+		}
+
+		msg := protocol.Message{
+			ID:        generateID(),
+			From:      "_system",
+			To:        []string{s.AgentID},
+			Content:   fmt.Sprintf("[Scheduled %s] %s", s.ID, s.Message),
+			TicketID:  s.TicketID,
+			Timestamp: now,
+		}
+		if err := r.RouteMessage(msg); err != nil {
+			r.logger.Error("failed to route scheduled message", "schedule", s.ID, "ticket", s.TicketID, "error", err)
+		} else {
+			fired++
+		}
+
+		if s.Cron == "" {
+			r.deleteSchedule(s.ID)
+			continue
+		}
+		sched, err := cron.ParseStandard(s.Cron)
+		if err != nil {
+			r.logger.Error("dropping schedule with invalid cron", "schedule", s.ID, "cron", s.Cron, "error", err)
+			r.deleteSchedule(s.ID)
+			continue
+		}
+		s.NextRun = sched.Next(now)
+		if err := r.store.SaveSchedule(s); err != nil {
+			r.logger.Error("failed to advance schedule", "schedule", s.ID, "error", err)
+		}
+	}
+	return fired, nil
+}
+
+func (r *Registry) deleteSchedule(id string) {
+	if err := r.store.DeleteSchedule(id); err != nil {
+		r.logger.Error("failed to delete schedule", "schedule", id, "error", err)
+	}
+}
+
+// RunSchedules fires due scheduled messages every interval until ctx is
+// cancelled. Schedules live in the ticket store, so anything that came due
+// while the daemon was down fires on the first tick.
+func (r *Registry) RunSchedules(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if _, err := r.FireDueSchedules(time.Now()); err != nil {
+			r.logger.Error("schedule sweep failed", "error", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package registry
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/h1v3-io/h1v3/internal/ticket"
+)
+
+func TestScheduleMessage_OneShot(t *testing.T) {
+	r := newTestRegistry(t)
+	spec, ag := dummyAgent("agent-b")
+	r.RegisterAgent(spec, ag)
+	tk, _ := r.CreateTicket("agent-a", "Deploy", "", "", []string{"agent-b"}, nil)
+
+	now := time.Now()
+	s, err := r.ScheduleMessage(ticket.Schedule{TicketID: tk.ID, AgentID: "agent-b", Message: "check the deploy", NextRun: now.Add(30 * time.Minute), CreatedBy: "agent-b"})
+	if err != nil {
+		t.Fatalf("schedule: %v", err)
+	}
+
+	if n, _ := r.FireDueSchedules(now); n != 0 {
+		t.Fatalf("expected nothing due yet, fired %d", n)
+	}
+	if n, _ := r.FireDueSchedules(now.Add(31 * time.Minute)); n != 1 {
+		t.Fatalf("expected 1 fired, got %d", n)
+	}
+
+	h, _ := r.GetAgent("agent-b")
+	select {
+	case msg := <-h.Inbox:
+		if msg.From != "_system" || !strings.Contains(msg.Content, "check the deploy") || !strings.Contains(msg.Content, s.ID) {
+			t.Errorf("unexpected scheduled message: %+v", msg)
+		}
+	default:
+		t.Fatal("expected scheduled message in inbox")
+	}
+
+	if left, _ := r.Store().ListSchedules(); len(left) != 0 {
+		t.Errorf("one-shot schedule should be removed after firing, got %+v", left)
+	}
+}
+
+func TestScheduleMessage_Recurring(t *testing.T) {
+	r := newTestRegistry(t)
+	tk, _ := r.CreateTicket("agent-a", "Standup", "", "", []string{"agent-a"}, nil)
+
+	s, err := r.ScheduleMessage(ticket.Schedule{TicketID: tk.ID, AgentID: "agent-a", Message: "standup", Cron: "@every 1h", CreatedBy: "agent-a"})
+	if err != nil {
+		t.Fatalf("schedule: %v", err)
+	}
+	first := s.NextRun
+
+	if n, _ := r.FireDueSchedules(first); n != 1 {
+		t.Fatalf("expected 1 fired, got %d", n)
+	}
+	left, _ := r.Store().ListSchedules()
+	if len(left) != 1 || !left[0].NextRun.After(first) {
+		t.Fatalf("recurring schedule should advance, got %+v", left)
+	}
+}
+
+func TestScheduleMessage_Validation(t *testing.T) {
+	r := newTestRegistry(t)
+	tk, _ := r.CreateTicket("agent-a", "T", "", "", []string{"agent-b"}, nil)
+
+	if _, err := r.ScheduleMessage(ticket.Schedule{TicketID: tk.ID, AgentID: "agent-b", Message: "x", Cron: "not a cron"}); err == nil {
+		t.Error("expected invalid cron error")
+	}
+	if _, err := r.ScheduleMessage(ticket.Schedule{TicketID: tk.ID, AgentID: "agent-b", Message: "x"}); err == nil {
+		t.Error("expected missing run time error")
+	}
+
+	r.CloseTicket(tk.ID, "done", "agent-a")
+	if _, err := r.ScheduleMessage(ticket.Schedule{TicketID: tk.ID, AgentID: "agent-b", Message: "x", NextRun: time.Now().Add(time.Hour)}); err == nil {
+		t.Error("expected closed ticket error")
+	}
+}
+
+func TestFireDueSchedules_DropsClosedTicket(t *testing.T) {
+	r := newTestRegistry(t)
+	tk, _ := r.CreateTicket("agent-a", "T", "", "", []string{"agent-b"}, nil)
+	s, _ := r.ScheduleMessage(ticket.Schedule{TicketID: tk.ID, AgentID: "agent-b", Message: "x", Cron: "@hourly", CreatedBy: "agent-a"})
+	r.CloseTicket(tk.ID, "done", "agent-a")
+
+	if n, _ := r.FireDueSchedules(s.NextRun); n != 0 {
+		t.Errorf("expected nothing fired on a closed ticket, got %d", n)
+	}
+	if left, _ := r.Store().ListSchedules(); len(left) != 0 {
+		t.Errorf("schedule on closed ticket should be dropped, got %+v", left)
+	}
+}
+
+func TestCancelSchedule(t *testing.T) {
+	r := newTestRegistry(t)
+	tk, _ := r.CreateTicket("agent-a", "T", "", "", []string{"agent-b"}, nil)
+	s, _ := r.ScheduleMessage(ticket.Schedule{TicketID: tk.ID, AgentID: "agent-b", Message: "x", NextRun: time.Now().Add(time.Hour), CreatedBy: "agent-a"})
+
+	if err := r.CancelSchedule(s.ID, "agent-b"); err == nil {
+		t.Error("expected error cancelling another agent's schedule")
+	}
+	if err := r.CancelSchedule(s.ID, "agent-a"); err != nil {
+		t.Fatalf("cancel: %v", err)
+	}
+	if err := r.CancelSchedule(s.ID, "agent-a"); err == nil {
+		t.Error("expected error cancelling a missing schedule")
+	}
+}
@@ -0,0 +1,49 @@
+package registry
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/h1v3-io/h1v3/pkg/protocol"
+)
+
+// StartupTag marks the self-tickets opened by Startup.
+const StartupTag = "startup"
+
+// Startup opens a self-ticket for the agent carrying its StartupPrompt and
+// delivers the prompt to its inbox, so the agent runs one turn right after
+// registration. The turn is an ordinary worker turn: failures are retried
+// and logged like any other, and whatever the agent replies or closes the
+// ticket with is stored on the ticket. Returns nil, nil when the agent has
+// no startup prompt.
+func (r *Registry) Startup(agentID string) (*protocol.Ticket, error) {
+	h, ok := r.GetAgent(agentID)
+	if !ok {
+		return nil, fmt.Errorf("registry: startup: agent %q not found", agentID)
+	}
+	prompt := h.Spec.StartupPrompt
+	if prompt == "" {
+		return nil, nil
+	}
+
+	to := []string{agentID}
+	t := newTicket(agentID, "Startup", prompt, "", to, []string{StartupTag})
+	msg := protocol.Message{
+		ID:   generateID(),
+		From: "_system",
+		To:   to,
+		Content: "[startup] You have just been started. Before handling any requests:\n\n" + prompt +
+			"\n\nThis is your own startup ticket; nobody is waiting on it. Close it with close_ticket and a short summary when you are done.",
+		TicketID:  t.ID,
+		Timestamp: time.Now(),
+	}
+	if err := r.store.SaveWithMessage(t, msg); err != nil {
+		return nil, fmt.Errorf("registry: startup: %w", err)
+	}
+	t.Messages = []protocol.Message{msg}
+
+	r.recordEvent(t.ID, protocol.EventCreated, "_system", "startup ticket for "+agentID)
+	r.logger.Info("startup ticket created", "agent", agentID, "ticket", t.ID)
+	r.deliver(msg)
+	return t, nil
+}
@@ -0,0 +1,46 @@
+package registry
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestStartup(t *testing.T) {
+	r := newTestRegistry(t)
+	spec, ag := dummyAgent("board")
+	spec.StartupPrompt = "Fetch the project board into memory."
+	spec.CanDelegateTo = []string{"someone-else"} // self-tickets are not delegation
+	r.RegisterAgent(spec, ag)
+	quiet, ag := dummyAgent("quiet")
+	r.RegisterAgent(quiet, ag)
+
+	tk, err := r.Startup("board")
+	if err != nil {
+		t.Fatalf("startup: %v", err)
+	}
+	if tk.CreatedBy != "board" || !slices.Equal(tk.WaitingOn, []string{"board"}) || !slices.Contains(tk.Tags, StartupTag) {
+		t.Errorf("expected a self-ticket tagged %q, got %+v", StartupTag, tk)
+	}
+
+	h, _ := r.GetAgent("board")
+	select {
+	case msg := <-h.Inbox:
+		if msg.From != "_system" || msg.TicketID != tk.ID || !strings.Contains(msg.Content, spec.StartupPrompt) {
+			t.Errorf("unexpected startup message %+v", msg)
+		}
+	default:
+		t.Fatal("expected the startup prompt in the agent's inbox")
+	}
+	stored, _ := r.GetTicket(tk.ID)
+	if len(stored.Messages) != 1 {
+		t.Errorf("expected the prompt persisted on the ticket, got %d messages", len(stored.Messages))
+	}
+
+	if tk, err := r.Startup("quiet"); tk != nil || err != nil {
+		t.Errorf("expected no-op without a startup prompt, got %v, %v", tk, err)
+	}
+	if _, err := r.Startup("ghost"); err == nil {
+		t.Error("expected error for unknown agent")
+	}
+}
@@ -0,0 +1,53 @@
+package registry
+
+import (
+	"github.com/h1v3-io/h1v3/pkg/protocol"
+)
+
+// subscriberBuffer is how many messages a subscriber may fall behind before
+// further messages are dropped for it.
+const subscriberBuffer = 64
+
+// Subscribe returns a channel receiving every message added to the ticket
+// from now on, and a func that ends the subscription and closes the channel.
+// A subscriber that falls behind misses messages rather than slowing routing
+// down.
+func (r *Registry) Subscribe(ticketID string) (<-chan protocol.Message, func()) {
+	ch := make(chan protocol.Message, subscriberBuffer)
+	r.subMu.Lock()
+	if r.subs == nil {
+		r.subs = make(map[string]map[chan protocol.Message]struct{})
+	}
+	if r.subs[ticketID] == nil {
+		r.subs[ticketID] = make(map[chan protocol.Message]struct{})
+	}
+	r.subs[ticketID][ch] = struct{}{}
+	r.subMu.Unlock()
+
+	cancel := func() {
+		r.subMu.Lock()
+		defer r.subMu.Unlock()
+		if _, ok := r.subs[ticketID][ch]; !ok {
+			return
+		}
+		delete(r.subs[ticketID], ch)
+		if len(r.subs[ticketID]) == 0 {
+			delete(r.subs, ticketID)
+		}
+		close(ch)
+	}
+	return ch, cancel
+}
+
+// publish fans a persisted message out to the ticket's subscribers.
+func (r *Registry) publish(msg protocol.Message) {
+	r.subMu.Lock()
+	defer r.subMu.Unlock()
+	for ch := range r.subs[msg.TicketID] {
+		select {
+		case ch <- msg:
+		default:
+			r.logger.Warn("ticket subscriber is behind, message dropped", "ticket", msg.TicketID, "msg_id", msg.ID)
+		}
+	}
+}
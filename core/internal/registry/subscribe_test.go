@@ -0,0 +1,98 @@
+package registry
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/h1v3-io/h1v3/pkg/protocol"
+)
+
+func TestSubscribe(t *testing.T) {
+	r := newTestRegistry(t)
+	tk, _ := r.CreateTicket("agent-a", "Watched", "", "", []string{"agent-b"}, nil)
+	other, _ := r.CreateTicket("agent-a", "Other", "", "", []string{"agent-b"}, nil)
+
+	msgs, cancel := r.Subscribe(tk.ID)
+	r.RouteMessage(protocol.Message{ID: "m1", From: "agent-a", To: []string{"agent-b"}, TicketID: tk.ID, Content: "hello"})
+	r.RouteMessage(protocol.Message{ID: "m2", From: "agent-a", To: []string{"agent-b"}, TicketID: other.ID, Content: "elsewhere"})
+	r.PersistMessage(tk.ID, protocol.Message{ID: "m3", From: "_system", TicketID: tk.ID, Content: "receipt"})
+
+	for _, want := range []string{"m1", "m3"} {
+		select {
+		case got := <-msgs:
+			if got.ID != want {
+				t.Errorf("got %s, want %s", got.ID, want)
+			}
+		default:
+			t.Fatalf("expected %s on the subscription", want)
+		}
+	}
+	select {
+	case got := <-msgs:
+		t.Errorf("unexpected message %+v", got)
+	default:
+	}
+
+	cancel()
+	cancel() // idempotent
+	if _, ok := <-msgs; ok {
+		t.Error("expected the channel closed after cancel")
+	}
+	if err := r.RouteMessage(protocol.Message{From: "agent-a", To: []string{"agent-b"}, TicketID: tk.ID, Content: "after"}); err != nil {
+		t.Fatalf("route after cancel: %v", err)
+	}
+}
+
+func TestSubscribe_SlowSubscriberDoesNotBlock(t *testing.T) {
+	r := newTestRegistry(t)
+	tk, _ := r.CreateTicket("agent-a", "Busy", "", "", []string{"agent-b"}, nil)
+	msgs, cancel := r.Subscribe(tk.ID)
+	defer cancel()
+
+	for range subscriberBuffer + 5 {
+		if err := r.RouteMessage(protocol.Message{From: "agent-a", To: []string{"agent-b"}, TicketID: tk.ID, Content: "x"}); err != nil {
+			t.Fatalf("route: %v", err)
+		}
+	}
+	if len(msgs) != subscriberBuffer {
+		t.Errorf("expected a full buffer of %d, got %d", subscriberBuffer, len(msgs))
+	}
+}
+
+func TestSubscribe_SeesCloseNoticesAndCascadeRelays(t *testing.T) {
+	r := newTestRegistry(t)
+	for _, id := range []string{"lead", "coder", "supervisor"} {
+		spec, ag := dummyAgent(id)
+		r.RegisterAgent(spec, ag)
+	}
+	root, _ := r.CreateTicket("_external", "Build feature", "", "", []string{"lead"}, nil)
+	child, _ := r.CreateTicket("lead", "Write code", "", root.ID, []string{"coder"}, nil)
+	r.WatchTicket(child.ID, "supervisor", "lead")
+
+	rootMsgs, cancelRoot := r.Subscribe(root.ID)
+	defer cancelRoot()
+	childMsgs, cancelChild := r.Subscribe(child.ID)
+	defer cancelChild()
+
+	if _, err := r.CloseTicketTree(root.ID, "shipped", "_external"); err != nil {
+		t.Fatalf("close tree: %v", err)
+	}
+
+	select {
+	case got := <-childMsgs:
+		if got.From != "_system" || !strings.Contains(got.Content, "was closed by") {
+			t.Errorf("expected the watcher close notice, got %+v", got)
+		}
+	default:
+		t.Error("expected the watcher close notice on the child's subscription")
+	}
+	select {
+	case got := <-rootMsgs:
+		if got.From != "_system" || !slices.Equal(got.To, []string{"lead"}) {
+			t.Errorf("expected the cascade relay to lead, got %+v", got)
+		}
+	default:
+		t.Error("expected the cascade relay on the root's subscription")
+	}
+}
@@ -0,0 +1,130 @@
+package registry
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/h1v3-io/h1v3/pkg/protocol"
+)
+
+// WatchTicket adds agentID to a ticket's watchers. Watchers receive every
+// message on the ticket and a notice when it closes, but are never addressed
+// by responses and have no say in its status. Watching a ticket the agent
+// already participates in, or already watches, is a no-op. An agent may
+// only watch tickets it created or is assigned to, and only the creator may
+// add other agents; callers that are not agents (the API, the system) are
+// not restricted.
+func (r *Registry) WatchTicket(ticketID, agentID, by string) error {
+	r.watchMu.Lock()
+	defer r.watchMu.Unlock()
+
+	tk, err := r.store.Get(ticketID)
+	if err != nil {
+		return fmt.Errorf("registry: watch ticket: %w", err)
+	}
+	if tk.Status == protocol.TicketClosed {
+		return fmt.Errorf("registry: watch ticket: ticket %s is closed", ticketID)
+	}
+	if agentID == tk.CreatedBy || slices.Contains(tk.WaitingOn, agentID) || slices.Contains(tk.Watchers, agentID) {
+		return nil
+	}
+	if _, isAgent := r.GetAgent(by); isAgent {
+		if by != tk.CreatedBy && !slices.Contains(tk.WaitingOn, by) {
+			return fmt.Errorf("registry: watch ticket: %s is not a participant in ticket %s", by, ticketID)
+		}
+		if agentID != by && by != tk.CreatedBy {
+			return fmt.Errorf("registry: watch ticket: only the ticket's creator can add other watchers")
+		}
+	}
+	if err := r.store.SetWatchers(ticketID, append(tk.Watchers, agentID)); err != nil {
+		return fmt.Errorf("registry: watch ticket: %w", err)
+	}
+	r.recordEvent(ticketID, protocol.EventWatched, by, agentID)
+	return nil
+}
+
+// UnwatchTicket removes agentID from a ticket's watchers, if present. An
+// agent may only remove itself, unless it created the ticket; callers that
+// are not agents are not restricted.
+func (r *Registry) UnwatchTicket(ticketID, agentID, by string) error {
+	r.watchMu.Lock()
+	defer r.watchMu.Unlock()
+
+	tk, err := r.store.Get(ticketID)
+	if err != nil {
+		return fmt.Errorf("registry: unwatch ticket: %w", err)
+	}
+	i := slices.Index(tk.Watchers, agentID)
+	if i < 0 {
+		return nil
+	}
+	if _, isAgent := r.GetAgent(by); isAgent && agentID != by && by != tk.CreatedBy {
+		return fmt.Errorf("registry: unwatch ticket: only the watcher or the ticket's creator can remove a watcher")
+	}
+	if err := r.store.SetWatchers(ticketID, slices.Delete(tk.Watchers, i, i+1)); err != nil {
+		return fmt.Errorf("registry: unwatch ticket: %w", err)
+	}
+	r.recordEvent(ticketID, protocol.EventUnwatched, by, agentID)
+	return nil
+}
+
+// notifyWatchers copies a delivered message into the inbox of every watcher
+// it was not already addressed to. The persisted message keeps its original
+// recipients, so watchers never show up in To.
+func (r *Registry) notifyWatchers(tk *protocol.Ticket, msg protocol.Message) {
+	if len(tk.Watchers) == 0 {
+		return
+	}
+	var waiting []*AgentHandle
+	r.mu.RLock()
+	for _, id := range tk.Watchers {
+		if id == msg.From || slices.Contains(msg.To, id) || !tk.IsWatcher(id) {
+			continue
+		}
+		h, ok := r.agents[id]
+		if !ok {
+			continue
+		}
+		switch err := r.deliverToAgent(h, msg); {
+		case err == nil:
+			r.logger.Debug("message delivered to watcher", "to", id, "ticket", tk.ID)
+		case errors.Is(err, errWouldBlock):
+			waiting = append(waiting, h)
+		}
+	}
+	r.mu.RUnlock()
+
+	for _, h := range waiting {
+		r.waitDeliver(h, msg)
+	}
+}
+
+// notifyWatchersClosed tells a closed ticket's watchers how it ended. The
+// notice is persisted on the ticket and delivered directly, since RouteMessage
+// no longer delivers on closed tickets.
+func (r *Registry) notifyWatchersClosed(tk *protocol.Ticket, summary, by string) {
+	var to []string
+	for _, id := range tk.Watchers {
+		if tk.IsWatcher(id) {
+			to = append(to, id)
+		}
+	}
+	if len(to) == 0 {
+		return
+	}
+	msg := protocol.Message{
+		ID:        generateID(),
+		From:      "_system",
+		To:        to,
+		Content:   fmt.Sprintf("Ticket %s (%s) was closed by %s. Summary: %s", tk.ID, tk.Title, by, summary),
+		TicketID:  tk.ID,
+		Timestamp: time.Now(),
+	}
+	if err := r.PersistMessage(tk.ID, msg); err != nil {
+		r.logger.Error("failed to persist watcher close notice", "ticket", tk.ID, "error", err)
+		return
+	}
+	r.deliver(msg)
+}
@@ -0,0 +1,142 @@
+package registry
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/h1v3-io/h1v3/pkg/protocol"
+)
+
+func TestWatchTicket(t *testing.T) {
+	r := newTestRegistry(t)
+	for _, id := range []string{"lead", "coder", "supervisor"} {
+		spec, ag := dummyAgent(id)
+		r.RegisterAgent(spec, ag)
+	}
+	coder, _ := r.GetAgent("coder")
+	sup, _ := r.GetAgent("supervisor")
+
+	tk, _ := r.CreateTicket("lead", "Fix bug", "", "", []string{"coder"}, nil)
+	if err := r.WatchTicket(tk.ID, "supervisor", "lead"); err != nil {
+		t.Fatalf("watch: %v", err)
+	}
+	// Participants and existing watchers are not added again.
+	r.WatchTicket(tk.ID, "supervisor", "supervisor")
+	r.WatchTicket(tk.ID, "coder", "lead")
+
+	got, _ := r.GetTicket(tk.ID)
+	if !slices.Equal(got.Watchers, []string{"supervisor"}) {
+		t.Fatalf("expected watchers [supervisor], got %v", got.Watchers)
+	}
+	if !slices.Equal(got.WaitingOn, []string{"coder"}) {
+		t.Errorf("watching must not change waiting_on, got %v", got.WaitingOn)
+	}
+
+	r.RouteMessage(protocol.Message{ID: "m1", From: "lead", To: []string{"coder"}, Content: "please fix", TicketID: tk.ID})
+	if len(coder.Inbox) != 1 || len(sup.Inbox) != 1 {
+		t.Fatalf("expected one delivery each to coder and supervisor, got %d and %d", len(coder.Inbox), len(sup.Inbox))
+	}
+	<-coder.Inbox
+	if m := <-sup.Inbox; m.ID != "m1" || !slices.Equal(m.To, []string{"coder"}) {
+		t.Errorf("expected watcher copy of m1 addressed to coder, got %+v", m)
+	}
+
+	if err := r.CloseTicket(tk.ID, "fixed", "lead"); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+	if len(sup.Inbox) != 1 {
+		t.Fatalf("expected close notice for watcher, got %d messages", len(sup.Inbox))
+	}
+	notice := <-sup.Inbox
+	if notice.From != "_system" || !strings.Contains(notice.Content, "fixed") {
+		t.Errorf("unexpected close notice: %+v", notice)
+	}
+	if len(coder.Inbox) != 0 {
+		t.Errorf("close notice must only go to watchers, coder got %d", len(coder.Inbox))
+	}
+
+	events, _ := r.TicketEvents(tk.ID)
+	var watched int
+	for _, ev := range events {
+		if ev.Type == protocol.EventWatched {
+			watched++
+		}
+	}
+	if watched != 1 {
+		t.Errorf("expected one watched event, got %d", watched)
+	}
+}
+
+func TestUnwatchTicket(t *testing.T) {
+	r := newTestRegistry(t)
+	for _, id := range []string{"lead", "coder", "supervisor"} {
+		spec, ag := dummyAgent(id)
+		r.RegisterAgent(spec, ag)
+	}
+	sup, _ := r.GetAgent("supervisor")
+
+	tk, _ := r.CreateTicket("lead", "Fix bug", "", "", []string{"coder"}, nil)
+	r.WatchTicket(tk.ID, "supervisor", "lead")
+	if err := r.UnwatchTicket(tk.ID, "supervisor", "supervisor"); err != nil {
+		t.Fatalf("unwatch: %v", err)
+	}
+	r.RouteMessage(protocol.Message{From: "lead", To: []string{"coder"}, Content: "ping", TicketID: tk.ID})
+	r.CloseTicket(tk.ID, "done", "lead")
+	if len(sup.Inbox) != 0 {
+		t.Errorf("expected no deliveries after unwatch, got %d", len(sup.Inbox))
+	}
+	if err := r.WatchTicket(tk.ID, "supervisor", "lead"); err == nil {
+		t.Error("expected error watching a closed ticket")
+	}
+}
+
+func TestUnwatchTicket_Authorization(t *testing.T) {
+	r := newTestRegistry(t)
+	for _, id := range []string{"lead", "coder", "supervisor"} {
+		spec, ag := dummyAgent(id)
+		r.RegisterAgent(spec, ag)
+	}
+	tk, _ := r.CreateTicket("lead", "Fix bug", "", "", []string{"coder"}, nil)
+	r.WatchTicket(tk.ID, "supervisor", "lead")
+
+	// The supervised assignee cannot drop its supervisor.
+	if err := r.UnwatchTicket(tk.ID, "supervisor", "coder"); err == nil || !strings.Contains(err.Error(), "only the watcher or the ticket's creator") {
+		t.Errorf("expected the assignee refused, got %v", err)
+	}
+	if got, _ := r.GetTicket(tk.ID); !slices.Equal(got.Watchers, []string{"supervisor"}) {
+		t.Errorf("expected watchers [supervisor], got %v", got.Watchers)
+	}
+	if err := r.UnwatchTicket(tk.ID, "supervisor", "lead"); err != nil {
+		t.Errorf("expected the creator allowed, got %v", err)
+	}
+	r.WatchTicket(tk.ID, "supervisor", "lead")
+	if err := r.UnwatchTicket(tk.ID, "supervisor", "api"); err != nil {
+		t.Errorf("expected the operator allowed, got %v", err)
+	}
+}
+
+func TestWatchTicket_Authorization(t *testing.T) {
+	r := newTestRegistry(t)
+	for _, id := range []string{"lead", "coder", "outsider", "spy"} {
+		spec, ag := dummyAgent(id)
+		r.RegisterAgent(spec, ag)
+	}
+	tk, _ := r.CreateTicket("lead", "Fix bug", "", "", []string{"coder"}, nil)
+
+	if err := r.WatchTicket(tk.ID, "outsider", "outsider"); err == nil || !strings.Contains(err.Error(), "not a participant") {
+		t.Errorf("expected a non-participant refused, got %v", err)
+	}
+	if err := r.WatchTicket(tk.ID, "spy", "outsider"); err == nil {
+		t.Error("expected a non-participant refused adding another agent")
+	}
+	if err := r.WatchTicket(tk.ID, "spy", "coder"); err == nil || !strings.Contains(err.Error(), "only the ticket's creator") {
+		t.Errorf("expected an assignee refused adding another agent, got %v", err)
+	}
+	if err := r.WatchTicket(tk.ID, "spy", "api"); err != nil {
+		t.Errorf("expected the operator allowed, got %v", err)
+	}
+	if got, _ := r.GetTicket(tk.ID); !slices.Equal(got.Watchers, []string{"spy"}) {
+		t.Errorf("expected watchers [spy], got %v", got.Watchers)
+	}
+}
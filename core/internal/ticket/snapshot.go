@@ -0,0 +1,381 @@
+package ticket
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/h1v3-io/h1v3/pkg/protocol"
+)
+
+// ErrSnapshotConflict is returned by Import when a ticket or schedule in the
+// snapshot already exists and skipExisting is false.
+var ErrSnapshotConflict = errors.New("already exists")
+
+// Snapshot is a full dump of a ticket store: hot and archived tickets with
+// their messages, the event timelines, per-call token usage, schedules, and
+// dead letters. In-flight markers are
+// transient and left out. Attachment files are embedded as Data, so the
+// snapshot does not depend on the source data dir.
+type Snapshot struct {
+	Tickets     []*protocol.Ticket     `json:"tickets"`
+	Archived    []*protocol.Ticket     `json:"archived,omitempty"`
+	Events      []protocol.TicketEvent `json:"events,omitempty"`
+	Usage       []UsageRecord          `json:"usage,omitempty"`
+	Schedules   []Schedule             `json:"schedules,omitempty"`
+	DeadLetters []DeadLetter           `json:"dead_letters,omitempty"`
+}
+
+// UsageRecord is one provider call's token usage on a ticket, as stored by
+// RecordUsage.
+type UsageRecord struct {
+	ID               int64     `json:"id"`
+	TicketID         string    `json:"ticket_id"`
+	Model            string    `json:"model"`
+	PromptTokens     int       `json:"prompt_tokens"`
+	CompletionTokens int       `json:"completion_tokens"`
+	Timestamp        time.Time `json:"timestamp"`
+}
+
+// ImportStats counts what Import wrote and what it skipped.
+type ImportStats struct {
+	Tickets     int `json:"tickets"`
+	Messages    int `json:"messages"`
+	Events      int `json:"events"`
+	Usage       int `json:"usage"`
+	Schedules   int `json:"schedules"`
+	DeadLetters int `json:"dead_letters"`
+	Skipped     int `json:"skipped"`
+}
+
+// Export reads the whole store, oldest tickets first.
+func (s *SQLiteStore) Export() (*Snapshot, error) {
+	snap := &Snapshot{}
+	var err error
+	if snap.Tickets, err = s.exportTickets("tickets", "ticket_messages"); err != nil {
+		return nil, err
+	}
+	if snap.Archived, err = s.exportTickets("archived_tickets", "archived_ticket_messages"); err != nil {
+		return nil, err
+	}
+
+	rows, err := s.db.Query(`SELECT id, ticket_id, type, actor, detail, timestamp FROM ticket_events ORDER BY id`)
+	if err != nil {
+		return nil, fmt.Errorf("ticket store: export events: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var ev protocol.TicketEvent
+		var typ, ts string
+		if err := rows.Scan(&ev.ID, &ev.TicketID, &typ, &ev.Actor, &ev.Detail, &ts); err != nil {
+			return nil, fmt.Errorf("ticket store: export events: %w", err)
+		}
+		ev.Type = protocol.TicketEventType(typ)
+		ev.Timestamp, _ = time.Parse(time.RFC3339, ts)
+		snap.Events = append(snap.Events, ev)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("ticket store: export events: %w", err)
+	}
+
+	if snap.Usage, err = s.exportUsage(); err != nil {
+		return nil, err
+	}
+	if snap.Schedules, err = s.ListSchedules(); err != nil {
+		return nil, err
+	}
+	if snap.DeadLetters, err = s.ListDeadLetters(); err != nil {
+		return nil, err
+	}
+	return snap, nil
+}
+
+func (s *SQLiteStore) exportUsage() ([]UsageRecord, error) {
+	rows, err := s.db.Query(`SELECT id, ticket_id, model, prompt_tokens, completion_tokens, timestamp FROM ticket_usage ORDER BY id`)
+	if err != nil {
+		return nil, fmt.Errorf("ticket store: export usage: %w", err)
+	}
+	defer rows.Close()
+	var usage []UsageRecord
+	for rows.Next() {
+		var u UsageRecord
+		var ts string
+		if err := rows.Scan(&u.ID, &u.TicketID, &u.Model, &u.PromptTokens, &u.CompletionTokens, &ts); err != nil {
+			return nil, fmt.Errorf("ticket store: export usage: %w", err)
+		}
+		u.Timestamp, _ = time.Parse(time.RFC3339, ts)
+		usage = append(usage, u)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("ticket store: export usage: %w", err)
+	}
+	return usage, nil
+}
+
+func (s *SQLiteStore) exportTickets(table, messagesTable string) ([]*protocol.Ticket, error) {
+	rows, err := s.db.Query(`SELECT ` + ticketColumns + ` FROM ` + table + ` ORDER BY created_at, rowid`)
+	if err != nil {
+		return nil, fmt.Errorf("ticket store: export: %w", err)
+	}
+	var tickets []*protocol.Ticket
+	for rows.Next() {
+		t, err := scanTicketRows(rows)
+		if err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("ticket store: export scan: %w", err)
+		}
+		tickets = append(tickets, t)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("ticket store: export: %w", err)
+	}
+
+	for _, t := range tickets {
+		if t.Messages, err = s.loadMessages(messagesTable, t.ID); err != nil {
+			return nil, err
+		}
+		if err := embedBlobs(t); err != nil {
+			return nil, err
+		}
+		if t.Messages == nil {
+			t.Messages = []protocol.Message{}
+		}
+	}
+	return tickets, nil
+}
+
+// embedBlobs reads every attachment file of t into Data and drops the
+// source Path, which means nothing outside the source data dir.
+func embedBlobs(t *protocol.Ticket) error {
+	for i := range t.Messages {
+		for j := range t.Messages[i].Attachments {
+			a := &t.Messages[i].Attachments[j]
+			if a.Path == "" {
+				continue
+			}
+			data, err := os.ReadFile(a.Path)
+			if err != nil {
+				return fmt.Errorf("ticket store: export attachment of ticket %s: %w", t.ID, err)
+			}
+			a.Data, a.Path = data, ""
+		}
+	}
+	return nil
+}
+
+// Import writes snap into the store in one transaction, keeping every ID and
+// timestamp. Embedded attachments are written under the store's own
+// attachment dir; one carried only as a Path must already be inside it. A
+// ticket, schedule or dead letter whose ID is already present (for tickets,
+// hot or archived) is skipped when skipExisting is set and otherwise aborts
+// the whole import with ErrSnapshotConflict. Events and usage are only
+// imported for tickets that were written; events get fresh row IDs in their
+// original order, while usage rows keep theirs and conflict like tickets do.
+func (s *SQLiteStore) Import(snap *Snapshot, skipExisting bool) (ImportStats, error) {
+	var stats ImportStats
+	tx, err := s.db.Begin()
+	if err != nil {
+		return stats, fmt.Errorf("ticket store: import: %w", err)
+	}
+	defer tx.Rollback()
+
+	var written []string // blob files, removed again if the import fails
+	defer func() {
+		for _, p := range written {
+			os.Remove(p)
+		}
+	}()
+
+	imported := make(map[string]bool)
+	importTickets := func(tickets []*protocol.Ticket, archived bool) error {
+		for _, t := range tickets {
+			var n int
+			err := tx.QueryRow(`SELECT (SELECT COUNT(*) FROM tickets WHERE id = ?) + (SELECT COUNT(*) FROM archived_tickets WHERE id = ?)`, t.ID, t.ID).Scan(&n)
+			if err != nil {
+				return fmt.Errorf("ticket store: import: %w", err)
+			}
+			if n > 0 {
+				if skipExisting {
+					stats.Skipped++
+					continue
+				}
+				return fmt.Errorf("ticket store: import ticket %s: %w", t.ID, ErrSnapshotConflict)
+			}
+			if err := s.checkAttachmentPaths(t); err != nil {
+				return err
+			}
+			var paths []string
+			if archived {
+				paths, err = s.insertArchived(tx, t)
+			} else {
+				err = saveTicket(tx, t)
+				for _, msg := range t.Messages {
+					if err != nil {
+						break
+					}
+					var p []string
+					p, err = s.insertMessage(tx, t.ID, msg)
+					paths = append(paths, p...)
+				}
+			}
+			written = append(written, paths...)
+			if err != nil {
+				return err
+			}
+			imported[t.ID] = true
+			stats.Tickets++
+			stats.Messages += len(t.Messages)
+		}
+		return nil
+	}
+	if err := importTickets(snap.Tickets, false); err != nil {
+		return stats, err
+	}
+	if err := importTickets(snap.Archived, true); err != nil {
+		return stats, err
+	}
+
+	for _, ev := range snap.Events {
+		if !imported[ev.TicketID] {
+			continue
+		}
+		_, err := tx.Exec(`INSERT INTO ticket_events (ticket_id, type, actor, detail, timestamp) VALUES (?, ?, ?, ?, ?)`,
+			ev.TicketID, string(ev.Type), ev.Actor, ev.Detail, ev.Timestamp.Format(time.RFC3339))
+		if err != nil {
+			return stats, fmt.Errorf("ticket store: import events: %w", err)
+		}
+		stats.Events++
+	}
+
+	for _, u := range snap.Usage {
+		if !imported[u.TicketID] {
+			continue
+		}
+		var n int
+		if err := tx.QueryRow(`SELECT COUNT(*) FROM ticket_usage WHERE id = ?`, u.ID).Scan(&n); err != nil {
+			return stats, fmt.Errorf("ticket store: import usage: %w", err)
+		}
+		if n > 0 {
+			if skipExisting {
+				stats.Skipped++
+				continue
+			}
+			return stats, fmt.Errorf("ticket store: import usage %d: %w", u.ID, ErrSnapshotConflict)
+		}
+		_, err := tx.Exec(`INSERT INTO ticket_usage (id, ticket_id, model, prompt_tokens, completion_tokens, timestamp) VALUES (?, ?, ?, ?, ?, ?)`,
+			u.ID, u.TicketID, u.Model, u.PromptTokens, u.CompletionTokens, u.Timestamp.Format(time.RFC3339))
+		if err != nil {
+			return stats, fmt.Errorf("ticket store: import usage: %w", err)
+		}
+		stats.Usage++
+	}
+
+	for _, sc := range snap.Schedules {
+		var n int
+		if err := tx.QueryRow(`SELECT COUNT(*) FROM scheduled_messages WHERE id = ?`, sc.ID).Scan(&n); err != nil {
+			return stats, fmt.Errorf("ticket store: import schedules: %w", err)
+		}
+		if n > 0 {
+			if skipExisting {
+				stats.Skipped++
+				continue
+			}
+			return stats, fmt.Errorf("ticket store: import schedule %s: %w", sc.ID, ErrSnapshotConflict)
+		}
+		_, err := tx.Exec(`INSERT INTO scheduled_messages (id, ticket_id, agent_id, message, cron, next_run, created_by, created_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+			sc.ID, sc.TicketID, sc.AgentID, sc.Message, sc.Cron,
+			sc.NextRun.UTC().Format(time.RFC3339), sc.CreatedBy, sc.CreatedAt.UTC().Format(time.RFC3339))
+		if err != nil {
+			return stats, fmt.Errorf("ticket store: import schedules: %w", err)
+		}
+		stats.Schedules++
+	}
+
+	for _, d := range snap.DeadLetters {
+		var n int
+		if err := tx.QueryRow(`SELECT COUNT(*) FROM dead_letters WHERE id = ?`, d.ID).Scan(&n); err != nil {
+			return stats, fmt.Errorf("ticket store: import dead letters: %w", err)
+		}
+		if n > 0 {
+			if skipExisting {
+				stats.Skipped++
+				continue
+			}
+			return stats, fmt.Errorf("ticket store: import dead letter %s: %w", d.ID, ErrSnapshotConflict)
+		}
+		msg, err := json.Marshal(d.Message)
+		if err != nil {
+			return stats, fmt.Errorf("ticket store: import dead letters: %w", err)
+		}
+		_, err = tx.Exec(`INSERT INTO dead_letters (id, ticket_id, target, reason, message, created_at) VALUES (?, ?, ?, ?, ?, ?)`,
+			d.ID, d.Message.TicketID, d.Target, d.Reason, string(msg), d.CreatedAt.UTC().Format(time.RFC3339Nano))
+		if err != nil {
+			return stats, fmt.Errorf("ticket store: import dead letters: %w", err)
+		}
+		stats.DeadLetters++
+	}
+
+	if err := tx.Commit(); err != nil {
+		return stats, fmt.Errorf("ticket store: import: %w", err)
+	}
+	written = nil
+	return stats, nil
+}
+
+// checkAttachmentPaths rejects attachments of t that have no embedded data
+// and point outside the store's attachment dir, which an imported store
+// must not depend on.
+func (s *SQLiteStore) checkAttachmentPaths(t *protocol.Ticket) error {
+	for _, msg := range t.Messages {
+		for _, a := range msg.Attachments {
+			if a.Data != nil || a.Path == "" {
+				continue
+			}
+			rel, err := filepath.Rel(s.attachDir, a.Path)
+			if err != nil || !filepath.IsLocal(rel) {
+				return fmt.Errorf("ticket store: import ticket %s: attachment %q path %s is outside %s", t.ID, a.Name, a.Path, s.attachDir)
+			}
+		}
+	}
+	return nil
+}
+
+// insertArchived writes a ticket straight into the archive tables. Attachment
+// rows stay in message_attachments, as they do for Archive. It returns the
+// blob files written.
+func (s *SQLiteStore) insertArchived(tx execer, t *protocol.Ticket) ([]string, error) {
+	waitingOn, _ := json.Marshal(t.WaitingOn)
+	tags, _ := json.Marshal(t.Tags)
+	watchers, _ := json.Marshal(nonNil(t.Watchers))
+	_, err := tx.Exec(`INSERT INTO archived_tickets (`+ticketColumns+`, archived_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		t.ID, t.Title, t.Goal, string(t.Status), t.CreatedBy, string(waitingOn), string(tags),
+		t.ParentID, t.Summary, t.CreatedAt.Format(time.RFC3339), formatTime(t.ClosedAt), string(watchers), t.Priority, formatTime(t.DueAt), time.Now().Format(time.RFC3339))
+	if err != nil {
+		return nil, fmt.Errorf("ticket store: import archived: %w", err)
+	}
+	for _, tag := range t.Tags {
+		if _, err := tx.Exec(`INSERT OR IGNORE INTO archived_ticket_tags (ticket_id, tag) VALUES (?, ?)`, t.ID, tag); err != nil {
+			return nil, fmt.Errorf("ticket store: import archived: %w", err)
+		}
+	}
+	var written []string
+	for _, msg := range t.Messages {
+		recipients, _ := json.Marshal(msg.To)
+		_, err := tx.Exec(`INSERT INTO archived_ticket_messages (id, ticket_id, sender, recipients, content, timestamp) VALUES (?, ?, ?, ?, ?, ?)`,
+			msg.ID, t.ID, msg.From, string(recipients), msg.Content, msg.Timestamp.Format(time.RFC3339))
+		if err != nil {
+			return written, fmt.Errorf("ticket store: import archived: %w", err)
+		}
+		paths, err := s.saveAttachments(tx, t.ID, msg)
+		written = append(written, paths...)
+		if err != nil {
+			return written, err
+		}
+	}
+	return written, nil
+}
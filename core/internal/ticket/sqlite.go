@@ -4,6 +4,10 @@ import (
 	"database/sql"
 	"encoding/json"
 	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
 	"time"
 
 	_ "modernc.org/sqlite"
@@ -11,9 +15,12 @@ import (
 	"github.com/h1v3-io/h1v3/pkg/protocol"
 )
 
-// SQLiteStore implements Store using SQLite.
+// SQLiteStore implements Store using SQLite. Attachment blobs are written to
+// an "attachments" directory next to the database file.
 type SQLiteStore struct {
-	db *sql.DB
+	db        *sql.DB
+	attachDir string
+	fts       bool // ticket_messages_fts is available for MessageQuery
 }
 
 // NewSQLiteStore opens (or creates) a SQLite database and runs migrations.
@@ -29,7 +36,7 @@ func NewSQLiteStore(path string) (*SQLiteStore, error) {
 		return nil, fmt.Errorf("ticket store: wal: %w", err)
 	}
 
-	s := &SQLiteStore{db: db}
+	s := &SQLiteStore{db: db, attachDir: filepath.Join(filepath.Dir(path), "attachments")}
 	if err := s.migrate(); err != nil {
 		db.Close()
 		return nil, err
@@ -50,7 +57,10 @@ func (s *SQLiteStore) migrate() error {
 			parent_id  TEXT NOT NULL DEFAULT '',
 			summary    TEXT NOT NULL DEFAULT '',
 			created_at TEXT NOT NULL,
-			closed_at  TEXT
+			closed_at  TEXT,
+			watchers   TEXT NOT NULL DEFAULT '[]',
+			priority   INTEGER NOT NULL DEFAULT 0,
+			due_at     TEXT
 		);
 
 		CREATE TABLE IF NOT EXISTS ticket_messages (
@@ -73,46 +83,293 @@ func (s *SQLiteStore) migrate() error {
 	// Add columns to existing databases (idempotent).
 	s.db.Exec(`ALTER TABLE tickets ADD COLUMN goal TEXT NOT NULL DEFAULT ''`)
 	s.db.Exec(`ALTER TABLE tickets ADD COLUMN parent_id TEXT NOT NULL DEFAULT ''`)
+	s.db.Exec(`ALTER TABLE tickets ADD COLUMN watchers TEXT NOT NULL DEFAULT '[]'`)
+	s.db.Exec(`ALTER TABLE tickets ADD COLUMN priority INTEGER NOT NULL DEFAULT 0`)
+	s.db.Exec(`ALTER TABLE tickets ADD COLUMN due_at TEXT`)
 
+	if err := s.migrateTags(); err != nil {
+		return err
+	}
+	s.fts = s.migrateFTS() == nil
+
+	_, err = s.db.Exec(`
+		CREATE TABLE IF NOT EXISTS ticket_events (
+			id        INTEGER PRIMARY KEY AUTOINCREMENT,
+			ticket_id TEXT NOT NULL,
+			type      TEXT NOT NULL,
+			actor     TEXT NOT NULL DEFAULT '',
+			detail    TEXT NOT NULL DEFAULT '',
+			timestamp TEXT NOT NULL
+		);
+
+		CREATE INDEX IF NOT EXISTS idx_events_ticket ON ticket_events(ticket_id);
+
+		CREATE TABLE IF NOT EXISTS message_attachments (
+			message_id TEXT NOT NULL,
+			ticket_id  TEXT NOT NULL,
+			position   INTEGER NOT NULL,
+			name       TEXT NOT NULL,
+			mime_type  TEXT NOT NULL DEFAULT '',
+			path       TEXT NOT NULL,
+			size       INTEGER NOT NULL DEFAULT 0,
+			PRIMARY KEY (message_id, position)
+		);
+
+		CREATE INDEX IF NOT EXISTS idx_attachments_ticket ON message_attachments(ticket_id);
+	`)
+	if err != nil {
+		return fmt.Errorf("ticket store: migrate events: %w", err)
+	}
+
+	// Archive tables mirror the hot tables; see Archive.
+	_, err = s.db.Exec(`
+		CREATE TABLE IF NOT EXISTS archived_tickets (
+			id          TEXT PRIMARY KEY,
+			title       TEXT NOT NULL,
+			goal        TEXT NOT NULL DEFAULT '',
+			status      TEXT NOT NULL DEFAULT 'closed',
+			created_by  TEXT NOT NULL,
+			waiting_on  TEXT NOT NULL DEFAULT '[]',
+			tags        TEXT NOT NULL DEFAULT '[]',
+			parent_id   TEXT NOT NULL DEFAULT '',
+			summary     TEXT NOT NULL DEFAULT '',
+			created_at  TEXT NOT NULL,
+			closed_at   TEXT,
+			archived_at TEXT NOT NULL,
+			watchers    TEXT NOT NULL DEFAULT '[]',
+			priority    INTEGER NOT NULL DEFAULT 0,
+			due_at      TEXT
+		);
+
+		CREATE TABLE IF NOT EXISTS archived_ticket_messages (
+			id         TEXT PRIMARY KEY,
+			ticket_id  TEXT NOT NULL,
+			sender     TEXT NOT NULL,
+			recipients TEXT NOT NULL DEFAULT '[]',
+			content    TEXT NOT NULL,
+			timestamp  TEXT NOT NULL
+		);
+
+		CREATE TABLE IF NOT EXISTS archived_ticket_tags (
+			ticket_id TEXT NOT NULL,
+			tag       TEXT NOT NULL,
+			PRIMARY KEY (ticket_id, tag)
+		);
+
+		CREATE INDEX IF NOT EXISTS idx_archived_messages_ticket ON archived_ticket_messages(ticket_id);
+		CREATE INDEX IF NOT EXISTS idx_archived_tickets_parent ON archived_tickets(parent_id);
+		CREATE INDEX IF NOT EXISTS idx_archived_ticket_tags_tag ON archived_ticket_tags(tag);
+	`)
+	if err != nil {
+		return fmt.Errorf("ticket store: migrate archive: %w", err)
+	}
+	s.db.Exec(`ALTER TABLE archived_tickets ADD COLUMN watchers TEXT NOT NULL DEFAULT '[]'`)
+	s.db.Exec(`ALTER TABLE archived_tickets ADD COLUMN priority INTEGER NOT NULL DEFAULT 0`)
+	s.db.Exec(`ALTER TABLE archived_tickets ADD COLUMN due_at TEXT`)
+
+	_, err = s.db.Exec(`
+		CREATE TABLE IF NOT EXISTS inflight_messages (
+			agent_id   TEXT NOT NULL,
+			message_id TEXT NOT NULL,
+			ticket_id  TEXT NOT NULL,
+			started_at TEXT NOT NULL,
+			PRIMARY KEY (agent_id, message_id)
+		);
+
+		CREATE TABLE IF NOT EXISTS scheduled_messages (
+			id         TEXT PRIMARY KEY,
+			ticket_id  TEXT NOT NULL,
+			agent_id   TEXT NOT NULL,
+			message    TEXT NOT NULL,
+			cron       TEXT NOT NULL DEFAULT '',
+			next_run   TEXT NOT NULL,
+			created_by TEXT NOT NULL,
+			created_at TEXT NOT NULL
+		);
+	`)
+	if err != nil {
+		return fmt.Errorf("ticket store: migrate inflight and schedules: %w", err)
+	}
+
+	_, err = s.db.Exec(`
+		CREATE TABLE IF NOT EXISTS ticket_usage (
+			id                INTEGER PRIMARY KEY AUTOINCREMENT,
+			ticket_id         TEXT NOT NULL,
+			model             TEXT NOT NULL,
+			prompt_tokens     INTEGER NOT NULL,
+			completion_tokens INTEGER NOT NULL,
+			timestamp         TEXT NOT NULL
+		);
+		CREATE INDEX IF NOT EXISTS idx_ticket_usage_ticket ON ticket_usage(ticket_id);
+	`)
+	if err != nil {
+		return fmt.Errorf("ticket store: migrate usage: %w", err)
+	}
+
+	_, err = s.db.Exec(`
+		CREATE TABLE IF NOT EXISTS dead_letters (
+			id         TEXT PRIMARY KEY,
+			ticket_id  TEXT NOT NULL,
+			target     TEXT NOT NULL,
+			reason     TEXT NOT NULL,
+			message    TEXT NOT NULL,
+			created_at TEXT NOT NULL
+		);
+	`)
+	if err != nil {
+		return fmt.Errorf("ticket store: migrate dead letters: %w", err)
+	}
+
+	return nil
+}
+
+// migrateTags creates the normalized ticket_tags table used for filtering and,
+// on first creation, backfills it from the JSON tags column.
+func (s *SQLiteStore) migrateTags() error {
+	var exists int
+	if err := s.db.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = 'ticket_tags'`).Scan(&exists); err != nil {
+		return fmt.Errorf("ticket store: migrate tags: %w", err)
+	}
+	if exists > 0 {
+		return nil
+	}
+
+	_, err := s.db.Exec(`
+		CREATE TABLE ticket_tags (
+			ticket_id TEXT NOT NULL REFERENCES tickets(id),
+			tag       TEXT NOT NULL,
+			PRIMARY KEY (ticket_id, tag)
+		);
+
+		CREATE INDEX IF NOT EXISTS idx_ticket_tags_tag ON ticket_tags(tag);
+
+		INSERT OR IGNORE INTO ticket_tags (ticket_id, tag)
+			SELECT t.id, j.value FROM tickets t, json_each(t.tags) j
+			WHERE json_valid(t.tags) AND j.type = 'text';
+	`)
+	if err != nil {
+		return fmt.Errorf("ticket store: migrate tags: %w", err)
+	}
 	return nil
 }
 
+// migrateFTS creates an FTS5 index over message content, kept in step with
+// ticket_messages by triggers, and fills it on first creation. It fails if
+// SQLite was built without FTS5; MessageQuery then falls back to LIKE.
+func (s *SQLiteStore) migrateFTS() error {
+	var exists int
+	if err := s.db.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE name = 'ticket_messages_fts'`).Scan(&exists); err != nil {
+		return err
+	}
+	if exists > 0 {
+		return nil
+	}
+	_, err := s.db.Exec(`
+		CREATE VIRTUAL TABLE ticket_messages_fts USING fts5(content, content='ticket_messages', content_rowid='rowid');
+
+		CREATE TRIGGER ticket_messages_fts_insert AFTER INSERT ON ticket_messages BEGIN
+			INSERT INTO ticket_messages_fts (rowid, content) VALUES (new.rowid, new.content);
+		END;
+		CREATE TRIGGER ticket_messages_fts_delete AFTER DELETE ON ticket_messages BEGIN
+			INSERT INTO ticket_messages_fts (ticket_messages_fts, rowid, content) VALUES ('delete', old.rowid, old.content);
+		END;
+		CREATE TRIGGER ticket_messages_fts_update AFTER UPDATE OF content ON ticket_messages BEGIN
+			INSERT INTO ticket_messages_fts (ticket_messages_fts, rowid, content) VALUES ('delete', old.rowid, old.content);
+			INSERT INTO ticket_messages_fts (rowid, content) VALUES (new.rowid, new.content);
+		END;
+
+		INSERT INTO ticket_messages_fts (ticket_messages_fts) VALUES ('rebuild');
+	`)
+	return err
+}
+
 func (s *SQLiteStore) Save(t *protocol.Ticket) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("ticket store: save: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := saveTicket(tx, t); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("ticket store: save: %w", err)
+	}
+	return nil
+}
+
+// SaveWithMessage creates or updates a ticket and appends msg to it in one
+// transaction, so a new ticket is never visible without its first message.
+func (s *SQLiteStore) SaveWithMessage(t *protocol.Ticket, msg protocol.Message) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("ticket store: save with message: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := saveTicket(tx, t); err != nil {
+		return err
+	}
+	// Attachment blobs are written outside the transaction, so remove them
+	// if it does not commit.
+	written, err := s.insertMessage(tx, t.ID, msg)
+	if err == nil {
+		if err = tx.Commit(); err != nil {
+			err = fmt.Errorf("ticket store: save with message: %w", err)
+		}
+	}
+	if err != nil {
+		s.removeBlobs(t.ID, written)
+		return err
+	}
+	return nil
+}
+
+// execer is satisfied by both *sql.DB and *sql.Tx.
+type execer interface {
+	Exec(query string, args ...any) (sql.Result, error)
+}
+
+// saveTicket upserts a ticket row and replaces its tags.
+func saveTicket(tx execer, t *protocol.Ticket) error {
 	waitingOn, _ := json.Marshal(t.WaitingOn)
 	tags, _ := json.Marshal(t.Tags)
-	var closedAt *string
-	if t.ClosedAt != nil {
-		v := t.ClosedAt.Format(time.RFC3339)
-		closedAt = &v
-	}
+	watchers, _ := json.Marshal(nonNil(t.Watchers))
 
-	_, err := s.db.Exec(`
-		INSERT INTO tickets (id, title, goal, status, created_by, waiting_on, tags, parent_id, summary, created_at, closed_at)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	_, err := tx.Exec(`
+		INSERT INTO tickets (`+ticketColumns+`)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 		ON CONFLICT(id) DO UPDATE SET
 			title=excluded.title, goal=excluded.goal, status=excluded.status, waiting_on=excluded.waiting_on,
-			tags=excluded.tags, parent_id=excluded.parent_id, summary=excluded.summary, closed_at=excluded.closed_at
+			tags=excluded.tags, parent_id=excluded.parent_id, summary=excluded.summary, closed_at=excluded.closed_at,
+			watchers=excluded.watchers, priority=excluded.priority, due_at=excluded.due_at
 	`, t.ID, t.Title, t.Goal, string(t.Status), t.CreatedBy, string(waitingOn), string(tags),
-		t.ParentID, t.Summary, t.CreatedAt.Format(time.RFC3339), closedAt)
+		t.ParentID, t.Summary, t.CreatedAt.Format(time.RFC3339), formatTime(t.ClosedAt), string(watchers), t.Priority, formatTime(t.DueAt))
 	if err != nil {
 		return fmt.Errorf("ticket store: save: %w", err)
 	}
+
+	if _, err := tx.Exec(`DELETE FROM ticket_tags WHERE ticket_id = ?`, t.ID); err != nil {
+		return fmt.Errorf("ticket store: save tags: %w", err)
+	}
+	for _, tag := range t.Tags {
+		if _, err := tx.Exec(`INSERT OR IGNORE INTO ticket_tags (ticket_id, tag) VALUES (?, ?)`, t.ID, tag); err != nil {
+			return fmt.Errorf("ticket store: save tags: %w", err)
+		}
+	}
 	return nil
 }
 
+// Get looks up a ticket in the hot tables first and falls back to the archive.
 func (s *SQLiteStore) Get(id string) (*protocol.Ticket, error) {
-	row := s.db.QueryRow(`SELECT id, title, goal, status, created_by, waiting_on, tags, parent_id, summary, created_at, closed_at FROM tickets WHERE id = ?`, id)
-
-	t, err := scanTicket(row)
+	t, messagesTable, err := s.getTicket(id)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("ticket %q not found", id)
-		}
-		return nil, fmt.Errorf("ticket store: get: %w", err)
+		return nil, err
 	}
 
 	// Load messages
-	msgs, err := s.loadMessages(id)
+	msgs, err := s.loadMessages(messagesTable, id)
 	if err != nil {
 		return nil, err
 	}
@@ -120,34 +377,58 @@ func (s *SQLiteStore) Get(id string) (*protocol.Ticket, error) {
 	return t, nil
 }
 
-func (s *SQLiteStore) List(filter Filter) ([]*protocol.Ticket, error) {
-	query := "SELECT id, title, goal, status, created_by, waiting_on, tags, parent_id, summary, created_at, closed_at FROM tickets WHERE 1=1"
-	var args []any
+// GetWithMessages retrieves a ticket with only its most recent limit messages,
+// oldest first. It also returns the total number of messages on the ticket.
+// limit <= 0 loads them all.
+func (s *SQLiteStore) GetWithMessages(id string, limit int) (*protocol.Ticket, int, error) {
+	t, messagesTable, err := s.getTicket(id)
+	if err != nil {
+		return nil, 0, err
+	}
 
-	if filter.Status != nil {
-		query += " AND status = ?"
-		args = append(args, string(*filter.Status))
+	var total int
+	if err := s.db.QueryRow(`SELECT COUNT(*) FROM `+messagesTable+` WHERE ticket_id = ?`, id).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("ticket store: count messages: %w", err)
 	}
-	if filter.AgentID != "" {
-		query += " AND (created_by = ? OR waiting_on LIKE ?)"
-		args = append(args, filter.AgentID, fmt.Sprintf("%%%s%%", filter.AgentID))
+
+	query := `SELECT id, sender, recipients, content, timestamp FROM ` + messagesTable + ` WHERE ticket_id = ? ORDER BY timestamp DESC, rowid DESC`
+	if limit > 0 {
+		query += fmt.Sprintf(" LIMIT %d", limit)
 	}
-	if len(filter.Tags) > 0 {
-		for _, tag := range filter.Tags {
-			query += " AND tags LIKE ?"
-			args = append(args, fmt.Sprintf("%%%s%%", tag))
-		}
+	msgs, err := s.queryMessages(id, query, id)
+	if err != nil {
+		return nil, 0, err
 	}
-	if filter.ParentID != "" {
-		query += " AND parent_id = ?"
-		args = append(args, filter.ParentID)
+	slices.Reverse(msgs)
+	t.Messages = msgs
+	return t, total, nil
+}
+
+// getTicket loads a ticket row from the hot or archive table, returning the
+// name of the matching messages table.
+func (s *SQLiteStore) getTicket(id string) (*protocol.Ticket, string, error) {
+	row := s.db.QueryRow(`SELECT `+ticketColumns+` FROM tickets WHERE id = ?`, id)
+	messagesTable := "ticket_messages"
+
+	t, err := scanTicket(row)
+	if err == sql.ErrNoRows {
+		row = s.db.QueryRow(`SELECT `+ticketColumns+` FROM archived_tickets WHERE id = ?`, id)
+		messagesTable = "archived_ticket_messages"
+		t, err = scanTicket(row)
 	}
-	if filter.Query != "" {
-		query += " AND (title LIKE ? OR summary LIKE ?)"
-		pattern := fmt.Sprintf("%%%s%%", filter.Query)
-		args = append(args, pattern, pattern)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, "", fmt.Errorf("ticket %q not found", id)
+		}
+		return nil, "", fmt.Errorf("ticket store: get: %w", err)
 	}
-	query += " ORDER BY created_at DESC"
+	return t, messagesTable, nil
+}
+
+func (s *SQLiteStore) List(filter Filter) ([]*protocol.Ticket, error) {
+	where, args := s.buildWhere(filter)
+	query := "SELECT " + ticketColumns + " FROM " + ticketSource(filter) + where
+	query += " ORDER BY priority DESC, created_at DESC"
 	if filter.Limit > 0 {
 		query += fmt.Sprintf(" LIMIT %d", filter.Limit)
 	}
@@ -170,51 +451,142 @@ func (s *SQLiteStore) List(filter Filter) ([]*protocol.Ticket, error) {
 }
 
 func (s *SQLiteStore) Count(filter Filter) (int, error) {
-	query := "SELECT COUNT(*) FROM tickets WHERE 1=1"
-	var args []any
+	where, args := s.buildWhere(filter)
+	query := "SELECT COUNT(*) FROM " + ticketSource(filter) + where
 
-	if filter.Status != nil {
-		query += " AND status = ?"
-		args = append(args, string(*filter.Status))
+	var count int
+	err := s.db.QueryRow(query, args...).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("ticket store: count: %w", err)
 	}
-	if filter.AgentID != "" {
-		query += " AND (created_by = ? OR waiting_on LIKE ?)"
-		args = append(args, filter.AgentID, fmt.Sprintf("%%%s%%", filter.AgentID))
+	return count, nil
+}
+
+func (s *SQLiteStore) AppendMessage(ticketID string, msg protocol.Message) error {
+	_, err := s.insertMessage(s.db, ticketID, msg)
+	return err
+}
+
+// insertMessage writes a message row and its attachments through ex. It
+// returns the blob files it wrote, even on error, so a caller whose
+// transaction fails can remove them.
+func (s *SQLiteStore) insertMessage(ex execer, ticketID string, msg protocol.Message) ([]string, error) {
+	recipients, _ := json.Marshal(msg.To)
+	_, err := ex.Exec(`INSERT INTO ticket_messages (id, ticket_id, sender, recipients, content, timestamp) VALUES (?, ?, ?, ?, ?, ?)`,
+		msg.ID, ticketID, msg.From, string(recipients), msg.Content, msg.Timestamp.UTC().Format(time.RFC3339))
+	if err != nil {
+		return nil, fmt.Errorf("ticket store: append message: %w", err)
 	}
-	if len(filter.Tags) > 0 {
-		for _, tag := range filter.Tags {
-			query += " AND tags LIKE ?"
-			args = append(args, fmt.Sprintf("%%%s%%", tag))
+	return s.saveAttachments(ex, ticketID, msg)
+}
+
+// saveAttachments writes inline attachment data to disk and records metadata
+// for every attachment on msg. It returns the blob files written so far.
+func (s *SQLiteStore) saveAttachments(ex execer, ticketID string, msg protocol.Message) ([]string, error) {
+	var written []string
+	for i, a := range msg.Attachments {
+		if a.Data != nil {
+			dir := filepath.Join(s.attachDir, ticketID)
+			if err := os.MkdirAll(dir, 0o755); err != nil {
+				return written, fmt.Errorf("ticket store: save attachment: %w", err)
+			}
+			a.Path = filepath.Join(dir, fmt.Sprintf("%s-%d-%s", msg.ID, i, filepath.Base(a.Name)))
+			if err := os.WriteFile(a.Path, a.Data, 0o644); err != nil {
+				return written, fmt.Errorf("ticket store: save attachment: %w", err)
+			}
+			written = append(written, a.Path)
+			a.Size = int64(len(a.Data))
+		}
+		if a.Path == "" {
+			return written, fmt.Errorf("ticket store: attachment %q has neither data nor path", a.Name)
+		}
+		_, err := ex.Exec(`INSERT OR REPLACE INTO message_attachments (message_id, ticket_id, position, name, mime_type, path, size) VALUES (?, ?, ?, ?, ?, ?, ?)`,
+			msg.ID, ticketID, i, a.Name, a.MimeType, a.Path, a.Size)
+		if err != nil {
+			return written, fmt.Errorf("ticket store: save attachment: %w", err)
 		}
 	}
-	if filter.ParentID != "" {
-		query += " AND parent_id = ?"
-		args = append(args, filter.ParentID)
+	return written, nil
+}
+
+// removeBlobs deletes attachment files that were written for a message that
+// was never stored, and the ticket's attachment directory if that leaves it
+// empty.
+func (s *SQLiteStore) removeBlobs(ticketID string, paths []string) {
+	if len(paths) == 0 {
+		return
 	}
-	if filter.Query != "" {
-		query += " AND (title LIKE ? OR summary LIKE ?)"
-		pattern := fmt.Sprintf("%%%s%%", filter.Query)
-		args = append(args, pattern, pattern)
+	for _, p := range paths {
+		os.Remove(p)
 	}
+	os.Remove(filepath.Join(s.attachDir, ticketID)) // fails unless empty
+}
 
-	var count int
-	err := s.db.QueryRow(query, args...).Scan(&count)
+func (s *SQLiteStore) AppendEvent(ev protocol.TicketEvent) error {
+	if ev.Timestamp.IsZero() {
+		ev.Timestamp = time.Now()
+	}
+	_, err := s.db.Exec(`INSERT INTO ticket_events (ticket_id, type, actor, detail, timestamp) VALUES (?, ?, ?, ?, ?)`,
+		ev.TicketID, string(ev.Type), ev.Actor, ev.Detail, ev.Timestamp.Format(time.RFC3339))
 	if err != nil {
-		return 0, fmt.Errorf("ticket store: count: %w", err)
+		return fmt.Errorf("ticket store: append event: %w", err)
 	}
-	return count, nil
+	return nil
 }
 
-func (s *SQLiteStore) AppendMessage(ticketID string, msg protocol.Message) error {
-	recipients, _ := json.Marshal(msg.To)
-	_, err := s.db.Exec(`INSERT INTO ticket_messages (id, ticket_id, sender, recipients, content, timestamp) VALUES (?, ?, ?, ?, ?, ?)`,
-		msg.ID, ticketID, msg.From, string(recipients), msg.Content, msg.Timestamp.Format(time.RFC3339))
+func (s *SQLiteStore) Events(ticketID string) ([]protocol.TicketEvent, error) {
+	rows, err := s.db.Query(`SELECT id, type, actor, detail, timestamp FROM ticket_events WHERE ticket_id = ? ORDER BY id`, ticketID)
 	if err != nil {
-		return fmt.Errorf("ticket store: append message: %w", err)
+		return nil, fmt.Errorf("ticket store: events: %w", err)
+	}
+	defer rows.Close()
+
+	var events []protocol.TicketEvent
+	for rows.Next() {
+		var ev protocol.TicketEvent
+		var typ, ts string
+		if err := rows.Scan(&ev.ID, &typ, &ev.Actor, &ev.Detail, &ts); err != nil {
+			return nil, fmt.Errorf("ticket store: scan event: %w", err)
+		}
+		ev.TicketID = ticketID
+		ev.Type = protocol.TicketEventType(typ)
+		ev.Timestamp, _ = time.Parse(time.RFC3339, ts)
+		events = append(events, ev)
+	}
+	return events, rows.Err()
+}
+
+func (s *SQLiteStore) RecordUsage(ticketID, model string, u protocol.Usage) error {
+	_, err := s.db.Exec(`INSERT INTO ticket_usage (ticket_id, model, prompt_tokens, completion_tokens, timestamp) VALUES (?, ?, ?, ?, ?)`,
+		ticketID, model, u.PromptTokens, u.CompletionTokens, time.Now().Format(time.RFC3339))
+	if err != nil {
+		return fmt.Errorf("ticket store: record usage: %w", err)
 	}
 	return nil
 }
 
+func (s *SQLiteStore) Usage(ticketID string) (*protocol.TicketUsage, error) {
+	rows, err := s.db.Query(`SELECT model, COUNT(*), SUM(prompt_tokens), SUM(completion_tokens) FROM ticket_usage WHERE ticket_id = ? GROUP BY model ORDER BY model`, ticketID)
+	if err != nil {
+		return nil, fmt.Errorf("ticket store: usage: %w", err)
+	}
+	defer rows.Close()
+
+	usage := &protocol.TicketUsage{Models: []protocol.ModelUsage{}}
+	for rows.Next() {
+		var m protocol.ModelUsage
+		if err := rows.Scan(&m.Model, &m.Calls, &m.PromptTokens, &m.CompletionTokens); err != nil {
+			return nil, fmt.Errorf("ticket store: scan usage: %w", err)
+		}
+		usage.Models = append(usage.Models, m)
+		usage.Calls += m.Calls
+		usage.PromptTokens += m.PromptTokens
+		usage.CompletionTokens += m.CompletionTokens
+	}
+	usage.TotalTokens = usage.PromptTokens + usage.CompletionTokens
+	return usage, rows.Err()
+}
+
 func (s *SQLiteStore) UpdateStatus(ticketID string, status protocol.TicketStatus) error {
 	result, err := s.db.Exec(`UPDATE tickets SET status = ? WHERE id = ?`, string(status), ticketID)
 	if err != nil {
@@ -227,6 +599,32 @@ func (s *SQLiteStore) UpdateStatus(ticketID string, status protocol.TicketStatus
 	return nil
 }
 
+func (s *SQLiteStore) UpdateWaitingOn(ticketID string, to []string) error {
+	data, _ := json.Marshal(nonNil(to))
+	result, err := s.db.Exec(`UPDATE tickets SET waiting_on = ? WHERE id = ?`, string(data), ticketID)
+	if err != nil {
+		return fmt.Errorf("ticket store: update waiting_on: %w", err)
+	}
+	n, _ := result.RowsAffected()
+	if n == 0 {
+		return fmt.Errorf("ticket %q not found", ticketID)
+	}
+	return nil
+}
+
+func (s *SQLiteStore) SetWatchers(ticketID string, watchers []string) error {
+	data, _ := json.Marshal(nonNil(watchers))
+	result, err := s.db.Exec(`UPDATE tickets SET watchers = ? WHERE id = ?`, string(data), ticketID)
+	if err != nil {
+		return fmt.Errorf("ticket store: set watchers: %w", err)
+	}
+	n, _ := result.RowsAffected()
+	if n == 0 {
+		return fmt.Errorf("ticket %q not found", ticketID)
+	}
+	return nil
+}
+
 func (s *SQLiteStore) Close(ticketID string, summary string) error {
 	now := time.Now().Format(time.RFC3339)
 	result, err := s.db.Exec(`UPDATE tickets SET status = 'closed', summary = ?, closed_at = ? WHERE id = ?`,
@@ -241,6 +639,256 @@ func (s *SQLiteStore) Close(ticketID string, summary string) error {
 	return nil
 }
 
+func (s *SQLiteStore) Reopen(ticketID string) error {
+	result, err := s.db.Exec(`UPDATE tickets SET status = 'open', closed_at = NULL WHERE id = ?`, ticketID)
+	if err != nil {
+		return fmt.Errorf("ticket store: reopen: %w", err)
+	}
+	n, _ := result.RowsAffected()
+	if n == 0 {
+		return fmt.Errorf("ticket %q not found", ticketID)
+	}
+	return nil
+}
+
+// Archive moves tickets closed before the cutoff, with their messages and tags,
+// into the archive tables. A closed ticket that still has a non-archivable
+// child (open, or closed after the cutoff) stays put, as do its ancestors, so
+// live sub-tickets never point at an archived parent. Event timelines are an
+// audit log and, like usage records, are left in place.
+func (s *SQLiteStore) Archive(closedBefore time.Time) (int, error) {
+	rows, err := s.db.Query(`SELECT id, parent_id, status, closed_at FROM tickets`)
+	if err != nil {
+		return 0, fmt.Errorf("ticket store: archive: %w", err)
+	}
+	eligible := make(map[string]bool)
+	parents := make(map[string]string) // child id → parent id
+	for rows.Next() {
+		var id, parentID, status string
+		var closedAtStr *string
+		if err := rows.Scan(&id, &parentID, &status, &closedAtStr); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("ticket store: archive scan: %w", err)
+		}
+		if parentID != "" {
+			parents[id] = parentID
+		}
+		if status != string(protocol.TicketClosed) || closedAtStr == nil {
+			continue
+		}
+		if closedAt, err := time.Parse(time.RFC3339, *closedAtStr); err == nil && closedAt.Before(closedBefore) {
+			eligible[id] = true
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("ticket store: archive: %w", err)
+	}
+
+	// Keep parents of anything staying in the hot tables, transitively.
+	for changed := true; changed; {
+		changed = false
+		for child, parent := range parents {
+			if !eligible[child] && eligible[parent] {
+				delete(eligible, parent)
+				changed = true
+			}
+		}
+	}
+	if len(eligible) == 0 {
+		return 0, nil
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("ticket store: archive: %w", err)
+	}
+	defer tx.Rollback()
+
+	now := time.Now().Format(time.RFC3339)
+	for id := range eligible {
+		stmts := []struct {
+			query string
+			args  []any
+		}{
+			{`INSERT OR REPLACE INTO archived_tickets (` + ticketColumns + `, archived_at) SELECT ` + ticketColumns + `, ? FROM tickets WHERE id = ?`, []any{now, id}},
+			{`INSERT OR REPLACE INTO archived_ticket_messages (id, ticket_id, sender, recipients, content, timestamp) SELECT id, ticket_id, sender, recipients, content, timestamp FROM ticket_messages WHERE ticket_id = ?`, []any{id}},
+			{`INSERT OR IGNORE INTO archived_ticket_tags (ticket_id, tag) SELECT ticket_id, tag FROM ticket_tags WHERE ticket_id = ?`, []any{id}},
+			{`DELETE FROM ticket_messages WHERE ticket_id = ?`, []any{id}},
+			{`DELETE FROM ticket_tags WHERE ticket_id = ?`, []any{id}},
+			{`DELETE FROM tickets WHERE id = ?`, []any{id}},
+		}
+		for _, st := range stmts {
+			if _, err := tx.Exec(st.query, st.args...); err != nil {
+				return 0, fmt.Errorf("ticket store: archive %s: %w", id, err)
+			}
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("ticket store: archive: %w", err)
+	}
+	return len(eligible), nil
+}
+
+func (s *SQLiteStore) MarkInFlight(agentID, ticketID, messageID string) error {
+	_, err := s.db.Exec(`INSERT OR REPLACE INTO inflight_messages (agent_id, message_id, ticket_id, started_at) VALUES (?, ?, ?, ?)`,
+		agentID, messageID, ticketID, time.Now().Format(time.RFC3339))
+	if err != nil {
+		return fmt.Errorf("ticket store: mark in-flight: %w", err)
+	}
+	return nil
+}
+
+func (s *SQLiteStore) ClearInFlight(agentID, messageID string) error {
+	if _, err := s.db.Exec(`DELETE FROM inflight_messages WHERE agent_id = ? AND message_id = ?`, agentID, messageID); err != nil {
+		return fmt.Errorf("ticket store: clear in-flight: %w", err)
+	}
+	return nil
+}
+
+func (s *SQLiteStore) ListInFlight() ([]InFlight, error) {
+	rows, err := s.db.Query(`SELECT agent_id, message_id, ticket_id, started_at FROM inflight_messages ORDER BY started_at`)
+	if err != nil {
+		return nil, fmt.Errorf("ticket store: list in-flight: %w", err)
+	}
+	defer rows.Close()
+
+	var out []InFlight
+	for rows.Next() {
+		var f InFlight
+		var ts string
+		if err := rows.Scan(&f.AgentID, &f.MessageID, &f.TicketID, &ts); err != nil {
+			return nil, fmt.Errorf("ticket store: scan in-flight: %w", err)
+		}
+		f.StartedAt, _ = time.Parse(time.RFC3339, ts)
+		out = append(out, f)
+	}
+	return out, rows.Err()
+}
+
+func (s *SQLiteStore) SaveSchedule(sc Schedule) error {
+	_, err := s.db.Exec(`INSERT OR REPLACE INTO scheduled_messages (id, ticket_id, agent_id, message, cron, next_run, created_by, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		sc.ID, sc.TicketID, sc.AgentID, sc.Message, sc.Cron,
+		sc.NextRun.UTC().Format(time.RFC3339), sc.CreatedBy, sc.CreatedAt.UTC().Format(time.RFC3339))
+	if err != nil {
+		return fmt.Errorf("ticket store: save schedule: %w", err)
+	}
+	return nil
+}
+
+func (s *SQLiteStore) GetSchedule(id string) (*Schedule, error) {
+	rows, err := s.querySchedules(`WHERE id = ?`, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("schedule %q not found", id)
+	}
+	return &rows[0], nil
+}
+
+func (s *SQLiteStore) DeleteSchedule(id string) error {
+	result, err := s.db.Exec(`DELETE FROM scheduled_messages WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("ticket store: delete schedule: %w", err)
+	}
+	n, _ := result.RowsAffected()
+	if n == 0 {
+		return fmt.Errorf("schedule %q not found", id)
+	}
+	return nil
+}
+
+func (s *SQLiteStore) ListSchedules() ([]Schedule, error) {
+	return s.querySchedules(`ORDER BY next_run`)
+}
+
+func (s *SQLiteStore) querySchedules(clause string, args ...any) ([]Schedule, error) {
+	rows, err := s.db.Query(`SELECT id, ticket_id, agent_id, message, cron, next_run, created_by, created_at FROM scheduled_messages `+clause, args...)
+	if err != nil {
+		return nil, fmt.Errorf("ticket store: list schedules: %w", err)
+	}
+	defer rows.Close()
+
+	var out []Schedule
+	for rows.Next() {
+		var sc Schedule
+		var next, created string
+		if err := rows.Scan(&sc.ID, &sc.TicketID, &sc.AgentID, &sc.Message, &sc.Cron, &next, &sc.CreatedBy, &created); err != nil {
+			return nil, fmt.Errorf("ticket store: scan schedule: %w", err)
+		}
+		sc.NextRun, _ = time.Parse(time.RFC3339, next)
+		sc.CreatedAt, _ = time.Parse(time.RFC3339, created)
+		out = append(out, sc)
+	}
+	return out, rows.Err()
+}
+
+func (s *SQLiteStore) SaveDeadLetter(d DeadLetter) error {
+	msg, err := json.Marshal(d.Message)
+	if err != nil {
+		return fmt.Errorf("ticket store: save dead letter: %w", err)
+	}
+	_, err = s.db.Exec(`INSERT OR REPLACE INTO dead_letters (id, ticket_id, target, reason, message, created_at) VALUES (?, ?, ?, ?, ?, ?)`,
+		d.ID, d.Message.TicketID, d.Target, d.Reason, string(msg), d.CreatedAt.UTC().Format(time.RFC3339Nano))
+	if err != nil {
+		return fmt.Errorf("ticket store: save dead letter: %w", err)
+	}
+	return nil
+}
+
+func (s *SQLiteStore) GetDeadLetter(id string) (*DeadLetter, error) {
+	rows, err := s.queryDeadLetters(`WHERE id = ?`, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("%w: %q", ErrDeadLetterNotFound, id)
+	}
+	return &rows[0], nil
+}
+
+func (s *SQLiteStore) DeleteDeadLetter(id string) error {
+	result, err := s.db.Exec(`DELETE FROM dead_letters WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("ticket store: delete dead letter: %w", err)
+	}
+	n, _ := result.RowsAffected()
+	if n == 0 {
+		return fmt.Errorf("%w: %q", ErrDeadLetterNotFound, id)
+	}
+	return nil
+}
+
+func (s *SQLiteStore) ListDeadLetters() ([]DeadLetter, error) {
+	return s.queryDeadLetters(`ORDER BY created_at, id`)
+}
+
+func (s *SQLiteStore) queryDeadLetters(clause string, args ...any) ([]DeadLetter, error) {
+	rows, err := s.db.Query(`SELECT id, target, reason, message, created_at FROM dead_letters `+clause, args...)
+	if err != nil {
+		return nil, fmt.Errorf("ticket store: list dead letters: %w", err)
+	}
+	defer rows.Close()
+
+	var out []DeadLetter
+	for rows.Next() {
+		var d DeadLetter
+		var msg, created string
+		if err := rows.Scan(&d.ID, &d.Target, &d.Reason, &msg, &created); err != nil {
+			return nil, fmt.Errorf("ticket store: scan dead letter: %w", err)
+		}
+		if err := json.Unmarshal([]byte(msg), &d.Message); err != nil {
+			return nil, fmt.Errorf("ticket store: decode dead letter %s: %w", d.ID, err)
+		}
+		d.CreatedAt, _ = time.Parse(time.RFC3339Nano, created)
+		out = append(out, d)
+	}
+	return out, rows.Err()
+}
+
 // DB returns the underlying database connection (for testing or direct access).
 func (s *SQLiteStore) DB() *sql.DB {
 	return s.db
@@ -248,8 +896,112 @@ func (s *SQLiteStore) DB() *sql.DB {
 
 // --- helpers ---
 
-func (s *SQLiteStore) loadMessages(ticketID string) ([]protocol.Message, error) {
-	rows, err := s.db.Query(`SELECT id, sender, recipients, content, timestamp FROM ticket_messages WHERE ticket_id = ? ORDER BY timestamp`, ticketID)
+const ticketColumns = "id, title, goal, status, created_by, waiting_on, tags, parent_id, summary, created_at, closed_at, watchers, priority, due_at"
+
+// ticketSource returns the table (or union of hot and archived tables) that a
+// filtered query should read from.
+func ticketSource(filter Filter) string {
+	if filter.IncludeArchived {
+		return "(SELECT " + ticketColumns + " FROM tickets UNION ALL SELECT " + ticketColumns + " FROM archived_tickets)"
+	}
+	return "tickets"
+}
+
+// buildWhere translates a Filter into a WHERE clause and its arguments,
+// shared by List and Count.
+func (s *SQLiteStore) buildWhere(filter Filter) (string, []any) {
+	where := " WHERE 1=1"
+	var args []any
+
+	if filter.Status != nil {
+		where += " AND status = ?"
+		args = append(args, string(*filter.Status))
+	}
+	if filter.AgentID != "" {
+		where += " AND (created_by = ? OR waiting_on LIKE ?)"
+		args = append(args, filter.AgentID, fmt.Sprintf("%%%s%%", filter.AgentID))
+	}
+	tagTable := "ticket_tags"
+	if filter.IncludeArchived {
+		tagTable = "(SELECT ticket_id, tag FROM ticket_tags UNION ALL SELECT ticket_id, tag FROM archived_ticket_tags)"
+	}
+	for _, tag := range filter.Tags {
+		where += " AND id IN (SELECT ticket_id FROM " + tagTable + " WHERE tag = ?)"
+		args = append(args, tag)
+	}
+	if len(filter.AnyTags) > 0 {
+		placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(filter.AnyTags)), ", ")
+		where += " AND id IN (SELECT ticket_id FROM " + tagTable + " WHERE tag IN (" + placeholders + "))"
+		for _, tag := range filter.AnyTags {
+			args = append(args, tag)
+		}
+	}
+	if filter.ParentID != "" {
+		where += " AND parent_id = ?"
+		args = append(args, filter.ParentID)
+	}
+	if filter.MinPriority != nil {
+		where += " AND priority >= ?"
+		args = append(args, *filter.MinPriority)
+	}
+	if filter.OverdueOnly {
+		where += " AND status = ? AND due_at IS NOT NULL AND due_at < ?"
+		args = append(args, string(protocol.TicketOpen), time.Now().UTC().Format(time.RFC3339))
+	}
+	if filter.Query != "" {
+		where += " AND (title LIKE ? OR summary LIKE ?)"
+		pattern := fmt.Sprintf("%%%s%%", filter.Query)
+		args = append(args, pattern, pattern)
+	}
+	if words := strings.Fields(filter.MessageQuery); len(words) > 0 {
+		// Like the FTS query, a message matches when it contains every word.
+		like, likeArgs := likeAllWords(words)
+		if s.fts {
+			where += " AND (id IN (SELECT m.ticket_id FROM ticket_messages m JOIN ticket_messages_fts f ON f.rowid = m.rowid WHERE ticket_messages_fts MATCH ?)"
+			args = append(args, ftsQuery(filter.MessageQuery))
+		} else {
+			where += " AND (id IN (SELECT ticket_id FROM ticket_messages WHERE " + like + ")"
+			args = append(args, likeArgs...)
+		}
+		if filter.IncludeArchived {
+			where += " OR id IN (SELECT ticket_id FROM archived_ticket_messages WHERE " + like + ")"
+			args = append(args, likeArgs...)
+		}
+		where += ")"
+	}
+	return where, args
+}
+
+// likeAllWords returns a condition matching content that contains every
+// word, with one LIKE per word, and its arguments.
+func likeAllWords(words []string) (string, []any) {
+	conds := make([]string, len(words))
+	args := make([]any, len(words))
+	for i, w := range words {
+		conds[i] = "content LIKE ?"
+		args[i] = "%" + w + "%"
+	}
+	return strings.Join(conds, " AND "), args
+}
+
+// ftsQuery turns free text into an FTS5 query matching messages that
+// contain every word, with each word quoted so punctuation is not parsed as
+// query syntax.
+func ftsQuery(text string) string {
+	words := strings.Fields(text)
+	for i, w := range words {
+		words[i] = `"` + strings.ReplaceAll(w, `"`, `""`) + `"`
+	}
+	return strings.Join(words, " ")
+}
+
+func (s *SQLiteStore) loadMessages(table, ticketID string) ([]protocol.Message, error) {
+	return s.queryMessages(ticketID, `SELECT id, sender, recipients, content, timestamp FROM `+table+` WHERE ticket_id = ? ORDER BY timestamp`, ticketID)
+}
+
+// queryMessages runs a message query for one ticket and attaches attachments.
+func (s *SQLiteStore) queryMessages(ticketID, query string, args ...any) ([]protocol.Message, error) {
+	rows, err := s.db.Query(query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("ticket store: load messages: %w", err)
 	}
@@ -267,7 +1019,35 @@ func (s *SQLiteStore) loadMessages(ticketID string) ([]protocol.Message, error)
 		m.TicketID = ticketID
 		msgs = append(msgs, m)
 	}
-	return msgs, rows.Err()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if err := s.loadAttachments(ticketID, msgs); err != nil {
+		return nil, err
+	}
+	return msgs, nil
+}
+
+func (s *SQLiteStore) loadAttachments(ticketID string, msgs []protocol.Message) error {
+	rows, err := s.db.Query(`SELECT message_id, name, mime_type, path, size FROM message_attachments WHERE ticket_id = ? ORDER BY message_id, position`, ticketID)
+	if err != nil {
+		return fmt.Errorf("ticket store: load attachments: %w", err)
+	}
+	defer rows.Close()
+
+	byMsg := make(map[string][]protocol.Attachment)
+	for rows.Next() {
+		var msgID string
+		var a protocol.Attachment
+		if err := rows.Scan(&msgID, &a.Name, &a.MimeType, &a.Path, &a.Size); err != nil {
+			return fmt.Errorf("ticket store: scan attachment: %w", err)
+		}
+		byMsg[msgID] = append(byMsg[msgID], a)
+	}
+	for i := range msgs {
+		msgs[i].Attachments = byMsg[msgs[i].ID]
+	}
+	return rows.Err()
 }
 
 type scannable interface {
@@ -276,12 +1056,12 @@ type scannable interface {
 
 func scanTicketFromRow(s scannable) (*protocol.Ticket, error) {
 	var t protocol.Ticket
-	var waitingOnJSON, tagsJSON, createdAtStr string
-	var closedAtStr *string
+	var waitingOnJSON, tagsJSON, watchersJSON, createdAtStr string
+	var closedAtStr, dueAtStr *string
 	var status string
 
 	err := s.Scan(&t.ID, &t.Title, &t.Goal, &status, &t.CreatedBy, &waitingOnJSON, &tagsJSON,
-		&t.ParentID, &t.Summary, &createdAtStr, &closedAtStr)
+		&t.ParentID, &t.Summary, &createdAtStr, &closedAtStr, &watchersJSON, &t.Priority, &dueAtStr)
 	if err != nil {
 		return nil, err
 	}
@@ -289,11 +1069,16 @@ func scanTicketFromRow(s scannable) (*protocol.Ticket, error) {
 	t.Status = protocol.TicketStatus(status)
 	json.Unmarshal([]byte(waitingOnJSON), &t.WaitingOn)
 	json.Unmarshal([]byte(tagsJSON), &t.Tags)
+	json.Unmarshal([]byte(watchersJSON), &t.Watchers)
 	t.CreatedAt, _ = time.Parse(time.RFC3339, createdAtStr)
 	if closedAtStr != nil {
 		ct, _ := time.Parse(time.RFC3339, *closedAtStr)
 		t.ClosedAt = &ct
 	}
+	if dueAtStr != nil {
+		dt, _ := time.Parse(time.RFC3339, *dueAtStr)
+		t.DueAt = &dt
+	}
 
 	// Ensure nil slices are empty slices
 	if t.WaitingOn == nil {
@@ -306,6 +1091,24 @@ func scanTicketFromRow(s scannable) (*protocol.Ticket, error) {
 	return &t, nil
 }
 
+// formatTime stores an optional time as RFC3339 text, or NULL. Times are
+// written in UTC so due dates compare correctly as strings.
+func formatTime(t *time.Time) *string {
+	if t == nil {
+		return nil
+	}
+	v := t.UTC().Format(time.RFC3339)
+	return &v
+}
+
+// nonNil keeps empty lists serialized as "[]" rather than "null".
+func nonNil(ids []string) []string {
+	if ids == nil {
+		return []string{}
+	}
+	return ids
+}
+
 func scanTicket(row *sql.Row) (*protocol.Ticket, error) {
 	return scanTicketFromRow(row)
 }
@@ -1,8 +1,12 @@
 package ticket
 
 import (
+	"database/sql"
+	"errors"
 	"fmt"
+	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
@@ -144,6 +148,39 @@ func TestUpdateStatus_NotFound(t *testing.T) {
 	}
 }
 
+func TestSetWatchers(t *testing.T) {
+	s := newTestStore(t)
+
+	ticket := &protocol.Ticket{
+		ID: "t-w1", Title: "Test", Status: protocol.TicketOpen,
+		CreatedBy: "a", WaitingOn: []string{"b"}, CreatedAt: time.Now().Truncate(time.Second),
+	}
+	s.Save(ticket)
+
+	if err := s.SetWatchers("t-w1", []string{"sup"}); err != nil {
+		t.Fatalf("set watchers: %v", err)
+	}
+	got, _ := s.Get("t-w1")
+	if len(got.Watchers) != 1 || got.Watchers[0] != "sup" {
+		t.Errorf("expected watchers [sup], got %v", got.Watchers)
+	}
+
+	// Watchers survive archiving and are not matched as participants.
+	if n, _ := s.Count(Filter{AgentID: "sup"}); n != 0 {
+		t.Errorf("watchers must not count as participants, got %d", n)
+	}
+	s.Close("t-w1", "done")
+	s.Archive(time.Now().Add(time.Hour))
+	got, _ = s.Get("t-w1")
+	if len(got.Watchers) != 1 {
+		t.Errorf("expected watchers kept in archive, got %v", got.Watchers)
+	}
+
+	if err := s.SetWatchers("nonexistent", nil); err == nil {
+		t.Error("expected error for missing ticket")
+	}
+}
+
 func TestClose(t *testing.T) {
 	s := newTestStore(t)
 
@@ -169,6 +206,26 @@ func TestClose(t *testing.T) {
 	}
 }
 
+func TestReopen(t *testing.T) {
+	s := newTestStore(t)
+	s.Save(&protocol.Ticket{ID: "t-006", Title: "Test", Status: protocol.TicketOpen, CreatedBy: "a", CreatedAt: time.Now()})
+	s.Close("t-006", "Premature")
+
+	if err := s.Reopen("t-006"); err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	got, _ := s.Get("t-006")
+	if got.Status != protocol.TicketOpen {
+		t.Errorf("expected open, got %q", got.Status)
+	}
+	if got.ClosedAt != nil {
+		t.Errorf("expected closed_at cleared, got %v", got.ClosedAt)
+	}
+	if err := s.Reopen("missing"); err == nil {
+		t.Error("expected error for unknown ticket")
+	}
+}
+
 func TestList_All(t *testing.T) {
 	s := newTestStore(t)
 
@@ -223,3 +280,700 @@ func TestList_Limit(t *testing.T) {
 		t.Errorf("expected 2 tickets, got %d", len(tickets))
 	}
 }
+
+func saveTagged(t *testing.T, s *SQLiteStore, id string, tags ...string) {
+	t.Helper()
+	err := s.Save(&protocol.Ticket{
+		ID: id, Title: id, Status: protocol.TicketOpen, CreatedBy: "a",
+		Tags: tags, CreatedAt: time.Now().Truncate(time.Second),
+	})
+	if err != nil {
+		t.Fatalf("save %s: %v", id, err)
+	}
+}
+
+func TestList_TagsExactMatch(t *testing.T) {
+	s := newTestStore(t)
+	saveTagged(t, s, "t-go", "go")
+	saveTagged(t, s, "t-golang", "golang")
+
+	tickets, _ := s.List(Filter{Tags: []string{"go"}})
+	if len(tickets) != 1 || tickets[0].ID != "t-go" {
+		t.Errorf("expected only t-go, got %v", ticketIDs(tickets))
+	}
+}
+
+func TestList_TagsAllAndAny(t *testing.T) {
+	s := newTestStore(t)
+	saveTagged(t, s, "t-1", "bug", "urgent")
+	saveTagged(t, s, "t-2", "bug")
+	saveTagged(t, s, "t-3", "feature")
+
+	all, _ := s.List(Filter{Tags: []string{"bug", "urgent"}})
+	if len(all) != 1 || all[0].ID != "t-1" {
+		t.Errorf("AND: expected [t-1], got %v", ticketIDs(all))
+	}
+
+	anyOf, _ := s.List(Filter{AnyTags: []string{"urgent", "feature"}})
+	if len(anyOf) != 2 {
+		t.Errorf("OR: expected 2 tickets, got %v", ticketIDs(anyOf))
+	}
+
+	n, err := s.Count(Filter{AnyTags: []string{"bug", "urgent"}})
+	if err != nil {
+		t.Fatalf("count: %v", err)
+	}
+	if n != 2 {
+		t.Errorf("OR count: expected 2, got %d", n)
+	}
+}
+
+func TestSave_ReplacesTags(t *testing.T) {
+	s := newTestStore(t)
+	saveTagged(t, s, "t-1", "old")
+	saveTagged(t, s, "t-1", "new")
+
+	if n, _ := s.Count(Filter{Tags: []string{"old"}}); n != 0 {
+		t.Errorf("expected stale tag to be removed, got %d", n)
+	}
+	if n, _ := s.Count(Filter{Tags: []string{"new"}}); n != 1 {
+		t.Errorf("expected 1 ticket with new tag, got %d", n)
+	}
+}
+
+func TestMigrate_BackfillsTags(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.db")
+	s, err := NewSQLiteStore(path)
+	if err != nil {
+		t.Fatalf("create store: %v", err)
+	}
+	saveTagged(t, s, "t-1", "legacy")
+	// Simulate a database created before ticket_tags existed.
+	if _, err := s.DB().Exec(`DROP TABLE ticket_tags`); err != nil {
+		t.Fatalf("drop: %v", err)
+	}
+	s.DB().Close()
+
+	s, err = NewSQLiteStore(path)
+	if err != nil {
+		t.Fatalf("reopen store: %v", err)
+	}
+	defer s.DB().Close()
+
+	if n, _ := s.Count(Filter{Tags: []string{"legacy"}}); n != 1 {
+		t.Errorf("expected backfilled tag to match 1 ticket, got %d", n)
+	}
+}
+
+func TestList_OrdersByPriority(t *testing.T) {
+	s := newTestStore(t)
+	now := time.Now().Truncate(time.Second)
+	for _, tk := range []struct {
+		id       string
+		priority int
+		age      time.Duration
+	}{
+		{"t-old-normal", 0, 3 * time.Minute},
+		{"t-new-normal", 0, time.Minute},
+		{"t-urgent", 2, 5 * time.Minute},
+		{"t-low", -1, 0},
+		{"t-high", 1, 2 * time.Minute},
+	} {
+		err := s.Save(&protocol.Ticket{
+			ID: tk.id, Title: tk.id, Status: protocol.TicketOpen, CreatedBy: "a",
+			Priority: tk.priority, CreatedAt: now.Add(-tk.age),
+		})
+		if err != nil {
+			t.Fatalf("save %s: %v", tk.id, err)
+		}
+	}
+
+	tickets, err := s.List(Filter{})
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	want := []string{"t-urgent", "t-high", "t-new-normal", "t-old-normal", "t-low"}
+	if got := ticketIDs(tickets); fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+	if tickets[0].Priority != 2 {
+		t.Errorf("expected priority 2 loaded, got %d", tickets[0].Priority)
+	}
+
+	minPriority := 1
+	if n, _ := s.Count(Filter{MinPriority: &minPriority}); n != 2 {
+		t.Errorf("expected 2 tickets with priority >= 1, got %d", n)
+	}
+}
+
+func TestDueAt_PersistsAndFiltersOverdue(t *testing.T) {
+	s := newTestStore(t)
+	now := time.Now().Truncate(time.Second)
+	// A non-UTC zone checks that due dates compare as instants.
+	zone := time.FixedZone("UTC+5", 5*60*60)
+	past, future := now.Add(-time.Hour).In(zone), now.Add(time.Hour)
+	for _, tk := range []*protocol.Ticket{
+		{ID: "t-late", DueAt: &past},
+		{ID: "t-later", DueAt: &future},
+		{ID: "t-none"},
+		{ID: "t-late-closed", DueAt: &past, Status: protocol.TicketClosed},
+	} {
+		tk.Title, tk.CreatedBy, tk.CreatedAt = tk.ID, "a", now
+		if tk.Status == "" {
+			tk.Status = protocol.TicketOpen
+		}
+		if err := s.Save(tk); err != nil {
+			t.Fatalf("save %s: %v", tk.ID, err)
+		}
+	}
+
+	got, err := s.Get("t-late")
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	if got.DueAt == nil || !got.DueAt.Equal(past) {
+		t.Errorf("expected due %v, got %v", past, got.DueAt)
+	}
+	if got, _ := s.Get("t-none"); got.DueAt != nil {
+		t.Errorf("expected no due date, got %v", got.DueAt)
+	}
+
+	overdue, err := s.List(Filter{OverdueOnly: true})
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if ids := ticketIDs(overdue); len(ids) != 1 || ids[0] != "t-late" {
+		t.Errorf("expected only t-late overdue, got %v", ids)
+	}
+	if n, _ := s.Count(Filter{OverdueOnly: true}); n != 1 {
+		t.Errorf("expected overdue count 1, got %d", n)
+	}
+}
+
+func TestMigrate_AddsPriorityColumn(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.db")
+	// A tickets table as created before priorities existed.
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	_, err = db.Exec(`
+		CREATE TABLE tickets (
+			id         TEXT PRIMARY KEY,
+			title      TEXT NOT NULL,
+			goal       TEXT NOT NULL DEFAULT '',
+			status     TEXT NOT NULL DEFAULT 'open',
+			created_by TEXT NOT NULL,
+			waiting_on TEXT NOT NULL DEFAULT '[]',
+			tags       TEXT NOT NULL DEFAULT '[]',
+			parent_id  TEXT NOT NULL DEFAULT '',
+			summary    TEXT NOT NULL DEFAULT '',
+			created_at TEXT NOT NULL,
+			closed_at  TEXT,
+			watchers   TEXT NOT NULL DEFAULT '[]'
+		);
+		INSERT INTO tickets (id, title, created_by, created_at) VALUES ('t-legacy', 'Legacy', 'a', '2025-01-01T00:00:00Z');
+	`)
+	db.Close()
+	if err != nil {
+		t.Fatalf("create legacy schema: %v", err)
+	}
+
+	s, err := NewSQLiteStore(path)
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	defer s.DB().Close()
+
+	got, err := s.Get("t-legacy")
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	if got.Priority != 0 {
+		t.Errorf("expected existing ticket to default to priority 0, got %d", got.Priority)
+	}
+	if err := s.Save(&protocol.Ticket{ID: "t-new", Title: "New", Status: protocol.TicketOpen, CreatedBy: "a", Priority: 3, CreatedAt: time.Now()}); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	if tickets, _ := s.List(Filter{}); len(tickets) != 2 || tickets[0].ID != "t-new" {
+		t.Errorf("expected t-new listed first, got %v", ticketIDs(tickets))
+	}
+
+	// Reopening runs the migration again without error.
+	s.DB().Close()
+	if s, err = NewSQLiteStore(path); err != nil {
+		t.Fatalf("reopen store: %v", err)
+	}
+	s.DB().Close()
+}
+
+func ticketIDs(tickets []*protocol.Ticket) []string {
+	ids := make([]string, len(tickets))
+	for i, tk := range tickets {
+		ids[i] = tk.ID
+	}
+	return ids
+}
+
+func TestArchive(t *testing.T) {
+	s := newTestStore(t)
+	old := time.Now().Add(-48 * time.Hour).Truncate(time.Second)
+
+	save := func(id, parentID string, status protocol.TicketStatus, closedAt *time.Time) {
+		t.Helper()
+		err := s.Save(&protocol.Ticket{
+			ID: id, Title: id, Status: status, CreatedBy: "a", ParentID: parentID,
+			Tags: []string{"x"}, CreatedAt: old, ClosedAt: closedAt,
+		})
+		if err != nil {
+			t.Fatalf("save %s: %v", id, err)
+		}
+	}
+	save("t-old", "", protocol.TicketClosed, &old)
+	save("t-parent", "", protocol.TicketClosed, &old)
+	save("t-child", "t-parent", protocol.TicketOpen, nil)
+	save("t-open", "", protocol.TicketOpen, nil)
+	s.AppendMessage("t-old", protocol.Message{ID: "m-1", From: "a", Content: "hi", Timestamp: old})
+
+	n, err := s.Archive(time.Now().Add(-24 * time.Hour))
+	if err != nil {
+		t.Fatalf("archive: %v", err)
+	}
+	if n != 1 {
+		t.Errorf("expected 1 archived ticket, got %d", n)
+	}
+
+	hot, _ := s.List(Filter{})
+	if len(hot) != 3 {
+		t.Errorf("expected 3 hot tickets, got %v", ticketIDs(hot))
+	}
+	all, _ := s.List(Filter{IncludeArchived: true, Tags: []string{"x"}})
+	if len(all) != 4 {
+		t.Errorf("expected 4 tickets including archived, got %v", ticketIDs(all))
+	}
+
+	got, err := s.Get("t-old")
+	if err != nil {
+		t.Fatalf("get archived: %v", err)
+	}
+	if len(got.Messages) != 1 || got.Messages[0].Content != "hi" {
+		t.Errorf("expected archived message to be kept, got %v", got.Messages)
+	}
+
+	// Once the child closes and ages out, the parent goes with it.
+	s.Close("t-child", "done")
+	n, _ = s.Archive(time.Now().Add(time.Hour))
+	if n != 2 {
+		t.Errorf("expected parent and child archived together, got %d", n)
+	}
+}
+
+func TestAppendMessage_Attachments(t *testing.T) {
+	s := newTestStore(t)
+	s.Save(&protocol.Ticket{
+		ID: "t-att", Title: "Test", Status: protocol.TicketOpen,
+		CreatedBy: "a", CreatedAt: time.Now().Truncate(time.Second),
+	})
+
+	msg := protocol.Message{
+		ID: "m-att", From: "_external", Content: "see file", TicketID: "t-att",
+		Timestamp:   time.Now().Truncate(time.Second),
+		Attachments: []protocol.Attachment{{Name: "notes.txt", MimeType: "text/plain", Data: []byte("hello")}},
+	}
+	if err := s.AppendMessage("t-att", msg); err != nil {
+		t.Fatalf("append: %v", err)
+	}
+
+	got, _ := s.Get("t-att")
+	if len(got.Messages) != 1 || len(got.Messages[0].Attachments) != 1 {
+		t.Fatalf("expected 1 message with 1 attachment, got %+v", got.Messages)
+	}
+	a := got.Messages[0].Attachments[0]
+	if a.Name != "notes.txt" || a.Size != 5 || a.Data != nil {
+		t.Errorf("unexpected attachment metadata: %+v", a)
+	}
+	data, err := os.ReadFile(a.Path)
+	if err != nil {
+		t.Fatalf("read blob: %v", err)
+	}
+	if string(data) != "hello" {
+		t.Errorf("expected blob 'hello', got %q", data)
+	}
+}
+
+func TestInFlight_MarkListClear(t *testing.T) {
+	s := newTestStore(t)
+
+	if err := s.MarkInFlight("dev", "tk-1", "m-1"); err != nil {
+		t.Fatalf("mark: %v", err)
+	}
+	// Marking again (a retry) must not duplicate the entry.
+	if err := s.MarkInFlight("dev", "tk-1", "m-1"); err != nil {
+		t.Fatalf("re-mark: %v", err)
+	}
+	if err := s.MarkInFlight("qa", "tk-1", "m-1"); err != nil {
+		t.Fatalf("mark qa: %v", err)
+	}
+
+	got, err := s.ListInFlight()
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("expected 2 markers, got %d", len(got))
+	}
+
+	if err := s.ClearInFlight("dev", "m-1"); err != nil {
+		t.Fatalf("clear: %v", err)
+	}
+	got, _ = s.ListInFlight()
+	if len(got) != 1 || got[0].AgentID != "qa" || got[0].TicketID != "tk-1" {
+		t.Errorf("expected only qa marker left, got %+v", got)
+	}
+}
+
+func TestGetWithMessages(t *testing.T) {
+	s := newTestStore(t)
+	base := time.Now().Add(-time.Hour).Truncate(time.Second)
+	s.Save(&protocol.Ticket{ID: "t-win", Title: "Window", Status: protocol.TicketOpen, CreatedBy: "a", CreatedAt: base})
+	for i := 0; i < 5; i++ {
+		s.AppendMessage("t-win", protocol.Message{
+			ID: fmt.Sprintf("m-%d", i), From: "a", Content: fmt.Sprintf("msg %d", i),
+			Timestamp: base.Add(time.Duration(i) * time.Minute),
+		})
+	}
+
+	got, total, err := s.GetWithMessages("t-win", 2)
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	if total != 5 {
+		t.Errorf("total = %d, want 5", total)
+	}
+	if len(got.Messages) != 2 || got.Messages[0].ID != "m-3" || got.Messages[1].ID != "m-4" {
+		t.Errorf("expected m-3, m-4 oldest first, got %+v", got.Messages)
+	}
+
+	got, _, _ = s.GetWithMessages("t-win", 0)
+	if len(got.Messages) != 5 {
+		t.Errorf("limit 0: expected all 5 messages, got %d", len(got.Messages))
+	}
+
+	if _, _, err := s.GetWithMessages("missing", 2); err == nil {
+		t.Error("expected error for missing ticket")
+	}
+}
+
+// TestGetWithMessages_NonUTC windows messages whose timestamps carry a
+// non-UTC offset, as on hosts with a local time zone.
+func TestGetWithMessages_NonUTC(t *testing.T) {
+	s := newTestStore(t)
+	east := time.FixedZone("UTC+5", 5*60*60)
+	base := time.Now().Add(-time.Hour).Truncate(time.Second).In(east)
+	s.Save(&protocol.Ticket{ID: "t-tz", Title: "TZ", Status: protocol.TicketOpen, CreatedBy: "a", CreatedAt: base})
+	for i := 0; i < 4; i++ {
+		s.AppendMessage("t-tz", protocol.Message{
+			ID: fmt.Sprintf("m-%d", i), From: "a", Content: fmt.Sprintf("msg %d", i),
+			Timestamp: base.Add(time.Duration(i) * time.Minute),
+		})
+	}
+
+	got, _, err := s.GetWithMessages("t-tz", 2)
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	if len(got.Messages) != 2 || got.Messages[0].ID != "m-2" || got.Messages[1].ID != "m-3" {
+		t.Errorf("expected m-2, m-3 oldest first, got %+v", got.Messages)
+	}
+	if want := base.Add(2 * time.Minute); !got.Messages[0].Timestamp.Equal(want) {
+		t.Errorf("timestamp = %v, want %v", got.Messages[0].Timestamp, want)
+	}
+}
+
+func TestSaveWithMessage_Atomic(t *testing.T) {
+	s := newTestStore(t)
+	now := time.Now().Truncate(time.Second)
+
+	tk := &protocol.Ticket{ID: "t-a", Title: "A", Status: protocol.TicketOpen, CreatedBy: "a", CreatedAt: now}
+	msg := protocol.Message{ID: "m-1", From: "a", To: []string{"b"}, Content: "first", Timestamp: now}
+	if err := s.SaveWithMessage(tk, msg); err != nil {
+		t.Fatalf("save with message: %v", err)
+	}
+	got, err := s.Get("t-a")
+	if err != nil || len(got.Messages) != 1 {
+		t.Fatalf("expected ticket with 1 message, got %v, %v", got, err)
+	}
+
+	// Duplicate message ID fails the insert; the second ticket must roll back.
+	tk2 := &protocol.Ticket{ID: "t-b", Title: "B", Status: protocol.TicketOpen, CreatedBy: "a", Tags: []string{"x"}, CreatedAt: now}
+	if err := s.SaveWithMessage(tk2, msg); err == nil {
+		t.Fatal("expected error for duplicate message ID")
+	}
+	if _, err := s.Get("t-b"); err == nil {
+		t.Error("expected no orphan ticket after failed save")
+	}
+	if n, _ := s.Count(Filter{Tags: []string{"x"}}); n != 0 {
+		t.Errorf("expected rolled-back tags, got %d tickets", n)
+	}
+}
+
+func TestSaveWithMessage_RemovesBlobsOnFailure(t *testing.T) {
+	s := newTestStore(t)
+	tk := &protocol.Ticket{ID: "t-c", Title: "C", Status: protocol.TicketOpen, CreatedBy: "a", CreatedAt: time.Now()}
+	// The first attachment is written to disk before the second fails.
+	msg := protocol.Message{ID: "m-c", From: "a", To: []string{"b"}, Timestamp: time.Now(), Attachments: []protocol.Attachment{
+		{Name: "ok.txt", Data: []byte("hello")},
+		{Name: "broken.txt"},
+	}}
+	if err := s.SaveWithMessage(tk, msg); err == nil {
+		t.Fatal("expected error for an attachment without data or path")
+	}
+	if _, err := os.Stat(filepath.Join(s.attachDir, "t-c")); !os.IsNotExist(err) {
+		t.Errorf("expected the ticket's attachment blobs to be removed, stat: %v", err)
+	}
+}
+
+func TestExportImport_RoundTrip(t *testing.T) {
+	src := newTestStore(t)
+	created := time.Date(2025, 3, 1, 9, 0, 0, 0, time.UTC)
+	closed := created.Add(time.Hour)
+
+	src.SaveWithMessage(&protocol.Ticket{ID: "t-old", Title: "Old", Status: protocol.TicketClosed, CreatedBy: "a", WaitingOn: []string{}, CreatedAt: created, ClosedAt: &closed},
+		protocol.Message{ID: "m-old", From: "a", To: []string{"b"}, Content: "done", Timestamp: created})
+	if n, err := src.Archive(created.Add(2 * time.Hour)); err != nil || n != 1 {
+		t.Fatalf("archive: %d, %v", n, err)
+	}
+	src.SaveWithMessage(&protocol.Ticket{ID: "t-live", Title: "Live", Status: protocol.TicketOpen, CreatedBy: "a", WaitingOn: []string{"b"}, Tags: []string{"external", "chat:42"}, CreatedAt: created},
+		protocol.Message{ID: "m-1", From: "a", To: []string{"b"}, Content: "hi", Timestamp: created,
+			Attachments: []protocol.Attachment{{Name: "a.txt", Data: []byte("abc")}}})
+	src.AppendEvent(protocol.TicketEvent{TicketID: "t-live", Type: protocol.EventCreated, Actor: "a", Timestamp: created})
+	src.RecordUsage("t-live", "gpt-4o", protocol.Usage{PromptTokens: 100, CompletionTokens: 20})
+	src.RecordUsage("t-live", "gpt-4o", protocol.Usage{PromptTokens: 50, CompletionTokens: 5})
+	src.SaveSchedule(Schedule{ID: "s-1", TicketID: "t-live", AgentID: "b", Message: "ping", NextRun: closed, CreatedBy: "a", CreatedAt: created})
+	src.SaveDeadLetter(DeadLetter{ID: "dl-1", Target: "gone", Reason: "no such agent", CreatedAt: created,
+		Message: protocol.Message{ID: "m-dl", TicketID: "t-live", From: "a", To: []string{"gone"}, Content: "lost", Timestamp: created}})
+
+	snap, err := src.Export()
+	if err != nil {
+		t.Fatalf("export: %v", err)
+	}
+	if len(snap.Tickets) != 1 || len(snap.Archived) != 1 || len(snap.Events) != 1 || len(snap.Usage) != 2 || len(snap.Schedules) != 1 || len(snap.DeadLetters) != 1 {
+		t.Fatalf("unexpected snapshot shape: %+v", snap)
+	}
+	if a := snap.Tickets[0].Messages[0].Attachments[0]; string(a.Data) != "abc" || a.Path != "" {
+		t.Errorf("expected the blob embedded without its source path, got %+v", a)
+	}
+
+	dst := newTestStore(t)
+	stats, err := dst.Import(snap, false)
+	if err != nil {
+		t.Fatalf("import: %v", err)
+	}
+	if stats.Tickets != 2 || stats.Messages != 2 || stats.Events != 1 || stats.Usage != 2 || stats.Schedules != 1 || stats.DeadLetters != 1 || stats.Skipped != 0 {
+		t.Errorf("stats = %+v", stats)
+	}
+
+	live, err := dst.Get("t-live")
+	if err != nil {
+		t.Fatalf("get live: %v", err)
+	}
+	if !live.CreatedAt.Equal(created) || len(live.Messages) != 1 || live.Messages[0].ID != "m-1" || !live.Messages[0].Timestamp.Equal(created) {
+		t.Errorf("live ticket not preserved: %+v", live)
+	}
+	a := live.Messages[0].Attachments
+	if len(a) != 1 || a[0].Name != "a.txt" || a[0].Size != 3 || !strings.HasPrefix(a[0].Path, dst.attachDir+string(filepath.Separator)) {
+		t.Fatalf("attachment not rewritten under the target store: %+v", a)
+	}
+	if data, err := os.ReadFile(a[0].Path); err != nil || string(data) != "abc" {
+		t.Errorf("attachment blob not restored: %q, %v", data, err)
+	}
+	if n, _ := dst.Count(Filter{Tags: []string{"chat:42"}}); n != 1 {
+		t.Errorf("expected session tag to survive, got %d", n)
+	}
+	old, err := dst.Get("t-old")
+	if err != nil || old.ClosedAt == nil || !old.ClosedAt.Equal(closed) || len(old.Messages) != 1 {
+		t.Errorf("archived ticket not preserved: %+v, %v", old, err)
+	}
+	if n, _ := dst.Count(Filter{}); n != 1 {
+		t.Errorf("archived ticket should stay archived, hot count = %d", n)
+	}
+	if sc, err := dst.GetSchedule("s-1"); err != nil || !sc.NextRun.Equal(closed) {
+		t.Errorf("schedule not preserved: %+v, %v", sc, err)
+	}
+	if d, err := dst.GetDeadLetter("dl-1"); err != nil || d.Target != "gone" || d.Message.Content != "lost" || !d.CreatedAt.Equal(created) {
+		t.Errorf("dead letter not preserved: %+v, %v", d, err)
+	}
+	if u, err := dst.Usage("t-live"); err != nil || len(u.Models) != 1 || u.Models[0].Calls != 2 || u.Models[0].PromptTokens != 150 {
+		t.Errorf("usage not preserved: %+v, %v", u, err)
+	}
+	if got, _ := dst.exportUsage(); len(got) != 2 || got[0].ID != snap.Usage[0].ID || !got[1].Timestamp.Equal(snap.Usage[1].Timestamp) {
+		t.Errorf("usage IDs and timestamps not preserved: %+v", got)
+	}
+
+	// Re-importing conflicts unless existing IDs are skipped.
+	if _, err := dst.Import(snap, false); !errors.Is(err, ErrSnapshotConflict) {
+		t.Errorf("expected conflict, got %v", err)
+	}
+	stats, err = dst.Import(snap, true)
+	if err != nil || stats.Tickets != 0 || stats.Skipped != 4 {
+		t.Errorf("skip import: %+v, %v", stats, err)
+	}
+	if evs, _ := dst.Events("t-live"); len(evs) != 1 {
+		t.Errorf("skipped ticket's events should not be duplicated, got %d", len(evs))
+	}
+}
+
+func TestImport_RejectsAttachmentPathOutsideStore(t *testing.T) {
+	s := newTestStore(t)
+	snap := &Snapshot{Tickets: []*protocol.Ticket{{
+		ID: "t-x", Title: "X", Status: protocol.TicketOpen, CreatedBy: "a", CreatedAt: time.Now(),
+		Messages: []protocol.Message{{ID: "m-x", From: "a", Timestamp: time.Now(),
+			Attachments: []protocol.Attachment{{Name: "passwd", Path: filepath.Join(s.attachDir, "..", "..", "passwd")}}}},
+	}}}
+	if _, err := s.Import(snap, false); err == nil || !strings.Contains(err.Error(), "outside") {
+		t.Fatalf("expected path outside the store to be rejected, got %v", err)
+	}
+	if _, err := s.Get("t-x"); err == nil {
+		t.Error("rejected import should write nothing")
+	}
+}
+
+func TestRecordUsage(t *testing.T) {
+	s := newTestStore(t)
+
+	s.RecordUsage("t-1", "gpt-4o", protocol.Usage{PromptTokens: 1000, CompletionTokens: 200})
+	s.RecordUsage("t-1", "gpt-4o", protocol.Usage{PromptTokens: 500, CompletionTokens: 100})
+	s.RecordUsage("t-1", "claude-sonnet", protocol.Usage{PromptTokens: 2000, CompletionTokens: 0})
+	s.RecordUsage("t-2", "gpt-4o", protocol.Usage{PromptTokens: 7, CompletionTokens: 7})
+
+	u, err := s.Usage("t-1")
+	if err != nil {
+		t.Fatalf("usage: %v", err)
+	}
+	if u.Calls != 3 || u.PromptTokens != 3500 || u.CompletionTokens != 300 || u.TotalTokens != 3800 {
+		t.Errorf("unexpected totals: %+v", u)
+	}
+	if len(u.Models) != 2 || u.Models[0].Model != "claude-sonnet" || u.Models[1].Calls != 2 {
+		t.Fatalf("expected per-model rows sorted by model, got %+v", u.Models)
+	}
+
+	u.Price(map[string]protocol.ModelCost{"gpt-4o": {PromptPerMillion: 2.5, CompletionPerMillion: 10}})
+	if u.Models[0].EstimatedCostUSD != nil {
+		t.Error("expected no cost for a model without a price")
+	}
+	if u.EstimatedCostUSD == nil || *u.EstimatedCostUSD != 0.00675 {
+		t.Errorf("expected $0.00675 for gpt-4o, got %v", u.EstimatedCostUSD)
+	}
+
+	if u, _ := s.Usage("t-none"); u.Calls != 0 || u.Models == nil {
+		t.Errorf("expected empty usage with a non-nil model list, got %+v", u)
+	}
+}
+
+func TestList_MessageQuery(t *testing.T) {
+	s := newTestStore(t)
+	if !s.fts {
+		t.Fatal("expected FTS5 to be available")
+	}
+	now := time.Now().Truncate(time.Second)
+	for i, tk := range []struct{ id, content string }{
+		{"t-deploy", "The canary rollout failed on the eu-west cluster"},
+		{"t-billing", "Invoice 4471 was charged twice"},
+		{"t-other", "Nothing to see here"},
+	} {
+		s.Save(&protocol.Ticket{ID: tk.id, Title: "Ticket", Status: protocol.TicketOpen, CreatedBy: "a", CreatedAt: now.Add(time.Duration(i) * time.Second)})
+		if err := s.AppendMessage(tk.id, protocol.Message{ID: "m-" + tk.id, From: "a", To: []string{"b"}, Content: tk.content, Timestamp: now}); err != nil {
+			t.Fatalf("append: %v", err)
+		}
+	}
+
+	search := func(q string) []string {
+		t.Helper()
+		tickets, err := s.List(Filter{MessageQuery: q})
+		if err != nil {
+			t.Fatalf("list %q: %v", q, err)
+		}
+		return ticketIDs(tickets)
+	}
+	for q, want := range map[string]string{
+		"canary":            "[t-deploy]",
+		"rollout eu-west":   "[t-deploy]",
+		"invoice 4471":      "[t-billing]",
+		`charged "twice"`:   "[t-billing]",
+		"canary invoice":    "[]",
+		"title-only-phrase": "[]",
+	} {
+		if got := fmt.Sprint(search(q)); got != want {
+			t.Errorf("MessageQuery %q: got %s, want %s", q, got, want)
+		}
+	}
+	if n, _ := s.Count(Filter{MessageQuery: "canary"}); n != 1 {
+		t.Errorf("count = %d, want 1", n)
+	}
+
+	// Archived messages leave the index but are found with IncludeArchived.
+	s.Close("t-deploy", "done")
+	s.DB().Exec(`UPDATE tickets SET closed_at = ? WHERE id = 't-deploy'`, now.Add(-48*time.Hour).UTC().Format(time.RFC3339))
+	if _, err := s.Archive(now.Add(-24 * time.Hour)); err != nil {
+		t.Fatalf("archive: %v", err)
+	}
+	if got := search("canary"); len(got) != 0 {
+		t.Errorf("expected archived ticket out of the hot search, got %v", got)
+	}
+	// Archived messages match word by word too, not as one phrase.
+	for q, want := range map[string]string{
+		"canary":          "[t-deploy]",
+		"eu-west canary":  "[t-deploy]",
+		"canary invoice":  "[]",
+		"rollout failed!": "[]",
+	} {
+		tickets, _ := s.List(Filter{MessageQuery: q, IncludeArchived: true})
+		if got := fmt.Sprint(ticketIDs(tickets)); got != want {
+			t.Errorf("archived MessageQuery %q: got %s, want %s", q, got, want)
+		}
+	}
+
+	// Without FTS5 the same searches fall back to LIKE, one per word.
+	s.fts = false
+	for q, want := range map[string]string{
+		"4471":         "[t-billing]",
+		"twice 4471":   "[t-billing]",
+		"invoice 4471": "[t-billing]",
+		"twice canary": "[]",
+	} {
+		if got := fmt.Sprint(search(q)); got != want {
+			t.Errorf("LIKE fallback %q: got %s, want %s", q, got, want)
+		}
+	}
+}
+
+func TestMigrate_BuildsMessageIndex(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.db")
+	s, err := NewSQLiteStore(path)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	s.Save(&protocol.Ticket{ID: "t-1", Title: "Old", Status: protocol.TicketOpen, CreatedBy: "a", CreatedAt: time.Now()})
+	s.AppendMessage("t-1", protocol.Message{ID: "m-1", From: "a", Content: "zebra crossing", Timestamp: time.Now()})
+	// A database from before the index existed.
+	_, err = s.DB().Exec(`
+		DROP TRIGGER ticket_messages_fts_insert;
+		DROP TRIGGER ticket_messages_fts_delete;
+		DROP TRIGGER ticket_messages_fts_update;
+		DROP TABLE ticket_messages_fts;
+	`)
+	s.DB().Close()
+	if err != nil {
+		t.Fatalf("drop index: %v", err)
+	}
+
+	s, err = NewSQLiteStore(path)
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	defer s.DB().Close()
+	tickets, err := s.List(Filter{MessageQuery: "zebra"})
+	if err != nil || len(tickets) != 1 {
+		t.Errorf("expected existing messages indexed, got %v, %v", ticketIDs(tickets), err)
+	}
+}
@@ -1,6 +1,11 @@
 package ticket
 
-import "github.com/h1v3-io/h1v3/pkg/protocol"
+import (
+	"errors"
+	"time"
+
+	"github.com/h1v3-io/h1v3/pkg/protocol"
+)
 
 // Store is the persistence interface for tickets and their messages.
 type Store interface {
@@ -8,24 +13,117 @@ type Store interface {
 	Save(ticket *protocol.Ticket) error
 	// Get retrieves a ticket by ID, including its messages.
 	Get(id string) (*protocol.Ticket, error)
-	// List returns tickets matching the filter.
+	// GetWithMessages retrieves a ticket with only its most recent limit
+	// messages, plus the ticket's total message count.
+	GetWithMessages(id string, limit int) (*protocol.Ticket, int, error)
+	// List returns tickets matching the filter, highest priority first and
+	// newest first within a priority.
 	List(filter Filter) ([]*protocol.Ticket, error)
 	// Count returns the number of tickets matching the filter.
 	Count(filter Filter) (int, error)
+	// SaveWithMessage saves a ticket and appends a message to it atomically.
+	SaveWithMessage(ticket *protocol.Ticket, msg protocol.Message) error
 	// AppendMessage adds a message to a ticket.
 	AppendMessage(ticketID string, msg protocol.Message) error
 	// UpdateStatus changes a ticket's status.
 	UpdateStatus(ticketID string, status protocol.TicketStatus) error
+	// UpdateWaitingOn replaces a ticket's assignees.
+	UpdateWaitingOn(ticketID string, to []string) error
+	// SetWatchers replaces a ticket's watcher list.
+	SetWatchers(ticketID string, watchers []string) error
 	// Close marks a ticket as closed with a summary.
 	Close(ticketID string, summary string) error
+	// Reopen sets a closed ticket back to open and clears its closed_at.
+	Reopen(ticketID string) error
+	// AppendEvent records an entry in a ticket's event timeline.
+	AppendEvent(ev protocol.TicketEvent) error
+	// Events returns a ticket's event timeline, oldest first.
+	Events(ticketID string) ([]protocol.TicketEvent, error)
+	// RecordUsage adds the token usage of one provider call made for a
+	// ticket.
+	RecordUsage(ticketID, model string, u protocol.Usage) error
+	// Usage returns a ticket's recorded token usage, aggregated per model.
+	Usage(ticketID string) (*protocol.TicketUsage, error)
+	// Archive moves tickets closed before the cutoff out of the hot tables,
+	// returning how many were archived.
+	Archive(closedBefore time.Time) (int, error)
+	// MarkInFlight records that an agent has started processing a message.
+	MarkInFlight(agentID, ticketID, messageID string) error
+	// ClearInFlight removes an in-flight marker once processing has finished.
+	ClearInFlight(agentID, messageID string) error
+	// ListInFlight returns all in-flight markers, oldest first.
+	ListInFlight() ([]InFlight, error)
+	// SaveSchedule creates or updates a scheduled message.
+	SaveSchedule(s Schedule) error
+	// GetSchedule retrieves a scheduled message by ID.
+	GetSchedule(id string) (*Schedule, error)
+	// DeleteSchedule removes a scheduled message.
+	DeleteSchedule(id string) error
+	// ListSchedules returns all scheduled messages, soonest first.
+	ListSchedules() ([]Schedule, error)
+	// SaveDeadLetter records a message that could not be delivered.
+	SaveDeadLetter(d DeadLetter) error
+	// GetDeadLetter retrieves a dead letter by ID.
+	GetDeadLetter(id string) (*DeadLetter, error)
+	// DeleteDeadLetter removes a dead letter.
+	DeleteDeadLetter(id string) error
+	// ListDeadLetters returns all dead letters, oldest first.
+	ListDeadLetters() ([]DeadLetter, error)
+}
+
+// ErrDeadLetterNotFound is returned for a dead letter ID that does not
+// exist.
+var ErrDeadLetterNotFound = errors.New("dead letter not found")
+
+// DeadLetter is a message that could not be delivered to one of its
+// recipients, kept so an operator can inspect it and replay it.
+type DeadLetter struct {
+	ID        string           `json:"id"`
+	Target    string           `json:"target"` // the recipient it missed
+	Reason    string           `json:"reason"`
+	Message   protocol.Message `json:"message"`
+	CreatedAt time.Time        `json:"created_at"`
+}
+
+// Schedule is a message to be routed to an agent on a ticket at a future
+// time, once or on a recurring cron schedule.
+type Schedule struct {
+	ID        string    `json:"id"`
+	TicketID  string    `json:"ticket_id"`
+	AgentID   string    `json:"agent_id"` // recipient
+	Message   string    `json:"message"`
+	Cron      string    `json:"cron,omitempty"` // recurrence; empty for a one-shot
+	NextRun   time.Time `json:"next_run"`
+	CreatedBy string    `json:"created_by"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// InFlight marks a message an agent pulled from its inbox but has not
+// finished processing. Markers left behind by a crash are redelivered on
+// startup.
+type InFlight struct {
+	AgentID   string
+	TicketID  string
+	MessageID string
+	StartedAt time.Time
 }
 
 // Filter constrains ticket list queries.
 type Filter struct {
 	Status   *protocol.TicketStatus
 	AgentID  string   // matches created_by or waiting_on
-	Tags     []string // all must match
+	Tags     []string // exact match; all must match
+	AnyTags  []string // exact match; at least one must match
 	Query    string   // text search on title and summary
 	ParentID string   // exact match on parent_id
 	Limit    int      // 0 = no limit
+
+	MinPriority *int // only tickets with at least this priority
+	OverdueOnly bool // only open tickets whose due date has passed
+
+	// MessageQuery matches tickets with a message containing every word,
+	// using the full-text index when SQLite has FTS5.
+	MessageQuery string
+
+	IncludeArchived bool // also search archived tickets
 }
@@ -0,0 +1,67 @@
+package ticket
+
+import (
+	"slices"
+
+	"github.com/h1v3-io/h1v3/pkg/protocol"
+)
+
+// Sub-ticket tree depth bounds for BuildTree.
+const (
+	DefaultTreeDepth = 3
+	MaxTreeDepth     = 10
+)
+
+// Node is one ticket in a sub-ticket tree. Messages are left out so the tree
+// stays small enough to show an agent or operator at a glance.
+type Node struct {
+	ID        string                `json:"id"`
+	Title     string                `json:"title"`
+	Status    protocol.TicketStatus `json:"status"`
+	WaitingOn []string              `json:"waiting_on"`
+	Summary   string                `json:"summary,omitempty"`
+	Children  []Node                `json:"children,omitempty"`
+	Truncated bool                  `json:"truncated,omitempty"` // has sub-tickets below the depth limit
+}
+
+// BuildTree returns root with its sub-tickets nested up to depth levels
+// below it, oldest first. list is typically Store.List or a wrapper with the
+// same signature. Archived sub-tickets are included.
+func BuildTree(list func(Filter) ([]*protocol.Ticket, error), root *protocol.Ticket, depth int) (Node, error) {
+	if depth <= 0 {
+		depth = DefaultTreeDepth
+	}
+	depth = min(depth, MaxTreeDepth)
+	seen := map[string]bool{root.ID: true}
+
+	var build func(t *protocol.Ticket, depth int) (Node, error)
+	build = func(t *protocol.Ticket, depth int) (Node, error) {
+		n := Node{ID: t.ID, Title: t.Title, Status: t.Status, WaitingOn: t.WaitingOn, Summary: t.Summary}
+		filter := Filter{ParentID: t.ID, IncludeArchived: true}
+		if depth == 0 {
+			filter.Limit = 1
+		}
+		subs, err := list(filter)
+		if err != nil {
+			return n, err
+		}
+		if depth == 0 {
+			n.Truncated = len(subs) > 0
+			return n, nil
+		}
+		slices.Reverse(subs) // List is newest first
+		for _, sub := range subs {
+			if seen[sub.ID] {
+				continue
+			}
+			seen[sub.ID] = true
+			child, err := build(sub, depth-1)
+			if err != nil {
+				return n, err
+			}
+			n.Children = append(n.Children, child)
+		}
+		return n, nil
+	}
+	return build(root, depth)
+}
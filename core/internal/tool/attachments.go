@@ -0,0 +1,106 @@
+package tool
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/h1v3-io/h1v3/pkg/protocol"
+)
+
+// ReadAttachmentTool reads a file attached to a message on a ticket, or copies
+// it into the agent's workspace so other tools can work on it. Only the
+// ticket's participants (creator, assignees and watchers) may read its
+// attachments.
+type ReadAttachmentTool struct {
+	Broker     TicketBroker
+	AgentID    string
+	AllowedDir string // workspace that save_to must resolve under
+}
+
+func (t *ReadAttachmentTool) Name() string { return "read_attachment" }
+func (t *ReadAttachmentTool) Description() string {
+	return "Read a file attached to a ticket message, or copy it into your workspace with save_to"
+}
+func (t *ReadAttachmentTool) Parameters() map[string]any {
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"name":      map[string]any{"type": "string", "description": "Attachment file name"},
+			"ticket_id": map[string]any{"type": "string", "description": "Ticket the attachment belongs to (default: current ticket)"},
+			"save_to":   map[string]any{"type": "string", "description": "Optional workspace path to copy the attachment to instead of returning its content"},
+		},
+		"required": []string{"name"},
+	}
+}
+
+func (t *ReadAttachmentTool) Execute(ctx context.Context, params map[string]any) (string, error) {
+	name := getString(params, "name")
+	if name == "" {
+		return "", fmt.Errorf("read_attachment: name is required")
+	}
+	ticketID := getString(params, "ticket_id")
+	if ticketID == "" {
+		ticketID = CurrentTicketFromContext(ctx)
+	}
+	if ticketID == "" {
+		return "", fmt.Errorf("read_attachment: ticket_id is required outside a ticket context")
+	}
+
+	tk, err := t.Broker.GetTicket(ticketID)
+	if err != nil {
+		return "", fmt.Errorf("read_attachment: %w", err)
+	}
+	if !isParticipant(tk, t.AgentID) {
+		return "", fmt.Errorf("read_attachment: you are not a participant in ticket %s", ticketID)
+	}
+	att, ok := findAttachment(tk, name)
+	if !ok {
+		return "", fmt.Errorf("read_attachment: no attachment %q on ticket %s", name, ticketID)
+	}
+
+	data := att.Data
+	if data == nil {
+		data, err = os.ReadFile(att.Path)
+		if err != nil {
+			return "", fmt.Errorf("read_attachment: %w", err)
+		}
+	}
+
+	if saveTo := getString(params, "save_to"); saveTo != "" {
+		path, err := checkPath(saveTo, t.AllowedDir)
+		if err != nil {
+			return "", err
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return "", fmt.Errorf("read_attachment: create dirs: %w", err)
+		}
+		if err := os.WriteFile(path, data, 0o644); err != nil {
+			return "", fmt.Errorf("read_attachment: %w", err)
+		}
+		return fmt.Sprintf("Saved %s (%d bytes) to %s", att.Name, len(data), path), nil
+	}
+
+	if !strings.HasPrefix(att.MimeType, "text/") && !utf8.Valid(data) {
+		return fmt.Sprintf("%s is binary (%s, %d bytes). Use save_to to copy it into your workspace.", att.Name, att.MimeType, len(data)), nil
+	}
+	if len(data) > maxReadSize {
+		return string(data[:maxReadSize]) + "\n... [truncated]", nil
+	}
+	return string(data), nil
+}
+
+// findAttachment returns the most recent attachment with the given name.
+func findAttachment(tk *protocol.Ticket, name string) (protocol.Attachment, bool) {
+	for i := len(tk.Messages) - 1; i >= 0; i-- {
+		for _, a := range tk.Messages[i].Attachments {
+			if a.Name == name {
+				return a, true
+			}
+		}
+	}
+	return protocol.Attachment{}, false
+}
@@ -0,0 +1,88 @@
+package tool
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/h1v3-io/h1v3/pkg/protocol"
+)
+
+func newAttachmentTicket(t *testing.T, broker *testBroker) string {
+	t.Helper()
+	tk, err := broker.CreateAndRoute("_external", "Files", "", "", []string{"agent-a"}, nil, 0, nil, protocol.Message{
+		ID: "m-1", From: "_external", To: []string{"agent-a"}, Content: "here", Timestamp: time.Now(),
+		Attachments: []protocol.Attachment{{Name: "notes.txt", MimeType: "text/plain", Data: []byte("hello world")}},
+	})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	return tk.ID
+}
+
+func TestReadAttachmentTool_Content(t *testing.T) {
+	broker := newTestBroker(t)
+	ticketID := newAttachmentTicket(t, broker)
+
+	rt := &ReadAttachmentTool{Broker: broker, AgentID: "agent-a"}
+	ctx := WithCurrentTicket(context.Background(), ticketID)
+	result, err := rt.Execute(ctx, map[string]any{"name": "notes.txt"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result != "hello world" {
+		t.Errorf("expected attachment content, got %q", result)
+	}
+}
+
+func TestReadAttachmentTool_SaveTo(t *testing.T) {
+	broker := newTestBroker(t)
+	ticketID := newAttachmentTicket(t, broker)
+	dir := t.TempDir()
+
+	rt := &ReadAttachmentTool{Broker: broker, AgentID: "agent-a", AllowedDir: dir}
+	result, err := rt.Execute(context.Background(), map[string]any{
+		"name": "notes.txt", "ticket_id": ticketID, "save_to": filepath.Join(dir, "in", "notes.txt"),
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(result, "Saved") {
+		t.Errorf("expected save confirmation, got %q", result)
+	}
+	data, _ := os.ReadFile(filepath.Join(dir, "in", "notes.txt"))
+	if string(data) != "hello world" {
+		t.Errorf("expected copied content, got %q", data)
+	}
+
+	if _, err := rt.Execute(context.Background(), map[string]any{
+		"name": "notes.txt", "ticket_id": ticketID, "save_to": "/etc/evil",
+	}); err == nil {
+		t.Error("expected error for save_to outside workspace")
+	}
+}
+
+func TestReadAttachmentTool_NotFound(t *testing.T) {
+	broker := newTestBroker(t)
+	ticketID := newAttachmentTicket(t, broker)
+
+	rt := &ReadAttachmentTool{Broker: broker, AgentID: "agent-a"}
+	_, err := rt.Execute(context.Background(), map[string]any{"name": "missing.pdf", "ticket_id": ticketID})
+	if err == nil {
+		t.Fatal("expected error for missing attachment")
+	}
+}
+
+func TestReadAttachmentTool_NonParticipant(t *testing.T) {
+	broker := newTestBroker(t)
+	ticketID := newAttachmentTicket(t, broker)
+
+	rt := &ReadAttachmentTool{Broker: broker, AgentID: "agent-z"}
+	_, err := rt.Execute(context.Background(), map[string]any{"name": "notes.txt", "ticket_id": ticketID})
+	if err == nil || !strings.Contains(err.Error(), "not a participant") {
+		t.Fatalf("expected a non-participant refused, got %v", err)
+	}
+}
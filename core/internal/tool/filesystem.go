@@ -1,10 +1,16 @@
 package tool
 
 import (
+	"bufio"
+	"bytes"
+	"cmp"
 	"context"
 	"fmt"
+	"io/fs"
 	"os"
 	"path/filepath"
+	"regexp"
+	"slices"
 	"strings"
 )
 
@@ -63,7 +69,10 @@ func (t *ReadFileTool) Execute(_ context.Context, params map[string]any) (string
 
 // --- WriteFile ---
 
-type WriteFileTool struct{ AllowedDir string }
+type WriteFileTool struct {
+	AllowedDir string
+	Quota      *DiskQuota // optional cap on bytes kept under AllowedDir
+}
 
 func (t *WriteFileTool) Name() string        { return "write_file" }
 func (t *WriteFileTool) Description() string  { return "Write content to a file (creates parent directories if needed)" }
@@ -84,10 +93,19 @@ func (t *WriteFileTool) Execute(_ context.Context, params map[string]any) (strin
 		return "", err
 	}
 	content := getString(params, "content")
+	var oldSize int64
+	if info, err := os.Stat(path); err == nil {
+		oldSize = info.Size()
+	}
+	if err := t.Quota.Reserve(oldSize, int64(len(content))); err != nil {
+		return "", fmt.Errorf("write_file: %w", err)
+	}
 	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Quota.Reserve(int64(len(content)), oldSize)
 		return "", fmt.Errorf("write_file: create dirs: %w", err)
 	}
 	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Quota.Reserve(int64(len(content)), oldSize)
 		return "", fmt.Errorf("write_file: %w", err)
 	}
 	return fmt.Sprintf("Wrote %d bytes to %s", len(content), path), nil
@@ -95,7 +113,10 @@ func (t *WriteFileTool) Execute(_ context.Context, params map[string]any) (strin
 
 // --- EditFile ---
 
-type EditFileTool struct{ AllowedDir string }
+type EditFileTool struct {
+	AllowedDir string
+	Quota      *DiskQuota // optional cap on bytes kept under AllowedDir
+}
 
 func (t *EditFileTool) Name() string        { return "edit_file" }
 func (t *EditFileTool) Description() string  { return "Replace old_text with new_text in a file (old_text must be a unique match)" }
@@ -134,7 +155,11 @@ func (t *EditFileTool) Execute(_ context.Context, params map[string]any) (string
 	}
 
 	result := strings.Replace(content, oldText, newText, 1)
+	if err := t.Quota.Reserve(int64(len(data)), int64(len(result))); err != nil {
+		return "", fmt.Errorf("edit_file: %w", err)
+	}
 	if err := os.WriteFile(path, []byte(result), 0o644); err != nil {
+		t.Quota.Reserve(int64(len(result)), int64(len(data)))
 		return "", fmt.Errorf("edit_file: write: %w", err)
 	}
 	return fmt.Sprintf("Replaced text in %s", path), nil
@@ -180,3 +205,249 @@ func (t *ListDirTool) Execute(_ context.Context, params map[string]any) (string,
 	}
 	return b.String(), nil
 }
+
+// --- Grep ---
+
+const (
+	maxGrepPattern  = 1000    // longest regexp accepted
+	maxGrepMatches  = 200     // matching lines returned
+	maxGrepLine     = 300     // characters shown per matching line
+	maxGrepFileSize = 5 << 20 // larger files are skipped
+)
+
+// GrepTool searches file contents under a directory for a regexp, skipping
+// binary files, symlinks and .git directories.
+type GrepTool struct{ AllowedDir string }
+
+func (t *GrepTool) Name() string { return "grep" }
+func (t *GrepTool) Description() string {
+	return fmt.Sprintf("Search file contents for a regular expression (Go RE2 syntax), returning file:line: text for each matching line (at most %d)", maxGrepMatches)
+}
+func (t *GrepTool) Parameters() map[string]any {
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"pattern": map[string]any{"type": "string", "description": "Regular expression to search for, e.g. func \\w+Handler or (?i)todo"},
+			"path":    map[string]any{"type": "string", "description": "File or directory to search (default: your working directory)"},
+			"glob":    map[string]any{"type": "string", "description": "Only search files whose name matches this glob, e.g. *.go (a glob containing / is matched against the path relative to path)"},
+		},
+		"required": []string{"pattern"},
+	}
+}
+
+func (t *GrepTool) Execute(ctx context.Context, params map[string]any) (string, error) {
+	pattern := getString(params, "pattern")
+	if pattern == "" {
+		return "", fmt.Errorf("grep: pattern is required")
+	}
+	if len(pattern) > maxGrepPattern {
+		return "", fmt.Errorf("grep: pattern is longer than %d characters", maxGrepPattern)
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return "", fmt.Errorf("grep: %w", err)
+	}
+	glob := getString(params, "glob")
+	if _, err := filepath.Match(glob, ""); err != nil {
+		return "", fmt.Errorf("grep: invalid glob: %w", err)
+	}
+
+	start := getString(params, "path")
+	if start == "" {
+		start = cmp.Or(t.AllowedDir, ".")
+	}
+	root, err := checkPath(start, t.AllowedDir)
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	matches := 0
+	err = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil // unreadable entries are skipped
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if d.Name() == ".git" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		rel, _ := filepath.Rel(root, path)
+		if rel == "." {
+			rel = d.Name()
+		}
+		if glob != "" {
+			name := d.Name()
+			if strings.Contains(glob, "/") {
+				name = filepath.ToSlash(rel)
+			}
+			if ok, _ := filepath.Match(glob, name); !ok {
+				return nil
+			}
+		}
+		if matches >= maxGrepMatches {
+			return filepath.SkipAll
+		}
+		matches += grepFile(&b, path, rel, re, maxGrepMatches-matches)
+		return nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("grep: %w", err)
+	}
+
+	if matches == 0 {
+		return "No matches.", nil
+	}
+	if matches >= maxGrepMatches {
+		fmt.Fprintf(&b, "... [stopped at %d matches; narrow the pattern, path or glob]\n", maxGrepMatches)
+	}
+	return b.String(), nil
+}
+
+// grepFile writes up to limit lines of the file at path matching re, as
+// name:line: text, and returns how many it wrote. Binary files (a NUL byte
+// in the first 8KB) and files over maxGrepFileSize are skipped.
+func grepFile(b *strings.Builder, path, name string, re *regexp.Regexp, limit int) int {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0
+	}
+	defer f.Close()
+	if info, err := f.Stat(); err != nil || info.Size() > maxGrepFileSize {
+		return 0
+	}
+
+	r := bufio.NewReader(f)
+	if head, _ := r.Peek(8 << 10); bytes.IndexByte(head, 0) >= 0 {
+		return 0
+	}
+
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 64<<10), maxGrepFileSize)
+	n := 0
+	for line := 1; sc.Scan() && n < limit; line++ {
+		text := sc.Text()
+		if !re.MatchString(text) {
+			continue
+		}
+		if len(text) > maxGrepLine {
+			text = text[:maxGrepLine] + "…"
+		}
+		fmt.Fprintf(b, "%s:%d: %s\n", filepath.ToSlash(name), line, text)
+		n++
+	}
+	return n
+}
+
+// --- Glob ---
+
+const maxGlobResults = 500
+
+// GlobTool finds files under AllowedDir whose relative path matches a glob.
+// Besides filepath.Match syntax, a ** path element matches any number of
+// directories, so **/*.go finds Go files at every depth.
+type GlobTool struct{ AllowedDir string }
+
+func (t *GlobTool) Name() string { return "glob" }
+func (t *GlobTool) Description() string {
+	return fmt.Sprintf("Find files by path pattern relative to your working directory, e.g. **/*.go, cmd/*/main.go or docs/**. Returns matching paths sorted (at most %d); directories end in /", maxGlobResults)
+}
+func (t *GlobTool) Parameters() map[string]any {
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"pattern": map[string]any{"type": "string", "description": "Glob relative to your working directory; * matches within one path element, ** across any number of directories"},
+		},
+		"required": []string{"pattern"},
+	}
+}
+
+func (t *GlobTool) Execute(ctx context.Context, params map[string]any) (string, error) {
+	pattern := strings.Trim(filepath.ToSlash(getString(params, "pattern")), "/")
+	if pattern == "" {
+		return "", fmt.Errorf("glob: pattern is required")
+	}
+	parts := strings.Split(pattern, "/")
+	for _, p := range parts {
+		if _, err := filepath.Match(p, ""); err != nil {
+			return "", fmt.Errorf("glob: invalid pattern: %w", err)
+		}
+	}
+	root, err := checkPath(cmp.Or(t.AllowedDir, "."), t.AllowedDir)
+	if err != nil {
+		return "", err
+	}
+
+	var found []string
+	truncated := false
+	err = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || path == root {
+			return nil
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if _, err := checkPath(path, t.AllowedDir); err != nil {
+			return nil
+		}
+		if d.IsDir() && d.Name() == ".git" && parts[0] != ".git" {
+			return filepath.SkipDir
+		}
+		rel, _ := filepath.Rel(root, path)
+		if !matchGlob(parts, strings.Split(filepath.ToSlash(rel), "/")) {
+			return nil
+		}
+		if len(found) == maxGlobResults {
+			truncated = true
+			return filepath.SkipAll
+		}
+		if d.IsDir() {
+			rel += string(filepath.Separator)
+		}
+		found = append(found, filepath.ToSlash(rel))
+		return nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("glob: %w", err)
+	}
+
+	if len(found) == 0 {
+		return "No files match.", nil
+	}
+	slices.Sort(found)
+	out := strings.Join(found, "\n") + "\n"
+	if truncated {
+		out += fmt.Sprintf("... [stopped at %d results; narrow the pattern]\n", maxGlobResults)
+	}
+	return out, nil
+}
+
+// matchGlob reports whether the path elements match the pattern elements,
+// where a ** element matches zero or more path elements.
+func matchGlob(pattern, path []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(path); i++ {
+				if matchGlob(pattern[1:], path[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(path) == 0 {
+			return false
+		}
+		if ok, _ := filepath.Match(pattern[0], path[0]); !ok {
+			return false
+		}
+		pattern, path = pattern[1:], path[1:]
+	}
+	return len(path) == 0
+}
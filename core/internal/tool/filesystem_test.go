@@ -4,7 +4,9 @@ import (
 	"context"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestReadFile(t *testing.T) {
@@ -119,3 +121,154 @@ func TestListDir(t *testing.T) {
 		t.Error("expected non-empty listing")
 	}
 }
+
+func TestGrep(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) {
+		path := filepath.Join(dir, name)
+		os.MkdirAll(filepath.Dir(path), 0o755)
+		os.WriteFile(path, []byte(content), 0o644)
+	}
+	write("main.go", "package main\n\nfunc handleLogin() {}\nfunc helper() {}\n")
+	write("pkg/auth/auth.go", "package auth\n\n// TODO: handleLogout\nfunc handleLogout() {}\n")
+	write("pkg/notes.txt", "handleLogin is documented here\n")
+	write("logo.png", "\x89PNG\x00\x00func handleBinary()\n")
+	write(".git/config", "func handleGit()\n")
+
+	tool := &GrepTool{AllowedDir: dir}
+	grep := func(params map[string]any) string {
+		t.Helper()
+		result, err := tool.Execute(context.Background(), params)
+		if err != nil {
+			t.Fatalf("grep %v: %v", params, err)
+		}
+		return result
+	}
+
+	got := grep(map[string]any{"pattern": `func handle\w+`})
+	want := "main.go:3: func handleLogin() {}\npkg/auth/auth.go:4: func handleLogout() {}\n"
+	if got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+
+	if got := grep(map[string]any{"pattern": "handleLog", "glob": "*.txt"}); got != "pkg/notes.txt:1: handleLogin is documented here\n" {
+		t.Errorf("glob *.txt: got %q", got)
+	}
+	if got := grep(map[string]any{"pattern": "handle", "glob": "pkg/*/*.go"}); !strings.HasPrefix(got, "pkg/auth/auth.go:3:") || strings.Contains(got, "main.go") {
+		t.Errorf("path glob: got %q", got)
+	}
+	if got := grep(map[string]any{"pattern": "(?i)todo", "path": filepath.Join(dir, "pkg", "auth")}); got != "auth.go:3: // TODO: handleLogout\n" {
+		t.Errorf("subtree: got %q", got)
+	}
+	if got := grep(map[string]any{"pattern": "nothing-matches-this"}); got != "No matches." {
+		t.Errorf("expected no matches, got %q", got)
+	}
+
+	for _, params := range []map[string]any{
+		{"pattern": "("},
+		{"pattern": strings.Repeat("a", maxGrepPattern+1)},
+		{"pattern": "x", "path": "/etc"},
+	} {
+		if _, err := tool.Execute(context.Background(), params); err == nil {
+			t.Errorf("expected an error for %.40v", params)
+		}
+	}
+}
+
+func TestGrep_MatchLimit(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "big.txt"), []byte(strings.Repeat("match\n", maxGrepMatches+50)), 0o644)
+
+	result, err := (&GrepTool{AllowedDir: dir}).Execute(context.Background(), map[string]any{"pattern": "match"})
+	if err != nil {
+		t.Fatalf("grep: %v", err)
+	}
+	if n := strings.Count(result, "big.txt:"); n != maxGrepMatches || !strings.Contains(result, "stopped at") {
+		t.Errorf("expected %d matches and a truncation note, got %d", maxGrepMatches, n)
+	}
+}
+
+func TestGlob(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{
+		"main.go", "README.md", "cmd/app/main.go", "cmd/app/main_test.go",
+		"internal/tool/grep.go", "internal/tool/testdata/x.json", ".git/HEAD",
+	} {
+		path := filepath.Join(dir, name)
+		os.MkdirAll(filepath.Dir(path), 0o755)
+		os.WriteFile(path, nil, 0o644)
+	}
+
+	tool := &GlobTool{AllowedDir: dir}
+	for pattern, want := range map[string]string{
+		"**/*.go":        "cmd/app/main.go\ncmd/app/main_test.go\ninternal/tool/grep.go\nmain.go\n",
+		"*.go":           "main.go\n",
+		"*":              "README.md\ncmd/\ninternal/\nmain.go\n",
+		"cmd/*/main.go":  "cmd/app/main.go\n",
+		"**/*_test.go":   "cmd/app/main_test.go\n",
+		"internal/**":    "internal/\ninternal/tool/\ninternal/tool/grep.go\ninternal/tool/testdata/\ninternal/tool/testdata/x.json\n",
+		"**/*.{go,md}":   "No files match.",
+		"**/*.[jm][sd]*": "README.md\ninternal/tool/testdata/x.json\n",
+		".git/*":         ".git/HEAD\n",
+	} {
+		got, err := tool.Execute(context.Background(), map[string]any{"pattern": pattern})
+		if err != nil {
+			t.Fatalf("glob %q: %v", pattern, err)
+		}
+		if got != want {
+			t.Errorf("glob %q:\ngot:\n%s\nwant:\n%s", pattern, got, want)
+		}
+	}
+
+	if _, err := tool.Execute(context.Background(), map[string]any{"pattern": "[a-"}); err == nil {
+		t.Error("expected an error for a malformed pattern")
+	}
+}
+
+func TestWriteAndEditFile_DiskQuota(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "existing.txt"), []byte("0123456789"), 0o644) // 10 bytes
+	quota := NewDiskQuota(dir, 25)
+	write := &WriteFileTool{AllowedDir: dir, Quota: quota}
+	edit := &EditFileTool{AllowedDir: dir, Quota: quota}
+	ctx := context.Background()
+
+	if _, err := write.Execute(ctx, map[string]any{"path": filepath.Join(dir, "a.txt"), "content": "0123456789"}); err != nil {
+		t.Fatalf("write within quota: %v", err)
+	}
+	_, err := write.Execute(ctx, map[string]any{"path": filepath.Join(dir, "b.txt"), "content": "0123456789"})
+	if err == nil || !strings.Contains(err.Error(), "disk quota exceeded") {
+		t.Fatalf("expected quota error, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "b.txt")); !os.IsNotExist(err) {
+		t.Error("rejected write should not create the file")
+	}
+
+	// Overwriting counts only the difference.
+	if _, err := write.Execute(ctx, map[string]any{"path": filepath.Join(dir, "a.txt"), "content": "012345678901234"}); err != nil {
+		t.Errorf("overwrite within quota: %v", err)
+	}
+	if _, err := edit.Execute(ctx, map[string]any{"path": filepath.Join(dir, "a.txt"), "old_text": "0123", "new_text": "0123456789"}); err == nil {
+		t.Error("expected edit growing past the quota to fail")
+	}
+	if _, err := edit.Execute(ctx, map[string]any{"path": filepath.Join(dir, "a.txt"), "old_text": "01234567890", "new_text": ""}); err != nil {
+		t.Errorf("shrinking edit: %v", err)
+	}
+}
+
+func TestDiskQuota_Rescan(t *testing.T) {
+	dir := t.TempDir()
+	q := &DiskQuota{Dir: dir, Limit: 100, Refresh: time.Hour}
+	if err := q.Reserve(0, 60); err != nil {
+		t.Fatal(err)
+	}
+	// Usage is tracked, not re-measured: nothing was actually written.
+	if err := q.Reserve(0, 60); err == nil {
+		t.Fatal("expected tracked usage to count toward the limit")
+	}
+	q.Refresh = time.Nanosecond
+	time.Sleep(time.Millisecond)
+	if err := q.Reserve(0, 60); err != nil {
+		t.Errorf("expected a rescan of the empty dir to reset usage, got %v", err)
+	}
+}
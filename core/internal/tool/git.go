@@ -0,0 +1,142 @@
+package tool
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	defaultGitLog = 10
+	maxGitLog     = 100
+)
+
+// GitTool runs a fixed set of git actions in AllowedDir. Each action builds
+// its own argument list, so the model chooses paths and a commit message but
+// never flags. Repository discovery stops at AllowedDir: a workspace that is
+// not itself a repository is refused rather than reaching a parent's.
+// Commits are made as AuthorName <AuthorEmail>; without AuthorName, git
+// reads the identity from the user's own configuration.
+type GitTool struct {
+	AllowedDir  string
+	AuthorName  string
+	AuthorEmail string
+	Timeout     time.Duration
+}
+
+func (t *GitTool) Name() string { return "git" }
+func (t *GitTool) Description() string {
+	return "Inspect and commit to the git repository in your working directory: status, diff, log, add, commit"
+}
+func (t *GitTool) Parameters() map[string]any {
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"action":  map[string]any{"type": "string", "enum": []string{"status", "diff", "log", "add", "commit"}, "description": "Git action to run"},
+			"paths":   map[string]any{"type": "array", "items": map[string]any{"type": "string"}, "description": "Files to limit diff and log to; required for add"},
+			"staged":  map[string]any{"type": "boolean", "description": "For diff: show staged changes instead of unstaged"},
+			"limit":   map[string]any{"type": "integer", "description": "For log: number of commits (default 10, max 100)"},
+			"message": map[string]any{"type": "string", "description": "Commit message (required for commit)"},
+		},
+		"required": []string{"action"},
+	}
+}
+
+func (t *GitTool) Execute(ctx context.Context, params map[string]any) (string, error) {
+	if t.AllowedDir == "" {
+		return "", fmt.Errorf("git: no working directory configured")
+	}
+	root, err := checkPath(t.AllowedDir, t.AllowedDir)
+	if err != nil {
+		return "", err
+	}
+	paths, err := t.pathArgs(root, getStringSlice(params, "paths"))
+	if err != nil {
+		return "", err
+	}
+
+	action := getString(params, "action")
+	var args []string
+	empty := "(no output)"
+	switch action {
+	case "status":
+		args = []string{"status", "--short", "--branch"}
+	case "diff":
+		args = []string{"diff", "--no-color", "--no-ext-diff"}
+		if staged, _ := params["staged"].(bool); staged {
+			args = append(args, "--cached")
+		}
+		args = append(append(args, "--"), paths...)
+		empty = "No changes."
+	case "log":
+		n := defaultGitLog
+		if l, ok := params["limit"].(float64); ok && l > 0 {
+			n = min(int(l), maxGitLog)
+		}
+		args = append([]string{"log", "--no-color", fmt.Sprintf("--max-count=%d", n), "--format=%h %ad %an: %s", "--date=short", "--"}, paths...)
+		empty = "No commits."
+	case "add":
+		if len(paths) == 0 {
+			return "", fmt.Errorf("git: add needs paths")
+		}
+		args = append([]string{"add", "--"}, paths...)
+		empty = fmt.Sprintf("Staged %d path(s).", len(paths))
+	case "commit":
+		message := strings.TrimSpace(getString(params, "message"))
+		if message == "" {
+			return "", fmt.Errorf("git: commit needs a message")
+		}
+		args = []string{"commit", "--message=" + message}
+	default:
+		return "", fmt.Errorf("git: unknown action %q", action)
+	}
+
+	sb := sandbox{tool: "git", workDir: root, timeout: t.Timeout, env: t.env(root)}
+	out, err := sb.run(ctx, "git", append([]string{"--no-pager"}, args...)...)
+	if err != nil {
+		return "", err
+	}
+	if out == "(no output)\n[exit code 0]" {
+		return empty, nil
+	}
+	return out, nil
+}
+
+// pathArgs checks each path against AllowedDir and makes it relative to
+// root, so nothing the model passes can be read as a flag.
+func (t *GitTool) pathArgs(root string, paths []string) ([]string, error) {
+	var out []string
+	for _, p := range paths {
+		if !filepath.IsAbs(p) {
+			p = filepath.Join(root, p)
+		}
+		abs, err := checkPath(p, t.AllowedDir)
+		if err != nil {
+			return nil, fmt.Errorf("git: %w", err)
+		}
+		rel, _ := filepath.Rel(root, abs)
+		out = append(out, filepath.ToSlash(rel))
+	}
+	return out, nil
+}
+
+// env stops repository discovery above root, keeps git from prompting, and
+// sets the commit identity, or restores the HOME the sandbox replaced so
+// the user's git configuration still applies.
+func (t *GitTool) env(root string) []string {
+	env := []string{
+		"GIT_CEILING_DIRECTORIES=" + filepath.Dir(root),
+		"GIT_TERMINAL_PROMPT=0",
+	}
+	if t.AuthorName == "" {
+		return append(env, "HOME="+os.Getenv("HOME"))
+	}
+	env = append(env, "GIT_AUTHOR_NAME="+t.AuthorName, "GIT_COMMITTER_NAME="+t.AuthorName)
+	if t.AuthorEmail != "" {
+		env = append(env, "GIT_AUTHOR_EMAIL="+t.AuthorEmail, "GIT_COMMITTER_EMAIL="+t.AuthorEmail)
+	}
+	return env
+}
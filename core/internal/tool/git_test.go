@@ -0,0 +1,94 @@
+package tool
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// newGitRepo creates a repository in a temp dir, or skips without git.
+func newGitRepo(t *testing.T) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	dir := t.TempDir()
+	cmd := exec.Command("git", "init", "-q", "-b", "main", dir)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git init: %v: %s", err, out)
+	}
+	return dir
+}
+
+func TestGit_Workflow(t *testing.T) {
+	dir := newGitRepo(t)
+	tool := &GitTool{AllowedDir: dir, AuthorName: "coder", AuthorEmail: "coder@h1v3.local"}
+	run := func(params map[string]any) string {
+		t.Helper()
+		out, err := tool.Execute(context.Background(), params)
+		if err != nil {
+			t.Fatalf("%v: %v", params, err)
+		}
+		return out
+	}
+
+	os.WriteFile(filepath.Join(dir, "a.txt"), []byte("one\n"), 0o644)
+	if out := run(map[string]any{"action": "status"}); !strings.Contains(out, "?? a.txt") {
+		t.Errorf("status = %q", out)
+	}
+	if out := run(map[string]any{"action": "add", "paths": []any{"a.txt"}}); out != "Staged 1 path(s)." {
+		t.Errorf("add = %q", out)
+	}
+	if out := run(map[string]any{"action": "diff", "staged": true}); !strings.Contains(out, "+one") {
+		t.Errorf("staged diff = %q", out)
+	}
+	if out := run(map[string]any{"action": "commit", "message": "--amend first"}); !strings.Contains(out, "--amend first") {
+		t.Errorf("commit = %q", out)
+	}
+	if out := run(map[string]any{"action": "log"}); !strings.Contains(out, "coder: --amend first") {
+		t.Errorf("log = %q", out)
+	}
+	if out := run(map[string]any{"action": "diff"}); out != "No changes." {
+		t.Errorf("diff = %q", out)
+	}
+
+	os.WriteFile(filepath.Join(dir, "a.txt"), []byte("two\n"), 0o644)
+	if out := run(map[string]any{"action": "diff", "paths": []any{"a.txt"}}); !strings.Contains(out, "-one\n+two") {
+		t.Errorf("diff = %q", out)
+	}
+}
+
+func TestGit_Rejects(t *testing.T) {
+	dir := newGitRepo(t)
+	tool := &GitTool{AllowedDir: dir}
+	for name, params := range map[string]map[string]any{
+		"flag as path":   {"action": "add", "paths": []any{"--all"}},
+		"outside dir":    {"action": "diff", "paths": []any{"../x"}},
+		"add no paths":   {"action": "add"},
+		"commit no msg":  {"action": "commit", "message": "  "},
+		"unknown action": {"action": "push"},
+	} {
+		out, err := tool.Execute(context.Background(), params)
+		if name == "flag as path" {
+			// Taken as a file name, which does not exist.
+			if err != nil || !strings.Contains(out, "did not match any files") {
+				t.Errorf("%s: got %q, %v", name, out, err)
+			}
+			continue
+		}
+		if err == nil {
+			t.Errorf("%s: expected an error, got %q", name, out)
+		}
+	}
+
+	// A workspace inside someone else's repository is not a repository.
+	sub := filepath.Join(dir, "workspace")
+	os.MkdirAll(sub, 0o755)
+	out, err := (&GitTool{AllowedDir: sub}).Execute(context.Background(), map[string]any{"action": "status"})
+	if err != nil || !strings.Contains(out, "not a git repository") {
+		t.Errorf("expected parent repository refused, got %q, %v", out, err)
+	}
+}
@@ -3,12 +3,33 @@ package tool
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 )
 
 // AgentInfo holds basic agent metadata for the discovery tool.
 type AgentInfo struct {
-	ID   string `json:"id"`
-	Role string `json:"role"`
+	ID      string `json:"id"`
+	Role    string `json:"role"`
+	Summary string `json:"summary,omitempty"` // short capability summary, if known
+}
+
+// AgentProfile is the detailed, read-only view of an agent returned by
+// get_agent.
+type AgentProfile struct {
+	ID             string       `json:"id"`
+	Role           string       `json:"role"`
+	Summary        string       `json:"summary,omitempty"`
+	State          string       `json:"state,omitempty"`
+	Tools          []string     `json:"tools"`
+	Skills         []SkillBrief `json:"skills,omitempty"`
+	CanDelegateTo  []string     `json:"can_delegate_to,omitempty"`
+	CanReceiveFrom []string     `json:"can_receive_from,omitempty"`
+}
+
+// SkillBrief names a skill an agent has and what it is for.
+type SkillBrief struct {
+	Slug        string `json:"slug"`
+	Description string `json:"description,omitempty"`
 }
 
 // AgentLister provides agent discovery. Implemented by the registry adapter
@@ -17,6 +38,12 @@ type AgentLister interface {
 	ListAgentInfo() []AgentInfo
 }
 
+// AgentProfiler looks up an agent's detailed profile. Implemented by the
+// registry adapter in cmd/h1v3d.
+type AgentProfiler interface {
+	AgentProfile(id string) (*AgentProfile, bool)
+}
+
 // ListAgentsTool lets agents discover other agents in the hive.
 type ListAgentsTool struct {
 	Lister AgentLister
@@ -24,7 +51,7 @@ type ListAgentsTool struct {
 
 func (t *ListAgentsTool) Name() string { return "list_agents" }
 func (t *ListAgentsTool) Description() string {
-	return "List all agents in the hive with their IDs and roles."
+	return "List all agents in the hive with their IDs, roles and a short capability summary. Use get_agent for an agent's tools and skills."
 }
 func (t *ListAgentsTool) Parameters() map[string]any {
 	return map[string]any{
@@ -38,3 +65,36 @@ func (t *ListAgentsTool) Execute(_ context.Context, _ map[string]any) (string, e
 	out, _ := json.MarshalIndent(agents, "", "  ")
 	return string(out), nil
 }
+
+// GetAgentTool returns one agent's profile so a delegating agent can pick the
+// right assignee and write a better goal.
+type GetAgentTool struct {
+	Profiler AgentProfiler
+}
+
+func (t *GetAgentTool) Name() string { return "get_agent" }
+func (t *GetAgentTool) Description() string {
+	return "Get an agent's profile: role, capability summary, tools, skills and delegation limits. Use it to choose whom to create a ticket for."
+}
+func (t *GetAgentTool) Parameters() map[string]any {
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"agent_id": map[string]any{"type": "string", "description": "Agent ID (from list_agents)"},
+		},
+		"required": []string{"agent_id"},
+	}
+}
+
+func (t *GetAgentTool) Execute(_ context.Context, params map[string]any) (string, error) {
+	id := getString(params, "agent_id")
+	if id == "" {
+		return "", fmt.Errorf("get_agent: agent_id is required")
+	}
+	profile, ok := t.Profiler.AgentProfile(id)
+	if !ok {
+		return "", fmt.Errorf("get_agent: unknown agent %q — use list_agents to see available agents", id)
+	}
+	out, _ := json.MarshalIndent(profile, "", "  ")
+	return string(out), nil
+}
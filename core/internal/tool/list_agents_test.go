@@ -0,0 +1,41 @@
+package tool
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+type stubProfiler map[string]*AgentProfile
+
+func (s stubProfiler) AgentProfile(id string) (*AgentProfile, bool) {
+	p, ok := s[id]
+	return p, ok
+}
+
+func TestGetAgentTool(t *testing.T) {
+	tool := &GetAgentTool{Profiler: stubProfiler{
+		"coder": {ID: "coder", Role: "Developer", Tools: []string{"exec", "write_file"},
+			Skills: []SkillBrief{{Slug: "go", Description: "Go conventions"}}},
+	}}
+
+	out, err := tool.Execute(context.Background(), map[string]any{"agent_id": "coder"})
+	if err != nil {
+		t.Fatalf("execute: %v", err)
+	}
+	var got AgentProfile
+	if err := json.Unmarshal([]byte(out), &got); err != nil {
+		t.Fatalf("output is not a profile: %v\n%s", err, out)
+	}
+	if got.Role != "Developer" || len(got.Tools) != 2 || got.Skills[0].Slug != "go" {
+		t.Errorf("unexpected profile %+v", got)
+	}
+
+	if _, err := tool.Execute(context.Background(), map[string]any{"agent_id": "ghost"}); err == nil || !strings.Contains(err.Error(), "unknown agent") {
+		t.Errorf("expected unknown agent error, got %v", err)
+	}
+	if _, err := tool.Execute(context.Background(), map[string]any{}); err == nil {
+		t.Error("expected error without agent_id")
+	}
+}
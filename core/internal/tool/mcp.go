@@ -5,8 +5,10 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
 	"os/exec"
 	"strings"
@@ -26,6 +28,19 @@ type MCPClient struct {
 	name      string
 	transport MCPTransport
 	tools     []*MCPToolWrapper
+
+	maxReconnects int // restart attempts after the server dies; 0 disables
+	logger        *slog.Logger
+	reconnectMu   sync.Mutex
+}
+
+// defaultMCPReconnects is how many times a dead stdio server is restarted
+// before a call fails, unless MCPServerConfig.MaxReconnects says otherwise.
+const defaultMCPReconnects = 3
+
+// mcpRestarter is a transport that can respawn a dead server.
+type mcpRestarter interface {
+	Restart() (bool, error)
 }
 
 // MCPToolWrapper wraps a remote MCP tool as a local Tool.
@@ -49,7 +64,7 @@ func (w *MCPToolWrapper) Execute(ctx context.Context, params map[string]any) (st
 
 type jsonRPCRequest struct {
 	JSONRPC string `json:"jsonrpc"`
-	ID      int64  `json:"id"`
+	ID      int64  `json:"id,omitempty"` // zero for notifications
 	Method  string `json:"method"`
 	Params  any    `json:"params,omitempty"`
 }
@@ -95,20 +110,60 @@ type mcpContent struct {
 
 // --- Stdio Transport ---
 
+// errMCPDisconnected marks transport errors caused by the server process
+// going away; MCPClient reconnects on them.
+var errMCPDisconnected = errors.New("server disconnected")
+
 // StdioTransport communicates with an MCP server via stdin/stdout of a spawned process.
+// Requests may be in flight concurrently: each is sent under an ID of the
+// transport's own, and a background reader hands every response to the
+// request waiting on its ID, so a server may answer in any order. The
+// command is kept so Restart can respawn the process if it dies.
 type StdioTransport struct {
-	cmd    *exec.Cmd
-	stdin  io.WriteCloser
-	stdout *bufio.Reader
-	mu     sync.Mutex
-	nextID atomic.Int64
+	ctx     context.Context // spawn context, reused on restart
+	command string
+	args    []string
+	env     []string
+	nextID  atomic.Int64
+
+	mu   sync.Mutex
+	conn *stdioConn
 }
 
+// stdioConn is one running server process and the requests waiting on it.
+type stdioConn struct {
+	cmd     *exec.Cmd
+	stdin   io.WriteCloser
+	stdout  *bufio.Reader
+	writeMu sync.Mutex // keeps concurrent writes from interleaving
+
+	mu      sync.Mutex
+	pending map[int64]chan json.RawMessage // request ID → waiter
+	err     error                          // set once the connection breaks
+
+	closeOnce sync.Once
+	closeErr  error
+}
+
+// stdioCloseGrace is how long Close lets a server exit after its stdin is
+// closed before killing it.
+var stdioCloseGrace = 5 * time.Second
+
 // NewStdioTransport spawns a process and returns a transport.
 func NewStdioTransport(ctx context.Context, command string, args []string, env []string) (*StdioTransport, error) {
-	cmd := exec.CommandContext(ctx, command, args...)
-	if len(env) > 0 {
-		cmd.Env = env
+	t := &StdioTransport{ctx: ctx, command: command, args: args, env: env}
+	conn, err := t.spawn()
+	if err != nil {
+		return nil, err
+	}
+	t.conn = conn
+	return t, nil
+}
+
+func (t *StdioTransport) spawn() (*stdioConn, error) {
+	cmd := exec.CommandContext(t.ctx, t.command, t.args...)
+	if len(t.env) > 0 {
+		cmd.Env = t.env
 	}
 
 	stdin, err := cmd.StdinPipe()
@@ -124,38 +179,231 @@ func NewStdioTransport(ctx context.Context, command string, args []string, env [
 
 	if err := cmd.Start(); err != nil {
 		stdin.Close()
-		return nil, fmt.Errorf("mcp stdio: start %q: %w", command, err)
+		return nil, fmt.Errorf("mcp stdio: start %q: %w", t.command, err)
 	}
 
-	return &StdioTransport{
-		cmd:    cmd,
-		stdin:  stdin,
-		stdout: bufio.NewReader(stdout),
-	}, nil
+	conn := newStdioConn(stdin, stdout)
+	conn.cmd = cmd
+	return conn, nil
 }
 
-func (t *StdioTransport) Send(ctx context.Context, msg json.RawMessage) (json.RawMessage, error) {
+// newStdioTransport speaks JSON-RPC over the given pipes, without a process
+// to restart.
+func newStdioTransport(stdin io.WriteCloser, stdout io.Reader) *StdioTransport {
+	return &StdioTransport{conn: newStdioConn(stdin, stdout)}
+}
+
+// newStdioConn starts the response reader for a pair of pipes.
+func newStdioConn(stdin io.WriteCloser, stdout io.Reader) *stdioConn {
+	c := &stdioConn{
+		stdin:   stdin,
+		stdout:  bufio.NewReader(stdout),
+		pending: make(map[int64]chan json.RawMessage),
+	}
+	go c.readLoop()
+	return c
+}
+
+func (t *StdioTransport) current() *stdioConn {
 	t.mu.Lock()
 	defer t.mu.Unlock()
+	return t.conn
+}
 
-	// Write message + newline
-	data := append(msg, '\n')
-	if _, err := t.stdin.Write(data); err != nil {
-		return nil, fmt.Errorf("mcp stdio: write: %w", err)
+// Send writes a JSON-RPC message and, unless it is a notification (no id),
+// waits for the matching response. The response carries the caller's
+// original id. Errors from a dead server process wrap errMCPDisconnected.
+func (t *StdioTransport) Send(ctx context.Context, msg json.RawMessage) (json.RawMessage, error) {
+	conn := t.current()
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(msg, &fields); err != nil {
+		return nil, fmt.Errorf("mcp stdio: invalid message: %w", err)
+	}
+	callerID, isRequest := fields["id"]
+	if !isRequest {
+		return nil, conn.write(msg)
 	}
 
-	// Read response line
-	line, err := t.stdout.ReadBytes('\n')
+	id := t.nextID.Add(1)
+	fields["id"], _ = json.Marshal(id)
+	data, err := json.Marshal(fields)
 	if err != nil {
-		return nil, fmt.Errorf("mcp stdio: read: %w", err)
+		return nil, fmt.Errorf("mcp stdio: marshal: %w", err)
+	}
+
+	ch := make(chan json.RawMessage, 1)
+	conn.mu.Lock()
+	if conn.err != nil {
+		err := conn.err
+		conn.mu.Unlock()
+		return nil, err
+	}
+	conn.pending[id] = ch
+	conn.mu.Unlock()
+
+	if err := conn.write(data); err != nil {
+		conn.forget(id)
+		return nil, err
 	}
 
-	return json.RawMessage(bytes.TrimSpace(line)), nil
+	select {
+	case resp, ok := <-ch:
+		if !ok {
+			conn.mu.Lock()
+			defer conn.mu.Unlock()
+			return nil, conn.err
+		}
+		return withID(resp, callerID), nil
+	case <-ctx.Done():
+		conn.forget(id)
+		return nil, fmt.Errorf("mcp stdio: %w", ctx.Err())
+	}
+}
+
+// Restart respawns the server process if the current one has died, failing
+// any requests still waiting on it. It reports whether a new process was
+// started; a healthy connection is left alone, so concurrent callers that
+// saw the same failure restart it only once. The old process is killed
+// rather than waited on, since a server that closed stdout may never exit,
+// and it is reaped after t.mu is released so callers are not held up.
+func (t *StdioTransport) Restart() (bool, error) {
+	t.mu.Lock()
+	old := t.conn
+	if old.broken() == nil {
+		t.mu.Unlock()
+		return false, nil
+	}
+	if t.command == "" {
+		t.mu.Unlock()
+		return false, fmt.Errorf("mcp stdio: no command to restart")
+	}
+	conn, err := t.spawn()
+	if err == nil {
+		t.conn = conn
+	}
+	t.mu.Unlock()
+
+	old.close(0)
+	if err != nil {
+		return false, err
+	}
+	return true, nil
 }
 
 func (t *StdioTransport) Close() error {
-	t.stdin.Close()
-	return t.cmd.Wait()
+	return t.current().close(stdioCloseGrace)
+}
+
+func (c *stdioConn) write(data []byte) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	if _, err := c.stdin.Write(append(data, '\n')); err != nil {
+		err = fmt.Errorf("mcp stdio: write: %w: %v", errMCPDisconnected, err)
+		c.fail(err)
+		return err
+	}
+	return nil
+}
+
+// broken returns the error that broke the connection, or nil.
+func (c *stdioConn) broken() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.err
+}
+
+// fail marks the connection broken and fails every request waiting on it.
+func (c *stdioConn) fail(err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.err == nil {
+		c.err = err
+	}
+	for id, ch := range c.pending {
+		close(ch)
+		delete(c.pending, id)
+	}
+}
+
+// forget drops the waiter for a request that will no longer be read.
+func (c *stdioConn) forget(id int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.pending, id)
+}
+
+// readLoop hands each response line to the request waiting on its id until
+// stdout closes, then fails every request still waiting. Server
+// notifications and requests, and responses nobody waits for any more, are
+// dropped.
+func (c *stdioConn) readLoop() {
+	for {
+		line, err := c.stdout.ReadBytes('\n')
+		if line = bytes.TrimSpace(line); len(line) > 0 {
+			c.dispatch(line)
+		}
+		if err != nil {
+			c.fail(fmt.Errorf("mcp stdio: read: %w: %v", errMCPDisconnected, err))
+			return
+		}
+	}
+}
+
+func (c *stdioConn) dispatch(line []byte) {
+	var head struct {
+		ID     *int64 `json:"id"`
+		Method string `json:"method"`
+	}
+	if json.Unmarshal(line, &head) != nil || head.ID == nil || head.Method != "" {
+		return
+	}
+	c.mu.Lock()
+	ch, ok := c.pending[*head.ID]
+	delete(c.pending, *head.ID)
+	c.mu.Unlock()
+	if ok {
+		ch <- json.RawMessage(line)
+	}
+}
+
+// close shuts the process's stdin and waits up to grace for it to exit,
+// then kills it. Only the first call does anything; later ones return its
+// result.
+func (c *stdioConn) close(grace time.Duration) error {
+	c.closeOnce.Do(func() {
+		c.stdin.Close()
+		if c.cmd == nil {
+			return
+		}
+		done := make(chan error, 1)
+		go func() { done <- c.cmd.Wait() }()
+		if grace > 0 {
+			timer := time.NewTimer(grace)
+			defer timer.Stop()
+			select {
+			case c.closeErr = <-done:
+				return
+			case <-timer.C:
+			}
+		}
+		c.cmd.Process.Kill()
+		c.closeErr = <-done
+	})
+	return c.closeErr
+}
+
+// withID returns resp with its id replaced by id.
+func withID(resp, id json.RawMessage) json.RawMessage {
+	var fields map[string]json.RawMessage
+	if json.Unmarshal(resp, &fields) != nil {
+		return resp
+	}
+	fields["id"] = id
+	out, err := json.Marshal(fields)
+	if err != nil {
+		return resp
+	}
+	return out
 }
 
 // --- HTTP Transport ---
@@ -168,10 +416,14 @@ type HTTPTransport struct {
 }
 
 // NewHTTPTransport creates a transport that POSTs JSON-RPC to the given URL.
-func NewHTTPTransport(url string) *HTTPTransport {
+// A nil client uses a default one with a 60s timeout.
+func NewHTTPTransport(url string, client *http.Client) *HTTPTransport {
+	if client == nil {
+		client = &http.Client{Timeout: 60 * time.Second}
+	}
 	return &HTTPTransport{
 		url:    url,
-		client: &http.Client{Timeout: 60 * time.Second},
+		client: client,
 	}
 }
 
@@ -207,8 +459,10 @@ func (t *HTTPTransport) Close() error { return nil }
 // NewMCPClient creates an MCP client and performs the initialize handshake.
 func NewMCPClient(ctx context.Context, name string, transport MCPTransport) (*MCPClient, error) {
 	c := &MCPClient{
-		name:      name,
-		transport: transport,
+		name:          name,
+		transport:     transport,
+		maxReconnects: defaultMCPReconnects,
+		logger:        slog.Default(),
 	}
 
 	// Initialize handshake
@@ -224,7 +478,49 @@ func NewMCPClient(ctx context.Context, name string, transport MCPTransport) (*MC
 	return c, nil
 }
 
+// call sends a request, reconnecting and retrying once if the server has
+// died.
 func (c *MCPClient) call(ctx context.Context, method string, params any) (json.RawMessage, error) {
+	result, err := c.send(ctx, method, params)
+	if !errors.Is(err, errMCPDisconnected) || c.maxReconnects <= 0 {
+		return result, err
+	}
+	if rerr := c.reconnect(ctx); rerr != nil {
+		return nil, fmt.Errorf("%w (%v)", err, rerr)
+	}
+	return c.send(ctx, method, params)
+}
+
+// reconnect restarts a dead server and redoes the initialize handshake, up
+// to maxReconnects times. Callers that hit the same failure concurrently
+// share one restart.
+func (c *MCPClient) reconnect(ctx context.Context) error {
+	r, ok := c.transport.(mcpRestarter)
+	if !ok {
+		return fmt.Errorf("mcp: %q: transport cannot reconnect", c.name)
+	}
+	c.reconnectMu.Lock()
+	defer c.reconnectMu.Unlock()
+
+	var err error
+	for attempt := 1; attempt <= c.maxReconnects; attempt++ {
+		var restarted bool
+		if restarted, err = r.Restart(); err == nil {
+			if !restarted {
+				return nil
+			}
+			c.logger.Warn("mcp server died, restarted", "server", c.name, "attempt", attempt)
+			if err = c.initialize(ctx); err == nil {
+				return nil
+			}
+		}
+		c.logger.Warn("mcp reconnect failed", "server", c.name, "attempt", attempt, "error", err)
+	}
+	return fmt.Errorf("mcp: reconnect %q: giving up after %d attempts: %w", c.name, c.maxReconnects, err)
+}
+
+// send makes a single request.
+func (c *MCPClient) send(ctx context.Context, method string, params any) (json.RawMessage, error) {
 	req := jsonRPCRequest{
 		JSONRPC: "2.0",
 		ID:      time.Now().UnixNano(),
@@ -264,7 +560,7 @@ func (c *MCPClient) initialize(ctx context.Context) error {
 		},
 	}
 
-	_, err := c.call(ctx, "initialize", params)
+	_, err := c.send(ctx, "initialize", params)
 	if err != nil {
 		return fmt.Errorf("mcp: initialize %q: %w", c.name, err)
 	}
@@ -360,7 +656,7 @@ func RegisterMCPTools(ctx context.Context, registry *Registry, servers []MCPServ
 		case "stdio":
 			transport, err = NewStdioTransport(ctx, srv.Command, srv.Args, srv.Env)
 		case "http":
-			transport = NewHTTPTransport(srv.URL)
+			transport = NewHTTPTransport(srv.URL, srv.HTTPClient)
 		default:
 			return nil, fmt.Errorf("mcp: unknown transport %q for server %q", srv.Transport, srv.Name)
 		}
@@ -380,6 +676,12 @@ func RegisterMCPTools(ctx context.Context, registry *Registry, servers []MCPServ
 			}
 			return nil, err
 		}
+		if srv.MaxReconnects != 0 {
+			client.maxReconnects = max(srv.MaxReconnects, 0)
+		}
+		if srv.Logger != nil {
+			client.logger = srv.Logger
+		}
 
 		// Register all tools from this server
 		for _, t := range client.Tools() {
@@ -400,4 +702,12 @@ type MCPServerConfig struct {
 	Args      []string `json:"args,omitempty"`
 	Env       []string `json:"env,omitempty"`
 	URL       string   `json:"url,omitempty"`
+
+	HTTPClient *http.Client `json:"-"` // http transport only; nil = default client
+
+	// MaxReconnects is how many times a stdio server that dies is
+	// restarted before a call fails: 0 means the default (3), negative
+	// disables restarting.
+	MaxReconnects int          `json:"max_reconnects,omitempty"`
+	Logger        *slog.Logger `json:"-"` // nil = slog.Default()
 }
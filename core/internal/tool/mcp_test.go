@@ -1,12 +1,19 @@
 package tool
 
 import (
+	"bufio"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"strconv"
+	"sync"
 	"testing"
+	"time"
 )
 
 // mockTransport simulates an MCP server for testing.
@@ -282,7 +289,7 @@ func TestHTTPTransport_ServerError(t *testing.T) {
 	}))
 	defer srv.Close()
 
-	transport := NewHTTPTransport(srv.URL)
+	transport := NewHTTPTransport(srv.URL, nil)
 	_, err := transport.Send(context.Background(), json.RawMessage(`{}`))
 	if err == nil {
 		t.Fatal("expected error for 500 status")
@@ -301,3 +308,214 @@ func TestMCPClient_EmptyToolsList(t *testing.T) {
 		t.Errorf("expected 0 tools, got %d", len(client.Tools()))
 	}
 }
+
+// pipeServer is the far end of a StdioTransport: it reads the requests the
+// transport writes and writes back replies.
+type pipeServer struct {
+	requests *bufio.Reader
+	replies  *io.PipeWriter
+}
+
+func newPipeTransport(t *testing.T) (*StdioTransport, *pipeServer) {
+	t.Helper()
+	reqR, reqW := io.Pipe()
+	respR, respW := io.Pipe()
+	transport := newStdioTransport(reqW, respR)
+	t.Cleanup(func() { transport.Close(); respW.Close() })
+	return transport, &pipeServer{requests: bufio.NewReader(reqR), replies: respW}
+}
+
+func (s *pipeServer) read(t *testing.T) jsonRPCRequest {
+	t.Helper()
+	line, err := s.requests.ReadBytes('\n')
+	if err != nil {
+		t.Errorf("server read: %v", err)
+		return jsonRPCRequest{}
+	}
+	var req jsonRPCRequest
+	json.Unmarshal(line, &req)
+	return req
+}
+
+func (s *pipeServer) reply(id int64, result string) {
+	data, _ := json.Marshal(jsonRPCResponse{JSONRPC: "2.0", ID: &id, Result: json.RawMessage(result)})
+	s.replies.Write(append(data, '\n'))
+}
+
+func TestStdioTransport_OutOfOrderResponses(t *testing.T) {
+	transport, server := newPipeTransport(t)
+
+	// The server waits for both requests, then answers the second first.
+	go func() {
+		first, second := server.read(t), server.read(t)
+		server.replies.Write([]byte(`{"jsonrpc":"2.0","method":"notifications/progress"}` + "\n"))
+		server.reply(second.ID, fmt.Sprintf("%q", second.Method))
+		server.reply(first.ID, fmt.Sprintf("%q", first.Method))
+	}()
+
+	var wg sync.WaitGroup
+	for i, method := range []string{"tools/list", "tools/call"} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			callerID := int64(100 + i)
+			req, _ := json.Marshal(jsonRPCRequest{JSONRPC: "2.0", ID: callerID, Method: method})
+			ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+			defer cancel()
+			data, err := transport.Send(ctx, req)
+			if err != nil {
+				t.Errorf("%s: %v", method, err)
+				return
+			}
+			var resp jsonRPCResponse
+			json.Unmarshal(data, &resp)
+			if string(resp.Result) != fmt.Sprintf("%q", method) || resp.ID == nil || *resp.ID != callerID {
+				t.Errorf("%s: got response %s", method, data)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestStdioTransport_CancelAndServerExit(t *testing.T) {
+	transport, server := newPipeTransport(t)
+	// The server never answers: it reads a request, a notification and a
+	// last request, then exits.
+	go func() {
+		for range 3 {
+			server.read(t)
+		}
+		server.replies.Close()
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := transport.Send(ctx, json.RawMessage(`{"jsonrpc":"2.0","id":1,"method":"slow"}`)); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected a deadline error, got %v", err)
+	}
+
+	// Notifications are written without waiting for a reply.
+	if _, err := transport.Send(context.Background(), json.RawMessage(`{"jsonrpc":"2.0","method":"notifications/initialized"}`)); err != nil {
+		t.Fatalf("notification: %v", err)
+	}
+
+	// A request in flight when the server goes away fails instead of hanging.
+	if _, err := transport.Send(context.Background(), json.RawMessage(`{"jsonrpc":"2.0","id":2,"method":"tools/list"}`)); err == nil {
+		t.Fatal("expected an error once the server exits")
+	}
+}
+
+// TestMCPHelperServer is not a real test: run as a subprocess with
+// H1V3_MCP_HELPER=1, it is a minimal stdio MCP server whose one tool
+// returns the server's PID. With H1V3_MCP_HELPER=hang it closes stdout and
+// never exits, like a wedged server.
+func TestMCPHelperServer(t *testing.T) {
+	switch os.Getenv("H1V3_MCP_HELPER") {
+	case "1":
+	case "hang":
+		os.Stdout.Close()
+		time.Sleep(time.Hour)
+		return
+	default:
+		return
+	}
+	sc := bufio.NewScanner(os.Stdin)
+	for sc.Scan() {
+		var req jsonRPCRequest
+		if json.Unmarshal(sc.Bytes(), &req) != nil || req.ID == 0 {
+			continue
+		}
+		var result any
+		switch req.Method {
+		case "initialize":
+			result = map[string]any{"protocolVersion": "2024-11-05"}
+		case "tools/list":
+			result = mcpToolsListResult{Tools: []mcpToolDef{{Name: "pid"}}}
+		case "tools/call":
+			result = mcpCallToolResult{Content: []mcpContent{{Type: "text", Text: strconv.Itoa(os.Getpid())}}}
+		}
+		data, _ := json.Marshal(result)
+		out, _ := json.Marshal(jsonRPCResponse{JSONRPC: "2.0", ID: &req.ID, Result: data})
+		os.Stdout.Write(append(out, '\n'))
+	}
+	os.Exit(0)
+}
+
+func TestMCPClient_ReconnectsAfterServerDies(t *testing.T) {
+	ctx := context.Background()
+	clients, err := RegisterMCPTools(ctx, NewRegistry(), []MCPServerConfig{{
+		Name:      "helper",
+		Transport: "stdio",
+		Command:   os.Args[0],
+		Args:      []string{"-test.run=^TestMCPHelperServer$"},
+		Env:       append(os.Environ(), "H1V3_MCP_HELPER=1"),
+	}})
+	if err != nil {
+		t.Fatalf("RegisterMCPTools: %v", err)
+	}
+	client := clients[0]
+	defer client.Close()
+	transport := client.transport.(*StdioTransport)
+
+	first, err := client.CallTool(ctx, "pid", nil)
+	if err != nil {
+		t.Fatalf("first call: %v", err)
+	}
+	transport.current().cmd.Process.Kill()
+
+	second, err := client.CallTool(ctx, "pid", nil)
+	if err != nil {
+		t.Fatalf("call after crash: %v", err)
+	}
+	if second == first {
+		t.Errorf("expected a new server process, still talking to PID %s", first)
+	}
+
+	// With restarts disabled the failure reaches the caller.
+	client.maxReconnects = 0
+	transport.current().cmd.Process.Kill()
+	if _, err := client.CallTool(ctx, "pid", nil); !errors.Is(err, errMCPDisconnected) {
+		t.Errorf("expected a disconnected error, got %v", err)
+	}
+}
+
+func TestStdioTransport_RestartKillsHungServer(t *testing.T) {
+	transport, err := NewStdioTransport(context.Background(), os.Args[0],
+		[]string{"-test.run=^TestMCPHelperServer$"}, append(os.Environ(), "H1V3_MCP_HELPER=hang"))
+	if err != nil {
+		t.Fatalf("NewStdioTransport: %v", err)
+	}
+	old := transport.current()
+	t.Cleanup(func() {
+		transport.current().cmd.Process.Kill()
+		transport.Close()
+	})
+
+	deadline := time.Now().Add(5 * time.Second)
+	for old.broken() == nil {
+		if time.Now().After(deadline) {
+			t.Fatal("connection never broke after the server closed stdout")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := transport.Restart()
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("restart: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("restart hung waiting on a server that does not exit")
+	}
+	if transport.current() == old {
+		t.Error("expected a new connection after restart")
+	}
+	if old.cmd.ProcessState == nil {
+		t.Error("expected the old process to be reaped")
+	}
+}
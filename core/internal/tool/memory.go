@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"sort"
 	"strings"
+	"time"
 
 	"github.com/h1v3-io/h1v3/internal/memory"
 )
@@ -46,8 +47,10 @@ type WriteMemoryTool struct {
 	Store *memory.Store
 }
 
-func (t *WriteMemoryTool) Name() string        { return "write_memory" }
-func (t *WriteMemoryTool) Description() string { return "Write content to a memory scope, replacing any existing content." }
+func (t *WriteMemoryTool) Name() string { return "write_memory" }
+func (t *WriteMemoryTool) Description() string {
+	return "Write content to a memory scope, replacing any existing content. Set ttl_seconds for notes that should expire."
+}
 func (t *WriteMemoryTool) Parameters() map[string]any {
 	return map[string]any{
 		"type":     "object",
@@ -61,6 +64,10 @@ func (t *WriteMemoryTool) Parameters() map[string]any {
 				"type":        "string",
 				"description": "The content to store.",
 			},
+			"ttl_seconds": map[string]any{
+				"type":        "integer",
+				"description": "Optional lifetime in seconds; the scope is forgotten after it. Omit to keep it until deleted.",
+			},
 		},
 	}
 }
@@ -74,9 +81,19 @@ func (t *WriteMemoryTool) Execute(_ context.Context, params map[string]any) (str
 	if content == "" {
 		return "", fmt.Errorf("content is required")
 	}
-	if err := t.Store.Set(scope, content); err != nil {
+	var ttl time.Duration
+	if s, ok := params["ttl_seconds"].(float64); ok {
+		if s < 0 {
+			return "", fmt.Errorf("write_memory: ttl_seconds must not be negative")
+		}
+		ttl = time.Duration(s) * time.Second
+	}
+	if err := t.Store.SetWithTTL(scope, content, ttl); err != nil {
 		return "", fmt.Errorf("write_memory: %w", err)
 	}
+	if ttl > 0 {
+		return fmt.Sprintf("Memory scope %q updated (%d bytes), expires in %s.", scope, len(content), ttl), nil
+	}
 	return fmt.Sprintf("Memory scope %q updated (%d bytes).", scope, len(content)), nil
 }
 
@@ -113,6 +130,103 @@ func (t *ListMemoryTool) Execute(_ context.Context, _ map[string]any) (string, e
 	return b.String(), nil
 }
 
+// SearchMemoryTool finds memory scopes by words in their name or content.
+type SearchMemoryTool struct {
+	Store *memory.Store
+}
+
+func (t *SearchMemoryTool) Name() string { return "search_memory" }
+func (t *SearchMemoryTool) Description() string {
+	return "Search memory scope names and content for words (case-insensitive); returns matching scopes with a snippet."
+}
+func (t *SearchMemoryTool) Parameters() map[string]any {
+	return map[string]any{
+		"type":     "object",
+		"required": []string{"query"},
+		"properties": map[string]any{
+			"query": map[string]any{
+				"type":        "string",
+				"description": "Words to look for; a scope matches when it contains all of them.",
+			},
+		},
+	}
+}
+
+func (t *SearchMemoryTool) Execute(_ context.Context, params map[string]any) (string, error) {
+	query, _ := params["query"].(string)
+	if strings.TrimSpace(query) == "" {
+		return "", fmt.Errorf("query is required")
+	}
+	hits := t.Store.Search(query)
+	if len(hits) == 0 {
+		return fmt.Sprintf("No memory scopes match %q.", query), nil
+	}
+
+	var b strings.Builder
+	for _, h := range hits {
+		fmt.Fprintf(&b, "- %s: %s\n", h.Scope, h.Snippet)
+	}
+	if len(hits) == memory.MaxHits {
+		fmt.Fprintf(&b, "(showing the first %d; narrow the query for more)\n", memory.MaxHits)
+	}
+	return b.String(), nil
+}
+
+// defaultSemanticHits is how many notes semantic_search_memory returns
+// unless asked for another number.
+const defaultSemanticHits = 5
+
+// SemanticSearchMemoryTool finds the memory scopes closest in meaning to a
+// query, by embedding similarity.
+type SemanticSearchMemoryTool struct {
+	Vectors *memory.VectorStore
+}
+
+func (t *SemanticSearchMemoryTool) Name() string { return "semantic_search_memory" }
+func (t *SemanticSearchMemoryTool) Description() string {
+	return "Find memory scopes by meaning rather than exact words; returns the closest scopes with a similarity score and snippet."
+}
+func (t *SemanticSearchMemoryTool) Parameters() map[string]any {
+	return map[string]any{
+		"type":     "object",
+		"required": []string{"query"},
+		"properties": map[string]any{
+			"query": map[string]any{
+				"type":        "string",
+				"description": "What you are looking for, in your own words.",
+			},
+			"limit": map[string]any{
+				"type":        "integer",
+				"description": fmt.Sprintf("Number of scopes to return (default %d, max %d).", defaultSemanticHits, memory.MaxHits),
+			},
+		},
+	}
+}
+
+func (t *SemanticSearchMemoryTool) Execute(ctx context.Context, params map[string]any) (string, error) {
+	query, _ := params["query"].(string)
+	if strings.TrimSpace(query) == "" {
+		return "", fmt.Errorf("query is required")
+	}
+	k := defaultSemanticHits
+	if l, ok := params["limit"].(float64); ok && l > 0 {
+		k = min(int(l), memory.MaxHits)
+	}
+	matches, err := t.Vectors.Search(ctx, query, k)
+	if err != nil {
+		return "", fmt.Errorf("semantic_search_memory: %w", err)
+	}
+	if len(matches) == 0 {
+		return "Memory is empty.", nil
+	}
+
+	var b strings.Builder
+	for _, m := range matches {
+		fmt.Fprintf(&b, "- %s (%.2f): %s\n", m.Scope, m.Score, m.Snippet)
+	}
+	return b.String(), nil
+}
+
 // DeleteMemoryTool removes a memory scope.
 type DeleteMemoryTool struct {
 	Store *memory.Store
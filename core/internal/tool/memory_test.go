@@ -71,6 +71,30 @@ func TestWriteMemory(t *testing.T) {
 	}
 }
 
+func TestWriteMemory_TTL(t *testing.T) {
+	store := newTestMemoryStore(t)
+	tool := &WriteMemoryTool{Store: store}
+
+	got, err := tool.Execute(context.Background(), map[string]any{
+		"scope":       "oncall",
+		"content":     "alice this week",
+		"ttl_seconds": float64(3600),
+	})
+	if err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	if !strings.Contains(got, "expires in 1h0m0s") {
+		t.Errorf("expected the expiry in the confirmation, got %q", got)
+	}
+	if v := store.Get("oncall"); v != "alice this week" {
+		t.Errorf("stored = %q", v)
+	}
+
+	if _, err := tool.Execute(context.Background(), map[string]any{"scope": "oncall", "content": "x", "ttl_seconds": float64(-1)}); err == nil {
+		t.Error("expected an error for a negative ttl")
+	}
+}
+
 func TestWriteMemory_NoContent(t *testing.T) {
 	store := newTestMemoryStore(t)
 	tool := &WriteMemoryTool{Store: store}
@@ -113,6 +137,57 @@ func TestListMemory_Empty(t *testing.T) {
 	}
 }
 
+func TestSearchMemory(t *testing.T) {
+	store := newTestMemoryStore(t)
+	store.Set("deployment", "Use blue/green.")
+	store.Set("project", "We deploy with Argo CD.")
+	store.Set("team", "Alice owns billing.")
+	tool := &SearchMemoryTool{Store: store}
+
+	got, err := tool.Execute(context.Background(), map[string]any{"query": "Deploy"})
+	if err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	if want := "- deployment: Use blue/green.\n- project: We deploy with Argo CD.\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	got, _ = tool.Execute(context.Background(), map[string]any{"query": "kubernetes"})
+	if !strings.Contains(got, "No memory scopes match") {
+		t.Errorf("expected no matches, got %q", got)
+	}
+}
+
+func TestSemanticSearchMemory(t *testing.T) {
+	store := newTestMemoryStore(t)
+	store.Set("deployment", "Use blue/green.")
+	store.Set("team", "Alice owns billing.")
+	// Notes about billing point one way, everything else the other.
+	embed := func(_ context.Context, texts []string) ([][]float32, error) {
+		out := make([][]float32, len(texts))
+		for i, text := range texts {
+			if strings.Contains(text, "billing") || strings.Contains(text, "invoices") {
+				out[i] = []float32{1, 0}
+			} else {
+				out[i] = []float32{0, 1}
+			}
+		}
+		return out, nil
+	}
+	tool := &SemanticSearchMemoryTool{Vectors: memory.NewVectorStore(store, embed)}
+
+	got, err := tool.Execute(context.Background(), map[string]any{"query": "who sends invoices?", "limit": float64(1)})
+	if err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	if want := "- team (1.00): Alice owns billing.\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if _, err := tool.Execute(context.Background(), map[string]any{"query": " "}); err == nil {
+		t.Error("expected an error for an empty query")
+	}
+}
+
 func TestDeleteMemory(t *testing.T) {
 	store := newTestMemoryStore(t)
 	store.Set("temp", "data")
@@ -0,0 +1,157 @@
+package tool
+
+import (
+	"bytes"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+)
+
+// maxLogLine bounds a streamed log line; longer lines are logged in pieces.
+const maxLogLine = 4096
+
+// outputCapture collects a command's stdout and stderr for the model. Each
+// stream keeps its first and last limit/2 bytes, so memory stays bounded no
+// matter how much a command prints; Render then fits both streams into limit
+// bytes. With a logger set, every complete line is also logged as it arrives.
+type outputCapture struct {
+	limit  int
+	logger *slog.Logger
+
+	mu     sync.Mutex
+	stdout streamBuffer
+	stderr streamBuffer
+}
+
+func newOutputCapture(limit int, logger *slog.Logger) *outputCapture {
+	c := &outputCapture{limit: limit, logger: logger}
+	keep := (limit + 1) / 2
+	c.stdout = streamBuffer{name: "stdout", keep: keep}
+	c.stderr = streamBuffer{name: "stderr", keep: keep}
+	return c
+}
+
+// Stdout and Stderr return the writers to hand to exec.Cmd.
+func (c *outputCapture) Stdout() *captureWriter { return &captureWriter{c, &c.stdout} }
+func (c *outputCapture) Stderr() *captureWriter { return &captureWriter{c, &c.stderr} }
+
+type captureWriter struct {
+	c *outputCapture
+	s *streamBuffer
+}
+
+func (w *captureWriter) Write(p []byte) (int, error) {
+	w.c.mu.Lock()
+	defer w.c.mu.Unlock()
+	w.s.write(p)
+	if w.c.logger != nil {
+		w.s.line = append(w.s.line, p...)
+		for {
+			i := bytes.IndexByte(w.s.line, '\n')
+			if i < 0 && len(w.s.line) < maxLogLine {
+				break
+			}
+			if i < 0 {
+				i = maxLogLine
+			}
+			w.c.logger.Info("exec output", "stream", w.s.name, "line", string(w.s.line[:i]))
+			w.s.line = w.s.line[min(i+1, len(w.s.line)):]
+		}
+	}
+	return len(p), nil
+}
+
+// flushLogs logs any trailing partial lines once the command has exited.
+func (c *outputCapture) flushLogs() {
+	if c.logger == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, s := range []*streamBuffer{&c.stdout, &c.stderr} {
+		if len(s.line) > 0 {
+			c.logger.Info("exec output", "stream", s.name, "line", string(s.line))
+			s.line = nil
+		}
+	}
+}
+
+// Render formats the captured output with a section per non-empty stream
+// and the exit code. When the streams together exceed the limit, each is
+// cut in the middle with a note saying how much was left out; a stream that
+// needs less than half the limit leaves the rest to the other.
+func (c *outputCapture) Render(exitCode int) string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	outBudget, errBudget := c.stdout.total, c.stderr.total
+	if outBudget+errBudget > c.limit {
+		half := c.limit / 2
+		outBudget, errBudget = min(outBudget, half), min(errBudget, half)
+		if spare := c.limit - outBudget - errBudget; spare > 0 {
+			if c.stdout.total > outBudget {
+				outBudget = min(c.stdout.total, outBudget+spare)
+			} else {
+				errBudget = min(c.stderr.total, errBudget+spare)
+			}
+		}
+	}
+
+	var b strings.Builder
+	for _, sec := range []struct {
+		s      *streamBuffer
+		budget int
+	}{{&c.stdout, outBudget}, {&c.stderr, errBudget}} {
+		if sec.s.total == 0 {
+			continue
+		}
+		fmt.Fprintf(&b, "[%s]\n", sec.s.name)
+		b.WriteString(sec.s.render(sec.budget))
+		if !strings.HasSuffix(b.String(), "\n") {
+			b.WriteString("\n")
+		}
+	}
+	if b.Len() == 0 {
+		b.WriteString("(no output)\n")
+	}
+	fmt.Fprintf(&b, "[exit code %d]", exitCode)
+	return b.String()
+}
+
+// streamBuffer keeps the first and last keep bytes written to one stream and
+// counts the total.
+type streamBuffer struct {
+	name  string
+	keep  int
+	head  []byte
+	tail  []byte // the most recent bytes past the head, at most keep long
+	total int
+	line  []byte // pending partial line for log streaming
+}
+
+func (s *streamBuffer) write(p []byte) {
+	s.total += len(p)
+	if n := min(s.keep-len(s.head), len(p)); n > 0 {
+		s.head = append(s.head, p[:n]...)
+		p = p[n:]
+	}
+	if len(p) == 0 {
+		return
+	}
+	s.tail = append(s.tail, p...)
+	if over := len(s.tail) - s.keep; over > 0 {
+		s.tail = append(s.tail[:0], s.tail[over:]...)
+	}
+}
+
+// render returns the stream cut down to budget bytes, eliding the middle.
+func (s *streamBuffer) render(budget int) string {
+	if s.total <= budget {
+		return string(s.head) + string(s.tail)
+	}
+	headN := min(budget/2, len(s.head))
+	tailN := min(budget-headN, len(s.tail))
+	return fmt.Sprintf("%s\n... [%d bytes truncated] ...\n%s",
+		s.head[:headN], s.total-headN-tailN, s.tail[len(s.tail)-tailN:])
+}
@@ -0,0 +1,407 @@
+package tool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// --- ApplyPatch ---
+
+// ApplyPatchTool applies a unified diff to files under AllowedDir. Every hunk
+// of every file is checked against the current contents before anything is
+// written, so a patch either applies in full or not at all. Hunks may sit at
+// other line numbers than the header says (as when earlier edits shifted the
+// file); their context lines must still match exactly.
+type ApplyPatchTool struct {
+	AllowedDir string
+	Quota      *DiskQuota // optional cap on bytes kept under AllowedDir
+}
+
+func (t *ApplyPatchTool) Name() string { return "apply_patch" }
+func (t *ApplyPatchTool) Description() string {
+	return "Apply a unified diff (--- a/file, +++ b/file, @@ hunks) to one or more files. All hunks must match or nothing is written; use /dev/null to create or delete a file"
+}
+func (t *ApplyPatchTool) Parameters() map[string]any {
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"patch": map[string]any{"type": "string", "description": "Unified diff; paths are relative to the working directory"},
+		},
+		"required": []string{"patch"},
+	}
+}
+
+func (t *ApplyPatchTool) Execute(_ context.Context, params map[string]any) (string, error) {
+	files, err := parsePatch(getString(params, "patch"))
+	if err != nil {
+		return "", fmt.Errorf("apply_patch: %w", err)
+	}
+
+	// Work out every file's new contents first; a file patched twice sees
+	// the result of the first patch.
+	type change struct {
+		path    string
+		old     []byte
+		existed bool
+		new     string
+		del     bool
+		summary string
+	}
+	var changes []*change
+	byPath := map[string]*change{}
+	for _, f := range files {
+		name := f.newName
+		if f.deletes() {
+			name = f.oldName
+		}
+		path, err := t.resolve(name)
+		if err != nil {
+			return "", fmt.Errorf("apply_patch: %w", err)
+		}
+		c := byPath[path]
+		if c == nil {
+			c = &change{path: path}
+			data, err := os.ReadFile(path)
+			switch {
+			case err == nil:
+				c.old, c.existed = data, true
+			case !errors.Is(err, fs.ErrNotExist):
+				return "", fmt.Errorf("apply_patch: %s: %w", name, err)
+			}
+			c.new, c.del = string(c.old), !c.existed
+			byPath[path] = c
+			changes = append(changes, c)
+		}
+
+		switch {
+		case f.creates() && !c.del:
+			return "", fmt.Errorf("apply_patch: %s already exists; nothing was written", name)
+		case !f.creates() && c.del:
+			return "", fmt.Errorf("apply_patch: %s does not exist; nothing was written", name)
+		}
+		result, err := f.apply(c.new)
+		if err != nil {
+			return "", fmt.Errorf("apply_patch: %s: %w; nothing was written", name, err)
+		}
+		c.new, c.del = result, f.deletes()
+		switch {
+		case f.deletes():
+			c.summary = "Deleted " + name
+		case !c.existed:
+			c.summary = "Created " + name
+		default:
+			c.summary = fmt.Sprintf("Patched %s (%d hunks)", name, len(f.hunks))
+			if len(f.hunks) == 1 {
+				c.summary = fmt.Sprintf("Patched %s (1 hunk)", name)
+			}
+		}
+	}
+
+	// Write, undoing earlier files if a later one fails.
+	var done []*change
+	undo := func() {
+		for _, c := range done {
+			if c.existed {
+				os.WriteFile(c.path, c.old, 0o644)
+			} else {
+				os.Remove(c.path)
+			}
+			t.Quota.Reserve(int64(len(c.new)), int64(len(c.old)))
+		}
+	}
+	for _, c := range changes {
+		if c.del && !c.existed {
+			continue // created and deleted again within the patch
+		}
+		newSize := int64(len(c.new))
+		if c.del {
+			newSize = 0
+		}
+		if err := t.Quota.Reserve(int64(len(c.old)), newSize); err != nil {
+			undo()
+			return "", fmt.Errorf("apply_patch: %w", err)
+		}
+		if err := writePatched(c.path, c.new, c.del); err != nil {
+			t.Quota.Reserve(newSize, int64(len(c.old)))
+			undo()
+			return "", fmt.Errorf("apply_patch: %w", err)
+		}
+		if c.del {
+			c.new = ""
+		}
+		done = append(done, c)
+	}
+
+	var b strings.Builder
+	for i, c := range changes {
+		if i > 0 {
+			b.WriteByte('\n')
+		}
+		b.WriteString(c.summary)
+	}
+	return b.String(), nil
+}
+
+// resolve maps a patch path to a checked absolute path. Relative paths are
+// taken from AllowedDir, since that is what diffs of the workspace contain.
+func (t *ApplyPatchTool) resolve(name string) (string, error) {
+	if !filepath.IsAbs(name) && t.AllowedDir != "" {
+		name = filepath.Join(t.AllowedDir, name)
+	}
+	return checkPath(name, t.AllowedDir)
+}
+
+func writePatched(path, content string, del bool) error {
+	if del {
+		return os.Remove(path)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("create dirs: %w", err)
+	}
+	return os.WriteFile(path, []byte(content), 0o644)
+}
+
+// filePatch is one file's section of a unified diff.
+type filePatch struct {
+	oldName, newName string // "" for /dev/null
+	hunks            []hunk
+}
+
+func (f *filePatch) creates() bool { return f.oldName == "" }
+func (f *filePatch) deletes() bool { return f.newName == "" }
+
+// hunk is one @@ section. Lines keep their ' ', '-' or '+' prefix.
+type hunk struct {
+	header   string
+	oldStart int
+	lines    []string
+
+	// oldNoEOL and newNoEOL are set by "\ No newline at end of file"
+	// after the last line of that side.
+	oldNoEOL, newNoEOL bool
+}
+
+var hunkHeader = regexp.MustCompile(`^@@ -(\d+)(?:,(\d+))? \+(\d+)(?:,(\d+))? @@`)
+
+// parsePatch splits a unified diff into files and hunks. Lines outside
+// them, such as "diff --git" and "index" lines, are ignored.
+func parsePatch(patch string) ([]*filePatch, error) {
+	lines := strings.Split(strings.ReplaceAll(patch, "\r\n", "\n"), "\n")
+	var files []*filePatch
+	for i := 0; i < len(lines); i++ {
+		if !strings.HasPrefix(lines[i], "--- ") {
+			continue
+		}
+		if i+1 >= len(lines) || !strings.HasPrefix(lines[i+1], "+++ ") {
+			return nil, fmt.Errorf("line %d: \"---\" header without a \"+++\" line", i+1)
+		}
+		f := &filePatch{oldName: patchPath(lines[i][4:]), newName: patchPath(lines[i+1][4:])}
+		if f.oldName == "" && f.newName == "" {
+			return nil, fmt.Errorf("line %d: both sides are /dev/null", i+1)
+		}
+		i += 2
+		for i < len(lines) && strings.HasPrefix(lines[i], "@@") {
+			h, next, err := parseHunk(lines, i)
+			if err != nil {
+				return nil, err
+			}
+			f.hunks = append(f.hunks, h)
+			i = next
+		}
+		if len(f.hunks) == 0 {
+			return nil, fmt.Errorf("%s: no hunks", displayName(f))
+		}
+		files = append(files, f)
+		i-- // the loop's increment moves back onto the next line
+	}
+	if len(files) == 0 {
+		return nil, errors.New("no file headers (\"--- a/file\" and \"+++ b/file\") found")
+	}
+	return files, nil
+}
+
+// parseHunk reads the hunk starting at lines[i] and returns the index of
+// the line after it.
+func parseHunk(lines []string, i int) (hunk, int, error) {
+	m := hunkHeader.FindStringSubmatch(lines[i])
+	if m == nil {
+		return hunk{}, 0, fmt.Errorf("line %d: malformed hunk header %q", i+1, lines[i])
+	}
+	h := hunk{header: m[0]}
+	h.oldStart, _ = strconv.Atoi(m[1])
+	oldCount, newCount := hunkCount(m[2]), hunkCount(m[4])
+
+	i++
+	var last byte
+	noEOL := func() {
+		switch last {
+		case '-':
+			h.oldNoEOL = true
+		case '+':
+			h.newNoEOL = true
+		case ' ':
+			h.oldNoEOL, h.newNoEOL = true, true
+		}
+	}
+	for oldCount > 0 || newCount > 0 {
+		if i >= len(lines) {
+			return hunk{}, 0, fmt.Errorf("hunk %s: ends early", h.header)
+		}
+		line := lines[i]
+		if line == "" {
+			line = " " // editors often strip the space of an empty context line
+		}
+		switch line[0] {
+		case ' ':
+			oldCount--
+			newCount--
+		case '-':
+			oldCount--
+		case '+':
+			newCount--
+		case '\\':
+			noEOL()
+			i++
+			continue
+		default:
+			return hunk{}, 0, fmt.Errorf("line %d: unexpected %q in hunk %s", i+1, lines[i], h.header)
+		}
+		if oldCount < 0 || newCount < 0 {
+			return hunk{}, 0, fmt.Errorf("hunk %s: more lines than its header counts", h.header)
+		}
+		h.lines = append(h.lines, line)
+		last = line[0]
+		i++
+	}
+	if i < len(lines) && strings.HasPrefix(lines[i], `\`) {
+		noEOL()
+		i++
+	}
+	return h, i, nil
+}
+
+func hunkCount(s string) int {
+	if s == "" {
+		return 1
+	}
+	n, _ := strconv.Atoi(s)
+	return n
+}
+
+// patchPath strips a header's timestamp and its a/ or b/ prefix; /dev/null
+// becomes "".
+func patchPath(s string) string {
+	if i := strings.IndexByte(s, '\t'); i >= 0 {
+		s = s[:i]
+	}
+	s = strings.TrimSpace(s)
+	if s == "/dev/null" {
+		return ""
+	}
+	if strings.HasPrefix(s, "a/") || strings.HasPrefix(s, "b/") {
+		s = s[2:]
+	}
+	return s
+}
+
+func displayName(f *filePatch) string {
+	if f.newName != "" {
+		return f.newName
+	}
+	return f.oldName
+}
+
+// apply returns content with the file's hunks applied in order.
+func (f *filePatch) apply(content string) (string, error) {
+	lines := strings.Split(content, "\n")
+	eol := content == "" || strings.HasSuffix(content, "\n")
+	if eol {
+		lines = lines[:len(lines)-1]
+	}
+
+	pos := 0    // hunks apply in order, never before the previous one's end
+	offset := 0 // how far earlier hunks moved the lines below them
+	for n, h := range f.hunks {
+		var old, repl []string
+		for _, l := range h.lines {
+			if l[0] != '+' {
+				old = append(old, l[1:])
+			}
+			if l[0] != '-' {
+				repl = append(repl, l[1:])
+			}
+		}
+		// Header line numbers refer to the original file, so shift them by
+		// what the earlier hunks added, removed or were found away from.
+		want := h.oldStart - 1 + offset
+		var at int
+		if len(old) == 0 {
+			// A pure insertion goes after line oldStart.
+			want++
+			at = min(max(want, pos), len(lines))
+		} else if at = findHunk(lines, old, pos, want); at < 0 {
+			return "", fmt.Errorf("hunk %d (%s) does not match the file", n+1, h.header)
+		}
+		touchesEnd := at+len(old) == len(lines)
+		lines = append(lines[:at:at], append(repl, lines[at+len(old):]...)...)
+		pos = at + len(repl)
+		offset += at - want + len(repl) - len(old)
+		// The final newline follows the new side's marker; without one, a
+		// hunk that removed the old side's marker adds it back.
+		if touchesEnd && h.newNoEOL {
+			eol = false
+		} else if touchesEnd && h.oldNoEOL {
+			eol = true
+		}
+	}
+
+	if len(lines) == 0 {
+		return "", nil
+	}
+	out := strings.Join(lines, "\n")
+	if eol {
+		out += "\n"
+	}
+	return out, nil
+}
+
+// findHunk returns where old occurs in lines at or after from, preferring
+// the position nearest want, or -1.
+func findHunk(lines, old []string, from, want int) int {
+	best := -1
+	for i := from; i+len(old) <= len(lines); i++ {
+		if !linesEqual(lines[i:i+len(old)], old) {
+			continue
+		}
+		if best < 0 || abs(i-want) < abs(best-want) {
+			best = i
+		}
+		if i >= want {
+			break // later matches are only further away
+		}
+	}
+	return best
+}
+
+func linesEqual(a, b []string) bool {
+	for i := range b {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,154 @@
+package tool
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestApplyPatch_MultiHunk(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "main.go"), []byte("package main\n\nfunc a() {\n\treturn\n}\n\nfunc b() {}\n\nfunc c() {\n\treturn\n}\n"), 0o644)
+	os.MkdirAll(filepath.Join(dir, "docs"), 0o755)
+	os.WriteFile(filepath.Join(dir, "docs", "old.md"), []byte("gone\n"), 0o644)
+
+	// The second hunk's line number is off by two, as in hand-written diffs;
+	// "return" appears twice, which edit_file could not target.
+	patch := `diff --git a/main.go b/main.go
+--- a/main.go
++++ b/main.go
+@@ -3,3 +3,3 @@
+ func a() {
+-	return
++	return // a
+ }
+@@ -11,3 +11,4 @@ func b() {}
+ func c() {
+-	return
++	log()
++	return // c
+ }
+--- /dev/null
++++ b/docs/new.md
+@@ -0,0 +1,2 @@
++# New
++text
+--- a/docs/old.md
++++ /dev/null
+@@ -1 +0,0 @@
+-gone
+`
+	tool := &ApplyPatchTool{AllowedDir: dir}
+	result, err := tool.Execute(context.Background(), map[string]any{"patch": patch})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := "Patched main.go (2 hunks)\nCreated docs/new.md\nDeleted docs/old.md"; result != want {
+		t.Errorf("result = %q, want %q", result, want)
+	}
+
+	got, _ := os.ReadFile(filepath.Join(dir, "main.go"))
+	if want := "package main\n\nfunc a() {\n\treturn // a\n}\n\nfunc b() {}\n\nfunc c() {\n\tlog()\n\treturn // c\n}\n"; string(got) != want {
+		t.Errorf("main.go = %q", got)
+	}
+	if got, _ := os.ReadFile(filepath.Join(dir, "docs", "new.md")); string(got) != "# New\ntext\n" {
+		t.Errorf("new.md = %q", got)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "docs", "old.md")); !os.IsNotExist(err) {
+		t.Errorf("expected old.md deleted, got %v", err)
+	}
+}
+
+func TestApplyPatch_InsertionAfterEarlierHunk(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "list.txt"), []byte("one\ntwo\nthree\nfour\nfive\nsix\n"), 0o644)
+
+	// The first hunk adds two lines, so the insertion after original line 5
+	// lands two lines further down in the patched file.
+	patch := `--- a/list.txt
++++ b/list.txt
+@@ -1,2 +1,4 @@
+ one
++one and a bit
++one and a half
+ two
+@@ -5,0 +8,1 @@
++five and a half
+`
+	tool := &ApplyPatchTool{AllowedDir: dir}
+	if _, err := tool.Execute(context.Background(), map[string]any{"patch": patch}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got, _ := os.ReadFile(filepath.Join(dir, "list.txt"))
+	if want := "one\none and a bit\none and a half\ntwo\nthree\nfour\nfive\nfive and a half\nsix\n"; string(got) != want {
+		t.Errorf("list.txt = %q, want %q", got, want)
+	}
+}
+
+func TestApplyPatch_StaleContextWritesNothing(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "a.txt"), []byte("one\ntwo\nthree\n"), 0o644)
+	os.WriteFile(filepath.Join(dir, "b.txt"), []byte("alpha\nbeta\n"), 0o644)
+
+	// a.txt applies; b.txt's context has since changed.
+	patch := `--- a/a.txt
++++ b/a.txt
+@@ -1,3 +1,3 @@
+ one
+-two
++TWO
+ three
+--- a/b.txt
++++ b/b.txt
+@@ -1,2 +1,2 @@
+ alpha
+-gamma
++delta
+`
+	tool := &ApplyPatchTool{AllowedDir: dir}
+	_, err := tool.Execute(context.Background(), map[string]any{"patch": patch})
+	if err == nil || !strings.Contains(err.Error(), "b.txt: hunk 1") || !strings.Contains(err.Error(), "nothing was written") {
+		t.Fatalf("expected a stale-context error for b.txt, got %v", err)
+	}
+	if got, _ := os.ReadFile(filepath.Join(dir, "a.txt")); string(got) != "one\ntwo\nthree\n" {
+		t.Errorf("a.txt was modified: %q", got)
+	}
+}
+
+func TestApplyPatch_NoNewlineAtEOF(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "f.txt")
+	os.WriteFile(path, []byte("a\nb"), 0o644)
+
+	patch := "--- a/f.txt\n+++ b/f.txt\n@@ -1,2 +1,3 @@\n a\n-b\n\\ No newline at end of file\n+b\n+c\n"
+	tool := &ApplyPatchTool{AllowedDir: dir}
+	if _, err := tool.Execute(context.Background(), map[string]any{"patch": patch}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got, _ := os.ReadFile(path); string(got) != "a\nb\nc\n" {
+		t.Errorf("f.txt = %q", got)
+	}
+}
+
+func TestApplyPatch_Rejects(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "f.txt"), []byte("x\n"), 0o644)
+	tool := &ApplyPatchTool{AllowedDir: dir}
+
+	for name, patch := range map[string]string{
+		"outside dir": "--- a/../evil.txt\n+++ b/../evil.txt\n@@ -0,0 +1 @@\n+x\n",
+		"no headers":  "@@ -1 +1 @@\n-x\n+y\n",
+		"create over": "--- /dev/null\n+++ b/f.txt\n@@ -0,0 +1 @@\n+y\n",
+		"missing":     "--- a/nope.txt\n+++ b/nope.txt\n@@ -1 +1 @@\n-x\n+y\n",
+		"short hunk":  "--- a/f.txt\n+++ b/f.txt\n@@ -1,2 +1,2 @@\n-x\n",
+	} {
+		if _, err := tool.Execute(context.Background(), map[string]any{"patch": patch}); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+	if got, _ := os.ReadFile(filepath.Join(dir, "f.txt")); string(got) != "x\n" {
+		t.Errorf("f.txt was modified: %q", got)
+	}
+}
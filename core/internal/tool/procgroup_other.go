@@ -0,0 +1,9 @@
+//go:build !unix
+
+package tool
+
+import "os/exec"
+
+// killProcessGroup is a no-op without Unix process groups; only the command
+// itself is killed when its context ends.
+func killProcessGroup(cmd *exec.Cmd) {}
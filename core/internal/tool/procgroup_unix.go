@@ -0,0 +1,19 @@
+//go:build unix
+
+package tool
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// killProcessGroup makes cmd lead its own process group and, when its
+// context ends, kills the whole group, so children a shell started (a
+// build, a sleep in a pipeline) die with it instead of holding its output
+// open.
+func killProcessGroup(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+}
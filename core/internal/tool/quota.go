@@ -0,0 +1,80 @@
+package tool
+
+import (
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// defaultQuotaRefresh is how long DiskQuota trusts its tracked usage before
+// walking the workspace again.
+const defaultQuotaRefresh = time.Minute
+
+// DiskQuota caps how many bytes an agent may keep under its workspace. Usage
+// is measured by walking Dir at most once per Refresh and adjusted by the
+// writes the file tools make in between, so a write never stats the whole
+// tree. Changes made outside the tools (exec, other processes) are picked up
+// on the next walk. A nil *DiskQuota allows everything.
+type DiskQuota struct {
+	Dir     string
+	Limit   int64         // bytes
+	Refresh time.Duration // 0 = one minute
+
+	mu      sync.Mutex
+	used    int64
+	scanned time.Time
+}
+
+// NewDiskQuota returns a quota of limit bytes over dir.
+func NewDiskQuota(dir string, limit int64) *DiskQuota {
+	return &DiskQuota{Dir: dir, Limit: limit}
+}
+
+// Reserve records that a file of oldSize bytes is being replaced by one of
+// newSize bytes, refusing if that would push usage over the limit. Shrinking
+// is always allowed, so a failed write can be undone with
+// Reserve(newSize, oldSize).
+func (q *DiskQuota) Reserve(oldSize, newSize int64) error {
+	if q == nil || q.Limit <= 0 {
+		return nil
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	refresh := q.Refresh
+	if refresh <= 0 {
+		refresh = defaultQuotaRefresh
+	}
+	if time.Since(q.scanned) > refresh {
+		q.used = dirSize(q.Dir)
+		q.scanned = time.Now()
+	}
+
+	delta := newSize - oldSize
+	if delta > 0 && q.used+delta > q.Limit {
+		return fmt.Errorf("disk quota exceeded: writing %d more bytes would use %d of %d bytes allowed in %s",
+			delta, q.used+delta, q.Limit, q.Dir)
+	}
+	q.used = max(q.used+delta, 0)
+	return nil
+}
+
+// dirSize sums the sizes of regular files under dir, skipping anything it
+// cannot read.
+func dirSize(dir string) int64 {
+	var total int64
+	filepath.WalkDir(dir, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.Type().IsRegular() {
+			if info, err := d.Info(); err == nil {
+				total += info.Size()
+			}
+		}
+		return nil
+	})
+	return total
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"sync"
+	"time"
 
 	"github.com/h1v3-io/h1v3/pkg/protocol"
 )
@@ -12,6 +13,7 @@ import (
 type Registry struct {
 	mu    sync.RWMutex
 	tools map[string]Tool
+	usage *Usage
 }
 
 // NewRegistry creates an empty tool registry.
@@ -19,6 +21,20 @@ func NewRegistry() *Registry {
 	return &Registry{tools: make(map[string]Tool)}
 }
 
+// SetUsage makes Execute count every call, builtin or MCP, in u.
+func (r *Registry) SetUsage(u *Usage) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.usage = u
+}
+
+// Usage returns the counters set with SetUsage, or nil.
+func (r *Registry) Usage() *Usage {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.usage
+}
+
 // Register adds a tool to the registry.
 func (r *Registry) Register(t Tool) {
 	r.mu.Lock()
@@ -76,16 +92,39 @@ func (r *Registry) Definitions() []protocol.ToolDefinition {
 }
 
 // Execute runs the named tool with the given parameters.
-// Returns the tool output as a string, or an error description.
+// Params are validated against the tool's declared schema first; a mismatch
+// is returned as a *ValidationError without calling the tool.
+// Returns the tool output as a string, or an error description. Calls of
+// registered tools, including rejected ones, are counted in the Usage.
 func (r *Registry) Execute(ctx context.Context, name string, params map[string]any) (string, error) {
 	r.mu.RLock()
 	t, ok := r.tools[name]
+	usage := r.usage
 	r.mu.RUnlock()
 
 	if !ok {
 		return "", fmt.Errorf("tool %q not found", name)
 	}
-	return t.Execute(ctx, params)
+	start := time.Now()
+	if err := ValidateParams(name, t.Parameters(), params); err != nil {
+		usage.Record(name, time.Since(start), err)
+		return "", err
+	}
+	out, err := t.Execute(ctx, params)
+	usage.Record(name, time.Since(start), err)
+	return out, err
+}
+
+// IsSerial reports whether the named tool must run outside the parallel batch.
+func (r *Registry) IsSerial(name string) bool {
+	r.mu.RLock()
+	t, ok := r.tools[name]
+	r.mu.RUnlock()
+	if !ok {
+		return false
+	}
+	s, ok := t.(SerialTool)
+	return ok && s.Serial()
 }
 
 // Len returns the number of registered tools.
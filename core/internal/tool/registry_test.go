@@ -2,6 +2,7 @@ package tool
 
 import (
 	"context"
+	"path/filepath"
 	"testing"
 )
 
@@ -49,6 +50,52 @@ func TestRegistry_ExecuteUnknown(t *testing.T) {
 	}
 }
 
+// strictTool requires a "path" parameter, so calls without one fail validation.
+type strictTool struct{ stubTool }
+
+func (s *strictTool) Parameters() map[string]any {
+	return map[string]any{
+		"type":       "object",
+		"properties": map[string]any{"path": map[string]any{"type": "string"}},
+		"required":   []any{"path"},
+	}
+}
+
+func TestRegistry_Usage(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tool_stats", "coder.json")
+	reg := NewRegistry()
+	reg.Register(&stubTool{name: "echo"})
+	reg.Register(&strictTool{stubTool{name: "read"}})
+	reg.SetUsage(NewUsage(path))
+
+	ctx := context.Background()
+	reg.Execute(ctx, "echo", nil)
+	reg.Execute(ctx, "echo", nil)
+	reg.Execute(ctx, "read", map[string]any{})
+	reg.Execute(ctx, "nope", nil) // unknown tools are not counted
+
+	check := func(stats []ToolStat) {
+		t.Helper()
+		if len(stats) != 2 {
+			t.Fatalf("expected 2 tools, got %+v", stats)
+		}
+		if stats[0].Name != "echo" || stats[0].Calls != 2 || stats[0].Failures != 0 || stats[0].LastUsed.IsZero() {
+			t.Errorf("echo stat = %+v", stats[0])
+		}
+		if stats[1].Name != "read" || stats[1].Calls != 1 || stats[1].Failures != 1 {
+			t.Errorf("read stat = %+v", stats[1])
+		}
+	}
+	check(reg.Usage().Stats())
+	check(NewUsage(path).Stats()) // survives a restart
+
+	var none *Usage
+	none.Record("echo", 0, nil)
+	if none.Stats() != nil {
+		t.Error("nil Usage should record nothing")
+	}
+}
+
 func TestRegistry_Definitions(t *testing.T) {
 	reg := NewRegistry()
 	reg.Register(&stubTool{name: "a", result: ""})
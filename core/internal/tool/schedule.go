@@ -0,0 +1,165 @@
+package tool
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/h1v3-io/h1v3/internal/ticket"
+	"github.com/h1v3-io/h1v3/pkg/protocol"
+)
+
+// MessageScheduler persists scheduled messages. Implemented by the registry.
+type MessageScheduler interface {
+	GetTicket(ticketID string) (*protocol.Ticket, error)
+	ScheduleMessage(s ticket.Schedule) (*ticket.Schedule, error)
+	CancelSchedule(id, by string) error
+}
+
+// --- ScheduleTool ---
+
+// ScheduleTool schedules a future _system message on a ticket, once or on a
+// recurring cron schedule. Like respond_to_ticket, it is only for the
+// ticket's creator and assignees, and only wakes the ticket's participants.
+type ScheduleTool struct {
+	Scheduler MessageScheduler
+	AgentID   string
+	Agents    AgentLister // optional; validates "to" when set
+}
+
+func (t *ScheduleTool) Name() string { return "schedule" }
+func (t *ScheduleTool) Description() string {
+	return "Schedule a reminder message on a ticket, once (after a delay or at a time) or recurring (cron). The message wakes the recipient when it fires. Returns a schedule ID for cancel_schedule."
+}
+func (t *ScheduleTool) Parameters() map[string]any {
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"message":   map[string]any{"type": "string", "description": "Message to deliver when the schedule fires"},
+			"delay":     map[string]any{"type": "string", "description": "Fire once after this duration, e.g. \"30m\" or \"2h\""},
+			"at":        map[string]any{"type": "string", "description": "Fire once at this RFC 3339 time, e.g. \"2025-01-02T09:00:00Z\""},
+			"cron":      map[string]any{"type": "string", "description": "Recur on this cron schedule (5 fields, or @daily, @every 1h, ...)"},
+			"ticket_id": map[string]any{"type": "string", "description": "Ticket to post on (default: current ticket)"},
+			"to":        map[string]any{"type": "string", "description": "Agent to wake (default: yourself)"},
+		},
+		"required": []string{"message"},
+	}
+}
+
+func (t *ScheduleTool) Execute(ctx context.Context, params map[string]any) (string, error) {
+	message := getString(params, "message")
+	if message == "" {
+		return "", fmt.Errorf("schedule: message is required")
+	}
+
+	ticketID := getString(params, "ticket_id")
+	if ticketID == "" {
+		ticketID = CurrentTicketFromContext(ctx)
+	}
+	if ticketID == "" {
+		return "", fmt.Errorf("schedule: ticket_id is required outside a ticket context")
+	}
+
+	to := getString(params, "to")
+	if to == "" {
+		to = t.AgentID
+	} else if t.Agents != nil {
+		if err := validateAgentIDs(t.Agents, []string{to}); err != nil {
+			return "", fmt.Errorf("schedule: %w", err)
+		}
+	}
+
+	tk, err := t.Scheduler.GetTicket(ticketID)
+	if err != nil {
+		return "", fmt.Errorf("schedule: ticket %q not found", ticketID)
+	}
+	if tk.CreatedBy != t.AgentID && !slices.Contains(tk.WaitingOn, t.AgentID) {
+		return "", fmt.Errorf("schedule: you are not the creator or an assignee of ticket %s", ticketID)
+	}
+	if !isParticipant(tk, to) {
+		return "", fmt.Errorf("schedule: %s is not a participant in ticket %s", to, ticketID)
+	}
+
+	s := ticket.Schedule{
+		TicketID:  ticketID,
+		AgentID:   to,
+		Message:   message,
+		Cron:      getString(params, "cron"),
+		CreatedBy: t.AgentID,
+	}
+
+	delay, at := getString(params, "delay"), getString(params, "at")
+	set := 0
+	for _, v := range []string{delay, at, s.Cron} {
+		if v != "" {
+			set++
+		}
+	}
+	if set != 1 {
+		return "", fmt.Errorf("schedule: exactly one of delay, at, or cron is required")
+	}
+
+	switch {
+	case delay != "":
+		d, err := time.ParseDuration(delay)
+		if err != nil || d <= 0 {
+			return "", fmt.Errorf("schedule: invalid delay %q (use a positive duration like \"30m\")", delay)
+		}
+		s.NextRun = time.Now().Add(d)
+	case at != "":
+		when, err := time.Parse(time.RFC3339, at)
+		if err != nil {
+			return "", fmt.Errorf("schedule: invalid time %q (use RFC 3339): %w", at, err)
+		}
+		if !when.After(time.Now()) {
+			return "", fmt.Errorf("schedule: time %q is in the past", at)
+		}
+		s.NextRun = when
+	}
+
+	created, err := t.Scheduler.ScheduleMessage(s)
+	if err != nil {
+		return "", fmt.Errorf("schedule: %w", err)
+	}
+
+	recurrence := "once"
+	if created.Cron != "" {
+		recurrence = fmt.Sprintf("recurring (%s)", created.Cron)
+	}
+	return fmt.Sprintf("Scheduled %s on ticket %s for %s, %s; next run %s",
+		created.ID, created.TicketID, created.AgentID, recurrence, created.NextRun.Format(time.RFC3339)), nil
+}
+
+// --- CancelScheduleTool ---
+
+// CancelScheduleTool cancels a schedule created by the calling agent.
+type CancelScheduleTool struct {
+	Scheduler MessageScheduler
+	AgentID   string
+}
+
+func (t *CancelScheduleTool) Name() string { return "cancel_schedule" }
+func (t *CancelScheduleTool) Description() string {
+	return "Cancel a schedule you created with the schedule tool"
+}
+func (t *CancelScheduleTool) Parameters() map[string]any {
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"schedule_id": map[string]any{"type": "string", "description": "ID returned by the schedule tool"},
+		},
+		"required": []string{"schedule_id"},
+	}
+}
+
+func (t *CancelScheduleTool) Execute(_ context.Context, params map[string]any) (string, error) {
+	id := getString(params, "schedule_id")
+	if id == "" {
+		return "", fmt.Errorf("cancel_schedule: schedule_id is required")
+	}
+	if err := t.Scheduler.CancelSchedule(id, t.AgentID); err != nil {
+		return "", fmt.Errorf("cancel_schedule: %w", err)
+	}
+	return fmt.Sprintf("Schedule %s cancelled", id), nil
+}
@@ -0,0 +1,139 @@
+package tool
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/h1v3-io/h1v3/internal/ticket"
+	"github.com/h1v3-io/h1v3/pkg/protocol"
+)
+
+// fakeScheduler records scheduled messages in memory.
+type fakeScheduler struct {
+	scheduled []ticket.Schedule
+	cancelled []string
+}
+
+// GetTicket serves tk-1 (created by lead, assigned to ops, watched by audit)
+// and tk-9 (created by pm).
+func (f *fakeScheduler) GetTicket(id string) (*protocol.Ticket, error) {
+	switch id {
+	case "tk-1":
+		return &protocol.Ticket{ID: id, CreatedBy: "lead", WaitingOn: []string{"ops"}, Watchers: []string{"audit"}}, nil
+	case "tk-9":
+		return &protocol.Ticket{ID: id, CreatedBy: "pm"}, nil
+	}
+	return nil, fmt.Errorf("ticket %s not found", id)
+}
+
+func (f *fakeScheduler) ScheduleMessage(s ticket.Schedule) (*ticket.Schedule, error) {
+	s.ID = "s-1"
+	f.scheduled = append(f.scheduled, s)
+	return &s, nil
+}
+
+func (f *fakeScheduler) CancelSchedule(id, by string) error {
+	f.cancelled = append(f.cancelled, id+" by "+by)
+	return nil
+}
+
+func TestScheduleTool_Delay(t *testing.T) {
+	sched := &fakeScheduler{}
+	tool := &ScheduleTool{Scheduler: sched, AgentID: "ops"}
+	ctx := WithCurrentTicket(context.Background(), "tk-1")
+
+	result, err := tool.Execute(ctx, map[string]any{"message": "check deploy", "delay": "30m"})
+	if err != nil {
+		t.Fatalf("execute: %v", err)
+	}
+	if !strings.Contains(result, "s-1") {
+		t.Errorf("result should include the schedule ID: %q", result)
+	}
+
+	got := sched.scheduled[0]
+	if got.TicketID != "tk-1" || got.AgentID != "ops" || got.CreatedBy != "ops" || got.Cron != "" {
+		t.Errorf("unexpected schedule: %+v", got)
+	}
+	if d := time.Until(got.NextRun); d < 29*time.Minute || d > 31*time.Minute {
+		t.Errorf("expected next run in ~30m, got %v", d)
+	}
+}
+
+func TestScheduleTool_Cron(t *testing.T) {
+	sched := &fakeScheduler{}
+	tool := &ScheduleTool{Scheduler: sched, AgentID: "pm"}
+
+	_, err := tool.Execute(context.Background(), map[string]any{"message": "standup", "cron": "0 9 * * 1-5", "ticket_id": "tk-9"})
+	if err != nil {
+		t.Fatalf("execute: %v", err)
+	}
+	if got := sched.scheduled[0]; got.Cron != "0 9 * * 1-5" || got.TicketID != "tk-9" || !got.NextRun.IsZero() {
+		t.Errorf("unexpected schedule: %+v", got)
+	}
+}
+
+func TestScheduleTool_InvalidTiming(t *testing.T) {
+	tool := &ScheduleTool{Scheduler: &fakeScheduler{}, AgentID: "ops"}
+	ctx := WithCurrentTicket(context.Background(), "tk-1")
+
+	cases := []map[string]any{
+		{"message": "x"},
+		{"message": "x", "delay": "30m", "cron": "@daily"},
+		{"message": "x", "delay": "soon"},
+		{"message": "x", "at": "2000-01-01T00:00:00Z"},
+	}
+	for _, params := range cases {
+		if _, err := tool.Execute(ctx, params); err == nil {
+			t.Errorf("expected error for %v", params)
+		}
+	}
+}
+
+func TestScheduleTool_Participation(t *testing.T) {
+	sched := &fakeScheduler{}
+	ctx := WithCurrentTicket(context.Background(), "tk-1")
+	params := func(to string) map[string]any {
+		return map[string]any{"message": "x", "delay": "1h", "to": to}
+	}
+
+	cases := []struct {
+		caller, to string
+		ok         bool
+	}{
+		{"lead", "ops", true},     // creator wakes an assignee
+		{"ops", "lead", true},     // assignee wakes the creator
+		{"lead", "audit", true},   // a watcher may be woken
+		{"audit", "audit", false}, // but watchers cannot schedule
+		{"stranger", "ops", false},
+		{"lead", "stranger", false},
+	}
+	for _, c := range cases {
+		tool := &ScheduleTool{Scheduler: sched, AgentID: c.caller}
+		_, err := tool.Execute(ctx, params(c.to))
+		if (err == nil) != c.ok {
+			t.Errorf("%s scheduling for %s: got err %v, want ok=%v", c.caller, c.to, err, c.ok)
+		}
+	}
+	if len(sched.scheduled) != 3 {
+		t.Errorf("expected 3 schedules, got %d", len(sched.scheduled))
+	}
+
+	tool := &ScheduleTool{Scheduler: sched, AgentID: "lead"}
+	if _, err := tool.Execute(context.Background(), map[string]any{"message": "x", "delay": "1h", "ticket_id": "tk-404"}); err == nil {
+		t.Error("expected an error for an unknown ticket")
+	}
+}
+
+func TestCancelScheduleTool(t *testing.T) {
+	sched := &fakeScheduler{}
+	tool := &CancelScheduleTool{Scheduler: sched, AgentID: "ops"}
+	if _, err := tool.Execute(context.Background(), map[string]any{"schedule_id": "s-1"}); err != nil {
+		t.Fatalf("execute: %v", err)
+	}
+	if len(sched.cancelled) != 1 || sched.cancelled[0] != "s-1 by ops" {
+		t.Errorf("unexpected cancellations: %v", sched.cancelled)
+	}
+}
@@ -0,0 +1,204 @@
+package tool
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// FieldError describes one argument that does not match a tool's schema.
+type FieldError struct {
+	Field   string `json:"field"`
+	Problem string `json:"problem"`
+}
+
+// ValidationError is returned when tool arguments do not match the tool's
+// declared JSON schema. It is reported back to the model as the tool result.
+type ValidationError struct {
+	Tool   string       `json:"tool"`
+	Fields []FieldError `json:"fields"`
+}
+
+func (e *ValidationError) Error() string {
+	parts := make([]string, len(e.Fields))
+	for i, f := range e.Fields {
+		parts[i] = f.Field + ": " + f.Problem
+	}
+	return fmt.Sprintf("%s: invalid arguments: %s", e.Tool, strings.Join(parts, "; "))
+}
+
+// ValidateParams checks params against a tool's JSON schema. Only the subset
+// of JSON schema used by tool declarations is enforced: required properties,
+// property types (including array item types), enums, and
+// additionalProperties: false. Returns nil when params are valid.
+func ValidateParams(toolName string, schema, params map[string]any) error {
+	var fields []FieldError
+
+	props, _ := schema["properties"].(map[string]any)
+	for _, name := range toStrings(schema["required"]) {
+		if v, ok := params[name]; !ok || v == nil {
+			fields = append(fields, FieldError{Field: name, Problem: "required"})
+		}
+	}
+
+	// Iterate in sorted order so errors are stable across calls.
+	names := make([]string, 0, len(params))
+	for name := range params {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		v := params[name]
+		prop, ok := props[name].(map[string]any)
+		if !ok {
+			if additional, ok := schema["additionalProperties"].(bool); ok && !additional {
+				fields = append(fields, FieldError{Field: name, Problem: "unknown argument"})
+			}
+			continue
+		}
+		if v == nil {
+			continue
+		}
+		if problem := checkValue(prop, v); problem != "" {
+			fields = append(fields, FieldError{Field: name, Problem: problem})
+		}
+	}
+
+	if len(fields) == 0 {
+		return nil
+	}
+	return &ValidationError{Tool: toolName, Fields: fields}
+}
+
+// checkValue validates a single value against a property schema, returning a
+// description of the problem or "" if it matches.
+func checkValue(prop map[string]any, v any) string {
+	if types := toStrings(prop["type"]); len(types) > 0 {
+		matched := false
+		for _, typ := range types {
+			if hasType(v, typ) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return fmt.Sprintf("expected %s, got %s", strings.Join(types, " or "), typeName(v))
+		}
+	}
+
+	if enum := toAnys(prop["enum"]); len(enum) > 0 {
+		found := false
+		for _, e := range enum {
+			if fmt.Sprint(e) == fmt.Sprint(v) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			allowed := make([]string, len(enum))
+			for i, e := range enum {
+				allowed[i] = fmt.Sprint(e)
+			}
+			return fmt.Sprintf("must be one of %s", strings.Join(allowed, ", "))
+		}
+	}
+
+	if items, ok := prop["items"].(map[string]any); ok {
+		for i, item := range toAnys(v) {
+			if problem := checkValue(items, item); problem != "" {
+				return fmt.Sprintf("item %d: %s", i, problem)
+			}
+		}
+	}
+	return ""
+}
+
+func hasType(v any, typ string) bool {
+	switch typ {
+	case "string":
+		_, ok := v.(string)
+		return ok
+	case "boolean":
+		_, ok := v.(bool)
+		return ok
+	case "number":
+		switch v.(type) {
+		case float64, float32, int, int64, json.Number:
+			return true
+		}
+	case "integer":
+		switch n := v.(type) {
+		case int, int64:
+			return true
+		case float64:
+			return n == math.Trunc(n)
+		case json.Number:
+			_, err := n.Int64()
+			return err == nil
+		}
+	case "array":
+		switch v.(type) {
+		case []any, []string:
+			return true
+		}
+	case "object":
+		_, ok := v.(map[string]any)
+		return ok
+	case "null":
+		return v == nil
+	}
+	return false
+}
+
+func typeName(v any) string {
+	switch v.(type) {
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case float64, float32, int, int64, json.Number:
+		return "number"
+	case []any, []string:
+		return "array"
+	case map[string]any:
+		return "object"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+// toStrings accepts the []string used by built-in tool schemas as well as the
+// []any produced by decoding JSON (MCP tool schemas), and a bare string.
+func toStrings(v any) []string {
+	switch s := v.(type) {
+	case string:
+		return []string{s}
+	case []string:
+		return s
+	case []any:
+		out := make([]string, 0, len(s))
+		for _, item := range s {
+			if str, ok := item.(string); ok {
+				out = append(out, str)
+			}
+		}
+		return out
+	}
+	return nil
+}
+
+func toAnys(v any) []any {
+	switch s := v.(type) {
+	case []any:
+		return s
+	case []string:
+		out := make([]any, len(s))
+		for i, str := range s {
+			out[i] = str
+		}
+		return out
+	}
+	return nil
+}
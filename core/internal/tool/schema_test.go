@@ -0,0 +1,114 @@
+package tool
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+var testSchema = map[string]any{
+	"type": "object",
+	"properties": map[string]any{
+		"title":  map[string]any{"type": "string"},
+		"limit":  map[string]any{"type": "integer"},
+		"status": map[string]any{"type": "string", "enum": []string{"open", "closed"}},
+		"tags":   map[string]any{"type": "array", "items": map[string]any{"type": "string"}},
+		"force":  map[string]any{"type": "boolean"},
+	},
+	"required": []string{"title"},
+}
+
+func TestValidateParams_Valid(t *testing.T) {
+	params := map[string]any{
+		"title":  "x",
+		"limit":  float64(5), // JSON numbers decode as float64
+		"status": "open",
+		"tags":   []any{"a", "b"},
+		"force":  true,
+		"extra":  "ignored",
+	}
+	if err := ValidateParams("t", testSchema, params); err != nil {
+		t.Errorf("expected valid params, got %v", err)
+	}
+}
+
+func TestValidateParams_ListsOffendingFields(t *testing.T) {
+	params := map[string]any{
+		"limit":  1.5,
+		"status": "pending",
+		"tags":   []any{"a", 3.0},
+		"force":  "yes",
+	}
+	err := ValidateParams("search", testSchema, params)
+	var verr *ValidationError
+	if !errors.As(err, &verr) {
+		t.Fatalf("expected *ValidationError, got %v", err)
+	}
+	got := map[string]string{}
+	for _, f := range verr.Fields {
+		got[f.Field] = f.Problem
+	}
+	want := map[string]string{
+		"title":  "required",
+		"limit":  "expected integer, got number",
+		"status": "must be one of open, closed",
+		"tags":   "item 1: expected string, got number",
+		"force":  "expected boolean, got string",
+	}
+	for field, problem := range want {
+		if got[field] != problem {
+			t.Errorf("%s: got %q, want %q", field, got[field], problem)
+		}
+	}
+	if !strings.HasPrefix(err.Error(), "search: invalid arguments: title: required") {
+		t.Errorf("unexpected message: %s", err)
+	}
+}
+
+func TestValidateParams_DecodedJSONSchema(t *testing.T) {
+	// MCP schemas arrive as decoded JSON, so lists are []any.
+	schema := map[string]any{
+		"type":                 "object",
+		"properties":           map[string]any{"q": map[string]any{"type": []any{"string", "null"}}},
+		"required":             []any{"q"},
+		"additionalProperties": false,
+	}
+	if err := ValidateParams("mcp", schema, map[string]any{"q": "hi"}); err != nil {
+		t.Errorf("expected valid, got %v", err)
+	}
+	err := ValidateParams("mcp", schema, map[string]any{"q": "hi", "x": 1.0})
+	if err == nil || !strings.Contains(err.Error(), "x: unknown argument") {
+		t.Errorf("expected unknown argument error, got %v", err)
+	}
+}
+
+type schemaTool struct {
+	stubTool
+	called bool
+}
+
+func (s *schemaTool) Parameters() map[string]any { return testSchema }
+func (s *schemaTool) Execute(_ context.Context, _ map[string]any) (string, error) {
+	s.called = true
+	return "ok", nil
+}
+
+func TestRegistry_ExecuteValidatesParams(t *testing.T) {
+	st := &schemaTool{stubTool: stubTool{name: "search"}}
+	reg := NewRegistry()
+	reg.Register(st)
+
+	_, err := reg.Execute(context.Background(), "search", map[string]any{"limit": "ten"})
+	var verr *ValidationError
+	if !errors.As(err, &verr) || len(verr.Fields) != 2 {
+		t.Fatalf("expected validation error with 2 fields, got %v", err)
+	}
+	if st.called {
+		t.Error("tool should not run with invalid params")
+	}
+
+	if _, err := reg.Execute(context.Background(), "search", map[string]any{"title": "x"}); err != nil || !st.called {
+		t.Errorf("expected valid call to run, err=%v", err)
+	}
+}
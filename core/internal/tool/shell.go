@@ -1,18 +1,23 @@
 package tool
 
 import (
-	"bytes"
 	"context"
 	"fmt"
+	"log/slog"
 	"os"
 	"os/exec"
+	"slices"
 	"strings"
 	"time"
 )
 
 const (
 	defaultTimeout   = 60 * time.Second
-	maxOutputSize    = 10 * 1024 // 10KB
+	DefaultMaxOutput = 10 * 1024 // bytes of command output returned to the model
+
+	// waitDelay bounds how long a killed command's output pipes may stay
+	// open, should something outside its process group hold them.
+	waitDelay = 2 * time.Second
 )
 
 // blockedPatterns are shell commands that should never be executed.
@@ -26,10 +31,20 @@ var blockedPatterns = []string{
 	"chmod -R 777 /",
 }
 
-// ExecTool runs shell commands with safety guards.
+// ExecTool runs shell commands with safety guards. The result has separate
+// stdout and stderr sections and the exit code, capped at MaxOutput bytes
+// (default DefaultMaxOutput). A command running past Timeout (default 60s)
+// is killed with every process it started. With Logger set, output lines
+// are also logged as the command runs, so long builds can be followed live.
 type ExecTool struct {
-	WorkDir string
-	Timeout time.Duration
+	WorkDir   string
+	Timeout   time.Duration
+	MaxOutput int
+	Logger    *slog.Logger
+
+	// BlockedCommands are program names refused as the first word of any
+	// command in the line (split at ;, &, | and newlines).
+	BlockedCommands []string
 }
 
 func (t *ExecTool) Name() string        { return "exec" }
@@ -58,42 +73,100 @@ func (t *ExecTool) Execute(ctx context.Context, params map[string]any) (string,
 		}
 	}
 
-	timeout := t.Timeout
+	if name := blockedCommand(command, t.BlockedCommands); name != "" {
+		return "", fmt.Errorf("exec: command %q is blocked", name)
+	}
+
+	sb := sandbox{tool: "exec", workDir: t.WorkDir, timeout: t.Timeout, maxOutput: t.MaxOutput, logger: t.Logger}
+	return sb.run(ctx, "/bin/sh", "-c", command)
+}
+
+// blockedCommand returns the first program in the shell line whose name,
+// without its directory, is in blocked, or "" if there is none.
+func blockedCommand(line string, blocked []string) string {
+	if len(blocked) == 0 {
+		return ""
+	}
+	segments := strings.FieldsFunc(line, func(r rune) bool {
+		return r == ';' || r == '&' || r == '|' || r == '\n' || r == '(' || r == ')'
+	})
+	for _, seg := range segments {
+		fields := strings.Fields(seg)
+		if len(fields) == 0 {
+			continue
+		}
+		name := fields[0]
+		if i := strings.LastIndexByte(name, '/'); i >= 0 {
+			name = name[i+1:]
+		}
+		if slices.Contains(blocked, name) {
+			return name
+		}
+	}
+	return ""
+}
+
+// sandbox runs commands in workDir (created if needed, also used as HOME)
+// with a timeout. tool prefixes errors and log lines.
+type sandbox struct {
+	tool      string
+	workDir   string
+	timeout   time.Duration
+	maxOutput int          // default DefaultMaxOutput
+	logger    *slog.Logger // optional; streams output lines
+	env       []string     // extra KEY=value entries
+}
+
+// run executes the command and returns its captured output (see
+// outputCapture.Render). A non-zero exit is reported in the output rather
+// than as an error.
+func (s sandbox) run(ctx context.Context, name string, args ...string) (string, error) {
+	timeout := s.timeout
 	if timeout == 0 {
 		timeout = defaultTimeout
 	}
+	limit := s.maxOutput
+	if limit <= 0 {
+		limit = DefaultMaxOutput
+	}
 
 	ctx, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
 
-	cmd := exec.CommandContext(ctx, "/bin/sh", "-c", command)
-	if t.WorkDir != "" {
-		os.MkdirAll(t.WorkDir, 0o755)
-		cmd.Dir = t.WorkDir
-		cmd.Env = append(os.Environ(), "HOME="+t.WorkDir)
+	cmd := exec.CommandContext(ctx, name, args...)
+	killProcessGroup(cmd)
+	cmd.WaitDelay = waitDelay
+	if s.workDir != "" {
+		os.MkdirAll(s.workDir, 0o755)
+		cmd.Dir = s.workDir
+		cmd.Env = append(os.Environ(), "HOME="+s.workDir)
+	}
+	if len(s.env) > 0 {
+		if cmd.Env == nil {
+			cmd.Env = os.Environ()
+		}
+		cmd.Env = append(cmd.Env, s.env...)
 	}
 
-	var buf bytes.Buffer
-	cmd.Stdout = &buf
-	cmd.Stderr = &buf
+	var logger *slog.Logger
+	if s.logger != nil {
+		logger = s.logger.With("tool", s.tool)
+	}
+	out := newOutputCapture(limit, logger)
+	cmd.Stdout = out.Stdout()
+	cmd.Stderr = out.Stderr()
 
 	err := cmd.Run()
-
-	output := buf.String()
-	if len(output) > maxOutputSize {
-		output = output[:maxOutputSize] + "\n... [truncated]"
-	}
+	out.flushLogs()
 
 	if err != nil {
 		if ctx.Err() == context.DeadlineExceeded {
-			return output, fmt.Errorf("exec: command timed out after %s", timeout)
+			return out.Render(-1), fmt.Errorf("%s: command timed out after %s", s.tool, timeout)
 		}
-		// Return output + exit code for non-zero exits (not a hard error)
 		if exitErr, ok := err.(*exec.ExitError); ok {
-			return fmt.Sprintf("%s\n[exit code %d]", output, exitErr.ExitCode()), nil
+			return out.Render(exitErr.ExitCode()), nil
 		}
-		return "", fmt.Errorf("exec: %w", err)
+		return "", fmt.Errorf("%s: %w", s.tool, err)
 	}
-
-	return output, nil
+	return out.Render(0), nil
 }
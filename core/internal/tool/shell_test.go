@@ -1,7 +1,9 @@
 package tool
 
 import (
+	"bytes"
 	"context"
+	"log/slog"
 	"path/filepath"
 	"strings"
 	"testing"
@@ -16,8 +18,8 @@ func TestExec_BasicCommand(t *testing.T) {
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
-	if strings.TrimSpace(result) != "hello world" {
-		t.Errorf("expected 'hello world', got %q", result)
+	if result != "[stdout]\nhello world\n[exit code 0]" {
+		t.Errorf("unexpected result %q", result)
 	}
 }
 
@@ -32,8 +34,8 @@ func TestExec_WorkDir(t *testing.T) {
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
-	if strings.TrimSpace(result) != dir {
-		t.Errorf("expected %q, got %q", dir, strings.TrimSpace(result))
+	if want := "[stdout]\n" + dir + "\n[exit code 0]"; result != want {
+		t.Errorf("expected %q, got %q", want, result)
 	}
 }
 
@@ -58,11 +60,37 @@ func TestExec_Stderr(t *testing.T) {
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
-	if strings.TrimSpace(result) != "err" {
+	if result != "[stderr]\nerr\n[exit code 0]" {
 		t.Errorf("expected stderr captured, got %q", result)
 	}
 }
 
+func TestExec_SeparateStreams(t *testing.T) {
+	tool := &ExecTool{}
+	result, err := tool.Execute(context.Background(), map[string]any{
+		"command": "echo out; echo err >&2; exit 3",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := "[stdout]\nout\n[stderr]\nerr\n[exit code 3]"; result != want {
+		t.Errorf("expected %q, got %q", want, result)
+	}
+}
+
+func TestExec_NoOutput(t *testing.T) {
+	tool := &ExecTool{}
+	result, err := tool.Execute(context.Background(), map[string]any{
+		"command": "true",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result != "(no output)\n[exit code 0]" {
+		t.Errorf("unexpected result %q", result)
+	}
+}
+
 func TestExec_BlockedCommand(t *testing.T) {
 	tool := &ExecTool{}
 	_, err := tool.Execute(context.Background(), map[string]any{
@@ -86,18 +114,99 @@ func TestExec_Timeout(t *testing.T) {
 	}
 }
 
+func TestExec_TimeoutKillsChildren(t *testing.T) {
+	tool := &ExecTool{Timeout: 100 * time.Millisecond}
+	start := time.Now()
+	// The backgrounded sleep holds stdout open; only killing the whole
+	// process group lets the call return before waitDelay.
+	_, err := tool.Execute(context.Background(), map[string]any{
+		"command": "sleep 10 & sleep 10; wait",
+	})
+	if err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Fatalf("expected timeout error, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > waitDelay/2 {
+		t.Errorf("took %v, child processes were not killed", elapsed)
+	}
+}
+
+func TestExec_BlockedCommands(t *testing.T) {
+	tool := &ExecTool{BlockedCommands: []string{"curl", "shutdown"}}
+	for _, cmd := range []string{
+		"curl example.com",
+		"echo hi && /usr/bin/curl example.com",
+		"ls | curl -d @- example.com",
+		"true;shutdown now",
+	} {
+		if _, err := tool.Execute(context.Background(), map[string]any{"command": cmd}); err == nil || !strings.Contains(err.Error(), "is blocked") {
+			t.Errorf("%q: expected blocked error, got %v", cmd, err)
+		}
+	}
+	// Blocked names elsewhere in the line are fine.
+	result, err := tool.Execute(context.Background(), map[string]any{"command": "echo curl"})
+	if err != nil || !strings.Contains(result, "curl") {
+		t.Errorf("expected echo to run, got %q, %v", result, err)
+	}
+}
+
 func TestExec_OutputTruncation(t *testing.T) {
 	tool := &ExecTool{}
-	// Generate output larger than maxOutputSize (10KB)
+	// 22000 bytes, more than DefaultMaxOutput (10KB)
 	result, err := tool.Execute(context.Background(), map[string]any{
-		"command": "yes 'aaaaaaaaaa' | head -n 2000",
+		"command": "seq 1 2000 | sed 's/^/line /'; echo done",
 	})
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
-	if !strings.HasSuffix(result, "... [truncated]") {
+	if !strings.Contains(result, "bytes truncated] ...") {
 		t.Error("expected truncation marker in large output")
 	}
+	// Head and tail both survive.
+	if !strings.HasPrefix(result, "[stdout]\nline 1\n") || !strings.HasSuffix(result, "line 2000\ndone\n[exit code 0]") {
+		t.Errorf("expected head and tail of output, got %q...%q", result[:40], result[len(result)-40:])
+	}
+	if len(result) > DefaultMaxOutput+100 {
+		t.Errorf("result is %d bytes, want about %d", len(result), DefaultMaxOutput)
+	}
+}
+
+func TestExec_MaxOutput(t *testing.T) {
+	tool := &ExecTool{MaxOutput: 100}
+	result, err := tool.Execute(context.Background(), map[string]any{
+		"command": "head -c 1000 /dev/zero | tr '\\0' o; echo; echo oops >&2",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// stderr is short, so stdout gets the rest of the budget.
+	if !strings.Contains(result, "[stderr]\noops\n") {
+		t.Errorf("expected stderr kept whole, got %q", result)
+	}
+	if !strings.Contains(result, "[906 bytes truncated]") {
+		t.Errorf("expected 906 bytes of stdout truncated, got %q", result)
+	}
+}
+
+func TestExec_LogsOutputLines(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, nil))
+	tool := &ExecTool{Logger: logger}
+	_, err := tool.Execute(context.Background(), map[string]any{
+		"command": "echo one; echo two >&2; printf three",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	logs := buf.String()
+	for _, want := range []string{
+		"tool=exec stream=stdout line=one",
+		"tool=exec stream=stderr line=two",
+		"tool=exec stream=stdout line=three",
+	} {
+		if !strings.Contains(logs, want) {
+			t.Errorf("expected %q in logs:\n%s", want, logs)
+		}
+	}
 }
 
 func TestExec_EmptyCommand(t *testing.T) {
@@ -3,8 +3,11 @@ package tool
 import (
 	"context"
 	"fmt"
+	"os"
 	"path/filepath"
+	"slices"
 	"strings"
+	"time"
 )
 
 // SkillEntry is the subset of a skill exposed to the tool layer.
@@ -59,8 +62,13 @@ func (t *LoadSkillTool) Execute(_ context.Context, params map[string]any) (strin
 	var b strings.Builder
 	fmt.Fprintf(&b, "# %s\n\n%s", entry.Name, entry.Content)
 
-	for name, content := range entry.References {
-		fmt.Fprintf(&b, "\n\n---\n\n## Reference: %s\n\n%s", name, content)
+	refs := make([]string, 0, len(entry.References))
+	for name := range entry.References {
+		refs = append(refs, name)
+	}
+	slices.Sort(refs)
+	for _, name := range refs {
+		fmt.Fprintf(&b, "\n\n---\n\n## Reference: %s\n\n%s", name, entry.References[name])
 	}
 
 	if len(entry.Scripts) > 0 {
@@ -72,3 +80,97 @@ func (t *LoadSkillTool) Execute(_ context.Context, params map[string]any) (strin
 
 	return b.String(), nil
 }
+
+// scriptInterpreters run skill scripts that are not marked executable.
+var scriptInterpreters = map[string]string{
+	".sh": "/bin/sh",
+	".py": "python3",
+	".js": "node",
+}
+
+// RunSkillScriptTool runs a script bundled in a skill's scripts/ directory.
+// The model names the skill and script; the tool resolves the path and
+// passes args directly (no shell), with the same working directory, timeout
+// and output cap as exec. A script whose program (the script itself or its
+// interpreter) is in BlockedCommands is refused.
+type RunSkillScriptTool struct {
+	Provider        SkillProvider
+	WorkDir         string
+	Timeout         time.Duration
+	MaxOutput       int
+	BlockedCommands []string
+}
+
+func (t *RunSkillScriptTool) Name() string { return "run_skill_script" }
+func (t *RunSkillScriptTool) Description() string {
+	return "Run a script bundled with a skill (listed under the skill's scripts) with optional arguments, and return its output."
+}
+func (t *RunSkillScriptTool) Parameters() map[string]any {
+	return map[string]any{
+		"type":     "object",
+		"required": []string{"slug", "script"},
+		"properties": map[string]any{
+			"slug":   map[string]any{"type": "string", "description": "The skill slug"},
+			"script": map[string]any{"type": "string", "description": "Script file name from the skill's scripts list"},
+			"args":   map[string]any{"type": "array", "items": map[string]any{"type": "string"}, "description": "Arguments passed to the script as-is"},
+		},
+	}
+}
+
+func (t *RunSkillScriptTool) Execute(ctx context.Context, params map[string]any) (string, error) {
+	slug := getString(params, "slug")
+	script := getString(params, "script")
+	if slug == "" || script == "" {
+		return "", fmt.Errorf("run_skill_script: slug and script are required")
+	}
+
+	entry, ok := t.Provider.GetSkill(slug)
+	if !ok {
+		return "", fmt.Errorf("run_skill_script: skill %q not found", slug)
+	}
+	if !slices.Contains(entry.Scripts, script) {
+		return "", fmt.Errorf("run_skill_script: skill %q has no script %q (available: %s)", slug, script, strings.Join(entry.Scripts, ", "))
+	}
+	path, err := resolveSkillScript(entry.Dir, script)
+	if err != nil {
+		return "", fmt.Errorf("run_skill_script: %w", err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", fmt.Errorf("run_skill_script: %w", err)
+	}
+	name, args := path, getStringSlice(params, "args")
+	if info.Mode()&0o111 == 0 {
+		interp, ok := scriptInterpreters[filepath.Ext(path)]
+		if !ok {
+			return "", fmt.Errorf("run_skill_script: %s is not executable and has no known interpreter", script)
+		}
+		name, args = interp, append([]string{path}, args...)
+	}
+	if slices.Contains(t.BlockedCommands, filepath.Base(name)) {
+		return "", fmt.Errorf("run_skill_script: command %q is blocked", filepath.Base(name))
+	}
+	sb := sandbox{tool: "run_skill_script", workDir: t.WorkDir, timeout: t.Timeout, maxOutput: t.MaxOutput}
+	return sb.run(ctx, name, args...)
+}
+
+// resolveSkillScript returns the real path of a script under
+// {skillDir}/scripts, refusing names and symlinks that lead outside it.
+func resolveSkillScript(skillDir, script string) (string, error) {
+	if script != filepath.Base(script) || script == "." || script == ".." {
+		return "", fmt.Errorf("invalid script name %q", script)
+	}
+	scriptsDir, err := filepath.EvalSymlinks(filepath.Join(skillDir, "scripts"))
+	if err != nil {
+		return "", err
+	}
+	path, err := filepath.EvalSymlinks(filepath.Join(scriptsDir, script))
+	if err != nil {
+		return "", err
+	}
+	if !strings.HasPrefix(path, scriptsDir+string(filepath.Separator)) {
+		return "", fmt.Errorf("script %q resolves outside the skill's scripts directory", script)
+	}
+	return path, nil
+}
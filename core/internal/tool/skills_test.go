@@ -0,0 +1,107 @@
+package tool
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+type stubSkills map[string]*SkillEntry
+
+func (s stubSkills) GetSkill(slug string) (*SkillEntry, bool) {
+	e, ok := s[slug]
+	return e, ok
+}
+
+func TestLoadSkill(t *testing.T) {
+	tool := &LoadSkillTool{Provider: stubSkills{"linear-api": {
+		Slug:       "linear-api",
+		Name:       "Linear API",
+		Content:    "Use the linear tools.",
+		References: map[string]string{"schema.md": "# Schema", "auth.md": "# Auth"},
+	}}}
+
+	out, err := tool.Execute(context.Background(), map[string]any{"slug": "linear-api"})
+	if err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	want := "# Linear API\n\nUse the linear tools." +
+		"\n\n---\n\n## Reference: auth.md\n\n# Auth" +
+		"\n\n---\n\n## Reference: schema.md\n\n# Schema"
+	if out != want {
+		t.Errorf("got %q, want %q", out, want)
+	}
+
+	out, err = tool.Execute(context.Background(), map[string]any{"slug": "nope"})
+	if err != nil || !strings.Contains(out, "not found") {
+		t.Errorf("unknown skill: %q, %v", out, err)
+	}
+}
+
+func TestRunSkillScript(t *testing.T) {
+	skillDir := t.TempDir()
+	scripts := filepath.Join(skillDir, "scripts")
+	os.MkdirAll(scripts, 0o755)
+	os.WriteFile(filepath.Join(scripts, "greet"), []byte("#!/bin/sh\necho \"hello $1\"\n"), 0o755)
+	os.WriteFile(filepath.Join(scripts, "count.sh"), []byte("echo \"$# args\"\n"), 0o644)
+	outside := filepath.Join(t.TempDir(), "evil.sh")
+	os.WriteFile(outside, []byte("echo pwned\n"), 0o755)
+	os.Symlink(outside, filepath.Join(scripts, "link.sh"))
+
+	tool := &RunSkillScriptTool{
+		Provider: stubSkills{"greeter": {Slug: "greeter", Dir: skillDir, Scripts: []string{"greet", "count.sh", "link.sh"}}},
+		WorkDir:  t.TempDir(),
+	}
+	run := func(params map[string]any) (string, error) {
+		return tool.Execute(context.Background(), params)
+	}
+
+	out, err := run(map[string]any{"slug": "greeter", "script": "greet", "args": []any{"world; rm -rf ~"}})
+	if err != nil {
+		t.Fatalf("greet: %v", err)
+	}
+	if out != "[stdout]\nhello world; rm -rf ~\n[exit code 0]" {
+		t.Errorf("args should pass through unevaluated, got %q", out)
+	}
+
+	out, err = run(map[string]any{"slug": "greeter", "script": "count.sh", "args": []any{"a", "b"}})
+	if err != nil || out != "[stdout]\n2 args\n[exit code 0]" {
+		t.Errorf("non-executable .sh via interpreter: %q, %v", out, err)
+	}
+
+	for _, tc := range []struct {
+		name   string
+		params map[string]any
+		want   string
+	}{
+		{"unknown skill", map[string]any{"slug": "nope", "script": "greet"}, "not found"},
+		{"unlisted script", map[string]any{"slug": "greeter", "script": "other"}, "has no script"},
+		{"symlink outside", map[string]any{"slug": "greeter", "script": "link.sh"}, "outside the skill's scripts directory"},
+	} {
+		if _, err := run(tc.params); err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("%s: error = %v, want %q", tc.name, err, tc.want)
+		}
+	}
+
+	if _, err := resolveSkillScript(skillDir, "../SKILL.md"); err == nil {
+		t.Error("expected path traversal to be refused")
+	}
+
+	// exec's limits apply too.
+	tool.BlockedCommands = []string{"sh"}
+	if _, err := run(map[string]any{"slug": "greeter", "script": "count.sh"}); err == nil || !strings.Contains(err.Error(), `command "sh" is blocked`) {
+		t.Errorf("blocked interpreter: error = %v", err)
+	}
+	tool.BlockedCommands = []string{"greet"}
+	if _, err := run(map[string]any{"slug": "greeter", "script": "greet"}); err == nil || !strings.Contains(err.Error(), "is blocked") {
+		t.Errorf("blocked script: error = %v", err)
+	}
+	tool.BlockedCommands = nil
+	tool.MaxOutput = 8
+	out, err = run(map[string]any{"slug": "greeter", "script": "greet", "args": []any{strings.Repeat("x", 100)}})
+	if err != nil || strings.Count(out, "x") > 8 {
+		t.Errorf("expected output capped at MaxOutput, got %q, %v", out, err)
+	}
+}
@@ -6,7 +6,9 @@ import (
 	"encoding/json"
 	"fmt"
 	"log/slog"
+	"slices"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/h1v3-io/h1v3/internal/ticket"
@@ -16,13 +18,20 @@ import (
 // TicketBroker abstracts ticket operations. Implemented by the registry
 // adapter in cmd/h1v3d to break the import cycle.
 type TicketBroker interface {
-	CreateTicket(from, title, goal, parentID string, to, tags []string) (*protocol.Ticket, error)
+	CreateAndRoute(from, title, goal, parentID string, to, tags []string, priority int, dueAt *time.Time, msg protocol.Message) (*protocol.Ticket, error)
 	GetTicket(ticketID string) (*protocol.Ticket, error)
 	ListTickets(filter ticket.Filter) ([]*protocol.Ticket, error)
 	CountTickets(filter ticket.Filter) (int, error)
-	CloseTicket(ticketID, summary string) error
-	UpdateTicketStatus(ticketID string, status protocol.TicketStatus) error
+	CloseTicket(ticketID, summary, by string) error
+	CloseTicketTree(ticketID, summary, by string) ([]string, error)
+	ReopenTicket(ticketID, reason, by string) error
+	ReassignTicket(ticketID string, to []string, reason, by string) error
+	UpdateTicketStatus(ticketID string, status protocol.TicketStatus, by string) error
 	RouteMessage(msg protocol.Message) error
+	TicketEvents(ticketID string) ([]protocol.TicketEvent, error)
+	TicketUsage(ticketID string) (*protocol.TicketUsage, error)
+	WatchTicket(ticketID, agentID, by string) error
+	UnwatchTicket(ticketID, agentID, by string) error
 }
 
 // contextKey is an unexported type for context keys in this package.
@@ -40,6 +49,19 @@ const respondedKey = contextKey("responded")
 // deferredMsgsKey is the context key for deferred message delivery.
 const deferredMsgsKey = contextKey("deferred_messages")
 
+// respondedFlag and deferredMessages hold per-turn state behind their own
+// lock, since tool calls from the same turn may run concurrently. Keeping
+// the lock in the turn's context means other agents' turns never contend.
+type respondedFlag struct {
+	mu   sync.Mutex
+	flag *bool
+}
+
+type deferredMessages struct {
+	mu   sync.Mutex
+	msgs *[]protocol.Message
+}
+
 // WithCurrentTicket returns a context with the current ticket ID set.
 func WithCurrentTicket(ctx context.Context, ticketID string) context.Context {
 	return context.WithValue(ctx, TicketContextKey, ticketID)
@@ -69,21 +91,25 @@ func InputMessagesFromContext(ctx context.Context) []protocol.ChatMessage {
 // WithRespondedFlag returns a context carrying a mutable responded flag.
 // The flag is set to true when respond_to_ticket is called.
 func WithRespondedFlag(ctx context.Context) (context.Context, *bool) {
-	flag := new(bool)
-	return context.WithValue(ctx, respondedKey, flag), flag
+	r := &respondedFlag{flag: new(bool)}
+	return context.WithValue(ctx, respondedKey, r), r.flag
 }
 
 // Responded returns true if respond_to_ticket was called in this context.
 func Responded(ctx context.Context) bool {
-	if flag, ok := ctx.Value(respondedKey).(*bool); ok {
-		return *flag
+	if r, ok := ctx.Value(respondedKey).(*respondedFlag); ok {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		return *r.flag
 	}
 	return false
 }
 
 func markResponded(ctx context.Context) {
-	if flag, ok := ctx.Value(respondedKey).(*bool); ok {
-		*flag = true
+	if r, ok := ctx.Value(respondedKey).(*respondedFlag); ok {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		*r.flag = true
 	}
 }
 
@@ -91,13 +117,15 @@ func markResponded(ctx context.Context) {
 // delivery. Messages on the current ticket are deferred so that a subsequent
 // close_ticket in the same turn can suppress inbox delivery.
 func WithDeferredMessages(ctx context.Context) (context.Context, *[]protocol.Message) {
-	msgs := &[]protocol.Message{}
-	return context.WithValue(ctx, deferredMsgsKey, msgs), msgs
+	d := &deferredMessages{msgs: &[]protocol.Message{}}
+	return context.WithValue(ctx, deferredMsgsKey, d), d.msgs
 }
 
 func deferMessage(ctx context.Context, msg protocol.Message) {
-	if msgs, ok := ctx.Value(deferredMsgsKey).(*[]protocol.Message); ok {
-		*msgs = append(*msgs, msg)
+	if d, ok := ctx.Value(deferredMsgsKey).(*deferredMessages); ok {
+		d.mu.Lock()
+		defer d.mu.Unlock()
+		*d.msgs = append(*d.msgs, msg)
 	}
 }
 
@@ -129,8 +157,21 @@ func generateMsgID() string {
 	return fmt.Sprintf("m-%x", b)
 }
 
+// isParticipant reports whether agentID created, is assigned to, or watches
+// tk.
+func isParticipant(tk *protocol.Ticket, agentID string) bool {
+	return tk.CreatedBy == agentID || slices.Contains(tk.WaitingOn, agentID) || slices.Contains(tk.Watchers, agentID)
+}
+
 // collectRecipients returns all ticket participants except the sender.
+// On external session tickets an assignee answers the user only: fellow
+// front agents fanned out on the same session are not woken by each other's
+// replies, which would have them talk in circles.
 func collectRecipients(tk *protocol.Ticket, sender string) []string {
+	if tk.CreatedBy == "_external" && slices.Contains(tk.WaitingOn, sender) {
+		return []string{tk.CreatedBy}
+	}
+
 	seen := make(map[string]bool)
 	seen[sender] = true
 
@@ -191,15 +232,35 @@ func validateAgentIDs(lister AgentLister, ids []string) error {
 // --- CreateTicketTool ---
 
 type CreateTicketTool struct {
-	Broker  TicketBroker
-	AgentID string
-	Agents  AgentLister
+	Broker    TicketBroker
+	AgentID   string
+	Agents    AgentLister
+	Templates []protocol.TicketTemplate // optional presets selectable with the "template" param
 }
 
-func (t *CreateTicketTool) Name() string        { return "create_ticket" }
-func (t *CreateTicketTool) Description() string  { return "Create a ticket to delegate work to other agents" }
+func (t *CreateTicketTool) Name() string { return "create_ticket" }
+func (t *CreateTicketTool) Serial() bool { return true }
+func (t *CreateTicketTool) Description() string {
+	desc := "Create a ticket to delegate work to other agents"
+	if len(t.Templates) == 0 {
+		return desc
+	}
+	var b strings.Builder
+	b.WriteString(desc)
+	b.WriteString(". Prefer a template when one fits; explicit title, goal and to override the template's. Templates:")
+	for _, tmpl := range t.Templates {
+		fmt.Fprintf(&b, "\n- %s", tmpl.Name)
+		if vars := tmpl.Vars(); len(vars) > 0 {
+			fmt.Fprintf(&b, " (vars: %s)", strings.Join(vars, ", "))
+		}
+		if tmpl.Description != "" {
+			b.WriteString(": " + tmpl.Description)
+		}
+	}
+	return b.String()
+}
 func (t *CreateTicketTool) Parameters() map[string]any {
-	return map[string]any{
+	params := map[string]any{
 		"type": "object",
 		"properties": map[string]any{
 			"to":    map[string]any{"type": "array", "items": map[string]any{"type": "string"}, "description": "Target agent IDs"},
@@ -207,11 +268,65 @@ func (t *CreateTicketTool) Parameters() map[string]any {
 			"goal":  map[string]any{"type": "string", "description": "Concrete completion condition — what response or outcome would satisfy this ticket (e.g. 'Get the agent's display name')"},
 			"message":   map[string]any{"type": "string", "description": "Optional free-form message to include with the ticket (e.g. research results, context, supporting data)"},
 			"tags":      map[string]any{"type": "array", "items": map[string]any{"type": "string"}, "description": "Optional tags"},
+			"priority":  map[string]any{"type": "integer", "description": "Optional priority; higher is more urgent and listed first. 0 (default) is normal, negative is low"},
+			"due_at":    map[string]any{"type": "string", "description": "Optional deadline as an RFC3339 time (e.g. 2025-06-01T17:00:00Z)"},
 			"confirmed": map[string]any{"type": "boolean", "description": "Set to true to confirm creating a sub-ticket to the same agent as the parent ticket"},
 			"reason":    map[string]any{"type": "string", "description": "Required when confirmed=true — explain why a new sub-ticket is needed instead of using respond_to_ticket, close_ticket, or wait"},
 		},
 		"required": []string{"to", "title", "goal"},
 	}
+	if len(t.Templates) > 0 {
+		names := make([]string, len(t.Templates))
+		for i, tmpl := range t.Templates {
+			names[i] = tmpl.Name
+		}
+		props := params["properties"].(map[string]any)
+		props["template"] = map[string]any{"type": "string", "enum": names, "description": "Ticket template to fill in title, goal and assignees from"}
+		props["vars"] = map[string]any{"type": "object", "additionalProperties": map[string]any{"type": "string"}, "description": "Values for the template's {{variables}}"}
+		delete(params, "required") // a template can supply them
+	}
+	return params
+}
+
+// applyTemplate fills title, goal, message, to and tags from the named
+// template. Values passed explicitly take precedence; tags are merged.
+func (t *CreateTicketTool) applyTemplate(name string, params map[string]any, title, goal, message *string, to, tags *[]string) error {
+	i := slices.IndexFunc(t.Templates, func(tmpl protocol.TicketTemplate) bool { return tmpl.Name == name })
+	if i < 0 {
+		names := make([]string, len(t.Templates))
+		for j, tmpl := range t.Templates {
+			names[j] = tmpl.Name
+		}
+		return fmt.Errorf("unknown template %q (available: %s)", name, strings.Join(names, ", "))
+	}
+	vars := make(map[string]string)
+	if raw, ok := params["vars"].(map[string]any); ok {
+		for k, v := range raw {
+			vars[k] = fmt.Sprint(v)
+		}
+	}
+	tmpl, err := t.Templates[i].Expand(vars)
+	if err != nil {
+		return err
+	}
+	if *title == "" {
+		*title = tmpl.Title
+	}
+	if *goal == "" {
+		*goal = tmpl.Goal
+	}
+	if tmpl.Message != "" {
+		*message = strings.TrimSpace(tmpl.Message + "\n\n" + *message)
+	}
+	if len(*to) == 0 {
+		*to = tmpl.To
+	}
+	for _, tag := range tmpl.Tags {
+		if !slices.Contains(*tags, tag) {
+			*tags = append(*tags, tag)
+		}
+	}
+	return nil
 }
 
 func (t *CreateTicketTool) Execute(ctx context.Context, params map[string]any) (string, error) {
@@ -220,6 +335,24 @@ func (t *CreateTicketTool) Execute(ctx context.Context, params map[string]any) (
 	message := getString(params, "message")
 	to := getStringSlice(params, "to")
 	tags := getStringSlice(params, "tags")
+	priority := 0
+	if p, ok := params["priority"].(float64); ok {
+		priority = int(p)
+	}
+	var dueAt *time.Time
+	if v := getString(params, "due_at"); v != "" {
+		d, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return "", fmt.Errorf("create_ticket: due_at must be an RFC3339 time (e.g. 2025-06-01T17:00:00Z): %w", err)
+		}
+		dueAt = &d
+	}
+
+	if name := getString(params, "template"); name != "" {
+		if err := t.applyTemplate(name, params, &title, &goal, &message, &to, &tags); err != nil {
+			return "", fmt.Errorf("create_ticket: %w", err)
+		}
+	}
 
 	if title == "" {
 		return "", fmt.Errorf("create_ticket: title is required")
@@ -279,13 +412,9 @@ func (t *CreateTicketTool) Execute(ctx context.Context, params map[string]any) (
 		}
 	}
 
-	tk, err := t.Broker.CreateTicket(t.AgentID, title, goal, parentID, to, tags)
-	if err != nil {
-		return "", fmt.Errorf("create_ticket: %w", err)
-	}
-
-	// Deliver initial message to target agents via normal routing.
-	// Include the goal and optional message in the body so assignees have the full context.
+	// The initial message carries the goal and optional message so assignees
+	// have the full context. It is saved together with the ticket and then
+	// delivered via normal routing.
 	content := title
 	if goal != "" {
 		content = title + "\n\n" + goal
@@ -298,11 +427,11 @@ func (t *CreateTicketTool) Execute(ctx context.Context, params map[string]any) (
 		From:      t.AgentID,
 		To:        to,
 		Content:   content,
-		TicketID:  tk.ID,
 		Timestamp: time.Now(),
 	}
-	if err := t.Broker.RouteMessage(msg); err != nil {
-		return "", fmt.Errorf("create_ticket: route: %w", err)
+	tk, err := t.Broker.CreateAndRoute(t.AgentID, title, goal, parentID, to, tags, priority, dueAt, msg)
+	if err != nil {
+		return "", fmt.Errorf("create_ticket: %w", err)
 	}
 
 	return fmt.Sprintf("Ticket created: %s (title: %q, assigned to: %s)", tk.ID, title, strings.Join(to, ", ")), nil
@@ -317,6 +446,7 @@ type RespondToTicketTool struct {
 }
 
 func (t *RespondToTicketTool) Name() string        { return "respond_to_ticket" }
+func (t *RespondToTicketTool) Serial() bool { return true }
 func (t *RespondToTicketTool) Description() string  { return "Send a response on the current ticket" }
 func (t *RespondToTicketTool) Parameters() map[string]any {
 	return map[string]any{
@@ -348,6 +478,12 @@ func (t *RespondToTicketTool) Execute(ctx context.Context, params map[string]any
 	if tk.Status == protocol.TicketClosed {
 		return "Ticket is closed — message not delivered.", nil
 	}
+	if tk.IsWatcher(t.AgentID) {
+		// Watchers are read-only; count the turn as handled so the worker
+		// does not nudge them into responding.
+		markResponded(ctx)
+		return fmt.Sprintf("You are only watching ticket %s — message not delivered. Watchers cannot respond or change its status; call wait, or unwatch_ticket to stop receiving updates.", ticketID), nil
+	}
 
 	// goal_met validation: only responders (non-creators) may set it
 	goalMet, _ := params["goal_met"].(bool)
@@ -375,13 +511,13 @@ func (t *RespondToTicketTool) Execute(ctx context.Context, params map[string]any
 	// Status transitions
 	var statusNote string
 	if goalMet && tk.Status == protocol.TicketOpen {
-		if err := t.Broker.UpdateTicketStatus(ticketID, protocol.TicketAwaitingClose); err != nil {
+		if err := t.Broker.UpdateTicketStatus(ticketID, protocol.TicketAwaitingClose, t.AgentID); err != nil {
 			return "", fmt.Errorf("respond_to_ticket: update status: %w", err)
 		}
 		statusNote = " (status → awaiting_close)"
 	} else if tk.Status == protocol.TicketAwaitingClose && tk.CreatedBy == t.AgentID {
 		// Creator responding on an awaiting_close ticket reopens it
-		if err := t.Broker.UpdateTicketStatus(ticketID, protocol.TicketOpen); err != nil {
+		if err := t.Broker.UpdateTicketStatus(ticketID, protocol.TicketOpen, t.AgentID); err != nil {
 			return "", fmt.Errorf("respond_to_ticket: update status: %w", err)
 		}
 		statusNote = " (status → open)"
@@ -412,13 +548,17 @@ type CloseTicketTool struct {
 }
 
 func (t *CloseTicketTool) Name() string        { return "close_ticket" }
-func (t *CloseTicketTool) Description() string  { return "Close a ticket with a summary" }
+func (t *CloseTicketTool) Serial() bool { return true }
+func (t *CloseTicketTool) Description() string {
+	return "Close a ticket with a summary. A ticket with unclosed sub-tickets can only be closed with cascade, which closes the whole subtree with the same summary (for abandoning a line of work)."
+}
 func (t *CloseTicketTool) Parameters() map[string]any {
 	return map[string]any{
 		"type": "object",
 		"properties": map[string]any{
 			"ticket_id": map[string]any{"type": "string", "description": "Ticket ID to close"},
 			"summary":   map[string]any{"type": "string", "description": "Summary of what was accomplished"},
+			"cascade":   map[string]any{"type": "boolean", "description": "Also close all unclosed sub-tickets, at every depth (default false)"},
 		},
 		"required": []string{"ticket_id", "summary"},
 	}
@@ -441,6 +581,17 @@ func (t *CloseTicketTool) Execute(_ context.Context, params map[string]any) (str
 		return fmt.Sprintf("You cannot close this ticket — only the creator (%s) can close it. Use respond_to_ticket to send your response instead.", tk.CreatedBy), nil
 	}
 
+	if cascade, _ := params["cascade"].(bool); cascade {
+		closed, err := t.Broker.CloseTicketTree(ticketID, summary, t.AgentID)
+		if err != nil {
+			return "", fmt.Errorf("close_ticket: %w", err)
+		}
+		if subs := len(closed) - 1; subs > 0 {
+			return fmt.Sprintf("Ticket %s and %d sub-ticket(s) closed: %s", ticketID, subs, summary), nil
+		}
+		return fmt.Sprintf("Ticket %s closed: %s", ticketID, summary), nil
+	}
+
 	// Block closing if there are open or awaiting_close sub-tickets
 	var unclosedSubs []*protocol.Ticket
 	for _, st := range []protocol.TicketStatus{protocol.TicketOpen, protocol.TicketAwaitingClose} {
@@ -456,16 +607,123 @@ func (t *CloseTicketTool) Execute(_ context.Context, params map[string]any) (str
 		for _, s := range unclosedSubs {
 			ids = append(ids, fmt.Sprintf("%s (%s) [%s]", s.ID, s.Title, s.Status))
 		}
-		return "", fmt.Errorf("close_ticket: cannot close — %d unclosed sub-ticket(s) remain: %s. Use wait to wait for them to resolve.", len(unclosedSubs), strings.Join(ids, ", "))
+		return "", fmt.Errorf("close_ticket: cannot close — %d unclosed sub-ticket(s) remain: %s. Use wait to wait for them to resolve, or cascade to close them too.", len(unclosedSubs), strings.Join(ids, ", "))
 	}
 
-	if err := t.Broker.CloseTicket(ticketID, summary); err != nil {
+	if err := t.Broker.CloseTicket(ticketID, summary, t.AgentID); err != nil {
 		return "", fmt.Errorf("close_ticket: %w", err)
 	}
 
 	return fmt.Sprintf("Ticket %s closed: %s", ticketID, summary), nil
 }
 
+// --- ReopenTicketTool ---
+
+// ReopenTicketTool sets a closed ticket back to open, for when its summary
+// was wrong or more work turned up. Like close_ticket it is creator-only.
+type ReopenTicketTool struct {
+	Broker  TicketBroker
+	AgentID string
+}
+
+func (t *ReopenTicketTool) Name() string { return "reopen_ticket" }
+func (t *ReopenTicketTool) Serial() bool { return true }
+func (t *ReopenTicketTool) Description() string {
+	return "Reopen a closed ticket you created, when its summary was wrong or more work turned up. Participants are notified on the ticket."
+}
+func (t *ReopenTicketTool) Parameters() map[string]any {
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"ticket_id": map[string]any{"type": "string", "description": "Ticket ID to reopen"},
+			"reason":    map[string]any{"type": "string", "description": "Why the ticket is being reopened"},
+		},
+		"required": []string{"ticket_id"},
+	}
+}
+
+func (t *ReopenTicketTool) Execute(_ context.Context, params map[string]any) (string, error) {
+	ticketID := getString(params, "ticket_id")
+	if ticketID == "" {
+		return "", fmt.Errorf("reopen_ticket: ticket_id is required")
+	}
+
+	tk, err := t.Broker.GetTicket(ticketID)
+	if err != nil {
+		return "", fmt.Errorf("reopen_ticket: %w", err)
+	}
+	if tk.CreatedBy != t.AgentID {
+		return fmt.Sprintf("You cannot reopen this ticket — only the creator (%s) can reopen it. Create a new ticket instead.", tk.CreatedBy), nil
+	}
+	if tk.Status != protocol.TicketClosed {
+		return fmt.Sprintf("Ticket %s is %s, not closed — nothing to reopen.", ticketID, tk.Status), nil
+	}
+
+	if err := t.Broker.ReopenTicket(ticketID, getString(params, "reason"), t.AgentID); err != nil {
+		return "", fmt.Errorf("reopen_ticket: %w", err)
+	}
+	return fmt.Sprintf("Ticket %s reopened.", ticketID), nil
+}
+
+// --- ReassignTicketTool ---
+
+// ReassignTicketTool hands a ticket to different agents, for when the
+// assignee is stuck or the wrong one was picked. Creator-only.
+type ReassignTicketTool struct {
+	Broker  TicketBroker
+	AgentID string
+	Agents  AgentLister
+}
+
+func (t *ReassignTicketTool) Name() string { return "reassign_ticket" }
+func (t *ReassignTicketTool) Serial() bool { return true }
+func (t *ReassignTicketTool) Description() string {
+	return "Hand a ticket you created to different agents, replacing its current assignees. New assignees get the ticket's goal and history; removed assignees are told to stop."
+}
+func (t *ReassignTicketTool) Parameters() map[string]any {
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"ticket_id": map[string]any{"type": "string", "description": "Ticket ID to reassign"},
+			"to":        map[string]any{"type": "array", "items": map[string]any{"type": "string"}, "description": "New assignee agent IDs"},
+			"reason":    map[string]any{"type": "string", "description": "Why the ticket is being reassigned"},
+		},
+		"required": []string{"ticket_id", "to"},
+	}
+}
+
+func (t *ReassignTicketTool) Execute(_ context.Context, params map[string]any) (string, error) {
+	ticketID := getString(params, "ticket_id")
+	to := getStringSlice(params, "to")
+	if ticketID == "" || len(to) == 0 {
+		return "", fmt.Errorf("reassign_ticket: ticket_id and to are required")
+	}
+
+	tk, err := t.Broker.GetTicket(ticketID)
+	if err != nil {
+		return "", fmt.Errorf("reassign_ticket: %w", err)
+	}
+	if tk.CreatedBy != t.AgentID {
+		return fmt.Sprintf("You cannot reassign this ticket — only the creator (%s) can reassign it.", tk.CreatedBy), nil
+	}
+	if tk.Status == protocol.TicketClosed {
+		return fmt.Sprintf("Ticket %s is closed — reopen it first with reopen_ticket.", ticketID), nil
+	}
+	if slices.Contains(to, t.AgentID) {
+		return "", fmt.Errorf("reassign_ticket: cannot assign a ticket to yourself — do the work directly")
+	}
+	if t.Agents != nil {
+		if err := validateAgentIDs(t.Agents, to); err != nil {
+			return "", fmt.Errorf("reassign_ticket: %w", err)
+		}
+	}
+
+	if err := t.Broker.ReassignTicket(ticketID, to, getString(params, "reason"), t.AgentID); err != nil {
+		return "", fmt.Errorf("reassign_ticket: %w", err)
+	}
+	return fmt.Sprintf("Ticket %s reassigned to %s.", ticketID, strings.Join(to, ", ")), nil
+}
+
 // --- SearchTicketsTool ---
 
 type SearchTicketsTool struct {
@@ -475,16 +733,21 @@ type SearchTicketsTool struct {
 
 func (t *SearchTicketsTool) Name() string { return "search_tickets" }
 func (t *SearchTicketsTool) Description() string {
-	return "Search through tickets intentionally. Returns ticket count and compact summaries. Use get_ticket to read full details of a specific ticket."
+	return "Search through tickets intentionally. Returns ticket count and compact summaries, highest priority first. Use get_ticket to read full details of a specific ticket."
 }
 func (t *SearchTicketsTool) Parameters() map[string]any {
 	return map[string]any{
 		"type": "object",
 		"properties": map[string]any{
-			"query":       map[string]any{"type": "string", "description": "Text search on ticket title and summary"},
-			"status":      map[string]any{"type": "string", "enum": []string{"open", "awaiting_close", "closed"}, "description": "Filter by ticket status"},
-			"participant": map[string]any{"type": "string", "description": "Filter by agent ID (created_by or assigned to)"},
-			"limit":       map[string]any{"type": "integer", "description": "Max results to return (default 20)"},
+			"query":           map[string]any{"type": "string", "description": "Text search on ticket title and summary (or message bodies with search_messages)"},
+			"search_messages": map[string]any{"type": "boolean", "description": "Match query against the content of ticket messages instead of title and summary"},
+			"status":          map[string]any{"type": "string", "enum": []string{"open", "awaiting_close", "closed"}, "description": "Filter by ticket status"},
+			"participant":     map[string]any{"type": "string", "description": "Filter by agent ID (created_by or assigned to)"},
+			"tags":            map[string]any{"type": "array", "items": map[string]any{"type": "string"}, "description": "Only tickets carrying all of these tags"},
+			"any_tags":        map[string]any{"type": "array", "items": map[string]any{"type": "string"}, "description": "Only tickets carrying at least one of these tags"},
+			"min_priority":    map[string]any{"type": "integer", "description": "Only tickets with at least this priority"},
+			"overdue":         map[string]any{"type": "boolean", "description": "Only open tickets whose due date has passed"},
+			"limit":           map[string]any{"type": "integer", "description": "Max results to return (default 20)"},
 		},
 	}
 }
@@ -500,8 +763,19 @@ func (t *SearchTicketsTool) Execute(_ context.Context, params map[string]any) (s
 		filter.AgentID = participant
 	}
 	if query := getString(params, "query"); query != "" {
-		filter.Query = query
+		if searchMessages, _ := params["search_messages"].(bool); searchMessages {
+			filter.MessageQuery = query
+		} else {
+			filter.Query = query
+		}
+	}
+	filter.Tags = getStringSlice(params, "tags")
+	filter.AnyTags = getStringSlice(params, "any_tags")
+	if p, ok := params["min_priority"].(float64); ok {
+		minPriority := int(p)
+		filter.MinPriority = &minPriority
 	}
+	filter.OverdueOnly, _ = params["overdue"].(bool)
 
 	limit := 20
 	if l, ok := params["limit"].(float64); ok && l > 0 {
@@ -535,9 +809,19 @@ func (t *SearchTicketsTool) Execute(_ context.Context, params map[string]any) (s
 	}
 
 	for _, tk := range tickets {
-		fmt.Fprintf(&b, "- **%s** [%s] %s\n", tk.ID, tk.Status, tk.Title)
+		fmt.Fprintf(&b, "- **%s** [%s] %s", tk.ID, tk.Status, tk.Title)
+		if tk.Priority != 0 {
+			fmt.Fprintf(&b, " (priority %d)", tk.Priority)
+		}
+		b.WriteString("\n")
 		fmt.Fprintf(&b, "  from: %s, assigned: %s, created: %s",
 			tk.CreatedBy, strings.Join(tk.WaitingOn, ","), tk.CreatedAt.Format("2006-01-02 15:04"))
+		if tk.DueAt != nil {
+			fmt.Fprintf(&b, ", due: %s", tk.DueAt.Format("2006-01-02 15:04"))
+			if tk.Status == protocol.TicketOpen && tk.DueAt.Before(time.Now()) {
+				b.WriteString(" (overdue)")
+			}
+		}
 		if tk.Summary != "" {
 			fmt.Fprintf(&b, "\n  summary: %s", tk.Summary)
 		}
@@ -547,6 +831,68 @@ func (t *SearchTicketsTool) Execute(_ context.Context, params map[string]any) (s
 	return b.String(), nil
 }
 
+// --- MyTicketsTool ---
+
+// MyTicketsTool lists the calling agent's active tickets (open and
+// awaiting_close), grouped into those it created and those assigned to it.
+type MyTicketsTool struct {
+	Broker  TicketBroker
+	AgentID string
+}
+
+func (t *MyTicketsTool) Name() string { return "my_tickets" }
+func (t *MyTicketsTool) Description() string {
+	return "List your active (open and awaiting_close) tickets, grouped into tickets you created and tickets assigned to you. Use get_ticket to read full details."
+}
+func (t *MyTicketsTool) Parameters() map[string]any {
+	return map[string]any{
+		"type":       "object",
+		"properties": map[string]any{},
+	}
+}
+
+func (t *MyTicketsTool) Execute(_ context.Context, _ map[string]any) (string, error) {
+	var created, assigned []*protocol.Ticket
+	for _, status := range []protocol.TicketStatus{protocol.TicketOpen, protocol.TicketAwaitingClose} {
+		s := status
+		tickets, err := t.Broker.ListTickets(ticket.Filter{AgentID: t.AgentID, Status: &s})
+		if err != nil {
+			return "", fmt.Errorf("my_tickets: %w", err)
+		}
+		for _, tk := range tickets {
+			// The store matches waiting_on by substring, so check membership
+			// exactly to avoid picking up agents whose IDs contain ours.
+			switch {
+			case tk.CreatedBy == t.AgentID:
+				created = append(created, tk)
+			case slices.Contains(tk.WaitingOn, t.AgentID):
+				assigned = append(assigned, tk)
+			}
+		}
+	}
+
+	if len(created) == 0 && len(assigned) == 0 {
+		return "You have no open tickets.", nil
+	}
+
+	var b strings.Builder
+	writeGroup := func(heading string, tickets []*protocol.Ticket) {
+		fmt.Fprintf(&b, "## %s (%d)\n", heading, len(tickets))
+		if len(tickets) == 0 {
+			b.WriteString("None.\n")
+		}
+		for _, tk := range tickets {
+			fmt.Fprintf(&b, "- **%s** [%s] %s\n", tk.ID, tk.Status, tk.Title)
+			fmt.Fprintf(&b, "  from: %s, assigned: %s, created: %s\n",
+				tk.CreatedBy, strings.Join(tk.WaitingOn, ","), tk.CreatedAt.Format("2006-01-02 15:04"))
+		}
+	}
+	writeGroup("Assigned to me", assigned)
+	b.WriteString("\n")
+	writeGroup("Created by me", created)
+	return b.String(), nil
+}
+
 // --- GetTicketTool ---
 
 type GetTicketTool struct {
@@ -554,12 +900,13 @@ type GetTicketTool struct {
 }
 
 func (t *GetTicketTool) Name() string        { return "get_ticket" }
-func (t *GetTicketTool) Description() string  { return "Get full ticket details including messages" }
+func (t *GetTicketTool) Description() string  { return "Get full ticket details including messages, its event timeline and its sub-ticket tree" }
 func (t *GetTicketTool) Parameters() map[string]any {
 	return map[string]any{
 		"type": "object",
 		"properties": map[string]any{
 			"ticket_id": map[string]any{"type": "string", "description": "Ticket ID"},
+			"depth":     map[string]any{"type": "integer", "description": fmt.Sprintf("Levels of sub-tickets to include (default %d, max %d)", ticket.DefaultTreeDepth, ticket.MaxTreeDepth)},
 		},
 		"required": []string{"ticket_id"},
 	}
@@ -576,10 +923,117 @@ func (t *GetTicketTool) Execute(_ context.Context, params map[string]any) (strin
 		return "", fmt.Errorf("get_ticket: %w", err)
 	}
 
-	data, _ := json.MarshalIndent(tk, "", "  ")
+	events, err := t.Broker.TicketEvents(ticketID)
+	if err != nil {
+		return "", fmt.Errorf("get_ticket: events: %w", err)
+	}
+
+	depth := ticket.DefaultTreeDepth
+	if d, ok := params["depth"].(float64); ok && d > 0 {
+		depth = int(d)
+	}
+	tree, err := ticket.BuildTree(t.Broker.ListTickets, tk, depth)
+	if err != nil {
+		return "", fmt.Errorf("get_ticket: sub-tickets: %w", err)
+	}
+
+	usage, err := t.Broker.TicketUsage(ticketID)
+	if err != nil {
+		return "", fmt.Errorf("get_ticket: usage: %w", err)
+	}
+
+	data, _ := json.MarshalIndent(struct {
+		*protocol.Ticket
+		Events     []protocol.TicketEvent `json:"events"`
+		Usage      *protocol.TicketUsage  `json:"usage"`
+		SubTickets []ticket.Node          `json:"sub_tickets,omitempty"`
+	}{tk, events, usage, tree.Children}, "", "  ")
 	return string(data), nil
 }
 
+// --- WatchTicketTool ---
+
+// WatchTicketTool subscribes an agent to a ticket's messages without making
+// it a participant, e.g. a supervisor following a delegation.
+type WatchTicketTool struct {
+	Broker  TicketBroker
+	AgentID string
+	Agents  AgentLister
+}
+
+func (t *WatchTicketTool) Name() string { return "watch_ticket" }
+func (t *WatchTicketTool) Serial() bool { return true }
+func (t *WatchTicketTool) Description() string {
+	return "Watch a ticket read-only: receive its messages and a notice when it closes, without being able to respond or change its status"
+}
+func (t *WatchTicketTool) Parameters() map[string]any {
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"ticket_id": map[string]any{"type": "string", "description": "Ticket ID to watch"},
+			"agent_id":  map[string]any{"type": "string", "description": "Agent to add as watcher (default: yourself)"},
+		},
+		"required": []string{"ticket_id"},
+	}
+}
+
+func (t *WatchTicketTool) Execute(_ context.Context, params map[string]any) (string, error) {
+	ticketID := getString(params, "ticket_id")
+	if ticketID == "" {
+		return "", fmt.Errorf("watch_ticket: ticket_id is required")
+	}
+	agentID := getString(params, "agent_id")
+	if agentID == "" {
+		agentID = t.AgentID
+	} else if t.Agents != nil {
+		if err := validateAgentIDs(t.Agents, []string{agentID}); err != nil {
+			return "", fmt.Errorf("watch_ticket: %w", err)
+		}
+	}
+	if err := t.Broker.WatchTicket(ticketID, agentID, t.AgentID); err != nil {
+		return "", fmt.Errorf("watch_ticket: %w", err)
+	}
+	return fmt.Sprintf("%s is watching ticket %s.", agentID, ticketID), nil
+}
+
+// --- UnwatchTicketTool ---
+
+type UnwatchTicketTool struct {
+	Broker  TicketBroker
+	AgentID string
+}
+
+func (t *UnwatchTicketTool) Name() string { return "unwatch_ticket" }
+func (t *UnwatchTicketTool) Serial() bool { return true }
+func (t *UnwatchTicketTool) Description() string {
+	return "Stop watching a ticket"
+}
+func (t *UnwatchTicketTool) Parameters() map[string]any {
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"ticket_id": map[string]any{"type": "string", "description": "Ticket ID to stop watching"},
+			"agent_id":  map[string]any{"type": "string", "description": "Watcher to remove (default: yourself); only the ticket's creator can remove others"},
+		},
+		"required": []string{"ticket_id"},
+	}
+}
+
+func (t *UnwatchTicketTool) Execute(_ context.Context, params map[string]any) (string, error) {
+	ticketID := getString(params, "ticket_id")
+	if ticketID == "" {
+		return "", fmt.Errorf("unwatch_ticket: ticket_id is required")
+	}
+	agentID := getString(params, "agent_id")
+	if agentID == "" {
+		agentID = t.AgentID
+	}
+	if err := t.Broker.UnwatchTicket(ticketID, agentID, t.AgentID); err != nil {
+		return "", fmt.Errorf("unwatch_ticket: %w", err)
+	}
+	return fmt.Sprintf("%s is no longer watching ticket %s.", agentID, ticketID), nil
+}
+
 // --- WaitTool ---
 
 // WaitTool lets an agent pause without sending a response. The agent will be
@@ -587,6 +1041,7 @@ func (t *GetTicketTool) Execute(_ context.Context, params map[string]any) (strin
 type WaitTool struct{}
 
 func (t *WaitTool) Name() string        { return "wait" }
+func (t *WaitTool) Serial() bool { return true }
 func (t *WaitTool) Description() string  { return "Stop processing and wait. Use after create_ticket to wait for sub-ticket results before responding." }
 func (t *WaitTool) Parameters() map[string]any {
 	return map[string]any{
@@ -2,10 +2,13 @@ package tool
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"path/filepath"
+	"slices"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/h1v3-io/h1v3/internal/ticket"
 	"github.com/h1v3-io/h1v3/pkg/protocol"
@@ -15,6 +18,18 @@ import (
 type testBroker struct {
 	store    ticket.Store
 	messages []protocol.Message // track RouteMessage calls
+	created  int
+}
+
+// staticAgents implements AgentLister over a fixed set of agent IDs.
+type staticAgents []string
+
+func (a staticAgents) ListAgentInfo() []AgentInfo {
+	infos := make([]AgentInfo, len(a))
+	for i, id := range a {
+		infos[i] = AgentInfo{ID: id}
+	}
+	return infos
 }
 
 func newTestBroker(t *testing.T) *testBroker {
@@ -28,9 +43,10 @@ func newTestBroker(t *testing.T) *testBroker {
 	return &testBroker{store: store}
 }
 
-func (b *testBroker) CreateTicket(from, title, goal, parentID string, to, tags []string) (*protocol.Ticket, error) {
+func (b *testBroker) CreateAndRoute(from, title, goal, parentID string, to, tags []string, priority int, dueAt *time.Time, msg protocol.Message) (*protocol.Ticket, error) {
+	b.created++
 	tk := &protocol.Ticket{
-		ID:        fmt.Sprintf("tk-%d", len(b.messages)+1),
+		ID:        fmt.Sprintf("tk-%d", b.created),
 		Title:     title,
 		Goal:      goal,
 		Status:    protocol.TicketOpen,
@@ -38,10 +54,14 @@ func (b *testBroker) CreateTicket(from, title, goal, parentID string, to, tags [
 		WaitingOn: to,
 		Tags:      tags,
 		ParentID:  parentID,
+		Priority:  priority,
+		DueAt:     dueAt,
 	}
-	if err := b.store.Save(tk); err != nil {
+	msg.TicketID = tk.ID
+	if err := b.store.SaveWithMessage(tk, msg); err != nil {
 		return nil, err
 	}
+	b.messages = append(b.messages, msg)
 	return tk, nil
 }
 
@@ -57,12 +77,59 @@ func (b *testBroker) CountTickets(filter ticket.Filter) (int, error) {
 	return b.store.Count(filter)
 }
 
-func (b *testBroker) CloseTicket(id, summary string) error {
-	return b.store.Close(id, summary)
+func (b *testBroker) CloseTicket(id, summary, by string) error {
+	if err := b.store.Close(id, summary); err != nil {
+		return err
+	}
+	return b.store.AppendEvent(protocol.TicketEvent{TicketID: id, Type: protocol.EventClosed, Actor: by, Detail: summary})
+}
+
+func (b *testBroker) CloseTicketTree(id, summary, by string) ([]string, error) {
+	children, err := b.store.List(ticket.Filter{ParentID: id})
+	if err != nil {
+		return nil, err
+	}
+	var closed []string
+	for _, c := range children {
+		sub, err := b.CloseTicketTree(c.ID, summary, by)
+		if err != nil {
+			return nil, err
+		}
+		closed = append(closed, sub...)
+	}
+	if err := b.CloseTicket(id, summary, by); err != nil {
+		return nil, err
+	}
+	return append(closed, id), nil
+}
+
+func (b *testBroker) ReopenTicket(id, reason, by string) error {
+	if err := b.store.Reopen(id); err != nil {
+		return err
+	}
+	return b.store.AppendEvent(protocol.TicketEvent{TicketID: id, Type: protocol.EventReopened, Actor: by, Detail: reason})
+}
+
+func (b *testBroker) ReassignTicket(id string, to []string, reason, by string) error {
+	if err := b.store.UpdateWaitingOn(id, to); err != nil {
+		return err
+	}
+	return b.store.AppendEvent(protocol.TicketEvent{TicketID: id, Type: protocol.EventReassigned, Actor: by, Detail: reason})
+}
+
+func (b *testBroker) UpdateTicketStatus(ticketID string, status protocol.TicketStatus, by string) error {
+	if err := b.store.UpdateStatus(ticketID, status); err != nil {
+		return err
+	}
+	return b.store.AppendEvent(protocol.TicketEvent{TicketID: ticketID, Type: protocol.EventStatusChanged, Actor: by, Detail: string(status)})
+}
+
+func (b *testBroker) TicketEvents(ticketID string) ([]protocol.TicketEvent, error) {
+	return b.store.Events(ticketID)
 }
 
-func (b *testBroker) UpdateTicketStatus(ticketID string, status protocol.TicketStatus) error {
-	return b.store.UpdateStatus(ticketID, status)
+func (b *testBroker) TicketUsage(ticketID string) (*protocol.TicketUsage, error) {
+	return b.store.Usage(ticketID)
 }
 
 func (b *testBroker) RouteMessage(msg protocol.Message) error {
@@ -70,6 +137,22 @@ func (b *testBroker) RouteMessage(msg protocol.Message) error {
 	return b.store.AppendMessage(msg.TicketID, msg)
 }
 
+func (b *testBroker) WatchTicket(ticketID, agentID, by string) error {
+	tk, err := b.store.Get(ticketID)
+	if err != nil {
+		return err
+	}
+	return b.store.SetWatchers(ticketID, append(tk.Watchers, agentID))
+}
+
+func (b *testBroker) UnwatchTicket(ticketID, agentID, by string) error {
+	tk, err := b.store.Get(ticketID)
+	if err != nil {
+		return err
+	}
+	return b.store.SetWatchers(ticketID, slices.DeleteFunc(tk.Watchers, func(id string) bool { return id == agentID }))
+}
+
 // --- Tests ---
 
 func TestCreateTicketTool_Success(t *testing.T) {
@@ -93,6 +176,93 @@ func TestCreateTicketTool_Success(t *testing.T) {
 	}
 }
 
+func TestCreateTicketTool_PriorityAndSearch(t *testing.T) {
+	broker := newTestBroker(t)
+	ct := &CreateTicketTool{Broker: broker, AgentID: "agent-a"}
+	for _, params := range []map[string]any{
+		{"to": []any{"agent-b"}, "title": "Tidy docs", "goal": "Docs tidied"},
+		{"to": []any{"agent-b"}, "title": "Fix outage", "goal": "Service up", "priority": float64(5)},
+	} {
+		if _, err := ct.Execute(context.Background(), params); err != nil {
+			t.Fatalf("create: %v", err)
+		}
+	}
+
+	st := &SearchTicketsTool{Broker: broker, AgentID: "agent-a"}
+	result, err := st.Execute(context.Background(), map[string]any{})
+	if err != nil {
+		t.Fatalf("search: %v", err)
+	}
+	urgent, normal := strings.Index(result, "Fix outage (priority 5)"), strings.Index(result, "Tidy docs\n")
+	if urgent < 0 || normal < 0 || urgent > normal {
+		t.Errorf("expected the priority ticket listed first with its priority, got:\n%s", result)
+	}
+
+	result, err = st.Execute(context.Background(), map[string]any{"min_priority": float64(1)})
+	if err != nil {
+		t.Fatalf("search: %v", err)
+	}
+	if !strings.Contains(result, "Found 1 ticket(s)") || strings.Contains(result, "Tidy docs") {
+		t.Errorf("expected only the priority ticket, got:\n%s", result)
+	}
+}
+
+func TestCreateTicketTool_DueAt(t *testing.T) {
+	broker := newTestBroker(t)
+	ct := &CreateTicketTool{Broker: broker, AgentID: "agent-a"}
+	past := time.Now().Add(-time.Hour).UTC().Format(time.RFC3339)
+	for _, params := range []map[string]any{
+		{"to": []any{"agent-b"}, "title": "File report", "goal": "Report filed", "due_at": past},
+		{"to": []any{"agent-b"}, "title": "Someday", "goal": "Done"},
+	} {
+		if _, err := ct.Execute(context.Background(), params); err != nil {
+			t.Fatalf("create: %v", err)
+		}
+	}
+	if _, err := ct.Execute(context.Background(), map[string]any{
+		"to": []any{"agent-b"}, "title": "Bad", "goal": "Done", "due_at": "tomorrow",
+	}); err == nil || !strings.Contains(err.Error(), "RFC3339") {
+		t.Errorf("expected an RFC3339 error, got %v", err)
+	}
+
+	st := &SearchTicketsTool{Broker: broker, AgentID: "agent-a"}
+	result, err := st.Execute(context.Background(), map[string]any{"overdue": true})
+	if err != nil {
+		t.Fatalf("search: %v", err)
+	}
+	if !strings.Contains(result, "Found 1 ticket(s)") || !strings.Contains(result, "(overdue)") || strings.Contains(result, "Someday") {
+		t.Errorf("expected only the overdue ticket, got:\n%s", result)
+	}
+}
+
+func TestSearchTicketsTool_SearchMessages(t *testing.T) {
+	broker := newTestBroker(t)
+	ct := &CreateTicketTool{Broker: broker, AgentID: "agent-a"}
+	if _, err := ct.Execute(context.Background(), map[string]any{
+		"to": []any{"agent-b"}, "title": "Investigate alert", "goal": "Root cause found",
+		"message": "Pager fired for disk pressure on shard-17",
+	}); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	st := &SearchTicketsTool{Broker: broker, AgentID: "agent-a"}
+	result, err := st.Execute(context.Background(), map[string]any{"query": "shard-17"})
+	if err != nil {
+		t.Fatalf("search: %v", err)
+	}
+	if !strings.Contains(result, "Found 0 ticket(s)") {
+		t.Errorf("expected no title match, got:\n%s", result)
+	}
+
+	result, err = st.Execute(context.Background(), map[string]any{"query": "shard-17", "search_messages": true})
+	if err != nil {
+		t.Fatalf("search: %v", err)
+	}
+	if !strings.Contains(result, "Found 1 ticket(s)") || !strings.Contains(result, "Investigate alert") {
+		t.Errorf("expected the ticket found by message content, got:\n%s", result)
+	}
+}
+
 func TestCreateTicketTool_MissingTitle(t *testing.T) {
 	broker := newTestBroker(t)
 	ct := &CreateTicketTool{Broker: broker, AgentID: "agent-a"}
@@ -133,6 +303,67 @@ func TestCreateTicketTool_NoTargets(t *testing.T) {
 	}
 }
 
+func TestCreateTicketTool_Template(t *testing.T) {
+	broker := newTestBroker(t)
+	ct := &CreateTicketTool{Broker: broker, AgentID: "agent-a", Templates: []protocol.TicketTemplate{{
+		Name:  "code_review",
+		Title: "Code review: {{file}}",
+		Goal:  "Review {{ file }} and list any bugs",
+		To:    []string{"agent-b"},
+		Tags:  []string{"review"},
+	}}}
+
+	if _, err := ct.Execute(context.Background(), map[string]any{"template": "code_review"}); err == nil || !strings.Contains(err.Error(), "missing variables: file") {
+		t.Errorf("expected missing variable error, got %v", err)
+	}
+	if _, err := ct.Execute(context.Background(), map[string]any{"template": "nope"}); err == nil || !strings.Contains(err.Error(), "available: code_review") {
+		t.Errorf("expected unknown template error, got %v", err)
+	}
+
+	result, err := ct.Execute(context.Background(), map[string]any{
+		"template": "code_review",
+		"vars":     map[string]any{"file": "main.go"},
+		"tags":     []any{"urgent"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	tk, err := broker.GetTicket(extractTicketID(result))
+	if err != nil {
+		t.Fatalf("get ticket: %v", err)
+	}
+	if tk.Title != "Code review: main.go" || tk.Goal != "Review main.go and list any bugs" {
+		t.Errorf("title/goal not expanded: %q / %q", tk.Title, tk.Goal)
+	}
+	if len(tk.WaitingOn) != 1 || tk.WaitingOn[0] != "agent-b" {
+		t.Errorf("expected template assignee, got %v", tk.WaitingOn)
+	}
+	if strings.Join(tk.Tags, ",") != "urgent,review" {
+		t.Errorf("expected merged tags, got %v", tk.Tags)
+	}
+}
+
+func TestCreateTicketTool_TemplateSubTicketRequiresConfirmation(t *testing.T) {
+	broker := newTestBroker(t)
+	ct := &CreateTicketTool{Broker: broker, AgentID: "agent-a"}
+	result, _ := ct.Execute(context.Background(), map[string]any{
+		"to":    []any{"agent-b"},
+		"title": "Parent task",
+		"goal":  "Get something done",
+	})
+
+	ctB := &CreateTicketTool{Broker: broker, AgentID: "agent-b", Templates: []protocol.TicketTemplate{{
+		Name: "ask", Title: "Question", Goal: "Answer it", To: []string{"agent-a"},
+	}}}
+	result, err := ctB.Execute(WithCurrentTicket(context.Background(), extractTicketID(result)), map[string]any{"template": "ask"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(result, "CONFIRMATION REQUIRED") {
+		t.Errorf("expected confirmation prompt, got %q", result)
+	}
+}
+
 func TestRespondToTicketTool(t *testing.T) {
 	broker := newTestBroker(t)
 
@@ -227,6 +458,70 @@ func TestGetTicketTool_Success(t *testing.T) {
 	if !strings.Contains(resp, "Get test") {
 		t.Errorf("expected ticket title in JSON, got %q", resp)
 	}
+	if !strings.Contains(resp, `"events"`) {
+		t.Errorf("expected events in JSON, got %q", resp)
+	}
+}
+
+func TestGetTicketTool_Usage(t *testing.T) {
+	broker := newTestBroker(t)
+	ct := &CreateTicketTool{Broker: broker, AgentID: "agent-a"}
+	result, _ := ct.Execute(context.Background(), map[string]any{"to": []any{"agent-b"}, "title": "Usage", "goal": "g"})
+	ticketID := extractTicketID(result)
+	broker.store.RecordUsage(ticketID, "gpt-4o", protocol.Usage{PromptTokens: 100, CompletionTokens: 20})
+	broker.store.RecordUsage(ticketID, "gpt-4o", protocol.Usage{PromptTokens: 50, CompletionTokens: 5})
+
+	gt := &GetTicketTool{Broker: broker}
+	resp, err := gt.Execute(context.Background(), map[string]any{"ticket_id": ticketID})
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	var got struct {
+		Usage protocol.TicketUsage `json:"usage"`
+	}
+	json.Unmarshal([]byte(resp), &got)
+	if got.Usage.Calls != 2 || got.Usage.TotalTokens != 175 || len(got.Usage.Models) != 1 {
+		t.Errorf("expected 2 calls and 175 tokens on one model, got %+v", got.Usage)
+	}
+}
+
+func TestGetTicketTool_SubTicketTree(t *testing.T) {
+	broker := newTestBroker(t)
+	create := func(ctx context.Context, from, to, title string) string {
+		t.Helper()
+		ct := &CreateTicketTool{Broker: broker, AgentID: from}
+		result, err := ct.Execute(ctx, map[string]any{"to": []any{to}, "title": title, "goal": "g"})
+		if err != nil {
+			t.Fatalf("create %s: %v", title, err)
+		}
+		return extractTicketID(result)
+	}
+	root := create(context.Background(), "front", "lead", "Root")
+	child := create(WithCurrentTicket(context.Background(), root), "lead", "coder", "Child")
+	create(WithCurrentTicket(context.Background(), child), "coder", "tester", "Grandchild")
+
+	gt := &GetTicketTool{Broker: broker}
+	var got struct {
+		SubTickets []ticket.Node `json:"sub_tickets"`
+	}
+	resp, err := gt.Execute(context.Background(), map[string]any{"ticket_id": root})
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	json.Unmarshal([]byte(resp), &got)
+	if len(got.SubTickets) != 1 || got.SubTickets[0].Title != "Child" || got.SubTickets[0].Status != protocol.TicketOpen {
+		t.Fatalf("expected open Child sub-ticket, got %+v", got.SubTickets)
+	}
+	if kids := got.SubTickets[0].Children; len(kids) != 1 || kids[0].Title != "Grandchild" {
+		t.Errorf("expected nested Grandchild, got %+v", kids)
+	}
+
+	resp, _ = gt.Execute(context.Background(), map[string]any{"ticket_id": root, "depth": float64(1)})
+	got.SubTickets = nil
+	json.Unmarshal([]byte(resp), &got)
+	if len(got.SubTickets) != 1 || got.SubTickets[0].Children != nil || !got.SubTickets[0].Truncated {
+		t.Errorf("expected depth 1 to truncate below Child, got %+v", got.SubTickets)
+	}
 }
 
 func TestCreateTicketTool_SubTicketSameRecipient_RequiresConfirmation(t *testing.T) {
@@ -454,6 +749,73 @@ func TestRespondToTicketTool_CreatorRespond_ReopensAwaitingClose(t *testing.T) {
 	}
 }
 
+func TestReopenTicketTool(t *testing.T) {
+	broker := newTestBroker(t)
+	ct := &CreateTicketTool{Broker: broker, AgentID: "agent-a"}
+	result, _ := ct.Execute(context.Background(), map[string]any{"to": []any{"agent-b"}, "title": "Reopen", "goal": "Test reopening"})
+	ticketID := extractTicketID(result)
+	broker.CloseTicket(ticketID, "done", "agent-a")
+
+	// Only the creator may reopen.
+	resp, err := (&ReopenTicketTool{Broker: broker, AgentID: "agent-b"}).Execute(context.Background(), map[string]any{"ticket_id": ticketID})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(resp, "cannot reopen") {
+		t.Errorf("expected guidance message, got %q", resp)
+	}
+	if tk, _ := broker.GetTicket(ticketID); tk.Status != protocol.TicketClosed {
+		t.Fatalf("non-creator reopened the ticket")
+	}
+
+	rt := &ReopenTicketTool{Broker: broker, AgentID: "agent-a"}
+	resp, err = rt.Execute(context.Background(), map[string]any{"ticket_id": ticketID, "reason": "missed a case"})
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	if tk, _ := broker.GetTicket(ticketID); tk.Status != protocol.TicketOpen || !strings.Contains(resp, "reopened") {
+		t.Errorf("expected the ticket open, got %s (%q)", tk.Status, resp)
+	}
+	if resp, _ := rt.Execute(context.Background(), map[string]any{"ticket_id": ticketID}); !strings.Contains(resp, "not closed") {
+		t.Errorf("expected a no-op for an open ticket, got %q", resp)
+	}
+}
+
+func TestReassignTicketTool(t *testing.T) {
+	broker := newTestBroker(t)
+	ct := &CreateTicketTool{Broker: broker, AgentID: "agent-a"}
+	result, _ := ct.Execute(context.Background(), map[string]any{"to": []any{"agent-b"}, "title": "Reassign", "goal": "Test reassigning"})
+	ticketID := extractTicketID(result)
+	agents := staticAgents{"agent-a", "agent-b", "agent-c"}
+
+	resp, err := (&ReassignTicketTool{Broker: broker, AgentID: "agent-b", Agents: agents}).Execute(context.Background(), map[string]any{"ticket_id": ticketID, "to": []any{"agent-c"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(resp, "cannot reassign") {
+		t.Errorf("expected guidance for a non-creator, got %q", resp)
+	}
+
+	rt := &ReassignTicketTool{Broker: broker, AgentID: "agent-a", Agents: agents}
+	if _, err := rt.Execute(context.Background(), map[string]any{"ticket_id": ticketID, "to": []any{"agent-z"}}); err == nil || !strings.Contains(err.Error(), "unknown agent(s): agent-z") {
+		t.Errorf("expected an unknown-agent error, got %v", err)
+	}
+	if _, err := rt.Execute(context.Background(), map[string]any{"ticket_id": ticketID, "to": []any{"agent-a"}}); err == nil {
+		t.Error("expected an error reassigning to the creator")
+	}
+
+	if _, err := rt.Execute(context.Background(), map[string]any{"ticket_id": ticketID, "to": []any{"agent-c"}, "reason": "agent-b is stuck"}); err != nil {
+		t.Fatalf("reassign: %v", err)
+	}
+	tk, _ := broker.GetTicket(ticketID)
+	if !slices.Equal(tk.WaitingOn, []string{"agent-c"}) {
+		t.Errorf("expected waiting_on [agent-c], got %v", tk.WaitingOn)
+	}
+	if got := collectRecipients(tk, "agent-a"); !slices.Equal(got, []string{"agent-c"}) {
+		t.Errorf("expected responses to reach the new assignee only, got %v", got)
+	}
+}
+
 func TestCloseTicketTool_BlocksOnAwaitingCloseSubs(t *testing.T) {
 	broker := newTestBroker(t)
 
@@ -477,7 +839,7 @@ func TestCloseTicketTool_BlocksOnAwaitingCloseSubs(t *testing.T) {
 	subID := extractTicketID(subResult)
 
 	// Set sub-ticket to awaiting_close
-	broker.UpdateTicketStatus(subID, protocol.TicketAwaitingClose)
+	broker.UpdateTicketStatus(subID, protocol.TicketAwaitingClose, "test")
 
 	// Try to close parent — should fail
 	closeTool := &CloseTicketTool{Broker: broker, AgentID: "agent-a"}
@@ -493,6 +855,63 @@ func TestCloseTicketTool_BlocksOnAwaitingCloseSubs(t *testing.T) {
 	}
 }
 
+func TestCloseTicketTool_Cascade(t *testing.T) {
+	broker := newTestBroker(t)
+
+	// root (a → b) → mid (b → c) → leaf (c → d)
+	create := func(agent, to, parentID, title string) string {
+		ctx := context.Background()
+		if parentID != "" {
+			ctx = WithCurrentTicket(ctx, parentID)
+		}
+		ct := &CreateTicketTool{Broker: broker, AgentID: agent}
+		result, err := ct.Execute(ctx, map[string]any{"to": []any{to}, "title": title, "goal": title})
+		if err != nil {
+			t.Fatalf("create %s: %v", title, err)
+		}
+		return extractTicketID(result)
+	}
+	rootID := create("agent-a", "agent-b", "", "Root")
+	midID := create("agent-b", "agent-c", rootID, "Mid")
+	leafID := create("agent-c", "agent-d", midID, "Leaf")
+
+	closeAs := func(agent, ticketID string) (string, error) {
+		tool := &CloseTicketTool{Broker: broker, AgentID: agent}
+		return tool.Execute(context.Background(), map[string]any{"ticket_id": ticketID, "summary": "Abandoned", "cascade": true})
+	}
+
+	// Only each ticket's creator may cascade from it, whatever its depth.
+	for _, tc := range []struct{ agent, ticketID string }{
+		{"agent-b", rootID}, {"agent-c", rootID}, {"agent-d", rootID},
+		{"agent-a", midID}, {"agent-c", midID},
+		{"agent-a", leafID}, {"agent-b", leafID}, {"agent-d", leafID},
+	} {
+		resp, err := closeAs(tc.agent, tc.ticketID)
+		if err != nil || !strings.Contains(resp, "cannot close") {
+			t.Errorf("%s cascading %s: expected refusal, got %q, %v", tc.agent, tc.ticketID, resp, err)
+		}
+	}
+	for _, id := range []string{rootID, midID, leafID} {
+		if tk, _ := broker.GetTicket(id); tk.Status == protocol.TicketClosed {
+			t.Fatalf("%s closed by a refused cascade", id)
+		}
+	}
+
+	resp, err := closeAs("agent-a", rootID)
+	if err != nil {
+		t.Fatalf("cascade: %v", err)
+	}
+	if !strings.Contains(resp, "2 sub-ticket(s) closed") {
+		t.Errorf("unexpected response %q", resp)
+	}
+	for _, id := range []string{rootID, midID, leafID} {
+		tk, _ := broker.GetTicket(id)
+		if tk.Status != protocol.TicketClosed || tk.Summary != "Abandoned" {
+			t.Errorf("%s: expected closed with shared summary, got %s %q", id, tk.Status, tk.Summary)
+		}
+	}
+}
+
 func TestCreateTicketTool_AwaitingCloseParent_RequiresConfirmation(t *testing.T) {
 	broker := newTestBroker(t)
 
@@ -506,7 +925,7 @@ func TestCreateTicketTool_AwaitingCloseParent_RequiresConfirmation(t *testing.T)
 	parentID := extractTicketID(result)
 
 	// Set parent to awaiting_close
-	broker.UpdateTicketStatus(parentID, protocol.TicketAwaitingClose)
+	broker.UpdateTicketStatus(parentID, protocol.TicketAwaitingClose, "test")
 
 	// Agent-a (creator) tries to create sub-ticket while parent is awaiting_close
 	parentCtx := WithCurrentTicket(context.Background(), parentID)
@@ -534,3 +953,107 @@ func extractTicketID(result string) string {
 	}
 	return ""
 }
+
+func TestMyTicketsTool_GroupsByRole(t *testing.T) {
+	broker := newTestBroker(t)
+	save := func(tk *protocol.Ticket) {
+		t.Helper()
+		if err := broker.store.Save(tk); err != nil {
+			t.Fatalf("save %s: %v", tk.ID, err)
+		}
+	}
+	save(&protocol.Ticket{ID: "tk-mine", Title: "Mine", Status: protocol.TicketOpen, CreatedBy: "dev", WaitingOn: []string{"qa"}})
+	save(&protocol.Ticket{ID: "tk-todo", Title: "Todo", Status: protocol.TicketAwaitingClose, CreatedBy: "pm", WaitingOn: []string{"dev"}})
+	save(&protocol.Ticket{ID: "tk-done", Title: "Done", Status: protocol.TicketClosed, CreatedBy: "pm", WaitingOn: []string{"dev"}})
+	save(&protocol.Ticket{ID: "tk-other", Title: "Other", Status: protocol.TicketOpen, CreatedBy: "pm", WaitingOn: []string{"devops"}})
+
+	tool := &MyTicketsTool{Broker: broker, AgentID: "dev"}
+	result, err := tool.Execute(context.Background(), map[string]any{})
+	if err != nil {
+		t.Fatalf("execute: %v", err)
+	}
+
+	assignedIdx := strings.Index(result, "Assigned to me (1)")
+	createdIdx := strings.Index(result, "Created by me (1)")
+	if assignedIdx < 0 || createdIdx < 0 {
+		t.Fatalf("missing group headings:\n%s", result)
+	}
+	if i := strings.Index(result, "tk-todo"); i < assignedIdx || i > createdIdx {
+		t.Errorf("tk-todo should be under 'Assigned to me':\n%s", result)
+	}
+	if i := strings.Index(result, "tk-mine"); i < createdIdx {
+		t.Errorf("tk-mine should be under 'Created by me':\n%s", result)
+	}
+	for _, id := range []string{"tk-done", "tk-other"} {
+		if strings.Contains(result, id) {
+			t.Errorf("result should not include %s:\n%s", id, result)
+		}
+	}
+}
+
+func TestMyTicketsTool_Empty(t *testing.T) {
+	broker := newTestBroker(t)
+	tool := &MyTicketsTool{Broker: broker, AgentID: "dev"}
+	result, err := tool.Execute(context.Background(), map[string]any{})
+	if err != nil {
+		t.Fatalf("execute: %v", err)
+	}
+	if !strings.Contains(result, "no open tickets") {
+		t.Errorf("unexpected result: %q", result)
+	}
+}
+
+func TestCollectRecipients_ExternalSession(t *testing.T) {
+	tk := &protocol.Ticket{CreatedBy: "_external", WaitingOn: []string{"front", "logger"}}
+	if got := collectRecipients(tk, "front"); fmt.Sprint(got) != "[_external]" {
+		t.Errorf("front agent reply should go to the user only, got %v", got)
+	}
+
+	tk = &protocol.Ticket{CreatedBy: "pm", WaitingOn: []string{"dev", "qa"}}
+	if got := collectRecipients(tk, "dev"); fmt.Sprint(got) != "[pm qa]" {
+		t.Errorf("expected [pm qa], got %v", got)
+	}
+}
+
+func TestWatchTicketTool_WatcherIsReadOnly(t *testing.T) {
+	broker := newTestBroker(t)
+
+	ct := &CreateTicketTool{Broker: broker, AgentID: "agent-a"}
+	result, _ := ct.Execute(context.Background(), map[string]any{
+		"to":    []any{"agent-b"},
+		"title": "Watched task",
+		"goal":  "Get a result",
+	})
+	ticketID := extractTicketID(result)
+
+	wt := &WatchTicketTool{Broker: broker, AgentID: "supervisor"}
+	if _, err := wt.Execute(context.Background(), map[string]any{"ticket_id": ticketID}); err != nil {
+		t.Fatalf("watch: %v", err)
+	}
+	tk, _ := broker.GetTicket(ticketID)
+	if !tk.IsWatcher("supervisor") {
+		t.Fatalf("expected supervisor to watch, watchers %v", tk.Watchers)
+	}
+
+	rt := &RespondToTicketTool{Broker: broker, AgentID: "supervisor"}
+	ctx, responded := WithRespondedFlag(WithCurrentTicket(context.Background(), ticketID))
+	ctx, deferred := WithDeferredMessages(ctx)
+	resp, err := rt.Execute(ctx, map[string]any{"message": "looks good", "goal_met": true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(resp, "only watching") || len(*deferred) != 0 || !*responded {
+		t.Errorf("expected watcher response to be refused quietly, got %q (deferred %d)", resp, len(*deferred))
+	}
+	if tk, _ := broker.GetTicket(ticketID); tk.Status != protocol.TicketOpen {
+		t.Errorf("watcher must not change status, got %q", tk.Status)
+	}
+
+	ut := &UnwatchTicketTool{Broker: broker, AgentID: "supervisor"}
+	if _, err := ut.Execute(context.Background(), map[string]any{"ticket_id": ticketID}); err != nil {
+		t.Fatalf("unwatch: %v", err)
+	}
+	if tk, _ := broker.GetTicket(ticketID); len(tk.Watchers) != 0 {
+		t.Errorf("expected no watchers after unwatch, got %v", tk.Watchers)
+	}
+}
@@ -9,3 +9,10 @@ type Tool interface {
 	Parameters() map[string]any // JSON Schema
 	Execute(ctx context.Context, params map[string]any) (string, error)
 }
+
+// SerialTool is implemented by tools that must not run concurrently with
+// other tool calls from the same turn, such as ticket state mutations.
+// A serial call waits for the calls before it and runs alone, in call order.
+type SerialTool interface {
+	Serial() bool
+}
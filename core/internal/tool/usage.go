@@ -0,0 +1,111 @@
+package tool
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ToolStat aggregates one tool's invocations by an agent.
+type ToolStat struct {
+	Name        string    `json:"name"`
+	Calls       int64     `json:"calls"`
+	Failures    int64     `json:"failures"`
+	TotalMillis int64     `json:"total_ms"`
+	LastUsed    time.Time `json:"last_used"`
+}
+
+// Usage counts tool invocations for one agent. With a path set, counters are
+// loaded from and written back to a JSON file there so they survive restarts.
+// A nil *Usage records nothing.
+type Usage struct {
+	mu    sync.Mutex
+	path  string
+	stats map[string]*ToolStat
+}
+
+// NewUsage returns a Usage persisted at path, loading any counters already
+// there. An empty path keeps counters in memory only.
+func NewUsage(path string) *Usage {
+	u := &Usage{path: path, stats: make(map[string]*ToolStat)}
+	if path == "" {
+		return u
+	}
+	if data, err := os.ReadFile(path); err == nil {
+		var saved []ToolStat
+		if json.Unmarshal(data, &saved) == nil {
+			for _, s := range saved {
+				u.stats[s.Name] = &s
+			}
+		}
+	}
+	return u
+}
+
+// Record counts one call of the named tool. Persisting is best effort; a
+// write failure never fails the tool call.
+func (u *Usage) Record(name string, d time.Duration, err error) {
+	if u == nil {
+		return
+	}
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	s, ok := u.stats[name]
+	if !ok {
+		s = &ToolStat{Name: name}
+		u.stats[name] = s
+	}
+	s.Calls++
+	if err != nil {
+		s.Failures++
+	}
+	s.TotalMillis += d.Milliseconds()
+	s.LastUsed = time.Now().UTC()
+	u.save()
+}
+
+// Stats returns a copy of the counters, most-called first.
+func (u *Usage) Stats() []ToolStat {
+	if u == nil {
+		return nil
+	}
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	out := make([]ToolStat, 0, len(u.stats))
+	for _, s := range u.stats {
+		out = append(out, *s)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Calls != out[j].Calls {
+			return out[i].Calls > out[j].Calls
+		}
+		return out[i].Name < out[j].Name
+	})
+	return out
+}
+
+// save writes the counters atomically. Callers hold u.mu.
+func (u *Usage) save() {
+	if u.path == "" {
+		return
+	}
+	out := make([]ToolStat, 0, len(u.stats))
+	for _, s := range u.stats {
+		out = append(out, *s)
+	}
+	data, err := json.Marshal(out)
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(u.path), 0o755); err != nil {
+		return
+	}
+	tmp := u.path + ".tmp"
+	if os.WriteFile(tmp, data, 0o644) == nil {
+		os.Rename(tmp, u.path)
+	}
+}
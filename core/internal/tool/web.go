@@ -20,11 +20,20 @@ const (
 	defaultNumResults = 5
 )
 
+// httpClient returns c, or a default client when c is nil.
+func httpClient(c *http.Client) *http.Client {
+	if c != nil {
+		return c
+	}
+	return &http.Client{Timeout: fetchTimeout}
+}
+
 // --- WebSearch ---
 
 // WebSearchTool searches the web using Brave Search API.
 type WebSearchTool struct {
-	APIKey string // Brave Search API key
+	APIKey string       // Brave Search API key
+	Client *http.Client // optional; defaults to a client with a 30s timeout
 }
 
 func (t *WebSearchTool) Name() string        { return "web_search" }
@@ -58,8 +67,7 @@ func (t *WebSearchTool) Execute(ctx context.Context, params map[string]any) (str
 	req.Header.Set("Accept", "application/json")
 	req.Header.Set("X-Subscription-Token", t.APIKey)
 
-	client := &http.Client{Timeout: fetchTimeout}
-	resp, err := client.Do(req)
+	resp, err := httpClient(t.Client).Do(req)
 	if err != nil {
 		return "", fmt.Errorf("web_search: %w", err)
 	}
@@ -98,7 +106,9 @@ type braveSearchResponse struct {
 // --- WebFetch ---
 
 // WebFetchTool fetches a URL and extracts readable content.
-type WebFetchTool struct{}
+type WebFetchTool struct {
+	Client *http.Client // optional; defaults to a client with a 30s timeout
+}
 
 func (t *WebFetchTool) Name() string        { return "web_fetch" }
 func (t *WebFetchTool) Description() string  { return "Fetch a URL and extract readable text content" }
@@ -129,8 +139,7 @@ func (t *WebFetchTool) Execute(ctx context.Context, params map[string]any) (stri
 	}
 	req.Header.Set("User-Agent", "h1v3-agent/1.0")
 
-	client := &http.Client{Timeout: fetchTimeout}
-	resp, err := client.Do(req)
+	resp, err := httpClient(t.Client).Do(req)
 	if err != nil {
 		return "", fmt.Errorf("web_fetch: %w", err)
 	}
@@ -4,18 +4,41 @@ import "slices"
 
 // AgentSpec defines a persistent agent's configuration.
 type AgentSpec struct {
-	ID               string            `json:"id"`
-	Role             string            `json:"role"`
-	Provider         string            `json:"provider,omitempty"`
-	CoreInstructions string            `json:"core_instructions"`
-	ScopedContexts   map[string]string `json:"scoped_contexts,omitempty"`
-	ToolsWhitelist   []string          `json:"tools_whitelist,omitempty"`
-	ToolsBlacklist   []string          `json:"tools_blacklist,omitempty"`
-	Skills           []string          `json:"skills,omitempty"`
-	Directory        string            `json:"directory"`
-	WakeSchedule     string            `json:"wake_schedule,omitempty"`
+	ID                    string            `json:"id"`
+	Role                  string            `json:"role"`
+	Provider              string            `json:"provider,omitempty"`
+	FallbackProviders     []string          `json:"fallback_providers,omitempty"` // tried in order when the provider fails with a retryable error
+	Model                 string            `json:"model,omitempty"`              // sent to Provider in place of its default model; empty = provider default
+	CoreInstructions      string            `json:"core_instructions"`
+	ScopedContexts        map[string]string `json:"scoped_contexts,omitempty"`
+	ToolsWhitelist        []string          `json:"tools_whitelist,omitempty"`
+	ToolsBlacklist        []string          `json:"tools_blacklist,omitempty"`
+	Skills                []string          `json:"skills,omitempty"`
+	Directory             string            `json:"directory"`
+	WakeSchedule          string            `json:"wake_schedule,omitempty"`
+	StartupPrompt         string            `json:"startup_prompt,omitempty"`          // run once on registration; see Registry.Startup
+	Temperature           *float64          `json:"temperature,omitempty"`             // nil = provider default
+	MaxTokens             int               `json:"max_tokens,omitempty"`              // 0 = provider default
+	RequestTimeoutSeconds int               `json:"request_timeout_seconds,omitempty"` // per LLM call (streams: between chunks); 0 = provider client default
+	InboxSize             int               `json:"inbox_size,omitempty"`              // 0 = registry default (64)
+	InboxPolicy           string            `json:"inbox_policy,omitempty"`            // InboxDrop, InboxDropOldest, InboxBlock or InboxSpill; default: the hive's, else InboxDrop
+	DiskQuotaMB           int               `json:"disk_quota_mb,omitempty"`           // cap on files written under Directory by the file tools; 0 = unlimited
+	CanDelegateTo         []string          `json:"can_delegate_to,omitempty"`         // agents this agent may assign tickets to; empty = any
+	CanReceiveFrom        []string          `json:"can_receive_from,omitempty"`        // agents that may assign tickets to this agent; empty = any
+	Rules                 []string          `json:"rules,omitempty"`                   // operating rules for the system prompt; replace the built-in core rules
+	KeepDefaultRules      bool              `json:"keep_default_rules,omitempty"`      // add Rules to the built-in core rules instead of replacing them
+	OutputSchema          map[string]any    `json:"output_schema,omitempty"`           // JSON schema final replies must match; {} = any JSON object; nil = free text
 }
 
+// Backpressure policies for AgentSpec.InboxPolicy, applied when a message
+// arrives while the agent's inbox is full.
+const (
+	InboxDrop       = "drop"        // drop the new message
+	InboxDropOldest = "drop_oldest" // drop the oldest queued message to make room
+	InboxBlock      = "block"       // wait for space up to a timeout, then drop
+	InboxSpill      = "spill"       // queue the overflow durably and feed it in as space frees
+)
+
 // ToolAllowed reports whether the named tool is permitted for this agent.
 // If a whitelist is set, only listed tools are allowed (blacklist is ignored).
 // If only a blacklist is set, all tools except listed ones are allowed.
@@ -29,3 +52,15 @@ func (s AgentSpec) ToolAllowed(name string) bool {
 	}
 	return true
 }
+
+// MayDelegateTo reports whether this agent may assign a ticket to the agent
+// with the given ID. An empty CanDelegateTo allows any target.
+func (s AgentSpec) MayDelegateTo(id string) bool {
+	return len(s.CanDelegateTo) == 0 || slices.Contains(s.CanDelegateTo, id)
+}
+
+// AcceptsFrom reports whether the agent with the given ID may assign a
+// ticket to this agent. An empty CanReceiveFrom allows any sender.
+func (s AgentSpec) AcceptsFrom(id string) bool {
+	return len(s.CanReceiveFrom) == 0 || slices.Contains(s.CanReceiveFrom, id)
+}
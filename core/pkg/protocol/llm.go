@@ -7,6 +7,28 @@ type ChatMessage struct {
 	ToolCalls  []ToolCall `json:"tool_calls,omitempty"`
 	ToolCallID string     `json:"tool_call_id,omitempty"`
 	Name       string     `json:"name,omitempty"`
+
+	// Parts, if set, is the message content as typed parts (e.g. a user
+	// message or tool result carrying an image). Providers that support
+	// parts send them in place of Content; Content should still hold a text
+	// rendering for providers, models and logs that only handle text.
+	Parts []ContentPart `json:"parts,omitempty"`
+}
+
+// Content part types.
+const (
+	PartText  = "text"
+	PartImage = "image"
+	PartJSON  = "json"
+)
+
+// ContentPart is one typed piece of message content.
+type ContentPart struct {
+	Type     string `json:"type"`                // PartText, PartImage, or PartJSON
+	Text     string `json:"text,omitempty"`      // PartText
+	MimeType string `json:"mime_type,omitempty"` // PartImage, e.g. "image/png"
+	Data     []byte `json:"data,omitempty"`      // image bytes, or the raw JSON document for PartJSON
+	URL      string `json:"url,omitempty"`       // PartImage given by URL instead of Data
 }
 
 // ToolCall represents the LLM requesting a tool execution.
@@ -14,6 +36,11 @@ type ToolCall struct {
 	ID        string         `json:"id"`
 	Name      string         `json:"name"`
 	Arguments map[string]any `json:"arguments"`
+
+	// ArgumentsError is set by providers when the model's arguments were
+	// not valid JSON; Arguments is then empty. The agent loop asks the model
+	// to re-emit the call instead of running the tool.
+	ArgumentsError string `json:"arguments_error,omitempty"`
 }
 
 // ChatResponse is the parsed response from an LLM provider.
@@ -21,6 +48,29 @@ type ChatResponse struct {
 	Content   string     `json:"content"`
 	ToolCalls []ToolCall `json:"tool_calls,omitempty"`
 	Usage     Usage      `json:"usage"`
+	Model     string     `json:"model,omitempty"` // model the request was sent to
+}
+
+// StreamChunk is an incremental piece of a streamed chat response. Err is
+// set on the last chunk when the stream fails; Usage and Model are set on
+// the Done chunk when the provider reports them.
+type StreamChunk struct {
+	ContentDelta  string         `json:"content_delta,omitempty"`
+	ToolCallDelta *ToolCallDelta `json:"tool_call_delta,omitempty"`
+	Done          bool           `json:"done,omitempty"`
+	Usage         *Usage         `json:"usage,omitempty"`
+	Model         string         `json:"model,omitempty"`
+	Err           error          `json:"-"`
+}
+
+// ToolCallDelta is a fragment of a streamed tool call. Index identifies the
+// call within the response; ID and Name arrive with its first fragment and
+// ArgumentsDelta fragments concatenate to the JSON arguments.
+type ToolCallDelta struct {
+	Index          int    `json:"index"`
+	ID             string `json:"id,omitempty"`
+	Name           string `json:"name,omitempty"`
+	ArgumentsDelta string `json:"arguments_delta,omitempty"`
 }
 
 // HasToolCalls returns true if the response contains tool call requests.
@@ -32,6 +82,11 @@ func (r *ChatResponse) HasToolCalls() bool {
 type Usage struct {
 	PromptTokens     int `json:"prompt_tokens"`
 	CompletionTokens int `json:"completion_tokens"`
+
+	// Prompt caching, where the provider reports it. Anthropic counts these
+	// separately from PromptTokens.
+	CacheCreationTokens int `json:"cache_creation_tokens,omitempty"` // written to the cache
+	CacheReadTokens     int `json:"cache_read_tokens,omitempty"`     // served from the cache
 }
 
 // TotalTokens returns the sum of prompt and completion tokens.
@@ -39,11 +94,38 @@ func (u Usage) TotalTokens() int {
 	return u.PromptTokens + u.CompletionTokens
 }
 
+// ModelCost is a model's price in US dollars per million tokens.
+type ModelCost struct {
+	PromptPerMillion     float64 `json:"prompt_per_million"`
+	CompletionPerMillion float64 `json:"completion_per_million"`
+}
+
+// Cost returns the price of u at these rates.
+func (c ModelCost) Cost(u Usage) float64 {
+	return (float64(u.PromptTokens)*c.PromptPerMillion + float64(u.CompletionTokens)*c.CompletionPerMillion) / 1e6
+}
+
 // ChatRequest holds parameters for an LLM chat call.
 type ChatRequest struct {
 	Model       string           `json:"model"`
 	Messages    []ChatMessage    `json:"messages"`
 	Tools       []ToolDefinition `json:"tools,omitempty"`
 	MaxTokens   int              `json:"max_tokens,omitempty"`
-	Temperature float64          `json:"temperature,omitempty"`
+	Temperature *float64         `json:"temperature,omitempty"` // nil = provider default
+
+	ResponseFormat *ResponseFormat `json:"response_format,omitempty"` // nil = free-form text
+}
+
+// Response format types.
+const (
+	FormatJSONObject = "json_object"
+	FormatJSONSchema = "json_schema"
+)
+
+// ResponseFormat asks the model for JSON output: any JSON object, or one
+// matching Schema.
+type ResponseFormat struct {
+	Type   string         `json:"type"`             // FormatJSONObject or FormatJSONSchema
+	Name   string         `json:"name,omitempty"`   // schema name, for FormatJSONSchema
+	Schema map[string]any `json:"schema,omitempty"` // JSON schema, for FormatJSONSchema
 }
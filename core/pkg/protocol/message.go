@@ -4,10 +4,22 @@ import "time"
 
 // Message is the fundamental unit of communication between agents.
 type Message struct {
-	ID        string    `json:"id"`
-	From      string    `json:"from"`
-	To        []string  `json:"to"`
-	Content   string    `json:"content"`
-	TicketID  string    `json:"ticket_id"`
-	Timestamp time.Time `json:"timestamp"`
+	ID          string       `json:"id"`
+	From        string       `json:"from"`
+	To          []string     `json:"to"`
+	Content     string       `json:"content"`
+	TicketID    string       `json:"ticket_id"`
+	Timestamp   time.Time    `json:"timestamp"`
+	Attachments []Attachment `json:"attachments,omitempty"`
+}
+
+// Attachment is a file carried by a message. Inbound attachments usually
+// arrive with inline Data; once persisted, Data is written to disk and only
+// Path is kept.
+type Attachment struct {
+	Name     string `json:"name"`
+	MimeType string `json:"mime_type,omitempty"`
+	Size     int64  `json:"size,omitempty"`
+	Path     string `json:"path,omitempty"` // file on disk under the data dir
+	Data     []byte `json:"data,omitempty"` // inline content (base64 in JSON)
 }
@@ -1,6 +1,12 @@
 package protocol
 
-import "time"
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
 
 // TicketStatus represents the lifecycle state of a ticket.
 type TicketStatus string
@@ -19,10 +25,137 @@ type Ticket struct {
 	Status    TicketStatus `json:"status"`
 	CreatedBy string       `json:"created_by"`
 	WaitingOn []string     `json:"waiting_on"`
+	Watchers  []string     `json:"watchers,omitempty"` // read-only observers; never block or close the ticket
 	Messages  []Message    `json:"messages"`
 	Tags      []string     `json:"tags,omitempty"`
 	ParentID  string       `json:"parent_ticket_id,omitempty"`
+	Priority  int          `json:"priority,omitempty"` // higher is more urgent; 0 = normal
 	CreatedAt time.Time    `json:"created_at"`
 	ClosedAt  *time.Time   `json:"closed_at,omitempty"`
+	DueAt     *time.Time   `json:"due_at,omitempty"` // deadline; see Filter.OverdueOnly
 	Summary   string       `json:"summary,omitempty"`
 }
+
+// TicketEventType identifies what happened in a TicketEvent.
+type TicketEventType string
+
+const (
+	EventCreated       TicketEventType = "created"
+	EventMessage       TicketEventType = "message"
+	EventStatusChanged TicketEventType = "status_changed"
+	EventReassigned    TicketEventType = "reassigned"
+	EventClosed        TicketEventType = "closed"
+	EventReopened      TicketEventType = "reopened"
+	EventWatched       TicketEventType = "watched"
+	EventUnwatched     TicketEventType = "unwatched"
+)
+
+// IsWatcher reports whether agentID watches the ticket without being its
+// creator or an assignee.
+func (t *Ticket) IsWatcher(agentID string) bool {
+	return agentID != t.CreatedBy && !slices.Contains(t.WaitingOn, agentID) && slices.Contains(t.Watchers, agentID)
+}
+
+// TicketEvent is one entry in a ticket's audit timeline.
+type TicketEvent struct {
+	ID        int64           `json:"id"`
+	TicketID  string          `json:"ticket_id"`
+	Type      TicketEventType `json:"type"`
+	Actor     string          `json:"actor"`
+	Detail    string          `json:"detail,omitempty"`
+	Timestamp time.Time       `json:"timestamp"`
+}
+
+// TicketUsage is the token usage of the provider calls made while agents
+// worked on a ticket, per model and in total. Costs are estimates, set only
+// for models with a configured price.
+type TicketUsage struct {
+	Models           []ModelUsage `json:"models"`
+	Calls            int          `json:"calls"`
+	PromptTokens     int          `json:"prompt_tokens"`
+	CompletionTokens int          `json:"completion_tokens"`
+	TotalTokens      int          `json:"total_tokens"`
+	EstimatedCostUSD *float64     `json:"estimated_cost_usd,omitempty"`
+}
+
+// ModelUsage is a ticket's usage of one model.
+type ModelUsage struct {
+	Model            string   `json:"model"`
+	Calls            int      `json:"calls"`
+	PromptTokens     int      `json:"prompt_tokens"`
+	CompletionTokens int      `json:"completion_tokens"`
+	EstimatedCostUSD *float64 `json:"estimated_cost_usd,omitempty"`
+}
+
+// Price sets estimated costs from per-model prices. Models without a price
+// are left out of the total.
+func (u *TicketUsage) Price(costs map[string]ModelCost) {
+	var total float64
+	priced := false
+	for i := range u.Models {
+		m := &u.Models[i]
+		c, ok := costs[m.Model]
+		if !ok {
+			continue
+		}
+		cost := c.Cost(Usage{PromptTokens: m.PromptTokens, CompletionTokens: m.CompletionTokens})
+		m.EstimatedCostUSD = &cost
+		total += cost
+		priced = true
+	}
+	if priced {
+		u.EstimatedCostUSD = &total
+	}
+}
+
+// TicketTemplate is a preset for a common kind of delegation. Title, Goal and
+// Message may contain {{name}} placeholders filled in when a ticket is created
+// from the template.
+type TicketTemplate struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description,omitempty"`
+	Title       string   `json:"title"`
+	Goal        string   `json:"goal"`
+	Message     string   `json:"message,omitempty"`
+	To          []string `json:"to,omitempty"`
+	Tags        []string `json:"tags,omitempty"`
+}
+
+var templateVarRe = regexp.MustCompile(`\{\{\s*([A-Za-z_][A-Za-z0-9_]*)\s*\}\}`)
+
+// Vars returns the placeholder names used by the template, in order of first
+// appearance.
+func (t TicketTemplate) Vars() []string {
+	var names []string
+	for _, s := range []string{t.Title, t.Goal, t.Message} {
+		for _, m := range templateVarRe.FindAllStringSubmatch(s, -1) {
+			if !slices.Contains(names, m[1]) {
+				names = append(names, m[1])
+			}
+		}
+	}
+	return names
+}
+
+// Expand returns a copy of the template with every placeholder replaced from
+// vars. It fails if any placeholder has no value.
+func (t TicketTemplate) Expand(vars map[string]string) (TicketTemplate, error) {
+	var missing []string
+	for _, name := range t.Vars() {
+		if _, ok := vars[name]; !ok {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		return t, fmt.Errorf("template %q: missing variables: %s", t.Name, strings.Join(missing, ", "))
+	}
+	expand := func(s string) string {
+		return templateVarRe.ReplaceAllStringFunc(s, func(m string) string {
+			return vars[templateVarRe.FindStringSubmatch(m)[1]]
+		})
+	}
+	t.Title = expand(t.Title)
+	t.Goal = expand(t.Goal)
+	t.Message = expand(t.Message)
+	return t, nil
+}
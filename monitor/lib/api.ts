@@ -3,6 +3,7 @@ import { getApiUrl, getApiKey, clearAuth } from "./auth";
 export interface Agent {
   id: string;
   role: string;
+  status?: "active" | "dormant";
 }
 
 export interface Message {